@@ -0,0 +1,187 @@
+// Runner-side logic of gh-secrets-migrator: copies the secrets a migration
+// workflow exposes to their targets, following the plan the CLI generated.
+//
+// Inputs come from environment variables, never from the script text:
+// MIGRATION_PLAN (JSON plan) and SOURCE_SECRETS (the workflow's secrets as
+// JSON). Values are piped to `gh secret set` on stdin, so gh encrypts them and
+// no value appears in a command line. Phase and secret marker lines match
+// those of the shell steps, so the CLI can follow the run.
+//
+// Called by actions/github-script with its `core` and `exec` helpers, either
+// from the composite action in this directory or inlined into the workflow.
+async function migrate({ core, exec }) {
+  const plan = JSON.parse(process.env.MIGRATION_PLAN);
+  const secrets = JSON.parse(process.env.SOURCE_SECRETS || '{}');
+  const has = (object, key) => Object.prototype.hasOwnProperty.call(object, key);
+  let migrationFailed = false;
+
+  // Secret policy: globs matched case-insensitively; deny always wins, a non-empty allowlist limits
+  const glob = (pattern) => new RegExp('^' + pattern.split('').map((char) =>
+    char === '*' ? '.*' : char === '?' ? '.' : char.replace(/[.+^$()|[\]\\{}]/g, '\\$&')).join('') + '$', 'i');
+  const deny = plan.deny.map(glob);
+  const allow = plan.allow.map(glob);
+  const policyAllows = (name) =>
+    !deny.some((pattern) => pattern.test(name)) && (allow.length === 0 || allow.some((pattern) => pattern.test(name)));
+
+  // Value rules: literal, case-sensitive rewrites of a secret's value, applied in order
+  const rewrites = plan.value_rewrites || {};
+  function rewriteValue(name, value) {
+    if (!has(rewrites, name)) {
+      return value;
+    }
+    for (const rule of rewrites[name]) {
+      if (!rule.prefix) {
+        value = value.split(rule.from).join(rule.to);
+      } else if (value.startsWith(rule.from)) {
+        value = rule.to + value.slice(rule.from.length);
+      }
+    }
+    console.log('Rewrote the value of ' + name + ' (value rules)');
+    return value;
+  }
+
+  async function phase(name, title, body) {
+    // Titles stay on one line: a line break would start a new workflow command
+    core.startGroup(title.replace(/[\x00-\x1f\x7f-\x9f\u2028\u2029]+/g, ' '));
+    console.log(plan.marker + ' phase-start ' + name);
+    let ok = false;
+    try {
+      ok = await body();
+    } catch (error) {
+      console.log('ERROR: ' + error.message);
+    } finally {
+      core.endGroup();
+      console.log(plan.marker + ' phase-end ' + name + (ok ? ' ok' : ' failed'));
+    }
+    if (!ok) {
+      migrationFailed = true;
+    }
+  }
+
+  function secretMarker(ok, level, name, location) {
+    console.log([plan.marker, 'secret', ok ? 'ok' : 'failed', level, name, location].join(' '));
+  }
+
+  async function gh(args, input) {
+    const options = { ignoreReturnCode: true };
+    if (input !== undefined) {
+      options.input = Buffer.from(input, 'utf8');
+    }
+    return exec.getExecOutput('gh', args, options);
+  }
+
+  // Sets one secret on the target, reporting it with a secret marker
+  async function setSecret(level, location, sourceName, targetName, scope) {
+    if (!has(secrets, sourceName)) {
+      console.log('ERROR: ' + sourceName + ' is not available to the workflow');
+      secretMarker(false, level, targetName, location);
+      return false;
+    }
+    const value = rewriteValue(sourceName, String(secrets[sourceName]));
+    const bytes = Buffer.byteLength(value, 'utf8');
+    if (bytes > plan.value_limit_bytes) {
+      console.log('ERROR: ' + sourceName + ' is ' + bytes + ' bytes; GitHub limits secret values to ' + plan.value_limit_bytes + ' bytes');
+      secretMarker(false, level, targetName, location);
+      return false;
+    }
+    const result = await gh(['secret', 'set', targetName].concat(scope), value);
+    if (result.exitCode === 0) {
+      console.log('OK: Created ' + targetName + ' (' + location + ')');
+    } else {
+      console.log('ERROR: Failed to create secret ' + targetName + ' (' + location + ')');
+    }
+    secretMarker(result.exitCode === 0, level, targetName, location);
+    return result.exitCode === 0;
+  }
+
+  const targetName = (name) => (has(plan.name_map, name) ? plan.name_map[name] : name);
+
+  for (const target of plan.targets) {
+    const repo = plan.target_org + '/' + target.repo;
+    if (plan.repository_secrets) {
+      await phase('repository-secrets', target.title, async () => {
+        const names = Object.keys(secrets).filter((name) => !plan.internal.includes(name));
+        let ok = true;
+        let done = 0;
+        for (const name of names) {
+          done += 1;
+          if (done % plan.batch_size === 0 || done === names.length) {
+            console.log(plan.marker + ' progress repository-secrets ' + done + '/' + names.length);
+          }
+          if (!policyAllows(name)) {
+            console.log('Skipping ' + name + ' (blocked by secret policy)');
+            continue;
+          }
+          if (target.skip.includes(name)) {
+            console.log('Skipping ' + name + ' (already exists on target)');
+            continue;
+          }
+          ok = (await setSecret('repository', repo, name, targetName(name), ['--repo', repo])) && ok;
+        }
+        return ok;
+      });
+    }
+    for (const [index, item] of target.environment_secrets.entries()) {
+      const location = repo + ':' + item.target_environment;
+      await phase('environment-secrets', 'Environment secret ' + item.environment + ' - ' + item.name + target.suffix, async () => {
+        const ok = await setSecret('environment', location, item.name, targetName(item.name),
+          ['--repo', repo, '--env', item.target_environment]);
+        if (ok) {
+          console.log(plan.marker + ' progress environment-secrets ' + (index + 1) + '/' + target.environment_secrets.length);
+        }
+        return ok;
+      });
+    }
+  }
+
+  for (const [index, item] of plan.org_secrets.entries()) {
+    await phase('organization-secrets', 'Organization secret ' + item.name, async () => {
+      const name = targetName(item.name);
+      const scope = ['--org', plan.target_org];
+      if (item.visibility) {
+        scope.push('--visibility', item.visibility);
+      }
+      if (item.visibility === 'selected' && item.repositories.length) {
+        scope.push('--repos', item.repositories.join(','));
+      }
+      if (!(await setSecret('organization', plan.target_org, item.name, name, scope))) {
+        return false;
+      }
+      console.log(plan.marker + ' progress organization-secrets ' + (index + 1) + '/' + plan.org_secrets.length);
+      if (item.visibility !== 'selected') {
+        return true;
+      }
+      // Read back the selected repositories and re-apply until they match the intended scope
+      const expected = [...item.repositories].sort();
+      for (let attempt = 1; attempt <= 3; attempt += 1) {
+        const listed = await gh(['api', '--paginate', 'orgs/' + plan.target_org + '/actions/secrets/' + name + '/repositories', '--jq', '.repositories[].name']);
+        const actual = listed.stdout.split('\n').filter((line) => line).sort();
+        if (listed.exitCode === 0 && actual.join(',') === expected.join(',')) {
+          console.log('OK: Verified repository scope of ' + name);
+          return true;
+        }
+        const missing = expected.filter((repo) => !actual.includes(repo));
+        const unexpected = actual.filter((repo) => !expected.includes(repo));
+        console.log('WARNING: Scope mismatch for ' + name + ' (attempt ' + attempt + '/3): missing [' + missing + '], unexpected [' + unexpected + ']');
+        if (attempt === 3) {
+          break;
+        }
+        await new Promise((resolve) => setTimeout(resolve, attempt * 5000));
+        await setSecret('organization', plan.target_org, item.name, name, scope);
+      }
+      console.log('ERROR: Repository scope of ' + name + ' does not match the source after 3 attempts');
+      secretMarker(false, 'organization', name, plan.target_org);
+      return false;
+    });
+  }
+
+  if (migrationFailed) {
+    core.setFailed('MIGRATION FAILED - Some secrets could not be created');
+  } else {
+    console.log('OK: All secrets migrated successfully!');
+  }
+}
+
+if (typeof module !== 'undefined') {
+  module.exports = migrate;
+}
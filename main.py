@@ -1,7 +1,7 @@
 #!/usr/bin/env python3
 """GitHub Secrets Migrator - Migrate secrets from one repository to another."""
 
-from src.cli import migrate
+from src.cli import cli
 
 if __name__ == "__main__":
-    migrate()
+    cli()
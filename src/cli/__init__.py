@@ -1,5 +1,5 @@
 """Command-line interface for GitHub Secrets Migrator."""
 # Re-export for backwards compatibility and convenience
-from src.cli.commands import migrate
+from src.cli.commands import cli, migrate
 
-__all__ = ['migrate']
+__all__ = ['cli', 'migrate']
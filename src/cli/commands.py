@@ -1,139 +1,3744 @@
 """Command-line interface for GitHub Secrets Migrator."""
+import copy
+import json
 import os
+import sys
+import time
+import webbrowser
+from datetime import datetime, timedelta
+from typing import List, Optional, Sequence
 import click
 from src.utils.logger import Logger
-from src.core.migrator import Migrator
-from src.core.config import MigrationConfig
+from src.core.migrator import RUN_POLL_SECONDS, Migrator
+from src.core.config import (
+    MigrationConfig, check_promotion, check_selection, check_target_env, check_unarchive,
+    parse_levels
+)
+from src.core.placeholders import (
+    PLACEHOLDER_MODES, DEFAULT_PLACEHOLDER_VALUE,
+    format_placeholder_drift, load_report_placeholders, unreplaced_placeholders,
+)
+from src.core.workflow_generator import (
+    DEFAULT_WORKFLOW_TIMEOUT_MINUTES, DELIVERY_MODES, GH_CLI_PINNED_VERSION, RUNNER_OSES,
+    WORKFLOW_ENGINES, check_action_ref
+)
+from src.core.events import EVENT_STREAM_FORMATS, EventLog, NdjsonStream
+from src.core.audit import AuditLog, verify_chain
+from src.core.transcript import write_transcript
+from src.core.environment_config import (
+    dump_environment_config,
+    format_reviewer,
+    load_environment_config,
+)
+from src.core.reviewers import ReviewerMap, load_reviewer_map
+from src.core.fanout import load_targets_file
+from src.core.credentials import (
+    GH_TOKEN_ENV,
+    SHARED_TOKEN_ENV,
+    default_host,
+    gh_stored_token,
+    resolve_token,
+    token_sources_hint,
+)
+from src.core.consolidation import consolidation_error, find_collisions, parse_source, plan_source
+from src.core.policy import SecretPolicy, load_policy
+from src.core.plan_file import (
+    PlannedRun, SavedPlan, format_plan, load_plan, plan_drift, save_plan
+)
+from src.core.pipeline import (
+    PipelineJob,
+    PipelineResult,
+    load_pipeline,
+    order_shared_first,
+    run_pipeline,
+)
+from src.core.exit_codes import (
+    EXIT_FAILED, EXIT_NOTHING_TO_MIGRATE, EXIT_PARTIAL, EXIT_VERIFICATION, VerificationMismatch,
+    exit_code,
+)
+from src.core.sandbox import SANDBOX_ENV
+from src.core.shared_repos import shared_automation
+from src.core.cancel import CANCEL_WAIT_POLLS, placeholder_only, recorded_placeholders
+from src.core.repo_filters import RepoFilter
+from src.core.repo_refs import normalize_host, parse_repo_ref
+from src.core.migration_state import MigrationRecord, default_state_path, load_state_file
+from src.core.state_crypto import STATE_PASSPHRASE_ENV, StateCipher, make_state_cipher
+from src.core.status import STATUS_FORMATS, TEMPORARY_SECRETS, MigrationStatus, format_status
+from src.core.log_scan import SCAN_FORMATS, format_findings, scan_run_logs
+from src.core.gei import load_gei_log, results_csv, usage_csv
+from src.core.deploy_keys import (
+    DEFAULT_DEPLOY_KEY_PREFIX, DeployKey, KeypairGenerator, plan_deploy_keys
+)
+from src.core.attestation import (
+    ATTESTATION_SIGNERS, build_manifest, make_signer, manifest_text
+)
+from src.core.destinations import PLUGIN_PROTOCOL, PluginRunner, load_destination_plugin
+from src.core.org_inventory import (
+    INVENTORY_FORMATS, build_inventory, format_inventory_summary, inventory_csv,
+    inventory_repositories, load_inventory
+)
+from src.core.secret_usage import (
+    USAGE_FORMATS, build_usage_report, format_usage_report, scan_workflows
+)
+from src.core.inventory import (
+    diff_inventories,
+    diff_variables,
+    format_diff,
+    format_variable_diff,
+)
+from src.core.filters import is_managed_secret, managed_secrets
+from src.core.preflight import QUOTA_CHECK_MODES
+from src.core.branch_rules import BRANCH_CHECK_MODES
+from src.core.snapshots import DEFAULT_STATE_DIR, STATE_DIR_ENV
+from src.core.conflicts import CONFLICT_POLICIES
+from src.core.namespaces import SECRET_NAMESPACES, NamespaceUnavailableError, select_matching
+from src.core.naming import NamingConvention, SecretNameTransformer, check_commit_options
+from src.clients.github import DEFAULT_API_TIMEOUT, REPO_VISIBILITIES, GitHubClient
+from src.core.token_templates import (
+    DEFAULT_EXPIRES_IN_DAYS,
+    TOKEN_ROLES,
+    describe_permissions,
+    token_template_url,
+)
+from src.utils.progress import Progress
+from src.utils.clock import format_skew
+from src.utils.cron import CronSchedule
+from src.utils.etag_cache import ETagCache
+from src.utils.throttle import DispatchThrottle
+from src.utils.signals import EXIT_CANCELLED, EXIT_TIMED_OUT, Deadline, install_signal_handlers
+from src.core.callbacks import CALLBACK_SECRET_ENV, CallbackSender
+from src.core.notifications import build_summary_text, send_notification
+from src.core.telemetry import TELEMETRY_URL_ENV, build_telemetry_payload, send_telemetry
+from src.utils.metrics import DEFAULT_PUSHGATEWAY_JOB, build_run_metrics, push_metrics
+
+
+class DefaultCommandGroup(click.Group):
+    """Click group that falls back to a default command.
+
+    Keeps `main.py --source-org ...` working as a migration while allowing
+    named subcommands such as `main.py pipeline config.yml`.
+    """
+
+    def __init__(self, *args, default_command: str = "migrate", **kwargs):
+        super().__init__(*args, **kwargs)
+        self.default_command = default_command
+
+    def parse_args(self, ctx, args):
+        if args and args[0] not in self.commands and args[0] != "--help":
+            args = [self.default_command] + list(args)
+        return super().parse_args(ctx, args)
+
+
+@click.group(cls=DefaultCommandGroup)
+def cli():
+    """GitHub Secrets Migrator - migrate secrets between organizations and repositories.
+
+    Runs `migrate` when no subcommand is given. Ctrl-C (or SIGTERM) cancels the
+    run and removes a partially created migration branch and temporary secrets.
+    """
+    install_signal_handlers()
+
+
+def verbosity_options(func):
+    """Add the -v/-vv, --quiet and --no-color options shared by all commands."""
+    func = click.option(
+        "--no-color",
+        is_flag=True,
+        help="Disable colored output "
+             "(also disabled when NO_COLOR is set or output is not a terminal)"
+    )(func)
+    func = click.option(
+        "-q",
+        "--quiet",
+        is_flag=True,
+        help="Only print errors and the final summary (for CI)"
+    )(func)
+    func = click.option(
+        "-v",
+        "--verbose",
+        count=True,
+        help="Increase verbosity: -v for debug output, -vv to also trace API rate limits"
+    )(func)
+    return func
+
+
+def _make_logger(
+    verbose: int, quiet: bool, no_color: bool = False, events_path: str = ""
+) -> Logger:
+    """Create the logger for a command, rejecting conflicting verbosity flags.
+
+    With events_path '-' the event stream owns stdout, so every log line goes
+    to stderr.
+    """
+    logger = Logger(verbose=verbose, quiet=quiet, color=False if no_color else None)
+    if events_path == "-":
+        logger.use_stderr()
+    if quiet and verbose:
+        logger.error("--quiet cannot be combined with -v/--verbose")
+        raise SystemExit(1)
+    return logger
+
+
+def event_stream_options(func):
+    """Add the live event stream options shared by commands recording events."""
+    func = click.option(
+        "--events-file",
+        "events_file",
+        default="-",
+        show_default=True,
+        help="Where --events writes the stream ('-' for stdout; logs then go to stderr)"
+    )(func)
+    func = click.option(
+        "--events",
+        "events_format",
+        type=click.Choice(EVENT_STREAM_FORMATS),
+        default=None,
+        help="Stream every event as it happens (ndjson: one JSON object per line), "
+             "e.g. for live dashboards over long bulk runs"
+    )(func)
+    return func
+
+
+def _events_path(events_format: Optional[str], events_file: str) -> str:
+    """Return where the event stream goes ('-' for stdout), or "" when not streaming."""
+    return events_file if events_format else ""
+
+
+def _stream_events(events_path: str, events: EventLog, logger: Logger) -> None:
+    """Write the run's events to events_path as they are recorded (see _events_path).
+
+    Raises:
+        SystemExit: If the stream file cannot be opened
+    """
+    if not events_path:
+        return
+    if events_path == "-":
+        events.subscribe(NdjsonStream(sys.stdout))
+        return
+    try:
+        handle = open(events_path, "w", encoding="utf-8")
+    except OSError as e:
+        logger.error(f"Failed to open event stream {events_path}: {e}")
+        raise SystemExit(1)
+    click.get_current_context().call_on_close(handle.close)
+    events.subscribe(NdjsonStream(handle))
+
+
+def _report_error(logger: Logger, error: Exception) -> None:
+    """Log an error, followed by its remediation hint when it carries one."""
+    logger.error(str(error))
+    remediation = getattr(error, "remediation", "")
+    if remediation:
+        logger.hint(remediation)
+
+
+def pushgateway_options(func):
+    """Add the Pushgateway options shared by migration commands."""
+    func = click.option(
+        "--pushgateway-job",
+        default=DEFAULT_PUSHGATEWAY_JOB,
+        show_default=True,
+        help="Job name used when pushing metrics to the Pushgateway"
+    )(func)
+    func = click.option(
+        "--pushgateway-url",
+        default="",
+        help="Push completion metrics (migrated, failures, duration) to this Prometheus Pushgateway"
+    )(func)
+    return func
+
+
+def _push_run_metrics(
+    url: str, job: str, migrated: int, failures: int, started_at: float, logger: Logger
+) -> None:
+    """Push completion metrics if a Pushgateway was configured; never fails the run."""
+    if not url:
+        return
+    finished_at = time.time()
+    metrics = build_run_metrics(migrated, failures, finished_at - started_at, finished_at)
+    try:
+        push_metrics(url, metrics, job=job)
+        logger.info(f"Pushed completion metrics to {url}")
+    except (OSError, ValueError) as e:
+        logger.warn(f"Failed to push metrics to {url}: {e}")
+
+
+def timeout_options(func):
+    """Add the overall and per-request timeout options shared by migration commands."""
+    func = click.option(
+        "--api-timeout",
+        type=click.FloatRange(min=1),
+        default=DEFAULT_API_TIMEOUT,
+        show_default=True,
+        help="Seconds to wait for each GitHub API response"
+    )(func)
+    func = click.option(
+        "--timeout",
+        "run_timeout",
+        type=click.FloatRange(min=0),
+        default=0,
+        help="Stop the run after this many seconds, cleaning up like Ctrl-C (0: no limit)"
+    )(func)
+    return func
+
+
+def state_file_options(func):
+    """Add the options locating the migration state file, for commands that read it."""
+    func = click.option(
+        "--state-file",
+        default="",
+        help="Migration state file (default: the one migrate writes under --state-dir)"
+    )(func)
+    func = click.option(
+        "--state-dir",
+        default=DEFAULT_STATE_DIR,
+        envvar=STATE_DIR_ENV,
+        show_default=True,
+        help=f"State directory the migration used (or set {STATE_DIR_ENV})"
+    )(func)
+    return func
+
+
+def state_encryption_options(func):
+    """Add the options encrypting state files at rest."""
+    func = click.option(
+        "--state-age-identity",
+        default="",
+        help="age identity file decrypting age-encrypted state files"
+    )(func)
+    func = click.option(
+        "--state-age-recipient",
+        "state_age_recipients",
+        multiple=True,
+        help="age public key state files are encrypted to (repeatable; needs the age CLI)"
+    )(func)
+    func = click.option(
+        "--state-passphrase",
+        default="",
+        envvar=STATE_PASSPHRASE_ENV,
+        help="Passphrase encrypting snapshots, the ledger and the state file at rest "
+             f"(prefer setting {STATE_PASSPHRASE_ENV})"
+    )(func)
+    return func
+
+
+def _state_cipher(
+    passphrase: str, age_recipients: Sequence[str], age_identity: str, logger: Logger
+) -> Optional[StateCipher]:
+    """Build the state file cipher, exiting on invalid settings."""
+    try:
+        return make_state_cipher(passphrase, age_recipients, age_identity)
+    except ValueError as e:
+        logger.error(f"Invalid state encryption settings: {e}")
+        raise SystemExit(1)
+
+
+def _recorded_migration(
+    state_dir: str, state_file: str, source_org: str, source_repo: str, org_to_org: bool,
+    cipher: Optional[StateCipher], logger: Logger
+) -> Optional[MigrationRecord]:
+    """Return the latest migration recorded for the source, or None (warning if unreadable)."""
+    path = state_file or default_state_path(state_dir, source_org, source_repo, org_to_org)
+    try:
+        return load_state_file(path, cipher).latest
+    except (OSError, ValueError) as e:
+        logger.warn(f"Ignoring migration state file {path}: {e}")
+        return None
+
+
+def _recorded_branch(record: Optional[MigrationRecord]) -> str:
+    """Return the migration branch a recorded run created, or '' if unknown."""
+    if record is None:
+        return ""
+    branches = record.resources_of("branch")
+    return branches[-1]["name"] if branches else record.inputs.get("branch_name", "")
+
+
+def cache_options(func):
+    """Add the metadata cache option shared by migration commands."""
+    return click.option(
+        "--metadata-cache",
+        "metadata_cache",
+        default="",
+        help="JSON file keeping ETags of secret/environment listings between runs, "
+             "so unchanged listings are revalidated (304) instead of re-read"
+    )(func)
+
+
+def _make_cache(path: str, logger: Logger) -> ETagCache:
+    """Create the metadata cache, loading path if it exists."""
+    cache = ETagCache(path)
+    if cache.entries:
+        logger.debug(f"Loaded {len(cache.entries)} cached listing(s) from {path}")
+    return cache
+
+
+def _save_cache(cache: ETagCache, logger: Logger) -> None:
+    """Persist the metadata cache and log its hit rate; never fails the run."""
+    logger.debug(f"Metadata cache: {cache.describe()}")
+    try:
+        cache.save()
+    except OSError as e:
+        logger.warn(f"Failed to write metadata cache {cache.path}: {e}")
+
+
+def audit_options(func):
+    """Add the audit log option shared by commands calling the GitHub API."""
+    return click.option(
+        "--audit-log",
+        "audit_log_path",
+        default="",
+        help="Append a hash-chained record of every GitHub API read/write to this file "
+             "(check it with 'audit verify')"
+    )(func)
+
+
+def _make_audit_log(path: str, logger: Logger) -> Optional[AuditLog]:
+    """Open the audit log, continuing its chain; its head is logged when the command ends.
+
+    Raises:
+        SystemExit: If the existing log cannot be read
+    """
+    if not path:
+        return None
+    try:
+        audit = AuditLog(path)
+    except (OSError, ValueError) as e:
+        logger.error(f"Cannot append to audit log {path}: {e}")
+        raise SystemExit(1)
+    click.get_current_context().call_on_close(
+        lambda: logger.info(
+            f"Audit log: {audit.appended} API call(s) appended to {path} (head {audit.head[:16]})"
+        )
+    )
+    return audit
+
+
+def telemetry_options(func):
+    """Add the opt-in usage statistics options shared by migration commands."""
+    func = click.option(
+        "--telemetry-url",
+        default="",
+        envvar=TELEMETRY_URL_ENV,
+        help=f"HTTPS endpoint receiving --telemetry payloads (or set {TELEMETRY_URL_ENV})"
+    )(func)
+    func = click.option(
+        "--telemetry",
+        is_flag=True,
+        help="Send anonymous aggregate usage statistics (counts, durations, error classes)"
+    )(func)
+    return func
+
+
+def _send_telemetry(
+    enabled: bool, url: str, events: EventLog, command: str, started_at: float, logger: Logger
+) -> None:
+    """Send anonymous usage statistics if the user opted in; never fails the run."""
+    if not enabled:
+        return
+    payload = build_telemetry_payload(events, command, time.time() - started_at)
+    logger.debug(f"Telemetry payload: {json.dumps(payload, sort_keys=True)}")
+    if not url:
+        logger.warn(
+            f"--telemetry set but no endpoint configured ({TELEMETRY_URL_ENV}); nothing sent"
+        )
+        return
+    try:
+        send_telemetry(url, payload)
+        logger.info("Sent anonymous usage statistics")
+    except (OSError, ValueError) as e:
+        logger.warn(f"Failed to send usage statistics: {e}")
+
+
+def notification_options(func):
+    """Add the completion notification options shared by migration commands."""
+    func = click.option(
+        "--notify-report-url",
+        default="",
+        help="Link to the published run report to include in the notification"
+    )(func)
+    func = click.option(
+        "--notify-webhook",
+        default="",
+        help="Slack or Teams incoming webhook URL that receives a summary when the run ends"
+    )(func)
+    return func
+
+
+def _notify(
+    url: str,
+    report_url: str,
+    report_path: str,
+    title: str,
+    migrated: int,
+    failures: int,
+    started_at: float,
+    events: EventLog,
+    logger: Logger,
+    details: Optional[List[str]] = None
+) -> None:
+    """Post the completion summary if a webhook was configured; never fails the run."""
+    if not url:
+        return
+    text = build_summary_text(
+        title, migrated, failures, time.time() - started_at, events, details,
+        report=report_url or report_path
+    )
+    try:
+        send_notification(url, events.redact(text))
+        logger.info("Posted completion summary to the notification webhook")
+    except (OSError, ValueError) as e:
+        logger.warn(f"Failed to post completion summary: {e}")
+
+
+def callback_options(func):
+    """Add the signed lifecycle callback options shared by migration commands."""
+    func = click.option(
+        "--callback-secret",
+        default="",
+        envvar=CALLBACK_SECRET_ENV,
+        help=f"Shared secret used to HMAC-sign callbacks (prefer {CALLBACK_SECRET_ENV})"
+    )(func)
+    func = click.option(
+        "--callback-url",
+        default="",
+        help="POST a signed JSON callback to this URL when the run starts, finishes or fails"
+    )(func)
+    return func
+
+
+def _make_callbacks(
+    url: str, secret: str, command: str, events: EventLog, logger: Logger
+) -> CallbackSender:
+    """Create the callback sender, refusing to send unsigned callbacks.
+
+    Raises:
+        SystemExit: If a callback URL is given without a signing secret
+    """
+    if url and not secret:
+        logger.error(f"--callback-url requires --callback-secret (or {CALLBACK_SECRET_ENV})")
+        raise SystemExit(1)
+    events.add_redaction(secret)
+    return CallbackSender(url, secret, command, logger, redact=events.redact)
+
+
+def repo_filter_options(func):
+    """Add the options scoping org-wide repository discovery (see RepoFilter)."""
+    func = click.option(
+        "--name",
+        "name_patterns",
+        multiple=True,
+        help="Only discover repositories whose name matches this glob, e.g. 'payments-*' "
+             "(repeatable)"
+    )(func)
+    func = click.option(
+        "--language",
+        "languages",
+        multiple=True,
+        help="Only discover repositories whose primary language is this one (repeatable)"
+    )(func)
+    func = click.option(
+        "--visibility",
+        "visibilities",
+        multiple=True,
+        type=click.Choice(REPO_VISIBILITIES),
+        help="Only discover repositories with this visibility (repeatable)"
+    )(func)
+    func = click.option(
+        "--topic",
+        "topics",
+        multiple=True,
+        help="Only discover repositories with this topic (repeatable; any of them matches)"
+    )(func)
+    func = click.option(
+        "--exclude-forks",
+        is_flag=True,
+        help="Leave forks out of org-wide discovery"
+    )(func)
+    return func
+
+
+def hostname_options(func):
+    """Add the options naming the GitHub host of each side, for commands using both."""
+    func = click.option(
+        "--target-hostname",
+        default="",
+        help="GitHub host of the target, e.g. a GHES hostname (default: GH_HOST or github.com)"
+    )(func)
+    func = click.option(
+        "--source-hostname",
+        default="",
+        help="GitHub host of the source, e.g. a GHES hostname (default: GH_HOST or github.com)"
+    )(func)
+    return func
+
+
+def hostname_option(func):
+    """Add --hostname, for commands acting on a single side."""
+    return click.option(
+        "--hostname",
+        default="",
+        help="GitHub host to act on, e.g. a GHES hostname (default: GH_HOST or github.com)"
+    )(func)
+
+
+def _host(hostname: str) -> str:
+    """Return the host a side talks to: its --*hostname, else GH_HOST, else github.com."""
+    return normalize_host(hostname or default_host(os.environ))
+
+
+def _stored_token(host: str) -> str:
+    """Return the token `gh auth login` stored for host ('' if none)."""
+    return gh_stored_token(host, os.environ)
+
+
+def _resolve_pats(
+    source_pat: str, target_pat: str, logger: Logger, source_host: str = "", target_host: str = ""
+) -> tuple:
+    """Resolve source and target PATs from flags, environment variables or gh's login.
+
+    Each side takes its flag, then SOURCE_GITHUB_TOKEN/TARGET_GITHUB_TOKEN,
+    then GH_ENTERPRISE_TOKEN when the side's host is a GHES host (GH_TOKEN
+    otherwise), then GITHUB_TOKEN, then the token `gh auth login` stored for
+    the host.
+
+    Raises:
+        SystemExit: If either token is missing
+    """
+    source_pat_value, source_origin = resolve_token(
+        "source", "--source-pat", source_pat, os.environ, _host(source_host), _stored_token
+    )
+    target_pat_value, target_origin = resolve_token(
+        "target", "--target-pat", target_pat, os.environ, _host(target_host), _stored_token
+    )
+    if source_origin == target_origin and source_origin in (GH_TOKEN_ENV, SHARED_TOKEN_ENV):
+        logger.info(
+            f"{source_origin} environment variable detected, "
+            "using it for both source and target authentication"
+        )
+    else:
+        for side, origin in (("source", source_origin), ("target", target_origin)):
+            if origin:
+                logger.debug(f"Using the {side} token from {origin}")
+
+    # Validate we have PATs for both
+    missing = [side for side, value in (("source", source_pat_value), ("target", target_pat_value))
+               if not value]
+    for side in missing:
+        logger.error(f"{side}-pat is required (or set {token_sources_hint(side)})")
+    if missing:
+        raise SystemExit(1)
+
+    return source_pat_value, target_pat_value
+
+
+def _positional_repo(
+    value: str, side: str, org: str, repos: Sequence[str], hostname: str, logger: Logger
+) -> tuple:
+    """Merge a positional SOURCE/TARGET reference into the side's host, owner and repositories.
+
+    Returns:
+        (host, org, repos): host comes from the reference, then --*-hostname,
+        then GH_HOST; the reference's repository comes first among repos
+
+    Raises:
+        SystemExit: If the reference is invalid or contradicts the side's flags
+    """
+    host = normalize_host(hostname)
+    if not value:
+        return _host(host), org, tuple(repos)
+    try:
+        ref = parse_repo_ref(value, default_host="")
+    except ValueError as e:
+        logger.error(f"Invalid {side.upper()}: {e}")
+        raise SystemExit(1)
+    if org and org.lower() != ref.owner.lower():
+        logger.error(f"{side.upper()} '{value}' is in '{ref.owner}' but --{side}-org is '{org}'")
+        raise SystemExit(1)
+    if ref.host and host and ref.host != host:
+        logger.error(f"{side.upper()} '{value}' is on {ref.host} but --{side}-hostname is {host}")
+        raise SystemExit(1)
+    if ref.repo:
+        repos = [ref.repo] + [repo for repo in repos if repo != ref.repo]
+    return _host(ref.host or host), ref.owner, tuple(repos)
+
+
+def _resolve_pat(pat: str, side: str, logger: Logger, hostname: str = "") -> str:
+    """Resolve the token of a single-organization command acting on the source or target side.
+
+    Raises:
+        SystemExit: If no token is configured
+    """
+    pat_value, origin = resolve_token(
+        side, "--pat", pat, os.environ, _host(hostname), _stored_token
+    )
+    if not pat_value:
+        logger.error(f"pat is required (or set {token_sources_hint(side)})")
+        raise SystemExit(1)
+    logger.debug(f"Using the {side} token from {origin}")
+    return pat_value
+
+
+@cli.command()
+@click.argument("source", required=False, default="")
+@click.argument("target", required=False, default="")
+@click.option(
+    "--source-org",
+    default="",
+    help="Source organization name (or pass SOURCE as OWNER/REPO)"
+)
+@click.option(
+    "--source-repo",
+    "source_repos",
+    multiple=True,
+    help="Source repository name (required for both repo-to-repo and org-to-org migrations); "
+         "repeat to consolidate several sources into one target, each prefixed with its "
+         "repository name (or REPO=PREFIX_)"
+)
+@click.option(
+    "--target-org",
+    default="",
+    help="Target organization name (or pass TARGET as OWNER/REPO)"
+)
+@click.option(
+    "--target-repo",
+    "target_repos",
+    multiple=True,
+    help="Target repository name (required for repo-to-repo migration, optional for org-to-org); "
+         "repeat to replicate the source's secrets to several targets in one run"
+)
+@click.option(
+    "--targets-file",
+    type=click.Path(exists=True, dir_okay=False),
+    default=None,
+    help="File listing target repositories, one per line (fan-out; combined with --target-repo)"
+)
+@click.option(
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source repository "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target repository "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@hostname_options
+@verbosity_options
+@click.option(
+    "--skip-envs",
+    is_flag=True,
+    help="Skip environment recreation (by default environments are recreated)"
+)
+@click.option(
+    "--org-to-org",
+    is_flag=True,
+    help="Migrate organization secrets only (ignores repo and environment secrets)"
+)
+@click.option(
+    "--levels",
+    default="",
+    help="Comma-separated scopes to process: repo, env, org (default: repo,env, "
+         "or org with --org-to-org); adding org to a repo-to-repo run also migrates "
+         "organization secrets and variables"
+)
+@click.option(
+    "--secrets-only",
+    is_flag=True,
+    help="Migrate secrets but not Actions variables"
+)
+@click.option(
+    "--variables-only",
+    is_flag=True,
+    help="Migrate Actions variables but not secrets"
+)
+@click.option(
+    "--placeholder-mode",
+    type=click.Choice(PLACEHOLDER_MODES),
+    default="none",
+    show_default=True,
+    help="Create placeholder secrets on the target before the workflow runs "
+         "(none, value, or skip-existing to leave existing target secrets untouched)"
+)
+@click.option(
+    "--placeholder-value",
+    default=DEFAULT_PLACEHOLDER_VALUE,
+    show_default=True,
+    help="Value used for placeholder secrets"
+)
+@click.option(
+    "--gh-cli-version",
+    default=GH_CLI_PINNED_VERSION,
+    show_default=True,
+    help="gh CLI version the workflow installs when the runner's gh is missing or too old "
+         "(checked against the release's SHA-256 checksums)"
+)
+@click.option(
+    "--prune",
+    is_flag=True,
+    help="Delete target secrets that no longer exist on the source "
+         "(keeps repeated syncs consistent)"
+)
+@click.option(
+    "--only-used",
+    is_flag=True,
+    help="Migrate only secrets referenced by the source repository's workflows "
+         "(secrets.NAME), reporting the others as orphans"
+)
+@click.option(
+    "--promote-to-org",
+    "promote_to_org",
+    multiple=True,
+    metavar="SECRET",
+    help="Repository secret to create as a target organization secret scoped to the "
+         "target repository instead of as a repository secret (repeatable)"
+)
+@click.option(
+    "--target-prefix",
+    default="",
+    help="Prefix added to every migrated secret name on the target (e.g. LEGACY_)"
+)
+@click.option(
+    "--target-suffix",
+    default="",
+    help="Suffix added to every migrated secret name on the target"
+)
+@click.option(
+    "--rename-regex",
+    "rename_rules",
+    multiple=True,
+    help="sed-style rule renaming secrets on the target, e.g. 's/^PROD_/PRD_/' "
+         "(repeatable, applied in order before prefix/suffix; flags: g, i)"
+)
+@click.option(
+    "--policy",
+    "policy_file",
+    default="",
+    help="YAML file with 'deny'/'allow' lists of secret names or patterns (e.g. '*_PRIVATE_KEY'); "
+         "denied secrets are never migrated"
+)
+@click.option(
+    "--value-rules",
+    "value_rules_file",
+    default="",
+    help="YAML file of rules rewriting secret values in the workflow, e.g. replacing "
+         "'ghes.corp.example' with 'github.com' in secrets matching '*_URL'"
+)
+@click.option(
+    "--destination-plugin",
+    "destination_plugins",
+    multiple=True,
+    metavar="FILE",
+    help="YAML definition of a destination plugin the workflow also hands the migrated "
+         "secrets to, e.g. a vault or secrets manager (repeatable)"
+)
+@click.option(
+    "--rego-policy",
+    default="",
+    help="Rego policy defining data.secrets_migrator.decision, evaluated with the opa CLI "
+         "for every secret to allow, deny or rename it"
+)
+@click.option(
+    "--map-environment",
+    "environment_mappings",
+    multiple=True,
+    help="Route a source environment's secrets to a differently named target environment, "
+         "as SOURCE=TARGET (repeatable)"
+)
+@click.option(
+    "--target-env",
+    default="",
+    help="Write source repository secrets into this target environment (created if "
+         "missing) instead of the target repository level"
+)
+@click.option(
+    "--conflict-policy",
+    type=click.Choice(CONFLICT_POLICIES),
+    default="overwrite",
+    show_default=True,
+    help="What to do with secrets that already exist on the target"
+)
+@click.option(
+    "--runner-label",
+    "runner_labels",
+    multiple=True,
+    help="Runner label for the migration workflow's runs-on (repeatable; default ubuntu-latest)"
+)
+@click.option(
+    "--runner-os",
+    type=click.Choice(RUNNER_OSES),
+    default="ubuntu",
+    show_default=True,
+    help="Runner OS of the migration workflow; windows runners get PowerShell steps"
+)
+@click.option(
+    "--workflow-engine",
+    type=click.Choice(WORKFLOW_ENGINES),
+    default="shell",
+    show_default=True,
+    help="shell: a bash/PowerShell step per secret; github-script: one pinned "
+         "actions/github-script step runs the whole migration; action: one step "
+         "uses the published secrets-migrator-action (needs --action-ref)"
+)
+@click.option(
+    "--action-ref",
+    default="",
+    help="Action used by --workflow-engine action, pinned to a commit "
+         "(e.g. renan-alm/secrets-migrator-action@<commit SHA>)"
+)
+@click.option(
+    "--target-app-id",
+    type=click.IntRange(min=1),
+    default=None,
+    help="GitHub App the workflow mints a short-lived target token from, with the private key "
+         "in the source's SECRETS_MIGRATOR_TARGET_APP_KEY secret (no target PAT is stored)"
+)
+@click.option(
+    "--approval-environment",
+    default="",
+    help="Source environment with required reviewers the workflow runs in; the temporary "
+         "secrets are stored there, so nothing is read until a reviewer approves the run"
+)
+@click.option(
+    "--workflow-timeout",
+    type=click.IntRange(min=1, max=360),
+    default=DEFAULT_WORKFLOW_TIMEOUT_MINUTES,
+    show_default=True,
+    help="timeout-minutes of the migration workflow's job"
+)
+@click.option(
+    "--delivery",
+    type=click.Choice(DELIVERY_MODES),
+    default="push",
+    show_default=True,
+    help="push: the workflow runs when its branch is pushed; "
+         "pull-request: open a PR and run it only once merged"
+)
+@click.option(
+    "--branch-name",
+    default="",
+    help="Branch the migration workflow is pushed to "
+         "(default: migrate-secrets, or migrate-org-secrets with --org-to-org)"
+)
+@click.option("--commit-message", default="", help="Commit message for the workflow file")
+@click.option("--committer-name", default="", help="Author/committer name for the workflow commit")
+@click.option(
+    "--committer-email",
+    default="",
+    help="Author/committer email for the workflow commit (with --committer-name)"
+)
+@click.option(
+    "--migrate-settings",
+    is_flag=True,
+    help="Also copy repository settings consumers depend on: the Actions access policy "
+         "(which repositories may use this repository's actions and reusable workflows)"
+)
+@click.option(
+    "--create-target-repo",
+    is_flag=True,
+    help="Create the target repository when it does not exist yet (repo-to-repo mode)"
+)
+@click.option(
+    "--target-repo-visibility",
+    type=click.Choice(REPO_VISIBILITIES),
+    default="private",
+    show_default=True,
+    help="Visibility of a repository created by --create-target-repo"
+)
+@click.option(
+    "--wait",
+    is_flag=True,
+    help="Wait for the migration workflow run to finish and confirm from its log which "
+         "secrets were set on the target (fails the run if any was not)"
+)
+@click.option(
+    "--unarchive",
+    is_flag=True,
+    help="Temporarily unarchive archived source or target repositories and archive them "
+         "again once the run ends (needs --wait); without it, archived repositories fail upfront"
+)
+@click.option(
+    "--sandbox",
+    is_flag=True,
+    help=f"Safety net for test runs: refuse to start unless the source and target are "
+         f"throwaway organizations or repositories designated in {SANDBOX_ENV}"
+)
+@click.option(
+    "--remigrate",
+    is_flag=True,
+    help="Migrate every secret again, including those an earlier run already migrated "
+         "(by default they are skipped while unchanged on the source)"
+)
+@click.option(
+    "--tracking-issue",
+    is_flag=True,
+    help="Open an issue on the target repo listing migrated secrets, placeholders and follow-ups"
+)
+@click.option(
+    "--pre-hook",
+    default="",
+    metavar="COMMAND",
+    help="Shell command run before each repository's migration (e.g. pausing its deployment "
+         "pipelines); the migration stops if it fails"
+)
+@click.option(
+    "--post-hook",
+    default="",
+    metavar="COMMAND",
+    help="Shell command run after each repository's migration, with the result in "
+         "GH_SECRETS_MIGRATOR_RESULT (e.g. updating a change ticket)"
+)
+@click.option(
+    "--quota-check",
+    type=click.Choice(QUOTA_CHECK_MODES),
+    default="fail",
+    show_default=True,
+    help="What to do when the target would exceed GitHub's secret limits "
+         "(100 per repository/environment, 1000 per organization)"
+)
+@click.option(
+    "--naming-pattern",
+    default="",
+    help="Regular expression every migrated secret name must fully match "
+         "(e.g. '[A-Z][A-Z0-9_]*')"
+)
+@click.option(
+    "--naming-max-length",
+    type=click.IntRange(min=0),
+    default=0,
+    help="Longest secret name allowed by the naming conventions (0: no limit)"
+)
+@click.option(
+    "--naming-reserved-prefix",
+    "naming_reserved_prefixes",
+    multiple=True,
+    help="Prefix migrated secret names must not use (repeatable, case-insensitive)"
+)
+@click.option(
+    "--enforce-naming",
+    is_flag=True,
+    help="Fail the run when secret names break the naming conventions instead of warning"
+)
+@click.option(
+    "--state-dir",
+    default=DEFAULT_STATE_DIR,
+    envvar=STATE_DIR_ENV,
+    show_default=True,
+    help="Directory for run state such as pre-write target snapshots "
+         f"(or set {STATE_DIR_ENV})"
+)
+@click.option(
+    "--state-file",
+    default="",
+    help="JSON file recording this migration's inputs, created resources and per-secret "
+         "outcomes (default: migrations/<org>__<repo>.json under --state-dir)"
+)
+@click.option(
+    "--no-workflow-lint",
+    is_flag=True,
+    help="Push the generated workflow without the pre-push lint (YAML, expressions, bash -n, "
+         "actionlint if installed)"
+)
+@click.option(
+    "--no-snapshot",
+    is_flag=True,
+    help="Do not save the target inventory to the state directory before the first write"
+)
+@click.option(
+    "--branch-check",
+    type=click.Choice(BRANCH_CHECK_MODES),
+    default="warn",
+    show_default=True,
+    help="What to do when source rulesets or branch protections would block the migration "
+         "branch (auto: try common automation prefixes such as 'automation/')"
+)
+@click.option(
+    "--report",
+    "report_path",
+    default="",
+    help="Write a JSON report of the run's events to this file"
+)
+@click.option(
+    "--transcript",
+    "transcript_path",
+    default="",
+    help="Write a redacted Markdown narrative of the run to this file (for tickets or PRs)"
+)
+@timeout_options
+@cache_options
+@pushgateway_options
+@telemetry_options
+@notification_options
+@callback_options
+@event_stream_options
+@audit_options
+@state_encryption_options
+def migrate(
+    source,
+    target,
+    source_org,
+    source_repos,
+    target_org,
+    target_repos,
+    targets_file,
+    source_pat,
+    target_pat,
+    source_hostname,
+    target_hostname,
+    verbose,
+    quiet,
+    no_color,
+    skip_envs,
+    org_to_org,
+    levels,
+    secrets_only,
+    variables_only,
+    placeholder_mode,
+    placeholder_value,
+    gh_cli_version,
+    prune,
+    only_used,
+    promote_to_org,
+    target_prefix,
+    target_suffix,
+    rename_rules,
+    policy_file,
+    value_rules_file,
+    destination_plugins,
+    rego_policy,
+    quota_check,
+    naming_pattern,
+    naming_max_length,
+    naming_reserved_prefixes,
+    enforce_naming,
+    state_dir,
+    state_file,
+    no_snapshot,
+    no_workflow_lint,
+    branch_check,
+    environment_mappings,
+    target_env,
+    conflict_policy,
+    runner_labels,
+    runner_os,
+    workflow_engine,
+    action_ref,
+    target_app_id,
+    approval_environment,
+    workflow_timeout,
+    migrate_settings,
+    create_target_repo,
+    target_repo_visibility,
+    tracking_issue,
+    pre_hook,
+    post_hook,
+    wait,
+    unarchive,
+    sandbox,
+    remigrate,
+    delivery,
+    branch_name,
+    commit_message,
+    committer_name,
+    committer_email,
+    report_path,
+    transcript_path,
+    run_timeout,
+    api_timeout,
+    metadata_cache,
+    pushgateway_url,
+    pushgateway_job,
+    telemetry,
+    telemetry_url,
+    notify_webhook,
+    notify_report_url,
+    callback_url,
+    callback_secret,
+    events_format,
+    events_file,
+    audit_log_path,
+    state_passphrase,
+    state_age_recipients,
+    state_age_identity,
+    plan_path="",
+    sync=False,
+    sync_from_audit_log=False,
+    inventory_path="",
+):
+    """Migrate GitHub secrets from one organization/repository to another.
+
+    SOURCE and TARGET may replace the organization and repository flags, as
+    OWNER/REPO, HOST/OWNER/REPO or repository URLs (GHES hosts included);
+    TARGET may name only the organization with --org-to-org.
+
+    Two modes of operation:
+    - Repository to Repository: Migrates repo and environment secrets
+    - Organization to Organization: Migrates only org secrets (--org-to-org flag)
+    """
+    events_path = _events_path(events_format, events_file)
+    logger = _make_logger(verbose, quiet, no_color, events_path)
+
+    source_host, source_org, source_repos = _positional_repo(
+        source, "source", source_org, source_repos, source_hostname, logger
+    )
+    target_host, target_org, target_repos = _positional_repo(
+        target, "target", target_org, target_repos, target_hostname, logger
+    )
+    # plan --inventory: each repository the inventory lists goes to its namesake on the target
+    inventory_repos: List[str] = []
+    if inventory_path:
+        if source_repos or target_repos or targets_file or org_to_org:
+            logger.error("--inventory plans the repositories it lists: drop the source and target")
+            logger.error("repositories and --org-to-org")
+            raise SystemExit(1)
+        try:
+            recorded_inventory = load_inventory(inventory_path)
+        except (OSError, ValueError) as e:
+            logger.error(f"Invalid inventory {inventory_path}: {e}")
+            raise SystemExit(1)
+        inventory_org = recorded_inventory["organization"]
+        if source_org and source_org.lower() != inventory_org.lower():
+            logger.error(f"{inventory_path} inventories {inventory_org}, not {source_org}")
+            raise SystemExit(1)
+        source_org = inventory_org
+        inventory_repos = inventory_repositories(recorded_inventory)
+        if not inventory_repos:
+            logger.error(f"{inventory_path} lists no repository secrets or variables")
+            raise SystemExit(1)
+        logger.info(f"Planning {len(inventory_repos)} repository(ies) from {inventory_path}")
+        source_repos = target_repos = tuple(inventory_repos[:1])
+    for side, org in (("source", source_org), ("target", target_org)):
+        if not org:
+            logger.error(f"{side}-org is required (or pass {side.upper()} as OWNER/REPO)")
+            raise SystemExit(1)
+
+    # Consolidation: every source writes into the target under its own prefix
+    sources = list(dict.fromkeys(source_repos))
+    consolidating = len(sources) > 1
+    source_prefixes = dict(parse_source(spec) for spec in sources) if consolidating else {}
+    source_repo = next(iter(source_prefixes), sources[0] if sources else "")
+    if not consolidating and "=" in source_repo:
+        logger.error("A REPO=PREFIX_ source only applies when consolidating several sources")
+        raise SystemExit(1)
+
+    # Validate source-repo is always provided (required for workflow execution)
+    if not source_repo:
+        logger.error("source-repo is required for both repo-to-repo and org-to-org migrations")
+        logger.error("The migration workflow must run in a source repository")
+        raise SystemExit(1)
+
+    targets = list(dict.fromkeys(target_repos))
+    if targets_file:
+        try:
+            targets = list(dict.fromkeys(targets + load_targets_file(targets_file, target_org)))
+        except (OSError, ValueError) as e:
+            logger.error(f"Invalid targets file {targets_file}: {e}")
+            raise SystemExit(1)
+    target_repo = targets[0] if targets else ""
+
+    consolidation_problem = None
+    if consolidating:
+        consolidation_problem = consolidation_error(len(targets), org_to_org, prune)
+    if consolidation_problem:
+        logger.error(consolidation_problem)
+        raise SystemExit(1)
+
+    # Validate modes
+    if org_to_org:
+        if len(targets) > 1:
+            logger.error("Several target repositories (fan-out) need repo-to-repo migration")
+            raise SystemExit(1)
+        # For org-to-org: source-repo required, target-repo optional (defaults to source-repo name)
+        logger.info("Organization-to-Organization mode: org secrets only")
+        logger.info(f"Source repository (for workflow): {source_repo}")
+        logger.info(f"Target repository: {target_repo if target_repo else source_repo}")
+    else:
+        # For repo-to-repo: both repos required
+        if not target_repo:
+            logger.error("target-repo is required for repo-to-repo migration")
+            logger.error("(or use --org-to-org flag for organization-to-organization migration)")
+            raise SystemExit(1)
+        logger.info("Repository-to-Repository mode")
+        for repo in source_prefixes or [source_repo]:
+            suffix = f" (prefix {source_prefixes[repo] or 'none'})" if consolidating else ""
+            logger.info(f"Source: {source_org}/{repo}{suffix}")
+        for repo in targets:
+            logger.info(f"Target: {target_org}/{repo}")
+
+    try:
+        for prefix in source_prefixes.values() or [""]:
+            SecretNameTransformer(f"{target_prefix}{prefix}", target_suffix, rename_rules)
+    except ValueError as e:
+        logger.error(str(e))
+        raise SystemExit(1)
+
+    if wait and delivery == "pull-request":
+        logger.error("--wait needs --delivery push: with pull-request delivery the workflow")
+        logger.error("only runs once the pull request is merged")
+        raise SystemExit(1)
+
+    try:
+        check_commit_options(branch_name, committer_name, committer_email)
+        check_action_ref(workflow_engine, action_ref)
+        check_unarchive(unarchive, wait, delivery)
+        NamingConvention(naming_pattern, naming_max_length, naming_reserved_prefixes)
+    except ValueError as e:
+        logger.error(str(e))
+        raise SystemExit(1)
+
+    selected_levels = parse_levels(levels)
+    try:
+        check_selection(selected_levels, org_to_org, not variables_only, not secrets_only)
+    except ValueError as e:
+        logger.error(f"Invalid --levels/--secrets-only/--variables-only: {e}")
+        raise SystemExit(1)
+    try:
+        check_promotion(promote_to_org, org_to_org, selected_levels)
+    except ValueError as e:
+        logger.error(str(e))
+        raise SystemExit(1)
+
+    environment_map = {}
+    for mapping in environment_mappings:
+        source_env, _, mapped_env = mapping.partition("=")
+        if not source_env or not mapped_env:
+            logger.error(f"Invalid --map-environment '{mapping}': expected SOURCE=TARGET")
+            raise SystemExit(1)
+        environment_map[source_env] = mapped_env
+    try:
+        check_target_env(target_env, org_to_org, selected_levels, environment_map)
+    except ValueError as e:
+        logger.error(str(e))
+        raise SystemExit(1)
+
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_host, target_host
+    )
+
+    config = MigrationConfig(
+        source_org=source_org,
+        source_repo=source_repo,
+        target_org=target_org,
+        target_repo=target_repo,
+        source_pat=source_pat_value,
+        target_pat=target_pat_value,
+        verbose=verbose,
+        skip_envs=skip_envs,
+        org_to_org=org_to_org,
+        levels=selected_levels,
+        migrate_secrets=not variables_only,
+        migrate_variables=not secrets_only,
+        placeholder_mode=placeholder_mode,
+        placeholder_value=placeholder_value,
+        gh_cli_version=gh_cli_version,
+        prune=prune,
+        target_prefix=target_prefix,
+        target_suffix=target_suffix,
+        rename_rules=rename_rules,
+        policy_file=policy_file,
+        value_rules_file=value_rules_file,
+        destination_plugins=destination_plugins,
+        rego_policy=rego_policy,
+        quota_check=quota_check,
+        naming_pattern=naming_pattern,
+        naming_max_length=naming_max_length,
+        naming_reserved_prefixes=naming_reserved_prefixes,
+        enforce_naming=enforce_naming,
+        only_used=only_used,
+        promote_to_org=promote_to_org,
+        wait=wait,
+        unarchive=unarchive,
+        sandbox=sandbox,
+        remigrate=remigrate,
+        sync=sync,
+        sync_from_audit_log=sync_from_audit_log,
+        environment_map=environment_map,
+        target_env=target_env,
+        conflict_policy=conflict_policy,
+        runner_labels=runner_labels,
+        runner_os=runner_os,
+        workflow_engine=workflow_engine,
+        action_ref=action_ref,
+        target_app_id=str(target_app_id or ""),
+        approval_environment=approval_environment,
+        workflow_timeout=workflow_timeout,
+        tracking_issue=tracking_issue,
+        pre_hook=pre_hook,
+        post_hook=post_hook,
+        delivery=delivery,
+        branch_name=branch_name,
+        commit_message=commit_message,
+        committer_name=committer_name,
+        committer_email=committer_email,
+        branch_check=branch_check,
+        state_dir=state_dir,
+        state_file=state_file,
+        state_passphrase=state_passphrase,
+        state_age_recipients=state_age_recipients,
+        state_age_identity=state_age_identity,
+        snapshot=not no_snapshot,
+        workflow_lint=not no_workflow_lint,
+        api_timeout=api_timeout,
+        migrate_settings=migrate_settings,
+        create_target_repo=create_target_repo,
+        target_repo_visibility=target_repo_visibility,
+        extra_target_repos=targets[1:],
+        source_host=source_host,
+        target_host=target_host
+    )
+
+    configs = [config]
+    if inventory_repos:
+        configs = []
+        for repo in inventory_repos:
+            repo_config = copy.copy(config)
+            repo_config.source_repo = repo_config.target_repo = repo
+            configs.append(repo_config)
+    if consolidating:
+        configs = []
+        for repo, prefix in source_prefixes.items():
+            source_config = copy.copy(config)
+            source_config.source_repo = repo
+            source_config.target_prefix = f"{target_prefix}{prefix}"
+            configs.append(source_config)
+
+    if plan_path:
+        _write_plan(
+            plan_path, configs, consolidating, logger, events_path,
+            EventLog([source_pat_value, target_pat_value]), report_path, transcript_path,
+            run_timeout, metadata_cache, audit_log_path
+        )
+        return
+
+    if org_to_org:
+        title = f"organization secrets {source_org} → {target_org}"
+    else:
+        title = ", ".join(f"{source_org}/{repo}" for repo in source_prefixes or [source_repo])
+        title += " → " + ", ".join(f"{target_org}/{repo}" for repo in targets)
+    events = EventLog([source_pat_value, target_pat_value])
+    _stream_events(events_path, events, logger)
+    callbacks = _make_callbacks(callback_url, callback_secret, "migrate", events, logger)
+    callbacks.send(
+        "started", source_org=source_org, source_repo=source_repo,
+        target_org=target_org, target_repo=target_repo, org_to_org=org_to_org
+    )
+    started_at = time.time()
+    succeeded = False
+    error = ""
+    completed = 0
+    nothing_to_migrate = True
+    cache = _make_cache(metadata_cache, logger)
+    audit = _make_audit_log(audit_log_path, logger)
+    deadline = Deadline(run_timeout)
+    try:
+        with deadline:
+            if consolidating:
+                _check_consolidation(configs, logger, events, cache, audit)
+            for run_config in configs:
+                migrator = Migrator(run_config, logger, events, cache, audit)
+                migrator.run()
+                completed += 1
+                nothing_to_migrate = nothing_to_migrate and migrator.wrote_nothing
+        succeeded = True
+
+    except KeyboardInterrupt:
+        if deadline.expired:
+            error = f"Timed out after {run_timeout:g}s (--timeout)"
+            logger.error(f"Migration {error.lower()}")
+            raise SystemExit(EXIT_TIMED_OUT)
+        error = "Cancelled"
+        logger.error("Migration cancelled")
+        raise SystemExit(EXIT_CANCELLED)
+    except RuntimeError as e:
+        error = str(e)
+        _report_error(logger, e)
+        raise SystemExit(exit_code(e, completed))
+    except Exception as e:
+        error = f"Unexpected error: {type(e).__name__}: {e}"
+        logger.error(error)
+        raise SystemExit(1)
+    finally:
+        _save_cache(cache, logger)
+        _write_run_outputs(events, logger, report_path, transcript_path)
+        # Repositories migrated before a failure count; the rest were not attempted
+        failures = 0 if succeeded else 1
+        _push_run_metrics(pushgateway_url, pushgateway_job, completed, failures, started_at, logger)
+        _send_telemetry(telemetry, telemetry_url, events, "migrate", started_at, logger)
+        _notify(
+            notify_webhook, notify_report_url, report_path, title,
+            completed, failures, started_at, events, logger
+        )
+        callbacks.send(
+            "finished" if succeeded else "failed",
+            migrated=completed, failures=failures,
+            duration_seconds=round(time.time() - started_at, 3), error=error
+        )
+    # Nothing was written at all; a sync with nothing to do is the normal case,
+    # not an outcome to branch on
+    if nothing_to_migrate and not sync:
+        raise SystemExit(EXIT_NOTHING_TO_MIGRATE)
+
+
+def _check_consolidation(
+    configs: List[MigrationConfig],
+    logger: Logger,
+    events: EventLog,
+    cache: Optional[ETagCache],
+    audit: Optional[AuditLog]
+) -> None:
+    """Detect target secrets several sources would write, before any source runs.
+
+    With conflict policy 'fail', secrets already on the target are reported
+    too, since later sources would otherwise fail after earlier ones wrote.
+
+    Raises:
+        RuntimeError: If any collision is found
+    """
+    first = configs[0]
+    source_api = GitHubClient(
+        first.source_pat, logger, cache, first.api_timeout, audit, "source", first.source_host
+    )
+    logger.info(f"Checking {len(configs)} sources for colliding target secret names...")
+    planned = []
+    for config in configs:
+        namer = SecretNameTransformer(
+            config.target_prefix, config.target_suffix, config.rename_rules
+        )
+        try:
+            policy = load_policy(config.policy_file) if config.policy_file else SecretPolicy()
+        except (OSError, ValueError) as e:
+            raise RuntimeError(f"Failed to load secret policy '{config.policy_file}': {e}")
+        planned += plan_source(
+            config.source_repo, namer,
+            source_api.list_repo_secrets(config.source_org, config.source_repo),
+            source_api.list_all_environments_with_secrets(config.source_org, config.source_repo),
+            config.environment_map, policy, config.promote_to_org, config.target_env
+        )
+    existing = None
+    if first.conflict_policy == "fail":
+        target_api = GitHubClient(
+            first.target_pat, logger, cache, first.api_timeout, audit, "target", first.target_host
+        )
+        existing = {"repository": target_api.list_repo_secrets(first.target_org, first.target_repo)}
+        for environment in target_api.list_environments(first.target_org, first.target_repo):
+            existing[f"environment {environment}"] = target_api.list_environment_secrets(
+                first.target_org, first.target_repo, environment
+            )
+    problems = find_collisions(planned, existing)
+    if problems:
+        for problem in problems:
+            logger.error(f"  {problem}")
+        events.emit(
+            "error", f"{len(problems)} collision(s) between consolidated sources", problems=problems
+        )
+        raise RuntimeError(
+            f"Consolidation stopped before any write: {len(problems)} collision(s); "
+            "set distinct prefixes with --source-repo REPO=PREFIX_ or rename with --rename-regex"
+        )
+    logger.success(
+        f"✓ {len(planned)} secret(s) from {len(configs)} sources map to distinct target names"
+    )
+    events.emit(
+        "decision", f"Consolidating {len(configs)} sources: no colliding target names",
+        secrets=len(planned)
+    )
+
+
+def _write_run_outputs(
+    events: EventLog, logger: Logger, report_path: str, transcript_path: str
+) -> None:
+    """Write the JSON report and transcript requested on the command line."""
+    if report_path:
+        try:
+            events.write_json_report(report_path)
+            logger.info(f"Report written to {report_path}")
+        except OSError as e:
+            logger.error(f"Failed to write report to {report_path}: {e}")
+    if transcript_path:
+        try:
+            write_transcript(events, transcript_path)
+            logger.info(f"Transcript written to {transcript_path}")
+        except OSError as e:
+            logger.error(f"Failed to write transcript to {transcript_path}: {e}")
+
+
+def _write_plan(
+    plan_path: str,
+    configs: List[MigrationConfig],
+    consolidating: bool,
+    logger: Logger,
+    events_path: str,
+    events: EventLog,
+    report_path: str,
+    transcript_path: str,
+    run_timeout: float,
+    metadata_cache: str,
+    audit_log_path: str
+) -> None:
+    """Work out each run's secret changes without writing, then save and print the plan."""
+    _stream_events(events_path, events, logger)
+    cache = _make_cache(metadata_cache, logger)
+    audit = _make_audit_log(audit_log_path, logger)
+    deadline = Deadline(run_timeout)
+    try:
+        with deadline:
+            if consolidating:
+                _check_consolidation(configs, logger, events, cache, audit)
+            plan = SavedPlan([
+                PlannedRun(config.options(), Migrator(config, logger, events, cache, audit).plan())
+                for config in configs
+            ])
+        save_plan(plan_path, plan)
+    except KeyboardInterrupt:
+        if deadline.expired:
+            logger.error(f"Planning timed out after {run_timeout:g}s (--timeout)")
+            raise SystemExit(EXIT_TIMED_OUT)
+        logger.error("Planning cancelled")
+        raise SystemExit(EXIT_CANCELLED)
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+    except OSError as e:
+        logger.error(f"Failed to write plan {plan_path}: {e}")
+        raise SystemExit(1)
+    finally:
+        _save_cache(cache, logger)
+        _write_run_outputs(events, logger, report_path, transcript_path)
+
+    for line in format_plan(plan):
+        click.echo(line, err=events_path == "-")
+    logger.summary(
+        f"Plan written to {plan_path} (SHA-256 {plan.digest})\n"
+        f"Review it, then run: python main.py apply {plan_path}"
+    )
+
+
+# migrate options that only concern a run's outcome, not what it would change
+_RUN_OUTCOME_OPTIONS = (
+    "pushgateway_url", "pushgateway_job", "telemetry", "telemetry_url", "notify_webhook",
+    "notify_report_url", "callback_url", "callback_secret",
+)
+
+
+def _plan(plan_path, inventory_path, **options):
+    """Run `migrate` in planning mode, writing plan_path."""
+    migrate.callback(
+        plan_path=plan_path, inventory_path=inventory_path,
+        **dict.fromkeys(_RUN_OUTCOME_OPTIONS), **options
+    )
+
+
+cli.add_command(click.Command(
+    "plan",
+    callback=_plan,
+    params=[
+        click.Option(
+            ["-o", "--out", "plan_path"],
+            required=True,
+            type=click.Path(dir_okay=False),
+            help="File the plan is written to (JSON)"
+        ),
+        click.Option(
+            ["--inventory", "inventory_path"],
+            default="",
+            type=click.Path(exists=True, dir_okay=False),
+            help="Inventory (JSON or CSV, see `inventory`): plan one run per repository it "
+                 "lists, into the repository of the same name in the target organization"
+        ),
+        *(param for param in migrate.params if param.name not in _RUN_OUTCOME_OPTIONS),
+    ],
+    help="""Write a reviewable plan of a migration without changing anything.
+
+    Takes the options of `migrate`. Both sides are read and every secret the
+    run would create, replace, delete (--prune) or skip is listed with its
+    target name, location and, for organization secrets, visibility; the plan
+    file also records the options. `apply` executes exactly that plan.
+    With --inventory, the plan covers every repository of an inventory.
+    """,
+))
+
+
+def _sync(watch, from_audit_log, **options):
+    """Run `migrate` in sync mode, once or (with --watch) on a cron schedule until stopped."""
+    options["sync_from_audit_log"] = from_audit_log
+    if not watch:
+        migrate.callback(sync=True, **options)
+        return
+    logger = _make_logger(options["verbose"], options["quiet"], options["no_color"])
+    try:
+        schedule = CronSchedule(watch)
+    except ValueError as e:
+        logger.error(f"Invalid --watch schedule: {e}")
+        raise SystemExit(1)
+    logger.info(f"Watching: syncing now, then on '{schedule.expression}' until stopped")
+    while True:
+        try:
+            migrate.callback(sync=True, **options)
+        except SystemExit as e:
+            if e.code == EXIT_CANCELLED:
+                raise
+            # A failed or timed-out sync is retried at the next scheduled time
+            logger.warn(f"Sync failed (exit status {e.code}); retrying on schedule")
+        next_run = schedule.next_after(datetime.now())
+        logger.info(f"Next sync at {next_run.isoformat(sep=' ', timespec='minutes')}")
+        try:
+            time.sleep(max(0.0, (next_run - datetime.now()).total_seconds()))
+        except KeyboardInterrupt:
+            logger.info("Stopped watching")
+            raise SystemExit(EXIT_CANCELLED)
+
+
+cli.add_command(click.Command(
+    "sync",
+    callback=_sync,
+    params=[
+        *migrate.params,
+        click.Option(
+            ["--watch"],
+            default="",
+            metavar="CRON",
+            help="Keep running: sync now, then on this cron schedule in local time "
+                 "(e.g. '*/15 * * * *') until stopped with Ctrl-C or SIGTERM"
+        ),
+        click.Option(
+            ["--from-audit-log"],
+            is_flag=True,
+            help="Scan the source only when its organization's audit log records secret or "
+                 "variable changes since the last run (GitHub Enterprise; needs read:audit_log)"
+        ),
+    ],
+    help="""Migrate only the secrets that changed since the last completed run.
+
+    Takes the options of `migrate`. Secrets missing on the target, and
+    secrets updated on the source since the last completed run recorded in
+    the state file started, are migrated; the others are left alone. When
+    nothing changed, no branch or workflow is created. The first sync of a
+    source migrates every secret. Meant to be repeated while source and
+    target run side by side, e.g. with --watch until the final cutover.
+    With --from-audit-log, runs whose audit log window holds no change end
+    without listing a single secret.
+    """,
+))
+
+
+@cli.command()
+@click.argument("plan_file", type=click.Path(exists=True, dir_okay=False))
+@click.option(
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@click.option(
+    "--state-passphrase",
+    default="",
+    envvar=STATE_PASSPHRASE_ENV,
+    help="Passphrase of the state files, when they are encrypted at rest "
+         f"(prefer setting {STATE_PASSPHRASE_ENV})"
+)
+@click.option(
+    "--digest",
+    "expected_digest",
+    default="",
+    help="Only apply the plan if its SHA-256 is this one (the digest that was signed off)"
+)
+@click.option(
+    "--report",
+    "report_path",
+    default="",
+    help="Write a JSON report of the run's events to this file"
+)
+@click.option(
+    "--transcript",
+    "transcript_path",
+    default="",
+    help="Write a redacted Markdown narrative of the run to this file (for tickets or PRs)"
+)
+@click.option(
+    "--timeout",
+    "run_timeout",
+    type=click.FloatRange(min=0),
+    default=0,
+    help="Stop the run after this many seconds, cleaning up like Ctrl-C (0: no limit)"
+)
+@cache_options
+@verbosity_options
+@event_stream_options
+@audit_options
+def apply(
+    plan_file,
+    source_pat,
+    target_pat,
+    state_passphrase,
+    expected_digest,
+    report_path,
+    transcript_path,
+    run_timeout,
+    metadata_cache,
+    verbose,
+    quiet,
+    no_color,
+    events_format,
+    events_file,
+    audit_log_path,
+):
+    """Execute a plan written by `plan`, exactly as it was reviewed.
+
+    The secret changes are first worked out again from the live source and
+    target. If any differs from the plan (a secret added, removed or renamed
+    on the source, or created on the target meanwhile), nothing is written
+    and the differences are listed, so a new plan can be made and reviewed.
+    """
+    events_path = _events_path(events_format, events_file)
+    logger = _make_logger(verbose, quiet, no_color, events_path)
+    try:
+        plan = load_plan(plan_file)
+    except (OSError, ValueError) as e:
+        logger.error(f"Invalid plan file {plan_file}: {e}")
+        raise SystemExit(1)
+    if expected_digest and expected_digest.lower() != plan.digest:
+        logger.error(f"Plan {plan_file} has SHA-256 {plan.digest}, not {expected_digest}")
+        raise SystemExit(1)
+    logger.info(f"Applying plan {plan_file} (SHA-256 {plan.digest}, made {plan.created_at})")
+
+    first = plan.runs[0].options
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, first.get("source_host", ""), first.get("target_host", "")
+    )
+    configs = [
+        run.build_config(source_pat_value, target_pat_value, state_passphrase)
+        for run in plan.runs
+    ]
+    events = EventLog([source_pat_value, target_pat_value])
+    _stream_events(events_path, events, logger)
+    cache = _make_cache(metadata_cache, logger)
+    audit = _make_audit_log(audit_log_path, logger)
+    deadline = Deadline(run_timeout)
+    completed = 0
+    nothing_to_migrate = True
+    try:
+        with deadline:
+            drift = []
+            for run, config in zip(plan.runs, configs):
+                current = Migrator(config, logger, events, cache, audit).plan()
+                drift += [f"{run.title}: {line}" for line in plan_drift(run.changes, current)]
+            if drift:
+                for line in drift:
+                    logger.error(f"  {line}")
+                events.emit("error", f"Plan {plan_file} is out of date", drift=drift)
+                raise VerificationMismatch(
+                    f"The source or target changed since the plan was made ({len(drift)} "
+                    "difference(s)); nothing was written. Run `plan` again and review the new plan"
+                )
+            logger.success("✓ Source and target still match the plan")
+            for config in configs:
+                migrator = Migrator(config, logger, events, cache, audit)
+                migrator.run()
+                completed += 1
+                nothing_to_migrate = nothing_to_migrate and migrator.wrote_nothing
+    except KeyboardInterrupt:
+        if deadline.expired:
+            logger.error(f"Apply timed out after {run_timeout:g}s (--timeout)")
+            raise SystemExit(EXIT_TIMED_OUT)
+        logger.error("Apply cancelled")
+        raise SystemExit(EXIT_CANCELLED)
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(exit_code(e, completed))
+    finally:
+        _save_cache(cache, logger)
+        _write_run_outputs(events, logger, report_path, transcript_path)
+    logger.summary(f"Plan {plan_file} applied")
+    if nothing_to_migrate:
+        raise SystemExit(EXIT_NOTHING_TO_MIGRATE)
+
+
+def _order_shared_first(
+    jobs: List[PipelineJob], api: GitHubClient, events: EventLog, logger: Logger
+) -> List[PipelineJob]:
+    """Detect shared repositories among pipeline jobs and run them first."""
+    def is_shared(job: PipelineJob) -> bool:
+        org, repo = job.options["source_org"], job.options["source_repo"]
+        try:
+            paths = shared_automation(api.list_automation_files(org, repo))
+        except RuntimeError as e:
+            logger.warn(f"Could not inspect {org}/{repo} for shared workflows: {e}")
+            events.emit(
+                "warning", f"{org}/{repo} not inspected; treated as a consumer", job=job.name
+            )
+            return False
+        if paths:
+            logger.info(f"{org}/{repo} hosts shared automation: {', '.join(paths)}")
+            events.emit(
+                "decision", f"{org}/{repo} hosts shared automation; migrating it first",
+                job=job.name, paths=paths
+            )
+        return bool(paths)
+
+    ordered = order_shared_first(jobs, is_shared)
+    logger.info(f"Job order: {', '.join(job.name for job in ordered)}")
+    return ordered
+
+
+@cli.command()
+@click.argument(
+    "config_file", required=False, default="", type=click.Path(exists=True, dir_okay=False)
+)
+@click.option(
+    "--after-gei",
+    "gei_log",
+    default="",
+    type=click.Path(exists=True, dir_okay=False),
+    help="GEI (gh gei) migration log: add a job for each repository it migrated successfully"
+)
+@click.option(
+    "--results-csv",
+    "results_csv_path",
+    default="",
+    help="Write one CSV row per job (source_org, source_repo, target_org, target_repo, "
+         "status), in the org/repo format of GEI tooling"
+)
+@click.option(
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source repositories "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target repositories "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set, or gh is logged in)"
+)
+@hostname_options
+@verbosity_options
+@click.option(
+    "--report",
+    "report_path",
+    default="",
+    help="Write a JSON report of all jobs' events to this file"
+)
+@click.option(
+    "--transcript",
+    "transcript_path",
+    default="",
+    help="Write a redacted Markdown narrative of all jobs to this file"
+)
+@click.option(
+    "--shared-first",
+    is_flag=True,
+    help="Run org-secret jobs and repos hosting reusable workflows/composite actions first"
+)
+@click.option(
+    "--dispatch-rate",
+    type=click.FloatRange(min=0),
+    default=0,
+    help="Push at most this many migration workflows per minute across the jobs, e.g. 10, "
+         "to spare the source's runners and secondary rate limits (0: no pacing)"
+)
+@timeout_options
+@cache_options
+@pushgateway_options
+@telemetry_options
+@notification_options
+@callback_options
+@event_stream_options
+@audit_options
+@state_encryption_options
+def pipeline(
+    config_file,
+    gei_log,
+    results_csv_path,
+    source_pat,
+    target_pat,
+    source_hostname,
+    target_hostname,
+    verbose,
+    quiet,
+    no_color,
+    report_path,
+    transcript_path,
+    shared_first,
+    dispatch_rate,
+    run_timeout,
+    api_timeout,
+    metadata_cache,
+    pushgateway_url,
+    pushgateway_job,
+    telemetry,
+    telemetry_url,
+    notify_webhook,
+    notify_report_url,
+    callback_url,
+    callback_secret,
+    events_format,
+    events_file,
+    audit_log_path,
+    state_passphrase,
+    state_age_recipients,
+    state_age_identity,
+):
+    """Run an ordered list of migration jobs defined in a YAML CONFIG_FILE.
+
+    Each job accepts the same options as `migrate` (e.g. org_to_org, source_repo,
+    target_repo, skip_envs) plus `name` and `continue_on_error`. A failing job
+    stops the pipeline unless it sets `continue_on_error: true`.
+
+    With --shared-first, jobs are reordered so organization secrets and
+    repositories hosting reusable workflows or composite actions are migrated
+    before the repositories consuming them; if one of those jobs fails, the
+    consumer jobs are not run.
+
+    With --after-gei, the repositories a GitHub Enterprise Importer log
+    records as migrated get a job each, after the config's jobs and with its
+    defaults; CONFIG_FILE is then optional.
+    """
+    events_path = _events_path(events_format, events_file)
+    logger = _make_logger(verbose, quiet, no_color, events_path)
+    if not config_file and not gei_log:
+        logger.error("Pass a pipeline CONFIG_FILE, --after-gei, or both")
+        raise SystemExit(1)
+
+    gei_jobs = []
+    if gei_log:
+        try:
+            migrations = load_gei_log(gei_log)
+        except (OSError, ValueError) as e:
+            logger.error(f"Cannot read GEI log {gei_log}: {e}")
+            raise SystemExit(1)
+        for migration in migrations:
+            if not migration.succeeded:
+                logger.warn(
+                    f"Skipping {migration.label}: GEI migration "
+                    f"{migration.state.lower() or 'did not finish'}"
+                )
+        gei_jobs = [migration.job() for migration in migrations if migration.succeeded]
+        logger.info(f"GEI log {gei_log}: {len(gei_jobs)} repository(ies) migrated")
+        if not gei_jobs:
+            logger.error(f"No repository in {gei_log} was migrated successfully")
+            raise SystemExit(1)
+    try:
+        jobs = load_pipeline(config_file, gei_jobs)
+    except (OSError, ValueError) as e:
+        logger.error(f"Invalid pipeline config {config_file or gei_log}: {e}")
+        raise SystemExit(1)
+
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_hostname, target_hostname
+    )
+    events = EventLog([source_pat_value, target_pat_value])
+    _stream_events(events_path, events, logger)
+    callbacks = _make_callbacks(callback_url, callback_secret, "pipeline", events, logger)
+
+    audit = _make_audit_log(audit_log_path, logger)
+    if shared_first:
+        source_api = GitHubClient(
+            source_pat_value, logger, audit=audit, side="source", host=_host(source_hostname)
+        )
+        jobs = _order_shared_first(jobs, source_api, events, logger)
+
+    # One cache for every job, so listings shared between jobs (e.g. organization
+    # secrets of a common target) are revalidated instead of re-read
+    cache = _make_cache(metadata_cache, logger)
+    # One throttle too, so the dispatch rate holds across jobs
+    throttle = DispatchThrottle(dispatch_rate, logger)
+
+    def run_job(job: PipelineJob) -> None:
+        config = job.build_config(source_pat_value, target_pat_value)
+        config.verbose = config.verbose or verbose
+        if "api_timeout" not in job.options:
+            config.api_timeout = api_timeout
+        config.state_passphrase = state_passphrase
+        if "state_age_recipients" not in job.options:
+            config.state_age_recipients = list(state_age_recipients)
+        if "state_age_identity" not in job.options:
+            config.state_age_identity = state_age_identity
+        if "source_host" not in job.options:
+            config.source_host = _host(source_hostname)
+        if "target_host" not in job.options:
+            config.target_host = _host(target_hostname)
+        Migrator(config, logger, events, cache, audit, throttle).run()
+
+    progress = Progress(len(jobs), "Jobs", logger)
+
+    def record_result(result: PipelineResult) -> None:
+        progress.advance(f"{result.name}: {result.status}")
+        if result.status == "failed":
+            events.emit("error", f"Job '{result.name}' failed: {result.error}", job=result.name)
+        elif result.status == "not_run":
+            reason = result.error or "pipeline stopped"
+            events.emit("skipped", f"Job '{result.name}' not run ({reason})", job=result.name)
+        callbacks.send(
+            "job_failed" if result.status == "failed" else "job_finished",
+            job=result.name, status=result.status, error=result.error
+        )
+
+    logger.info(f"Running pipeline with {len(jobs)} job(s) from {config_file or gei_log}")
+    callbacks.send("started", jobs=[job.name for job in jobs])
+    started_at = time.time()
+    deadline = Deadline(run_timeout)
+    try:
+        with deadline, progress:
+            results = run_pipeline(jobs, run_job, logger, on_result=record_result)
+    except KeyboardInterrupt:
+        reason = f"timed out after {run_timeout:g}s" if deadline.expired else "cancelled"
+        logger.error(f"Pipeline {reason}; remaining jobs were not run")
+        events.emit("error", f"Pipeline {reason}; remaining jobs were not run")
+        raise SystemExit(EXIT_TIMED_OUT if deadline.expired else EXIT_CANCELLED)
+    finally:
+        _save_cache(cache, logger)
+        _write_run_outputs(events, logger, report_path, transcript_path)
+    if results_csv_path:
+        _write_results_csv(results_csv_path, jobs, results, logger)
+
+    _push_run_metrics(
+        pushgateway_url, pushgateway_job,
+        sum(1 for result in results if result.status == "succeeded"),
+        sum(1 for result in results if result.status == "failed"),
+        started_at, logger
+    )
+    _send_telemetry(telemetry, telemetry_url, events, "pipeline", started_at, logger)
+    _notify(
+        notify_webhook, notify_report_url, report_path, f"pipeline {config_file or gei_log}",
+        sum(1 for result in results if result.status == "succeeded"),
+        sum(1 for result in results if result.status == "failed"),
+        started_at, events, logger,
+        details=[f"- {result.name}: {result.status}" for result in results]
+    )
+    failures = sum(1 for result in results if result.status == "failed")
+    callbacks.send(
+        "failed" if failures else "finished",
+        migrated=sum(1 for result in results if result.status == "succeeded"),
+        failures=failures, duration_seconds=round(time.time() - started_at, 3)
+    )
+
+    logger.summary("Pipeline summary:")
+    for result in results:
+        suffix = f" - {result.error}" if result.error else ""
+        logger.summary(f"  - {result.name}: {result.status}{suffix}")
+
+    if failures:
+        succeeded = any(result.status == "succeeded" for result in results)
+        raise SystemExit(EXIT_PARTIAL if succeeded else EXIT_FAILED)
+
+
+def _write_results_csv(
+    path: str, jobs: List[PipelineJob], results: List[PipelineResult], logger: Logger
+) -> None:
+    """Write one CSV row per pipeline job, with the org/repo columns of GEI tooling."""
+    rows = []
+    for job, result in zip(jobs, results):
+        options = job.options
+        rows.append({
+            "source_org": options["source_org"],
+            "source_repo": options["source_repo"],
+            "target_org": options["target_org"],
+            "target_repo": "" if options.get("org_to_org") else options["target_repo"],
+            "job": result.name,
+            "status": result.status,
+            "error": result.error,
+        })
+    try:
+        with open(path, "w", encoding="utf-8", newline="") as handle:
+            handle.write(results_csv(rows))
+        logger.info(f"Results written to {path}")
+    except OSError as e:
+        logger.error(f"Failed to write results to {path}: {e}")
+
+
+@cli.command()
+@click.option("--source-org", required=True, help="Source organization name")
+@click.option("--source-repo", default="", help="Source repository name (repository inventories)")
+@click.option("--target-org", required=True, help="Target organization name")
+@click.option(
+    "--target-repo",
+    default="",
+    help="Target repository name (defaults to source-repo)"
+)
+@click.option(
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option("--org-to-org", is_flag=True, help="Compare organization secrets instead")
+@click.option("--skip-envs", is_flag=True, help="Do not compare environment secrets")
+@click.option(
+    "--variables",
+    is_flag=True,
+    help="Also compare Actions variables, including their values"
+)
+@click.option(
+    "--skew-tolerance",
+    type=float,
+    default=2.0,
+    show_default=True,
+    help="Seconds within which source and target update times are considered equal"
+)
+@hostname_options
+@click.option(
+    "--exit-code",
+    "exit_code_on_diff",
+    is_flag=True,
+    help=f"Exit with {EXIT_VERIFICATION} when the target is not in sync with the source"
+)
+@click.option(
+    "--from-report",
+    "report_path",
+    type=click.Path(exists=True, dir_okay=False),
+    help="JSON report (--report) of the migration whose placeholders to check for drift "
+         "(default: the latest migration in its state file)"
+)
+@state_file_options
+@state_encryption_options
+@verbosity_options
+@audit_options
+def diff(
+    source_org,
+    source_repo,
+    target_org,
+    target_repo,
+    source_pat,
+    target_pat,
+    source_hostname,
+    target_hostname,
+    org_to_org,
+    skip_envs,
+    variables,
+    skew_tolerance,
+    exit_code_on_diff,
+    report_path,
+    state_dir,
+    state_file,
+    state_passphrase,
+    state_age_recipients,
+    state_age_identity,
+    verbose,
+    quiet,
+    no_color,
+    audit_log_path,
+):
+    """Compare secret inventories between source and target.
+
+    Prints secrets missing on the target, stale target copies (source updated
+    later), visibility mismatches and target-only secrets, so the delta can be
+    reviewed before running `migrate`. Placeholders the migration in
+    --from-report (or the latest one in the state file) created and its
+    workflow never replaced are listed too. With --variables, Actions
+    variables are compared as well; their values are readable, so value
+    mismatches are reported.
+    Exits with 0 even when differences exist, unless --exit-code is given.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    target_repo = target_repo or source_repo
+    if not org_to_org and not source_repo:
+        logger.error(
+            "source-repo is required to compare repository secrets (or use --org-to-org)"
+        )
+        raise SystemExit(1)
+    placeholders = []
+    if report_path:
+        try:
+            placeholders = load_report_placeholders(report_path)
+        except (OSError, ValueError) as e:
+            logger.error(f"Invalid report {report_path}: {e}")
+            raise SystemExit(1)
+    elif source_repo:
+        cipher = _state_cipher(state_passphrase, state_age_recipients, state_age_identity, logger)
+        recorded = _recorded_migration(
+            state_dir, state_file, source_org, source_repo, org_to_org, cipher, logger
+        )
+        if recorded is not None:
+            placeholders = recorded_placeholders(recorded, "" if org_to_org else target_repo)
+
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_hostname, target_hostname
+    )
+    audit = _make_audit_log(audit_log_path, logger)
+    source_api = GitHubClient(
+        source_pat_value, logger, audit=audit, side="source", host=_host(source_hostname)
+    )
+    target_api = GitHubClient(
+        target_pat_value, logger, audit=audit, side="target", host=_host(target_hostname)
+    )
+
+    try:
+        if org_to_org:
+            logger.info(f"Comparing organization secrets: {source_org} → {target_org}")
+            source_records = source_api.list_org_secret_records(source_org)
+            target_records = target_api.list_org_secret_records(target_org)
+        else:
+            logger.info(
+                f"Comparing repository secrets: "
+                f"{source_org}/{source_repo} → {target_org}/{target_repo}"
+            )
+            source_records = source_api.list_repo_secret_records(source_org, source_repo)
+            target_records = target_api.list_repo_secret_records(target_org, target_repo)
+            if not skip_envs:
+                source_records += source_api.list_environment_secret_records(
+                    source_org, source_repo
+                )
+                target_records += target_api.list_environment_secret_records(
+                    target_org, target_repo
+                )
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    source_records = [record for record in source_records if is_managed_secret(record.name)]
+    target_records = [record for record in target_records if is_managed_secret(record.name)]
+
+    # Update times are stamped by each host's clock; measure both so a skewed
+    # host (e.g. a GHES appliance) doesn't make secrets look stale or fresh
+    skews = []
+    for side, api in (("source", source_api), ("target", target_api)):
+        skew = api.get_clock_skew()
+        if skew is None:
+            logger.warn(f"Could not determine {side} server time; assuming its clock is in sync")
+            skew = timedelta(0)
+        else:
+            logger.debug(f"{side.capitalize()} clock skew: {format_skew(skew)}")
+        skews.append(skew)
+
+    result = diff_inventories(
+        source_records, target_records,
+        source_skew=skews[0], target_skew=skews[1],
+        tolerance=timedelta(seconds=skew_tolerance)
+    )
+    for line in format_diff(result):
+        click.echo(line)
+    in_sync = not result.has_changes
+
+    # A placeholder is newer than its source secret, so the comparison above
+    # counts it as in sync; it is stale if untouched since it was written
+    if placeholders:
+        stale = unreplaced_placeholders(
+            placeholders, target_records,
+            target_skew=skews[1], tolerance=timedelta(seconds=skew_tolerance)
+        )
+        for line in format_placeholder_drift(stale):
+            click.echo(line)
+        in_sync = in_sync and not stale
+
+    if variables:
+        try:
+            if org_to_org:
+                source_variables = source_api.list_org_variable_records(source_org)
+                target_variables = target_api.list_org_variable_records(target_org)
+            else:
+                source_variables = source_api.list_repo_variable_records(
+                    source_org, source_repo, include_envs=not skip_envs
+                )
+                target_variables = target_api.list_repo_variable_records(
+                    target_org, target_repo, include_envs=not skip_envs
+                )
+        except RuntimeError as e:
+            _report_error(logger, e)
+            raise SystemExit(1)
+        variable_result = diff_variables(source_variables, target_variables)
+        for line in format_variable_diff(variable_result):
+            click.echo(line)
+        in_sync = in_sync and not variable_result.has_changes
+
+    if in_sync:
+        logger.summary("Target is in sync with source")
+    elif exit_code_on_diff:
+        raise SystemExit(EXIT_VERIFICATION)
+
+
+@cli.command()
+@click.option("--org", required=True, help="Organization owning the repositories")
+@click.option(
+    "--repo",
+    "repos",
+    multiple=True,
+    help="Repository to scan (repeatable; every non-archived repository of --org by default)"
+)
+@repo_filter_options
+@click.option(
+    "--skip-org-secrets",
+    is_flag=True,
+    help="Do not list organization secrets (needs organization admin access)"
+)
+@click.option(
+    "--format",
+    "output_format",
+    type=click.Choice(USAGE_FORMATS),
+    default="text",
+    show_default=True,
+    help="Output format"
+)
+@click.option(
+    "--output",
+    "-o",
+    "output_path",
+    default="",
+    help="Write the report to this file instead of standard output"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@hostname_option
+@verbosity_options
+@audit_options
+def usage(
+    org, repos, exclude_forks, topics, visibilities, languages, name_patterns, skip_org_secrets,
+    output_format, output_path, pat, hostname, verbose, quiet, no_color, audit_log_path
+):
+    """Show which workflows consume which secrets.
+
+    Scans the workflow files of the default branch for secrets.NAME
+    references and matches them against repository, environment and
+    organization secrets, listing unused secrets and references to secrets
+    that do not exist. Useful to decide what to migrate or retire.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    if not output_path:
+        # The report owns standard output
+        logger.use_stderr()
+    repo_filter = RepoFilter(exclude_forks, topics, visibilities, languages, name_patterns)
+    if repos and not repo_filter.is_empty:
+        logger.error("Repository filters only apply to org-wide discovery; drop them or --repo")
+        raise SystemExit(1)
+    pat_value = _resolve_pat(pat, "source", logger, hostname)
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="source", host=_host(hostname))
+
+    try:
+        repos = list(dict.fromkeys(repos)) or api.list_org_repositories(org, repo_filter)
+        scanned = {}
+        for repo in repos:
+            logger.info(f"Scanning {org}/{repo}...")
+            records = api.list_repo_secret_records(org, repo)
+            records += api.list_environment_secret_records(org, repo)
+            records = [record for record in records if is_managed_secret(record.name)]
+            files = api.list_automation_files(org, repo, include_actions=False)
+            scanned[repo] = (records, scan_workflows(files))
+        org_secrets = [] if skip_org_secrets else managed_secrets(api.list_org_secrets(org))
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    report = build_usage_report(org, scanned, org_secrets)
+    if output_format == "json":
+        document = json.dumps(report, indent=2) + "\n"
+    elif output_format == "csv":
+        document = usage_csv(report)
+    else:
+        document = "".join(f"{line}\n" for line in format_usage_report(report))
+    if not output_path:
+        click.echo(document, nl=False)
+    else:
+        try:
+            with open(output_path, "w", encoding="utf-8") as handle:
+                handle.write(document)
+        except OSError as e:
+            logger.error(f"Failed to write {output_path}: {e}")
+            raise SystemExit(1)
+
+    secrets = [secret for entry in report["repositories"] for secret in entry["secrets"]]
+    secrets += report["organization_secrets"]
+    unused = [
+        secret for secret in secrets if not secret.get("workflows") and not secret.get("consumers")
+    ]
+    logger.summary(
+        f"Scanned {len(repos)} repository(ies): {len(secrets)} secret(s), {len(unused)} unused"
+    )
+
+
+@cli.command()
+@click.option("--org", required=True, help="Organization to inventory")
+@click.option(
+    "--repo",
+    "repos",
+    multiple=True,
+    help="Repository to include (repeatable; every non-archived repository of --org by default)"
+)
+@repo_filter_options
+@click.option(
+    "--skip-org",
+    is_flag=True,
+    help="Leave out organization secrets and variables (they need organization admin access)"
+)
+@click.option(
+    "--skip-variables",
+    is_flag=True,
+    help="Inventory secrets only"
+)
+@click.option(
+    "--format",
+    "output_format",
+    type=click.Choice(INVENTORY_FORMATS),
+    default="json",
+    show_default=True,
+    help="Output format"
+)
+@click.option(
+    "--output",
+    "-o",
+    "output_path",
+    default="",
+    help="Write the inventory to this file instead of standard output"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@hostname_option
+@verbosity_options
+@audit_options
+def inventory(
+    org, repos, exclude_forks, topics, visibilities, languages, name_patterns, skip_org,
+    skip_variables, output_format, output_path, pat, hostname, verbose, quiet, no_color,
+    audit_log_path
+):
+    """Snapshot every Actions secret and variable of an organization.
+
+    Records organization, repository and environment secrets and variables
+    with their organization visibility and selected repositories and their
+    last update, never values. The JSON or CSV file is an audit artifact and
+    the input of `plan --inventory`, which plans the migration of every
+    repository it lists.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    if not output_path:
+        # The inventory owns standard output
+        logger.use_stderr()
+    repo_filter = RepoFilter(exclude_forks, topics, visibilities, languages, name_patterns)
+    if repos and not repo_filter.is_empty:
+        logger.error("Repository filters only apply to org-wide discovery; drop them or --repo")
+        raise SystemExit(1)
+    pat_value = _resolve_pat(pat, "source", logger, hostname)
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="source", host=_host(hostname))
+
+    try:
+        repos = list(dict.fromkeys(repos)) or api.list_org_repositories(org, repo_filter)
+        scanned = {}
+        for repo in repos:
+            logger.info(f"Inventorying {org}/{repo}...")
+            secrets = api.list_repo_secret_records(org, repo)
+            secrets += api.list_environment_secret_records(org, repo)
+            variables = [] if skip_variables else api.list_repo_variable_records(org, repo)
+            scanned[repo] = (secrets, variables)
+        org_secrets, org_variables, scopes = [], [], {}
+        if not skip_org:
+            org_secrets = api.list_org_secret_records(org)
+            org_variables = [] if skip_variables else api.list_org_variable_records(org)
+            for record in org_secrets:
+                if record.visibility == "selected":
+                    scopes[("secret", record.name)] = api.get_org_secret_scope(org, record.name)
+            for record in org_variables:
+                if record.visibility == "selected":
+                    scopes[("variable", record.name)] = api.get_org_variable_scope(
+                        org, record.name
+                    )
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    document = build_inventory(org, scanned, org_secrets, org_variables, scopes)
+    if output_format == "csv":
+        text = inventory_csv(document)
+    else:
+        text = json.dumps(document, indent=2) + "\n"
+    if not output_path:
+        click.echo(text, nl=False)
+    else:
+        try:
+            with open(output_path, "w", encoding="utf-8", newline="") as handle:
+                handle.write(text)
+        except OSError as e:
+            logger.error(f"Failed to write {output_path}: {e}")
+            raise SystemExit(1)
+    logger.summary(format_inventory_summary(document))
+
+
+@cli.command()
+@click.option("--source-org", required=True, help="Source organization name")
+@click.option(
+    "--source-repo",
+    required=True,
+    help="Source repository the migration workflow runs in"
+)
+@click.option("--target-org", required=True, help="Target organization name")
+@click.option("--target-repo", default="", help="Target repository name (default: source repo)")
+@click.option("--org-to-org", is_flag=True, help="Report an organization secrets migration")
+@click.option(
+    "--branch-name",
+    default="",
+    help="Migration branch (default: migrate-secrets, or migrate-org-secrets with --org-to-org)"
+)
+@click.option(
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option(
+    "--format",
+    "output_format",
+    type=click.Choice(STATUS_FORMATS),
+    default="text",
+    show_default=True,
+    help="Output format"
+)
+@state_file_options
+@state_encryption_options
+@hostname_options
+@verbosity_options
+@audit_options
+def status(
+    source_org,
+    source_repo,
+    target_org,
+    target_repo,
+    org_to_org,
+    branch_name,
+    source_pat,
+    target_pat,
+    source_hostname,
+    target_hostname,
+    output_format,
+    state_dir,
+    state_file,
+    state_passphrase,
+    state_age_recipients,
+    state_age_identity,
+    verbose,
+    quiet,
+    no_color,
+    audit_log_path,
+):
+    """Show where a migration stands.
+
+    Reports whether the migration branch and workflow file exist in the
+    source repository, the state of the latest workflow run, leftover
+    temporary secrets, and which secrets the target currently holds. The
+    last migration recorded in the state file adds its per-secret outcomes.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    # The report owns standard output
+    logger.use_stderr()
+    cipher = _state_cipher(state_passphrase, state_age_recipients, state_age_identity, logger)
+    recorded = _recorded_migration(
+        state_dir, state_file, source_org, source_repo, org_to_org, cipher, logger
+    )
+    workflow_file = "migrate-org-secrets.yml" if org_to_org else "migrate-secrets.yml"
+    workflow_path = f".github/workflows/{workflow_file}"
+    branch = branch_name or _recorded_branch(recorded) or (
+        "migrate-org-secrets" if org_to_org else "migrate-secrets"
+    )
+    target_repo = target_repo or source_repo
+
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_hostname, target_hostname
+    )
+    audit = _make_audit_log(audit_log_path, logger)
+    source_api = GitHubClient(
+        source_pat_value, logger, audit=audit, side="source", host=_host(source_hostname)
+    )
+    target_api = GitHubClient(
+        target_pat_value, logger, audit=audit, side="target", host=_host(target_hostname)
+    )
+
+    try:
+        branch_present = source_api.branch_exists(source_org, source_repo, branch)
+        workflow_present = branch_present and source_api.file_exists(
+            source_org, source_repo, workflow_path, branch
+        )
+        temporary = [
+            name for name in source_api.list_repo_secrets(source_org, source_repo)
+            if name in TEMPORARY_SECRETS
+        ]
+        run = source_api.get_latest_workflow_run(source_org, source_repo, workflow_file, branch)
+        if org_to_org:
+            target = target_org
+            org_secrets = managed_secrets(target_api.list_org_secrets(target_org))
+            labels = [f"org:{name}" for name in org_secrets]
+        else:
+            target = f"{target_org}/{target_repo}"
+            records = target_api.list_repo_secret_records(target_org, target_repo)
+            records += target_api.list_environment_secret_records(target_org, target_repo)
+            labels = [
+                record.label for record in sorted(records, key=lambda record: record.key)
+                if is_managed_secret(record.name)
+            ]
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    result = MigrationStatus(
+        f"{source_org}/{source_repo}", branch, workflow_path, target,
+        branch_exists=branch_present, workflow_exists=workflow_present,
+        temporary_secrets=temporary, run=run, target_secrets=labels, recorded=recorded
+    )
+    if output_format == "json":
+        click.echo(json.dumps(result.to_dict(), indent=2))
+    else:
+        for line in format_status(result):
+            click.echo(line)
 
 
-@click.command()
+@cli.command("scan-logs")
+@click.option("--source-org", required=True, help="Source organization name")
 @click.option(
-    "--source-org",
+    "--source-repo",
     required=True,
-    help="Source organization name"
+    help="Source repository the migration workflow runs in"
+)
+@click.option(
+    "--run-id",
+    "run_ids",
+    type=int,
+    multiple=True,
+    help="Workflow run to scan (repeatable; default: the runs the last recorded migration "
+         "triggered, else the latest run of the migration workflow)"
+)
+@click.option("--org-to-org", is_flag=True, help="Scan an organization secrets migration")
+@click.option(
+    "--branch-name",
+    default="",
+    help="Migration branch (default: migrate-secrets, or migrate-org-secrets with --org-to-org)"
+)
+@click.option(
+    "--allow",
+    "allowed",
+    multiple=True,
+    metavar="FINGERPRINT",
+    help="Fingerprint of a finding checked and known to be harmless (repeatable)"
+)
+@click.option(
+    "--format",
+    "output_format",
+    type=click.Choice(SCAN_FORMATS),
+    default="text",
+    show_default=True,
+    help="Output format"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
 )
+@state_file_options
+@state_encryption_options
+@hostname_option
+@verbosity_options
+@audit_options
+def scan_logs(
+    source_org, source_repo, run_ids, org_to_org, branch_name, allowed, output_format, pat,
+    state_dir, state_file, state_passphrase, state_age_recipients, state_age_identity,
+    hostname, verbose, quiet, no_color, audit_log_path
+):
+    """Scan migration workflow run logs for plaintext secrets.
+
+    Downloads the log of every job of the runs and looks for values that
+    escaped GitHub's masking: well-known credential formats (GitHub, AWS,
+    Slack and Google tokens, private keys, JWTs), credential-like
+    assignments and long high-entropy strings. Findings show a short prefix
+    and a fingerprint, never the value. Exits with status 1 when anything
+    is found, so the secrets involved can be rotated.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    # The report owns standard output
+    logger.use_stderr()
+    pat_value = _resolve_pat(pat, "source", logger, hostname)
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="source", host=_host(hostname))
+
+    try:
+        run_ids = list(dict.fromkeys(run_ids))
+        if not run_ids:
+            cipher = _state_cipher(
+                state_passphrase, state_age_recipients, state_age_identity, logger
+            )
+            recorded = _recorded_migration(
+                state_dir, state_file, source_org, source_repo, org_to_org, cipher, logger
+            )
+            if recorded is not None:
+                run_ids = [
+                    int(resource["run_id"]) for resource in recorded.resources_of("workflow_run")
+                ]
+            if not run_ids:
+                workflow_file = "migrate-org-secrets.yml" if org_to_org else "migrate-secrets.yml"
+                branch = branch_name or _recorded_branch(recorded) or (
+                    "migrate-org-secrets" if org_to_org else "migrate-secrets"
+                )
+                run = api.get_latest_workflow_run(source_org, source_repo, workflow_file, branch)
+                run_ids = [run.run_id] if run else []
+        if not run_ids:
+            logger.error(f"No migration workflow run found in {source_org}/{source_repo}")
+            raise SystemExit(1)
+        findings = []
+        for run_id in run_ids:
+            logger.info(f"Scanning the logs of workflow run {run_id}...")
+            logs = api.download_job_logs(source_org, source_repo, run_id)
+            findings += scan_run_logs(
+                [(f"run {run_id} {job}", text) for job, text in logs], allowed
+            )
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    if output_format == "json":
+        document = {
+            "repository": f"{source_org}/{source_repo}",
+            "runs": run_ids,
+            "findings": [finding.to_dict() for finding in findings],
+        }
+        click.echo(json.dumps(document, indent=2))
+    else:
+        for line in format_findings(findings):
+            click.echo(line)
+    if findings:
+        logger.error(
+            f"{len(findings)} possible plaintext secret(s) in the logs of {len(run_ids)} run(s)"
+        )
+        raise SystemExit(1)
+    logger.summary(f"No plaintext secrets found in the logs of {len(run_ids)} run(s)")
+
+
+@cli.command()
+@click.option("--source-org", required=True, help="Source organization name")
 @click.option(
     "--source-repo",
     required=True,
-    help="Source repository name (required for both repo-to-repo and org-to-org migrations)"
+    help="Source repository the migration workflow runs in"
 )
+@click.option("--target-org", required=True, help="Target organization name")
+@click.option("--target-repo", default="", help="Target repository name (default: source repo)")
+@click.option("--org-to-org", is_flag=True, help="Cancel an organization secrets migration")
 @click.option(
-    "--target-org",
-    required=True,
-    help="Target organization name"
+    "--branch-name",
+    default="",
+    help="Migration branch (default: migrate-secrets, or migrate-org-secrets with --org-to-org)"
 )
 @click.option(
-    "--target-repo",
-    required=False,
+    "--from-report",
+    "report_path",
     default="",
-    help="Target repository name (required for repo-to-repo migration, optional for org-to-org)"
+    help="JSON report of the migration run (--report), naming the placeholders it created"
 )
 @click.option(
     "--source-pat",
     default="",
-    help="Personal Access Token for source repository (optional if GITHUB_TOKEN is set)"
+    help="Personal Access Token for source "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
 )
 @click.option(
     "--target-pat",
     default="",
-    help="Personal Access Token for target repository (optional if GITHUB_TOKEN is set)"
+    help="Personal Access Token for target "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set)"
 )
+@click.option("--yes", is_flag=True, help="Cancel without asking for confirmation")
+@state_file_options
+@state_encryption_options
+@hostname_options
+@verbosity_options
+@audit_options
+def cancel(
+    source_org,
+    source_repo,
+    target_org,
+    target_repo,
+    org_to_org,
+    branch_name,
+    report_path,
+    source_pat,
+    target_pat,
+    source_hostname,
+    target_hostname,
+    yes,
+    state_dir,
+    state_file,
+    state_passphrase,
+    state_age_recipients,
+    state_age_identity,
+    verbose,
+    quiet,
+    no_color,
+    audit_log_path,
+):
+    """Abort a migration and clean up after it.
+
+    Cancels the workflow run if it is still queued or in progress, deletes
+    the migration branch (and the workflow file on it) and the temporary
+    token secrets from the source repository. Target secrets the migration
+    created as placeholders (read from its state file, or from --from-report)
+    and the workflow never overwrote are deleted too.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    cipher = _state_cipher(state_passphrase, state_age_recipients, state_age_identity, logger)
+    recorded = _recorded_migration(
+        state_dir, state_file, source_org, source_repo, org_to_org, cipher, logger
+    )
+    workflow_file = "migrate-org-secrets.yml" if org_to_org else "migrate-secrets.yml"
+    branch = branch_name or _recorded_branch(recorded) or (
+        "migrate-org-secrets" if org_to_org else "migrate-secrets"
+    )
+    target_repo = target_repo or source_repo
+    source = f"{source_org}/{source_repo}"
+    target = target_org if org_to_org else f"{target_org}/{target_repo}"
+
+    placeholders = []
+    if report_path:
+        try:
+            placeholders = load_report_placeholders(report_path)
+        except (OSError, ValueError) as e:
+            logger.error(f"Cannot read migration report {report_path}: {e}")
+            raise SystemExit(1)
+    elif recorded is not None:
+        placeholders = recorded_placeholders(recorded, "" if org_to_org else target_repo)
+
+    if not yes and not click.confirm(f"Cancel the migration from {source} to {target}?"):
+        logger.info("Aborted: nothing changed")
+        return
+
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_hostname, target_hostname
+    )
+    audit = _make_audit_log(audit_log_path, logger)
+    source_api = GitHubClient(
+        source_pat_value, logger, audit=audit, side="source", host=_host(source_hostname)
+    )
+    target_api = GitHubClient(
+        target_pat_value, logger, audit=audit, side="target", host=_host(target_hostname)
+    )
+
+    failures = 0
+    try:
+        run = source_api.get_latest_workflow_run(source_org, source_repo, workflow_file, branch)
+        if run is not None and run.status != "completed":
+            source_api.cancel_workflow_run(source_org, source_repo, run.run_id)
+            logger.info(f"Cancellation requested for workflow run {run.run_id}; waiting...")
+            status = run.status
+            for _ in range(CANCEL_WAIT_POLLS):
+                status, _conclusion = source_api.get_workflow_run_state(
+                    source_org, source_repo, run.run_id
+                )
+                if status == "completed":
+                    break
+                time.sleep(RUN_POLL_SECONDS)
+            if status == "completed":
+                logger.success(f"Cancelled workflow run {run.run_id}")
+            else:
+                logger.warn(f"Workflow run {run.run_id} is still {status.replace('_', ' ')}")
+        elif run is not None:
+            logger.info(f"Workflow run {run.run_id} already finished ({run.conclusion})")
+
+        if source_api.branch_exists(source_org, source_repo, branch):
+            source_api.delete_branch(source_org, source_repo, branch)
+            if source_api.branch_exists(source_org, source_repo, branch):
+                failures += 1
+                logger.error(f"Could not delete migration branch '{branch}' from {source}")
+            else:
+                logger.success(f"Deleted migration branch '{branch}' and its workflow file")
+
+        for name in source_api.list_repo_secrets(source_org, source_repo):
+            if name not in TEMPORARY_SECRETS:
+                continue
+            try:
+                source_api.delete_secret(source_org, source_repo, name)
+                logger.success(f"Deleted temporary secret {name} from {source}")
+            except RuntimeError as e:
+                failures += 1
+                _report_error(logger, e)
+
+        if not report_path and recorded is None:
+            logger.info(
+                "Placeholder secrets on the target were left alone: no migration state file "
+                "was found; pass --from-report with the migration's --report file to remove them"
+            )
+        elif placeholders:
+            if org_to_org:
+                records = target_api.list_org_secret_records(target_org)
+            else:
+                records = target_api.list_repo_secret_records(target_org, target_repo)
+                records += target_api.list_environment_secret_records(target_org, target_repo)
+            started = datetime.fromisoformat(run.created_at) if run and run.created_at else None
+            stale = placeholder_only(placeholders, records, started)
+            for record in stale:
+                try:
+                    if record.level == "org":
+                        target_api.delete_org_secret(target_org, record.name)
+                    elif record.level == "env":
+                        target_api.delete_environment_secret(
+                            target_org, target_repo, record.environment, record.name
+                        )
+                    else:
+                        target_api.delete_secret(target_org, target_repo, record.name)
+                    logger.success(f"Deleted placeholder {record.label} from {target}")
+                except RuntimeError as e:
+                    failures += 1
+                    _report_error(logger, e)
+            kept = len(placeholders) - len(stale)
+            if kept:
+                logger.info(f"{kept} placeholder(s) kept: already overwritten or no longer present")
+    except RuntimeError as e:
+        _report_error(logger, e)
+        raise SystemExit(1)
+
+    if failures:
+        logger.error(f"Cancellation incomplete: {failures} cleanup step(s) failed")
+        raise SystemExit(1)
+    logger.summary(f"Migration from {source} to {target} cancelled and cleaned up")
+
+
+@cli.command("deploy-keys")
+@click.option("--source-org", required=True, help="Source organization name")
+@click.option("--source-repo", required=True, help="Source repository whose deploy keys to read")
+@click.option("--target-org", required=True, help="Target organization name")
+@click.option("--target-repo", default="", help="Target repository name (default: source repo)")
 @click.option(
-    "--verbose",
-    is_flag=True,
-    help="Enable verbose logging"
+    "--secret-prefix",
+    default=DEFAULT_DEPLOY_KEY_PREFIX,
+    show_default=True,
+    help="Prefix of the target secrets holding the new private keys, followed by the "
+         "key's title in upper case"
 )
 @click.option(
-    "--skip-envs",
-    is_flag=True,
-    help="Skip environment recreation (by default environments are recreated)"
+    "-o",
+    "--report",
+    "report_path",
+    default="",
+    help="Write the mapping of source keys to new keys and secrets to this JSON file"
 )
+@click.option("--dry-run", is_flag=True, help="Show the plan without generating or installing keys")
+@click.option("--yes", is_flag=True, help="Install the new keys without asking for confirmation")
 @click.option(
-    "--org-to-org",
-    is_flag=True,
-    help="Migrate organization secrets only (ignores repo and environment secrets)"
+    "--source-pat",
+    default="",
+    help="Personal Access Token for source "
+         "(optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
 )
-def migrate(
+@click.option(
+    "--target-pat",
+    default="",
+    help="Personal Access Token for target "
+         "(optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@hostname_options
+@verbosity_options
+@audit_options
+def deploy_keys(
     source_org,
     source_repo,
     target_org,
     target_repo,
+    secret_prefix,
+    report_path,
+    dry_run,
+    yes,
     source_pat,
     target_pat,
+    source_hostname,
+    target_hostname,
     verbose,
-    skip_envs,
-    org_to_org,
+    quiet,
+    no_color,
+    audit_log_path,
 ):
-    """Migrate GitHub secrets from one organization/repository to another.
+    """Regenerate the source repository's deploy keys on the target repository.
 
-    Two modes of operation:
-    - Repository to Repository: Migrates repo and environment secrets
-    - Organization to Organization: Migrates only org secrets (--org-to-org flag)
+    Private keys of deploy keys cannot be read from the API, so each source
+    key gets a fresh Ed25519 keypair (generated locally with ssh-keygen):
+    the public key is installed on the target with the same title and
+    read-only or read-write access, and the private key is stored as a
+    target repository secret. Keys whose title the target already uses are
+    skipped. The mapping of old keys to new keys and secrets is printed
+    (and written with --report) so whatever cloned with an old key can be
+    switched over; source keys are left in place.
     """
-    logger = Logger(verbose=verbose)
+    logger = _make_logger(verbose, quiet, no_color)
+    target_repo = target_repo or source_repo
+    target = f"{target_org}/{target_repo}"
+    source_pat_value, target_pat_value = _resolve_pats(
+        source_pat, target_pat, logger, source_hostname, target_hostname
+    )
+    audit = _make_audit_log(audit_log_path, logger)
+    source_api = GitHubClient(
+        source_pat_value, logger, audit=audit, side="source", host=_host(source_hostname)
+    )
+    target_api = GitHubClient(
+        target_pat_value, logger, audit=audit, side="target", host=_host(target_hostname)
+    )
 
-    # Validate source-repo is always provided (required for workflow execution)
-    if not source_repo:
-        logger.error("source-repo is required for both repo-to-repo and org-to-org migrations")
-        logger.error("The migration workflow must run in a source repository")
+    try:
+        source_keys = source_api.list_deploy_keys(source_org, source_repo)
+        target_keys = target_api.list_deploy_keys(target_org, target_repo)
+        plans = plan_deploy_keys(
+            [DeployKey.from_api(key) for key in source_keys],
+            [DeployKey.from_api(key) for key in target_keys],
+            target_api.list_repo_secrets(target_org, target_repo),
+            secret_prefix,
+        )
+    except RuntimeError as e:
+        _report_error(logger, e)
         raise SystemExit(1)
+    if not plans:
+        logger.success(f"{source_org}/{source_repo} has no deploy keys")
+        return
+    for plan in plans:
+        logger.info(plan.describe())
+    pending = [plan for plan in plans if plan.action == "create"]
+    if dry_run or not pending:
+        logger.summary(f"{len(pending)} deploy key(s) to regenerate on {target}")
+        return
+    if not yes and not click.confirm(
+        f"Install {len(pending)} new deploy key(s) on {target} and store their private keys "
+        "as secrets?"
+    ):
+        logger.info("Aborted: nothing changed")
+        return
 
-    # Validate modes
-    if org_to_org:
-        # For org-to-org: source-repo required, target-repo optional (defaults to source-repo name)
-        logger.info("Organization-to-Organization mode: org secrets only")
-        logger.info(f"Source repository (for workflow): {source_repo}")
-        logger.info(f"Target repository: {target_repo if target_repo else source_repo}")
-    else:
-        # For repo-to-repo: both repos required
-        if not target_repo:
-            logger.error("target-repo is required for repo-to-repo migration")
-            logger.error("(or use --org-to-org flag for organization-to-organization migration)")
-            raise SystemExit(1)
-        logger.info("Repository-to-Repository mode")
-        logger.info(f"Source: {source_org}/{source_repo}")
-        logger.info(f"Target: {target_org}/{target_repo}")
+    try:
+        generator = KeypairGenerator()
+    except ValueError as e:
+        logger.error(str(e))
+        raise SystemExit(1)
+    for plan in pending:
+        try:
+            private_key, public_key = generator.generate(f"{target} {plan.key.title}")
+            # The secret first: a key installed without its private half could not be used
+            target_api.create_repo_secret(target_org, target_repo, plan.secret, private_key)
+            installed = target_api.add_deploy_key(
+                target_org, target_repo, plan.key.title, public_key, plan.key.read_only
+            )
+            plan.target_key_id = int(installed["id"])
+            plan.target_fingerprint = DeployKey.from_api(installed).fingerprint
+            logger.success(plan.describe())
+        except (RuntimeError, ValueError, KeyError) as e:
+            plan.error = str(e)
+            logger.error(plan.describe())
 
-    # Check for GITHUB_TOKEN environment variable
-    github_token = os.getenv("GITHUB_TOKEN")
-    if github_token:
-        logger.info(
-            "GITHUB_TOKEN environment variable detected, "
-            "using it for both source and target authentication"
-        )
-        source_pat_value = github_token
-        target_pat_value = github_token
-    else:
-        source_pat_value = source_pat
-        target_pat_value = target_pat
+    if report_path:
+        document = {
+            "source": f"{source_org}/{source_repo}",
+            "target": target,
+            "keys": [plan.to_dict() for plan in plans],
+        }
+        try:
+            with open(report_path, "w", encoding="utf-8") as handle:
+                json.dump(document, handle, indent=2)
+        except OSError as e:
+            logger.error(f"Failed to write deploy key report {report_path}: {e}")
+    failed = sum(plan.status == "failed" for plan in plans)
+    logger.summary(
+        f"Regenerated {len(pending) - failed} deploy key(s) on {target}"
+        + (f", {failed} failed" if failed else "")
+        + "; switch whatever used the old keys to the new secrets"
+    )
+    if failed:
+        raise SystemExit(1)
 
-    # Validate we have PATs for both
-    if not source_pat_value or not target_pat_value:
-        logger.error(
-            "source-pat and target-pat are required "
-            "(or set GITHUB_TOKEN environment variable)"
-        )
+
+@cli.command("token-template")
+@click.option(
+    "--role",
+    type=click.Choice(TOKEN_ROLES),
+    required=True,
+    help="Token to create: 'source' (extract side) or 'target' (write side)"
+)
+@click.option(
+    "--owner",
+    required=True,
+    help="Organization (or user) that owns the source or target repositories"
+)
+@click.option(
+    "--org-to-org",
+    is_flag=True,
+    help="Token for an organization-to-organization migration"
+)
+@click.option(
+    "--expires-in",
+    type=int,
+    default=DEFAULT_EXPIRES_IN_DAYS,
+    show_default=True,
+    help="Token lifetime in days"
+)
+@click.option("--open", "open_browser", is_flag=True, help="Open the URL in the default browser")
+def token_template(role, owner, org_to_org, expires_in, open_browser):
+    """Print the fine-grained PAT creation URL with the minimum permissions pre-filled.
+
+    Repository access cannot be pre-filled: select only the repositories the
+    migration touches, and revoke the token once the migration is done.
+    """
+    logger = Logger()
+    try:
+        url = token_template_url(role, owner, org_to_org, expires_in)
+    except ValueError as e:
+        logger.error(str(e))
         raise SystemExit(1)
 
+    click.echo(f"Minimum permissions for the {role} token:")
+    for name, access, reason in describe_permissions(role, org_to_org):
+        click.echo(f"  - {name}: {access} ({reason})")
+    click.echo("")
+    click.echo(url)
+
+    if open_browser and not webbrowser.open(url):
+        logger.warn("Could not open a browser; copy the URL above instead")
+
+
+@cli.command()
+@click.option("--org", required=True, help="Organization owning the secrets")
+@click.option(
+    "--repo",
+    default="",
+    help="Repository owning the secrets (deletes organization secrets when omitted)"
+)
+@click.option(
+    "--namespace",
+    "namespaces",
+    type=click.Choice(SECRET_NAMESPACES),
+    multiple=True,
+    default=("actions",),
+    show_default=True,
+    help="Secret namespace to delete from (repeatable)"
+)
+@click.option(
+    "--filter",
+    "patterns",
+    multiple=True,
+    required=True,
+    help="Secret name or glob pattern to delete, e.g. 'OLD_*' (repeatable)"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option("--dry-run", is_flag=True, help="List matching secrets without deleting them")
+@click.option("--yes", is_flag=True, help="Delete without asking for confirmation")
+@click.option(
+    "--report",
+    "report_path",
+    default="",
+    help="Write a JSON report of the deletions to this file"
+)
+@hostname_option
+@verbosity_options
+@event_stream_options
+@audit_options
+def delete(
+    org, repo, namespaces, patterns, pat, hostname, dry_run, yes, report_path, verbose, quiet,
+    no_color, events_format, events_file, audit_log_path
+):
+    """Bulk-delete secrets matching a filter, e.g. to decommission a source after cutover.
+
+    Secrets reserved for the migrator are never deleted. Dependabot or
+    Codespaces namespaces whose API is disabled are skipped with a warning.
+    """
+    events_path = _events_path(events_format, events_file)
+    logger = _make_logger(verbose, quiet, no_color, events_path)
+    pat_value = _resolve_pat(pat, "source", logger, hostname)
+
+    namespaces = list(dict.fromkeys(namespaces))
+    owner = f"{org}/{repo}" if repo else org
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="source", host=_host(hostname))
+    events = EventLog([pat_value])
+    _stream_events(events_path, events, logger)
+    events.emit(
+        "run_started",
+        f"Deleting {', '.join(namespaces)} secrets in {owner} matching {', '.join(patterns)}",
+        namespaces=namespaces, owner=owner, patterns=list(patterns), dry_run=dry_run
+    )
+
+    failures = 0
     try:
-        config = MigrationConfig(
-            source_org=source_org,
-            source_repo=source_repo,
-            target_org=target_org,
-            target_repo=target_repo,
-            source_pat=source_pat_value,
-            target_pat=target_pat_value,
-            verbose=verbose,
-            skip_envs=skip_envs,
-            org_to_org=org_to_org
+        matched = []
+        for namespace in namespaces:
+            try:
+                names = api.list_namespace_secrets(namespace, org, repo)
+            except NamespaceUnavailableError as e:
+                logger.warn(f"Skipping {namespace} secrets in {owner}: {e.reason}")
+                events.emit(
+                    "skipped", f"{namespace} namespace skipped: {e.reason}",
+                    namespace=namespace, reason=e.reason
+                )
+                continue
+            except RuntimeError as e:
+                _report_error(logger, e)
+                events.emit("run_failed", str(e))
+                raise SystemExit(1)
+            matched.extend((namespace, name) for name in select_matching(names, patterns))
+
+        if not matched:
+            logger.summary(f"No {'/'.join(namespaces)} secrets in {owner} match the filter")
+            events.emit("decision", "Nothing to delete: no secrets match the filter")
+            events.emit("run_completed", "Delete completed", deleted=0, failures=0)
+            return
+
+        logger.info(f"{len(matched)} secret(s) in {owner} match the filter:")
+        for namespace, name in matched:
+            logger.info(f"  - {namespace}: {name}")
+
+        if dry_run:
+            for namespace, name in matched:
+                events.emit(
+                    "decision", f"Would delete {namespace} secret '{name}' (dry run)",
+                    secret=name, namespace=namespace
+                )
+            logger.summary(f"Dry run: {len(matched)} secret(s) would be deleted, nothing deleted")
+            events.emit("run_completed", "Dry run completed", deleted=0, failures=0)
+            return
+
+        if not yes and not click.confirm(f"Delete {len(matched)} secret(s) from {owner}?"):
+            logger.info("Aborted: nothing deleted")
+            events.emit("decision", "Deletion aborted at confirmation prompt")
+            return
+
+        unavailable = set()
+        skipped = 0
+        with Progress(len(matched), "Deleting", logger) as progress:
+            for namespace, name in matched:
+                if namespace in unavailable:
+                    skipped += 1
+                    progress.advance(name)
+                    continue
+                try:
+                    api.delete_namespace_secret(namespace, org, repo, name)
+                    logger.success(f"Deleted {namespace} secret {name}")
+                    events.emit(
+                        "deleted", f"Deleted {namespace} secret '{name}' from {owner}",
+                        secret=name, namespace=namespace
+                    )
+                except NamespaceUnavailableError as e:
+                    unavailable.add(namespace)
+                    skipped += 1
+                    logger.warn(f"Skipping remaining {namespace} secrets in {owner}: {e.reason}")
+                    events.emit(
+                        "skipped", f"{namespace} namespace skipped: {e.reason}",
+                        namespace=namespace, reason=e.reason
+                    )
+                except RuntimeError as e:
+                    failures += 1
+                    _report_error(logger, e)
+                    events.emit("error", str(e), secret=name, namespace=namespace)
+                progress.advance(name)
+
+        deleted = len(matched) - failures - skipped
+        events.emit(
+            "run_completed", "Delete completed",
+            deleted=deleted, failures=failures, skipped=skipped
         )
+        if failures:
+            logger.error(f"{failures} of {len(matched)} secret(s) could not be deleted")
+            raise SystemExit(1)
+        suffix = f" ({skipped} skipped: namespace API disabled)" if skipped else ""
+        logger.summary(f"Deleted {deleted} secret(s) from {owner}{suffix}")
+    finally:
+        _write_run_outputs(events, logger, report_path, "")
 
-        migrator = Migrator(config, logger)
-        migrator.run()
 
+@cli.group("env")
+def env_group():
+    """Manage environment definitions as code (export to YAML, apply to repositories)."""
+
+
+@env_group.command("export-config")
+@click.option("--org", required=True, help="Organization owning the repository")
+@click.option("--repo", required=True, help="Repository whose environments are exported")
+@click.option(
+    "--environment",
+    "environment_names",
+    multiple=True,
+    help="Only export this environment (repeatable; all environments by default)"
+)
+@click.option(
+    "--output",
+    "-o",
+    "output_path",
+    default="",
+    help="Write the YAML to this file instead of standard output"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if SOURCE_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@hostname_option
+@verbosity_options
+@audit_options
+def env_export_config(
+    org, repo, environment_names, output_path, pat, hostname, verbose, quiet, no_color,
+    audit_log_path
+):
+    """Export environment definitions of a repository to YAML.
+
+    Includes reviewers, wait timers, deployment branch/tag policies, secret
+    names and variables. Secret values are never exported.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    pat_value = _resolve_pat(pat, "source", logger, hostname)
+
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="source", host=_host(hostname))
+    try:
+        specs = api.export_environment_specs(org, repo)
     except RuntimeError as e:
-        logger.error(str(e))
+        _report_error(logger, e)
         raise SystemExit(1)
-    except Exception as e:
-        logger.error(f"Unexpected error: {type(e).__name__}: {e}")
+    if environment_names:
+        wanted = {name.lower() for name in environment_names}
+        missing = sorted(wanted - {spec.name.lower() for spec in specs})
+        if missing:
+            logger.error(f"Environment(s) not found in {org}/{repo}: {', '.join(missing)}")
+            raise SystemExit(1)
+        specs = [spec for spec in specs if spec.name.lower() in wanted]
+
+    document = dump_environment_config(specs, f"{org}/{repo}")
+    if not output_path:
+        click.echo(document, nl=False)
+        return
+    try:
+        with open(output_path, "w", encoding="utf-8") as handle:
+            handle.write(document)
+    except OSError as e:
+        logger.error(f"Failed to write {output_path}: {e}")
+        raise SystemExit(1)
+    logger.summary(f"Exported {len(specs)} environment(s) from {org}/{repo} to {output_path}")
+
+
+@env_group.command("apply-config")
+@click.argument("config_file", type=click.Path(exists=True, dir_okay=False))
+@click.option("--org", required=True, help="Organization owning the target repositories")
+@click.option(
+    "--repo",
+    "repos",
+    multiple=True,
+    required=True,
+    help="Repository to apply the environments to (repeatable)"
+)
+@click.option(
+    "--pat",
+    default="",
+    help="Personal Access Token (optional if TARGET_GITHUB_TOKEN or GITHUB_TOKEN is set)"
+)
+@click.option(
+    "--reviewer-map",
+    "reviewer_map_file",
+    type=click.Path(exists=True, dir_okay=False),
+    default=None,
+    help="YAML file mapping source user logins/team slugs to target ones "
+         "(unmapped reviewers keep their name)"
+)
+@click.option("--dry-run", is_flag=True, help="Validate the file and show the plan without writing")
+@click.option(
+    "--report",
+    "report_path",
+    default="",
+    help="Write a JSON report of the applied environments to this file"
+)
+@hostname_option
+@verbosity_options
+@event_stream_options
+@audit_options
+def env_apply_config(
+    config_file, org, repos, pat, hostname, reviewer_map_file, dry_run, report_path, verbose, quiet,
+    no_color, events_format, events_file, audit_log_path
+):
+    """Create or update environments in one or more repositories from a YAML file.
+
+    Protection rules are replaced, missing branch policies are added and
+    variables are created or updated. Listed secrets that do not exist on a
+    target are reported so their values can be provided. Reviewers are
+    translated with --reviewer-map; those missing on the target are skipped
+    and reported.
+    """
+    events_path = _events_path(events_format, events_file)
+    logger = _make_logger(verbose, quiet, no_color, events_path)
+    try:
+        specs = load_environment_config(config_file)
+    except (OSError, ValueError) as e:
+        logger.error(f"Invalid environment config {config_file}: {e}")
+        raise SystemExit(1)
+    try:
+        reviewer_map = load_reviewer_map(reviewer_map_file) if reviewer_map_file else ReviewerMap()
+    except (OSError, ValueError) as e:
+        logger.error(f"Invalid reviewer map {reviewer_map_file}: {e}")
+        raise SystemExit(1)
+    for spec in specs:
+        spec.reviewers = reviewer_map.map_reviewers(spec.reviewers)
+
+    repos = list(dict.fromkeys(repos))
+    if dry_run:
+        for repo in repos:
+            for spec in specs:
+                reviewers = ", ".join(format_reviewer(item) for item in spec.reviewers)
+                suffix = f" (reviewers: {reviewers})" if reviewers else ""
+                logger.info(f"Would apply environment '{spec.name}' to {org}/{repo}{suffix}")
+        logger.summary(f"Dry run: {len(specs)} environment(s) x {len(repos)} repository(ies)")
+        return
+
+    pat_value = _resolve_pat(pat, "target", logger, hostname)
+
+    audit = _make_audit_log(audit_log_path, logger)
+    api = GitHubClient(pat_value, logger, audit=audit, side="target", host=_host(hostname))
+    events = EventLog([pat_value])
+    _stream_events(events_path, events, logger)
+    events.emit(
+        "run_started", f"Applying {config_file} to {len(repos)} repository(ies) in {org}",
+        config=config_file, org=org, repos=repos
+    )
+    failures = 0
+    try:
+        with Progress(len(repos) * len(specs), "Applying", logger) as progress:
+            for repo in repos:
+                for spec in specs:
+                    try:
+                        result = api.apply_environment_spec(org, repo, spec)
+                        logger.success(f"Applied environment '{spec.name}' to {org}/{repo}")
+                        events.emit(
+                            "environment_created",
+                            f"Applied environment '{spec.name}' to {org}/{repo}",
+                            environment=spec.name, repo=repo
+                        )
+                        for reviewer in result.unresolved_reviewers:
+                            events.emit(
+                                "skipped",
+                                f"Reviewer {format_reviewer(reviewer)} of environment "
+                                f"'{spec.name}' not found in {org}",
+                                environment=spec.name, repo=repo, reviewer=reviewer
+                            )
+                            logger.warn(
+                                f"{org}/{repo}/{spec.name}: reviewer "
+                                f"{format_reviewer(reviewer)} not found in {org}, skipped"
+                            )
+                        for name in result.missing_secrets:
+                            events.emit(
+                                "warning",
+                                f"Secret '{name}' of environment '{spec.name}' in {org}/{repo} "
+                                "needs a value",
+                                environment=spec.name, repo=repo, secret=name
+                            )
+                        if result.missing_secrets:
+                            logger.warn(
+                                f"{org}/{repo}/{spec.name}: provide values for "
+                                f"{', '.join(result.missing_secrets)}"
+                            )
+                    except RuntimeError as e:
+                        failures += 1
+                        _report_error(logger, e)
+                        events.emit("error", str(e), environment=spec.name, repo=repo)
+                    progress.advance(f"{repo}/{spec.name}")
+
+        events.emit("run_completed", "Apply completed", failures=failures)
+        if failures:
+            logger.error(f"{failures} environment(s) could not be applied")
+            raise SystemExit(1)
+        logger.summary(f"Applied {len(specs)} environment(s) to {len(repos)} repository(ies)")
+    finally:
+        _write_run_outputs(events, logger, report_path, "")
+
+
+@cli.group("audit")
+def audit_group():
+    """Inspect audit logs written with --audit-log."""
+
+
+@audit_group.command("verify")
+@click.argument("log_file", type=click.Path(exists=True, dir_okay=False))
+@click.option(
+    "--anchor",
+    default="",
+    help="Head hash (or its first characters) logged by an earlier run; "
+         "it must still be in the log, which detects truncation"
+)
+@verbosity_options
+def audit_verify(log_file, anchor, verbose, quiet, no_color):
+    """Check that no record of an audit log was edited, removed or reordered.
+
+    Prints the number of records and the head hash. Keep the head somewhere
+    the log's writers cannot change (e.g. a ticket) and pass it back with
+    --anchor to also prove that no records were dropped from the end. Exits
+    with 5 when the log is not intact, 1 when it cannot be read.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    try:
+        with open(log_file, "r", encoding="utf-8") as handle:
+            count, head, problems = verify_chain(handle, anchor)
+    except OSError as e:
+        logger.error(f"Failed to read audit log {log_file}: {e}")
+        raise SystemExit(1)
+    for problem in problems:
+        logger.error(problem)
+    if problems:
+        logger.summary(f"Audit log {log_file} is NOT intact ({len(problems)} problem(s))")
+        raise SystemExit(EXIT_VERIFICATION)
+    logger.summary(f"Audit log {log_file} is intact: {count} record(s), head {head}")
+
+
+@cli.group("attest")
+def attest_group():
+    """Sign and verify attestations of recorded migrations."""
+
+
+@attest_group.command("sign")
+@click.option("--source-org", required=True, help="Source organization name")
+@click.option("--source-repo", required=True, help="Source repository the migration ran from")
+@click.option("--org-to-org", is_flag=True, help="Attest an organization secrets migration")
+@click.option(
+    "--signer",
+    type=click.Choice(ATTESTATION_SIGNERS),
+    default="minisign",
+    show_default=True,
+    help="Signing tool"
+)
+@click.option(
+    "--key",
+    default="",
+    help="Secret key file (minisign: required; cosign: optional, keyless signing "
+         "with an OIDC identity when omitted)"
+)
+@click.option(
+    "-o",
+    "--output",
+    "output_path",
+    default="",
+    help="Manifest file to write (default: migration-manifest.json); the signature "
+         "is written next to it"
+)
+@state_file_options
+@state_encryption_options
+@verbosity_options
+def attest_sign(
+    source_org, source_repo, org_to_org, signer, key, output_path, state_dir, state_file,
+    state_passphrase, state_age_recipients, state_age_identity, verbose, quiet, no_color
+):
+    """Write and sign the manifest of the last recorded migration.
+
+    The manifest lists which secrets were migrated (names and outcomes,
+    never values), from which repository to which targets, when, and the
+    logins of the tokens used. Auditors verify it with minisign or cosign
+    alone (see attest verify for the equivalent commands).
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    cipher = _state_cipher(state_passphrase, state_age_recipients, state_age_identity, logger)
+    record = _recorded_migration(
+        state_dir, state_file, source_org, source_repo, org_to_org, cipher, logger
+    )
+    if record is None:
+        logger.error(f"No migration recorded for {source_org}/{source_repo}")
+        raise SystemExit(1)
+    if record.status == "running":
+        logger.warn("The recorded migration has not finished; attesting its progress so far")
+    path = output_path or "migration-manifest.json"
+    try:
+        tool = make_signer(signer)
+        with open(path, "w", encoding="utf-8") as handle:
+            handle.write(manifest_text(build_manifest(record)))
+        signature = tool.sign(path, key, comment=f"migration of {source_org}/{source_repo}")
+    except (OSError, ValueError) as e:
+        logger.error(f"Failed to sign the migration manifest: {e}")
+        raise SystemExit(1)
+    logger.success(f"Wrote {path} ({len(record.secrets)} secret(s)), signed in {signature}")
+
+
+@attest_group.command("verify")
+@click.argument("manifest", type=click.Path(exists=True, dir_okay=False))
+@click.option(
+    "--signer",
+    type=click.Choice(ATTESTATION_SIGNERS),
+    default="minisign",
+    show_default=True,
+    help="Tool the manifest was signed with"
+)
+@click.option("--key", default="", help="Public key file of the signer")
+@click.option(
+    "--certificate-identity",
+    "identity",
+    default="",
+    help="Identity (e.g. e-mail) of a keyless cosign signer"
+)
+@click.option(
+    "--certificate-oidc-issuer",
+    "issuer",
+    default="",
+    help="OIDC issuer of a keyless cosign signer (e.g. https://github.com/login/oauth)"
+)
+@verbosity_options
+def attest_verify(manifest, signer, key, identity, issuer, verbose, quiet, no_color):
+    """Check the signature of a migration manifest and summarize it.
+
+    Equivalent to `minisign -V -p KEY -m MANIFEST` or `cosign verify-blob
+    --bundle MANIFEST.sigstore.json ...`, which auditors can run without
+    this tool. Exits with 5 when the signature does not verify.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    try:
+        make_signer(signer).verify(manifest, key, identity, issuer)
+        with open(manifest, "r", encoding="utf-8") as handle:
+            document = json.load(handle)
+        migration = document["migration"]
+    except (OSError, ValueError, KeyError, TypeError) as e:
+        logger.error(f"Migration manifest {manifest} does NOT verify: {e}")
+        raise SystemExit(EXIT_VERIFICATION)
+    actors = migration.get("actors") or {}
+    logger.summary(
+        f"Migration manifest {manifest} verifies: {migration.get('source')} → "
+        f"{', '.join(migration.get('targets') or [])}, {len(document.get('secrets') or [])} "
+        f"secret(s), {migration.get('status')} at {migration.get('finished_at')} "
+        f"by {actors.get('source') or 'unknown'} (source) / "
+        f"{actors.get('target') or 'unknown'} (target)"
+    )
+
+
+@cli.group("destination")
+def destination_group():
+    """Check destination plugins used with migrate --destination-plugin."""
+
+
+@destination_group.command("check")
+@click.argument("definition", type=click.Path(exists=True, dir_okay=False))
+@verbosity_options
+def destination_check(definition, verbose, quiet, no_color):
+    """Run a destination plugin locally against the protocol, without secrets.
+
+    Sends the plugin a describe request and a put request holding no
+    secrets, as the migration workflow would, and checks both answers.
+    Install the plugin and set its credential variables first. Exits with 1
+    when the plugin does not follow the protocol.
+    """
+    logger = _make_logger(verbose, quiet, no_color)
+    try:
+        plugin = load_destination_plugin(definition)
+        runner = PluginRunner(plugin)
+        description = runner.describe()
+        runner.probe()
+    except (OSError, ValueError) as e:
+        logger.error(f"Destination plugin {definition} does NOT pass the check: {e}")
         raise SystemExit(1)
+    about = f" ({description['description']})" if description.get("description") else ""
+    logger.success(f"Destination plugin '{plugin.name}'{about} follows protocol {PLUGIN_PROTOCOL}")
+    credentials = sorted(plugin.credentials.items())
+    if credentials:
+        logger.info("The workflow passes it " + ", ".join(
+            f"{variable} from secret {secret}" for variable, secret in credentials
+        ))
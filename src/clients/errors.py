@@ -0,0 +1,164 @@
+"""Typed GitHub API errors carrying remediation hints."""
+from datetime import datetime, timezone
+from typing import Any, Dict, Optional, Tuple
+
+_SCOPES_HINT = (
+    "classic tokens need the repo and workflow scopes (admin:org for organization "
+    "secrets); fine-grained tokens need read/write access to Secrets, Contents, "
+    "Workflows and Administration"
+)
+
+
+class GitHubAPIError(RuntimeError):
+    """A failed GitHub API call; remediation tells the user what to do about it."""
+
+    default_remediation = ""
+
+    def __init__(
+        self, message: str, status: Optional[int] = None, remediation: Optional[str] = None
+    ):
+        super().__init__(message)
+        self.status = status
+        self.remediation = self.default_remediation if remediation is None else remediation
+
+
+class AuthError(GitHubAPIError):
+    """HTTP 401: the token was rejected."""
+
+    default_remediation = (
+        "The token is invalid, expired or revoked. Create a new one and pass it with "
+        "--source-pat/--target-pat (or SOURCE_GITHUB_TOKEN/TARGET_GITHUB_TOKEN)."
+    )
+
+
+class PermissionDenied(GitHubAPIError):
+    """HTTP 403: the token is valid but not allowed to do this."""
+
+    default_remediation = f"The token lacks access: {_SCOPES_HINT}."
+
+
+class NotFound(GitHubAPIError):
+    """HTTP 404: the resource does not exist, or the token cannot see it."""
+
+    default_remediation = (
+        "Check the organization, repository and branch names. GitHub also answers 404 "
+        f"when the token cannot see a private resource: {_SCOPES_HINT}."
+    )
+
+
+class RateLimited(GitHubAPIError):
+    """The primary or secondary rate limit was hit."""
+
+    default_remediation = (
+        "GitHub rate limit exceeded. Wait for it to reset and retry; "
+        "--metadata-cache reduces the calls repeated runs make."
+    )
+
+
+class SecretTooLarge(GitHubAPIError):
+    """HTTP 422: a secret value exceeds GitHub's 48 KB limit."""
+
+    default_remediation = (
+        "Secret values are limited to 48 KB. Store the value encrypted in the repository "
+        "and keep only its passphrase as a secret, then exclude it from the migration."
+    )
+
+
+class EmptyRepository(GitHubAPIError):
+    """HTTP 409: the repository has no commits, so it has no refs to branch from."""
+
+    default_remediation = (
+        "The repository is empty. Push an initial commit to its default branch and retry."
+    )
+
+
+class RepositoryArchived(GitHubAPIError):
+    """HTTP 403: the repository is archived, so it is read-only."""
+
+    default_remediation = (
+        "Archived repositories are read-only. Unarchive it under Settings > General, or pass "
+        "--unarchive (with --wait) to unarchive it for the migration and archive it again after."
+    )
+
+
+class ActionsDisabled(GitHubAPIError):
+    """GitHub Actions is disabled, so the migration workflow cannot run."""
+
+    default_remediation = (
+        "The migration runs as a workflow on the source repository: enable GitHub Actions "
+        "under Settings > Actions > General (and in the organization's policies)."
+    )
+
+
+def _response(error: Exception) -> Tuple[Optional[int], str, Dict[str, Any]]:
+    """Return (status, message, headers) of a PyGithub exception (None/'' otherwise)."""
+    status = getattr(error, "status", None)
+    if not isinstance(status, int):
+        return None, str(error), {}
+    data = getattr(error, "data", None)
+    message = data.get("message", "") if isinstance(data, dict) else str(data or "")
+    raw_headers = getattr(error, "headers", None) or {}
+    headers = {str(key).lower(): value for key, value in raw_headers.items()}
+    return status, message or str(error), headers
+
+
+def _reset_hint(headers: Dict[str, Any]) -> str:
+    """Describe when a rate limit resets, from Retry-After or X-RateLimit-Reset."""
+    if headers.get("retry-after"):
+        return f" It resets in {headers['retry-after']}s."
+    try:
+        reset = datetime.fromtimestamp(int(headers["x-ratelimit-reset"]), timezone.utc)
+    except (KeyError, TypeError, ValueError):
+        return ""
+    return f" It resets at {reset.strftime('%H:%M:%S')} UTC."
+
+
+def api_error(error: Exception, action: str) -> GitHubAPIError:
+    """Wrap error in the GitHubAPIError subclass matching its response.
+
+    An error that is already typed keeps its class and remediation; its
+    message gains action as context.
+
+    Args:
+        error: Exception raised by PyGithub (or by a nested client call)
+        action: What was attempted, e.g. 'Failed to get commit SHA for org/repo/main'
+
+    Returns:
+        The typed error, to be raised by the caller
+    """
+    if isinstance(error, GitHubAPIError):
+        return type(error)(f"{action}: {error}", error.status, error.remediation)
+    status, message, headers = _response(error)
+    if status is None:
+        remediation = ""
+        if "timeout" in type(error).__name__.lower():
+            remediation = "GitHub did not answer in time. Retry, or raise --api-timeout."
+        return GitHubAPIError(f"{action}: {message}", None, remediation)
+
+    text = message.lower()
+    detail = f"{action}: HTTP {status}: {message}"
+    if type(error).__name__ == "RateLimitExceededException" or status == 429 or (
+        status == 403 and (headers.get("x-ratelimit-remaining") == "0" or "rate limit" in text)
+    ):
+        return RateLimited(detail, status, RateLimited.default_remediation + _reset_hint(headers))
+    if "actions" in text and "disabled" in text:
+        return ActionsDisabled(detail, status)
+    if status == 409 and "empty" in text:
+        return EmptyRepository(detail, status)
+    if status == 422 and "too large" in text:
+        return SecretTooLarge(detail, status)
+    if status == 401:
+        return AuthError(detail, status)
+    if status == 403:
+        if "archived" in text:
+            return RepositoryArchived(detail, status)
+        if "saml" in text or "sso" in text:
+            return PermissionDenied(
+                detail, status,
+                "The organization enforces SAML single sign-on: authorize the token for it "
+                "under Settings > Developer settings > Tokens > Configure SSO."
+            )
+        return PermissionDenied(detail, status)
+    if status == 404:
+        return NotFound(detail, status)
+    return GitHubAPIError(detail, status)
@@ -1,18 +1,170 @@
 """GitHub API client wrapper."""
 # flake8: noqa: E501
-from typing import List
-from github import Github
+from datetime import datetime, timedelta, timezone
+from typing import Callable, Dict, List, Optional, Set, Tuple, TypeVar, Union
+from urllib.parse import quote
+from github import Github, InputGitAuthor
+from src.clients.errors import api_error
 from src.utils.logger import Logger
+from src.utils.retry import is_not_found_error, retry_on_not_found
+from src.utils.clock import estimate_skew, parse_http_date
+from src.utils.http import get_text
+from src.utils.etag_cache import ETagCache, credential_scope
+from src.core.audit import READ, AuditLog
+from src.core.credentials import GITHUB_COM
+from src.core.inventory import SecretRecord, VariableRecord
+from src.core.repo_filters import RepoFilter, RepoInfo
+from src.core.repo_refs import api_base_url
+from src.core.scopes import OrgSecretScope
+from src.core.namespaces import NamespaceUnavailableError, secrets_api_path, unavailable_reason
+from src.core.shared_repos import ACTION_FILES, WORKFLOWS_DIR
+from src.core.status import WorkflowRunInfo
+from src.sealedbox import PublicKeyCache, SecretsPublicKey, parse_public_key, sealed_secret_payload
+from src.core.environment_config import (
+    EnvironmentApplyResult, EnvironmentSpec, branch_policy_payload, deployment_branches_from_api,
+    protection_from_api, spec_from_api, split_branch_pattern
+)
+
+T = TypeVar("T")
+
+# Seconds to wait for a single API response (PyGithub's default)
+DEFAULT_API_TIMEOUT = 15.0
+
+# Requester methods carrying every REST call PyGithub makes (verb and URL first)
+_AUDITED_REQUESTS = (
+    "requestJsonAndCheck", "requestMultipartAndCheck", "requestBlobAndCheck", "requestMemoryBlobAndCheck"
+)
+
+# Visibilities accepted when creating a repository ('internal' needs an enterprise organization)
+REPO_VISIBILITIES = ("private", "internal", "public")
+
+
+def _api_time(item: Dict) -> Optional[datetime]:
+    """Parse the updated_at timestamp of a raw API item, or None if absent or malformed."""
+    try:
+        return datetime.fromisoformat(str(item["updated_at"]).replace("Z", "+00:00"))
+    except (KeyError, ValueError):
+        return None
 
 
 class GitHubClient:
     """Client for GitHub API operations."""
 
-    def __init__(self, pat: str, logger: Logger):
-        """Initialize GitHub client with PAT."""
-        self.client = Github(pat)
+    def __init__(
+        self, pat: str, logger: Logger, cache: Optional[ETagCache] = None, timeout: float = DEFAULT_API_TIMEOUT,
+        audit: Optional[AuditLog] = None, side: str = "", host: str = GITHUB_COM
+    ):
+        """Initialize GitHub client with PAT.
+        
+        Args:
+            pat: Personal Access Token
+            logger: Logger instance
+            cache: Optional ETag cache for conditional metadata reads, shareable between clients
+            timeout: Seconds to wait for each API response before failing the call
+            audit: Optional audit log receiving every API call this client makes
+            side: 'source' or 'target', recorded in the audit log's token identity
+            host: GitHub host to talk to (github.com, a GHE.com tenant or a GHES appliance)
+        """
+        self.client = Github(pat, base_url=api_base_url(host), timeout=timeout)
+        self.host = host
+        self.timeout = timeout
         self.log = logger
+        self.cache = cache
+        self._cache_scope = credential_scope(pat)
+        # Repositories/environments created by this client; writes to them retry on 404
+        self._created_resources: Set[Tuple[str, ...]] = set()
+        # Public keys encrypting the secrets under each secrets API path
+        self._public_keys = PublicKeyCache()
+        if audit is not None:
+            self._audit_requests(audit, side)
+
+    def get_login(self) -> str:
+        """Return the login of the token's user, or '' for tokens that cannot read /user."""
+        try:
+            return self.client.get_user().login
+        except Exception as e:
+            self.log.debug(f"Could not read the authenticated user: {type(e).__name__}: {e}")
+            return ""
+
+    def _audit_requests(self, audit: AuditLog, side: str) -> None:
+        """Record every request made through PyGithub's requester in the audit log.
+        
+        The token's login is looked up once, before auditing starts; app and
+        fine-grained tokens that cannot read /user are identified by their
+        fingerprint only.
+        """
+        try:
+            login = self.client.get_user().login
+        except Exception:
+            login = ""
+        identity = {"side": side, "login": login, "token": self._cache_scope}
+        requester = self.client.requester
+
+        def audited(request: Callable, graphql: bool = False) -> Callable:
+            def call(*args, **kwargs):
+                if graphql:
+                    method, url, operation = "POST", "/graphql", READ
+                else:
+                    method = kwargs.get("verb", args[0] if args else "")
+                    url = kwargs.get("url", args[1] if len(args) > 1 else "")
+                    operation = ""
+                try:
+                    result = request(*args, **kwargs)
+                except Exception as e:
+                    audit.record(identity, method, url, getattr(e, "status", None), True, operation)
+                    raise
+                audit.record(identity, method, url, operation=operation)
+                return result
+            return call
+
+        for name in _AUDITED_REQUESTS:
+            if hasattr(requester, name):
+                setattr(requester, name, audited(getattr(requester, name)))
+        if hasattr(requester, "graphql_query"):
+            requester.graphql_query = audited(requester.graphql_query, graphql=True)
+
+    def _retry_if_fresh(self, resource: Tuple[str, ...], operation: Callable[[], T], description: str) -> T:
+        """Run operation, retrying on 404 if resource was just created by this client.
+        
+        GitHub is eventually consistent for newly created repositories and
+        environments; writes to them can 404 for a few seconds.
+        """
+        if resource in self._created_resources:
+            return retry_on_not_found(operation, logger=self.log, description=description)
+        return operation()
     
+    def _get_json(self, path: str, parameters: Optional[dict] = None) -> dict:
+        """GET a JSON document, revalidating a cached copy with If-None-Match when caching is on."""
+        if self.cache is None:
+            _, data = self.client.requester.requestJsonAndCheck("GET", path, parameters=parameters)
+            return data
+        query = "&".join(f"{key}={value}" for key, value in sorted((parameters or {}).items()))
+        key = f"{self._cache_scope}:{path}?{query}"
+        cached = self.cache.get(key)
+        headers = {"If-None-Match": cached[0]} if cached else None
+        response_headers, data = self.client.requester.requestJsonAndCheck(
+            "GET", path, parameters=parameters, headers=headers
+        )
+        if data is None and cached:
+            # 304 Not Modified has no body and does not count against the rate limit
+            self.cache.record(revalidated=True)
+            return cached[1]
+        self.cache.record(revalidated=False)
+        self.cache.put(key, response_headers.get("etag", ""), data)
+        return data
+
+    def _list_names(self, path: str, field: str) -> List[str]:
+        """Fetch every page of a list endpoint and return the items' names."""
+        names: List[str] = []
+        page = 1
+        while True:
+            data = self._get_json(path, {"per_page": 100, "page": page})
+            batch = [item["name"] for item in data.get(field, [])]
+            names.extend(batch)
+            if len(batch) < 100:
+                return names
+            page += 1
+
     def get_rate_limit_info(self) -> dict:
         """Get current rate limit information.
         
@@ -54,18 +206,33 @@ class GitHubClient:
         """Log current rate limit after an operation."""
         info = self.get_rate_limit_info()
         if info['remaining'] >= 0:
-            self.log.debug(
+            self.log.trace(
                 f"[{operation}] Rate limit: {info['remaining']}/{info['limit']} calls remaining "
                 f"(resets in ~{info['reset_in_seconds']}s)"
             )
 
+    def is_archived(self, org: str, repo: str) -> bool:
+        """Return whether the repository is archived (read-only)."""
+        try:
+            return bool(self.client.get_user(org).get_repo(repo).archived)
+        except Exception as e:
+            raise api_error(e, f"Failed to get repository: {org}/{repo}")
+
+    def set_archived(self, org: str, repo: str, archived: bool) -> None:
+        """Archive or unarchive the repository."""
+        try:
+            self.client.get_user(org).get_repo(repo).edit(archived=archived)
+            self.log.debug(f"{'Archived' if archived else 'Unarchived'} {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to {'archive' if archived else 'unarchive'} {org}/{repo}")
+
     def get_default_branch(self, org: str, repo: str) -> str:
         """Get the default branch of a repository."""
         try:
             repository = self.client.get_user(org).get_repo(repo)
             return repository.default_branch
-        except Exception:
-            raise RuntimeError(f"Failed to get repository: {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to get repository: {org}/{repo}")
 
     def get_commit_sha(self, org: str, repo: str, branch: str) -> str:
         """Get the commit SHA for a given branch."""
@@ -73,8 +240,41 @@ class GitHubClient:
             repository = self.client.get_user(org).get_repo(repo)
             ref = repository.get_git_ref(f"heads/{branch}")
             return ref.object.sha
-        except Exception:
-            raise RuntimeError(f"Failed to get commit SHA for {org}/{repo}/{branch}")
+        except Exception as e:
+            raise api_error(e, f"Failed to get commit SHA for {org}/{repo}/{branch}")
+
+    def initialize_branch(
+        self,
+        org: str,
+        repo: str,
+        branch: str,
+        message: str = "",
+        committer: Optional[Tuple[str, str]] = None
+    ) -> str:
+        """Give an empty repository its first commit, an empty .gitkeep file on branch.
+
+        The Git database API refuses empty repositories; the contents API creates
+        the branch and its first commit.
+
+        Returns:
+            SHA of the new commit
+        """
+        identity = {}
+        if committer:
+            identity["committer"] = identity["author"] = InputGitAuthor(*committer)
+        try:
+            repository = self.client.get_user(org).get_repo(repo)
+            result = repository.create_file(
+                path=".gitkeep",
+                message=message or "Initialize repository",
+                content="",
+                branch=branch,
+                **identity
+            )
+            self.log.debug(f"Initialized branch {branch} in {org}/{repo}")
+            return result["commit"].sha
+        except Exception as e:
+            raise api_error(e, f"Failed to initialize branch {branch} in {org}/{repo}")
 
     def create_branch(self, org: str, repo: str, branch_name: str, sha: str) -> None:
         """Create a new branch in the repository."""
@@ -82,8 +282,8 @@ class GitHubClient:
             repository = self.client.get_user(org).get_repo(repo)
             repository.create_git_ref(f"refs/heads/{branch_name}", sha)
             self.log.debug(f"Created branch {branch_name}")
-        except Exception:
-            raise RuntimeError(f"Failed to create branch {branch_name} in {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to create branch {branch_name} in {org}/{repo}")
 
     def delete_branch(self, org: str, repo: str, branch_name: str) -> None:
         """Delete a branch from the repository."""
@@ -98,25 +298,29 @@ class GitHubClient:
     def list_repo_secrets(self, org: str, repo: str) -> List[str]:
         """List all secrets in the repository."""
         try:
-            repository = self.client.get_user(org).get_repo(repo)
-            secrets = repository.get_secrets()
-            result = [secret.name for secret in secrets]
+            result = self._list_names(f"/repos/{org}/{repo}/actions/secrets", "secrets")
             self._log_rate_limit(f"list_repo_secrets({org}/{repo})")
             return result
-        except Exception:
-            raise RuntimeError(f"Failed to list secrets in {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to list secrets in {org}/{repo}")
 
     def create_repo_secret(self, org: str, repo: str, secret_name: str, secret_value: str) -> None:
         """Create or update a secret in the repository."""
+        secrets_path = f"/repos/{org}/{repo}/actions/secrets"
+
+        def write() -> None:
+            self.client.requester.requestJsonAndCheck(
+                "PUT", f"{secrets_path}/{quote(secret_name, safe='')}",
+                input=sealed_secret_payload(self._public_key(secrets_path), secret_value)
+            )
+
         try:
-            repository = self.client.get_user(org).get_repo(repo)
-            # PyGithub handles encryption automatically!
-            repository.create_secret(secret_name, secret_value)
+            self._retry_if_fresh(("repo", org, repo), write, f"create_repo_secret({secret_name})")
             self._log_rate_limit(f"create_repo_secret({org}/{repo}/{secret_name})")
             self.log.debug(f"Created/updated secret {secret_name} in {org}/{repo}")
         except Exception as e:
             self.log.error(f"Failed to create/update secret {secret_name}: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to create/update secret {secret_name}: {e}")
+            raise api_error(e, f"Failed to create/update secret {secret_name}")
 
     def delete_secret(self, org: str, repo: str, secret_name: str) -> None:
         """Delete a secret from the repository."""
@@ -125,51 +329,94 @@ class GitHubClient:
             secret = repository.get_secret(secret_name)
             secret.delete()
             self.log.debug(f"Deleted secret {secret_name}")
-        except Exception:
-            raise RuntimeError(f"Failed to delete secret {secret_name} from {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to delete secret {secret_name} from {org}/{repo}")
 
-    def create_file(self, org: str, repo: str, branch: str, path: str, contents: str) -> None:
-        """Create or update a file in the repository."""
+    def create_file(
+        self,
+        org: str,
+        repo: str,
+        branch: str,
+        path: str,
+        contents: str,
+        message: str = "",
+        committer: Optional[Tuple[str, str]] = None
+    ) -> None:
+        """Create or update a file in the repository.
+        
+        Args:
+            message: Commit message (defaults to "Add <path>")
+            committer: Optional (name, email) used as commit author and committer;
+                       the token's user is used when omitted
+        """
+        identity = {}
+        if committer:
+            identity["committer"] = identity["author"] = InputGitAuthor(*committer)
         try:
             repository = self.client.get_user(org).get_repo(repo)
             repository.create_file(
                 path=path,
-                message=f"Add {path}",
+                message=message or f"Add {path}",
                 content=contents,
-                branch=branch
+                branch=branch,
+                **identity
             )
             self.log.debug(f"Created file {path} on branch {branch}")
-        except Exception:
-            raise RuntimeError(f"Failed to create file {path} in {org}/{repo} on branch {branch}")
+        except Exception as e:
+            raise api_error(e, f"Failed to create file {path} in {org}/{repo} on branch {branch}")
 
     def list_environments(self, org: str, repo: str) -> List[str]:
         """List all environments in the repository."""
         try:
-            repository = self.client.get_repo(f"{org}/{repo}")
-            environments = []
-            for env in repository.get_environments():
-                environments.append(env.name)
+            environments = self._list_names(f"/repos/{org}/{repo}/environments", "environments")
             self._log_rate_limit(f"list_environments({org}/{repo})")
             return environments
         except Exception:
             self.log.debug(f"Failed to list environments in {org}/{repo}")
             return []
 
-    def create_environment(self, org: str, repo: str, environment_name: str) -> None:
-        """Create an environment in the repository. Gracefully handles if already exists."""
+    def create_environment(self, org: str, repo: str, environment_name: str) -> bool:
+        """Create an environment in the repository. Gracefully handles if already exists.
+        
+        Returns:
+            True if the environment was created, False if it already existed
+        """
         try:
             repository = self.client.get_repo(f"{org}/{repo}")
             repository.create_environment(environment_name)
+            self._created_resources.add(("env", org, repo, environment_name))
             self._log_rate_limit(f"create_environment({org}/{repo}/{environment_name})")
             self.log.debug(f"Created environment '{environment_name}' in {org}/{repo}")
+            return True
         except Exception as e:
             error_str = str(e)
             # Handle 409 Conflict (environment already exists)
             if "409" in error_str or "already exists" in error_str.lower():
                 self.log.debug(f"Environment '{environment_name}' already exists, skipping")
+                return False
             else:
                 self.log.error(f"Failed to create environment '{environment_name}': {type(e).__name__}: {e}")
-                raise RuntimeError(f"Failed to create environment '{environment_name}': {e}")
+                raise api_error(e, f"Failed to create environment '{environment_name}'")
+
+    def get_environment_spec(self, org: str, repo: str, environment_name: str) -> EnvironmentSpec:
+        """Get an environment's protection rules and deployment branches (no secrets or variables).
+        
+        Raises:
+            NotFound: If the environment does not exist
+        """
+        try:
+            env_path = f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}"
+            _, environment = self.client.requester.requestJsonAndCheck("GET", env_path)
+            policies: List[dict] = []
+            if (environment.get("deployment_branch_policy") or {}).get("custom_branch_policies"):
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"{env_path}/deployment-branch-policies", parameters={"per_page": 100}
+                )
+                policies = data.get("branch_policies", [])
+            self._log_rate_limit(f"get_environment_spec({org}/{repo}/{environment_name})")
+            return spec_from_api(environment, policies, [], {})
+        except Exception as e:
+            raise api_error(e, f"Failed to read environment '{environment_name}' of {org}/{repo}")
 
     def list_environment_names_with_secret_count(self, org: str, repo: str) -> dict:
         """List all environments with their secret counts.
@@ -197,51 +444,112 @@ class GitHubClient:
             self.log.debug("Failed to list environments with secret count")
             return {}
 
-    def list_environment_secrets(self, org: str, repo: str, environment_name: str) -> List[str]:
+    def list_environment_secrets(self, org: str, repo: str, environment_name: str, strict: bool = False) -> List[str]:
         """List all secret names in a specific environment.
         
         Args:
             org: Organization name
             repo: Repository name
             environment_name: Environment name
+            strict: Raise when the secrets cannot be listed instead of returning
+                an empty list (e.g. before pruning, where an empty list means
+                "delete everything")
             
         Returns:
             List of secret names in the environment
         """
         try:
-            repository = self.client.get_repo(f"{org}/{repo}")
-            env_obj = repository.get_environment(environment_name)
-            env_secrets_obj = env_obj.get_secrets()
-            secret_names = [secret.name for secret in env_secrets_obj]
-            return secret_names
-        except Exception:
+            return self._list_names(
+                f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}/secrets", "secrets"
+            )
+        except Exception as e:
+            if strict:
+                raise api_error(e, f"Failed to list secrets of environment '{environment_name}' in {org}/{repo}")
             self.log.debug(f"Could not fetch secrets for environment '{environment_name}' in {org}/{repo}")
             return []
 
-    def list_all_environments_with_secrets(self, org: str, repo: str) -> dict:
+    def get_environment_public_key(self, org: str, repo: str, environment_name: str) -> SecretsPublicKey:
+        """Get the public key the secrets of a repository environment are encrypted with.
+        
+        The key is fetched once per environment and client.
+        
+        Returns:
+            The key (a (key_id, base64-encoded key) tuple)
+        """
+        try:
+            return self._public_key(f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}/secrets")
+        except Exception as e:
+            raise api_error(e, f"Failed to read the secrets public key of environment '{environment_name}' in {org}/{repo}")
+
+    def create_environment_secret(
+        self, org: str, repo: str, environment_name: str, secret_name: str, secret_value: str
+    ) -> None:
+        """Create or update a secret in a repository environment.
+
+        Args:
+            org: Organization name
+            repo: Repository name
+            environment_name: Environment name
+            secret_name: Name of the secret
+            secret_value: Value of the secret (encrypted with the environment's public key)
+        """
+        secrets_path = f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}/secrets"
+
+        def write() -> None:
+            # A just-created environment can 404 here too, so the retry covers the key read
+            self.client.requester.requestJsonAndCheck(
+                "PUT", f"{secrets_path}/{quote(secret_name, safe='')}",
+                input=sealed_secret_payload(self._public_key(secrets_path), secret_value)
+            )
+
+        try:
+            self._retry_if_fresh(
+                ("env", org, repo, environment_name), write,
+                f"create_environment_secret({environment_name}/{secret_name})"
+            )
+            self._log_rate_limit(f"create_environment_secret({org}/{repo}/{environment_name}/{secret_name})")
+            self.log.debug(f"Created/updated secret {secret_name} in environment '{environment_name}'")
+        except Exception as e:
+            self.log.error(f"Failed to create/update environment secret {secret_name}: {type(e).__name__}: {e}")
+            raise api_error(e, f"Failed to create/update environment secret {secret_name}")
+
+    def delete_environment_secret(self, org: str, repo: str, environment_name: str, secret_name: str) -> None:
+        """Delete a secret from a repository environment.
+        
+        Args:
+            org: Organization name
+            repo: Repository name
+            environment_name: Environment name
+            secret_name: Name of the secret to delete
+        """
+        try:
+            repository = self.client.get_repo(f"{org}/{repo}")
+            env_obj = repository.get_environment(environment_name)
+            env_obj.delete_secret(secret_name)
+            self.log.debug(f"Deleted secret {secret_name} from environment '{environment_name}'")
+        except Exception as e:
+            self.log.error(f"Failed to delete environment secret {secret_name}: {type(e).__name__}: {e}")
+            raise api_error(e, f"Failed to delete environment secret {secret_name}")
+
+    def list_all_environments_with_secrets(self, org: str, repo: str, strict: bool = False) -> dict:
         """List all environments with their secret names.
         
         Returns a dictionary mapping environment names to lists of secret names.
         Example: {'production': ['DB_PASSWORD', 'API_KEY'], 'staging': ['DB_PASSWORD']}
+        With strict, a listing failure raises instead of leaving environments out
+        or empty.
         """
         try:
-            repository = self.client.get_repo(f"{org}/{repo}")
             env_info = {}
             
-            for env in repository.get_environments():
-                secret_names = []
-                try:
-                    env_obj = repository.get_environment(env.name)
-                    env_secrets_obj = env_obj.get_secrets()
-                    secret_names = [secret.name for secret in env_secrets_obj]
-                except Exception:
-                    self.log.debug(f"Could not fetch secrets for environment '{env.name}'")
-                
-                env_info[env.name] = secret_names
+            for env_name in self._list_names(f"/repos/{org}/{repo}/environments", "environments"):
+                env_info[env_name] = self.list_environment_secrets(org, repo, env_name, strict)
             
             self._log_rate_limit(f"list_all_environments_with_secrets({org}/{repo})")
             return env_info
-        except Exception:
+        except Exception as e:
+            if strict:
+                raise api_error(e, f"Failed to list environment secrets in {org}/{repo}")
             self.log.debug("Failed to list environments with secrets")
             return {}
 
@@ -255,33 +563,63 @@ class GitHubClient:
             List of secret names in the organization
         """
         try:
-            organization = self.client.get_organization(org)
-            secrets = organization.get_secrets()
-            secret_names = [secret.name for secret in secrets]
+            secret_names = self._list_names(f"/orgs/{org}/actions/secrets", "secrets")
             self._log_rate_limit(f"list_org_secrets({org})")
             self.log.debug(f"Found {len(secret_names)} organization secrets in {org}")
             return secret_names
-        except Exception:
+        except Exception as e:
             self.log.debug(f"Failed to list organization secrets in {org}")
-            raise RuntimeError(f"Failed to list organization secrets in {org}")
+            raise api_error(e, f"Failed to list organization secrets in {org}")
+
+    def _public_key(self, secrets_path: str) -> SecretsPublicKey:
+        """Return the public key of the secrets under secrets_path, fetched once per client."""
+        def fetch() -> SecretsPublicKey:
+            _, data = self.client.requester.requestJsonAndCheck("GET", f"{secrets_path}/public-key")
+            self._log_rate_limit(f"get_public_key({secrets_path})")
+            return parse_public_key(data)
 
-    def create_org_secret(self, org: str, secret_name: str, secret_value: str) -> None:
+        return self._public_keys.get(secrets_path, fetch)
+
+    def get_org_public_key(self, org: str) -> SecretsPublicKey:
+        """Get the public key organization Actions secrets are encrypted with.
+        
+        The key is fetched once per organization and client.
+        
+        Returns:
+            The key (a (key_id, base64-encoded key) tuple)
+        """
+        try:
+            return self._public_key(f"/orgs/{org}/actions/secrets")
+        except Exception as e:
+            raise api_error(e, f"Failed to read the Actions secrets public key of organization {org}")
+
+    def create_org_secret(
+        self, org: str, secret_name: str, secret_value: str, visibility: str = "all",
+        selected_repository_ids: Optional[List[int]] = None
+    ) -> None:
         """Create or update a secret in the organization.
         
         Args:
             org: Organization name
             secret_name: Name of the secret
-            secret_value: Value of the secret
+            secret_value: Value of the secret (encrypted with the organization's public key)
+            visibility: 'all', 'private' or 'selected'
+            selected_repository_ids: IDs of the repositories a 'selected' secret is available to
         """
         try:
-            organization = self.client.get_organization(org)
-            # PyGithub handles encryption automatically
-            organization.create_secret(secret_name, secret_value)
+            payload = {
+                **sealed_secret_payload(self.get_org_public_key(org), secret_value), "visibility": visibility
+            }
+            if visibility == "selected":
+                payload["selected_repository_ids"] = list(selected_repository_ids or [])
+            self.client.requester.requestJsonAndCheck(
+                "PUT", f"/orgs/{org}/actions/secrets/{quote(secret_name, safe='')}", input=payload
+            )
             self._log_rate_limit(f"create_org_secret({org}/{secret_name})")
             self.log.debug(f"Created/updated organization secret {secret_name} in {org}")
         except Exception as e:
             self.log.error(f"Failed to create/update organization secret {secret_name}: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to create/update organization secret {secret_name}: {e}")
+            raise api_error(e, f"Failed to create/update organization secret {secret_name}")
 
     def delete_org_secret(self, org: str, secret_name: str) -> None:
         """Delete a secret from the organization.
@@ -297,4 +635,815 @@ class GitHubClient:
             self.log.debug(f"Deleted organization secret {secret_name} from {org}")
         except Exception as e:
             self.log.error(f"Failed to delete organization secret {secret_name}: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to delete organization secret {secret_name}: {e}")
+            raise api_error(e, f"Failed to delete organization secret {secret_name}")
+
+    def list_repo_secret_records(self, org: str, repo: str) -> List[SecretRecord]:
+        """List repository secrets with metadata (names and timestamps only).
+        
+        Args:
+            org: Organization name
+            repo: Repository name
+        """
+        try:
+            repository = self.client.get_repo(f"{org}/{repo}")
+            records = [
+                SecretRecord(secret.name, "repo", updated_at=secret.updated_at)
+                for secret in repository.get_secrets()
+            ]
+            self._log_rate_limit(f"list_repo_secret_records({org}/{repo})")
+            return records
+        except Exception as e:
+            raise api_error(e, f"Failed to list secrets in {org}/{repo}")
+
+    def list_environment_secret_records(self, org: str, repo: str) -> List[SecretRecord]:
+        """List secrets of every environment in the repository with metadata.
+        
+        Args:
+            org: Organization name
+            repo: Repository name
+        """
+        records = []
+        try:
+            repository = self.client.get_repo(f"{org}/{repo}")
+            for env in repository.get_environments():
+                try:
+                    for secret in repository.get_environment(env.name).get_secrets():
+                        records.append(
+                            SecretRecord(secret.name, "env", environment=env.name, updated_at=secret.updated_at)
+                        )
+                except Exception:
+                    self.log.debug(f"Could not fetch secrets for environment '{env.name}'")
+            self._log_rate_limit(f"list_environment_secret_records({org}/{repo})")
+        except Exception:
+            self.log.debug(f"Failed to list environments in {org}/{repo}")
+        return records
+
+    def list_org_secret_records(self, org: str) -> List[SecretRecord]:
+        """List organization secrets with metadata (names, visibility and timestamps).
+        
+        Args:
+            org: Organization name
+        """
+        try:
+            organization = self.client.get_organization(org)
+            records = [
+                SecretRecord(secret.name, "org", visibility=secret.visibility, updated_at=secret.updated_at)
+                for secret in organization.get_secrets()
+            ]
+            self._log_rate_limit(f"list_org_secret_records({org})")
+            return records
+        except Exception as e:
+            raise api_error(e, f"Failed to list organization secrets in {org}")
+
+    def _list_variables(self, path: str) -> List[dict]:
+        """Fetch every page of an Actions variables endpoint."""
+        variables: List[dict] = []
+        page = 1
+        while True:
+            _, data = self.client.requester.requestJsonAndCheck(
+                "GET", path, parameters={"per_page": 30, "page": page}
+            )
+            batch = data.get("variables", [])
+            variables.extend(batch)
+            if len(batch) < 30:
+                return variables
+            page += 1
+
+    def list_repo_variable_records(self, org: str, repo: str, include_envs: bool = True) -> List[VariableRecord]:
+        """List Actions variables (with values) of a repository and, optionally, its environments.
+        
+        Args:
+            org: Organization name
+            repo: Repository name
+            include_envs: Also list the variables of every environment
+        """
+        try:
+            records = [
+                VariableRecord(item["name"], "repo", item.get("value", ""), updated_at=_api_time(item))
+                for item in self._list_variables(f"/repos/{org}/{repo}/actions/variables")
+            ]
+            if include_envs:
+                for env_name in self.list_environments(org, repo):
+                    path = f"/repos/{org}/{repo}/environments/{quote(env_name, safe='')}/variables"
+                    records.extend(
+                        VariableRecord(
+                            item["name"], "env", item.get("value", ""), environment=env_name,
+                            updated_at=_api_time(item)
+                        )
+                        for item in self._list_variables(path)
+                    )
+            self._log_rate_limit(f"list_repo_variable_records({org}/{repo})")
+            return records
+        except Exception as e:
+            raise api_error(e, f"Failed to list variables in {org}/{repo}")
+
+    def list_org_variable_records(self, org: str) -> List[VariableRecord]:
+        """List organization Actions variables with values."""
+        try:
+            records = [
+                VariableRecord(
+                    item["name"], "org", item.get("value", ""), visibility=item.get("visibility", ""),
+                    updated_at=_api_time(item)
+                )
+                for item in self._list_variables(f"/orgs/{org}/actions/variables")
+            ]
+            self._log_rate_limit(f"list_org_variable_records({org})")
+            return records
+        except Exception as e:
+            raise api_error(e, f"Failed to list variables in organization {org}")
+
+    def _write_variable(self, path: str, name: str, value: str, exists: bool, **fields) -> None:
+        """Create a variable under an Actions variables endpoint, or update it if it exists."""
+        payload = {"name": name, "value": value, **fields}
+        if exists:
+            self.client.requester.requestJsonAndCheck("PATCH", f"{path}/{quote(name, safe='')}", input=payload)
+        else:
+            self.client.requester.requestJsonAndCheck("POST", path, input=payload)
+
+    def set_repo_variable(self, org: str, repo: str, name: str, value: str, exists: bool = False) -> None:
+        """Create or update an Actions variable of a repository.
+        
+        Args:
+            org: Organization name
+            repo: Repository name
+            name: Variable name
+            value: Variable value
+            exists: Whether the variable already exists (it is updated rather than created)
+        """
+        try:
+            self._write_variable(f"/repos/{org}/{repo}/actions/variables", name, value, exists)
+            self._log_rate_limit(f"set_repo_variable({org}/{repo}/{name})")
+            self.log.debug(f"{'Updated' if exists else 'Created'} variable {name} in {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to set variable {name} in {org}/{repo}")
+
+    def set_environment_variable(
+        self, org: str, repo: str, environment_name: str, name: str, value: str, exists: bool = False
+    ) -> None:
+        """Create or update an Actions variable of an environment (see set_repo_variable)."""
+        path = f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}/variables"
+        try:
+            self._write_variable(path, name, value, exists)
+            self._log_rate_limit(f"set_environment_variable({org}/{repo}/{environment_name}/{name})")
+            self.log.debug(f"{'Updated' if exists else 'Created'} variable {name} in environment {environment_name}")
+        except Exception as e:
+            raise api_error(e, f"Failed to set variable {name} in environment {environment_name} of {org}/{repo}")
+
+    def get_org_variable_scope(self, org: str, name: str) -> OrgSecretScope:
+        """Get the visibility and selected repository names of an organization variable."""
+        path = f"/orgs/{org}/actions/variables/{quote(name, safe='')}"
+        try:
+            _, data = self.client.requester.requestJsonAndCheck("GET", path)
+            repositories = []
+            if data.get("visibility") == "selected":
+                _, selected = self.client.requester.requestJsonAndCheck(
+                    "GET", f"{path}/repositories", parameters={"per_page": 100}
+                )
+                repositories = [repo["name"] for repo in selected.get("repositories", [])]
+            self._log_rate_limit(f"get_org_variable_scope({org}/{name})")
+            return OrgSecretScope(data.get("visibility", "all"), repositories)
+        except Exception as e:
+            raise api_error(e, f"Failed to read scope of organization variable {name}")
+
+    def set_org_variable(self, org: str, name: str, value: str, scope: OrgSecretScope, exists: bool = False) -> List[str]:
+        """Create or update an organization Actions variable with a visibility scope.
+        
+        Selected repositories are matched by name in org.
+        
+        Returns:
+            Selected repository names that do not exist in org (left out of the scope)
+        """
+        try:
+            fields = {"visibility": scope.visibility}
+            missing = []
+            if scope.visibility == "selected":
+                repository_ids = []
+                for repo_name in scope.repositories:
+                    try:
+                        repository_ids.append(self.client.get_repo(f"{org}/{repo_name}").id)
+                    except Exception as e:
+                        if not is_not_found_error(e):
+                            raise
+                        missing.append(repo_name)
+                fields["selected_repository_ids"] = repository_ids
+            self._write_variable(f"/orgs/{org}/actions/variables", name, value, exists, **fields)
+            self._log_rate_limit(f"set_org_variable({org}/{name})")
+            self.log.debug(f"{'Updated' if exists else 'Created'} organization variable {name} in {org}")
+            return missing
+        except Exception as e:
+            raise api_error(e, f"Failed to set organization variable {name} in {org}")
+
+    def get_org_secret_scope(self, org: str, secret_name: str) -> OrgSecretScope:
+        """Get the visibility and selected repository names of an organization secret.
+        
+        Args:
+            org: Organization name
+            secret_name: Name of the organization secret
+        """
+        try:
+            secret = self.client.get_organization(org).get_secret(secret_name)
+            repositories = []
+            if secret.visibility == "selected":
+                repositories = [repo.name for repo in secret.selected_repositories]
+            self._log_rate_limit(f"get_org_secret_scope({org}/{secret_name})")
+            return OrgSecretScope(secret.visibility, repositories)
+        except Exception as e:
+            raise api_error(e, f"Failed to read scope of organization secret {secret_name}")
+
+    def add_org_secret_repository(self, org: str, secret_name: str, repo: str) -> None:
+        """Give a repository access to an organization secret with 'selected' visibility."""
+        try:
+            repository_id = self.client.get_repo(f"{org}/{repo}").id
+            self.client.requester.requestJsonAndCheck(
+                "PUT", f"/orgs/{org}/actions/secrets/{secret_name}/repositories/{repository_id}"
+            )
+            self._log_rate_limit(f"add_org_secret_repository({org}/{secret_name}/{repo})")
+            self.log.debug(f"Added {repo} to the repositories of organization secret {secret_name}")
+        except Exception as e:
+            raise api_error(e, f"Failed to add {repo} to organization secret {secret_name}")
+
+    @staticmethod
+    def _raise_if_namespace_unavailable(namespace: str, owner: str, error: Exception) -> None:
+        """Raise NamespaceUnavailableError if error means the namespace API is disabled."""
+        data = getattr(error, "data", None)
+        message = data.get("message", "") if isinstance(data, dict) else str(error)
+        reason = unavailable_reason(namespace, getattr(error, "status", None), str(message))
+        if reason:
+            raise NamespaceUnavailableError(namespace, owner, reason)
+
+    def list_namespace_secrets(self, namespace: str, org: str, repo: str = "") -> List[str]:
+        """List secret names in an Actions, Dependabot or Codespaces namespace.
+        
+        Args:
+            namespace: One of SECRET_NAMESPACES
+            org: Organization name
+            repo: Repository name; lists organization secrets when empty
+        """
+        path = secrets_api_path(namespace, org, repo)
+        owner = f"{org}/{repo}" if repo else org
+        try:
+            names: List[str] = []
+            page = 1
+            while True:
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", path, parameters={"per_page": 100, "page": page}
+                )
+                batch = [secret["name"] for secret in data.get("secrets", [])]
+                names.extend(batch)
+                if len(batch) < 100:
+                    break
+                page += 1
+            self._log_rate_limit(f"list_namespace_secrets({namespace}, {owner})")
+            return names
+        except Exception as e:
+            self._raise_if_namespace_unavailable(namespace, owner, e)
+            raise api_error(e, f"Failed to list {namespace} secrets in {owner}")
+
+    def delete_namespace_secret(self, namespace: str, org: str, repo: str, secret_name: str) -> None:
+        """Delete a secret from an Actions, Dependabot or Codespaces namespace.
+        
+        Args:
+            namespace: One of SECRET_NAMESPACES
+            org: Organization name
+            repo: Repository name; deletes an organization secret when empty
+            secret_name: Name of the secret to delete
+        """
+        path = f"{secrets_api_path(namespace, org, repo)}/{secret_name}"
+        owner = f"{org}/{repo}" if repo else org
+        try:
+            self.client.requester.requestJsonAndCheck("DELETE", path)
+            self._log_rate_limit(f"delete_namespace_secret({namespace}, {owner}/{secret_name})")
+            self.log.debug(f"Deleted {namespace} secret {secret_name} from {owner}")
+        except Exception as e:
+            self._raise_if_namespace_unavailable(namespace, owner, e)
+            raise api_error(e, f"Failed to delete {namespace} secret {secret_name} from {owner}")
+
+    def create_pull_request(self, org: str, repo: str, head: str, base: str, title: str, body: str) -> str:
+        """Open a pull request from head into base.
+        
+        Returns:
+            URL of the new pull request
+        """
+        try:
+            pull = self.client.get_repo(f"{org}/{repo}").create_pull(title=title, body=body, head=head, base=base)
+            self._log_rate_limit(f"create_pull_request({org}/{repo})")
+            self.log.debug(f"Opened pull request #{pull.number} in {org}/{repo}")
+            return pull.html_url
+        except Exception as e:
+            raise api_error(e, f"Failed to open pull request from {head} into {base} in {org}/{repo}")
+
+    def create_issue(self, org: str, repo: str, title: str, body: str) -> str:
+        """Open an issue in the repository.
+        
+        Returns:
+            URL of the new issue
+        """
+        try:
+            issue = self.client.get_repo(f"{org}/{repo}").create_issue(title=title, body=body)
+            self._log_rate_limit(f"create_issue({org}/{repo})")
+            self.log.debug(f"Opened issue #{issue.number} in {org}/{repo}")
+            return issue.html_url
+        except Exception as e:
+            raise api_error(e, f"Failed to open issue in {org}/{repo}")
+
+    def repository_exists(self, org: str, repo: str) -> bool:
+        """Return whether the repository exists and is visible to this token."""
+        try:
+            self.client.get_repo(f"{org}/{repo}")
+            self._log_rate_limit(f"repository_exists({org}/{repo})")
+            return True
+        except Exception as e:
+            if is_not_found_error(e):
+                return False
+            raise api_error(e, f"Failed to look up repository {org}/{repo}")
+
+    def list_org_repositories(self, org: str, repo_filter: Optional[RepoFilter] = None) -> List[str]:
+        """List the names of the organization's repositories, skipping archived ones and,
+        with repo_filter, those it leaves out."""
+        try:
+            infos = [
+                RepoInfo(
+                    repository.name,
+                    archived=bool(repository.archived),
+                    fork=bool(repository.fork),
+                    visibility=getattr(repository, "visibility", None)
+                    or ("private" if repository.private else "public"),
+                    language=repository.language or "",
+                    topics=getattr(repository, "topics", None) or [],
+                )
+                for repository in self.client.get_organization(org).get_repos()
+            ]
+            self._log_rate_limit(f"list_org_repositories({org})")
+        except Exception as e:
+            raise api_error(e, f"Failed to list repositories in {org}")
+        repo_filter = repo_filter or RepoFilter()
+        for info in infos:
+            reason = repo_filter.rejection(info)
+            if reason:
+                self.log.debug(f"Skipping {org}/{info.name} ({reason})")
+        return repo_filter.select(infos)
+
+    def list_audit_log(self, org: str, phrase: str) -> List[Dict]:
+        """Return the organization's audit log entries matching a search phrase, newest first.
+
+        The audit log API is only available on GitHub Enterprise Cloud and Server,
+        to organization owners (read:audit_log scope).
+        """
+        entries: List[Dict] = []
+        page = 1
+        try:
+            while True:
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"/orgs/{org}/audit-log",
+                    parameters={"phrase": phrase, "per_page": 100, "page": page}
+                )
+                batch = data if isinstance(data, list) else []
+                entries.extend(batch)
+                if len(batch) < 100:
+                    break
+                page += 1
+            self._log_rate_limit(f"list_audit_log({org})")
+        except Exception as e:
+            raise api_error(e, f"Failed to read the audit log of {org}")
+        return entries
+
+    def list_deploy_keys(self, org: str, repo: str) -> List[Dict]:
+        """List the deploy keys of a repository (public keys only; private keys cannot be read)."""
+        keys: List[Dict] = []
+        page = 1
+        try:
+            while True:
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"/repos/{org}/{repo}/keys", parameters={"per_page": 100, "page": page}
+                )
+                batch = data if isinstance(data, list) else []
+                keys.extend(batch)
+                if len(batch) < 100:
+                    break
+                page += 1
+            self._log_rate_limit(f"list_deploy_keys({org}/{repo})")
+        except Exception as e:
+            raise api_error(e, f"Failed to list deploy keys of {org}/{repo}")
+        return keys
+
+    def add_deploy_key(self, org: str, repo: str, title: str, key: str, read_only: bool = True) -> Dict:
+        """Install a deploy key on a repository and return it."""
+        try:
+            _, data = self.client.requester.requestJsonAndCheck(
+                "POST", f"/repos/{org}/{repo}/keys",
+                input={"title": title, "key": key, "read_only": read_only}
+            )
+            self._log_rate_limit(f"add_deploy_key({org}/{repo}/{title})")
+            self.log.debug(f"Added deploy key '{title}' to {org}/{repo}")
+            return data
+        except Exception as e:
+            raise api_error(e, f"Failed to add deploy key '{title}' to {org}/{repo}")
+
+    def get_repo_permissions(self, org: str, repo: str) -> Optional[Dict[str, bool]]:
+        """Return this token's permissions on a repository (admin, maintain, push, ...).
+        
+        Returns:
+            Permission flags, or None when the repository does not exist or is not visible
+        """
+        try:
+            _, data = self.client.requester.requestJsonAndCheck("GET", f"/repos/{org}/{repo}")
+            self._log_rate_limit(f"get_repo_permissions({org}/{repo})")
+            return data.get("permissions") or {}
+        except Exception as e:
+            if is_not_found_error(e):
+                return None
+            raise api_error(e, f"Failed to look up repository {org}/{repo}")
+
+    def get_token_scopes(self) -> Optional[List[str]]:
+        """Return the classic OAuth scopes of the token.
+        
+        Returns:
+            Scopes from the X-OAuth-Scopes header, or None for fine-grained and
+            GitHub App tokens, which do not report scopes
+        """
+        try:
+            headers, _ = self.client.requester.requestJsonAndCheck("GET", "/rate_limit")
+        except Exception as e:
+            raise api_error(e, f"Failed to read token scopes")
+        header = {key.lower(): value for key, value in headers.items()}.get("x-oauth-scopes")
+        if header is None:
+            return None
+        return [scope.strip() for scope in header.split(",") if scope.strip()]
+
+    def create_repository(self, org: str, repo: str, visibility: str) -> str:
+        """Create an empty repository in an organization.
+        
+        Args:
+            visibility: 'private', 'internal' (enterprise organizations only) or 'public'
+        
+        Returns:
+            URL of the new repository
+        """
+        try:
+            _, data = self.client.requester.requestJsonAndCheck(
+                "POST", f"/orgs/{org}/repos", input={"name": repo, "visibility": visibility, "auto_init": False}
+            )
+            self._log_rate_limit(f"create_repository({org}/{repo})")
+            self.log.debug(f"Created {visibility} repository {org}/{repo}")
+            return data.get("html_url", f"{org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to create repository {org}/{repo}")
+
+    def list_automation_files(self, org: str, repo: str, include_actions: bool = True) -> Dict[str, str]:
+        """Fetch workflow files and action metadata files from a repository.
+        
+        Reads every file in .github/workflows plus action.yml/action.yaml at the
+        repository root and one directory below it (the usual layout of
+        repositories hosting several actions).
+        
+        Args:
+            include_actions: Also look for action metadata files (False reads workflows only)
+        
+        Returns:
+            File contents keyed by repository path
+        """
+        def file_contents(path: str) -> list:
+            try:
+                contents = repository.get_contents(path)
+            except Exception as e:
+                if is_not_found_error(e):
+                    return []
+                raise
+            return contents if isinstance(contents, list) else [contents]
+
+        try:
+            repository = self.client.get_repo(f"{org}/{repo}")
+            files: Dict[str, str] = {}
+            for item in file_contents(WORKFLOWS_DIR):
+                if item.type == "file" and item.name.endswith((".yml", ".yaml")):
+                    files[item.path] = item.decoded_content.decode("utf-8", errors="replace")
+            for item in file_contents("") if include_actions else []:
+                candidates = [item] if item.type == "file" else []
+                if item.type == "dir" and not item.name.startswith("."):
+                    candidates = file_contents(item.path)
+                for candidate in candidates:
+                    if candidate.type == "file" and candidate.name in ACTION_FILES:
+                        files[candidate.path] = candidate.decoded_content.decode(
+                            "utf-8", errors="replace"
+                        )
+            self._log_rate_limit(f"list_automation_files({org}/{repo})")
+            return files
+        except Exception as e:
+            raise api_error(e, f"Failed to read workflows and actions in {org}/{repo}")
+
+    def branch_exists(self, org: str, repo: str, branch: str) -> bool:
+        """Return whether a branch exists in the repository."""
+        try:
+            self.client.get_repo(f"{org}/{repo}").get_git_ref(f"heads/{branch}")
+            return True
+        except Exception as e:
+            if is_not_found_error(e):
+                return False
+            raise api_error(e, f"Failed to look up branch {branch} in {org}/{repo}")
+
+    def file_exists(self, org: str, repo: str, path: str, ref: str) -> bool:
+        """Return whether a file exists at the given branch or commit."""
+        try:
+            self.client.get_repo(f"{org}/{repo}").get_contents(path, ref=ref)
+            return True
+        except Exception as e:
+            if is_not_found_error(e):
+                return False
+            raise api_error(e, f"Failed to look up {path} in {org}/{repo}@{ref}")
+
+    def get_latest_workflow_run(self, org: str, repo: str, workflow_file: str, branch: str) -> Optional[WorkflowRunInfo]:
+        """Return the most recent run of a workflow file on a branch, or None if it never ran."""
+        try:
+            repository = self.client.get_repo(f"{org}/{repo}")
+            try:
+                workflow = repository.get_workflow(workflow_file)
+            except Exception as e:
+                if is_not_found_error(e):
+                    return None
+                raise
+            for run in workflow.get_runs(branch=branch):
+                created_at = run.created_at.isoformat() if run.created_at else ""
+                return WorkflowRunInfo(run.id, run.status, run.conclusion, run.html_url, created_at)
+            return None
+        except Exception as e:
+            raise api_error(e, f"Failed to list runs of {workflow_file} in {org}/{repo}")
+
+    def get_workflow_run_state(self, org: str, repo: str, run_id: int) -> Tuple[str, Optional[str]]:
+        """Return a workflow run's status ('queued', 'in_progress', 'completed', ...) and conclusion."""
+        try:
+            run = self.client.get_repo(f"{org}/{repo}").get_workflow_run(run_id)
+            return run.status, run.conclusion
+        except Exception as e:
+            raise api_error(e, f"Failed to read workflow run {run_id} in {org}/{repo}")
+
+    def cancel_workflow_run(self, org: str, repo: str, run_id: int) -> None:
+        """Request cancellation of a queued or in-progress workflow run."""
+        try:
+            self.client.get_repo(f"{org}/{repo}").get_workflow_run(run_id).cancel()
+            self.log.debug(f"Requested cancellation of workflow run {run_id}")
+        except Exception as e:
+            raise api_error(e, f"Failed to cancel workflow run {run_id} in {org}/{repo}")
+
+    def download_run_logs(self, org: str, repo: str, run_id: int) -> str:
+        """Download the logs of every job of a workflow run, concatenated."""
+        return "\n".join(log for _, log in self.download_job_logs(org, repo, run_id))
+
+    def download_job_logs(self, org: str, repo: str, run_id: int) -> List[Tuple[str, str]]:
+        """Download the log of each job of a workflow run.
+        
+        GitHub answers each job's log request with a short-lived signed URL,
+        which is fetched without credentials.
+        
+        Returns:
+            (job name, log text) pairs, in job order
+        """
+        try:
+            run = self.client.get_repo(f"{org}/{repo}").get_workflow_run(run_id)
+            logs = [(job.name, get_text(job.logs_url(), self.timeout)) for job in run.jobs()]
+            self._log_rate_limit(f"download_job_logs({org}/{repo}#{run_id})")
+            return logs
+        except Exception as e:
+            raise api_error(e, f"Failed to download the logs of workflow run {run_id} in {org}/{repo}")
+
+    def get_actions_access_level(self, org: str, repo: str) -> Optional[str]:
+        """Read which repositories may use this repository's actions and reusable workflows.
+        
+        Returns:
+            'none', 'user', 'organization' or 'enterprise'; None when the server or
+            repository does not expose the setting (older GHES, public repositories)
+        """
+        try:
+            _, data = self.client.requester.requestJsonAndCheck(
+                "GET", f"/repos/{org}/{repo}/actions/permissions/access"
+            )
+            self._log_rate_limit(f"get_actions_access_level({org}/{repo})")
+            return data.get("access_level")
+        except Exception as e:
+            if is_not_found_error(e) or getattr(e, "status", None) == 422:
+                return None
+            raise api_error(e, f"Failed to read Actions access policy of {org}/{repo}")
+
+    def set_actions_access_level(self, org: str, repo: str, access_level: str) -> None:
+        """Set which repositories may use this repository's actions and reusable workflows."""
+        try:
+            self.client.requester.requestJsonAndCheck(
+                "PUT", f"/repos/{org}/{repo}/actions/permissions/access", input={"access_level": access_level}
+            )
+            self._log_rate_limit(f"set_actions_access_level({org}/{repo})")
+            self.log.debug(f"Set Actions access level of {org}/{repo} to {access_level}")
+        except Exception as e:
+            raise api_error(e, f"Failed to set Actions access policy of {org}/{repo}")
+
+    def get_branch_rules(self, org: str, repo: str, branch: str) -> List[dict]:
+        """List the ruleset rules that apply to a branch (which does not need to exist yet)."""
+        try:
+            rules: List[dict] = []
+            page = 1
+            while True:
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"/repos/{org}/{repo}/rules/branches/{quote(branch, safe='')}",
+                    parameters={"per_page": 100, "page": page}
+                )
+                batch = data if isinstance(data, list) else []
+                rules.extend(batch)
+                if len(batch) < 100:
+                    break
+                page += 1
+            self._log_rate_limit(f"get_branch_rules({org}/{repo}/{branch})")
+            return rules
+        except Exception as e:
+            raise api_error(e, f"Failed to read rulesets for branch {branch} in {org}/{repo}")
+
+    def list_branch_protection_rules(self, org: str, repo: str) -> List[dict]:
+        """List classic branch protection rules (patterns included) through the GraphQL API."""
+        query = """
+            query($owner: String!, $name: String!) {
+              repository(owner: $owner, name: $name) {
+                branchProtectionRules(first: 100) {
+                  nodes {
+                    pattern blocksCreations restrictsPushes
+                    requiresApprovingReviews requiresStatusChecks requiresCommitSignatures
+                  }
+                }
+              }
+            }
+        """
+        try:
+            _, data = self.client.requester.graphql_query(query, {"owner": org, "name": repo})
+            repository = data.get("data", {}).get("repository") or {}
+            self._log_rate_limit(f"list_branch_protection_rules({org}/{repo})")
+            return (repository.get("branchProtectionRules") or {}).get("nodes") or []
+        except Exception as e:
+            raise api_error(e, f"Failed to read branch protection rules in {org}/{repo}")
+
+    def export_environment_specs(self, org: str, repo: str) -> List[EnvironmentSpec]:
+        """Read every environment of a repository as an EnvironmentSpec.
+        
+        Includes protection rules, deployment branch/tag policies, secret names
+        and variables. Secret values cannot be read through the API.
+        """
+        try:
+            raw_environments: List[dict] = []
+            page = 1
+            while True:
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"/repos/{org}/{repo}/environments", parameters={"per_page": 100, "page": page}
+                )
+                batch = data.get("environments", [])
+                raw_environments.extend(batch)
+                if len(batch) < 100:
+                    break
+                page += 1
+
+            specs = []
+            for environment in raw_environments:
+                env_path = f"/repos/{org}/{repo}/environments/{quote(environment['name'], safe='')}"
+                policies: List[dict] = []
+                if (environment.get("deployment_branch_policy") or {}).get("custom_branch_policies"):
+                    _, data = self.client.requester.requestJsonAndCheck(
+                        "GET", f"{env_path}/deployment-branch-policies", parameters={"per_page": 100}
+                    )
+                    policies = data.get("branch_policies", [])
+                variables = {
+                    item["name"]: item.get("value", "") for item in self._list_variables(f"{env_path}/variables")
+                }
+                secrets = self.list_environment_secrets(org, repo, environment["name"])
+                specs.append(spec_from_api(environment, policies, secrets, variables))
+            self._log_rate_limit(f"export_environment_specs({org}/{repo})")
+            return specs
+        except Exception as e:
+            raise api_error(e, f"Failed to read environments of {org}/{repo}")
+
+    def _resolve_reviewers(self, org: str, reviewers: List[Dict[str, str]]) -> Tuple[List[dict], List[Dict[str, str]]]:
+        """Resolve {'user': login} / {'team': slug} reviewers to the IDs the environments API expects.
+        
+        Returns:
+            Tuple of (resolved reviewers, reviewers that do not exist in org)
+        """
+        resolved, unresolved = [], []
+        for reviewer in reviewers:
+            if "team" in reviewer:
+                path, kind = f"/orgs/{org}/teams/{quote(reviewer['team'], safe='')}", "Team"
+            else:
+                path, kind = f"/users/{quote(reviewer['user'], safe='')}", "User"
+            try:
+                _, data = self.client.requester.requestJsonAndCheck("GET", path)
+            except Exception as e:
+                if is_not_found_error(e):
+                    unresolved.append(reviewer)
+                    continue
+                raise
+            resolved.append({"type": kind, "id": data["id"]})
+        return resolved, unresolved
+
+    def _add_branch_policies(self, env_path: str, patterns: List[str]) -> None:
+        """Add the deployment branch/tag policies an environment lacks (existing ones are kept)."""
+        _, data = self.client.requester.requestJsonAndCheck(
+            "GET", f"{env_path}/deployment-branch-policies", parameters={"per_page": 100}
+        )
+        existing = {(item["name"], item.get("type", "branch")) for item in data.get("branch_policies", [])}
+        for pattern in patterns:
+            name, kind = split_branch_pattern(pattern)
+            if (name, kind) not in existing:
+                self.client.requester.requestJsonAndCheck(
+                    "POST", f"{env_path}/deployment-branch-policies", input={"name": name, "type": kind}
+                )
+
+    def get_deployment_branches(self, org: str, repo: str, environment_name: str) -> Union[str, List[str]]:
+        """Read which refs may deploy to an environment.
+        
+        Returns:
+            'all', 'protected' or the custom branch patterns ('tag:' prefix for tags)
+        """
+        env_path = f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}"
+        try:
+            _, environment = self.client.requester.requestJsonAndCheck("GET", env_path)
+            policy = environment.get("deployment_branch_policy")
+            policies: List[dict] = []
+            if (policy or {}).get("custom_branch_policies"):
+                _, data = self.client.requester.requestJsonAndCheck(
+                    "GET", f"{env_path}/deployment-branch-policies", parameters={"per_page": 100}
+                )
+                policies = data.get("branch_policies", [])
+            self._log_rate_limit(f"get_deployment_branches({org}/{repo}/{environment_name})")
+            return deployment_branches_from_api(policy, policies)
+        except Exception as e:
+            raise api_error(e, f"Failed to read deployment branch policy of environment '{environment_name}'")
+
+    def set_deployment_branches(self, org: str, repo: str, environment_name: str, branches: Union[str, List[str]]) -> None:
+        """Set which refs may deploy to an environment, keeping its wait timer and reviewers.
+        
+        Args:
+            branches: 'all', 'protected' or custom branch patterns ('tag:' prefix for
+                      tags); patterns the environment lacks are added, others are kept
+        """
+        env_path = f"/repos/{org}/{repo}/environments/{quote(environment_name, safe='')}"
+        try:
+            _, environment = self.client.requester.requestJsonAndCheck("GET", env_path)
+            payload = protection_from_api(environment)
+            payload["deployment_branch_policy"] = branch_policy_payload(branches)
+            self.client.requester.requestJsonAndCheck("PUT", env_path, input=payload)
+            if isinstance(branches, list) and branches:
+                self._add_branch_policies(env_path, branches)
+            self._log_rate_limit(f"set_deployment_branches({org}/{repo}/{environment_name})")
+            self.log.debug(f"Set deployment branch policy of environment '{environment_name}' in {org}/{repo}")
+        except Exception as e:
+            raise api_error(e, f"Failed to set deployment branch policy of environment '{environment_name}'")
+
+    def apply_environment_spec(self, org: str, repo: str, spec: EnvironmentSpec) -> EnvironmentApplyResult:
+        """Create or update an environment so it matches spec.
+        
+        Protection rules are replaced, missing deployment branch/tag policies are
+        added (existing ones are kept) and variables are created or updated.
+        Secrets are not written; their values are not part of a spec. Reviewers
+        that do not exist in org are left out rather than failing the update.
+        
+        Returns:
+            Secrets without a value on the target and reviewers that were left out
+        """
+        env_path = f"/repos/{org}/{repo}/environments/{quote(spec.name, safe='')}"
+        try:
+            reviewer_ids, unresolved = self._resolve_reviewers(org, spec.reviewers)
+            payload = spec.protection_payload(reviewer_ids)
+            self.client.requester.requestJsonAndCheck("PUT", env_path, input=payload)
+            self._created_resources.add(("env", org, repo, spec.name))
+
+            if spec.branch_patterns:
+                self._add_branch_policies(env_path, spec.branch_patterns)
+
+            current = {item["name"] for item in self._list_variables(f"{env_path}/variables")}
+            for name, value in spec.variables.items():
+                if name in current:
+                    self.client.requester.requestJsonAndCheck(
+                        "PATCH", f"{env_path}/variables/{name}", input={"name": name, "value": value}
+                    )
+                else:
+                    self.client.requester.requestJsonAndCheck(
+                        "POST", f"{env_path}/variables", input={"name": name, "value": value}
+                    )
+
+            present = set(self.list_environment_secrets(org, repo, spec.name))
+            self._log_rate_limit(f"apply_environment_spec({org}/{repo}/{spec.name})")
+            self.log.debug(f"Applied environment '{spec.name}' to {org}/{repo}")
+            return EnvironmentApplyResult([name for name in spec.secrets if name not in present], unresolved)
+        except Exception as e:
+            raise api_error(e, f"Failed to apply environment '{spec.name}' to {org}/{repo}")
+
+    def get_clock_skew(self) -> Optional[timedelta]:
+        """Estimate how far the API host's clock is ahead of the local clock.
+        
+        Uses the Date header of a rate-limit request (which does not count
+        against the rate limit). Returns None if the header is unavailable.
+        """
+        try:
+            sent_at = datetime.now(timezone.utc)
+            headers, _ = self.client.requester.requestJsonAndCheck("GET", "/rate_limit")
+            received_at = datetime.now(timezone.utc)
+        except Exception as e:
+            self.log.debug(f"Could not read server time: {e}")
+            return None
+        server_time = parse_http_date(headers.get("date", ""))
+        if server_time is None:
+            self.log.debug("Server response had no usable Date header")
+            return None
+        return estimate_skew(server_time, sent_at, received_at)
@@ -0,0 +1,176 @@
+"""Signed attestations of a migration, verifiable without this tool.
+
+The manifest states which secrets a recorded migration moved (names and
+outcomes, never values), between which repositories, when, and with whose
+tokens. It is signed with minisign or cosign (sigstore); auditors verify it
+with those tools and the signer's public key or, for keyless cosign
+signatures, the signer's identity.
+"""
+import json
+import shutil
+import subprocess  # nosec B404 - runs minisign/cosign with a fixed argv
+from datetime import datetime, timezone
+from typing import Any, Callable, Dict, List, Optional
+from src.core.migration_state import MigrationRecord
+
+MANIFEST_TYPE = "https://github.com/renan-alm/gh-secrets-migrator/migration-manifest/v1"
+ATTESTATION_SIGNERS = ("minisign", "cosign")
+
+
+def build_manifest(
+    record: MigrationRecord, generated_at: Optional[datetime] = None
+) -> Dict[str, Any]:
+    """Build the manifest of a recorded migration."""
+    inputs = record.inputs
+    org_to_org = bool(inputs.get("org_to_org"))
+    source = inputs.get("source_org", "")
+    if inputs.get("source_repo"):
+        source += f"/{inputs['source_repo']}"
+    if org_to_org:
+        targets = [inputs.get("target_org", "")]
+    else:
+        target_org = inputs.get("target_org", "")
+        targets = [f"{target_org}/{repo}" for repo in inputs.get("target_repos", [])]
+    return {
+        "type": MANIFEST_TYPE,
+        "generated_at": (generated_at or datetime.now(timezone.utc)).isoformat(),
+        "migration": {
+            "source": source,
+            "targets": targets,
+            "org_to_org": org_to_org,
+            "started_at": record.started_at,
+            "finished_at": record.finished_at or None,
+            "status": record.status,
+            "actors": record.actors,
+            "workflow_runs": [
+                resource.get("url") or resource.get("run_id")
+                for resource in record.resources_of("workflow_run")
+            ],
+        },
+        "secrets": [
+            {"level": level, "location": location, "name": name, **details}
+            for (level, location, name), details in sorted(record.secrets.items())
+        ],
+    }
+
+
+def manifest_text(manifest: Dict[str, Any]) -> str:
+    """Serialize a manifest deterministically: the signed bytes."""
+    return json.dumps(manifest, indent=2, sort_keys=True, ensure_ascii=False) + "\n"
+
+
+class Signer:
+    """Signs files and verifies their signatures with an external tool."""
+
+    tool = ""
+    # File written next to the signed file
+    suffix = ""
+
+    def __init__(self, executable: Optional[str] = None, run: Callable[..., Any] = subprocess.run):
+        """Locate the tool.
+
+        Args:
+            executable: Path of the tool (looked up on PATH by default)
+            run: subprocess.run replacement (injectable for tests)
+
+        Raises:
+            ValueError: If the tool is not installed
+        """
+        self.executable = executable or shutil.which(self.tool) or ""
+        if not self.executable:
+            raise ValueError(
+                f"the {self.tool} CLI is required to sign or verify but was not found on PATH"
+            )
+        self.run = run
+
+    def signature_path(self, path: str) -> str:
+        """Return where the signature of path is written."""
+        return path + self.suffix
+
+    def _call(self, argv: List[str]) -> str:
+        # No input or captured stdin: the tool may prompt for a key password
+        result = self.run(  # nosec B603 - fixed argv
+            [self.executable] + argv, stdout=subprocess.PIPE, stderr=subprocess.PIPE, timeout=300,
+        )
+        if result.returncode != 0:
+            detail = (result.stderr or b"").decode("utf-8", "replace").strip().splitlines()
+            raise ValueError(f"{self.tool} failed: {detail[-1] if detail else result.returncode}")
+        return (result.stdout or b"").decode("utf-8", "replace")
+
+    def sign(self, path: str, key: str, comment: str = "") -> str:
+        """Sign path, returning the signature file."""
+        raise NotImplementedError
+
+    def verify(self, path: str, key: str = "", identity: str = "", issuer: str = "") -> None:
+        """Verify the signature of path.
+
+        Raises:
+            ValueError: If the signature does not verify
+        """
+        raise NotImplementedError
+
+
+class MinisignSigner(Signer):
+    """minisign signatures (<file>.minisig), verified with the signer's public key."""
+
+    tool = "minisign"
+    suffix = ".minisig"
+
+    def sign(self, path: str, key: str, comment: str = "") -> str:
+        if not key:
+            raise ValueError("minisign needs a secret key file (--key)")
+        signature = self.signature_path(path)
+        argv = ["-S", "-s", key, "-m", path, "-x", signature]
+        if comment:
+            argv += ["-t", comment]
+        self._call(argv)
+        return signature
+
+    def verify(self, path: str, key: str = "", identity: str = "", issuer: str = "") -> None:
+        if not key:
+            raise ValueError("minisign verification needs the signer's public key file (--key)")
+        self._call(["-V", "-p", key, "-m", path, "-x", self.signature_path(path)])
+
+
+class CosignSigner(Signer):
+    """Sigstore bundles (<file>.sigstore.json) written by cosign.
+
+    With a key, the bundle is verified with its public key; without one,
+    cosign signs keylessly with an OIDC identity recorded in the bundle and
+    in the public transparency log, verified by identity and issuer.
+    """
+
+    tool = "cosign"
+    suffix = ".sigstore.json"
+
+    def sign(self, path: str, key: str, comment: str = "") -> str:
+        signature = self.signature_path(path)
+        argv = ["sign-blob", "--yes", "--bundle", signature]
+        if key:
+            argv += ["--key", key]
+        self._call(argv + [path])
+        return signature
+
+    def verify(self, path: str, key: str = "", identity: str = "", issuer: str = "") -> None:
+        argv = ["verify-blob", "--bundle", self.signature_path(path)]
+        if key:
+            argv += ["--key", key]
+        elif identity and issuer:
+            argv += ["--certificate-identity", identity, "--certificate-oidc-issuer", issuer]
+        else:
+            raise ValueError(
+                "keyless cosign verification needs the signer's identity and OIDC issuer"
+            )
+        self._call(argv + [path])
+
+
+def make_signer(tool: str, executable: Optional[str] = None) -> Signer:
+    """Return the signer using tool ('minisign' or 'cosign').
+
+    Raises:
+        ValueError: If tool is unknown or not installed
+    """
+    signers = {"minisign": MinisignSigner, "cosign": CosignSigner}
+    if tool not in signers:
+        raise ValueError(f"unknown signer '{tool}': expected {', '.join(ATTESTATION_SIGNERS)}")
+    return signers[tool](executable)
@@ -0,0 +1,169 @@
+"""Tamper-evident audit log of the GitHub API calls a run performs."""
+import getpass
+import hashlib
+import json
+import os
+import socket
+from datetime import datetime, timezone
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+from urllib.parse import urlparse
+
+# prev_hash of the first record of a log
+GENESIS_HASH = "0" * 64
+
+READ = "read"
+WRITE = "write"
+
+
+def record_hash(record: Dict[str, Any]) -> str:
+    """Return the SHA-256 of a record's canonical JSON, excluding its own hash."""
+    body = {key: value for key, value in record.items() if key != "hash"}
+    canonical = json.dumps(body, sort_keys=True, separators=(",", ":"))
+    return hashlib.sha256(canonical.encode("utf-8")).hexdigest()
+
+
+def request_path(url: str) -> str:
+    """Reduce a request URL (absolute for paginated calls) to its API path, without the query."""
+    if "://" in url:
+        return urlparse(url).path or "/"
+    return url.split("?", 1)[0]
+
+
+def operation_of(method: str) -> str:
+    """Classify an HTTP method as a read or a write."""
+    return READ if method.upper() in ("GET", "HEAD") else WRITE
+
+
+def _operator() -> str:
+    """Return who runs the tool: local user and host."""
+    try:
+        user = getpass.getuser()
+    except Exception:
+        user = "unknown"
+    return f"{user}@{socket.gethostname()}"
+
+
+def _parse(line: str, number: int) -> Dict[str, Any]:
+    try:
+        record = json.loads(line)
+    except ValueError:
+        raise ValueError(f"line {number}: not a JSON record")
+    if not isinstance(record, dict):
+        raise ValueError(f"line {number}: not a JSON record")
+    return record
+
+
+class AuditLog:
+    """Append-only JSON Lines log whose records are chained by hash.
+
+    Each record stores the hash of the previous one, so editing, removing or
+    reordering a record breaks the chain (see verify_chain). The file is
+    only ever appended to, by every run pointed at it, and never holds
+    secret values: request bodies are not recorded.
+    """
+
+    def __init__(self, path: str, operator: str = ""):
+        """Open (or create on first write) the log at path, continuing its chain.
+
+        Raises:
+            OSError: If the existing log cannot be read
+            ValueError: If its last record is malformed
+        """
+        self.path = path
+        self.operator = operator or _operator()
+        self.appended = 0
+        self._seq, self._head = 0, GENESIS_HASH
+        if os.path.exists(path):
+            with open(path, "r", encoding="utf-8") as handle:
+                lines = [line for line in handle if line.strip()]
+            if lines:
+                last = _parse(lines[-1], len(lines))
+                self._seq, self._head = int(last.get("seq", 0)), str(last.get("hash", ""))
+
+    @property
+    def head(self) -> str:
+        """Hash of the last record; anchoring it elsewhere also proves nothing was truncated."""
+        return self._head
+
+    def record(
+        self,
+        identity: Dict[str, str],
+        method: str,
+        url: str,
+        status: Optional[int] = None,
+        failed: bool = False,
+        operation: str = ""
+    ) -> Dict[str, Any]:
+        """Append one API call.
+
+        Args:
+            identity: Token identity ('side', 'login', 'token' fingerprint)
+            method: HTTP method
+            url: Request URL or path
+            status: HTTP status of a failed call (None when it succeeded or never got a response)
+            failed: Whether the call raised
+            operation: 'read' or 'write' (derived from method by default)
+
+        Returns:
+            The appended record
+        """
+        record: Dict[str, Any] = {
+            "seq": self._seq + 1,
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "operator": self.operator,
+            "identity": dict(identity),
+            "operation": operation or operation_of(method),
+            "method": method.upper(),
+            "path": request_path(url),
+            "outcome": "failed" if failed else "ok",
+            "status": status,
+            "prev_hash": self._head,
+        }
+        record["hash"] = record_hash(record)
+        fd = os.open(self.path, os.O_WRONLY | os.O_APPEND | os.O_CREAT, 0o600)
+        with os.fdopen(fd, "a", encoding="utf-8") as handle:
+            handle.write(json.dumps(record, sort_keys=True) + "\n")
+        self._seq, self._head = record["seq"], record["hash"]
+        self.appended += 1
+        return record
+
+
+def verify_chain(lines: Iterable[str], anchor: str = "") -> Tuple[int, str, List[str]]:
+    """Check an audit log's hash chain.
+
+    The chain alone cannot reveal that the newest records were cut off; a
+    head hash kept elsewhere after an earlier run (anchor) can, since it
+    must still be the hash of some record.
+
+    Args:
+        lines: Lines of the log file
+        anchor: Optional hash (or hash prefix) of a record known to exist
+
+    Returns:
+        (records, head hash, problems); problems is empty when no record was
+        edited, removed or reordered and the anchor was found
+    """
+    problems: List[str] = []
+    anchored = not anchor
+    count, seq, head = 0, 0, GENESIS_HASH
+    for number, line in enumerate(lines, 1):
+        if not line.strip():
+            continue
+        try:
+            record = _parse(line, number)
+        except ValueError as e:
+            problems.append(str(e))
+            continue
+        count += 1
+        if record.get("seq") != seq + 1:
+            problems.append(f"line {number}: sequence {record.get('seq')}, expected {seq + 1}")
+        if record.get("prev_hash") != head:
+            problems.append(f"line {number}: chain broken (records removed or reordered)")
+        if record.get("hash") != record_hash(record):
+            problems.append(f"line {number}: hash mismatch (record edited)")
+        seq = record.get("seq") if isinstance(record.get("seq"), int) else seq + 1
+        head = str(record.get("hash", ""))
+        anchored = anchored or head.startswith(anchor)
+    if not anchored:
+        problems.append(f"no record has hash {anchor} (records dropped from the end)")
+    return count, head, problems
@@ -0,0 +1,89 @@
+"""Secret and variable changes read from a source organization's audit log.
+
+Syncs driven by the audit log (GitHub Enterprise Cloud and Server) skip the
+scan of the source altogether when no Actions secret or variable of the
+migrated levels was created, updated or removed since the last run.
+"""
+from datetime import datetime, timedelta, timezone
+from typing import Any, Dict, Iterable, List, Optional, Sequence
+
+# Audit log entries can show up some minutes after the change they record
+AUDIT_LOG_LAG = timedelta(minutes=10)
+
+# Audit log action prefix of each level (as in MigrationConfig.levels)
+_LEVEL_PREFIXES = {"org": "org.", "repo": "repo.", "env": "environment."}
+_OPERATIONS = ("create", "update", "remove")
+
+
+def audited_actions(
+    levels: Sequence[str], secrets: bool = True, variables: bool = True
+) -> List[str]:
+    """Return the audit log actions that change secrets and/or variables of levels."""
+    kinds = [kind for kind, wanted in (("secret", secrets), ("variable", variables)) if wanted]
+    return [
+        f"{_LEVEL_PREFIXES[level]}{operation}_actions_{kind}"
+        for level in levels for kind in kinds for operation in _OPERATIONS
+    ]
+
+
+def audit_log_phrase(since: datetime, repo: str = "") -> str:
+    """Build the audit log search phrase for entries since a time (and of one 'org/repo')."""
+    stamp = since.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+    phrase = f"created:>={stamp}"
+    return f"{phrase} repo:{repo}" if repo else phrase
+
+
+def _entry_time(entry: Dict[str, Any]) -> Optional[datetime]:
+    """Return when an entry was recorded (@timestamp is in milliseconds), or None."""
+    stamp = entry.get("@timestamp", entry.get("created_at"))
+    if isinstance(stamp, (int, float)):
+        return datetime.fromtimestamp(stamp / 1000, tz=timezone.utc)
+    return None
+
+
+class AuditedChange:
+    """One audit log entry recording a change to an Actions secret or variable."""
+
+    def __init__(
+        self, action: str, repo: str = "", actor: str = "", at: Optional[datetime] = None
+    ):
+        self.action = action
+        self.repo = repo  # 'org/repo'; empty for organization secrets and variables
+        self.actor = actor
+        self.at = at
+
+    def describe(self) -> str:
+        """Return the change as one log line."""
+        line = self.action + (f" in {self.repo}" if self.repo else "")
+        if self.actor:
+            line += f" by {self.actor}"
+        if self.at:
+            line += f" at {self.at.isoformat(timespec='seconds')}"
+        return line
+
+
+def audited_changes(
+    entries: Iterable[Dict[str, Any]],
+    levels: Sequence[str],
+    repo: str = "",
+    secrets: bool = True,
+    variables: bool = True
+) -> List[AuditedChange]:
+    """Pick the entries that change secrets or variables a run migrates.
+
+    Organization-level changes count wherever they happened; repository and
+    environment changes count only in repo ('org/repo'), unless repo is empty.
+    """
+    actions = set(audited_actions(levels, secrets, variables))
+    changes = []
+    for entry in entries:
+        action = str(entry.get("action", ""))
+        if action not in actions:
+            continue
+        entry_repo = str(entry.get("repo") or entry.get("repository") or "")
+        organization = action.startswith(_LEVEL_PREFIXES["org"])
+        if repo and not organization and entry_repo.lower() != repo.lower():
+            continue
+        actor = str(entry.get("actor") or "")
+        changes.append(AuditedChange(action, entry_repo, actor, _entry_time(entry)))
+    return changes
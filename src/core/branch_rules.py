@@ -0,0 +1,102 @@
+"""Preflight of rulesets and branch protections that would block the migration branch."""
+from fnmatch import fnmatchcase
+from typing import Any, Dict, List
+
+BRANCH_CHECK_MODES = ("warn", "fail", "auto", "off")
+
+# Prefixes tried by --branch-check auto, in order; repositories often only
+# allow branches under an automation namespace
+AUTO_BRANCH_PREFIXES = ("", "secrets-migrator/", "automation/", "chore/", "ci/")
+
+# Ruleset rule types that stop the migrator from creating the branch or committing to it
+_BLOCKING_RULES = {
+    "creation": "restricts branch creation",
+    "update": "restricts pushes",
+    "pull_request": "requires changes through a pull request",
+    "required_status_checks": "requires status checks before pushing",
+    "required_deployments": "requires deployments before pushing",
+    "required_signatures": "requires signed commits",
+    "committer_email_pattern": "restricts committer emails",
+    "commit_author_email_pattern": "restricts commit author emails",
+}
+
+
+class BranchBlocker:
+    """A rule that would reject creating the migration branch or pushing the workflow."""
+
+    def __init__(self, source: str, reason: str):
+        self.source = source  # e.g. "ruleset 'protect-all'" or "branch protection 'release/*'"
+        self.reason = reason
+
+    def __str__(self) -> str:
+        return f"{self.source} {self.reason}"
+
+
+def ruleset_blockers(rules: List[Dict[str, Any]], workflow_path: str) -> List[BranchBlocker]:
+    """Select the active ruleset rules for a branch that block the migration.
+
+    Args:
+        rules: Items of GET /repos/{owner}/{repo}/rules/branches/{branch}
+        workflow_path: Path of the workflow file the migrator commits
+    """
+    blockers = []
+    for rule in rules:
+        kind = rule.get("type", "")
+        source = f"ruleset {rule.get('ruleset_id', '?')}"
+        if kind in _BLOCKING_RULES:
+            blockers.append(BranchBlocker(source, _BLOCKING_RULES[kind]))
+        elif kind == "file_path_restriction":
+            paths = (rule.get("parameters") or {}).get("restricted_file_paths") or []
+            if any(fnmatchcase(workflow_path, pattern) for pattern in paths):
+                blockers.append(BranchBlocker(source, f"restricts changes to {workflow_path}"))
+    return blockers
+
+
+def protection_blockers(protections: List[Dict[str, Any]], branch: str) -> List[BranchBlocker]:
+    """Select classic branch protection rules matching branch that block the migration.
+
+    Args:
+        protections: Branch protection rules as returned by the GraphQL API
+            (pattern, blocksCreations, restrictsPushes, requiresApprovingReviews,
+            requiresStatusChecks, requiresCommitSignatures)
+        branch: Migration branch name
+    """
+    blockers = []
+    for protection in protections:
+        pattern = protection.get("pattern", "")
+        if not branch_pattern_matches(pattern, branch):
+            continue
+        source = f"branch protection '{pattern}'"
+        if protection.get("blocksCreations") or protection.get("restrictsPushes"):
+            blockers.append(BranchBlocker(source, "restricts who can create or push to the branch"))
+        if protection.get("requiresApprovingReviews"):
+            blockers.append(BranchBlocker(source, "requires changes through a pull request"))
+        if protection.get("requiresStatusChecks"):
+            blockers.append(BranchBlocker(source, "requires status checks before pushing"))
+        if protection.get("requiresCommitSignatures"):
+            blockers.append(BranchBlocker(source, "requires signed commits"))
+    return blockers
+
+
+def branch_pattern_matches(pattern: str, branch: str) -> bool:
+    """Match a branch protection pattern (fnmatch syntax; '*' does not cross '/', '**' does)."""
+    if "**" in pattern:
+        return fnmatchcase(branch, pattern.replace("**", "*"))
+    return fnmatchcase(branch, pattern) and pattern.count("/") == branch.count("/")
+
+
+def candidate_branches(branch: str) -> List[str]:
+    """Branch names tried in order by --branch-check auto."""
+    return [f"{prefix}{branch}" for prefix in AUTO_BRANCH_PREFIXES]
+
+
+def remediation(branch: str, blockers: List[BranchBlocker]) -> List[str]:
+    """Return human-readable lines explaining why branch is blocked and how to fix it."""
+    lines = [f"Branch '{branch}' would be rejected by the source repository's rules:"]
+    lines.extend(f"  - {blocker}" for blocker in blockers)
+    lines.append(
+        "  Exclude the branch from these rules, add the token's user or app as a bypass actor, "
+        "pick an allowed name with --branch-name, or let --branch-check auto try common "
+        "automation prefixes"
+    )
+    return lines
@@ -0,0 +1,88 @@
+"""HMAC-signed JSON callbacks reporting run progress to an external system."""
+import hashlib
+import hmac
+import uuid
+from datetime import datetime, timezone
+from typing import Any, Callable, Dict, Optional
+from src.utils.http import encode_json, post_json
+from src.utils.logger import Logger
+
+CALLBACK_SECRET_ENV = "GH_SECRETS_MIGRATOR_CALLBACK_SECRET"
+SIGNATURE_HEADER = "X-Secrets-Migrator-Signature-256"
+EVENT_HEADER = "X-Secrets-Migrator-Event"
+RUN_ID_HEADER = "X-Secrets-Migrator-Run-Id"
+
+# started/finished/failed frame a run; job_* are sent per pipeline job
+CALLBACK_EVENTS = ("started", "job_finished", "job_failed", "finished", "failed")
+
+
+def sign_payload(secret: str, body: bytes) -> str:
+    """Return the signature header value for a request body ('sha256=<hex>')."""
+    digest = hmac.new(secret.encode("utf-8"), body, hashlib.sha256).hexdigest()
+    return f"sha256={digest}"
+
+
+def verify_signature(secret: str, body: bytes, signature: str) -> bool:
+    """Check a received signature header in constant time (for receivers and tests)."""
+    return hmac.compare_digest(sign_payload(secret, body), signature)
+
+
+class CallbackSender:
+    """Posts signed lifecycle callbacks for one run.
+
+    Every payload carries the event name, a run id shared by all callbacks of
+    the run, the CLI command and a UTC timestamp. Failures to deliver are
+    logged as warnings and never fail the migration.
+    """
+
+    def __init__(
+        self,
+        url: str,
+        secret: str,
+        command: str,
+        logger: Logger,
+        redact: Callable[[Any], Any] = lambda value: value,
+        post: Callable[..., None] = post_json
+    ):
+        self.url = url
+        self.secret = secret
+        self.command = command
+        self.log = logger
+        self.redact = redact
+        self.post = post
+        self.run_id = str(uuid.uuid4())
+
+    def build_payload(self, event: str, **fields: Any) -> Dict[str, Any]:
+        """Build the (redacted) callback document for event."""
+        if event not in CALLBACK_EVENTS:
+            raise ValueError(f"Unknown callback event '{event}'")
+        payload: Dict[str, Any] = {
+            "event": event,
+            "run_id": self.run_id,
+            "command": self.command,
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+        }
+        payload.update(fields)
+        return self.redact(payload)
+
+    def send(self, event: str, **fields: Any) -> Optional[Dict[str, Any]]:
+        """Post a callback if a URL is configured.
+
+        Returns:
+            The payload that was sent, or None if callbacks are disabled or delivery failed
+        """
+        if not self.url:
+            return None
+        payload = self.build_payload(event, **fields)
+        headers = {
+            SIGNATURE_HEADER: sign_payload(self.secret, encode_json(payload)),
+            EVENT_HEADER: event,
+            RUN_ID_HEADER: self.run_id,
+        }
+        try:
+            self.post(self.url, payload, headers=headers, schemes=("http", "https"))
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Failed to deliver '{event}' callback: {e}")
+            return None
+        self.log.debug(f"Delivered '{event}' callback to {self.url}")
+        return payload
@@ -0,0 +1,58 @@
+"""Which placeholder secrets an aborted migration leaves behind on the target."""
+from datetime import datetime
+from typing import List, Optional
+from src.core.inventory import SecretRecord
+from src.utils.clock import as_utc
+from src.core.migration_state import MigrationRecord
+
+# Polls (one per workflow run poll interval) waiting for a cancelled run to stop
+CANCEL_WAIT_POLLS = 12
+
+
+def recorded_placeholders(record: MigrationRecord, target_repo: str) -> List[SecretRecord]:
+    """Return the placeholders a recorded migration created on target_repo (or its organization).
+
+    Each record's updated_at is when the placeholder was written, if recorded.
+
+    Args:
+        record: Migration from the state file
+        target_repo: Target repository ('' for an organization secrets migration)
+    """
+    placeholders = []
+    for resource in record.resources_of("placeholder"):
+        if resource["level"] != "org" and resource.get("repo") != target_repo:
+            continue
+        created = resource.get("created_at")
+        placeholders.append(SecretRecord(
+            resource["name"], resource["level"], environment=resource.get("environment", ""),
+            updated_at=as_utc(datetime.fromisoformat(created)) if created else None
+        ))
+    return placeholders
+
+
+def placeholder_only(
+    placeholders: List[SecretRecord],
+    target_records: List[SecretRecord],
+    run_started: Optional[datetime] = None
+) -> List[SecretRecord]:
+    """Select the placeholders the workflow never overwrote with the real value.
+
+    The workflow only writes secrets once its run has started, so a
+    placeholder last updated before the run was created (or with no run at
+    all) still holds the placeholder value. Secrets without a known update
+    time are kept, as are placeholders no longer on the target.
+
+    Args:
+        placeholders: Placeholders the migration created (see src.core.placeholders)
+        target_records: Secrets currently on the target, with their update times
+        run_started: Creation time of the migration's workflow run, if it ran
+    """
+    current = {record.key: record for record in target_records}
+    selected = []
+    for placeholder in placeholders:
+        record = current.get(placeholder.key)
+        if record is None or record.updated_at is None:
+            continue
+        if run_started is None or as_utc(record.updated_at) < as_utc(run_started):
+            selected.append(record)
+    return selected
@@ -0,0 +1,239 @@
+"""Capability matrix: what a migration run will and won't do, validated before any write."""
+from typing import Any, Dict, List, Optional, Sequence
+from src.core.namespaces import OPTIONAL_NAMESPACES
+
+ALLOWED = "allowed"
+SKIPPED = "skipped"  # not requested, or not applicable to the mode
+BLOCKED = "blocked"  # requested, but the token or host does not allow it
+
+# Classic token scopes granting narrower ones
+_IMPLIED_SCOPES = {
+    "repo": ("public_repo", "repo:status", "repo_deployment", "repo:invite", "security_events"),
+    "admin:org": ("write:org", "read:org"),
+    "write:org": ("read:org",),
+}
+
+
+class Capability:
+    """One row of the matrix: a part of the migration and whether this run may do it."""
+
+    def __init__(self, namespace: str, status: str, reason: str):
+        self.namespace = namespace
+        self.status = status
+        self.reason = reason
+
+    def to_dict(self) -> Dict[str, str]:
+        """Return the row as recorded in the event log."""
+        return {"namespace": self.namespace, "status": self.status, "reason": self.reason}
+
+
+class CapabilityMatrix:
+    """Rows of namespace × allowed/skipped/blocked × reason for a run."""
+
+    def __init__(self) -> None:
+        self.rows: List[Capability] = []
+
+    def add(self, namespace: str, status: str, reason: str) -> None:
+        """Append a row."""
+        self.rows.append(Capability(namespace, status, reason))
+
+    @property
+    def blocked(self) -> List[Capability]:
+        """Rows requested by the run that cannot be carried out."""
+        return [row for row in self.rows if row.status == BLOCKED]
+
+    def format_table(self) -> List[str]:
+        """Render the matrix as aligned text lines (header first)."""
+        header = ("NAMESPACE", "STATUS", "REASON")
+        cells = [header] + [(row.namespace, row.status, row.reason) for row in self.rows]
+        widths = [max(len(cell[column]) for cell in cells) for column in range(2)]
+        return [
+            f"{namespace.ljust(widths[0])}  {status.ljust(widths[1])}  {reason}"
+            for namespace, status, reason in cells
+        ]
+
+
+def missing_scopes(granted: Optional[Sequence[str]], required: Sequence[str]) -> List[str]:
+    """Return the required classic token scopes the token lacks.
+
+    Args:
+        granted: Scopes from the X-OAuth-Scopes header; None for fine-grained
+            and app tokens, whose permissions can only be probed
+        required: Scopes the operation needs
+    """
+    if granted is None:
+        return []
+    effective = set(granted)
+    for scope in granted:
+        effective.update(_IMPLIED_SCOPES.get(scope, ()))
+    return [scope for scope in required if scope not in effective]
+
+
+def _scope_reason(token: str, missing: List[str]) -> str:
+    return f"{token} token lacks scope(s) {', '.join(missing)}"
+
+
+def _unsupported_namespaces(matrix: CapabilityMatrix) -> None:
+    for namespace in OPTIONAL_NAMESPACES:
+        matrix.add(
+            f"{namespace} secrets", SKIPPED,
+            "not migrated: values are not exposed to the migration workflow"
+        )
+
+
+def _org_rows(
+    matrix: CapabilityMatrix,
+    config: Any,
+    source_scopes: Optional[Sequence[str]],
+    target_scopes: Optional[Sequence[str]]
+) -> None:
+    """Add the organization secret and variable rows of a run processing the 'org' level."""
+    source_missing = missing_scopes(source_scopes, ("repo", "workflow", "admin:org"))
+    target_missing = missing_scopes(target_scopes, ("admin:org",))
+    if not config.migrate_secrets:
+        matrix.add("organization secrets", SKIPPED, "--variables-only")
+    elif source_missing:
+        matrix.add("organization secrets", BLOCKED, _scope_reason("source", source_missing))
+    elif target_missing:
+        matrix.add("organization secrets", BLOCKED, _scope_reason("target", target_missing))
+    else:
+        matrix.add(
+            "organization secrets", ALLOWED, "copied with visibility and selected repositories"
+        )
+
+    variables_missing = missing_scopes(source_scopes, ("admin:org",))
+    if not config.migrate_variables:
+        matrix.add("organization variables", SKIPPED, "--secrets-only")
+    elif variables_missing:
+        matrix.add("organization variables", BLOCKED, _scope_reason("source", variables_missing))
+    elif target_missing:
+        matrix.add("organization variables", BLOCKED, _scope_reason("target", target_missing))
+    else:
+        matrix.add(
+            "organization variables", ALLOWED,
+            "copied through the API with visibility and selected repositories"
+        )
+
+
+def repo_capabilities(
+    config: Any,
+    source_scopes: Optional[Sequence[str]],
+    target_scopes: Optional[Sequence[str]],
+    target_exists: bool,
+    target_admin: Optional[bool]
+) -> CapabilityMatrix:
+    """Build the matrix of a repository-to-repository run.
+
+    Args:
+        config: MigrationConfig of the run
+        source_scopes: Classic scopes of the source token (None if not a classic token)
+        target_scopes: Classic scopes of the target token (None if not a classic token)
+        target_exists: Whether the target repository exists
+        target_admin: Whether the target token administers the target
+            repository (None when unknown, e.g. the repository does not exist yet)
+    """
+    matrix = CapabilityMatrix()
+    source_missing = missing_scopes(source_scopes, ("repo", "workflow"))
+    target_missing = missing_scopes(target_scopes, ("repo",))
+
+    if target_exists:
+        matrix.add("target repository", ALLOWED, "exists")
+    elif config.create_target_repo:
+        matrix.add(
+            "target repository", ALLOWED,
+            f"missing; will be created ({config.target_repo_visibility})"
+        )
+        target_admin = True  # the creator administers a new repository
+    else:
+        matrix.add(
+            "target repository", BLOCKED, "not found; pass --create-target-repo to create it"
+        )
+
+    levels = config.selected_levels
+    if "repo" not in levels:
+        matrix.add("repository secrets", SKIPPED, "level not selected (--levels)")
+    elif not config.migrate_secrets:
+        matrix.add("repository secrets", SKIPPED, "--variables-only")
+    elif source_missing:
+        matrix.add("repository secrets", BLOCKED, _scope_reason("source", source_missing))
+    elif target_missing:
+        matrix.add("repository secrets", BLOCKED, _scope_reason("target", target_missing))
+    else:
+        matrix.add("repository secrets", ALLOWED, "copied by a workflow run on the source")
+
+    if "repo" not in levels:
+        matrix.add("repository variables", SKIPPED, "level not selected (--levels)")
+    elif not config.migrate_variables:
+        matrix.add("repository variables", SKIPPED, "--secrets-only")
+    elif target_missing:
+        matrix.add("repository variables", BLOCKED, _scope_reason("target", target_missing))
+    else:
+        matrix.add("repository variables", ALLOWED, "copied through the API")
+
+    if "env" not in levels:
+        for namespace in ("environments", "environment secrets", "environment variables"):
+            matrix.add(namespace, SKIPPED, "level not selected (--levels)")
+    else:
+        if config.skip_envs:
+            matrix.add("environments", SKIPPED, "--skip-envs")
+            into, status = "into environments that already exist on target", ALLOWED
+        elif target_admin is False:
+            matrix.add(
+                "environments", BLOCKED, "target token cannot administer the target repository"
+            )
+            into, status = "their environments cannot be created", BLOCKED
+        else:
+            matrix.add("environments", ALLOWED, "recreated on target")
+            into, status = "", ALLOWED
+        if not config.migrate_secrets:
+            matrix.add("environment secrets", SKIPPED, "--variables-only")
+        else:
+            matrix.add("environment secrets", status, into or "copied with repository secrets")
+        if not config.migrate_variables:
+            matrix.add("environment variables", SKIPPED, "--secrets-only")
+        else:
+            matrix.add("environment variables", status, into or "copied through the API")
+
+    if not config.migrate_settings:
+        matrix.add("repository settings", SKIPPED, "not requested (--migrate-settings)")
+    elif target_admin is False:
+        matrix.add(
+            "repository settings", BLOCKED, "target token cannot administer the target repository"
+        )
+    else:
+        matrix.add("repository settings", ALLOWED, "Actions access policy")
+
+    if "org" in levels:
+        _org_rows(matrix, config, source_scopes, target_scopes)
+    else:
+        matrix.add(
+            "organization secrets", SKIPPED, "only migrated with --org-to-org or --levels org"
+        )
+    _unsupported_namespaces(matrix)
+    return matrix
+
+
+def org_capabilities(
+    config: Any,
+    source_scopes: Optional[Sequence[str]],
+    target_scopes: Optional[Sequence[str]]
+) -> CapabilityMatrix:
+    """Build the matrix of an organization-to-organization run.
+
+    Args:
+        config: MigrationConfig of the run
+        source_scopes: Classic scopes of the source token (None if not a classic token)
+        target_scopes: Classic scopes of the target token (None if not a classic token)
+    """
+    matrix = CapabilityMatrix()
+    _org_rows(matrix, config, source_scopes, target_scopes)
+
+    not_applicable = "not applicable to org-to-org mode"
+    matrix.add("repository secrets", SKIPPED, not_applicable)
+    matrix.add("environments", SKIPPED, not_applicable)
+    if config.create_target_repo:
+        matrix.add("target repository", SKIPPED, f"--create-target-repo {not_applicable}")
+    if config.migrate_settings:
+        matrix.add("repository settings", SKIPPED, f"--migrate-settings {not_applicable}")
+    _unsupported_namespaces(matrix)
+    return matrix
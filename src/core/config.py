@@ -1,4 +1,95 @@
 """Configuration for migration."""
+import inspect
+from typing import Any, Dict, List, Optional, Sequence
+from src.clients.github import DEFAULT_API_TIMEOUT
+from src.core.credentials import GITHUB_COM
+from src.core.placeholders import DEFAULT_PLACEHOLDER_VALUE
+from src.core.snapshots import DEFAULT_STATE_DIR
+from src.core.workflow_generator import DEFAULT_WORKFLOW_TIMEOUT_MINUTES, GH_CLI_PINNED_VERSION
+
+# Secret and variable scopes --levels selects from
+MIGRATION_LEVELS = ("repo", "env", "org")
+
+# Options holding credentials, never read from or written to files (pipelines, plans)
+CREDENTIAL_OPTIONS = ("source_pat", "target_pat", "state_passphrase")
+
+
+def parse_levels(value: str) -> List[str]:
+    """Parse a comma-separated --levels value (e.g. 'repo,env')."""
+    return [level.strip() for level in value.split(",") if level.strip()]
+
+
+def check_selection(
+    levels: Sequence[str], org_to_org: bool, migrate_secrets: bool, migrate_variables: bool
+) -> None:
+    """Reject level and category selections a run cannot carry out.
+
+    Raises:
+        ValueError: If a level is unknown, a repository level is combined with
+            org_to_org, or neither secrets nor variables are migrated
+    """
+    unknown = [level for level in levels if level not in MIGRATION_LEVELS]
+    if unknown:
+        raise ValueError(
+            f"unknown level(s) {', '.join(unknown)}: expected {', '.join(MIGRATION_LEVELS)}"
+        )
+    if org_to_org and any(level != "org" for level in levels):
+        raise ValueError("organization-to-organization runs only process the 'org' level")
+    if not (migrate_secrets or migrate_variables):
+        raise ValueError("secrets-only and variables-only cannot be combined")
+
+
+def check_promotion(promote_to_org: Sequence[str], org_to_org: bool, levels: Sequence[str]) -> None:
+    """Reject --promote-to-org on runs that migrate no repository secrets.
+
+    Raises:
+        ValueError: If names are promoted in an organization-to-organization run, or
+            the 'repo' level is not selected
+    """
+    if not promote_to_org:
+        return
+    if org_to_org:
+        raise ValueError("--promote-to-org only applies to repository migrations")
+    if levels and "repo" not in levels:
+        raise ValueError("--promote-to-org needs the 'repo' level")
+
+
+def check_target_env(
+    target_env: str, org_to_org: bool, levels: Sequence[str], environment_map: Dict[str, str]
+) -> None:
+    """Reject --target-env on runs that migrate no repository secrets, or that route the
+    source environment of the same name elsewhere.
+
+    Raises:
+        ValueError: If the combination cannot be carried out
+    """
+    if not target_env:
+        return
+    if org_to_org:
+        raise ValueError("--target-env only applies to repository migrations")
+    if levels and "repo" not in levels:
+        raise ValueError("--target-env needs the 'repo' level")
+    if environment_map.get(target_env, target_env) != target_env:
+        raise ValueError(
+            f"--target-env {target_env} conflicts with --map-environment "
+            f"{target_env}={environment_map[target_env]}"
+        )
+
+
+def check_unarchive(unarchive: bool, wait: bool, delivery: str) -> None:
+    """Reject --unarchive runs that could archive a repository before the workflow is done.
+
+    Raises:
+        ValueError: If unarchive is set without wait, or with pull-request delivery
+    """
+    if not unarchive:
+        return
+    if delivery != "push":
+        raise ValueError("--unarchive cannot be combined with --delivery pull-request: the "
+                         "workflow only runs once the pull request is merged")
+    if not wait:
+        raise ValueError("--unarchive needs --wait, so repositories are archived again only "
+                         "after the workflow run has finished")
 
 
 class MigrationConfig:
@@ -14,7 +105,67 @@ class MigrationConfig:
         target_repo: str = "",
         verbose: bool = False,
         skip_envs: bool = False,
-        org_to_org: bool = False
+        org_to_org: bool = False,
+        placeholder_mode: str = "none",
+        placeholder_value: str = DEFAULT_PLACEHOLDER_VALUE,
+        gh_cli_version: str = GH_CLI_PINNED_VERSION,
+        prune: bool = False,
+        target_prefix: str = "",
+        target_suffix: str = "",
+        rename_rules: Sequence[str] = (),
+        policy_file: str = "",
+        value_rules_file: str = "",
+        destination_plugins: Sequence[str] = (),
+        pre_hook: str = "",
+        post_hook: str = "",
+        quota_check: str = "fail",
+        environment_map: Optional[Dict[str, str]] = None,
+        conflict_policy: str = "overwrite",
+        runner_labels: Sequence[str] = (),
+        runner_os: str = "ubuntu",
+        workflow_engine: str = "shell",
+        action_ref: str = "",
+        target_app_id: str = "",
+        approval_environment: str = "",
+        workflow_timeout: int = DEFAULT_WORKFLOW_TIMEOUT_MINUTES,
+        tracking_issue: bool = False,
+        delivery: str = "push",
+        branch_name: str = "",
+        commit_message: str = "",
+        committer_name: str = "",
+        committer_email: str = "",
+        branch_check: str = "warn",
+        state_dir: str = DEFAULT_STATE_DIR,
+        snapshot: bool = True,
+        workflow_lint: bool = True,
+        api_timeout: float = DEFAULT_API_TIMEOUT,
+        migrate_settings: bool = False,
+        create_target_repo: bool = False,
+        target_repo_visibility: str = "private",
+        extra_target_repos: Sequence[str] = (),
+        rego_policy: str = "",
+        naming_pattern: str = "",
+        naming_max_length: int = 0,
+        naming_reserved_prefixes: Sequence[str] = (),
+        enforce_naming: bool = False,
+        only_used: bool = False,
+        promote_to_org: Sequence[str] = (),
+        target_env: str = "",
+        wait: bool = False,
+        unarchive: bool = False,
+        sandbox: bool = False,
+        remigrate: bool = False,
+        sync: bool = False,
+        sync_from_audit_log: bool = False,
+        state_file: str = "",
+        state_passphrase: str = "",
+        state_age_recipients: Sequence[str] = (),
+        state_age_identity: str = "",
+        levels: Sequence[str] = (),
+        migrate_secrets: bool = True,
+        migrate_variables: bool = True,
+        source_host: str = GITHUB_COM,
+        target_host: str = GITHUB_COM
     ):
         self.source_org = source_org
         self.source_repo = source_repo
@@ -25,3 +176,115 @@ class MigrationConfig:
         self.verbose = verbose
         self.skip_envs = skip_envs
         self.org_to_org = org_to_org
+        self.placeholder_mode = placeholder_mode
+        self.placeholder_value = placeholder_value
+        self.gh_cli_version = gh_cli_version
+        self.prune = prune
+        self.target_prefix = target_prefix
+        self.target_suffix = target_suffix
+        self.rename_rules = list(rename_rules)
+        self.policy_file = policy_file
+        # YAML rules rewriting secret values in the workflow (see value_rules)
+        self.value_rules_file = value_rules_file
+        # YAML definitions of plugins the workflow also hands the secrets to (see destinations)
+        self.destination_plugins = list(destination_plugins)
+        # Shell commands run before and after the migration (see hooks)
+        self.pre_hook = pre_hook
+        self.post_hook = post_hook
+        self.quota_check = quota_check
+        self.environment_map = dict(environment_map or {})
+        self.conflict_policy = conflict_policy
+        self.runner_labels = list(runner_labels)
+        self.runner_os = runner_os
+        self.workflow_engine = workflow_engine  # 'shell', 'github-script' or 'action'
+        # owner/repo@<commit SHA> of the secrets-migrator-action (action engine)
+        self.action_ref = action_ref
+        # Set: the workflow mints its target token from this GitHub App instead of a stored PAT
+        self.target_app_id = str(target_app_id or "")
+        # Set: the workflow job runs in this source environment, whose reviewers must approve it
+        self.approval_environment = approval_environment
+        self.workflow_timeout = workflow_timeout  # minutes
+        self.tracking_issue = tracking_issue
+        self.delivery = delivery
+        # Empty values fall back to the mode's default branch name and commit message
+        self.branch_name = branch_name
+        self.commit_message = commit_message
+        self.committer_name = committer_name
+        self.committer_email = committer_email
+        self.branch_check = branch_check
+        self.state_dir = state_dir
+        self.snapshot = snapshot
+        self.workflow_lint = workflow_lint
+        self.api_timeout = api_timeout
+        self.migrate_settings = migrate_settings
+        self.create_target_repo = create_target_repo
+        self.target_repo_visibility = target_repo_visibility
+        # Fan-out: further repositories in target_org receiving the same secrets
+        self.extra_target_repos = [repo for repo in extra_target_repos if repo != target_repo]
+        # Rego file deciding allow/deny/rename per secret (evaluated with the opa CLI)
+        self.rego_policy = rego_policy
+        # Naming conventions discovered secrets are checked against; violations fail the run
+        # only with enforce_naming
+        self.naming_pattern = naming_pattern
+        self.naming_max_length = naming_max_length
+        self.naming_reserved_prefixes = list(naming_reserved_prefixes)
+        self.enforce_naming = enforce_naming
+        # Migrate only secrets the source repository's workflows reference
+        self.only_used = only_used
+        # Source repository secrets written as target organization secrets scoped to the
+        # target repositories instead of as repository secrets
+        self.promote_to_org = list(promote_to_org)
+        # Target environment repository secrets are written to instead of the repository level
+        self.target_env = target_env
+        # Wait for the workflow run and confirm each secret from its log
+        self.wait = wait
+        # Unarchive archived source/target repositories for the run, archiving them again after
+        self.unarchive = unarchive
+        # Refuse to run unless every organization/repository touched is a designated sandbox
+        self.sandbox = sandbox
+        # Migrate secrets again even if the ledger says an earlier run migrated them
+        self.remigrate = remigrate
+        # Incremental sync: leave out target secrets not updated on the source since the last
+        # completed run
+        self.sync = sync
+        # Sync only when the source organization's audit log records secret or variable changes
+        # since the last run (GitHub Enterprise)
+        self.sync_from_audit_log = sync_from_audit_log
+        # JSON file recording this migration (default: under state_dir, per source repository)
+        self.state_file = state_file
+        # Encryption at rest of snapshots, ledger and state file: a passphrase, or age
+        # recipients to write plus an identity file to read back
+        self.state_passphrase = state_passphrase
+        self.state_age_recipients = list(state_age_recipients)
+        self.state_age_identity = state_age_identity
+        # Scopes processed ('repo', 'env', 'org'); empty means the mode's own
+        # ('repo' and 'env', or 'org' with org_to_org)
+        self.levels = list(levels)
+        # Categories migrated: secrets (through a workflow run) and Actions variables (copied
+        # through the API)
+        self.migrate_secrets = migrate_secrets
+        self.migrate_variables = migrate_variables
+        # GitHub hosts of each side (github.com, a GHE.com tenant or a GHES appliance)
+        self.source_host = source_host
+        self.target_host = target_host
+
+    @staticmethod
+    def option_names() -> List[str]:
+        """Return the options a configuration may be built from, credentials left out."""
+        params = inspect.signature(MigrationConfig.__init__).parameters
+        return [name for name in params if name != "self" and name not in CREDENTIAL_OPTIONS]
+
+    def options(self) -> Dict[str, Any]:
+        """Return this configuration's options, credentials left out (as saved in a plan)."""
+        return {name: getattr(self, name) for name in self.option_names()}
+
+    @property
+    def selected_levels(self) -> List[str]:
+        """Scopes this run processes, in the order they are migrated."""
+        default = ["org"] if self.org_to_org else ["repo", "env"]
+        return [level for level in MIGRATION_LEVELS if level in (self.levels or default)]
+
+    @property
+    def target_repos(self) -> List[str]:
+        """Every target repository of a repo-to-repo run, the primary one first."""
+        return [self.target_repo] + self.extra_target_repos
@@ -0,0 +1,13 @@
+"""Handling of secrets that already exist on the target."""
+from typing import Iterable, List
+
+# overwrite: replace existing target secrets (default)
+# skip: leave existing target secrets untouched
+# fail: stop before writing anything if any target secret already exists
+CONFLICT_POLICIES = ("overwrite", "skip", "fail")
+
+
+def find_conflicts(target_names: Iterable[str], existing_names: Iterable[str]) -> List[str]:
+    """Return the target names that already exist on the target (case-insensitive)."""
+    existing = {name.upper() for name in existing_names}
+    return [name for name in target_names if name.upper() in existing]
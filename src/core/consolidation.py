@@ -0,0 +1,138 @@
+"""Consolidation of several source repositories' secrets into one target repository."""
+import re
+from typing import Dict, Iterable, List, Optional, Tuple
+from src.core.filters import managed_secrets
+from src.core.naming import SecretNameTransformer, secret_name_error
+from src.core.policy import SecretPolicy
+
+
+class PlannedSecret:
+    """A secret one source repository will write to the target."""
+
+    def __init__(self, source_repo: str, source_name: str, scope: str, target_name: str):
+        self.source_repo = source_repo
+        self.source_name = source_name
+        self.scope = scope  # 'repository' or 'environment <name>'
+        self.target_name = target_name
+
+    def __str__(self) -> str:
+        return f"{self.source_repo}:{self.source_name}"
+
+
+def source_prefix(repo: str) -> str:
+    """Return the automatic target name prefix of a source repository.
+
+    Runs of characters not allowed in secret names become underscores, e.g.
+    'payments-api' -> 'PAYMENTS_API_'; a leading digit gets an underscore in
+    front, since secret names cannot start with one.
+    """
+    prefix = re.sub(r"[^A-Za-z0-9]+", "_", repo).strip("_").upper()
+    if prefix[:1].isdigit():
+        prefix = f"_{prefix}"
+    return f"{prefix}_" if prefix else ""
+
+
+def parse_source(spec: str) -> Tuple[str, str]:
+    """Split a '--source-repo' value of a consolidation into (repository, prefix).
+
+    'repo' uses the automatic prefix; 'repo=PREFIX_' sets it explicitly
+    (an empty PREFIX keeps the names unchanged).
+    """
+    repo, separator, prefix = spec.partition("=")
+    return repo, prefix if separator else source_prefix(repo)
+
+
+def consolidation_error(target_count: int, org_to_org: bool, prune: bool) -> Optional[str]:
+    """Return why a consolidation (several sources, one target) cannot run with these options.
+
+    Every source runs as its own pass over the shared target, so --prune in
+    one pass would delete the secrets the other sources just wrote.
+    """
+    if org_to_org or target_count != 1:
+        return (
+            "Several source repositories (consolidation) need one target repository "
+            "and repo-to-repo migration"
+        )
+    if prune:
+        return (
+            "--prune cannot be combined with several source repositories: each source's pass "
+            "would delete the secrets the other sources wrote to the target"
+        )
+    return None
+
+
+def plan_source(
+    source_repo: str,
+    namer: SecretNameTransformer,
+    repo_secrets: Iterable[str],
+    env_secrets: Dict[str, List[str]],
+    environment_map: Optional[Dict[str, str]] = None,
+    policy: Optional[SecretPolicy] = None,
+    promoted: Iterable[str] = (),
+    target_env: str = ""
+) -> List[PlannedSecret]:
+    """List what one source writes to the target, after filtering, policy and renaming.
+
+    Args:
+        source_repo: Source repository name
+        namer: Name transformer of the source's run (prefixes included)
+        repo_secrets: Source repository secret names
+        env_secrets: Source environment secret names by environment
+        environment_map: Source-to-target environment routing
+        policy: Secret policy of the run
+        promoted: Repository secret names the source writes as organization secrets
+        target_env: Target environment repository secrets are written to (default: the
+            repository level)
+    """
+    policy = policy or SecretPolicy()
+    routes = environment_map or {}
+    promoted_names = {name.upper() for name in promoted}
+    repo_scope = f"environment {target_env}" if target_env else "repository"
+    scoped = [
+        ("organization" if name.upper() in promoted_names else repo_scope, name)
+        for name in managed_secrets(repo_secrets)
+    ]
+    for env_name, names in env_secrets.items():
+        scoped += [(f"environment {routes.get(env_name, env_name)}", name) for name in names]
+    return [
+        PlannedSecret(source_repo, name, scope, namer.transform(name))
+        for scope, name in scoped if policy.allows(name)
+    ]
+
+
+def find_collisions(
+    planned: List[PlannedSecret],
+    existing: Optional[Dict[str, List[str]]] = None
+) -> List[str]:
+    """Describe every target secret that would be written twice or is invalid.
+
+    GitHub secret names are case-insensitive, so names are compared upper-cased
+    within each scope. Sources promoting the same organization secret share it
+    rather than collide.
+
+    Args:
+        planned: Secrets of every source of the consolidation
+        existing: Optional target secret names by scope; when given, planned
+            secrets already present on the target are reported too
+
+    Returns:
+        Human-readable problems; empty when the consolidation is safe
+    """
+    problems = []
+    writers: Dict[Tuple[str, str], List[PlannedSecret]] = {}
+    for secret in planned:
+        error = secret_name_error(secret.target_name)
+        if error:
+            problems.append(f"{secret} -> {secret.scope} '{secret.target_name}': {error}")
+        writers.setdefault((secret.scope, secret.target_name.upper()), []).append(secret)
+    for (scope, _), secrets in writers.items():
+        if len(secrets) > 1 and scope != "organization":
+            sources = ", ".join(str(secret) for secret in secrets)
+            problems.append(f"{scope} '{secrets[0].target_name}' would be written by {sources}")
+    for scope, names in (existing or {}).items():
+        present = {name.upper() for name in names}
+        problems += [
+            f"{secret} -> {scope} '{secret.target_name}' already exists on target"
+            for secret in planned if secret.scope == scope and secret.target_name.upper() in present
+        ]
+    return problems
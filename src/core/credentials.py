@@ -0,0 +1,131 @@
+"""Resolution of source and target tokens from flags, environment variables and gh's login."""
+import os
+import shutil
+import subprocess  # nosec B404 - runs `gh auth token` with a fixed argv
+from typing import Any, Callable, Mapping, Optional, Tuple
+import yaml
+
+GITHUB_COM = "github.com"
+SHARED_TOKEN_ENV = "GITHUB_TOKEN"
+# Read by gh for github.com and GHE.com hosts, before GITHUB_TOKEN
+GH_TOKEN_ENV = "GH_TOKEN"
+HOST_ENV = "GH_HOST"
+SIDE_TOKEN_ENVS = {"source": "SOURCE_GITHUB_TOKEN", "target": "TARGET_GITHUB_TOKEN"}
+# Read by gh for GitHub Enterprise Server hosts; the second name is its legacy alias
+ENTERPRISE_TOKEN_ENVS = ("GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN")
+
+
+def is_enterprise_host(host: str) -> bool:
+    """Return whether host is a GitHub Enterprise Server appliance.
+
+    github.com and GHE.com (data residency) tenants are not; like gh, they
+    never use the enterprise token variables.
+    """
+    host = host.lower().strip()
+    if host.startswith("api."):
+        host = host[len("api."):]
+    return bool(host) and host != GITHUB_COM and not host.endswith(".ghe.com")
+
+
+def default_host(environ: Mapping[str, str]) -> str:
+    """Return the host of sides that do not name one: GH_HOST, as for gh, or github.com."""
+    return environ.get(HOST_ENV, "").strip() or GITHUB_COM
+
+
+def gh_config_dir(environ: Mapping[str, str]) -> str:
+    """Return the directory gh keeps its configuration in, following gh's own lookup."""
+    if environ.get("GH_CONFIG_DIR"):
+        return environ["GH_CONFIG_DIR"]
+    if environ.get("XDG_CONFIG_HOME"):
+        return os.path.join(environ["XDG_CONFIG_HOME"], "gh")
+    if os.name == "nt" and environ.get("AppData"):
+        return os.path.join(environ["AppData"], "GitHub CLI")
+    return os.path.join(os.path.expanduser("~"), ".config", "gh")
+
+
+def gh_stored_token(
+    host: str,
+    environ: Mapping[str, str],
+    run: Callable[..., Any] = subprocess.run,
+    gh: Optional[str] = None
+) -> str:
+    """Return the token `gh auth login` stored for host, or '' if there is none.
+
+    Tokens kept in plain text in gh's hosts.yml are read directly; tokens in
+    the system keyring are asked from `gh auth token` when gh is installed.
+
+    Args:
+        host: GitHub host
+        environ: Environment variables (locate gh's configuration)
+        run: subprocess.run replacement (injectable for tests)
+        gh: gh executable (looked up on PATH by default)
+    """
+    try:
+        with open(os.path.join(gh_config_dir(environ), "hosts.yml"), encoding="utf-8") as handle:
+            hosts = yaml.safe_load(handle) or {}
+        token = (hosts.get(host) or {}).get("oauth_token", "")
+        if isinstance(token, str) and token:
+            return token
+    except (OSError, yaml.YAMLError, AttributeError):
+        pass
+    gh = gh or shutil.which("gh")
+    if not gh:
+        return ""
+    try:
+        result = run(  # nosec B603 - fixed argv
+            [gh, "auth", "token", "--hostname", host], capture_output=True, text=True, timeout=10
+        )
+    except (OSError, subprocess.SubprocessError):
+        return ""
+    return result.stdout.strip() if result.returncode == 0 else ""
+
+
+def resolve_token(
+    side: str,
+    flag: str,
+    flag_value: str,
+    environ: Mapping[str, str],
+    host: str = GITHUB_COM,
+    stored_token: Optional[Callable[[str], str]] = None
+) -> Tuple[str, str]:
+    """Pick the token for one side of a migration.
+
+    Precedence: the flag, the side's own variable (SOURCE_GITHUB_TOKEN or
+    TARGET_GITHUB_TOKEN), then the variables gh reads for the host
+    (GH_ENTERPRISE_TOKEN for GHES appliances, GH_TOKEN otherwise), then
+    GITHUB_TOKEN shared by both sides, and finally the token gh stored for
+    the host at login.
+
+    Args:
+        side: 'source' or 'target'
+        flag: Command-line flag of the token (e.g. '--source-pat'), used as its origin
+        flag_value: Value passed to the flag
+        environ: Environment variables
+        host: GitHub host the side talks to
+        stored_token: Returns gh's stored token of a host ('' if none); only
+            called when nothing else is configured
+
+    Returns:
+        (token, origin) where origin names the flag, variable or gh login it
+        came from; ("", "") when no token is configured
+    """
+    side_env = SIDE_TOKEN_ENVS[side]
+    candidates = [(flag, flag_value), (side_env, environ.get(side_env, ""))]
+    if is_enterprise_host(host):
+        candidates += [(name, environ.get(name, "")) for name in ENTERPRISE_TOKEN_ENVS]
+    else:
+        candidates.append((GH_TOKEN_ENV, environ.get(GH_TOKEN_ENV, "")))
+    candidates.append((SHARED_TOKEN_ENV, environ.get(SHARED_TOKEN_ENV, "")))
+    for origin, value in candidates:
+        if value:
+            return value, origin
+    token = stored_token(host) if stored_token else ""
+    return (token, f"gh auth login ({host})") if token else ("", "")
+
+
+def token_sources_hint(side: str) -> str:
+    """Describe where a side's token can be configured, for error messages."""
+    return (
+        f"{SIDE_TOKEN_ENVS[side]}, {GH_TOKEN_ENV}, {ENTERPRISE_TOKEN_ENVS[0]} (GHES hosts) or "
+        f"{SHARED_TOKEN_ENV}, or log in with `gh auth login`"
+    )
@@ -0,0 +1,190 @@
+"""Regeneration of deploy keys on a target repository.
+
+The API never returns the private half of a deploy key, so deploy keys
+cannot be migrated. Instead each source key gets a fresh keypair: the public
+key is installed on the target repository under the same title and access,
+and the private key is stored as a target repository secret, so workflows
+and systems cloning with the old key can be pointed at the new one.
+"""
+import base64
+import hashlib
+import os
+import re
+import shutil
+import subprocess  # nosec B404 - runs ssh-keygen with a fixed argv
+import tempfile
+from typing import Any, Callable, Dict, Iterable, List, Optional, Tuple
+from src.core.naming import secret_name_error
+
+DEFAULT_DEPLOY_KEY_PREFIX = "DEPLOY_KEY_"
+
+
+def public_key_fingerprint(public_key: str) -> str:
+    """Return the SHA256 fingerprint of an OpenSSH public key, as ssh-keygen -l prints it."""
+    parts = public_key.split()
+    try:
+        blob = base64.b64decode(parts[1], validate=True)
+    except (IndexError, ValueError):
+        return ""
+    digest = base64.b64encode(hashlib.sha256(blob).digest()).decode("ascii")
+    return "SHA256:" + digest.rstrip("=")
+
+
+class DeployKey:
+    """A deploy key of a repository (public half only)."""
+
+    def __init__(self, key_id: int, title: str, key: str, read_only: bool = True):
+        self.key_id = key_id
+        self.title = title
+        self.key = key  # OpenSSH public key line
+        self.read_only = read_only
+
+    @classmethod
+    def from_api(cls, data: Dict[str, Any]) -> "DeployKey":
+        """Build a deploy key from a GET /repos/{owner}/{repo}/keys item."""
+        return cls(
+            int(data["id"]), data.get("title") or "", data.get("key") or "",
+            bool(data.get("read_only", True))
+        )
+
+    @property
+    def fingerprint(self) -> str:
+        """SHA256 fingerprint of the public key."""
+        return public_key_fingerprint(self.key)
+
+
+def deploy_key_secret_name(title: str, prefix: str = DEFAULT_DEPLOY_KEY_PREFIX) -> str:
+    """Return the secret name holding the private key of a deploy key, from its title."""
+    body = re.sub(r"[^A-Za-z0-9]+", "_", title).strip("_").upper() or "KEY"
+    return prefix + body
+
+
+class DeployKeyPlan:
+    """What happens to one source deploy key."""
+
+    def __init__(self, key: DeployKey, secret: str, action: str = "create", reason: str = ""):
+        self.key = key
+        self.secret = secret
+        self.action = action  # 'create' or 'skip'
+        self.reason = reason  # why it is skipped
+        self.target_key_id: Optional[int] = None
+        self.target_fingerprint = ""
+        self.error = ""
+
+    @property
+    def status(self) -> str:
+        """'created', 'failed', 'skipped' or 'planned'."""
+        if self.action == "skip":
+            return "skipped"
+        if self.error:
+            return "failed"
+        return "created" if self.target_key_id is not None else "planned"
+
+    def describe(self) -> str:
+        """Describe the key's mapping in one line."""
+        access = "read-only" if self.key.read_only else "read-write"
+        line = f"{self.key.title} ({access}, {self.key.fingerprint})"
+        if self.action == "skip":
+            return f"{line}: skipped, {self.reason}"
+        if self.error:
+            return f"{line}: FAILED, {self.error}"
+        if self.target_key_id is None:
+            return f"{line} → new key, private key in secret {self.secret}"
+        return (
+            f"{line} → key {self.target_key_id} ({self.target_fingerprint}), "
+            f"private key in secret {self.secret}"
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the report entry of the key (never the private key)."""
+        return {
+            "title": self.key.title,
+            "read_only": self.key.read_only,
+            "source_key_id": self.key.key_id,
+            "source_fingerprint": self.key.fingerprint,
+            "target_key_id": self.target_key_id,
+            "target_fingerprint": self.target_fingerprint or None,
+            "secret": self.secret if self.action == "create" else None,
+            "status": self.status,
+            "reason": self.reason or self.error or None,
+        }
+
+
+def plan_deploy_keys(
+    source_keys: Iterable[DeployKey],
+    target_keys: Iterable[DeployKey],
+    target_secrets: Iterable[str],
+    prefix: str = DEFAULT_DEPLOY_KEY_PREFIX
+) -> List[DeployKeyPlan]:
+    """Plan the regeneration of source deploy keys on a target repository.
+
+    Keys whose title the target already uses are skipped (run again after
+    removing them to replace them), as are keys whose secret name is taken
+    by an existing target secret or is invalid. Titles mapping to the same
+    secret name get numbered names.
+    """
+    target_titles = {key.title.lower() for key in target_keys}
+    taken = {name.upper() for name in target_secrets}
+    plans = []
+    for key in sorted(source_keys, key=lambda item: item.key_id):
+        base = deploy_key_secret_name(key.title, prefix)
+        secret, number = base, 2
+        while any(plan.secret == secret for plan in plans):
+            secret, number = f"{base}_{number}", number + 1
+        problem = secret_name_error(secret)
+        if key.title.lower() in target_titles:
+            plan = DeployKeyPlan(
+                key, secret, "skip", "the target already has a key with this title"
+            )
+        elif problem:
+            plan = DeployKeyPlan(key, secret, "skip", f"invalid secret name {secret}: {problem}")
+        elif secret in taken:
+            plan = DeployKeyPlan(key, secret, "skip", f"target secret {secret} already exists")
+        else:
+            plan = DeployKeyPlan(key, secret)
+        plans.append(plan)
+    return plans
+
+
+class KeypairGenerator:
+    """Generates Ed25519 SSH keypairs with ssh-keygen."""
+
+    def __init__(self, ssh_keygen: Optional[str] = None, run: Callable[..., Any] = subprocess.run):
+        """Locate ssh-keygen.
+
+        Args:
+            ssh_keygen: ssh-keygen executable (looked up on PATH by default)
+            run: subprocess.run replacement (injectable for tests)
+
+        Raises:
+            ValueError: If ssh-keygen is not installed
+        """
+        self.ssh_keygen = ssh_keygen or shutil.which("ssh-keygen") or ""
+        if not self.ssh_keygen:
+            raise ValueError(
+                "ssh-keygen is required to generate deploy keys but was not found on PATH"
+            )
+        self.run = run
+
+    def generate(self, comment: str) -> Tuple[str, str]:
+        """Return a new (private key, public key line) pair.
+
+        The private key only exists in a temporary directory removed on return.
+
+        Raises:
+            ValueError: If ssh-keygen fails
+        """
+        with tempfile.TemporaryDirectory() as directory:
+            path = os.path.join(directory, "id_ed25519")
+            result = self.run(  # nosec B603 - fixed argv
+                [self.ssh_keygen, "-q", "-t", "ed25519", "-N", "", "-C", comment, "-f", path],
+                capture_output=True, timeout=60,
+            )
+            if result.returncode != 0:
+                detail = (result.stderr or b"").decode("utf-8", "replace").strip().splitlines()
+                raise ValueError(f"ssh-keygen failed: {detail[0] if detail else result.returncode}")
+            with open(path, encoding="ascii") as handle:
+                private_key = handle.read()
+            with open(path + ".pub", encoding="ascii") as handle:
+                public_key = handle.read().strip()
+        return private_key, public_key
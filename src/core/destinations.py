@@ -0,0 +1,237 @@
+"""Destination plugins: custom places the migration workflow also writes secrets to.
+
+A plugin is any executable speaking a small JSON protocol over stdin and
+stdout, so teams can deliver secrets to Doppler, 1Password, CyberArk and the
+like without changes to this tool. It is declared in a YAML file:
+
+    name: doppler
+    command: [doppler-secrets-plugin]
+    config:
+      project: payments
+    credentials:
+      DOPPLER_TOKEN: DOPPLER_MIGRATION_TOKEN
+
+Values only exist in the workflow run, so the plugin runs there, after the
+secrets were written to the target: it receives one 'put' request holding
+every secret and answers with a result per secret. The workflow installs
+nothing at run time; the plugin must be on the runner's PATH (e.g. baked
+into a self-hosted runner image). 'credentials' maps the
+environment variables the plugin reads to source secrets providing them;
+those secrets are never migrated themselves. 'describe' requests let
+`destination check` verify a plugin locally.
+"""
+import json
+import re
+import shlex
+import shutil
+import subprocess  # nosec B404 - runs the plugin command of a destination definition
+from typing import Any, Callable, Dict, Iterable, List, Optional
+import yaml
+
+PLUGIN_PROTOCOL = 1
+
+_PLUGIN_NAME_RE = re.compile(r"^[a-z0-9][a-z0-9-]{0,39}$")
+_ENV_NAME_RE = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+# Variables of the workflow step delivering to a plugin, or of the runner itself
+_RESERVED_ENV = re.compile(
+    r"^(PLUGIN_|GITHUB_|RUNNER_|ACTIONS_)|^(VALUE_REWRITES|GH_TOKEN|PATH|HOME)$", re.IGNORECASE
+)
+
+
+class DestinationPlugin:
+    """A destination plugin definition."""
+
+    def __init__(
+        self,
+        name: str,
+        command: List[str],
+        config: Optional[Dict[str, Any]] = None,
+        credentials: Optional[Dict[str, str]] = None
+    ):
+        self.name = name
+        self.command = list(command)
+        # Passed verbatim as the 'destination' of every request (never holds secrets)
+        self.config = dict(config or {})
+        # Environment variable → source secret providing it
+        self.credentials = dict(credentials or {})
+
+    @property
+    def credential_secrets(self) -> List[str]:
+        """Source secrets holding the plugin's credentials."""
+        return sorted(set(self.credentials.values()))
+
+    def request(
+        self, operation: str, secrets: Optional[List[Dict[str, Any]]] = None
+    ) -> Dict[str, Any]:
+        """Build a request document ('put' requests carry secrets)."""
+        request: Dict[str, Any] = {
+            "protocol": PLUGIN_PROTOCOL, "operation": operation, "destination": self.config
+        }
+        if secrets is not None:
+            request["secrets"] = secrets
+        return request
+
+
+def parse_destination_plugin(data: Any) -> DestinationPlugin:
+    """Build a plugin from a loaded YAML definition.
+
+    Raises:
+        ValueError: If the definition is malformed
+    """
+    if not isinstance(data, dict):
+        raise ValueError("Destination plugin definition must be a mapping")
+    keys = ("name", "command", "config", "credentials")
+    unknown = sorted(str(key) for key in data if key not in keys)
+    if unknown:
+        raise ValueError(f"Destination plugin definition has unknown key(s): {', '.join(unknown)}")
+    name = data.get("name")
+    if not isinstance(name, str) or not _PLUGIN_NAME_RE.match(name):
+        raise ValueError(
+            "Destination plugin needs a 'name' of lowercase letters, digits and dashes (at most 40)"
+        )
+    command = data.get("command")
+    if isinstance(command, str):
+        command = shlex.split(command)
+    if not command or not isinstance(command, list) or not all(
+        isinstance(arg, str) and arg and "\n" not in arg for arg in command
+    ):
+        raise ValueError(
+            f"Destination plugin '{name}' needs a 'command': the executable and its arguments"
+        )
+    config = data.get("config") or {}
+    if not isinstance(config, dict):
+        raise ValueError(f"Destination plugin '{name}': 'config' must be a mapping")
+    credentials = data.get("credentials") or {}
+    if not isinstance(credentials, dict):
+        raise ValueError(
+            f"Destination plugin '{name}': 'credentials' must map environment variables to secrets"
+        )
+    for variable, secret in credentials.items():
+        variable, secret = str(variable), str(secret)
+        if not _ENV_NAME_RE.match(variable) or _RESERVED_ENV.match(variable):
+            raise ValueError(
+                f"Destination plugin '{name}': invalid or reserved variable '{variable}'"
+            )
+        if not _ENV_NAME_RE.match(secret):
+            raise ValueError(
+                f"Destination plugin '{name}': invalid secret name '{secret}' for {variable}"
+            )
+    credentials = {str(variable): str(secret) for variable, secret in credentials.items()}
+    return DestinationPlugin(name, command, config, credentials)
+
+
+def load_destination_plugin(path: str) -> DestinationPlugin:
+    """Load a destination plugin definition from a YAML file."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return parse_destination_plugin(yaml.safe_load(handle))
+
+
+def check_destination_names(plugins: Iterable[DestinationPlugin]) -> None:
+    """Check that no two plugins of a run share a name.
+
+    Raises:
+        ValueError: If names repeat
+    """
+    names = [plugin.name for plugin in plugins]
+    repeated = sorted({name for name in names if names.count(name) > 1})
+    if repeated:
+        raise ValueError(f"Destination plugin name(s) used more than once: {', '.join(repeated)}")
+
+
+def destination_items(
+    repo_secrets: Iterable[str] = (),
+    env_secrets: Optional[Dict[str, List[str]]] = None,
+    org_secrets: Iterable[str] = (),
+    name_map: Optional[Dict[str, str]] = None,
+    environment_map: Optional[Dict[str, str]] = None
+) -> List[Dict[str, Any]]:
+    """List what a plugin receives, without values.
+
+    Each item has an 'id' the plugin's result refers to, the source secret
+    the value is read from and the target name, level and (target)
+    environment the secret was written to.
+    """
+    name_map = name_map or {}
+    environment_map = environment_map or {}
+    entries = [("repository", "", name) for name in repo_secrets]
+    entries += [
+        ("environment", environment_map.get(env_name, env_name), name)
+        for env_name, names in (env_secrets or {}).items() for name in names
+    ]
+    entries += [("organization", "", name) for name in org_secrets]
+    return [
+        {
+            "id": index, "source_name": name, "name": name_map.get(name, name),
+            "level": level, "environment": environment,
+        }
+        for index, (level, environment, name) in enumerate(entries, 1)
+    ]
+
+
+def check_response(response: Any, operation: str) -> Dict[str, Any]:
+    """Validate a plugin's response document.
+
+    Raises:
+        ValueError: If the response does not follow the protocol
+    """
+    if not isinstance(response, dict) or response.get("protocol") != PLUGIN_PROTOCOL:
+        raise ValueError(f"the response does not declare protocol {PLUGIN_PROTOCOL}")
+    if operation == "describe":
+        operations = response.get("operations")
+        if not isinstance(operations, list) or "put" not in operations:
+            raise ValueError("the describe response does not list the 'put' operation")
+    elif not isinstance(response.get("results"), list):
+        raise ValueError("the put response has no 'results' list")
+    return response
+
+
+class PluginRunner:
+    """Runs a plugin command locally (`destination check`)."""
+
+    def __init__(self, plugin: DestinationPlugin, run: Callable[..., Any] = subprocess.run):
+        """Locate the plugin's executable.
+
+        Args:
+            plugin: Plugin definition
+            run: subprocess.run replacement (injectable for tests)
+
+        Raises:
+            ValueError: If the executable is not installed
+        """
+        self.plugin = plugin
+        self.executable = shutil.which(plugin.command[0]) or ""
+        if not self.executable:
+            raise ValueError(f"plugin command '{plugin.command[0]}' was not found on PATH")
+        self.run = run
+
+    def call(self, request: Dict[str, Any]) -> Dict[str, Any]:
+        """Send one request and return the validated response.
+
+        Raises:
+            ValueError: If the plugin fails or answers outside the protocol
+        """
+        result = self.run(  # nosec B603 - argv from the plugin definition
+            [self.executable] + self.plugin.command[1:], input=json.dumps(request).encode("utf-8"),
+            stdout=subprocess.PIPE, stderr=subprocess.PIPE, timeout=300,
+        )
+        if result.returncode != 0:
+            detail = (result.stderr or b"").decode("utf-8", "replace").strip().splitlines()
+            raise ValueError(
+                f"plugin '{self.plugin.name}' failed: {detail[-1] if detail else result.returncode}"
+            )
+        try:
+            response = json.loads((result.stdout or b"").decode("utf-8"))
+        except ValueError:
+            raise ValueError(f"plugin '{self.plugin.name}' did not answer with JSON")
+        try:
+            return check_response(response, request["operation"])
+        except ValueError as e:
+            raise ValueError(f"plugin '{self.plugin.name}': {e}")
+
+    def describe(self) -> Dict[str, Any]:
+        """Ask the plugin to describe itself."""
+        return self.call(self.plugin.request("describe"))
+
+    def probe(self) -> Dict[str, Any]:
+        """Send a put request without secrets, as a dry run of the workflow's request."""
+        return self.call(self.plugin.request("put", []))
@@ -0,0 +1,308 @@
+"""Environment definitions (protection rules, secret names, variables) as reviewable YAML."""
+from typing import Any, Dict, List, Optional, Tuple, Union
+import yaml
+from src.core.branch_rules import branch_pattern_matches
+from src.core.naming import secret_name_error
+
+# deployment_branches: 'all', 'protected', or a list of branch patterns ('tag:' prefix for tags)
+DEPLOYMENT_BRANCH_MODES = ("all", "protected")
+TAG_PREFIX = "tag:"
+MAX_WAIT_TIMER_MINUTES = 43200
+MAX_REVIEWERS = 6
+
+
+class EnvironmentSpec:
+    """Declarative definition of one deployment environment.
+
+    Secret values are never part of a spec; only secret names are recorded so
+    the receiving team knows which secrets to provide.
+    """
+
+    def __init__(
+        self,
+        name: str,
+        wait_timer: int = 0,
+        prevent_self_review: bool = False,
+        reviewers: Optional[List[Dict[str, str]]] = None,
+        deployment_branches: Union[str, List[str]] = "all",
+        secrets: Optional[List[str]] = None,
+        variables: Optional[Dict[str, str]] = None
+    ):
+        self.name = name
+        self.wait_timer = wait_timer
+        self.prevent_self_review = prevent_self_review
+        self.reviewers = list(reviewers or [])  # [{'user': login}] or [{'team': slug}]
+        self.deployment_branches = deployment_branches
+        self.secrets = list(secrets or [])
+        self.variables = dict(variables or {})
+
+    @property
+    def branch_patterns(self) -> List[str]:
+        """Custom deployment branch/tag patterns (empty for 'all' and 'protected')."""
+        return list(self.deployment_branches) if isinstance(self.deployment_branches, list) else []
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Serialize to the YAML document layout, omitting defaults."""
+        data: Dict[str, Any] = {"name": self.name}
+        if self.wait_timer:
+            data["wait_timer"] = self.wait_timer
+        if self.prevent_self_review:
+            data["prevent_self_review"] = True
+        if self.reviewers:
+            data["reviewers"] = self.reviewers
+        if self.deployment_branches != "all":
+            data["deployment_branches"] = self.deployment_branches
+        if self.secrets:
+            data["secrets"] = sorted(self.secrets)
+        if self.variables:
+            data["variables"] = dict(sorted(self.variables.items()))
+        return data
+
+    def protection_payload(self, reviewer_ids: List[Dict[str, Any]]) -> Dict[str, Any]:
+        """Build the body of PUT /repos/{owner}/{repo}/environments/{name}.
+
+        Args:
+            reviewer_ids: Reviewers resolved on the target, as {'type': 'User'|'Team', 'id': int}
+        """
+        return {
+            "wait_timer": self.wait_timer,
+            "prevent_self_review": self.prevent_self_review,
+            "reviewers": reviewer_ids or None,
+            "deployment_branch_policy": branch_policy_payload(self.deployment_branches),
+        }
+
+
+def branch_policy_payload(
+    deployment_branches: Union[str, List[str]]
+) -> Optional[Dict[str, bool]]:
+    """Build the deployment_branch_policy field of an environment (None allows all branches)."""
+    if deployment_branches == "protected":
+        return {"protected_branches": True, "custom_branch_policies": False}
+    if isinstance(deployment_branches, list) and deployment_branches:
+        return {"protected_branches": False, "custom_branch_policies": True}
+    return None
+
+
+def deployment_branches_from_api(
+    policy: Optional[Dict[str, Any]], branch_policies: List[Dict[str, Any]]
+) -> Union[str, List[str]]:
+    """Return 'all', 'protected' or the branch/tag patterns an environment allows.
+
+    Args:
+        policy: The environment's deployment_branch_policy (None when unrestricted)
+        branch_policies: Items of its deployment-branch-policies endpoint
+    """
+    policy = policy or {}
+    if policy.get("protected_branches"):
+        return "protected"
+    if policy.get("custom_branch_policies"):
+        return [
+            f"{TAG_PREFIX}{item['name']}" if item.get("type") == "tag" else item["name"]
+            for item in branch_policies
+        ]
+    return "all"
+
+
+def protection_from_api(environment: Dict[str, Any]) -> Dict[str, Any]:
+    """Return an environment's wait timer and reviewers as PUT environment fields.
+
+    Lets the deployment branch policy be updated without resetting the other rules.
+    """
+    fields: Dict[str, Any] = {"wait_timer": 0, "prevent_self_review": False, "reviewers": None}
+    for rule in environment.get("protection_rules") or []:
+        if rule.get("type") == "wait_timer":
+            fields["wait_timer"] = int(rule.get("wait_timer") or 0)
+        elif rule.get("type") == "required_reviewers":
+            fields["prevent_self_review"] = bool(rule.get("prevent_self_review", False))
+            fields["reviewers"] = [
+                {"type": entry.get("type", "User"), "id": (entry.get("reviewer") or {}).get("id")}
+                for entry in rule.get("reviewers") or []
+            ] or None
+    return fields
+
+
+class EnvironmentApplyResult:
+    """What an applied environment still needs from a human."""
+
+    def __init__(self, missing_secrets: List[str], unresolved_reviewers: List[Dict[str, str]]):
+        self.missing_secrets = missing_secrets  # listed secrets without a value on the target
+        self.unresolved_reviewers = unresolved_reviewers  # reviewers not found on the target
+
+
+def format_reviewer(reviewer: Dict[str, str]) -> str:
+    """Render a reviewer entry as 'user octocat' or 'team release-managers'."""
+    kind, name = next(iter(reviewer.items()))
+    return f"{kind} {name}"
+
+
+def spec_from_api(
+    environment: Dict[str, Any],
+    branch_policies: List[Dict[str, Any]],
+    secrets: List[str],
+    variables: Dict[str, str]
+) -> EnvironmentSpec:
+    """Build a spec from the REST representation of an environment.
+
+    Args:
+        environment: Item of GET /repos/{owner}/{repo}/environments
+        branch_policies: Items of the environment's deployment-branch-policies endpoint
+        secrets: Names of the environment's secrets
+        variables: Environment variables by name
+    """
+    wait_timer = 0
+    prevent_self_review = False
+    reviewers: List[Dict[str, str]] = []
+    for rule in environment.get("protection_rules") or []:
+        if rule.get("type") == "wait_timer":
+            wait_timer = int(rule.get("wait_timer") or 0)
+        elif rule.get("type") == "required_reviewers":
+            prevent_self_review = bool(rule.get("prevent_self_review", False))
+            for entry in rule.get("reviewers") or []:
+                reviewer = entry.get("reviewer") or {}
+                if entry.get("type") == "Team":
+                    reviewers.append({"team": reviewer.get("slug", "")})
+                else:
+                    reviewers.append({"user": reviewer.get("login", "")})
+
+    branches = deployment_branches_from_api(
+        environment.get("deployment_branch_policy"), branch_policies
+    )
+
+    return EnvironmentSpec(
+        environment["name"],
+        wait_timer=wait_timer,
+        prevent_self_review=prevent_self_review,
+        reviewers=reviewers,
+        deployment_branches=branches,
+        secrets=secrets,
+        variables=variables,
+    )
+
+
+def split_branch_pattern(pattern: str) -> Tuple[str, str]:
+    """Split a deployment_branches entry into (name, type), type being 'branch' or 'tag'."""
+    if pattern.startswith(TAG_PREFIX):
+        return pattern[len(TAG_PREFIX):], "tag"
+    return pattern, "branch"
+
+
+def approval_gate_problems(
+    spec: EnvironmentSpec, branch: str, branch_protected: bool = False
+) -> List[str]:
+    """Return why an environment cannot gate a migration workflow running on branch.
+
+    The environment must require reviewers, or runs would read its secrets
+    without approval, and must let branch deploy to it.
+
+    Returns:
+        Problems found; empty when the environment can gate the run
+    """
+    problems = []
+    if not spec.reviewers:
+        problems.append(f"environment '{spec.name}' has no required reviewers")
+    if spec.deployment_branches == "protected" and not branch_protected:
+        problems.append(
+            f"environment '{spec.name}' only accepts protected branches, and '{branch}' is not one"
+        )
+    elif isinstance(spec.deployment_branches, list):
+        patterns = [name for name, kind in map(split_branch_pattern, spec.deployment_branches)
+                    if kind == "branch"]
+        if not any(branch_pattern_matches(pattern, branch) for pattern in patterns):
+            problems.append(
+                f"environment '{spec.name}' does not allow branch '{branch}' to deploy "
+                f"(allowed: {', '.join(spec.deployment_branches)})"
+            )
+    return problems
+
+
+def _fail(env: str, message: str) -> None:
+    raise ValueError(f"Environment '{env}': {message}")
+
+
+def _parse_environment(index: int, raw: Any) -> EnvironmentSpec:
+    if not isinstance(raw, dict) or not raw.get("name"):
+        raise ValueError(f"Environment #{index} must be a mapping with a 'name'")
+    name = str(raw["name"])
+    known = {
+        "name", "wait_timer", "prevent_self_review", "reviewers",
+        "deployment_branches", "secrets", "variables",
+    }
+    unknown = sorted(str(key) for key in raw if key not in known)
+    if unknown:
+        _fail(name, f"unknown key(s): {', '.join(unknown)}")
+
+    wait_timer = raw.get("wait_timer", 0)
+    if not isinstance(wait_timer, int) or not 0 <= wait_timer <= MAX_WAIT_TIMER_MINUTES:
+        _fail(name, f"wait_timer must be 0-{MAX_WAIT_TIMER_MINUTES} minutes")
+
+    reviewers = raw.get("reviewers") or []
+    if not isinstance(reviewers, list) or len(reviewers) > MAX_REVIEWERS:
+        _fail(name, f"reviewers must be a list of at most {MAX_REVIEWERS} entries")
+    for reviewer in reviewers:
+        if not (isinstance(reviewer, dict) and len(reviewer) == 1
+                and set(reviewer) <= {"user", "team"}):
+            _fail(name, f"reviewer {reviewer!r} must be {{user: LOGIN}} or {{team: SLUG}}")
+
+    branches = raw.get("deployment_branches", "all")
+    if isinstance(branches, list):
+        if not branches or not all(
+            isinstance(item, str) and split_branch_pattern(item)[0] for item in branches
+        ):
+            _fail(name, "deployment_branches list must contain branch or 'tag:' patterns")
+    elif branches not in DEPLOYMENT_BRANCH_MODES:
+        _fail(name, "deployment_branches must be 'all', 'protected' or a list of patterns")
+
+    secrets = raw.get("secrets") or []
+    if not isinstance(secrets, list):
+        _fail(name, "secrets must be a list of names")
+    for secret in secrets:
+        error = secret_name_error(str(secret))
+        if error:
+            _fail(name, f"invalid secret name '{secret}': {error}")
+
+    variables = raw.get("variables") or {}
+    if not isinstance(variables, dict):
+        _fail(name, "variables must be a mapping of names to values")
+
+    return EnvironmentSpec(
+        name,
+        wait_timer=wait_timer,
+        prevent_self_review=bool(raw.get("prevent_self_review", False)),
+        reviewers=[{str(k): str(v) for k, v in reviewer.items()} for reviewer in reviewers],
+        deployment_branches=branches,
+        secrets=[str(secret) for secret in secrets],
+        variables={str(k): str(v) for k, v in variables.items()},
+    )
+
+
+def parse_environment_config(data: Any) -> List[EnvironmentSpec]:
+    """Parse an environment config document ({'environments': [...]}).
+
+    Raises:
+        ValueError: If the document is malformed
+    """
+    if not isinstance(data, dict) or not isinstance(data.get("environments"), list):
+        raise ValueError("Environment config must be a mapping with an 'environments' list")
+    specs = [_parse_environment(index, raw) for index, raw in enumerate(data["environments"], 1)]
+    names = [spec.name.lower() for spec in specs]
+    duplicates = sorted({name for name in names if names.count(name) > 1})
+    if duplicates:
+        raise ValueError(f"Duplicate environment(s): {', '.join(duplicates)}")
+    return specs
+
+
+def load_environment_config(path: str) -> List[EnvironmentSpec]:
+    """Load and validate an environment config YAML file."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return parse_environment_config(yaml.safe_load(handle))
+
+
+def dump_environment_config(specs: List[EnvironmentSpec], source: str = "") -> str:
+    """Render specs as an environment config YAML document."""
+    header = f"# Environments exported from {source}\n" if source else ""
+    header += "# Secret values are never exported; listed secrets must be provided on the target\n"
+    body = yaml.safe_dump(
+        {"environments": [spec.to_dict() for spec in specs]},
+        sort_keys=False, default_flow_style=False
+    )
+    return header + body
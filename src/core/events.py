@@ -0,0 +1,121 @@
+"""Event stream recorded during a migration run."""
+import json
+from datetime import datetime, timezone
+from typing import Any, Callable, Dict, Iterable, List, TextIO
+
+REDACTED = "***"
+
+# Formats of the live event stream (--events)
+EVENT_STREAM_FORMATS = ("ndjson",)
+
+
+class MigrationEvent:
+    """A single thing that happened during a migration run."""
+
+    def __init__(self, kind: str, message: str, data: Dict[str, Any]):
+        self.kind = kind
+        self.message = message
+        self.data = data
+        self.timestamp = datetime.now(timezone.utc).isoformat()
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Serialize the event to a JSON-friendly dictionary."""
+        return {
+            "timestamp": self.timestamp,
+            "kind": self.kind,
+            "message": self.message,
+            "data": self.data,
+        }
+
+
+class EventLog:
+    """Ordered, redacted record of migration events.
+
+    Every output format (JSON report, transcript) is rendered from this one
+    stream, so they always agree on what happened. Secret values are never
+    recorded; any sensitive string registered via add_redaction (e.g. PATs)
+    is masked before an event is stored.
+    """
+
+    def __init__(self, redact_values: Iterable[str] = ()):
+        self.events: List[MigrationEvent] = []
+        self._redact_values: List[str] = []
+        self._listeners: List[Callable[[MigrationEvent], None]] = []
+        for value in redact_values:
+            self.add_redaction(value)
+
+    def add_redaction(self, value: str) -> None:
+        """Register a sensitive string that must never appear in any output."""
+        if value and value not in self._redact_values:
+            self._redact_values.append(value)
+            # Mask longer values first so overlapping tokens are fully hidden
+            self._redact_values.sort(key=len, reverse=True)
+
+    def redact(self, value: Any) -> Any:
+        """Mask registered sensitive strings inside value (recursing into containers)."""
+        if isinstance(value, str):
+            for sensitive in self._redact_values:
+                value = value.replace(sensitive, REDACTED)
+            return value
+        if isinstance(value, dict):
+            return {key: self.redact(item) for key, item in value.items()}
+        if isinstance(value, (list, tuple)):
+            return [self.redact(item) for item in value]
+        return value
+
+    def subscribe(self, listener: Callable[[MigrationEvent], None]) -> None:
+        """Call listener with every (already redacted) event recorded from now on."""
+        self._listeners.append(listener)
+
+    def emit(self, kind: str, message: str, **data: Any) -> MigrationEvent:
+        """Record an event.
+
+        Args:
+            kind: Event kind (e.g. 'decision', 'skipped', 'conflict', 'link')
+            message: Human-readable description
+            **data: Structured details (names, counts, URLs - never secret values)
+        """
+        event = MigrationEvent(kind, self.redact(message), self.redact(data))
+        self.events.append(event)
+        for listener in self._listeners:
+            listener(event)
+        return event
+
+    def of_kind(self, *kinds: str) -> List[MigrationEvent]:
+        """Return events matching any of the given kinds, in order."""
+        return [event for event in self.events if event.kind in kinds]
+
+    def summary(self) -> Dict[str, int]:
+        """Count events by kind."""
+        counts: Dict[str, int] = {}
+        for event in self.events:
+            counts[event.kind] = counts.get(event.kind, 0) + 1
+        return counts
+
+    def to_report(self) -> Dict[str, Any]:
+        """Build the JSON report structure."""
+        return {
+            "summary": self.summary(),
+            "events": [event.to_dict() for event in self.events],
+        }
+
+    def write_json_report(self, path: str) -> None:
+        """Write the JSON report to path."""
+        with open(path, "w", encoding="utf-8") as handle:
+            json.dump(self.to_report(), handle, indent=2)
+            handle.write("\n")
+
+
+class NdjsonStream:
+    """Event listener writing each event as one JSON line as soon as it is recorded.
+
+    Lines are flushed immediately so dashboards tailing the stream see long
+    bulk runs progress in real time.
+    """
+
+    def __init__(self, handle: TextIO):
+        self.handle = handle
+
+    def __call__(self, event: MigrationEvent) -> None:
+        self.handle.write(json.dumps(event.to_dict()) + "\n")
+        self.handle.flush()
@@ -0,0 +1,46 @@
+"""Exit statuses of the CLI, so wrapper scripts can branch on the outcome.
+
+    0    success
+    1    any other failure
+    2    invalid command-line usage
+    3    a token was rejected or lacks access (auth failure)
+    4    partial migration: some secrets (or jobs) failed, others were migrated
+    5    verification mismatch: the result could not be confirmed as planned
+    6    nothing to migrate (migrate and apply only; sync treats it as success)
+    124  stopped by --timeout (EXIT_TIMED_OUT in src.utils.signals)
+    130  cancelled by Ctrl-C or SIGTERM (EXIT_CANCELLED)
+"""
+from src.clients.errors import AuthError, PermissionDenied
+
+EXIT_SUCCESS = 0
+EXIT_FAILED = 1
+EXIT_USAGE = 2  # click's own status for usage errors
+EXIT_AUTH = 3
+EXIT_PARTIAL = 4
+EXIT_VERIFICATION = 5
+EXIT_NOTHING_TO_MIGRATE = 6
+
+
+class PartialMigration(RuntimeError):
+    """Some secrets were not set on the target while others were."""
+
+
+class VerificationMismatch(RuntimeError):
+    """The outcome could not be confirmed, or the source and target changed since the plan."""
+
+
+def exit_code(error: Exception, completed: int = 0) -> int:
+    """Return the exit status for a failed run.
+
+    Args:
+        error: Why the run failed
+        completed: Runs (repositories, jobs) that had already succeeded; a
+            generic failure after some of them is a partial migration
+    """
+    if isinstance(error, (AuthError, PermissionDenied)):
+        return EXIT_AUTH
+    if isinstance(error, PartialMigration):
+        return EXIT_PARTIAL
+    if isinstance(error, VerificationMismatch):
+        return EXIT_VERIFICATION
+    return EXIT_PARTIAL if completed else EXIT_FAILED
@@ -0,0 +1,38 @@
+"""Target lists of fan-out runs (one source repository to several target repositories)."""
+import re
+from typing import Iterable, List
+
+_REPO_NAME = re.compile(r"^[A-Za-z0-9._-]+$")
+
+
+def parse_targets(lines: Iterable[str], target_org: str) -> List[str]:
+    """Parse target repositories, one per line, in order and without duplicates.
+
+    Lines hold a repository name or 'org/repo' with org equal to target_org
+    (every target shares the target token); blank lines and '#' comments are
+    ignored.
+
+    Raises:
+        ValueError: If a line is not a repository of target_org
+    """
+    repos: List[str] = []
+    for number, line in enumerate(lines, 1):
+        entry = line.split("#", 1)[0].strip()
+        if not entry:
+            continue
+        org, _, repo = entry.rpartition("/")
+        if org and org.lower() != target_org.lower():
+            raise ValueError(
+                f"line {number}: '{entry}' is not in target organization '{target_org}'"
+            )
+        if not _REPO_NAME.match(repo) or repo in (".", ".."):
+            raise ValueError(f"line {number}: invalid repository name '{entry}'")
+        if repo.lower() not in (known.lower() for known in repos):
+            repos.append(repo)
+    return repos
+
+
+def load_targets_file(path: str, target_org: str) -> List[str]:
+    """Load a targets file (see parse_targets)."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return parse_targets(handle, target_org)
@@ -0,0 +1,40 @@
+"""Secret name filtering shared by discovery, pruning and workflow generation."""
+from typing import Iterable, List
+
+# Private key of the GitHub App the workflow mints target tokens with (kept by the user)
+TARGET_APP_KEY_SECRET = "SECRETS_MIGRATOR_TARGET_APP_KEY"
+
+# Secrets owned by GitHub or by the migrator itself; never migrated or pruned
+SYSTEM_SECRETS = (
+    "github_token",
+    "SECRETS_MIGRATOR_PAT",
+    "SECRETS_MIGRATOR_TARGET_PAT",
+    "SECRETS_MIGRATOR_SOURCE_PAT",
+    TARGET_APP_KEY_SECRET,
+)
+
+
+def is_managed_secret(name: str) -> bool:
+    """Return True if the secret is managed by the migration (not a system secret)."""
+    return name not in SYSTEM_SECRETS
+
+
+def managed_secrets(names: Iterable[str]) -> List[str]:
+    """Filter a list of secret names down to the ones the migration manages."""
+    return [name for name in names if is_managed_secret(name)]
+
+
+def secrets_to_prune(source_names: Iterable[str], target_names: Iterable[str]) -> List[str]:
+    """Select managed target secrets that no longer exist on the source.
+
+    Names are compared case-insensitively, as GitHub stores them in upper case.
+
+    Args:
+        source_names: Secret names present on the source
+        target_names: Secret names present on the target
+
+    Returns:
+        Target secret names to delete, in target order
+    """
+    source = {name.upper() for name in source_names}
+    return [name for name in managed_secrets(target_names) if name.upper() not in source]
@@ -0,0 +1,168 @@
+"""Interoperability with GitHub Enterprise Importer (gh gei) repository migrations.
+
+GEI moves repositories but not their Actions secrets. Its migration logs
+tell which repositories reached the target, so secrets can follow them;
+and the CSVs written here use GEI's org/repo columns, so inventories and
+results line up with the repository lists of a GEI migration wave.
+"""
+import csv
+import io
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+# Leading "[2024-05-01 10:00:00] [INFO] " of each GEI log line
+_LINE_PREFIX = re.compile(r"^\[[^\]]*\]\s*\[[A-Z]+\]\s*")
+_FIELDS = {
+    "GITHUB SOURCE ORG": "source_org",
+    "SOURCE REPO": "source_repo",
+    "GITHUB TARGET ORG": "target_org",
+    "TARGET REPO": "target_repo",
+}
+_FIELD = re.compile(rf"^(?P<field>{'|'.join(_FIELDS)}): (?P<value>\S+)")
+_QUEUED = re.compile(r"migration \(ID: (?P<id>[^)\s]+)\) was successfully queued", re.IGNORECASE)
+_COMPLETED = re.compile(r"Migration completed \(ID: (?P<id>[^)\s]+)\)! State: (?P<state>\w+)")
+_WAITED = re.compile(r"Migration (?P<id>RM_\S+) (?P<state>succeeded|failed)", re.IGNORECASE)
+_FAILED = re.compile(r"Migration Failed\. Migration ID: (?P<id>\S+)", re.IGNORECASE)
+
+GEI_INVENTORY_COLUMNS = (
+    "org", "repo", "repo_secrets", "environment_secrets", "environments", "unused_secrets",
+    "unresolved_secrets",
+)
+GEI_RESULT_COLUMNS = (
+    "source_org", "source_repo", "target_org", "target_repo", "job", "status", "error",
+)
+
+
+class GeiMigration:
+    """One repository migration recorded in a GEI log."""
+
+    def __init__(
+        self,
+        source_org: str,
+        source_repo: str,
+        target_org: str,
+        target_repo: str,
+        migration_id: str = "",
+        state: str = ""
+    ):
+        self.source_org = source_org
+        self.source_repo = source_repo
+        self.target_org = target_org
+        self.target_repo = target_repo or source_repo
+        self.migration_id = migration_id  # e.g. 'RM_kgDaACQ...'
+        self.state = state  # 'SUCCEEDED', 'FAILED', ...; empty while unknown
+
+    @property
+    def succeeded(self) -> bool:
+        """Whether GEI reported the repository migrated."""
+        return self.state.upper() == "SUCCEEDED"
+
+    @property
+    def label(self) -> str:
+        """Source and target of the migration."""
+        return f"{self.source_org}/{self.source_repo} → {self.target_org}/{self.target_repo}"
+
+    def job(self) -> Dict[str, Any]:
+        """Return a pipeline job migrating the secrets of this repository."""
+        return {
+            "name": f"{self.source_org}/{self.source_repo}",
+            "source_org": self.source_org,
+            "source_repo": self.source_repo,
+            "target_org": self.target_org,
+            "target_repo": self.target_repo,
+        }
+
+
+def parse_gei_log(text: str) -> List[GeiMigration]:
+    """Read the repository migrations of a GEI log (gh gei migrate-repo, generated scripts).
+
+    Each migration is announced by its source and target fields; its outcome
+    comes from the completion line, matched by migration ID when the
+    migration was queued first (parallel scripts, wait-for-migration) and to
+    the last announced migration otherwise. A repository migrated more than
+    once keeps its last outcome.
+    """
+    migrations: List[GeiMigration] = []
+    by_id: Dict[str, GeiMigration] = {}
+    fields: Dict[str, str] = {}
+    pending: Optional[GeiMigration] = None
+    for raw in text.splitlines():
+        line = _LINE_PREFIX.sub("", raw.strip())
+        field = _FIELD.match(line)
+        if field:
+            name, value = _FIELDS[field.group("field")], field.group("value").strip("'\"")
+            if name == "source_org":
+                fields, pending = {}, None
+            fields[name] = value
+            if pending is None and {"source_org", "source_repo", "target_org"} <= set(fields):
+                pending = GeiMigration(
+                    fields["source_org"], fields["source_repo"], fields["target_org"],
+                    fields.get("target_repo", "")
+                )
+                migrations.append(pending)
+            elif pending is not None and name == "target_repo":
+                pending.target_repo = value
+            continue
+        queued = _QUEUED.search(line)
+        if queued and pending is not None:
+            pending.migration_id = queued.group("id")
+            by_id[pending.migration_id] = pending
+            continue
+        outcome = _COMPLETED.search(line) or _WAITED.search(line) or _FAILED.search(line)
+        if outcome:
+            state = outcome.groupdict().get("state") or "FAILED"
+            migration = by_id.get(outcome.group("id")) or pending
+            if migration is not None:
+                migration.migration_id = migration.migration_id or outcome.group("id")
+                migration.state = state.upper()
+    latest: Dict[Tuple[str, str], GeiMigration] = {}
+    for migration in migrations:
+        latest[(migration.source_org.lower(), migration.source_repo.lower())] = migration
+    return list(latest.values())
+
+
+def load_gei_log(path: str) -> List[GeiMigration]:
+    """Read a GEI log file.
+
+    Raises:
+        OSError: If the file cannot be read
+        ValueError: If it records no repository migration
+    """
+    with open(path, encoding="utf-8", errors="replace") as handle:
+        migrations = parse_gei_log(handle.read())
+    if not migrations:
+        raise ValueError("no repository migrations found (expected a gh gei log)")
+    return migrations
+
+
+def _csv(columns: Sequence[str], rows: List[Dict[str, Any]]) -> str:
+    """Render rows as CSV with a header line."""
+    output = io.StringIO()
+    writer = csv.DictWriter(output, fieldnames=list(columns), lineterminator="\n")
+    writer.writeheader()
+    writer.writerows(rows)
+    return output.getvalue()
+
+
+def usage_csv(report: Dict[str, Any]) -> str:
+    """Render a usage report (see build_usage_report) as one org/repo CSV row per repository."""
+    rows = []
+    for entry in report["repositories"]:
+        secrets = entry["secrets"]
+        rows.append({
+            "org": report["organization"],
+            "repo": entry["repo"],
+            "repo_secrets": sum(secret["level"] == "repo" for secret in secrets),
+            "environment_secrets": sum(secret["level"] == "env" for secret in secrets),
+            "environments": len(
+                {secret["environment"] for secret in secrets if secret["environment"]}
+            ),
+            "unused_secrets": sum(not secret["workflows"] for secret in secrets),
+            "unresolved_secrets": len(entry["unresolved"]),
+        })
+    return _csv(GEI_INVENTORY_COLUMNS, rows)
+
+
+def results_csv(rows: List[Dict[str, Any]]) -> str:
+    """Render per-repository migration results (GEI_RESULT_COLUMNS keys) as CSV."""
+    return _csv(GEI_RESULT_COLUMNS, rows)
@@ -0,0 +1,93 @@
+"""Commands run before and after each repository's migration (--pre-hook / --post-hook).
+
+Hooks are shell commands wiring a migration into its surroundings: updating a
+change ticket, pausing deployment pipelines while secrets move and
+re-enabling them afterwards, invalidating caches. They inherit the CLI's
+environment (tokens passed with --source-pat/--target-pat and secret values
+are never added) plus variables describing the run:
+
+    GH_SECRETS_MIGRATOR_HOOK          pre or post
+    GH_SECRETS_MIGRATOR_SOURCE_ORG    source organization
+    GH_SECRETS_MIGRATOR_SOURCE_REPO   source repository (empty for org-to-org)
+    GH_SECRETS_MIGRATOR_TARGET_ORG    target organization
+    GH_SECRETS_MIGRATOR_TARGET_REPOS  target repositories, comma-separated
+    GH_SECRETS_MIGRATOR_ORG_TO_ORG    true or false
+    GH_SECRETS_MIGRATOR_RESULT        post only: succeeded, failed or cancelled
+    GH_SECRETS_MIGRATOR_ERROR         post only: why the migration failed
+"""
+import os
+import subprocess  # nosec B404 - runs the hook commands the user configured
+from typing import Any, Callable, Dict, List
+
+HOOK_ENV_PREFIX = "GH_SECRETS_MIGRATOR_"
+HOOK_RESULTS = ("succeeded", "failed", "cancelled")
+DEFAULT_HOOK_TIMEOUT = 600  # seconds
+
+
+def hook_environment(
+    phase: str,
+    source_org: str,
+    source_repo: str,
+    target_org: str,
+    target_repos: List[str],
+    org_to_org: bool,
+    result: str = "",
+    error: str = ""
+) -> Dict[str, str]:
+    """Return the variables describing a migration to its hooks.
+
+    Raises:
+        ValueError: If phase or result is unknown
+    """
+    if phase not in ("pre", "post"):
+        raise ValueError(f"Unknown hook phase '{phase}'")
+    variables = {
+        "HOOK": phase,
+        "SOURCE_ORG": source_org,
+        "SOURCE_REPO": "" if org_to_org else source_repo,
+        "TARGET_ORG": target_org,
+        "TARGET_REPOS": ",".join(target_repos),
+        "ORG_TO_ORG": "true" if org_to_org else "false",
+    }
+    if phase == "post":
+        if result not in HOOK_RESULTS:
+            raise ValueError(f"Unknown migration result '{result}'")
+        variables.update(RESULT=result, ERROR=error)
+    return {HOOK_ENV_PREFIX + name: value for name, value in variables.items()}
+
+
+class HookRunner:
+    """Runs hook commands through the shell, like a CI step would."""
+
+    def __init__(
+        self, timeout: float = DEFAULT_HOOK_TIMEOUT, run: Callable[..., Any] = subprocess.run
+    ):
+        """Set how hooks run.
+
+        Args:
+            timeout: Seconds a hook may take before it is stopped
+            run: subprocess.run replacement (injectable for tests)
+        """
+        self.timeout = timeout
+        self.run = run
+
+    def __call__(self, command: str, variables: Dict[str, str]) -> List[str]:
+        """Run one hook and return its output lines (stdout and stderr).
+
+        Raises:
+            ValueError: If the hook fails or times out
+        """
+        try:
+            result = self.run(  # nosec B602 - the user's own hook command
+                command, shell=True, env={**os.environ, **variables},
+                stdout=subprocess.PIPE, stderr=subprocess.STDOUT, timeout=self.timeout,
+            )
+        except subprocess.TimeoutExpired:
+            raise ValueError(f"did not finish within {self.timeout:g}s")
+        except OSError as e:
+            raise ValueError(str(e))
+        lines = (result.stdout or b"").decode("utf-8", "replace").splitlines()
+        if result.returncode != 0:
+            detail = f": {lines[-1].strip()}" if lines and lines[-1].strip() else ""
+            raise ValueError(f"exited with status {result.returncode}{detail}")
+        return lines
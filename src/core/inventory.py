@@ -0,0 +1,225 @@
+"""Secret inventories and inventory comparison."""
+from datetime import datetime, timedelta
+from typing import Dict, List, Optional, Tuple
+
+
+class SecretRecord:
+    """Metadata about a single secret (never its value)."""
+
+    def __init__(
+        self,
+        name: str,
+        level: str,
+        environment: str = "",
+        visibility: str = "",
+        updated_at: Optional[datetime] = None
+    ):
+        self.name = name
+        self.level = level  # 'repo', 'env' or 'org'
+        self.environment = environment
+        self.visibility = visibility
+        self.updated_at = updated_at
+
+    @property
+    def key(self) -> Tuple[str, str, str]:
+        """Identity of the secret across inventories."""
+        return (self.level, self.environment, self.name)
+
+    @property
+    def label(self) -> str:
+        """Human-readable location of the secret."""
+        if self.level == "env":
+            return f"env:{self.environment}/{self.name}"
+        return f"{self.level}:{self.name}"
+
+
+class VariableRecord:
+    """An Actions variable; unlike secrets, variable values are readable."""
+
+    def __init__(
+        self,
+        name: str,
+        level: str,
+        value: str,
+        environment: str = "",
+        visibility: str = "",
+        updated_at: Optional[datetime] = None
+    ):
+        self.name = name
+        self.level = level  # 'repo', 'env' or 'org'
+        self.value = value
+        self.environment = environment
+        self.visibility = visibility  # organization variables only
+        self.updated_at = updated_at
+
+    @property
+    def key(self) -> Tuple[str, str, str]:
+        """Identity of the variable across inventories."""
+        return (self.level, self.environment, self.name)
+
+    @property
+    def label(self) -> str:
+        """Human-readable location of the variable."""
+        if self.level == "env":
+            return f"var:env:{self.environment}/{self.name}"
+        return f"var:{self.level}:{self.name}"
+
+
+class InventoryDiff:
+    """Delta between a source and a target inventory."""
+
+    def __init__(self):
+        self.missing_on_target: List[SecretRecord] = []
+        self.only_on_target: List[SecretRecord] = []
+        self.stale_on_target: List[Tuple[SecretRecord, SecretRecord]] = []
+        self.scope_mismatch: List[Tuple[SecretRecord, SecretRecord]] = []
+        self.in_sync: List[SecretRecord] = []
+
+    @property
+    def has_changes(self) -> bool:
+        """True if the target differs from the source in any way."""
+        return bool(
+            self.missing_on_target or self.only_on_target
+            or self.stale_on_target or self.scope_mismatch
+        )
+
+
+def diff_inventories(
+    source: List[SecretRecord],
+    target: List[SecretRecord],
+    source_skew: timedelta = timedelta(0),
+    target_skew: timedelta = timedelta(0),
+    tolerance: timedelta = timedelta(0)
+) -> InventoryDiff:
+    """Compare source and target inventories.
+
+    A target secret is considered stale when the source copy was updated after
+    the target copy; organization secrets are also compared by visibility.
+    Timestamps are stamped by each host's own clock, so they are shifted by
+    that host's skew before being compared.
+
+    Args:
+        source: Secrets discovered on the source
+        target: Secrets discovered on the target
+        source_skew: How far the source host's clock is ahead of the local clock
+        target_skew: How far the target host's clock is ahead of the local clock
+        tolerance: Margin within which timestamps are considered equal
+
+    Returns:
+        InventoryDiff describing what differs
+    """
+    diff = InventoryDiff()
+    target_by_key: Dict[Tuple[str, str, str], SecretRecord] = {
+        record.key: record for record in target
+    }
+    source_keys = set()
+
+    for record in source:
+        source_keys.add(record.key)
+        other = target_by_key.get(record.key)
+        if other is None:
+            diff.missing_on_target.append(record)
+            continue
+        changed = False
+        if record.level == "org" and record.visibility and other.visibility != record.visibility:
+            diff.scope_mismatch.append((record, other))
+            changed = True
+        if (
+            record.updated_at and other.updated_at
+            and record.updated_at - source_skew > other.updated_at - target_skew + tolerance
+        ):
+            diff.stale_on_target.append((record, other))
+            changed = True
+        if not changed:
+            diff.in_sync.append(record)
+
+    diff.only_on_target = [record for record in target if record.key not in source_keys]
+    return diff
+
+
+def _timestamp(value: Optional[datetime]) -> str:
+    return value.isoformat() if value else "unknown"
+
+
+def format_diff(diff: InventoryDiff) -> List[str]:
+    """Render an InventoryDiff as actionable lines."""
+    lines = []
+    for record in diff.missing_on_target:
+        lines.append(f"+ {record.label} missing on target (will be created by migrate)")
+    for source_record, target_record in diff.stale_on_target:
+        lines.append(
+            f"~ {source_record.label} updated on source at {_timestamp(source_record.updated_at)}, "
+            f"target last updated {_timestamp(target_record.updated_at)} (re-run migrate)"
+        )
+    for source_record, target_record in diff.scope_mismatch:
+        lines.append(
+            f"! {source_record.label} visibility is '{source_record.visibility}' on source "
+            f"but '{target_record.visibility}' on target"
+        )
+    for record in diff.only_on_target:
+        lines.append(f"- {record.label} only on target (removed by migrate --prune)")
+    lines.append(
+        f"{len(diff.missing_on_target)} missing, {len(diff.stale_on_target)} stale, "
+        f"{len(diff.scope_mismatch)} scope mismatch(es), "
+        f"{len(diff.only_on_target)} only on target, {len(diff.in_sync)} in sync"
+    )
+    return lines
+
+
+class VariableDiff:
+    """Delta between source and target Actions variables, values included."""
+
+    def __init__(self):
+        self.missing_on_target: List[VariableRecord] = []
+        self.only_on_target: List[VariableRecord] = []
+        self.value_mismatch: List[Tuple[VariableRecord, VariableRecord]] = []
+        self.in_sync: List[VariableRecord] = []
+
+    @property
+    def has_changes(self) -> bool:
+        """True if the target variables differ from the source in any way."""
+        return bool(self.missing_on_target or self.only_on_target or self.value_mismatch)
+
+
+def diff_variables(source: List[VariableRecord], target: List[VariableRecord]) -> VariableDiff:
+    """Compare source and target variables by name and value."""
+    diff = VariableDiff()
+    target_by_key = {record.key: record for record in target}
+    source_keys = set()
+    for record in source:
+        source_keys.add(record.key)
+        other = target_by_key.get(record.key)
+        if other is None:
+            diff.missing_on_target.append(record)
+        elif other.value != record.value:
+            diff.value_mismatch.append((record, other))
+        else:
+            diff.in_sync.append(record)
+    diff.only_on_target = [record for record in target if record.key not in source_keys]
+    return diff
+
+
+def _preview(value: str, limit: int = 40) -> str:
+    """Quote a variable value for display, truncating long values."""
+    text = value if len(value) <= limit else value[:limit - 3] + "..."
+    return repr(text)
+
+
+def format_variable_diff(diff: VariableDiff) -> List[str]:
+    """Render a VariableDiff as actionable lines."""
+    lines = []
+    for record in diff.missing_on_target:
+        lines.append(f"+ {record.label} missing on target")
+    for source_record, target_record in diff.value_mismatch:
+        lines.append(
+            f"~ {source_record.label} value differs: source {_preview(source_record.value)}, "
+            f"target {_preview(target_record.value)}"
+        )
+    for record in diff.only_on_target:
+        lines.append(f"- {record.label} only on target")
+    lines.append(
+        f"Variables: {len(diff.missing_on_target)} missing, "
+        f"{len(diff.value_mismatch)} value mismatch(es), "
+        f"{len(diff.only_on_target)} only on target, {len(diff.in_sync)} in sync"
+    )
+    return lines
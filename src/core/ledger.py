@@ -0,0 +1,165 @@
+"""Record of the secrets migrations confirmed on their targets, so re-runs can skip them."""
+import json
+import os
+import re
+from datetime import datetime, timezone
+from typing import Any, Dict, Iterable, Optional, Tuple
+from src.core.state_crypto import StateCipher, read_state, write_state
+from src.core.workflow_log import SecretOutcome
+
+LEDGER_SCHEMA_VERSION = 1
+
+_UNSAFE_PATH_CHARS = re.compile(r"[^A-Za-z0-9._-]")
+
+# (level, location, target name), as printed by the workflow's secret markers
+LedgerKey = Tuple[str, str, str]
+
+
+def ledger_path(state_dir: str, source: str) -> str:
+    """Return where the ledger of migrations from source is stored.
+
+    Layout: <state_dir>/ledger/<org>__<repo>.json (or <org>.json for organization secrets)
+    """
+    name = _UNSAFE_PATH_CHARS.sub("_", source.replace("/", "__"))
+    return os.path.join(state_dir, "ledger", f"{name}.json")
+
+
+class MigrationLedger:
+    """Secrets confirmed on a target, plus workflow runs whose outcome is not yet known.
+
+    Each confirmed secret remembers when its source secret was last updated,
+    so a secret rotated on the source since is migrated again.
+    """
+
+    def __init__(self, source: str):
+        self.source = source  # 'org/repo', or 'org' for organization secrets
+        self.entries: Dict[LedgerKey, Dict[str, Any]] = {}
+        # Run ID -> secrets it was expected to set, with their source update times
+        self.pending: Dict[int, Dict[LedgerKey, str]] = {}
+
+    def is_migrated(self, key: LedgerKey, source_updated_at: str) -> bool:
+        """Return True if the secret was confirmed and its source has not changed since.
+
+        Secrets whose source update time is unknown are never considered migrated.
+        """
+        entry = self.entries.get(key)
+        return bool(source_updated_at) and entry is not None and (
+            entry["source_updated_at"] == source_updated_at
+        )
+
+    def track_run(self, run_id: int, secrets: Dict[LedgerKey, str]) -> None:
+        """Remember the secrets a workflow run is expected to set.
+
+        Args:
+            run_id: Workflow run in the source repository
+            secrets: Source update time (ISO 8601, '' if unknown) by ledger key
+        """
+        self.pending[run_id] = dict(secrets)
+
+    def settle(
+        self, run_id: int, outcomes: Iterable[SecretOutcome], at: Optional[datetime] = None
+    ) -> int:
+        """Record the outcomes a finished run logged, and stop tracking it.
+
+        Secrets the run set are recorded; secrets it failed to set are
+        forgotten, so the next run migrates them again.
+
+        Returns:
+            Number of secrets recorded
+        """
+        expected = self.pending.pop(run_id, {})
+        at = at or datetime.now(timezone.utc)
+        recorded = 0
+        for outcome in outcomes:
+            if outcome.key not in expected:
+                continue
+            if not outcome.ok:
+                self.entries.pop(outcome.key, None)
+                continue
+            self.entries[outcome.key] = {
+                "source_updated_at": expected[outcome.key],
+                "run_id": run_id,
+                "migrated_at": at.isoformat(),
+            }
+            recorded += 1
+        return recorded
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the JSON document of the ledger."""
+        return {
+            "schema_version": LEDGER_SCHEMA_VERSION,
+            "source": self.source,
+            "secrets": [
+                {"level": level, "location": location, "name": name,
+                 **self.entries[(level, location, name)]}
+                for level, location, name in sorted(self.entries)
+            ],
+            "pending_runs": [
+                {
+                    "run_id": run_id,
+                    "secrets": [
+                        {"level": level, "location": location, "name": name,
+                         "source_updated_at": secrets[(level, location, name)]}
+                        for level, location, name in sorted(secrets)
+                    ],
+                }
+                for run_id, secrets in sorted(self.pending.items())
+            ],
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "MigrationLedger":
+        """Rebuild a ledger from its JSON document.
+
+        Raises:
+            ValueError: If the document is malformed
+        """
+        if not isinstance(data, dict) or data.get("schema_version") != LEDGER_SCHEMA_VERSION:
+            raise ValueError("unsupported ledger format")
+        ledger = cls(str(data.get("source", "")))
+        try:
+            for item in data.get("secrets", []):
+                ledger.entries[(item["level"], item["location"], item["name"])] = {
+                    "source_updated_at": item["source_updated_at"],
+                    "run_id": item["run_id"],
+                    "migrated_at": item["migrated_at"],
+                }
+            for run in data.get("pending_runs", []):
+                ledger.pending[int(run["run_id"])] = {
+                    (item["level"], item["location"], item["name"]): item["source_updated_at"]
+                    for item in run["secrets"]
+                }
+        except (KeyError, TypeError, ValueError) as e:
+            raise ValueError(f"malformed ledger entry: {e}")
+        return ledger
+
+
+def load_ledger(
+    state_dir: str, source: str, cipher: Optional[StateCipher] = None
+) -> MigrationLedger:
+    """Load the ledger of source, or an empty one if none was written yet.
+
+    Raises:
+        OSError: If the file exists but cannot be read
+        ValueError: If the file is not a ledger or cannot be decrypted
+    """
+    path = ledger_path(state_dir, source)
+    if not os.path.exists(path):
+        return MigrationLedger(source)
+    return MigrationLedger.from_dict(json.loads(read_state(path, cipher)))
+
+
+def save_ledger(
+    state_dir: str, ledger: MigrationLedger, cipher: Optional[StateCipher] = None
+) -> str:
+    """Write the ledger under state_dir (encrypted with cipher, if given) and return its path.
+
+    Raises:
+        OSError: If the file cannot be written
+        ValueError: If encryption fails
+    """
+    path = ledger_path(state_dir, ledger.source)
+    os.makedirs(os.path.dirname(path), exist_ok=True)
+    write_state(path, json.dumps(ledger.to_dict(), indent=2) + "\n", cipher)
+    return path
+
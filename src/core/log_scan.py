@@ -0,0 +1,154 @@
+"""Leak scanning of workflow run logs: plaintext secrets that escaped GitHub's masking.
+
+GitHub masks the exact values of secrets in logs, but not values derived
+from them (encoded, split, reformatted), nor secrets a step printed that
+were never registered. Findings come from two heuristics: the formats of
+well-known credentials, and long high-entropy strings. Matches are never
+reported in full, only a short prefix and a fingerprint to correlate them.
+"""
+import hashlib
+import math
+import re
+from collections import Counter
+from typing import Any, Dict, Iterable, List, Pattern, Sequence, Tuple
+
+SCAN_FORMATS = ("text", "json")
+
+# (rule, pattern) of well-known credential formats
+_FORMAT_RULES: Tuple[Tuple[str, Pattern[str]], ...] = (
+    ("github-token", re.compile(r"\bgh[pousr]_[A-Za-z0-9]{36,255}|\bgithub_pat_\w{22,255}")),
+    ("aws-access-key", re.compile(r"\b(?:AKIA|ASIA)[0-9A-Z]{16}\b")),
+    ("private-key", re.compile(r"-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----")),
+    ("slack-token", re.compile(r"\bxox[abposr]-[A-Za-z0-9-]{10,}")),
+    ("google-api-key", re.compile(r"\bAIza[0-9A-Za-z_-]{35}")),
+    ("jwt", re.compile(r"\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}")),
+)
+# A value assigned to something named like a credential, e.g. PASSWORD=... or "api_key": "..."
+_ASSIGNMENT = re.compile(
+    r"(?i)\b(?P<key>\w*(?:password|passwd|secret|token|api[_-]?key|credential)\w*)['\"]?"
+    r"\s*[:=]\s*['\"]?(?P<value>[^\s'\",;]{8,})"
+)
+# Runs of base64/hex-like characters, candidates for the entropy heuristic
+_CANDIDATE = re.compile(r"[A-Za-z0-9+/_=-]{32,}")
+_HEX = re.compile(r"[0-9a-fA-F]+")
+# Timestamp GitHub prefixes each log line with
+_TIMESTAMP = re.compile(r"^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?Z ")
+_GROUP = re.compile(r"^##\[group\](?P<title>.*)$")
+
+# Bits per character above which a candidate string looks random
+BASE64_ENTROPY_THRESHOLD = 4.5
+HEX_ENTROPY_THRESHOLD = 3.5
+ASSIGNMENT_ENTROPY_THRESHOLD = 3.0
+
+# Lengths of hex digests (commit SHAs, SHA-256) logged by checkout and pinned actions
+_DIGEST_LENGTHS = (40, 64)
+
+
+def shannon_entropy(text: str) -> float:
+    """Return the Shannon entropy of text, in bits per character."""
+    if not text:
+        return 0.0
+    counts = Counter(text)
+    return -sum(count / len(text) * math.log2(count / len(text)) for count in counts.values())
+
+
+def _masked(value: str) -> bool:
+    """Whether a value is masked or unevaluated rather than plaintext."""
+    return "***" in value or value.startswith(("$", "%", "<"))
+
+
+def _looks_random(candidate: str) -> bool:
+    """Whether a base64/hex-like string is random enough to be a credential."""
+    if _HEX.fullmatch(candidate):
+        if len(candidate) in _DIGEST_LENGTHS:
+            return False
+        return shannon_entropy(candidate) >= HEX_ENTROPY_THRESHOLD
+    if not (re.search(r"\d", candidate) and re.search(r"[A-Za-z]", candidate)):
+        return False
+    return shannon_entropy(candidate) >= BASE64_ENTROPY_THRESHOLD
+
+
+class LogFinding:
+    """A possible plaintext secret in a run log."""
+
+    def __init__(self, rule: str, job: str, line: int, step: str, match: str):
+        self.rule = rule  # credential format, 'assignment' or 'high-entropy'
+        self.job = job
+        self.line = line  # 1-based, in the job's log
+        self.step = step  # title of the log group the line is in, if any
+        self.preview = f"{match[:4]}… ({len(match)} chars)"
+        # Same value, same fingerprint: tells whether findings are one leak or several
+        self.fingerprint = hashlib.sha256(match.encode("utf-8")).hexdigest()[:12]
+
+    def describe(self) -> str:
+        """Return the finding as one report line."""
+        where = f"{self.job}, line {self.line}" + (f" ({self.step})" if self.step else "")
+        return f"{where}: {self.rule} {self.preview} [{self.fingerprint}]"
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the finding for the JSON report."""
+        return {
+            "rule": self.rule, "job": self.job, "line": self.line, "step": self.step,
+            "preview": self.preview, "fingerprint": self.fingerprint,
+        }
+
+
+def _line_matches(text: str) -> List[Tuple[str, str]]:
+    """Return (rule, matched value) of each possible secret on one log line."""
+    matches: List[Tuple[str, str]] = []
+    for rule, pattern in _FORMAT_RULES:
+        matches += [(rule, match.group(0)) for match in pattern.finditer(text)]
+    for match in _ASSIGNMENT.finditer(text):
+        value = match.group("value")
+        if not _masked(value) and shannon_entropy(value) >= ASSIGNMENT_ENTROPY_THRESHOLD:
+            matches.append(("assignment", value))
+    for match in _CANDIDATE.finditer(text):
+        candidate = match.group(0)
+        if _looks_random(candidate):
+            matches.append(("high-entropy", candidate))
+    # One report per value: a token matching a format is not repeated as high-entropy
+    reported: List[Tuple[str, str]] = []
+    for rule, value in matches:
+        if not any(value in seen or seen in value for _, seen in reported):
+            reported.append((rule, value))
+    return reported
+
+
+def scan_log(text: str, job: str = "", allow: Sequence[str] = ()) -> List[LogFinding]:
+    """Scan one job's log for possible plaintext secrets.
+
+    Args:
+        text: The log, as downloaded from GitHub
+        job: Job name reported with the findings
+        allow: Fingerprints of findings known to be harmless, left out
+    """
+    findings = []
+    step = ""
+    for number, raw in enumerate(text.splitlines(), start=1):
+        line = _TIMESTAMP.sub("", raw)
+        group = _GROUP.match(line)
+        if group:
+            step = group.group("title").strip()
+        for rule, value in _line_matches(line):
+            finding = LogFinding(rule, job, number, step, value)
+            if finding.fingerprint not in allow:
+                findings.append(finding)
+    return findings
+
+
+def scan_run_logs(logs: Iterable[Tuple[str, str]], allow: Sequence[str] = ()) -> List[LogFinding]:
+    """Scan (job name, log text) pairs, e.g. every job of a workflow run."""
+    return [finding for job, text in logs for finding in scan_log(text, job, allow)]
+
+
+def format_findings(findings: Sequence[LogFinding]) -> List[str]:
+    """Render findings as report lines, with what to do about them."""
+    if not findings:
+        return ["No possible plaintext secrets found"]
+    lines = [finding.describe() for finding in findings]
+    values = len({finding.fingerprint for finding in findings})
+    lines.append(
+        f"{len(findings)} possible leak(s) of {values} distinct value(s): check each line, "
+        "rotate the secrets that leaked and delete the run logs"
+    )
+    return lines
@@ -0,0 +1,196 @@
+"""Persistent record of migrations: inputs, created resources and per-secret outcomes."""
+import json
+import os
+import re
+from datetime import datetime, timezone
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+from src.core.state_crypto import StateCipher, read_state, write_state
+from src.core.workflow_log import SecretOutcome
+
+STATE_SCHEMA_VERSION = 1
+
+_UNSAFE_PATH_CHARS = re.compile(r"[^A-Za-z0-9._-]")
+
+# pending: the workflow run should set it; set / failed: as its log reported;
+# unconfirmed: the run finished without reporting it
+SECRET_OUTCOMES = ("pending", "set", "failed", "unconfirmed")
+
+
+def default_state_path(state_dir: str, source_org: str, source_repo: str, org_to_org: bool) -> str:
+    """Return the state file of migrations whose workflow runs in source_org/source_repo.
+
+    Layout: <state_dir>/migrations/<org>__<repo>.json, with an '.org-secrets'
+    suffix for organization secret migrations.
+    """
+    name = _UNSAFE_PATH_CHARS.sub("_", f"{source_org}__{source_repo}")
+    suffix = ".org-secrets" if org_to_org else ""
+    return os.path.join(state_dir, "migrations", f"{name}{suffix}.json")
+
+
+class MigrationRecord:
+    """One migration run as recorded in the state file."""
+
+    def __init__(
+        self,
+        inputs: Dict[str, Any],
+        started_at: str = "",
+        status: str = "running",
+        finished_at: str = "",
+        error: str = ""
+    ):
+        self.inputs = dict(inputs)  # options the run was started with (never tokens)
+        self.started_at = started_at or datetime.now(timezone.utc).isoformat()
+        self.status = status  # 'running', 'completed' or 'failed'
+        self.finished_at = finished_at
+        self.error = error
+        # Things the run created: {'kind': 'branch' | 'workflow_file' | 'temporary_secret' |
+        # 'placeholder' | 'environment' | 'repository' | 'pull_request' | 'workflow_run' |
+        # 'initial_commit' | 'unarchived_repository', ...}
+        self.resources: List[Dict[str, Any]] = []
+        # (level, location, target name) -> {'outcome': ..., 'run_id': ...}
+        self.secrets: Dict[Tuple[str, str, str], Dict[str, Any]] = {}
+        # GitHub logins of the source and target tokens ('' when a token cannot read /user)
+        self.actors: Dict[str, str] = {}
+
+    def add_resource(self, kind: str, **details: Any) -> None:
+        """Record a created resource (recording the same one twice has no effect)."""
+        resource = {"kind": kind, **details}
+        if resource not in self.resources:
+            self.resources.append(resource)
+
+    def resources_of(self, kind: str) -> List[Dict[str, Any]]:
+        """Return the recorded resources of one kind, in creation order."""
+        return [resource for resource in self.resources if resource["kind"] == kind]
+
+    def plan_secrets(self, run_id: Optional[int], keys: Iterable[Tuple[str, str, str]]) -> None:
+        """Record the secrets a workflow run is expected to set."""
+        for key in keys:
+            self.secrets[key] = {"outcome": "pending", "run_id": run_id}
+
+    def record_outcomes(self, run_id: int, outcomes: Iterable[SecretOutcome]) -> bool:
+        """Apply the outcomes a finished run logged to the secrets it was expected to set.
+
+        Secrets the log does not mention become 'unconfirmed'.
+
+        Returns:
+            True if the run belongs to this migration
+        """
+        planned = [key for key, entry in self.secrets.items() if entry["run_id"] == run_id]
+        if not planned:
+            return False
+        logged = {outcome.key: outcome.ok for outcome in outcomes}
+        for key in planned:
+            if key in logged:
+                self.secrets[key]["outcome"] = "set" if logged[key] else "failed"
+            else:
+                self.secrets[key]["outcome"] = "unconfirmed"
+        return True
+
+    def finish(self, error: str = "") -> None:
+        """Mark the run completed, or failed with error."""
+        self.status = "failed" if error else "completed"
+        self.error = error
+        self.finished_at = datetime.now(timezone.utc).isoformat()
+
+    def outcome_labels(self) -> List[Tuple[str, str]]:
+        """Return (location/name, outcome) of every planned secret, sorted."""
+        return [
+            (f"{location}/{name}", self.secrets[(level, location, name)]["outcome"])
+            for level, location, name in sorted(self.secrets)
+        ]
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the JSON document of the record."""
+        return {
+            "started_at": self.started_at,
+            "finished_at": self.finished_at or None,
+            "status": self.status,
+            "error": self.error or None,
+            "inputs": self.inputs,
+            "actors": self.actors,
+            "resources": self.resources,
+            "secrets": [
+                {"level": level, "location": location, "name": name,
+                 **self.secrets[(level, location, name)]}
+                for level, location, name in sorted(self.secrets)
+            ],
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "MigrationRecord":
+        """Rebuild a record from its JSON document.
+
+        Raises:
+            KeyError, TypeError: If the document is malformed
+        """
+        record = cls(
+            data["inputs"], data["started_at"], data["status"],
+            data.get("finished_at") or "", data.get("error") or ""
+        )
+        record.actors = dict(data.get("actors") or {})
+        record.resources = [dict(resource) for resource in data.get("resources", [])]
+        for item in data.get("secrets", []):
+            record.secrets[(item["level"], item["location"], item["name"])] = {
+                "outcome": item["outcome"], "run_id": item.get("run_id"),
+            }
+        return record
+
+
+class MigrationStateFile:
+    """The migrations recorded for one source, oldest first."""
+
+    def __init__(
+        self,
+        path: str,
+        migrations: Optional[List[MigrationRecord]] = None,
+        cipher: Optional[StateCipher] = None
+    ):
+        self.path = path
+        self.migrations = list(migrations or [])
+        self.cipher = cipher  # encrypts the file at rest when set
+
+    @property
+    def latest(self) -> Optional[MigrationRecord]:
+        """The most recent migration, if any was recorded."""
+        return self.migrations[-1] if self.migrations else None
+
+    def record_outcomes(self, run_id: int, outcomes: List[SecretOutcome]) -> None:
+        """Apply a finished run's outcomes to whichever migration triggered it."""
+        for migration in reversed(self.migrations):
+            if migration.record_outcomes(run_id, outcomes):
+                return
+
+    def save(self) -> None:
+        """Write the state file.
+
+        Raises:
+            OSError: If the file cannot be written
+            ValueError: If encryption fails
+        """
+        folder = os.path.dirname(self.path)
+        if folder:
+            os.makedirs(folder, exist_ok=True)
+        text = json.dumps({
+            "schema_version": STATE_SCHEMA_VERSION,
+            "migrations": [migration.to_dict() for migration in self.migrations],
+        }, indent=2)
+        write_state(self.path, text + "\n", self.cipher)
+
+
+def load_state_file(path: str, cipher: Optional[StateCipher] = None) -> MigrationStateFile:
+    """Load a state file, or an empty one if it does not exist yet.
+
+    Raises:
+        OSError: If the file exists but cannot be read
+        ValueError: If the file is not a migration state file or cannot be decrypted
+    """
+    if not os.path.exists(path):
+        return MigrationStateFile(path, cipher=cipher)
+    data = json.loads(read_state(path, cipher))
+    if not isinstance(data, dict) or data.get("schema_version") != STATE_SCHEMA_VERSION:
+        raise ValueError(f"{path} is not a migration state file")
+    try:
+        migrations = [MigrationRecord.from_dict(item) for item in data.get("migrations", [])]
+    except (KeyError, TypeError) as e:
+        raise ValueError(f"malformed migration record in {path}: {e}")
+    return MigrationStateFile(path, migrations, cipher)
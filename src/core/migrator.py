@@ -1,21 +1,323 @@
 """Core migration logic."""
 # flake8: noqa: E501
+import os
 import time
+from contextlib import contextmanager
+from datetime import datetime, timedelta
+from urllib.parse import quote
+from typing import Dict, Iterator, List, Optional, Tuple
 from src.clients.github import GitHubClient
+from src.clients.errors import AuthError, EmptyRepository, GitHubAPIError, NotFound, api_error
+from src.utils.http import get_text
 from src.utils.logger import Logger
+from src.utils.etag_cache import ETagCache
+from src.utils.throttle import DispatchThrottle
+from src.core.audit import AuditLog
+from src.utils.progress import Progress
 from src.core.config import MigrationConfig
-from src.core.workflow_generator import generate_workflow
+from src.core.workflow_generator import (
+    GH_CLI_CHECKSUMS_URL, GH_CLI_MIN_VERSION, GH_CLI_SHA256, FanOutTarget, generate_workflow, gh_cli_assets,
+    parse_gh_cli_checksums
+)
+from src.core.workflow_lint import lint_workflow, run_actionlint
+from src.core.placeholders import select_placeholder_secrets
+from src.core.events import EventLog, MigrationEvent
+from src.core.tracking_issue import build_tracking_issue
+from src.core.workflow_log import parse_secret_markers, unconfirmed_secrets
+from src.core.filters import is_managed_secret, managed_secrets, secrets_to_prune
+from src.core.naming import NamingConvention, SecretNameTransformer
+from src.core.conflicts import find_conflicts
+from src.core.policy import SecretPolicy, load_policy
+from src.core.value_rules import ValueRule, load_value_rules, resolve_value_rules
+from src.core.destinations import DestinationPlugin, check_destination_names, load_destination_plugin
+from src.core.hooks import HookRunner, hook_environment
+from src.core.sandbox import SANDBOX_ENV, check_sandbox, parse_sandbox, sandbox_locations
+from src.core.exit_codes import PartialMigration, VerificationMismatch
+from src.core.rego import DENY, RENAME, RegoPolicy, secret_input
+from src.core.secret_usage import scan_workflows
+from src.core.branch_rules import BranchBlocker, candidate_branches, protection_blockers, remediation, ruleset_blockers
+from src.core.environment_config import approval_gate_problems, format_reviewer
+from src.core.snapshots import build_snapshot, write_snapshot
+from src.core.ledger import LedgerKey, MigrationLedger, load_ledger, save_ledger
+from src.core.migration_state import MigrationRecord, MigrationStateFile, default_state_path, load_state_file
+from src.core.state_crypto import make_state_cipher
+from src.core.capabilities import org_capabilities, repo_capabilities
+from src.core.inventory import VariableRecord
+from src.core.scopes import OrgSecretScope
+from src.core.preflight import ENVIRONMENT_SECRET_LIMIT, ORG_SECRET_LIMIT, REPO_SECRET_LIMIT, QuotaPlan
+from src.core.plan_file import PlannedChange
+from src.core.sync import SYNC_TOLERANCE, last_completed_start, updated_since
+from src.core.audit_changes import AUDIT_LOG_LAG, audit_log_phrase, audited_changes
 
+# Seconds between workflow run status checks with --wait
+RUN_POLL_SECONDS = 10
 
 class Migrator:
     """Handles the secrets migration process."""
 
-    def __init__(self, config: MigrationConfig, logger: Logger):
+    def __init__(
+        self, config: MigrationConfig, logger: Logger, events: Optional[EventLog] = None,
+        cache: Optional[ETagCache] = None, audit: Optional[AuditLog] = None,
+        throttle: Optional[DispatchThrottle] = None
+    ):
         self.config = config
         self.log = logger
-        self.source_api = GitHubClient(config.source_pat, logger)
-        self.target_api = GitHubClient(config.target_pat, logger)
+        # Paces migration branch pushes, shared by the jobs of a pipeline (no pacing by default)
+        self.throttle = throttle or DispatchThrottle()
+        self.events = events if events is not None else EventLog()
+        self.events.add_redaction(config.source_pat)
+        self.events.add_redaction(config.target_pat)
+        self.events.add_redaction(config.state_passphrase)
+        self.namer = SecretNameTransformer(config.target_prefix, config.target_suffix, config.rename_rules)
+        self.policy = self._load_policy(config.policy_file)
+        self.value_rules = self._load_value_rules(config.value_rules_file)
+        self.destinations = self._load_destinations(config.destination_plugins, config.runner_os)
+        self.rego = self._load_rego_policy(config.rego_policy)
+        try:
+            self.state_cipher = make_state_cipher(
+                config.state_passphrase, config.state_age_recipients, config.state_age_identity
+            )
+        except ValueError as e:
+            raise RuntimeError(f"Invalid state encryption settings: {e}")
+        try:
+            self.naming = NamingConvention(
+                config.naming_pattern, config.naming_max_length, config.naming_reserved_prefixes
+            )
+        except ValueError as e:
+            raise RuntimeError(str(e))
+        # Repository/organization secrets the Rego policy denied or --only-used dropped;
+        # the workflow must skip them too
+        self._workflow_denied: List[str] = []
+        self.source_api = GitHubClient(config.source_pat, logger, cache, config.api_timeout, audit, "source", config.source_host)
+        self.target_api = GitHubClient(config.target_pat, logger, cache, config.api_timeout, audit, "target", config.target_host)
+        # Source branch/secrets to remove if the run is cancelled before the workflow takes over
+        self._pending_cleanup: List[Tuple[str, str, str]] = []
+        # Digests of the gh CLI archives the workflow may install (read once by _gh_cli_digests)
+        self._gh_cli_checksums: Optional[Dict[str, str]] = None
+        # (repo, name) of the temporary source secrets the workflow must delete
+        self._temporary_secrets: List[Tuple[str, str]] = []
+        # (client, org, repo) of the archived repositories --unarchive opened for this run
+        self._unarchived: List[Tuple[GitHubClient, str, str]] = []
+        # Secrets earlier runs confirmed on their targets (loaded by _open_ledger)
+        self.ledger = MigrationLedger(f"{config.source_org}/{config.source_repo}")
+        # Last update time (ISO 8601) of each source secret by (level, environment, name)
+        self._source_stamps: Dict[Tuple[str, str, str], str] = {}
+        # Whether _open_ledger ran (a run migrating repository and organization secrets opens it once)
+        self._ledger_opened = False
+        # Sync mode: source secrets updated before this time (source clock) are left out
+        self._sync_since: Optional[datetime] = None
+        # State file entry of this run (None when the state file cannot be read)
+        self._state: Optional[MigrationStateFile] = None
+        self._record: Optional[MigrationRecord] = None
+        # Set by plan(): both sides are read, nothing is written, secret changes are collected
+        self.planning = False
+        self.planned: List[PlannedChange] = []
+        self.hooks = HookRunner()
+        # Set when the run found no secret needing migration
+        self.nothing_to_migrate = False
+        # Variables, environments, settings and pruned secrets written to the target
+        self.target_writes = 0
     
+    @staticmethod
+    def _load_policy(path: str) -> SecretPolicy:
+        """Load the secret policy file, or an empty policy when none is configured."""
+        if not path:
+            return SecretPolicy()
+        try:
+            return load_policy(path)
+        except (OSError, ValueError) as e:
+            raise RuntimeError(f"Failed to load secret policy '{path}': {e}")
+
+    @staticmethod
+    def _load_value_rules(path: str) -> List[ValueRule]:
+        """Load the value rules file, or no rules when none is configured."""
+        if not path:
+            return []
+        try:
+            return load_value_rules(path)
+        except (OSError, ValueError) as e:
+            raise RuntimeError(f"Failed to load value rules '{path}': {e}")
+
+    @staticmethod
+    def _load_destinations(paths: List[str], runner_os: str) -> List[DestinationPlugin]:
+        """Load the destination plugin definitions."""
+        plugins = []
+        for path in paths:
+            try:
+                plugins.append(load_destination_plugin(path))
+            except (OSError, ValueError) as e:
+                raise RuntimeError(f"Failed to load destination plugin '{path}': {e}")
+        try:
+            check_destination_names(plugins)
+        except ValueError as e:
+            raise RuntimeError(str(e))
+        if plugins and runner_os == "windows":
+            raise RuntimeError("Destination plugins need a bash runner; use --runner-os ubuntu or macos")
+        return plugins
+
+    def _credential_rejection(self, name: str) -> Optional[str]:
+        """Return why a secret holding a destination plugin's credentials is not migrated."""
+        for plugin in self.destinations:
+            if name.upper() in (secret.upper() for secret in plugin.credential_secrets):
+                return f"credential of destination plugin '{plugin.name}'"
+        return None
+
+    @staticmethod
+    def _load_rego_policy(path: str) -> Optional[RegoPolicy]:
+        """Prepare the Rego policy, or None when none is configured."""
+        if not path:
+            return None
+        try:
+            return RegoPolicy(path)
+        except ValueError as e:
+            raise RuntimeError(f"Failed to load Rego policy '{path}': {e}")
+
+    @property
+    def wrote_nothing(self) -> bool:
+        """True when the run neither migrated a secret nor wrote anything else to the target
+        (exit status 6 of migrate and apply)."""
+        return self.nothing_to_migrate and not self.target_writes
+
+    def _nothing_to_migrate(self, reason: str) -> None:
+        """Record that the run ends without migrating any secret."""
+        self.nothing_to_migrate = True
+        self.events.emit("decision", f"Nothing to migrate: {reason}")
+
+    def _apply_policy(self, scope: str, secret_names: list, level: str = "repository", environment: str = "") -> list:
+        """Drop secrets the policy (and Rego policy) forbids, recording each skip and rename.
+        
+        Args:
+            scope: Human-readable scope of the names (e.g. "Repository", "Environment 'prod'")
+            secret_names: Source secret names
+            level: 'repository', 'environment' or 'organization', passed to the Rego policy
+            environment: Source environment of environment secrets
+        """
+        allowed = []
+        for name in secret_names:
+            reason = self._credential_rejection(name)
+            if reason and level != "environment" and name not in self._workflow_denied:
+                # The repository step copies every secret it can read: it must skip credentials too
+                self._workflow_denied.append(name)
+            reason = reason or self.policy.rejection_reason(name) or self._rego_rejection(name, level, environment)
+            if reason:
+                self.log.info(f"Skipping {scope.lower()} secret '{name}': {reason}")
+                self.events.emit("skipped", f"{scope} secret '{name}' skipped: {reason}", secret=name)
+            else:
+                allowed.append(name)
+        return allowed
+
+    def _rego_rejection(self, name: str, level: str, environment: str) -> Optional[str]:
+        """Ask the Rego policy about one secret; apply a rename, or return why it is denied."""
+        if self.rego is None:
+            return None
+        repo_level = level != "organization"
+        policy_input = secret_input(
+            name, level, self.config.source_org, self.config.target_org,
+            self.config.source_repo if repo_level else "", self.config.target_repo if repo_level else "",
+            environment
+        )
+        try:
+            decision = self.rego.evaluate(policy_input)
+        except ValueError as e:
+            raise RuntimeError(f"Rego policy failed for secret '{name}': {e}")
+        because = f" ({decision.reason})" if decision.reason else ""
+        if decision.action == DENY:
+            if level != "environment" and name not in self._workflow_denied:
+                self._workflow_denied.append(name)
+            return f"denied by Rego policy{because}"
+        if decision.action == RENAME:
+            previous = self.namer.overrides.get(name)
+            if previous and previous != decision.name:
+                raise RuntimeError(
+                    f"Rego policy renames secret '{name}' to both '{previous}' and '{decision.name}'; "
+                    "a secret keeps one target name across levels"
+                )
+            self.namer.overrides[name] = decision.name
+            self.events.emit("decision", f"Rego policy renames secret '{name}' to '{decision.name}'{because}", secret=name, target_name=decision.name, level=level)
+        return None
+
+    def _keep_used_secrets(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict]:
+        """Drop secrets no workflow of the source repository references (--only-used).
+        
+        Orphaned secrets are reported separately. If a workflow hands every
+        secret to code the scan cannot read, all secrets are kept.
+        
+        Returns:
+            The referenced repository secrets and environment secrets
+        """
+        self.log.info("Scanning source workflows for secret references...")
+        files = self.source_api.list_automation_files(self.config.source_org, self.config.source_repo, include_actions=False)
+        usage = scan_workflows(files)
+        self.log.debug(f"{len(files)} workflow file(s) reference {len(usage.references)} secret(s)")
+        if usage.wholesale:
+            self.log.warn(f"Workflows pass every secret on ({', '.join(usage.wholesale)}); migrating all secrets")
+            self.events.emit("warning", "--only-used kept every secret: workflows pass all secrets to code the scan cannot read", workflows=usage.wholesale)
+            return secret_names, env_secrets
+
+        used, orphaned = usage.split(secret_names)
+        used_env: dict = {}
+        orphaned_env: List[str] = []
+        for env_name, names in env_secrets.items():
+            used_env[env_name], env_orphans = usage.split(names)
+            orphaned_env.extend(f"{env_name}/{name}" for name in env_orphans)
+        for name in orphaned:
+            if name not in self._workflow_denied:
+                self._workflow_denied.append(name)
+        if not orphaned and not orphaned_env:
+            self.log.success("Every secret is referenced by a workflow")
+            return used, used_env
+
+        self.log.info(f"Orphaned secrets, referenced by no workflow and not migrated ({len(orphaned) + len(orphaned_env)} total):")
+        for label in orphaned + orphaned_env:
+            self.log.info(f"  - {label}")
+        for name in orphaned:
+            self.events.emit("skipped", f"Repository secret '{name}' skipped: not referenced by any workflow", secret=name, orphan=True)
+        for label in orphaned_env:
+            env_name, _, name = label.rpartition("/")
+            self.events.emit("skipped", f"Environment '{env_name}' secret '{name}' skipped: not referenced by any workflow", secret=name, environment=env_name, orphan=True)
+        self.events.emit(
+            "decision", f"{len(orphaned) + len(orphaned_env)} orphaned secret(s) not migrated (--only-used)",
+            orphans=orphaned, environment_orphans=orphaned_env
+        )
+        return used, used_env
+
+    def _workflow_policy(self) -> SecretPolicy:
+        """The deny/allow policy the workflow re-checks, including secrets dropped by name.
+
+        The repository step copies every secret exposed to the workflow, so
+        denied names must be excluded there as well.
+        """
+        if not self._workflow_denied:
+            return self.policy
+        return SecretPolicy(self.policy.deny + self._workflow_denied, self.policy.allow)
+
+    def _gh_cli_digests(self) -> Dict[str, str]:
+        """Read the SHA-256 of the --gh-cli-version archives from the release's checksums file.
+
+        Only needed for releases not pinned in GH_CLI_SHA256. Without them the
+        workflow still runs on runners whose gh is recent enough, but refuses to
+        install gh on the others, so a failed download is a warning.
+        """
+        if self._gh_cli_checksums is not None:
+            return self._gh_cli_checksums
+        version = self.config.gh_cli_version
+        assets = gh_cli_assets(version)
+        self._gh_cli_checksums = {}
+        if all(asset in GH_CLI_SHA256 for asset in assets):
+            return self._gh_cli_checksums
+        url = GH_CLI_CHECKSUMS_URL.format(version=version)
+        try:
+            self._gh_cli_checksums = parse_gh_cli_checksums(get_text(url, timeout=self.config.api_timeout), version)
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Could not download the gh CLI {version} checksums ({e}); runners whose gh is missing or older than {GH_CLI_MIN_VERSION} will not install it")
+            self.events.emit("warning", f"gh CLI {version} checksums not downloaded: {e}", url=url)
+            return self._gh_cli_checksums
+        missing = [asset for asset in assets if asset not in self._gh_cli_checksums and asset not in GH_CLI_SHA256]
+        if missing:
+            self.log.warn(f"The gh CLI {version} checksums list no digest for {', '.join(missing)}; runners needing those archives will not install gh")
+            self.events.emit("warning", f"gh CLI {version} checksums miss {len(missing)} archive(s)", assets=missing)
+        return self._gh_cli_checksums
+
     def _check_rate_limits(self, checkpoint: str) -> bool:
         """Check rate limits and warn if low.
         
@@ -32,7 +334,7 @@ class Migrator:
         target_ok = target_info['remaining'] >= 0
         
         if source_ok:
-            self.log.debug(
+            self.log.trace(
                 f"[{checkpoint}] Source: {source_info['remaining']}/{source_info['limit']} calls remaining"
             )
             if source_info['remaining'] < 50:
@@ -42,7 +344,7 @@ class Migrator:
                 )
         
         if target_ok:
-            self.log.debug(
+            self.log.trace(
                 f"[{checkpoint}] Target: {target_info['remaining']}/{target_info['limit']} calls remaining"
             )
             if target_info['remaining'] < 50:
@@ -138,6 +440,28 @@ class Migrator:
             branch_name: The branch that triggered the workflow
             workflow_name: The workflow file name (default: migrate-secrets.yml)
         """
+        run_id = self._find_workflow_run(branch_name, workflow_name)
+        return self._workflow_run_url(run_id) if run_id else ""
+
+    def _await_workflow_run(self, branch_name: str, workflow_name: str = "migrate-secrets.yml") -> Optional[int]:
+        """Look up the workflow run triggered by the push, retrying while GitHub registers it."""
+        max_retries = 6
+        for attempt in range(max_retries):
+            time.sleep(2 if attempt == 0 else 3)  # Initial 2s, then 3s between retries
+            run_id = self._find_workflow_run(branch_name, workflow_name)
+            if run_id:
+                self.log.debug(f"Found workflow run on attempt {attempt + 1}")
+                return run_id
+            if attempt < max_retries - 1:
+                self.log.debug(f"Workflow run not yet found, retrying... (attempt {attempt + 1}/{max_retries})")
+        return None
+
+    def _workflow_run_url(self, run_id: int) -> str:
+        """Return the web URL of a workflow run in the source repository."""
+        return f"https://{self.config.source_host}/{self.config.source_org}/{self.config.source_repo}/actions/runs/{run_id}"
+
+    def _find_workflow_run(self, branch_name: str, workflow_name: str = "migrate-secrets.yml") -> Optional[int]:
+        """Return the ID of the workflow run triggered by the push to the migration branch, if found."""
         try:
             repo = self.source_api.client.get_repo(
                 f"{self.config.source_org}/{self.config.source_repo}"
@@ -155,7 +479,7 @@ class Migrator:
                         workflow = w
                         break
                 if not workflow:
-                    return ""
+                    return None
             
             # Try multiple statuses: in_progress, queued, completed, failure
             for status in ["in_progress", "queued", "completed", "failure"]:
@@ -163,15 +487,492 @@ class Migrator:
                     runs = workflow.get_runs(branch=branch_name, status=status)
                     for run in runs:
                         self.log.debug(f"Found workflow run {run.id} with status {status}")
-                        return f"https://github.com/{self.config.source_org}/{self.config.source_repo}/actions/runs/{run.id}"
+                        return run.id
                 except Exception as status_error:
                     self.log.debug(f"No {status} runs found: {status_error}")
                     continue
             
-            return ""
+            return None
         except Exception as e:
             self.log.debug(f"Could not fetch workflow run details: {e}")
+            return None
+
+    def _confirm_run(self, run_id: int, expected: List[Tuple[str, str, str]]) -> None:
+        """Wait for the workflow run to finish, then confirm each secret from its log (--wait).
+        
+        Args:
+            run_id: Workflow run in the source repository
+            expected: (level, location, target name) of every secret the run should set,
+                as printed by the workflow's secret markers
+        
+        Raises:
+            PartialMigration: If some secrets failed while others were set
+            VerificationMismatch: If no secret failed but some were never reported
+            RuntimeError: If every secret failed, or the run did not succeed
+        """
+        org, repo = self.config.source_org, self.config.source_repo
+        run_url = self._workflow_run_url(run_id)
+        self.log.info(f"Waiting for workflow run {run_id} to finish...")
+        status, conclusion = self.source_api.get_workflow_run_state(org, repo, run_id)
+        while status != "completed":
+            time.sleep(RUN_POLL_SECONDS)
+            status, conclusion = self.source_api.get_workflow_run_state(org, repo, run_id)
+        self.log.info(f"Workflow run finished: {conclusion}")
+        self._verify_temporary_secrets_removed()
+
+        outcomes = parse_secret_markers(self.source_api.download_run_logs(org, repo, run_id))
+        self._settle_run(run_id, outcomes)
+        failed = [outcome.label for outcome in outcomes if not outcome.ok]
+        missing = [f"{location}/{name}" for _, location, name in unconfirmed_secrets(outcomes, expected)]
+        for outcome in outcomes:
+            if outcome.ok:
+                self.log.debug(f"✓ {outcome.label}")
+                self.events.emit("secret_confirmed", f"Workflow set {outcome.level} secret '{outcome.label}'", secret=outcome.name, level=outcome.level, location=outcome.location)
+        for label in failed:
+            self.log.warn(f"Secret not set on target: {label}")
+            self.events.emit("error", f"Workflow failed to set secret '{label}'", secret=label, run_url=run_url)
+        for label in missing:
+            self.log.warn(f"Secret not confirmed by the workflow log: {label}")
+            self.events.emit("error", f"Workflow log does not confirm secret '{label}'", secret=label, run_url=run_url)
+
+        if failed or missing:
+            message = (
+                f"{len(failed)} secret(s) failed and {len(missing)} were not confirmed by workflow run {run_id}: "
+                + ", ".join(failed + missing) + f" (see {run_url})"
+            )
+            if not failed:
+                # Secrets the log never mentions may well be set, but the run cannot confirm it
+                raise VerificationMismatch(message)
+            if any(outcome.ok for outcome in outcomes):
+                raise PartialMigration(message)
+            raise RuntimeError(message)
+        if conclusion != "success":
+            raise RuntimeError(f"Workflow run {run_id} concluded '{conclusion}' (see {run_url})")
+        confirmed = sum(1 for outcome in outcomes if outcome.ok)
+        self.log.success(f"Workflow run confirmed {confirmed} secret(s) on the target")
+
+    def _create_temporary_secret(self, repo: str, name: str, value: str) -> None:
+        """Store a temporary secret for the workflow on the source (in the approval environment, if any)."""
+        org, environment = self.config.source_org, self.config.approval_environment
+        if environment:
+            self.source_api.create_environment_secret(org, repo, environment, name, value)
+        else:
+            self.source_api.create_repo_secret(org, repo, name, value)
+        self._created("secret", repo, name)
+
+    def _delete_temporary_secret(self, repo: str, name: str) -> None:
+        """Delete a temporary secret from the source (from the approval environment, if any)."""
+        org, environment = self.config.source_org, self.config.approval_environment
+        if environment:
+            self.source_api.delete_environment_secret(org, repo, environment, name)
+        else:
+            self.source_api.delete_secret(org, repo, name)
+
+    def _workflow_actor(self) -> str:
+        """Return the login the migration workflow may be started by ('' for no restriction).
+        
+        Pushing the migration branch starts the run as the source token's user;
+        merged pull requests (--delivery pull-request) run as whoever merged them.
+        """
+        if self.config.delivery != "push":
             return ""
+        login = self.source_api.get_login()
+        if not login:
+            self.log.warn("Could not read the source token's user; the workflow will not check who started it")
+        return login
+
+    def _check_approval_environment(self, repo: str, branch_name: str) -> None:
+        """Check that the approval environment requires reviewers and accepts the workflow's branch.
+        
+        Raises:
+            RuntimeError: If it is missing or cannot gate the run
+        """
+        environment = self.config.approval_environment
+        if not environment:
+            return
+        spec = self.source_api.get_environment_spec(self.config.source_org, repo, environment)
+        # Merged pull requests run the workflow on the base branch, usually a protected one
+        pull_request = self.config.delivery == "pull-request"
+        branch = self.source_api.get_default_branch(self.config.source_org, repo) if pull_request else branch_name
+        problems = approval_gate_problems(spec, branch, branch_protected=pull_request)
+        if problems:
+            raise RuntimeError(f"Approval environment cannot gate the migration: {'; '.join(problems)}")
+        reviewers = ", ".join(format_reviewer(reviewer) for reviewer in spec.reviewers)
+        self.log.info(f"The workflow will wait for approval in environment '{environment}' ({reviewers})")
+        self.events.emit("decision", f"Workflow gated by environment '{environment}'", environment=environment, reviewers=reviewers)
+
+    def _verify_temporary_secrets_removed(self) -> None:
+        """Check that the finished workflow deleted the temporary source secrets, deleting leftovers.
+        
+        The workflow's cleanup step can fail (or be skipped if the run is
+        cancelled early), which would leave the target PAT readable on the
+        source; leftovers are removed with the source token.
+        """
+        org = self.config.source_org
+        for repo in dict.fromkeys(repo for repo, _ in self._temporary_secrets):
+            try:
+                if self.config.approval_environment:
+                    remaining = set(self.source_api.list_environment_secrets(org, repo, self.config.approval_environment))
+                else:
+                    remaining = set(self.source_api.list_repo_secrets(org, repo))
+            except RuntimeError as e:
+                self.log.warn(f"Could not verify that the temporary secrets were removed from {org}/{repo}: {e}")
+                continue
+            for name in [name for secret_repo, name in self._temporary_secrets if secret_repo == repo and name in remaining]:
+                self.log.warn(f"Workflow did not delete temporary secret {name} from {org}/{repo}; deleting it now")
+                try:
+                    self._delete_temporary_secret(repo, name)
+                    self.events.emit("deleted", f"Removed leftover temporary secret '{name}' from {org}/{repo}", resource_kind="secret", name=name)
+                except RuntimeError as e:
+                    self.log.error(f"Could not delete temporary secret {name}; delete it manually from {org}/{repo}: {e}")
+                    self.events.emit("warning", f"Temporary secret '{name}' left in {org}/{repo}", resource_kind="secret", name=name)
+        self.log.debug("Verified that the temporary secrets were removed from the source")
+
+    def _open_ledger(self) -> None:
+        """Load the ledger of earlier runs and record the outcome of the runs it still tracks.
+        
+        A run that finished since is read back from its log; a run still in
+        progress stays tracked, and its secrets are migrated again meanwhile.
+        """
+        if self._ledger_opened:
+            return
+        self._ledger_opened = True
+        org, repo = self.config.source_org, self.config.source_repo
+        try:
+            self.ledger = load_ledger(self.config.state_dir, self.ledger.source, self.state_cipher)
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Ignoring unreadable migration ledger ({e}); every secret is migrated again")
+            self.events.emit("warning", f"Migration ledger ignored: {e}")
+            return
+        for run_id in sorted(self.ledger.pending):
+            try:
+                status, _ = self.source_api.get_workflow_run_state(org, repo, run_id)
+                if status != "completed":
+                    self.log.info(f"Earlier workflow run {run_id} is still {status.replace('_', ' ')}; its secrets are not skipped")
+                    continue
+                outcomes = parse_secret_markers(self.source_api.download_run_logs(org, repo, run_id))
+            except RuntimeError as e:
+                self.log.warn(f"Could not read the outcome of earlier workflow run {run_id}; its secrets are migrated again: {e}")
+                self.ledger.pending.pop(run_id)
+                continue
+            recorded = self._settle_run(run_id, outcomes)
+            self.log.info(f"Earlier workflow run {run_id} confirmed {recorded} secret(s)")
+        if not self.planning:
+            self._save_ledger()
+
+    def _save_ledger(self) -> None:
+        """Write the ledger to the state directory (best effort)."""
+        try:
+            save_ledger(self.config.state_dir, self.ledger, self.state_cipher)
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Could not save the migration ledger; the next run migrates every secret again: {e}")
+            self.events.emit("warning", f"Migration ledger not saved: {e}")
+
+    def _settle_run(self, run_id: int, outcomes: list) -> int:
+        """Record in the ledger and the state file which secrets a finished workflow run set.
+        
+        Returns:
+            Number of secrets recorded as migrated
+        """
+        recorded = self.ledger.settle(run_id, outcomes)
+        self.log.debug(f"Recorded {recorded} migrated secret(s) in the ledger")
+        self._save_ledger()
+        if self._state is not None:
+            self._state.record_outcomes(run_id, outcomes)
+            self._save_state()
+        return recorded
+
+    def _track_run(self, run_id: Optional[int], stamps: Dict[LedgerKey, str]) -> None:
+        """Remember what a triggered workflow run should set, to be confirmed from its log later."""
+        if self._record is not None:
+            self._record.plan_secrets(run_id, stamps)
+            if run_id:
+                self._record.add_resource("workflow_run", repo=self.config.source_repo, run_id=run_id, url=self._workflow_run_url(run_id))
+            self._save_state()
+        if not run_id:
+            return
+        self.ledger.track_run(run_id, stamps)
+        self._save_ledger()
+
+    def _begin_record(self) -> None:
+        """Add this run to the state file, which records inputs, created resources and outcomes."""
+        config = self.config
+        path = config.state_file or default_state_path(config.state_dir, config.source_org, config.source_repo, config.org_to_org)
+        try:
+            self._state = load_state_file(path, self.state_cipher)
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Not recording this migration: cannot read state file {path}: {e}")
+            self.events.emit("warning", f"Migration state not recorded: {e}")
+            return
+        if config.sync:
+            self._sync_since = self._sync_point(self._state)
+        self._record = MigrationRecord({
+            "source_org": config.source_org,
+            "source_repo": config.source_repo,
+            "target_org": config.target_org,
+            "target_repos": [config.target_repo] if config.org_to_org else config.target_repos,
+            "org_to_org": config.org_to_org,
+            "branch_name": config.branch_name or ("migrate-org-secrets" if config.org_to_org else "migrate-secrets"),
+            "delivery": config.delivery,
+            "placeholder_mode": config.placeholder_mode,
+            "conflict_policy": config.conflict_policy,
+            "skip_envs": config.skip_envs,
+            "environment_map": config.environment_map,
+            "target_prefix": config.target_prefix,
+            "target_suffix": config.target_suffix,
+            "rename_rules": config.rename_rules,
+            "policy_file": config.policy_file,
+            "value_rules_file": config.value_rules_file,
+            "destination_plugins": config.destination_plugins,
+            "sandbox": config.sandbox,
+            "pre_hook": config.pre_hook,
+            "post_hook": config.post_hook,
+            "prune": config.prune,
+            "only_used": config.only_used,
+            "promote_to_org": config.promote_to_org,
+            "target_env": config.target_env,
+            "remigrate": config.remigrate,
+            "sync": config.sync,
+            "sync_from_audit_log": config.sync_from_audit_log,
+            "levels": config.selected_levels,
+            "secrets": config.migrate_secrets,
+            "variables": config.migrate_variables,
+        })
+        self._record.actors = {
+            "source": self.source_api.get_login(), "target": self.target_api.get_login()
+        }
+        self._state.migrations.append(self._record)
+        self._save_state()
+
+    def _sync_point(self, state: MigrationStateFile) -> Optional[datetime]:
+        """Return the source time before which secrets count as unchanged, or None when no
+        earlier run completed (the first sync migrates every secret)."""
+        since = last_completed_start(state)
+        if since is None:
+            self.log.info("Sync: no completed run recorded yet; every secret is migrated")
+            self.events.emit("decision", "Sync: no earlier completed run, every secret is migrated")
+            return None
+        self.log.info(f"Sync: migrating secrets new on the target or updated on the source since {since.isoformat()}")
+        self.events.emit("decision", f"Sync since the run started at {since.isoformat()}", since=since.isoformat())
+        # Update times are stamped by the source host's clock
+        skew = self.source_api.get_clock_skew() or timedelta(0)
+        since += skew - SYNC_TOLERANCE
+        if self.config.sync_from_audit_log:
+            # Look back over changes the previous run's audit log query may not have seen yet
+            since -= AUDIT_LOG_LAG
+        return since
+
+    def _audit_log_changed(self) -> bool:
+        """With --from-audit-log, check the source organization's audit log for secret or
+        variable changes since the last run.
+
+        Returns:
+            False when the audit log records no change of the migrated levels, so the run
+            can end without scanning; True otherwise, including when it cannot be read
+        """
+        config = self.config
+        if not (config.sync_from_audit_log and self._sync_since is not None):
+            return True
+        levels = config.selected_levels
+        repo = "" if config.org_to_org else f"{config.source_org}/{config.source_repo}"
+        # Organization changes are not tied to a repository, so only narrow the search without them
+        phrase = audit_log_phrase(self._sync_since, "" if "org" in levels else repo)
+        try:
+            entries = self.source_api.list_audit_log(config.source_org, phrase)
+        except GitHubAPIError as e:
+            self.log.warn(f"Cannot read the audit log of {config.source_org}, syncing with a full scan: {e}")
+            self.events.emit("warning", f"Audit log unavailable, full sync scan: {e}")
+            return True
+        changes = audited_changes(
+            entries, levels, repo, secrets=config.migrate_secrets, variables=config.migrate_variables
+        )
+        since = self._sync_since.isoformat()
+        if not changes:
+            self.log.success(f"✓ Audit log: no secret or variable changes since {since}; nothing to sync")
+            self.events.emit("decision", "Sync skipped: no changes in the audit log", since=since)
+            return False
+        for change in changes:
+            self.log.info(f"Audit log: {change.describe()}")
+        self.events.emit(
+            "decision", f"Sync: {len(changes)} change(s) in the audit log since {since}",
+            actions=sorted({change.action for change in changes})
+        )
+        return True
+
+    def _finish_record(self, error: str = "") -> None:
+        """Mark this run completed (or failed) in the state file."""
+        if self._record is None:
+            return
+        self._record.finish(error)
+        self._save_state()
+
+    def _save_state(self) -> None:
+        """Write the state file (best effort)."""
+        if self._state is None:
+            return
+        try:
+            self._state.save()
+        except (OSError, ValueError) as e:
+            self.log.warn(f"Could not save migration state to {self._state.path}: {e}")
+            self.events.emit("warning", f"Migration state not saved: {e}")
+            self._state = None
+            self._record = None
+
+    def _record_resource(self, kind: str, **details) -> None:
+        """Record something this run created in the state file."""
+        if self._record is None:
+            return
+        self._record.add_resource(kind, **details)
+        self._save_state()
+
+    def _created(self, kind: str, repo: str, name: str) -> None:
+        """Note a temporary source secret or migration branch: removed if the run is cancelled
+        before the workflow takes over, and recorded in the state file."""
+        self._pending_cleanup.append((kind, repo, name))
+        if kind == "secret":
+            self._temporary_secrets.append((repo, name))
+            self._record_resource("temporary_secret", repo=repo, name=name)
+        else:
+            self._record_resource("branch", repo=repo, name=name)
+
+    def _is_migrated(self, key: LedgerKey, source_key: Tuple[str, str, str], existing: List[str]) -> bool:
+        """Return True if an earlier run confirmed the secret, which still exists on the target
+        and has not changed on the source since."""
+        if key[2].upper() not in {name.upper() for name in existing}:
+            return False
+        return self.ledger.is_migrated(key, self._source_stamps.get(source_key, ""))
+
+    def _report_migrated(self, labels: List[str]) -> None:
+        """Log and record the secrets left out because an earlier run migrated them."""
+        for label in labels:
+            self.log.info(f"Skipping '{label}': migrated by an earlier run (use --remigrate to migrate it again)")
+            self.events.emit("skipped", f"Secret '{label}' skipped: migrated by an earlier run", secret=label, already_migrated=True)
+
+    def _note_plan(
+        self, action: str, level: str, names: list, environment: str = "", reason: str = "",
+        existing: Optional[List[str]] = None, scopes: Optional[Dict[str, OrgSecretScope]] = None
+    ) -> None:
+        """Collect secret changes of the current target while planning.
+        
+        Args:
+            action: 'create', 'delete' or 'skip'; creations of secrets listed in
+                    existing become 'replace'
+            level: 'repository', 'environment' or 'organization'
+            names: Source secret names, or target names for deletions
+            environment: Source environment of environment secrets
+            existing: Target secret names, to tell creations from replacements
+            scopes: Organization secret scopes by source name
+        """
+        if not self.planning:
+            return
+        org = self.config.target_org
+        location = {
+            "repository": f"{org}/{self.config.target_repo}",
+            "environment": f"{org}/{self.config.target_repo}:{self._target_env(environment)}",
+            "organization": org,
+        }[level]
+        taken = {name.upper() for name in existing or []}
+        rewritten = resolve_value_rules(self.value_rules, names) if action == "create" else {}
+        for name in names:
+            target_name = name if action == "delete" else self.namer.transform(name)
+            scope = (scopes or {}).get(name)
+            note = reason
+            if name in rewritten:
+                note = "value rewritten: " + "; ".join(rule.describe() for rule in rewritten[name])
+            self.planned.append(PlannedChange(
+                "replace" if action == "create" and target_name.upper() in taken else action,
+                level, location, target_name, "" if action == "delete" else name,
+                ", ".join([scope.visibility] + scope.repositories) if scope else "", note
+            ))
+
+    def _is_unchanged(self, target_name: str, source_key: Tuple[str, str, str], existing: List[str]) -> bool:
+        """Return True if, in sync mode, the secret exists on the target and was not updated on
+        the source since the last completed run."""
+        if self._sync_since is None or target_name.upper() not in {name.upper() for name in existing}:
+            return False
+        return not updated_since(self._source_stamps.get(source_key, ""), self._sync_since)
+
+    def _report_unchanged(self, labels: List[str]) -> None:
+        """Log and record the secrets a sync leaves out because they have not changed."""
+        for label in labels:
+            self.log.debug(f"Skipping '{label}': not updated on the source since the last run")
+            self.events.emit("skipped", f"Secret '{label}' skipped: unchanged since the last run", secret=label, unchanged=True)
+        if labels:
+            self.log.info(f"Sync: {len(labels)} secret(s) unchanged since the last run")
+
+    def _skip_unchanged(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict, list]:
+        """In sync mode, leave out secrets the current target holds that have not changed since.
+        
+        Returns:
+            Same as _skip_migrated
+        """
+        if self._sync_since is None:
+            return secret_names, env_secrets, []
+        org, target_repo = self.config.target_org, self.config.target_repo
+        existing = self.target_api.list_repo_secrets(org, target_repo)
+        unchanged = [
+            name for name in secret_names
+            if self._is_unchanged(self.namer.transform(name), ("repo", "", name), existing)
+        ]
+        labels = [f"repo:{self.namer.transform(name)}" for name in unchanged]
+        self._note_plan("skip", "repository", unchanged, reason="unchanged since the last run")
+        remaining = {}
+        for env_name, env_secret_names in env_secrets.items():
+            target_env = self._target_env(env_name)
+            env_existing = self.target_api.list_environment_secrets(org, target_repo, target_env)
+            env_unchanged = [
+                name for name in env_secret_names
+                if self._is_unchanged(self.namer.transform(name), ("env", env_name, name), env_existing)
+            ]
+            labels += [f"env:{target_env}/{self.namer.transform(name)}" for name in env_unchanged]
+            remaining[env_name] = [name for name in env_secret_names if name not in env_unchanged]
+            self._note_plan("skip", "environment", env_unchanged, env_name, "unchanged since the last run")
+        self._report_unchanged(labels)
+        return [name for name in secret_names if name not in unchanged], remaining, unchanged
+
+    def _skip_migrated(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict, list]:
+        """Leave out secrets an earlier run migrated to the current target.
+        
+        Returns:
+            Tuple of (repository secrets to migrate, environment secrets to migrate,
+            source repository secret names the workflow must skip)
+        """
+        if self.config.remigrate or not self.ledger.entries:
+            return secret_names, env_secrets, []
+        org, target_repo = self.config.target_org, self.config.target_repo
+        location = f"{org}/{target_repo}"
+        existing = self.target_api.list_repo_secrets(org, target_repo)
+        done = [
+            name for name in secret_names
+            if self._is_migrated(("repository", location, self.namer.transform(name)), ("repo", "", name), existing)
+        ]
+        labels = [f"repo:{self.namer.transform(name)}" for name in done]
+        self._note_plan("skip", "repository", done, reason="migrated by an earlier run")
+        remaining = {}
+        for env_name, env_secret_names in env_secrets.items():
+            target_env = self._target_env(env_name)
+            env_existing = self.target_api.list_environment_secrets(org, target_repo, target_env)
+            env_done = [
+                name for name in env_secret_names
+                if self._is_migrated(
+                    ("environment", f"{location}:{target_env}", self.namer.transform(name)), ("env", env_name, name), env_existing
+                )
+            ]
+            labels += [f"env:{target_env}/{self.namer.transform(name)}" for name in env_done]
+            remaining[env_name] = [name for name in env_secret_names if name not in env_done]
+            self._note_plan("skip", "environment", env_done, env_name, "migrated by an earlier run")
+        self._report_migrated(labels)
+        return [name for name in secret_names if name not in done], remaining, done
+
+    def _access_error(self, error: Exception, side: str, resource: str) -> GitHubAPIError:
+        """Type an error raised while probing a side's access, naming that side's token."""
+        typed = api_error(error, f"Cannot access {side} {resource}")
+        if isinstance(typed, AuthError):
+            typed.remediation = (
+                f"The {side} token is invalid, expired or revoked. Create a new one and pass it "
+                f"with --{side}-pat (or {side.upper()}_GITHUB_TOKEN)."
+            )
+        return typed
 
     def _validate_permissions(self) -> None:
         """Validate that both PATs have necessary permissions."""
@@ -189,34 +990,13 @@ class Migrator:
                 _ = list(secrets)  # Force evaluation
                 self.log.debug("✓ Source PAT has permission to manage secrets")
             except Exception as source_error:
-                error_msg = str(source_error)
-                if "404" in error_msg or "Not Found" in error_msg:
-                    raise RuntimeError(
-                        f"Source repository '{source_repo_path}' not found.\n"
-                        "Please verify:\n"
-                        f"  - Organization name is correct: {self.config.source_org}\n"
-                        f"  - Repository name is correct: {self.config.source_repo}\n"
-                        "  - PAT has access to the repository"
-                    )
-                elif "401" in error_msg or "Unauthorized" in error_msg:
-                    raise RuntimeError(
-                        "Authentication failed for source repository.\n"
-                        "The source PAT may be invalid, expired, or revoked.\n"
-                        "Please verify your source-pat is correct."
-                    )
-                elif "403" in error_msg or "Resource not accessible" in error_msg:
-                    raise RuntimeError(
-                        "Source PAT lacks permission to manage secrets.\n"
-                        "Ensure your source PAT has these scopes:\n"
-                        "  - 'repo' (Full control of private repositories)\n"
-                        "  - 'workflow' (Update GitHub Action workflows)"
-                    )
-                else:
-                    raise RuntimeError(f"Cannot access source repository: {source_error}")
+                raise self._access_error(source_error, "source", f"repository '{source_repo_path}'")
 
             # Check target PAT permissions
             self.log.debug("Checking target PAT permissions...")
             target_repo_path = f"{self.config.target_org}/{self.config.target_repo}"
+            if self.config.create_target_repo:
+                self._create_missing_target_repo()
             
             try:
                 target_repo = self.target_api.client.get_repo(target_repo_path)
@@ -227,30 +1007,10 @@ class Migrator:
                 _ = list(secrets)  # Force evaluation
                 self.log.debug("✓ Target PAT has permission to manage secrets")
             except Exception as target_error:
-                error_msg = str(target_error)
-                if "404" in error_msg or "Not Found" in error_msg:
-                    raise RuntimeError(
-                        f"Target repository '{target_repo_path}' not found.\n"
-                        "Please verify:\n"
-                        f"  - Organization name is correct: {self.config.target_org}\n"
-                        f"  - Repository name is correct: {self.config.target_repo}\n"
-                        "  - PAT has access to the repository"
-                    )
-                elif "401" in error_msg or "Unauthorized" in error_msg:
-                    raise RuntimeError(
-                        "Authentication failed for target repository.\n"
-                        "The target PAT may be invalid, expired, or revoked.\n"
-                        "Please verify your target-pat is correct."
-                    )
-                elif "403" in error_msg or "Resource not accessible" in error_msg:
-                    raise RuntimeError(
-                        "Target PAT lacks permission to manage secrets.\n"
-                        "Ensure your target PAT has these scopes:\n"
-                        "  - 'repo' (Full control of private repositories)\n"
-                        "  - 'workflow' (Update GitHub Action workflows)"
-                    )
-                else:
-                    raise RuntimeError(f"Cannot access target repository: {target_error}")
+                error = self._access_error(target_error, "target", f"repository '{target_repo_path}'")
+                if isinstance(error, NotFound):
+                    error.remediation += " Or pass --create-target-repo to create it."
+                raise error
 
             self.log.success("All PAT permissions validated!")
             
@@ -271,10 +1031,7 @@ class Migrator:
             raise
         except Exception as e:
             self.log.error(f"Unexpected validation error: {type(e).__name__}: {e}")
-            raise RuntimeError(
-                f"Unexpected error during PAT validation: {type(e).__name__}\n"
-                f"Details: {e}"
-            )
+            raise api_error(e, f"Unexpected error during PAT validation ({type(e).__name__})")
 
     def _recreate_environments(self) -> None:
         """List environments from source and recreate in target repository."""
@@ -287,6 +1044,7 @@ class Migrator:
 
             if not environments:
                 self.log.info("No environments to recreate")
+                self.events.emit("decision", "No environments to recreate on target")
                 return
 
             self.log.info(f"Environments to recreate ({len(environments)} total):")
@@ -295,23 +1053,773 @@ class Migrator:
 
             # Create environments in target repository
             self.log.debug("Creating environments in target repository...")
-            for env_name in environments:
-                try:
-                    self.target_api.create_environment(
-                        self.config.target_org,
-                        self.config.target_repo,
-                        env_name
-                    )
-                    self.log.debug(f"Successfully created/verified environment '{env_name}'")
-                except RuntimeError as e:
-                    # Only log as warning - don't fail the entire migration
-                    self.log.warn(f"Environment '{env_name}' error: {e}")
+            with Progress(len(environments), "Environments", self.log) as progress:
+                for env_name in environments:
+                    try:
+                        created = self.target_api.create_environment(
+                            self.config.target_org,
+                            self.config.target_repo,
+                            self._target_env(env_name)
+                        )
+                        self.log.debug(f"Successfully created/verified environment '{env_name}'")
+                        target_env = self._target_env(env_name)
+                        if created:
+                            self.target_writes += 1
+                            self.events.emit("environment_created", f"Created environment '{target_env}' on target", environment=target_env)
+                            self._record_resource("environment", repo=self.config.target_repo, name=target_env)
+                        else:
+                            self.events.emit("conflict", f"Environment '{target_env}' already existed on target; reused it", environment=target_env)
+                        self._copy_deployment_branches(env_name, target_env, created)
+                    except RuntimeError as e:
+                        # Only log as warning - don't fail the entire migration
+                        self.log.warn(f"Environment '{env_name}' error: {e}")
+                        self.events.emit("warning", f"Environment '{env_name}' could not be recreated: {e}", environment=env_name)
+                    progress.advance(env_name)
 
             self.log.success("Environment recreation completed!")
 
         except Exception as e:
             self.log.error(f"Unexpected error during environment recreation: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to recreate environments: {e}")
+            raise api_error(e, "Failed to recreate environments")
+
+    def _ensure_target_env(self) -> None:
+        """Create the --target-env environment repository secrets are written to, if missing."""
+        target_env = self.config.target_env
+        if self.target_api.create_environment(self.config.target_org, self.config.target_repo, target_env):
+            self.log.info(f"Created environment '{target_env}' for the repository secrets")
+            self.target_writes += 1
+            self.events.emit("environment_created", f"Created environment '{target_env}' on target", environment=target_env)
+            self._record_resource("environment", repo=self.config.target_repo, name=target_env)
+
+    def _route_to_target_env(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict]:
+        """Move repository secrets into the secrets of the --target-env environment.
+        
+        They join the source environment of that name (routed to itself, see
+        check_target_env) if it exists; a secret both hold is written once.
+        
+        Returns:
+            Tuple of (repository secrets left, which is empty, environment secrets)
+        """
+        target_env = self.config.target_env
+        names = env_secrets.get(target_env, [])
+        routed = names + [name for name in secret_names if name not in names]
+        for name in secret_names:
+            # Sync compares source update times by environment secret key from here on
+            stamp = self._source_stamps.get(("repo", "", name))
+            if stamp and ("env", target_env, name) not in self._source_stamps:
+                self._source_stamps[("env", target_env, name)] = stamp
+        self.log.info(f"Repository secrets go to target environment '{target_env}': {', '.join(secret_names)}")
+        self.events.emit(
+            "decision", f"Writing {len(secret_names)} repository secret(s) to target environment '{target_env}'",
+            secrets=secret_names, environment=target_env
+        )
+        return [], dict(env_secrets, **{target_env: routed})
+
+    def _copy_deployment_branches(self, env_name: str, target_env: str, created: bool) -> None:
+        """Replicate a source environment's deployment branch policy on its target environment.
+        
+        An environment that already existed on the target keeps its own policy
+        unless it allows every branch. Failures are reported, not raised.
+        """
+        org, repo = self.config.target_org, self.config.target_repo
+        try:
+            branches = self.source_api.get_deployment_branches(self.config.source_org, self.config.source_repo, env_name)
+            if branches == "all":
+                return
+            if not created:
+                current = self.target_api.get_deployment_branches(org, repo, target_env)
+                if current != "all":
+                    same = sorted(current) == sorted(branches) if isinstance(current, list) and isinstance(branches, list) else current == branches
+                    if not same:
+                        self.log.warn(f"Environment '{target_env}' keeps its own deployment branch policy on target")
+                        self.events.emit("conflict", f"Environment '{target_env}' already restricts deployment branches; its policy was kept", environment=target_env)
+                    return
+            self.target_api.set_deployment_branches(org, repo, target_env, branches)
+            self.target_writes += 1
+        except RuntimeError as e:
+            self.log.warn(f"Deployment branch policy of environment '{env_name}' not copied: {e}")
+            self.events.emit("warning", f"Deployment branch policy of environment '{env_name}' not copied: {e}", environment=env_name)
+            return
+        allowed = "protected branches" if branches == "protected" else ", ".join(branches)
+        self.log.debug(f"Environment '{target_env}' deploys from: {allowed}")
+        self.events.emit(
+            "decision", f"Environment '{target_env}' restricted to deploying from {allowed}",
+            environment=target_env, deployment_branches=branches
+        )
+
+    def _overwrite_variables(self, labels: List[str]) -> bool:
+        """Apply the conflict policy to variables that already exist on the target.
+        
+        Returns:
+            True if the existing variables are to be overwritten
+        
+        Raises:
+            RuntimeError: If the policy is 'fail'
+        """
+        policy = self.config.conflict_policy
+        if not labels or policy == "overwrite":
+            return True
+        if policy == "fail":
+            self.events.emit("error", f"{len(labels)} variable(s) already exist on target (conflict policy: fail)", variables=labels)
+            raise RuntimeError(f"Variables already exist on target (conflict policy 'fail'): {', '.join(labels)}")
+        for label in labels:
+            self.log.info(f"Skipping '{label}': already exists on target (conflict policy: skip)")
+            self.events.emit("conflict", f"Variable '{label}' already exists on target; left untouched", variable=label)
+        return False
+
+    def _migrate_variables(self, variables: List[VariableRecord]) -> None:
+        """Copy repository and environment variables to the current target repository.
+        
+        Variable values are readable, so they are written through the API
+        rather than by the workflow, and keep their names. Environment
+        variables go to the mapped target environment, which must exist.
+        """
+        org, repo = self.config.target_org, self.config.target_repo
+        if not variables:
+            self.log.info("No variables to migrate")
+            self.events.emit("decision", "No variables to migrate on target")
+            return
+        include_envs = any(record.level == "env" for record in variables)
+        existing = {record.key for record in self.target_api.list_repo_variable_records(org, repo, include_envs)}
+        target_envs = set(self.target_api.list_environments(org, repo)) if include_envs else set()
+
+        planned = []
+        for record in variables:
+            environment = self._target_env(record.environment) if record.level == "env" else ""
+            target = VariableRecord(record.name, record.level, record.value, environment)
+            if environment and environment not in target_envs:
+                self.log.warn(f"Skipping variable '{target.label}': environment '{environment}' does not exist on target")
+                self.events.emit("skipped", f"Variable '{target.label}' skipped: target environment missing", variable=target.label, environment=environment)
+                continue
+            planned.append((target, target.key in existing))
+        clashing = [target.label for target, exists in planned if exists]
+        if not self._overwrite_variables(clashing):
+            planned = [(target, exists) for target, exists in planned if not exists]
+
+        labels = [target.label for target, _ in planned]
+        self.log.info(f"Variables to migrate ({len(labels)} total):")
+        for label in labels:
+            self.log.info(f"  - {label}")
+        self.events.emit("decision", f"Migrating {len(labels)} variable(s)", variables=labels, target_repo=repo)
+        for target, exists in planned:
+            if target.level == "env":
+                self.target_api.set_environment_variable(org, repo, target.environment, target.name, target.value, exists)
+            else:
+                self.target_api.set_repo_variable(org, repo, target.name, target.value, exists)
+            self.target_writes += 1
+            if not exists:
+                self._record_resource("variable", level=target.level, repo=repo, environment=target.environment, name=target.name)
+        self.log.success(f"Migrated {len(planned)} variable(s)")
+
+    def _create_missing_target_repo(self) -> None:
+        """Create the target repository if it does not exist yet (--create-target-repo).
+        
+        Common mid-migration, when repositories have not been imported yet;
+        the repository is created empty so a later import can still fill it.
+        """
+        org, repo = self.config.target_org, self.config.target_repo
+        if self.target_api.repository_exists(org, repo):
+            self.log.debug(f"Target repository {org}/{repo} exists")
+            return
+        if self.planning:
+            raise RuntimeError(
+                f"Target repository {org}/{repo} does not exist yet, so there is nothing to plan against: "
+                "create it first (or migrate without a plan using --create-target-repo)"
+            )
+        visibility = self.config.target_repo_visibility
+        self.log.info(f"Target repository {org}/{repo} not found; creating it ({visibility})...")
+        url = self.target_api.create_repository(org, repo, visibility)
+        self.target_writes += 1
+        self.log.success(f"✓ Created {visibility} target repository {org}/{repo}")
+        self.events.emit("repository_created", f"Created {visibility} target repository {org}/{repo}", repo=repo, visibility=visibility)
+        self._record_resource("repository", repo=repo)
+        self.events.emit("link", "Target repository", url=url)
+
+    def _check_capabilities(self) -> None:
+        """Validate what this run will and won't do before anything is written.
+        
+        Prints a capability matrix (namespace × allowed/skipped/blocked ×
+        reason) built from the requested flags, the tokens' classic scopes and
+        the target repository's permissions, and stops the run when a requested
+        part of it is blocked.
+        """
+        source_scopes = self.source_api.get_token_scopes()
+        target_scopes = self.target_api.get_token_scopes()
+        if self.config.org_to_org:
+            matrix = org_capabilities(self.config, source_scopes, target_scopes)
+        else:
+            permissions = self.target_api.get_repo_permissions(self.config.target_org, self.config.target_repo)
+            target_admin = None if permissions is None else bool(permissions.get("admin"))
+            matrix = repo_capabilities(self.config, source_scopes, target_scopes, permissions is not None, target_admin)
+        self.log.info("Capability matrix:")
+        for line in matrix.format_table():
+            self.log.info(f"  {line}")
+        self.events.emit("capabilities", "Capability matrix validated", rows=[row.to_dict() for row in matrix.rows])
+        if matrix.blocked:
+            for row in matrix.blocked:
+                self.log.error(f"  {row.namespace}: {row.reason}")
+            raise RuntimeError(
+                f"{len(matrix.blocked)} requested part(s) of this run are blocked: "
+                f"{', '.join(row.namespace for row in matrix.blocked)}. Nothing was written."
+            )
+
+    def _migrate_settings(self) -> None:
+        """Copy repository settings that consumers of the migrated repository depend on.
+        
+        Currently the Actions access policy (which repositories may use this
+        repository's actions and reusable workflows), so shared tooling keeps
+        working for its consumers after the move. Servers that do not expose
+        the policy (older GHES versions, public repositories) are skipped.
+        """
+        source = f"{self.config.source_org}/{self.config.source_repo}"
+        level = self.source_api.get_actions_access_level(self.config.source_org, self.config.source_repo)
+        if level is None:
+            self.log.info(f"Actions access policy not available for {source}; skipping")
+            self.events.emit("skipped", f"Actions access policy not copied: not exposed for {source}", setting="actions_access")
+            return
+        target = f"{self.config.target_org}/{self.config.target_repo}"
+        current = self.target_api.get_actions_access_level(self.config.target_org, self.config.target_repo)
+        if current is None:
+            self.log.warn(f"Actions access policy not available on {target}; set it to '{level}' manually")
+            self.events.emit("warning", f"Actions access policy '{level}' could not be applied: not exposed for {target}", setting="actions_access", access_level=level)
+            return
+        if current == level:
+            self.log.info(f"Actions access policy of {target} already '{level}'")
+            self.events.emit("decision", f"Actions access policy already '{level}' on target", setting="actions_access", access_level=level)
+            return
+        self.target_api.set_actions_access_level(self.config.target_org, self.config.target_repo, level)
+        self.target_writes += 1
+        self.log.success(f"Copied Actions access policy '{level}' to {target}")
+        self.events.emit("decision", f"Copied Actions access policy '{level}' (was '{current}') to target", setting="actions_access", access_level=level, previous=current)
+
+    def _target_env(self, env_name: str) -> str:
+        """Return the target environment a source environment's secrets are routed to."""
+        return self.config.environment_map.get(env_name, env_name)
+
+    def _resolve_conflicts(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict, list]:
+        """Apply the conflict policy to secrets that already exist on the target.
+        
+        Returns:
+            Tuple of (repository secrets to migrate, environment secrets to migrate,
+            source repository secret names the workflow must skip)
+        
+        Raises:
+            RuntimeError: If the policy is 'fail' and any target secret already exists
+        """
+        policy = self.config.conflict_policy
+        if policy == "overwrite":
+            return secret_names, env_secrets, []
+        
+        existing = self.target_api.list_repo_secrets(self.config.target_org, self.config.target_repo)
+        conflicts = set(find_conflicts([self.namer.transform(name) for name in secret_names], existing))
+        repo_conflicts = [name for name in secret_names if self.namer.transform(name) in conflicts]
+        labels = [f"repo:{self.namer.transform(name)}" for name in repo_conflicts]
+        
+        env_conflicts = {}
+        for env_name, env_secret_names in env_secrets.items():
+            target_env = self._target_env(env_name)
+            env_existing = self.target_api.list_environment_secrets(self.config.target_org, self.config.target_repo, target_env)
+            env_clashes = set(find_conflicts([self.namer.transform(name) for name in env_secret_names], env_existing))
+            env_conflicts[env_name] = [name for name in env_secret_names if self.namer.transform(name) in env_clashes]
+            labels += [f"env:{target_env}/{self.namer.transform(name)}" for name in env_conflicts[env_name]]
+        
+        if not labels:
+            return secret_names, env_secrets, []
+        if policy == "fail":
+            self.events.emit("error", f"{len(labels)} secret(s) already exist on target (conflict policy: fail)", secrets=labels)
+            raise RuntimeError(f"Secrets already exist on target (conflict policy 'fail'): {', '.join(labels)}")
+        
+        for label in labels:
+            self.log.info(f"Skipping '{label}': already exists on target (conflict policy: skip)")
+            self.events.emit("conflict", f"Secret '{label}' already exists on target; left untouched", secret=label)
+        self._note_plan("skip", "repository", repo_conflicts, reason="already exists on target")
+        for env_name, names in env_conflicts.items():
+            self._note_plan("skip", "environment", names, env_name, "already exists on target")
+        return (
+            [name for name in secret_names if name not in repo_conflicts],
+            {env_name: [name for name in names if name not in env_conflicts[env_name]] for env_name, names in env_secrets.items()},
+            repo_conflicts
+        )
+
+    @contextmanager
+    def _targeting(self, target_repo: str) -> Iterator[None]:
+        """Point the target-side steps at one repository of a fan-out run."""
+        primary = self.config.target_repo
+        self.config.target_repo = target_repo
+        if len(self.config.target_repos) > 1:
+            self.log.info(f"Target {self.config.target_org}/{target_repo}:")
+        try:
+            yield
+        finally:
+            self.config.target_repo = primary
+
+    def _emit_discovered(self, scope: str, secret_names: list, **data) -> None:
+        """Record one event per secret found on the source, before any filtering."""
+        for name in secret_names:
+            self.events.emit("secret_discovered", f"Found {scope} secret '{name}'", secret=name, scope=scope, **data)
+
+    def _plan_target(self, secret_names: list, env_secrets: dict) -> Tuple[list, dict, list]:
+        """Skip migrated secrets, resolve conflicts and check quotas for the current target, then
+        report what it receives.
+        
+        Returns:
+            Same as _resolve_conflicts
+        """
+        secret_names, env_secrets, migrated = self._skip_migrated(secret_names, env_secrets)
+        secret_names, env_secrets, unchanged = self._skip_unchanged(secret_names, env_secrets)
+        secret_names, env_secrets, skip_secrets = self._resolve_conflicts(secret_names, env_secrets)
+        skip_secrets = migrated + unchanged + skip_secrets
+
+        if self.config.quota_check != "off":
+            self.log.info("Checking target secret quotas...")
+            self._check_quotas(self._repo_quota_plans(secret_names, env_secrets))
+
+        target = {"target_repo": self.config.target_repo} if self.config.extra_target_repos else {}
+        self.log.info(f"Secrets to migrate ({len(secret_names)} total):")
+        for name in secret_names:
+            self.log.info(f"  - {name}")
+        self.events.emit(
+            "decision", f"Migrating {len(secret_names)} repository secret(s)",
+            secrets=secret_names, level="repo", **target
+        )
+
+        if env_secrets:
+            self.log.info(f"Environment secrets to migrate ({len(env_secrets)} total):")
+            for env_name, env_secret_names in env_secrets.items():
+                if env_secret_names:
+                    self.log.info(f"  - {env_name}: {', '.join(env_secret_names)}")
+                else:
+                    self.log.info(f"  - {env_name}: (no secrets)")
+            env_secret_labels = [f"{env_name}/{name}" for env_name, names in env_secrets.items() for name in names]
+            self.events.emit(
+                "decision", f"Migrating {len(env_secret_labels)} environment secret(s) across {len(env_secrets)} environment(s)",
+                secrets=env_secret_labels, level="env", environments=list(env_secrets), **target
+            )
+        else:
+            self.log.debug("No environment secrets found in source repository")
+
+        if self.planning:
+            org, repo = self.config.target_org, self.config.target_repo
+            self._note_plan("create", "repository", secret_names, existing=self.target_api.list_repo_secrets(org, repo))
+            for env_name, env_secret_names in env_secrets.items():
+                existing = self.target_api.list_environment_secrets(org, repo, self._target_env(env_name)) if env_secret_names else []
+                self._note_plan("create", "environment", env_secret_names, env_name, existing=existing)
+        return secret_names, env_secrets, skip_secrets
+
+    def _validate_target_names(self, scope: str, secret_names: list) -> None:
+        """Fail before anything is written if renaming yields names GitHub would reject.
+        
+        Args:
+            scope: Human-readable scope of the names (e.g. "repository", "environment 'prod'")
+            secret_names: Source secret names sharing one target namespace
+        """
+        if self.namer.is_identity:
+            return
+        try:
+            self.namer.validate(secret_names)
+        except ValueError as e:
+            self.events.emit("error", f"Renaming produced invalid {scope} secret names", scope=scope, detail=str(e))
+            raise RuntimeError(f"{e} ({scope} secrets)")
+
+    def _check_naming(self, scopes: Dict[str, list]) -> None:
+        """Report secret names breaking the naming conventions before anything is written.
+        
+        Names are checked as they will appear on the target, so renames can
+        bring them in line. Violations are warnings unless --enforce-naming is
+        set, in which case the run fails once every scope has been reported.
+        
+        Args:
+            scopes: Source secret names by human-readable scope (e.g. "repository")
+        """
+        if self.naming.is_empty:
+            return
+        count = 0
+        kind = "error" if self.config.enforce_naming else "warning"
+        for scope, names in scopes.items():
+            name_map = self.namer.build_map(names)
+            found = self.naming.check(name_map.values())
+            for name, target_name in name_map.items():
+                if target_name not in found:
+                    continue
+                count += 1
+                label = f"'{name}'" if name == target_name else f"'{name}' (as '{target_name}')"
+                self.log.warn(f"{scope.capitalize()} secret {label} {'; '.join(found[target_name])}")
+                self.events.emit(kind, f"{scope.capitalize()} secret {label} breaks the naming conventions", secret=name, target_name=target_name, scope=scope, problems=found[target_name])
+        if not count:
+            self.log.success("Naming convention check passed")
+            return
+        if self.config.enforce_naming:
+            raise RuntimeError(
+                f"Naming convention check failed: {count} secret name(s) break the naming conventions"
+                " (rename them with --rename-regex, or drop --enforce-naming to migrate them anyway)"
+            )
+        self.log.warn(f"{count} secret name(s) break the naming conventions (use --enforce-naming to fail the run)")
+
+    def _check_quotas(self, plans: list) -> None:
+        """Compare projected target secret counts against GitHub limits.
+        
+        Depending on --quota-check, secrets that would not fit fail the run
+        before anything is written, or are reported as warnings.
+        """
+        if self.config.quota_check == "off":
+            return
+        for plan in plans:
+            for line in plan.describe():
+                self.log.debug(line)
+        over = [plan for plan in plans if not plan.fits]
+        if not over:
+            self.log.success("Preflight quota check passed")
+            return
+        for plan in over:
+            for line in plan.describe():
+                self.log.warn(line)
+            self.events.emit(
+                "error" if self.config.quota_check == "fail" else "warning",
+                f"{plan.scope} would exceed GitHub's limit of {plan.limit} secrets; {len(plan.overflow)} secret(s) will not fit",
+                scope=plan.scope, limit=plan.limit, overflow=plan.overflow
+            )
+        if self.config.quota_check == "fail":
+            raise RuntimeError(
+                "Preflight quota check failed: " + "; ".join(f"{plan.scope} needs {len(plan.overflow)} more slot(s)" for plan in over)
+                + " (use --quota-check warn to attempt the migration anyway)"
+            )
+
+    def _repo_quota_plans(self, secret_names: list, env_secrets: dict) -> list:
+        """Build quota plans for the target repository and each target environment."""
+        target_repo_names = self.target_api.list_repo_secrets(self.config.target_org, self.config.target_repo)
+        plans = [QuotaPlan(
+            f"Repository {self.config.target_org}/{self.config.target_repo}", REPO_SECRET_LIMIT,
+            target_repo_names, [self.namer.transform(name) for name in secret_names]
+        )]
+        for env_name, env_secret_names in env_secrets.items():
+            if not env_secret_names:
+                continue
+            target_env_names = self.target_api.list_environment_secrets(
+                self.config.target_org, self.config.target_repo, self._target_env(env_name)
+            )
+            plans.append(QuotaPlan(
+                f"Environment '{env_name}'", ENVIRONMENT_SECRET_LIMIT,
+                target_env_names, [self.namer.transform(name) for name in env_secret_names]
+            ))
+        return plans
+
+    def _name_map(self, secret_names: list) -> Optional[dict]:
+        """Build the source-to-target name map for the workflow, or None when names are unchanged."""
+        if self.namer.is_identity:
+            return None
+        name_map = self.namer.build_map(secret_names)
+        for source_name, target_name in name_map.items():
+            self.events.emit("decision", f"Secret '{source_name}' will be migrated as '{target_name}'", secret=source_name, target_name=target_name)
+        return name_map
+
+    def _value_rewrites(self, secret_names: list) -> Optional[dict]:
+        """Resolve the value rules of the secrets the workflow writes, or None when none apply."""
+        resolved = resolve_value_rules(self.value_rules, secret_names)
+        for name, rules in resolved.items():
+            described = "; ".join(rule.describe() for rule in rules)
+            self.log.info(f"Value of '{name}' will be rewritten: {described}")
+            self.events.emit("decision", f"Value of secret '{name}' will be rewritten", secret=name, rules=described)
+        return {name: [rule.to_dict() for rule in rules] for name, rules in resolved.items()} or None
+
+    def _org_secret_scopes(self, secret_names: list) -> dict:
+        """Read the source scope of each organization secret so the workflow can reproduce it.
+        
+        Selected repositories are matched by name in the target organization.
+        Secrets whose scope cannot be read keep GitHub's default visibility.
+        """
+        scopes = {}
+        for name in secret_names:
+            try:
+                scope = self.source_api.get_org_secret_scope(self.config.source_org, name)
+            except RuntimeError as e:
+                self.log.warn(f"Could not read scope of organization secret '{name}', using default visibility: {e}")
+                self.events.emit("warning", f"Scope of organization secret '{name}' unknown; default visibility used", secret=name)
+                continue
+            scopes[name] = scope
+            if scope.visibility == "selected":
+                repos = ", ".join(scope.repositories) or "(none)"
+                self.log.debug(f"Organization secret '{name}' is scoped to: {repos}")
+                self.events.emit(
+                    "decision", f"Organization secret '{name}' will be scoped to {len(scope.repositories)} selected repositories",
+                    secret=name, visibility=scope.visibility, repositories=scope.repositories
+                )
+        return scopes
+
+    def _promoted_secrets(self, secret_names: list) -> list:
+        """Return the repository secrets --promote-to-org names, warning about names not migrated."""
+        wanted = {name.upper() for name in self.config.promote_to_org}
+        promoted = [name for name in secret_names if name.upper() in wanted]
+        found = {name.upper() for name in promoted}
+        for name in self.config.promote_to_org:
+            if name.upper() not in found:
+                self.log.warn(f"Not promoting '{name}': no such repository secret is being migrated")
+                self.events.emit("warning", f"Secret '{name}' not promoted: not a migrated repository secret", secret=name)
+        return promoted
+
+    def _promotion_scopes(self, secret_names: list, targets: List[str]) -> Tuple[Dict[str, OrgSecretScope], Dict[str, OrgSecretScope]]:
+        """Decide the target organization secret each promoted repository secret becomes.
+        
+        A new organization secret is scoped to the target repositories. An existing
+        one keeps its visibility, and with 'selected' visibility gains the target
+        repositories, so several source repositories can promote the same secret.
+        The conflict policy decides whether an existing secret's value is replaced.
+        
+        Returns:
+            Tuple of (scopes of the secrets the workflow writes, scopes of existing
+            secrets left untouched but given the target repositories), by source name
+        
+        Raises:
+            RuntimeError: If the policy is 'fail' and a promoted secret already exists
+        """
+        org = self.config.target_org
+        existing = self.target_api.list_org_secrets(org)
+        written, kept = {}, {}
+        for name in secret_names:
+            target_name = self.namer.transform(name)
+            if not find_conflicts([target_name], existing):
+                written[name] = OrgSecretScope("selected", sorted(targets))
+                continue
+            scope = self.target_api.get_org_secret_scope(org, target_name)
+            if scope.visibility == "selected":
+                scope = OrgSecretScope("selected", sorted(set(scope.repositories) | set(targets)))
+            if self.config.conflict_policy == "overwrite":
+                written[name] = scope
+            else:
+                kept[name] = scope
+        if kept and self.config.conflict_policy == "fail":
+            labels = [f"org:{self.namer.transform(name)}" for name in kept]
+            self.events.emit("error", f"{len(labels)} promoted secret(s) already exist on target (conflict policy: fail)", secrets=labels)
+            raise RuntimeError(f"Organization secrets already exist on target (conflict policy 'fail'): {', '.join(labels)}")
+        for name in kept:
+            self.log.info(f"Not replacing organization secret '{self.namer.transform(name)}': already exists on target (conflict policy: skip)")
+            self.events.emit("conflict", f"Organization secret '{self.namer.transform(name)}' already exists on target; value left untouched", secret=name)
+        self._note_plan("create", "organization", list(written), existing=existing, scopes=written)
+        self._note_plan("skip", "organization", list(kept), reason="already exists on target")
+
+        for target_repo in targets:
+            shadowing = find_conflicts(
+                [self.namer.transform(name) for name in secret_names],
+                self.target_api.list_repo_secrets(org, target_repo)
+            )
+            for target_name in shadowing:
+                self.log.warn(f"Repository secret '{target_name}' of {org}/{target_repo} takes precedence over the promoted organization secret; delete it once the migration is verified")
+                self.events.emit("warning", f"Repository secret '{target_name}' shadows the promoted organization secret", secret=target_name, target_repo=target_repo)
+        if written:
+            self.log.info(f"Promoting {len(written)} repository secret(s) to organization secrets of {org}: {', '.join(written)}")
+            self.events.emit(
+                "decision", f"Promoting {len(written)} repository secret(s) to organization secrets",
+                secrets=list(written), level="org", repositories=sorted(targets)
+            )
+        return written, kept
+
+    def _share_kept_secrets(self, kept: Dict[str, OrgSecretScope], targets: List[str]) -> None:
+        """Give the target repositories access to promoted secrets that already existed."""
+        org = self.config.target_org
+        for name, scope in kept.items():
+            if scope.visibility != "selected":
+                continue  # every (private) repository already sees it
+            target_name = self.namer.transform(name)
+            for target_repo in targets:
+                self.target_api.add_org_secret_repository(org, target_name, target_repo)
+                self.target_writes += 1
+            self.log.info(f"Organization secret '{target_name}' now includes {', '.join(targets)}")
+
+    def _create_placeholders(self, secret_names: list, env_secrets: dict) -> None:
+        """Create placeholder secrets on the target repository before the workflow runs.
+        
+        Args:
+            secret_names: Repository secret names being migrated
+            env_secrets: Dict mapping environment names to secret names being migrated
+        """
+        mode = self.config.placeholder_mode
+        value = self.config.placeholder_value
+        
+        existing = []
+        if mode == "skip-existing":
+            existing = self.target_api.list_repo_secrets(self.config.target_org, self.config.target_repo)
+        
+        # Placeholders are created under the name the secret will have on the target
+        target_names = [self.namer.transform(name) for name in secret_names]
+        created = 0
+        selected = select_placeholder_secrets(mode, target_names, existing)
+        for name in target_names:
+            if name not in selected and mode == "skip-existing":
+                self.events.emit("skipped", f"Placeholder for '{name}' skipped: secret already exists on target", secret=name)
+        with Progress(len(selected), "Placeholders", self.log) as progress:
+            for name in selected:
+                try:
+                    self.target_api.create_repo_secret(
+                        self.config.target_org, self.config.target_repo, name, value
+                    )
+                    created += 1
+                    event = self.events.emit("placeholder_created", f"Created placeholder for repository secret '{name}'", secret=name, level="repo")
+                    self._record_resource("placeholder", level="repo", repo=self.config.target_repo, environment="", name=name, created_at=event.timestamp)
+                except RuntimeError as e:
+                    self.log.warn(f"Could not create placeholder for '{name}': {e}")
+                    self.events.emit("warning", f"Could not create placeholder for '{name}': {e}", secret=name)
+                progress.advance(name)
+        
+        if env_secrets and self.config.skip_envs:
+            self.log.debug("Skipping environment placeholders (--skip-envs flag set)")
+        elif env_secrets:
+            for env_name, env_secret_names in env_secrets.items():
+                env_existing = []
+                if mode == "skip-existing":
+                    env_existing = self.target_api.list_environment_secrets(
+                        self.config.target_org, self.config.target_repo, self._target_env(env_name)
+                    )
+                env_target_names = [self.namer.transform(name) for name in env_secret_names]
+                env_selected = select_placeholder_secrets(mode, env_target_names, env_existing)
+                for name in env_target_names:
+                    if name not in env_selected and mode == "skip-existing":
+                        self.events.emit("skipped", f"Placeholder for '{env_name}/{name}' skipped: secret already exists on target", secret=name, environment=env_name)
+                with Progress(len(env_selected), f"Placeholders ({env_name})", self.log) as progress:
+                    for name in env_selected:
+                        try:
+                            self.target_api.create_environment_secret(
+                                self.config.target_org, self.config.target_repo, self._target_env(env_name), name, value
+                            )
+                            created += 1
+                            event = self.events.emit("placeholder_created", f"Created placeholder for environment secret '{env_name}/{name}'", secret=name, environment=env_name, target_environment=self._target_env(env_name), level="env")
+                            self._record_resource("placeholder", level="env", repo=self.config.target_repo, environment=self._target_env(env_name), name=name, created_at=event.timestamp)
+                        except RuntimeError as e:
+                            self.log.warn(f"Could not create placeholder for '{env_name}/{name}': {e}")
+                            self.events.emit("warning", f"Could not create placeholder for '{env_name}/{name}': {e}", secret=name, environment=env_name)
+                        progress.advance(name)
+        
+        self.log.success(f"Created {created} placeholder secret(s) on target (mode: {mode})")
+
+    def _create_org_placeholders(self, secret_names: list) -> None:
+        """Create placeholder organization secrets on the target before the workflow runs.
+        
+        Args:
+            secret_names: Organization secret names being migrated
+        """
+        mode = self.config.placeholder_mode
+        
+        existing = []
+        if mode == "skip-existing":
+            existing = self.target_api.list_org_secrets(self.config.target_org)
+        
+        target_names = [self.namer.transform(name) for name in secret_names]
+        created = 0
+        selected = select_placeholder_secrets(mode, target_names, existing)
+        for name in target_names:
+            if name not in selected and mode == "skip-existing":
+                self.events.emit("skipped", f"Placeholder for organization secret '{name}' skipped: secret already exists on target", secret=name)
+        with Progress(len(selected), "Placeholders", self.log) as progress:
+            for name in selected:
+                try:
+                    self.target_api.create_org_secret(
+                        self.config.target_org, name, self.config.placeholder_value
+                    )
+                    created += 1
+                    event = self.events.emit("placeholder_created", f"Created placeholder for organization secret '{name}'", secret=name, level="org")
+                    self._record_resource("placeholder", level="org", repo="", environment="", name=name, created_at=event.timestamp)
+                except RuntimeError as e:
+                    self.log.warn(f"Could not create placeholder for organization secret '{name}': {e}")
+                    self.events.emit("warning", f"Could not create placeholder for organization secret '{name}': {e}", secret=name)
+                progress.advance(name)
+        
+        self.log.success(f"Created {created} placeholder organization secret(s) on target (mode: {mode})")
+
+    def _prune_scope(self, target_names: list) -> list:
+        """Keep the target names this run could have written: with its prefix and suffix, allowed by the policy.
+        
+        The policy is checked against the source name each target name was
+        migrated from. Other target secrets (another source's prefix, names
+        the policy excludes) are not this run's to delete.
+        """
+        scoped = []
+        for name in target_names:
+            source = self.namer.source_name(name)
+            if source is not None:
+                if is_managed_secret(source) and self.policy.allows(source):
+                    scoped.append(name)
+            elif self.policy.is_empty and self.namer.strip_affixes(name) is not None:
+                # Rename rules cannot be undone; with no policy to check, the prefix and suffix decide
+                scoped.append(name)
+        return scoped
+
+    def _prune_target_secrets(self, source_secrets: list, source_env_secrets: dict, repo_level: bool = True) -> None:
+        """Delete managed target secrets that no longer exist on the source.
+        
+        Only names within _prune_scope are considered. The source environment
+        secrets must have been listed strictly: a listing that silently came
+        back empty would delete every secret of the target environment.
+        
+        Args:
+            source_secrets: Repository secret names present on the source
+            source_env_secrets: Dict mapping source environment names to secret names
+            repo_level: Whether repository secrets are pruned (False leaves them alone)
+        """
+        target_secrets = self.target_api.list_repo_secrets(self.config.target_org, self.config.target_repo) if repo_level else []
+        pruned = 0
+        source_target_names = [self.namer.transform(name) for name in source_secrets]
+        for name in secrets_to_prune(source_target_names, self._prune_scope(target_secrets)):
+            if self.planning:
+                self._note_plan("delete", "repository", [name], reason="not present on source")
+                continue
+            try:
+                self.target_api.delete_secret(self.config.target_org, self.config.target_repo, name)
+                pruned += 1
+                self.target_writes += 1
+                self.log.info(f"  - Pruned repository secret '{name}'")
+                self.events.emit("pruned", f"Deleted target repository secret '{name}' (not present on source)", secret=name, level="repo")
+            except RuntimeError as e:
+                self.log.warn(f"Could not prune '{name}': {e}")
+                self.events.emit("warning", f"Could not prune repository secret '{name}': {e}", secret=name)
+        
+        # Only environments present on both sides are pruned; target-only environments are left alone
+        for env_name, env_secret_names in source_env_secrets.items():
+            target_env_secrets = self.target_api.list_environment_secrets(
+                self.config.target_org, self.config.target_repo, self._target_env(env_name), strict=True
+            )
+            env_target_names = [self.namer.transform(name) for name in env_secret_names]
+            for name in secrets_to_prune(env_target_names, self._prune_scope(target_env_secrets)):
+                if self.planning:
+                    self._note_plan("delete", "environment", [name], env_name, "not present on source")
+                    continue
+                try:
+                    self.target_api.delete_environment_secret(
+                        self.config.target_org, self.config.target_repo, self._target_env(env_name), name
+                    )
+                    pruned += 1
+                    self.target_writes += 1
+                    self.log.info(f"  - Pruned environment secret '{env_name}/{name}'")
+                    self.events.emit("pruned", f"Deleted target environment secret '{env_name}/{name}' (not present on source)", secret=name, environment=env_name, level="env")
+                except RuntimeError as e:
+                    self.log.warn(f"Could not prune '{env_name}/{name}': {e}")
+                    self.events.emit("warning", f"Could not prune environment secret '{env_name}/{name}': {e}", secret=name, environment=env_name)
+        
+        if not self.planning:
+            self.log.success(f"Pruned {pruned} target secret(s)")
+
+    def _prune_target_org_secrets(self, source_secrets: list) -> None:
+        """Delete managed target organization secrets (within _prune_scope) that no longer exist on the source.
+        
+        Args:
+            source_secrets: Organization secret names present on the source
+        """
+        target_secrets = self.target_api.list_org_secrets(self.config.target_org)
+        pruned = 0
+        source_target_names = [self.namer.transform(name) for name in source_secrets]
+        for name in secrets_to_prune(source_target_names, self._prune_scope(target_secrets)):
+            if self.planning:
+                self._note_plan("delete", "organization", [name], reason="not present on source")
+                continue
+            try:
+                self.target_api.delete_org_secret(self.config.target_org, name)
+                pruned += 1
+                self.target_writes += 1
+                self.log.info(f"  - Pruned organization secret '{name}'")
+                self.events.emit("pruned", f"Deleted target organization secret '{name}' (not present on source)", secret=name, level="org")
+            except RuntimeError as e:
+                self.log.warn(f"Could not prune organization secret '{name}': {e}")
+                self.events.emit("warning", f"Could not prune organization secret '{name}': {e}", secret=name)
+        
+        if not self.planning:
+            self.log.success(f"Pruned {pruned} target organization secret(s)")
 
     def _validate_org_permissions(self) -> None:
         """Validate that both PATs have necessary permissions for organization access."""
@@ -327,19 +1835,7 @@ class Migrator:
                 _ = list(secrets)  # Force evaluation
                 self.log.debug("✓ Source PAT has permission to access organization secrets")
             except Exception as source_error:
-                error_msg = str(source_error)
-                if "404" in error_msg or "Not Found" in error_msg:
-                    raise RuntimeError(
-                        f"Source organization '{self.config.source_org}' not found.\n"
-                        f"Please verify the organization name is correct."
-                    )
-                elif "401" in error_msg or "Unauthorized" in error_msg:
-                    raise RuntimeError(
-                        f"Source PAT does not have access to organization '{self.config.source_org}'.\n"
-                        f"Please verify your source PAT has the necessary permissions."
-                    )
-                else:
-                    raise RuntimeError(f"Failed to access source organization: {source_error}")
+                raise self._access_error(source_error, "source", f"organization '{self.config.source_org}'")
 
             # Check target PAT permissions
             self.log.debug("Checking target PAT permissions for organization access...")
@@ -352,19 +1848,7 @@ class Migrator:
                 _ = list(secrets)  # Force evaluation
                 self.log.debug("✓ Target PAT has permission to access organization secrets")
             except Exception as target_error:
-                error_msg = str(target_error)
-                if "404" in error_msg or "Not Found" in error_msg:
-                    raise RuntimeError(
-                        f"Target organization '{self.config.target_org}' not found.\n"
-                        f"Please verify the organization name is correct."
-                    )
-                elif "401" in error_msg or "Unauthorized" in error_msg:
-                    raise RuntimeError(
-                        f"Target PAT does not have access to organization '{self.config.target_org}'.\n"
-                        f"Please verify your target PAT has the necessary permissions."
-                    )
-                else:
-                    raise RuntimeError(f"Failed to access target organization: {target_error}")
+                raise self._access_error(target_error, "target", f"organization '{self.config.target_org}'")
 
             self.log.success("✓ Both PATs have necessary organization permissions")
 
@@ -372,7 +1856,50 @@ class Migrator:
             raise
         except Exception as e:
             self.log.error(f"Unexpected error during permission validation: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to validate organization permissions: {e}")
+            raise api_error(e, "Failed to validate organization permissions")
+
+    def _migrate_org_levels(self) -> None:
+        """Migrate organization variables, then organization secrets, as selected."""
+        if self.config.migrate_variables and not self.planning:
+            self.log.info("Migrating organization variables...")
+            self._migrate_org_variables()
+        if self.config.migrate_secrets:
+            self._migrate_org_secrets_workflow()
+
+    def _migrate_org_variables(self) -> None:
+        """Copy organization variables to the target organization with their visibility.
+        
+        Selected repositories are matched by name in the target organization;
+        those missing there are left out of the variable's scope.
+        """
+        source, target = self.config.source_org, self.config.target_org
+        variables = self.source_api.list_org_variable_records(source)
+        if not variables:
+            self.log.info("No organization variables to migrate")
+            self.events.emit("decision", "No organization variables to migrate")
+            return
+        existing = {record.name for record in self.target_api.list_org_variable_records(target)}
+        if not self._overwrite_variables([record.label for record in variables if record.name in existing]):
+            variables = [record for record in variables if record.name not in existing]
+
+        labels = [record.label for record in variables]
+        self.log.info(f"Organization variables to migrate ({len(labels)} total):")
+        for label in labels:
+            self.log.info(f"  - {label}")
+        self.events.emit("decision", f"Migrating {len(labels)} organization variable(s)", variables=labels)
+        for record in variables:
+            if record.visibility == "selected":
+                scope = self.source_api.get_org_variable_scope(source, record.name)
+            else:
+                scope = OrgSecretScope(record.visibility or "all")
+            missing = self.target_api.set_org_variable(target, record.name, record.value, scope, record.name in existing)
+            self.target_writes += 1
+            if missing:
+                self.log.warn(f"Organization variable '{record.name}': selected repositories missing on target: {', '.join(missing)}")
+                self.events.emit("warning", f"Organization variable '{record.name}' scoped without {len(missing)} missing repositories", variable=record.name, repositories=missing)
+            if record.name not in existing:
+                self._record_resource("variable", level="org", name=record.name)
+        self.log.success(f"Migrated {len(variables)} organization variable(s)")
 
     def _migrate_org_secrets_workflow(self) -> None:
         """Migrate organization secrets using GitHub Actions workflow.
@@ -389,83 +1916,595 @@ class Migrator:
             
             # Get list of org secrets from source
             org_secret_names = self.source_api.list_org_secrets(self.config.source_org)
+            self._emit_discovered("organization", org_secret_names)
             
             # Filter out system secrets
-            secrets_to_migrate = [
-                name for name in org_secret_names
-                if name not in ("SECRETS_MIGRATOR_PAT", "SECRETS_MIGRATOR_TARGET_PAT", "SECRETS_MIGRATOR_SOURCE_PAT")
-            ]
+            secrets_to_migrate = managed_secrets(org_secret_names)
+            
+            for name in org_secret_names:
+                if name not in secrets_to_migrate:
+                    self.events.emit("skipped", f"Organization secret '{name}' skipped: reserved for the migrator", secret=name)
+            
+            secrets_to_migrate = self._apply_policy("Organization", secrets_to_migrate, "organization")
+            self._validate_target_names("organization", secrets_to_migrate)
+            self._check_naming({"organization": secrets_to_migrate})
+            
+            self._open_ledger()
+            self._source_stamps = {
+                record.key: record.updated_at.isoformat()
+                for record in self.source_api.list_org_secret_records(self.config.source_org) if record.updated_at
+            }
+            
+            if self.config.prune:
+                self.log.info("Pruning target organization secrets not present on source...")
+                self._prune_target_org_secrets(org_secret_names)
             
             if not secrets_to_migrate:
-                self.log.info("No organization secrets to migrate (found only system secrets)")
+                self.log.info("No organization secrets to migrate (found only system or policy-blocked secrets)")
+                self._nothing_to_migrate("source organization holds only system or policy-blocked secrets")
                 return
             
+            if not self.config.remigrate and self.ledger.entries and secrets_to_migrate:
+                existing = self.target_api.list_org_secrets(self.config.target_org)
+                done = [
+                    name for name in secrets_to_migrate
+                    if self._is_migrated(("organization", self.config.target_org, self.namer.transform(name)), ("org", "", name), existing)
+                ]
+                self._report_migrated([f"org:{self.namer.transform(name)}" for name in done])
+                self._note_plan("skip", "organization", done, reason="migrated by an earlier run")
+                secrets_to_migrate = [name for name in secrets_to_migrate if name not in done]
+                if not secrets_to_migrate:
+                    self.log.info("No organization secrets to migrate (all migrated by an earlier run)")
+                    self._nothing_to_migrate("every organization secret was migrated by an earlier run")
+                    return
+            
+            if self._sync_since is not None:
+                existing = self.target_api.list_org_secrets(self.config.target_org)
+                unchanged = [
+                    name for name in secrets_to_migrate
+                    if self._is_unchanged(self.namer.transform(name), ("org", "", name), existing)
+                ]
+                self._report_unchanged([f"org:{self.namer.transform(name)}" for name in unchanged])
+                self._note_plan("skip", "organization", unchanged, reason="unchanged since the last run")
+                secrets_to_migrate = [name for name in secrets_to_migrate if name not in unchanged]
+                if not secrets_to_migrate:
+                    self.log.info("No organization secrets to migrate (none changed since the last run)")
+                    self._nothing_to_migrate("no organization secret changed since the last run")
+                    return
+            
+            if self.config.conflict_policy != "overwrite":
+                existing = self.target_api.list_org_secrets(self.config.target_org)
+                conflicts = set(find_conflicts([self.namer.transform(name) for name in secrets_to_migrate], existing))
+                clashing = [name for name in secrets_to_migrate if self.namer.transform(name) in conflicts]
+                if clashing and self.config.conflict_policy == "fail":
+                    self.events.emit("error", f"{len(clashing)} organization secret(s) already exist on target (conflict policy: fail)", secrets=clashing)
+                    raise RuntimeError(f"Organization secrets already exist on target (conflict policy 'fail'): {', '.join(clashing)}")
+                for name in clashing:
+                    self.log.info(f"Skipping organization secret '{name}': already exists on target (conflict policy: skip)")
+                    self.events.emit("conflict", f"Organization secret '{name}' already exists on target; left untouched", secret=name)
+                self._note_plan("skip", "organization", clashing, reason="already exists on target")
+                secrets_to_migrate = [name for name in secrets_to_migrate if name not in clashing]
+                if not secrets_to_migrate:
+                    self.log.info("No organization secrets to migrate (all already exist on target)")
+                    self._nothing_to_migrate("every organization secret already exists on target")
+                    return
+            
+            if self.config.quota_check != "off":
+                self._check_quotas([QuotaPlan(
+                    f"Organization {self.config.target_org}", ORG_SECRET_LIMIT,
+                    self.target_api.list_org_secrets(self.config.target_org),
+                    [self.namer.transform(name) for name in secrets_to_migrate]
+                )])
+            
             self.log.info(f"Organization secrets to migrate ({len(secrets_to_migrate)} total):")
             for name in secrets_to_migrate:
                 self.log.info(f"  - {name}")
+            self.events.emit(
+                "decision", f"Migrating {len(secrets_to_migrate)} organization secret(s)",
+                secrets=secrets_to_migrate, level="org"
+            )
+            if self.planning:
+                self._note_plan(
+                    "create", "organization", secrets_to_migrate,
+                    existing=self.target_api.list_org_secrets(self.config.target_org),
+                    scopes=self._org_secret_scopes(secrets_to_migrate)
+                )
+                return
             
-            branch_name = "migrate-org-secrets"
+            branch_name = self.config.branch_name or "migrate-org-secrets"
+            if self.config.branch_name and not self.config.org_to_org:
+                # The repository secrets workflow of the same run already uses --branch-name
+                branch_name = f"{self.config.branch_name}-org"
+            branch_name = self._check_branch_rules(
+                source_repo, branch_name, ".github/workflows/migrate-org-secrets.yml"
+            )
+            
+            if self.config.placeholder_mode != "none":
+                self.log.info("Creating placeholder organization secrets on target...")
+                self._create_org_placeholders(secrets_to_migrate)
+            
+            self._check_approval_environment(source_repo, branch_name)
             
             # Step 1: Create temporary secrets in source repo
             self.log.info("Creating temporary secrets in source repository...")
-            self.source_api.create_repo_secret(
-                self.config.source_org, source_repo,
-                "SECRETS_MIGRATOR_TARGET_PAT", self.config.target_pat
-            )
-            self.source_api.create_repo_secret(
-                self.config.source_org, source_repo,
-                "SECRETS_MIGRATOR_SOURCE_PAT", self.config.source_pat
-            )
+            if self.config.target_app_id:
+                self.log.info(f"Target token will be minted in the workflow from GitHub App {self.config.target_app_id}")
+            else:
+                self._create_temporary_secret(source_repo, "SECRETS_MIGRATOR_TARGET_PAT", self.config.target_pat)
+            self._create_temporary_secret(source_repo, "SECRETS_MIGRATOR_SOURCE_PAT", self.config.source_pat)
             
             # Step 2: Generate workflow with org secrets
             self.log.info("Generating workflow for organization secret migration...")
+            source_repo_obj = self.source_api.client.get_repo(f"{self.config.source_org}/{source_repo}")
             workflow_content = generate_workflow(
                 self.config.source_org, source_repo,
                 self.config.target_org, target_repo,
                 branch_name,
                 env_secrets=None,
-                org_secrets=secrets_to_migrate
+                org_secrets=secrets_to_migrate,
+                gh_cli_version=self.config.gh_cli_version,
+                gh_cli_digests=self._gh_cli_digests(),
+                name_map=self._name_map(secrets_to_migrate),
+                org_secret_scopes=self._org_secret_scopes(secrets_to_migrate),
+                value_rewrites=self._value_rewrites(secrets_to_migrate),
+                destinations=self.destinations,
+                policy=self._workflow_policy(),
+                runner_labels=self.config.runner_labels,
+                runner_os=self.config.runner_os,
+                engine=self.config.workflow_engine,
+                action_ref=self.config.action_ref,
+                target_app_id=self.config.target_app_id,
+                approval_environment=self.config.approval_environment,
+                expected_actor=self._workflow_actor(),
+                timeout_minutes=self.config.workflow_timeout,
+                delivery=self.config.delivery,
+                base_branch=source_repo_obj.default_branch,
+                workflow_path=".github/workflows/migrate-org-secrets.yml",
+                source_host=self.config.source_host,
+                target_host=self.config.target_host
             )
             
             # Step 3: Create migration branch and push workflow
             self.log.info(f"Creating migration branch '{branch_name}'...")
-            source_repo_obj = self.source_api.client.get_repo(f"{self.config.source_org}/{source_repo}")
             
             # Get default branch
             default_branch = source_repo_obj.default_branch
-            base_ref = source_repo_obj.get_git_ref(f"heads/{default_branch}")
+            base_sha = self._base_commit(source_repo, default_branch)
             
             # Create new branch
-            source_repo_obj.create_git_ref(f"refs/heads/{branch_name}", base_ref.object.sha)
+            self.throttle.wait(f"the push to {self.config.source_org}/{source_repo}")
+            self.source_api.create_branch(self.config.source_org, source_repo, branch_name, base_sha)
+            self._created("branch", source_repo, branch_name)
             self.log.debug(f"✓ Created migration branch '{branch_name}'")
             
             # Create workflow file
             workflow_path = ".github/workflows/migrate-org-secrets.yml"
             self.log.debug(f"Creating workflow file at {workflow_path}...")
             
-            source_repo_obj.create_file(
-                workflow_path,
-                "chore: add organization secrets migration workflow",
-                workflow_content,
-                branch=branch_name
+            self._lint_workflow(workflow_path, workflow_content)
+            self.source_api.create_file(
+                self.config.source_org, source_repo, branch_name, workflow_path, workflow_content,
+                message=self.config.commit_message or "chore: add organization secrets migration workflow",
+                committer=self._committer()
             )
             self.log.info(f"✓ Workflow pushed to branch '{branch_name}'")
+            self.events.emit("workflow_pushed", f"Pushed {workflow_path} to branch '{branch_name}'", branch=branch_name, path=workflow_path)
+            self._record_resource("workflow_file", repo=source_repo, branch=branch_name, path=workflow_path)
+
+            if self.config.delivery == "pull-request":
+                self._open_workflow_pull_request(source_repo, branch_name, default_branch, workflow_path)
+                self._pending_cleanup.clear()
+                return
+            # The running workflow deletes the temporary secrets itself
+            self._pending_cleanup.clear()
             
             # Step 4: Workflow is now running asynchronously - provide URL for monitoring
             self.log.success("✓ Workflow triggered successfully!")
             
-            workflow_url = f"https://github.com/{self.config.source_org}/{self.config.source_repo}/actions/workflows/migrate-org-secrets.yml"
-            self.log.success("✓ Organization secret migration started! Check the link below to monitor progress.")
-            self.log.info(f"Monitor workflow progress here: {workflow_url}")
+            workflow_url = f"https://{self.config.source_host}/{self.config.source_org}/{self.config.source_repo}/actions/workflows/migrate-org-secrets.yml"
+            self.log.summary(
+                "Organization secret migration started!\n"
+                f"Monitor workflow progress here: {workflow_url}"
+            )
+            self.events.emit("link", "Organization secrets migration workflow", url=workflow_url)
+            
+            run_id = self._await_workflow_run(branch_name, "migrate-org-secrets.yml")
+            stamps = {
+                ("organization", self.config.target_org, self.namer.transform(name)): self._source_stamps.get(("org", "", name), "")
+                for name in secrets_to_migrate
+            }
+            self._track_run(run_id, stamps)
+            if self.config.wait:
+                if not run_id:
+                    raise RuntimeError(f"Could not find the migration workflow run to wait for (see {workflow_url})")
+                self._confirm_run(run_id, list(stamps))
             
         except RuntimeError:
             raise
         except Exception as e:
             self.log.error(f"Error during organization secret migration: {type(e).__name__}: {e}")
-            raise RuntimeError(f"Failed to migrate organization secrets: {e}")
+            raise api_error(e, "Failed to migrate organization secrets")
+
+    def _branch_blockers(self, repo: str, branch_name: str, workflow_path: str) -> List[BranchBlocker]:
+        """Return the source rulesets/branch protections that would block branch_name."""
+        blockers = ruleset_blockers(
+            self.source_api.get_branch_rules(self.config.source_org, repo, branch_name), workflow_path
+        )
+        return blockers + protection_blockers(
+            self.source_api.list_branch_protection_rules(self.config.source_org, repo), branch_name
+        )
+
+    def _check_branch_rules(self, repo: str, branch_name: str, workflow_path: str) -> str:
+        """Check that the migration branch can be created and the workflow pushed to it.
+        
+        Depending on --branch-check, a blocked branch fails the run before
+        anything is written, is reported as a warning, or is replaced by the
+        first candidate name (see candidate_branches) that no rule blocks; auto
+        fails when every candidate is blocked. Rules the token cannot read are
+        not checked.
+        
+        Returns:
+            The branch name to use
+        """
+        if self.config.branch_check == "off":
+            return branch_name
+        candidates = [branch_name]
+        if self.config.branch_check == "auto":
+            candidates = candidate_branches(branch_name)
+        try:
+            blocked = {}
+            for candidate in candidates:
+                blockers = self._branch_blockers(repo, candidate, workflow_path)
+                if not blockers:
+                    if candidate != branch_name:
+                        self.log.info(f"Using branch '{candidate}': '{branch_name}' is blocked by the source repository's rules")
+                        self.events.emit(
+                            "decision", f"Migration branch '{branch_name}' is blocked; using '{candidate}'",
+                            branch=candidate, blocked=list(blocked)
+                        )
+                    else:
+                        self.log.debug(f"No rulesets or branch protections block '{branch_name}'")
+                    return candidate
+                blocked[candidate] = blockers
+        except RuntimeError as e:
+            self.log.debug(f"Skipping branch rules preflight: {e}")
+            return branch_name
+
+        for line in remediation(branch_name, blocked[branch_name]):
+            self.log.warn(line)
+        failing = self.config.branch_check != "warn"
+        self.events.emit(
+            "error" if failing else "warning",
+            f"Migration branch '{branch_name}' would be rejected by the source repository's rules",
+            branch=branch_name, blockers=[str(blocker) for blocker in blocked[branch_name]]
+        )
+        if failing:
+            raise RuntimeError(
+                f"Branch preflight failed: '{branch_name}' is blocked in {self.config.source_org}/{repo}"
+                + (" and no fallback branch name is allowed" if len(candidates) > 1 else "")
+                + " (use --branch-check warn to attempt the migration anyway)"
+            )
+        return branch_name
+
+    def _base_commit(self, repo: str, default_branch: str) -> str:
+        """Return the commit the migration branch starts from: the head of the default branch or,
+        when the repository is empty (e.g. just created by an importer), a first commit made on
+        it to host the workflow."""
+        try:
+            return self.source_api.get_commit_sha(self.config.source_org, repo, default_branch)
+        except EmptyRepository:
+            pass
+        self.log.warn(
+            f"{self.config.source_org}/{repo} has no commits: creating an initial commit "
+            f"(an empty .gitkeep) on '{default_branch}' to host the migration workflow"
+        )
+        sha = self.source_api.initialize_branch(
+            self.config.source_org, repo, default_branch, committer=self._committer()
+        )
+        self.events.emit(
+            "decision", f"Source repository was empty: created an initial commit on '{default_branch}'",
+            branch=default_branch
+        )
+        self._record_resource("initial_commit", repo=repo, branch=default_branch, sha=sha)
+        return sha
+
+    def _committer(self) -> Optional[Tuple[str, str]]:
+        """Return the configured (name, email) commit identity, if any."""
+        if self.config.committer_name and self.config.committer_email:
+            return (self.config.committer_name, self.config.committer_email)
+        return None
+
+    def _open_workflow_pull_request(self, repo: str, branch_name: str, base_branch: str, workflow_path: str) -> None:
+        """Propose the migration workflow in a pull request instead of letting the push trigger it."""
+        body = (
+            "This pull request adds the secrets migration workflow generated by gh-secrets-migrator.\n\n"
+            f"Merging it into `{base_branch}` runs the workflow once, copying secrets to "
+            f"`{self.config.target_org}`. The workflow deletes its temporary "
+            "`SECRETS_MIGRATOR_TARGET_PAT`/`SECRETS_MIGRATOR_SOURCE_PAT` secrets when it finishes; "
+            f"remove `{workflow_path}` in a follow-up pull request afterwards.\n\n"
+            "If this pull request is closed without merging, delete those two temporary secrets "
+            "from this repository manually."
+        )
+        url = self.source_api.create_pull_request(
+            self.config.source_org, repo, branch_name, base_branch,
+            "Add secrets migration workflow", body
+        )
+        self.log.success("✓ Migration workflow proposed in a pull request")
+        self.log.summary(
+            "Secrets migration awaits review!\n"
+            f"The workflow runs once this pull request is merged: {url}"
+        )
+        self.events.emit("link", "Migration workflow pull request", url=url)
+        self._record_resource("pull_request", repo=repo, url=url)
 
     def run(self) -> None:
-        """Execute the migration process."""
+        """Execute the migration process, recording start and outcome in the event log."""
+        if self.config.org_to_org:
+            description = f"Organization-to-organization migration: {self.config.source_org} → {self.config.target_org}"
+        else:
+            description = (
+                f"Repository-to-repository migration: {self.config.source_org}/{self.config.source_repo} → "
+                + ", ".join(f"{self.config.target_org}/{repo}" for repo in self.config.target_repos)
+            )
+        run_start = len(self.events.events)
+        self.events.emit(
+            "run_started", description,
+            source_org=self.config.source_org, source_repo=self.config.source_repo,
+            target_org=self.config.target_org, target_repo=self.config.target_repo,
+            extra_target_repos=self.config.extra_target_repos, org_to_org=self.config.org_to_org
+        )
+        self._begin_record()
+        # The post-hook runs whenever the pre-hook did, whatever the outcome
+        hooked = False
+        try:
+            self._check_sandbox()
+            if self._audit_log_changed():
+                hooked = True
+                self._run_hook("pre")
+                self._check_archived()
+                self._run_migration()
+        except KeyboardInterrupt as e:
+            self.events.emit("run_failed", "Migration cancelled", error_class=type(e).__name__)
+            self._cleanup_pending("Cancelled")
+            self._finish_record("Migration cancelled")
+            if hooked:
+                self._run_hook("post", "cancelled", "Migration cancelled")
+            raise
+        except Exception as e:
+            self.events.emit("run_failed", f"Migration failed: {e}", error_class=type(e).__name__)
+            self._cleanup_pending("Migration failed")
+            self._finish_record(self.events.redact(str(e)))
+            if hooked:
+                self._run_hook("post", "failed", self.events.redact(str(e)))
+            raise
+        finally:
+            self._rearchive()
+        if self.config.tracking_issue:
+            targets = [self.config.target_repo] if self.config.org_to_org else self.config.target_repos
+            for target_repo in targets:
+                with self._targeting(target_repo):
+                    self._open_tracking_issue(self.events.events[run_start:])
+        self._finish_record()
+        if hooked:
+            self._run_hook("post", "succeeded")
+        self.events.emit("run_completed", "Migration run completed")
+
+    def _run_hook(self, phase: str, result: str = "", error: str = "") -> None:
+        """Run the pre- or post-migration hook, if one is configured.
+
+        A failing pre-hook stops the migration before anything is written; a
+        failing post-hook is reported as a warning, since the secrets have
+        already moved (or failed to) by then.
+
+        Raises:
+            RuntimeError: If the pre-hook fails
+        """
+        command = self.config.pre_hook if phase == "pre" else self.config.post_hook
+        if not command:
+            return
+        targets = [] if self.config.org_to_org else self.config.target_repos
+        variables = hook_environment(
+            phase, self.config.source_org, self.config.source_repo, self.config.target_org,
+            targets, self.config.org_to_org, result, error
+        )
+        label = f"{phase}-migration hook"
+        self.log.info(f"Running {label}: {command}")
+        try:
+            for line in self.hooks(command, variables):
+                self.log.info(f"  [{phase}-hook] {self.events.redact(line)}")
+        except ValueError as e:
+            if phase == "pre":
+                raise RuntimeError(f"The {label} failed, so nothing was migrated: {e}")
+            self.log.warn(f"The {label} failed: {e}")
+            self.events.emit("warning", f"The {label} failed: {e}", hook=phase)
+            return
+        self.events.emit("hook_run", f"The {label} succeeded", hook=phase, result=result)
+
+    def plan(self) -> List[PlannedChange]:
+        """Work out the secret changes a run would make, reading both sides without writing.
+        
+        The same checks as a run apply (permissions, policies, naming, conflicts,
+        quotas), so a plan fails where the run would. Environments, variables and
+        settings are not planned; they are applied as configured.
+        
+        Returns:
+            The secrets the run would create, replace, delete (--prune) or skip
+        """
+        self.planning = True
+        self.planned = []
+        try:
+            self._check_archived()
+            self._run_migration()
+        finally:
+            self.planning = False
+        return self.planned
+
+    def _check_sandbox(self) -> None:
+        """With --sandbox, fail before any call unless the run stays within the designated sandboxes."""
+        if not self.config.sandbox:
+            return
+        locations = sandbox_locations(
+            self.config.source_org, self.config.source_repo, self.config.target_org,
+            self.config.target_repos, self.config.org_to_org
+        )
+        try:
+            check_sandbox(locations, parse_sandbox(os.environ.get(SANDBOX_ENV, "")))
+        except ValueError as e:
+            raise RuntimeError(str(e))
+        self.log.info(f"Sandbox run: {', '.join(locations)} designated in {SANDBOX_ENV}")
+
+    def _check_archived(self) -> None:
+        """Fail upfront on archived (read-only) source or target repositories, or unarchive
+        them for the run with --unarchive."""
+        repos = [(self.source_api, self.config.source_org, self.config.source_repo)]
+        if not self.config.org_to_org:
+            repos += [(self.target_api, self.config.target_org, repo) for repo in self.config.target_repos]
+        archived = []
+        for api, org, repo in repos:
+            try:
+                if repo and api.is_archived(org, repo):
+                    archived.append((api, org, repo))
+            except NotFound:
+                continue  # reported by the permission checks (or created by --create-target-repo)
+        if not archived:
+            return
+        names = ", ".join(f"{org}/{repo}" for _, org, repo in archived)
+        if not self.config.unarchive:
+            raise RuntimeError(
+                f"Archived repositories are read-only, so the migration cannot write to {names}: "
+                "unarchive them first, or pass --unarchive (with --wait) to unarchive them for the "
+                "migration and archive them again once the workflow run has finished"
+            )
+        if self.planning:
+            self.events.emit("decision", f"{names} will be unarchived for the migration")
+            return
+        for api, org, repo in archived:
+            self.log.warn(f"Unarchiving {org}/{repo} for the migration; it is archived again when the run ends")
+            api.set_archived(org, repo, False)
+            self._unarchived.append((api, org, repo))
+            self.events.emit("decision", f"Unarchived {org}/{repo} for the migration", repo=f"{org}/{repo}")
+            self._record_resource("unarchived_repository", repo=f"{org}/{repo}")
+
+    def _rearchive(self) -> None:
+        """Archive again the repositories _check_archived unarchived."""
+        while self._unarchived:
+            api, org, repo = self._unarchived.pop()
+            try:
+                api.set_archived(org, repo, True)
+                self.log.info(f"Archived {org}/{repo} again")
+                self.events.emit("decision", f"Archived {org}/{repo} again", repo=f"{org}/{repo}")
+            except RuntimeError as e:
+                self.log.error(f"Could not archive {org}/{repo} again; archive it manually: {e}")
+                self.events.emit("warning", f"{org}/{repo} left unarchived", repo=f"{org}/{repo}")
+
+    def _lint_workflow(self, workflow_path: str, content: str) -> None:
+        """Fail fast on a generated workflow that would not parse or run.
+        
+        Runs the built-in checks (YAML, job structure, expressions, `bash -n`
+        over run scripts) plus actionlint when it is installed.
+        """
+        if not self.config.workflow_lint:
+            self.log.debug("Workflow lint disabled (--no-workflow-lint)")
+            return
+        problems = lint_workflow(content)
+        findings = run_actionlint(content)
+        if findings is None:
+            self.log.debug("actionlint not installed; using built-in workflow checks only")
+        problems += [f"actionlint: {finding}" for finding in findings or []]
+        if not problems:
+            self.log.debug(f"Workflow lint passed for {workflow_path}")
+            return
+        for problem in problems:
+            self.log.error(f"  {problem}")
+        self.events.emit("error", f"Generated workflow {workflow_path} failed lint", problems=problems)
+        self._cleanup_pending("Workflow lint failed")
+        raise RuntimeError(
+            f"Generated workflow {workflow_path} failed lint with {len(problems)} problem(s); nothing was pushed "
+            "(please report this with the secret names involved, or use --no-workflow-lint to push anyway)"
+        )
+
+    def _snapshot_target(self, organization: bool = False) -> None:
+        """Save the target's inventory to the state directory before anything is written.
+        
+        Gives rollback and post-incident analysis a "before" picture even when
+        no dry run was made. A snapshot that cannot be taken stops the run.
+        
+        Args:
+            organization: Snapshot the target organization's secrets rather than
+                          the current target repository's
+        """
+        if self.planning:
+            return
+        if not self.config.snapshot:
+            self.log.debug("Target snapshot disabled (--no-snapshot)")
+            return
+        org = self.config.target_org
+        try:
+            if organization:
+                target = org
+                records = self.target_api.list_org_secret_records(org)
+                scopes = {
+                    record.name: self.target_api.get_org_secret_scope(org, record.name)
+                    for record in records if record.visibility == "selected"
+                }
+                snapshot = build_snapshot(target, records, [], scopes)
+            else:
+                target = f"{org}/{self.config.target_repo}"
+                records = self.target_api.list_repo_secret_records(org, self.config.target_repo)
+                records += self.target_api.list_environment_secret_records(org, self.config.target_repo)
+                environments = self.target_api.list_environments(org, self.config.target_repo)
+                snapshot = build_snapshot(target, records, environments)
+            path = write_snapshot(self.config.state_dir, snapshot, self.state_cipher)
+        except (RuntimeError, OSError, ValueError) as e:
+            self.events.emit("error", f"Could not snapshot target inventory: {e}")
+            raise RuntimeError(f"Could not snapshot the target before writing: {e} (use --no-snapshot to skip)")
+        self.log.info(f"Saved target inventory snapshot ({len(snapshot['secrets'])} secret(s)) to {path}")
+        self.events.emit("snapshot_saved", f"Snapshot of {target} saved before the first write", target=target, path=path, secrets=len(snapshot["secrets"]))
+
+    def _cleanup_pending(self, reason: str) -> None:
+        """Remove the migration branch and temporary secrets a cancelled or failed run left on the source.
+        
+        Only resources created before the workflow was pushed are tracked; once
+        it runs, the workflow deletes its temporary secrets itself. Cleanup is
+        best effort: pressing Ctrl-C again aborts it.
+        """
+        if not self._pending_cleanup:
+            return
+        self.log.warn(f"{reason}: removing the partially created migration branch and temporary secrets...")
+        for kind, repo, name in reversed(self._pending_cleanup):
+            try:
+                if kind == "branch":
+                    self.source_api.delete_branch(self.config.source_org, repo, name)
+                else:
+                    self._delete_temporary_secret(repo, name)
+                self.log.info(f"Removed {kind} {name} from {self.config.source_org}/{repo}")
+                self.events.emit("deleted", f"{reason}: removed {kind} '{name}' from {self.config.source_org}/{repo}", resource_kind=kind, name=name)
+            except RuntimeError as e:
+                self.log.warn(f"Could not remove {kind} {name}; delete it manually: {e}")
+                self.events.emit("warning", f"{reason}: {kind} '{name}' left in {self.config.source_org}/{repo}", resource_kind=kind, name=name)
+        self._pending_cleanup.clear()
+
+    def _open_tracking_issue(self, run_events: List[MigrationEvent]) -> None:
+        """Open a follow-up checklist issue on the target repository (best effort)."""
+        if self.config.org_to_org:
+            source = self.config.source_org
+        else:
+            source = f"{self.config.source_org}/{self.config.source_repo}"
+        target_repo = self.config.target_repo or self.config.source_repo
+        title, body = build_tracking_issue(
+            run_events, source, f"{self.config.target_org}/{target_repo}"
+        )
+        try:
+            url = self.target_api.create_issue(self.config.target_org, target_repo, title, body)
+        except RuntimeError as e:
+            self.log.warn(f"Could not open tracking issue: {e}")
+            self.events.emit("warning", f"Tracking issue not opened: {e}")
+            return
+        self.log.success(f"Opened tracking issue: {url}")
+        self.events.emit("link", "Tracking issue", url=url)
+
+    def _run_migration(self) -> None:
+        """Execute the migration steps."""
         self.log.info("Migrating Secrets...")
         
         # Handle org-to-org migration
@@ -476,77 +2515,194 @@ class Migrator:
             
             # Validate PAT permissions for org access
             self.log.info("Validating PAT permissions...")
+            self._check_capabilities()
             self._validate_org_permissions()
             
             # Check if rate limit is critically low before proceeding
             self._wait_for_rate_limit_reset()
             
+            self._snapshot_target(organization=True)
+            
+            if self.config.migrate_settings:
+                self.log.info("Repository settings are not migrated in org-to-org mode")
+                self.events.emit("decision", "Repository settings migration skipped: not applicable to org-to-org mode")
+            
             # Attempt org-only migration
-            self._migrate_org_secrets_workflow()
+            self._migrate_org_levels()
             return
         
         # Handle repo-to-repo migration (original flow)
+        targets = self.config.target_repos
         self.log.info(f"SOURCE ORG: {self.config.source_org}")
         self.log.info(f"SOURCE REPO: {self.config.source_repo}")
         self.log.info(f"TARGET ORG: {self.config.target_org}")
-        self.log.info(f"TARGET REPO: {self.config.target_repo}")
-        self.log.info("Mode: Repository-to-Repository")
+        self.log.info(f"TARGET REPO{'S' if len(targets) > 1 else ''}: {', '.join(targets)}")
+        self.log.info("Mode: Repository-to-Repository" + (f" (fan-out to {len(targets)} targets)" if len(targets) > 1 else ""))
 
-        # Validate PAT permissions
-        self.log.info("Validating PAT permissions...")
-        self._validate_permissions()
-        
-        # Check if rate limit is critically low before proceeding
-        self._wait_for_rate_limit_reset()
+        levels = self.config.selected_levels
+        variables = []
+        if self.config.migrate_variables and ("repo" in levels or "env" in levels):
+            self.log.debug("Fetching variables from source repository...")
+            variables = [
+                record for record in self.source_api.list_repo_variable_records(
+                    self.config.source_org, self.config.source_repo, include_envs="env" in levels
+                )
+                if record.level in levels
+            ]
 
-        # Step 1: Recreate environments (if not skipped)
-        if not self.config.skip_envs:
-            self.log.info("Recreating environments...")
-            self._recreate_environments()
-            self._check_rate_limits("after_env_recreation")
-        else:
-            self.log.info("Skipping environment recreation (--skip-envs flag set)")
+        for target_repo in targets:
+            with self._targeting(target_repo):
+                # Validate PAT permissions
+                self.log.info("Validating PAT permissions...")
+                self._check_capabilities()
+                self._validate_permissions()
+
+                # Check if rate limit is critically low before proceeding
+                self._wait_for_rate_limit_reset()
 
-        branch_name = "migrate-secrets"
+                self._snapshot_target()
+                if self.planning:
+                    continue  # environments, settings and variables are not planned
+
+                # Step 1: Recreate environments (if not skipped)
+                if "env" not in levels:
+                    self.log.info("Skipping environments (not selected by --levels)")
+                    self.events.emit("decision", "Environment recreation skipped (--levels)")
+                elif not self.config.skip_envs:
+                    self.log.info("Recreating environments...")
+                    self._recreate_environments()
+                    self._check_rate_limits("after_env_recreation")
+                else:
+                    self.log.info("Skipping environment recreation (--skip-envs flag set)")
+                    self.events.emit("decision", "Environment recreation skipped (--skip-envs)")
+
+                if self.config.target_env and self.config.migrate_secrets and "repo" in levels:
+                    self._ensure_target_env()
+
+                if self.config.migrate_settings:
+                    self.log.info("Migrating repository settings...")
+                    self._migrate_settings()
+
+                if self.config.migrate_variables and ("repo" in levels or "env" in levels):
+                    self.log.info("Migrating variables...")
+                    self._migrate_variables(variables)
+                    self._check_rate_limits("after_variables")
+
+        if self.config.migrate_secrets and ("repo" in levels or "env" in levels):
+            self._migrate_repo_secrets_workflow(targets)
+
+        if "org" in levels:
+            self.log.info(f"Migrating organization level: {self.config.source_org} → {self.config.target_org}")
+            self._validate_org_permissions()
+            self._snapshot_target(organization=True)
+            self._migrate_org_levels()
+
+    def _migrate_repo_secrets_workflow(self, targets: List[str]) -> None:
+        """Migrate the selected repository and environment secrets to every target with a workflow run."""
+        levels = self.config.selected_levels
+        branch_name = self.config.branch_name or "migrate-secrets"
 
         # Step 2: List secrets from source repository
-        self.log.debug("Fetching list of secrets from source repository...")
-        secret_names = self.source_api.list_repo_secrets(
-            self.config.source_org, self.config.source_repo
-        )
+        secret_names = []
+        if "repo" in levels:
+            self.log.debug("Fetching list of secrets from source repository...")
+            secret_names = self.source_api.list_repo_secrets(
+                self.config.source_org, self.config.source_repo
+            )
+        else:
+            self.events.emit("decision", "Repository secrets skipped (--levels)")
+
+        self._emit_discovered("repository", secret_names)
 
         # Filter out system secrets
-        secrets_to_migrate = [
-            name for name in secret_names
-            if name not in ("github_token", "SECRETS_MIGRATOR_PAT", "SECRETS_MIGRATOR_TARGET_PAT", "SECRETS_MIGRATOR_SOURCE_PAT")
-        ]
+        secrets_to_migrate = managed_secrets(secret_names)
+
+        for name in secret_names:
+            if name not in secrets_to_migrate:
+                self.events.emit("skipped", f"Repository secret '{name}' skipped: reserved for the migrator", secret=name)
+
+        env_secrets_info = {}
+        if "env" in levels:
+            self.log.debug("Fetching environment secrets from source repository...")
+            # Pruning deletes what the source lacks, so a failed listing must not read as empty
+            env_secrets_info = self.source_api.list_all_environments_with_secrets(
+                self.config.source_org, self.config.source_repo, strict=self.config.prune
+            )
+            for env_name, env_secret_names in env_secrets_info.items():
+                self._emit_discovered("environment", env_secret_names, environment=env_name)
+        else:
+            self.events.emit("decision", "Environment secrets skipped (--levels)")
+
+        self._open_ledger()
+        records = []
+        if "repo" in levels:
+            records += self.source_api.list_repo_secret_records(self.config.source_org, self.config.source_repo)
+        if "env" in levels:
+            records += self.source_api.list_environment_secret_records(self.config.source_org, self.config.source_repo)
+        self._source_stamps = {record.key: record.updated_at.isoformat() for record in records if record.updated_at}
+
+        if self.config.prune:
+            for target_repo in targets:
+                with self._targeting(target_repo):
+                    self.log.info("Pruning target secrets not present on source...")
+                    self._prune_target_secrets(secret_names, env_secrets_info, repo_level="repo" in levels)
+
+        if self.config.only_used:
+            secrets_to_migrate, env_secrets_info = self._keep_used_secrets(secrets_to_migrate, env_secrets_info)
 
-        if not secrets_to_migrate:
-            self.log.info("No secrets to migrate (found only system secrets)")
+        secrets_to_migrate = self._apply_policy("Repository", secrets_to_migrate)
+        env_secrets_info = {
+            env_name: self._apply_policy(f"Environment '{env_name}'", env_secret_names, "environment", env_name)
+            for env_name, env_secret_names in env_secrets_info.items()
+        }
+
+        self._validate_target_names("repository", secrets_to_migrate)
+        for env_name, env_secret_names in env_secrets_info.items():
+            self._validate_target_names(f"environment '{env_name}'", env_secret_names)
+        self._check_naming({
+            "repository": secrets_to_migrate,
+            **{f"environment '{env_name}'": names for env_name, names in env_secrets_info.items()},
+        })
+
+        if not secrets_to_migrate and not any(env_secrets_info.values()):
+            self.log.info("No secrets to migrate (found only system or policy-blocked secrets)")
+            self._nothing_to_migrate("source repository holds only system or policy-blocked secrets")
             return
 
-        self.log.info(f"Secrets to migrate ({len(secrets_to_migrate)} total):")
-        for name in secrets_to_migrate:
-            self.log.info(f"  - {name}")
+        # Promoted secrets become organization secrets, never repository secrets
+        promoted = self._promoted_secrets(secrets_to_migrate)
+        secrets_to_migrate = [name for name in secrets_to_migrate if name not in promoted]
+        promoted_scopes, kept_scopes = self._promotion_scopes(promoted, targets) if promoted else ({}, {})
+        if self.config.target_env and secrets_to_migrate:
+            secrets_to_migrate, env_secrets_info = self._route_to_target_env(secrets_to_migrate, env_secrets_info)
 
-        # Step 2b: List environment secrets from source repository (for informational purposes)
-        self.log.debug("Fetching environment secrets from source repository...")
-        env_secrets_info = self.source_api.list_all_environments_with_secrets(
-            self.config.source_org, self.config.source_repo
-        )
-        
+        plans = []
+        for target_repo in targets:
+            with self._targeting(target_repo):
+                plans.append(self._plan_target(secrets_to_migrate, env_secrets_info))
+        plans = [(target_secrets, target_env_secrets, skip + promoted) for target_secrets, target_env_secrets, skip in plans]
         self._check_rate_limits("after_listing_secrets")
-        
-        if env_secrets_info:
-            self.log.info(f"Environment secrets to migrate ({len(env_secrets_info)} total):")
-            for env_name, secret_names in env_secrets_info.items():
-                if secret_names:
-                    secret_list = ", ".join(secret_names)
-                    self.log.info(f"  - {env_name}: {secret_list}")
-                else:
-                    self.log.info(f"  - {env_name}: (no secrets)")
-        else:
-            self.log.debug("No environment secrets found in source repository")
+
+        if kept_scopes and not self.planning:
+            self._share_kept_secrets(kept_scopes, targets)
+        if not promoted_scopes and not any(target_secrets or any(target_env_secrets.values()) for target_secrets, target_env_secrets, _ in plans):
+            self.log.info("No secrets to migrate (all already migrated, unchanged since the last sync or present on the target)")
+            self._nothing_to_migrate("every secret was migrated by an earlier run or already exists on the target")
+            return
+        if self.planning:
+            return
+
+        branch_name = self._check_branch_rules(
+            self.config.source_repo, branch_name, ".github/workflows/migrate-secrets.yml"
+        )
+
+        # Step 2c: Create placeholder secrets on target (if enabled)
+        if self.config.placeholder_mode != "none":
+            for target_repo, (target_secrets, target_env_secrets, _) in zip(targets, plans):
+                with self._targeting(target_repo):
+                    self.log.info("Creating placeholder secrets on target...")
+                    self._create_placeholders(target_secrets, target_env_secrets)
+            self._check_rate_limits("after_placeholders")
 
         # Step 3: Get default branch and commit SHA
         self.log.debug("Getting default branch...")
@@ -555,9 +2711,7 @@ class Migrator:
         )
         self.log.debug(f"Default branch: {default_branch}")
 
-        master_commit_sha = self.source_api.get_commit_sha(
-            self.config.source_org, self.config.source_repo, default_branch
-        )
+        master_commit_sha = self._base_commit(self.config.source_repo, default_branch)
 
         # Step 4: Delete old migration branch if it exists
         self.log.debug(f"Checking if branch {branch_name} exists...")
@@ -565,27 +2719,24 @@ class Migrator:
             self.config.source_org, self.config.source_repo, branch_name
         )
 
-        # Step 5: Create target PAT secret in source repo (for workflow to access target)
-        self.log.info("Creating SECRETS_MIGRATOR_TARGET_PAT in source repository...")
-        self.source_api.create_repo_secret(
-            self.config.source_org,
-            self.config.source_repo,
-            "SECRETS_MIGRATOR_TARGET_PAT",
-            self.config.target_pat
-        )
-        self.log.debug("Successfully created SECRETS_MIGRATOR_TARGET_PAT")
+        self._check_approval_environment(self.config.source_repo, branch_name)
+
+        # Step 5: Create target PAT secret in source repo (for workflow to access target),
+        # unless the workflow mints a short-lived target token from a GitHub App
+        if self.config.target_app_id:
+            self.log.info(f"Target token will be minted in the workflow from GitHub App {self.config.target_app_id}")
+        else:
+            self.log.info("Creating SECRETS_MIGRATOR_TARGET_PAT in source repository...")
+            self._create_temporary_secret(self.config.source_repo, "SECRETS_MIGRATOR_TARGET_PAT", self.config.target_pat)
+            self.log.debug("Successfully created SECRETS_MIGRATOR_TARGET_PAT")
 
         # Step 5b: Create source PAT secret in source repo (for workflow cleanup only)
         self.log.info("Creating SECRETS_MIGRATOR_SOURCE_PAT in source repository...")
-        self.source_api.create_repo_secret(
-            self.config.source_org,
-            self.config.source_repo,
-            "SECRETS_MIGRATOR_SOURCE_PAT",
-            self.config.source_pat
-        )
+        self._create_temporary_secret(self.config.source_repo, "SECRETS_MIGRATOR_SOURCE_PAT", self.config.source_pat)
         self.log.debug("Successfully created SECRETS_MIGRATOR_SOURCE_PAT")
 
         # Step 6: Create migration branch
+        self.throttle.wait(f"the push to {self.config.source_org}/{self.config.source_repo}")
         self.log.debug(f"Creating branch {branch_name}...")
         self.source_api.create_branch(
             self.config.source_org,
@@ -593,6 +2744,7 @@ class Migrator:
             branch_name,
             master_commit_sha
         )
+        self._created("branch", self.config.source_repo, branch_name)
         
         self._check_rate_limits("after_branch_creation")
 
@@ -600,45 +2752,113 @@ class Migrator:
         self._wait_for_rate_limit_reset()
 
         # Step 7: Generate and create workflow file
+        _, primary_env_secrets, skip_secrets = plans[0]
+        migrated_names = list(dict.fromkeys(
+            [name for target_secrets, target_env_secrets, _ in plans
+             for name in target_secrets + [name for names in target_env_secrets.values() for name in names]]
+            + list(promoted_scopes)
+        ))
         workflow = generate_workflow(
             self.config.source_org, self.config.source_repo,
             self.config.target_org, self.config.target_repo, branch_name,
-            env_secrets_info
+            primary_env_secrets,
+            gh_cli_version=self.config.gh_cli_version,
+            gh_cli_digests=self._gh_cli_digests(),
+            name_map=self._name_map(migrated_names),
+            value_rewrites=self._value_rewrites(migrated_names),
+            destinations=self.destinations,
+            policy=self._workflow_policy(),
+            skip_secrets=skip_secrets,
+            environment_map=self.config.environment_map,
+            runner_labels=self.config.runner_labels,
+            runner_os=self.config.runner_os,
+            engine=self.config.workflow_engine,
+            action_ref=self.config.action_ref,
+            repo_secret_names=list(dict.fromkeys(name for target_secrets, _, _ in plans for name in target_secrets)),
+            target_app_id=self.config.target_app_id,
+            approval_environment=self.config.approval_environment,
+            expected_actor=self._workflow_actor(),
+            timeout_minutes=self.config.workflow_timeout,
+            delivery=self.config.delivery,
+            base_branch=default_branch,
+            workflow_path=".github/workflows/migrate-secrets.yml",
+            extra_targets=[
+                FanOutTarget(target_repo, target_env_secrets, target_skip)
+                for target_repo, (_, target_env_secrets, target_skip) in zip(targets[1:], plans[1:])
+            ],
+            # With --target-env, repository secrets are written by the environment steps
+            repo_secrets="repo" in levels and not self.config.target_env,
+            source_host=self.config.source_host,
+            target_host=self.config.target_host,
+            promoted_secrets=list(promoted_scopes),
+            promoted_scopes=promoted_scopes
         )
+        self._lint_workflow(".github/workflows/migrate-secrets.yml", workflow)
         self.log.debug("Creating workflow file...")
         self.source_api.create_file(
             self.config.source_org,
             self.config.source_repo,
             branch_name,
             ".github/workflows/migrate-secrets.yml",
-            workflow
+            workflow,
+            message=self.config.commit_message,
+            committer=self._committer()
+        )
+        self.events.emit(
+            "workflow_pushed", f"Pushed .github/workflows/migrate-secrets.yml to branch '{branch_name}'",
+            branch=branch_name, path=".github/workflows/migrate-secrets.yml"
+        )
+        self._record_resource(
+            "workflow_file", repo=self.config.source_repo, branch=branch_name, path=".github/workflows/migrate-secrets.yml"
         )
 
+        if self.config.delivery == "pull-request":
+            self._open_workflow_pull_request(
+                self.config.source_repo, branch_name, default_branch, ".github/workflows/migrate-secrets.yml"
+            )
+            self._pending_cleanup.clear()
+            self._check_rate_limits("migration_complete")
+            return
+        # The running workflow deletes the temporary secrets itself
+        self._pending_cleanup.clear()
+
         # Step 7: Fetch workflow run details with retries
         self.log.debug("Waiting for workflow to be triggered...")
         
-        workflow_run_url = ""
-        max_retries = 6
-        for attempt in range(max_retries):
-            time.sleep(2 if attempt == 0 else 3)  # Initial 2s, then 3s between retries
-            workflow_run_url = self._get_workflow_run_url(branch_name)
-            if workflow_run_url:
-                self.log.debug(f"Found workflow run URL on attempt {attempt + 1}")
-                break
-            if attempt < max_retries - 1:
-                self.log.debug(f"Workflow run not yet found, retrying... (attempt {attempt + 1}/{max_retries})")
+        run_id = self._await_workflow_run(branch_name)
+        workflow_run_url = self._workflow_run_url(run_id) if run_id else ""
         
         if workflow_run_url:
-            self.log.success(
+            self.log.summary(
                 f"Secrets migration workflow triggered!\n"
                 f"View progress: {workflow_run_url}"
             )
         else:
             # Fallback to generic actions page if we can't get the specific run
             self.log.debug("Could not find specific workflow run, using generic actions URL")
-            self.log.success(
+            workflow_run_url = f"https://{self.config.source_host}/{self.config.source_org}/{self.config.source_repo}/actions?query=branch%3A{quote(branch_name, safe='')}"
+            self.log.summary(
                 f"Secrets migration workflow triggered!\n"
-                f"View progress: https://github.com/{self.config.source_org}/{self.config.source_repo}/actions?query=branch%3Amigrate-secrets"
+                f"View progress: {workflow_run_url}"
             )
+        self.events.emit("link", "Secrets migration workflow run", url=workflow_run_url)
+        
+        stamps = {}
+        for target_repo, (target_secrets, target_env_secrets, _) in zip(targets, plans):
+            location = f"{self.config.target_org}/{target_repo}"
+            for name in target_secrets:
+                stamps[("repository", location, self.namer.transform(name))] = self._source_stamps.get(("repo", "", name), "")
+            for env_name, names in target_env_secrets.items():
+                env_location = f"{location}:{self._target_env(env_name)}"
+                for name in names:
+                    stamps[("environment", env_location, self.namer.transform(name))] = self._source_stamps.get(("env", env_name, name), "")
+        for name in promoted_scopes:
+            stamps[("organization", self.config.target_org, self.namer.transform(name))] = self._source_stamps.get(("repo", "", name), "")
+        self._track_run(run_id, stamps)
+        
+        if self.config.wait:
+            if not run_id:
+                raise RuntimeError(f"Could not find the migration workflow run to wait for (see {workflow_run_url})")
+            self._confirm_run(run_id, list(stamps))
         
         self._check_rate_limits("migration_complete")
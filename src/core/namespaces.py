@@ -0,0 +1,62 @@
+"""Secret namespaces (Actions, Dependabot, Codespaces) and bulk selection helpers."""
+import fnmatch
+from typing import Iterable, List, Optional
+from src.core.filters import is_managed_secret
+
+SECRET_NAMESPACES = ("actions", "dependabot", "codespaces")
+
+# Namespaces whose APIs an organization can switch off entirely
+OPTIONAL_NAMESPACES = ("dependabot", "codespaces")
+
+
+class NamespaceUnavailableError(RuntimeError):
+    """A secret namespace's API is disabled for the organization or repository."""
+
+    def __init__(self, namespace: str, owner: str, reason: str):
+        super().__init__(f"{namespace} secrets unavailable in {owner}: {reason}")
+        self.namespace = namespace
+        self.owner = owner
+        self.reason = reason
+
+
+def unavailable_reason(namespace: str, status: Optional[int], message: str) -> str:
+    """Return why a namespace API is disabled, or "" if the error is a genuine failure.
+
+    When Dependabot or Codespaces is disabled, their secrets endpoints answer
+    403 or 404 with a message naming the feature (or saying it is disabled or
+    not enabled). A bare 404 is not treated as disabled, since it also means
+    the organization or repository does not exist.
+    """
+    if namespace not in OPTIONAL_NAMESPACES or status not in (403, 404):
+        return ""
+    text = message.lower()
+    if namespace in text or "not enabled" in text or "disabled" in text:
+        return f"{namespace} API disabled (HTTP {status}: {message})"
+    return ""
+
+
+def secrets_api_path(namespace: str, org: str, repo: str = "") -> str:
+    """Return the REST path listing a repository's (or, without repo, an organization's) secrets.
+
+    Raises:
+        ValueError: If the namespace is unknown
+    """
+    if namespace not in SECRET_NAMESPACES:
+        expected = ", ".join(SECRET_NAMESPACES)
+        raise ValueError(f"Unknown secret namespace '{namespace}': expected one of {expected}")
+    if repo:
+        return f"/repos/{org}/{repo}/{namespace}/secrets"
+    return f"/orgs/{org}/{namespace}/secrets"
+
+
+def select_matching(names: Iterable[str], patterns: Iterable[str]) -> List[str]:
+    """Select secret names matching any glob pattern (case-insensitive).
+
+    Secrets reserved for the migrator are never selected.
+    """
+    patterns = [pattern.upper() for pattern in patterns]
+    return [
+        name for name in names
+        if is_managed_secret(name)
+        and any(fnmatch.fnmatchcase(name.upper(), pattern) for pattern in patterns)
+    ]
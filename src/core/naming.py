@@ -0,0 +1,249 @@
+"""Target-side secret name transformations, name validation and naming conventions."""
+import re
+from typing import Dict, Iterable, List, Optional, Sequence
+
+_AFFIX_PATTERN = re.compile(r"^[A-Za-z0-9_]*$")
+_SECRET_NAME_PATTERN = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+_RULE_FLAGS = {"g", "i"}
+# Characters git forbids anywhere in a ref name
+_BRANCH_FORBIDDEN = set(" ~^:?*[\\")
+
+
+def secret_name_error(name: str) -> Optional[str]:
+    """Return why GitHub would reject a secret name, or None if it is valid."""
+    if not name:
+        return "name is empty"
+    if not _SECRET_NAME_PATTERN.match(name):
+        return ("only letters, digits and underscores are allowed, "
+                "and it must not start with a digit")
+    if name.upper().startswith("GITHUB_"):
+        return "the GITHUB_ prefix is reserved"
+    return None
+
+
+def branch_name_error(name: str) -> Optional[str]:
+    """Return why git would reject a branch name, or None if it is valid."""
+    if not name:
+        return "name is empty"
+    forbidden = sorted(set(name) & _BRANCH_FORBIDDEN)
+    if forbidden or any(ord(char) < 32 or ord(char) == 127 for char in name):
+        shown = " ".join(repr(char) for char in forbidden) or "control characters"
+        return f"contains characters not allowed in branch names: {shown}"
+    if name.startswith(("-", "/")) or name.endswith(("/", ".", ".lock")):
+        return "must not start with '-' or '/', or end with '/', '.' or '.lock'"
+    if ".." in name or "//" in name or "@{" in name or "/." in name or name.startswith("."):
+        return "must not contain '..', '//', '@{' or a component starting with '.'"
+    return None
+
+
+def check_commit_options(branch_name: str, committer_name: str, committer_email: str) -> None:
+    """Validate a custom migration branch name and commit identity.
+
+    Raises:
+        ValueError: If the branch name is invalid or the identity is incomplete
+    """
+    if branch_name:
+        error = branch_name_error(branch_name)
+        if error:
+            raise ValueError(f"Invalid branch name '{branch_name}': {error}")
+    if bool(committer_name) != bool(committer_email):
+        raise ValueError("Committer name and email must be given together")
+    if committer_email and "@" not in committer_email:
+        raise ValueError(f"Invalid committer email '{committer_email}'")
+
+
+class NamingConvention:
+    """Organization naming rules that discovered secret names are checked against.
+
+    Names must fully match the pattern (if any), be at most max_length
+    characters long (0 for no limit) and not start with a reserved prefix;
+    prefixes are compared case-insensitively, like secret names.
+    """
+
+    def __init__(
+        self,
+        pattern: str = "",
+        max_length: int = 0,
+        reserved_prefixes: Sequence[str] = ()
+    ):
+        self.pattern_text = pattern
+        try:
+            self.pattern = re.compile(pattern) if pattern else None
+        except re.error as e:
+            raise ValueError(f"Invalid naming pattern '{pattern}': {e}")
+        if max_length < 0:
+            raise ValueError(f"Invalid naming max length {max_length}: must not be negative")
+        self.max_length = max_length
+        self.reserved_prefixes = [prefix for prefix in reserved_prefixes if prefix]
+
+    @property
+    def is_empty(self) -> bool:
+        """True if no rule is configured."""
+        return self.pattern is None and not self.max_length and not self.reserved_prefixes
+
+    def violations(self, name: str) -> List[str]:
+        """Return every rule the secret name breaks."""
+        problems = []
+        if self.pattern is not None and not self.pattern.fullmatch(name):
+            problems.append(f"does not match pattern '{self.pattern_text}'")
+        if self.max_length and len(name) > self.max_length:
+            problems.append(f"is {len(name)} characters long (max {self.max_length})")
+        for prefix in self.reserved_prefixes:
+            if name.upper().startswith(prefix.upper()):
+                problems.append(f"uses reserved prefix '{prefix}'")
+        return problems
+
+    def check(self, names: Iterable[str]) -> Dict[str, List[str]]:
+        """Map each violating name to its problems, in the order given."""
+        result = {}
+        for name in names:
+            problems = self.violations(name)
+            if problems:
+                result[name] = problems
+        return result
+
+
+class RenameRule:
+    """A sed-style substitution (s/PATTERN/REPLACEMENT/FLAGS) applied to secret names.
+
+    Any character may follow the leading 's' as delimiter. Flags: 'g' replaces
+    every match instead of the first, 'i' matches case-insensitively.
+    """
+
+    def __init__(self, rule: str):
+        self.rule = rule
+        syntax_error = f"Invalid rename rule '{rule}': expected s/PATTERN/REPLACEMENT/[FLAGS]"
+        if len(rule) < 2 or rule[0] != "s" or rule[1].isalnum() or rule[1] in "\\_":
+            raise ValueError(syntax_error)
+        delimiter = rule[1]
+        parts = self._split(rule[2:], delimiter)
+        if len(parts) != 3:
+            raise ValueError(syntax_error)
+        pattern, self.replacement, flags = parts
+        unknown = set(flags) - _RULE_FLAGS
+        if unknown:
+            raise ValueError(
+                f"Invalid rename rule '{rule}': unknown flag(s) {''.join(sorted(unknown))}"
+            )
+        self.count = 0 if "g" in flags else 1
+        try:
+            self.pattern = re.compile(pattern, re.IGNORECASE if "i" in flags else 0)
+            # Surfaces bad group references in the replacement before any name is seen
+            self.pattern.sub(self.replacement, "")
+        except re.error as e:
+            raise ValueError(f"Invalid rename rule '{rule}': {e}")
+
+    @staticmethod
+    def _split(body: str, delimiter: str) -> List[str]:
+        """Split on unescaped delimiters, unescaping them in the result."""
+        parts = [""]
+        i = 0
+        while i < len(body):
+            char = body[i]
+            if char == "\\" and i + 1 < len(body) and body[i + 1] == delimiter:
+                parts[-1] += delimiter
+                i += 2
+                continue
+            if char == delimiter:
+                parts.append("")
+            else:
+                parts[-1] += char
+            i += 1
+        return parts
+
+    def apply(self, name: str) -> str:
+        """Apply the substitution to a secret name."""
+        return self.pattern.sub(self.replacement, name, count=self.count)
+
+
+class SecretNameTransformer:
+    """Computes the name a secret receives on the target.
+
+    Rename rules run in order, then the prefix and suffix are added. An
+    override (e.g. a Rego policy's rename decision) replaces all of them for
+    its source name.
+    """
+
+    def __init__(self, prefix: str = "", suffix: str = "", rules: Sequence[str] = ()):
+        for label, value in (("prefix", prefix), ("suffix", suffix)):
+            if not _AFFIX_PATTERN.match(value):
+                raise ValueError(
+                    f"Invalid target {label} '{value}': "
+                    "only letters, digits and underscores are allowed"
+                )
+        self.prefix = prefix
+        self.suffix = suffix
+        self.rules = [RenameRule(rule) for rule in rules]
+        self.overrides: Dict[str, str] = {}
+
+    @property
+    def is_identity(self) -> bool:
+        """True if names are migrated unchanged."""
+        return not self.prefix and not self.suffix and not self.rules and not self.overrides
+
+    def transform(self, name: str) -> str:
+        """Return the target name for a source secret name.
+
+        The result is upper-cased, as GitHub stores secret names in upper case,
+        so it matches the name the target lists afterwards.
+        """
+        if name in self.overrides:
+            return self.overrides[name].upper()
+        for rule in self.rules:
+            name = rule.apply(name)
+        return f"{self.prefix}{name}{self.suffix}".upper()
+
+    def strip_affixes(self, target_name: str) -> Optional[str]:
+        """Return target_name without the prefix and suffix, or None if it does not carry them.
+
+        Compared case-insensitively, as GitHub stores secret names in upper case.
+        """
+        upper = target_name.upper()
+        prefix, suffix = self.prefix.upper(), self.suffix.upper()
+        if len(upper) <= len(prefix) + len(suffix):
+            return None
+        if not upper.startswith(prefix) or not upper.endswith(suffix):
+            return None
+        return target_name[len(prefix):len(target_name) - len(suffix)]
+
+    def source_name(self, target_name: str) -> Optional[str]:
+        """Return the source name target_name was migrated from, or None if it cannot be told.
+
+        Overrides are looked up in reverse and the prefix and suffix stripped.
+        Rename rules cannot be undone, so with rules only overridden names are known.
+        """
+        upper = target_name.upper()
+        for source, renamed in self.overrides.items():
+            if renamed.upper() == upper:
+                return source
+        if self.rules:
+            return None
+        return self.strip_affixes(target_name)
+
+    def build_map(self, names: Iterable[str]) -> Dict[str, str]:
+        """Map each source secret name to its target name."""
+        return {name: self.transform(name) for name in names}
+
+    def validate(self, names: Iterable[str]) -> Dict[str, str]:
+        """Build the name map for one secret scope, rejecting names GitHub would refuse.
+
+        Raises:
+            ValueError: If a target name is invalid or two secrets map to the same
+                target name (GitHub secret names are case-insensitive)
+        """
+        name_map = self.build_map(names)
+        problems = []
+        for source_name, target_name in name_map.items():
+            error = secret_name_error(target_name)
+            if error:
+                problems.append(f"'{source_name}' -> '{target_name}': {error}")
+        seen: Dict[str, str] = {}
+        for source_name, target_name in name_map.items():
+            key = target_name.upper()
+            if key in seen:
+                problems.append(f"'{seen[key]}' and '{source_name}' both map to '{target_name}'")
+            else:
+                seen[key] = source_name
+        if problems:
+            raise ValueError("Invalid target secret names: " + "; ".join(problems))
+        return name_map
@@ -0,0 +1,52 @@
+"""Completion summaries posted to Slack or Microsoft Teams incoming webhooks."""
+from typing import List, Optional
+from src.core.events import EventLog
+from src.utils.http import post_json
+from src.utils.progress import format_eta
+
+
+def build_summary_text(
+    title: str,
+    migrated: int,
+    failures: int,
+    duration: float,
+    events: EventLog,
+    details: Optional[List[str]] = None,
+    report: str = ""
+) -> str:
+    """Render the plain-text completion summary.
+
+    Args:
+        title: What ran (e.g. 'Pipeline pipeline.yml')
+        migrated: Repositories or jobs migrated successfully
+        failures: Repositories or jobs that failed
+        duration: Wall-clock duration of the run in seconds
+        events: Event log of the run; workflow links are listed from it
+        details: Extra lines, such as one per pipeline job
+        report: Location of the run report (URL or file path)
+    """
+    status = "finished with failures" if failures else "succeeded"
+    lines = [
+        f"Secrets migration {status}: {title}",
+        f"{migrated} migrated, {failures} failed in {format_eta(duration)}",
+    ]
+    lines.extend(details or [])
+    for event in events.events:
+        if event.kind == "link" and event.data.get("url"):
+            lines.append(f"{event.message}: {event.data['url']}")
+    if report:
+        lines.append(f"Report: {report}")
+    return "\n".join(lines)
+
+
+def send_notification(url: str, text: str, timeout: float = 10.0) -> None:
+    """Post text to an incoming webhook.
+
+    Slack and Teams incoming webhooks both accept a JSON body with a 'text'
+    field, so the same payload works for either.
+
+    Raises:
+        ValueError: If the URL is not https
+        OSError: If the webhook cannot be reached or rejects the message
+    """
+    post_json(url, {"text": text}, timeout=timeout)
@@ -0,0 +1,163 @@
+"""Organization-wide inventories of Actions secrets and variables.
+
+An inventory records every secret and variable of an organization and its
+repositories at one point in time: level, environment, organization
+visibility and selected repositories, and last update. Values are never
+recorded (secret values cannot be read; variable values are left out too).
+It serves as an audit artifact and as the list of repositories a later
+`plan --inventory` covers.
+"""
+import csv
+import io
+import json
+from datetime import datetime, timezone
+from typing import Any, Dict, List, Optional, Sequence, Tuple, Union
+from src.core.inventory import SecretRecord, VariableRecord
+from src.core.scopes import OrgSecretScope
+
+INVENTORY_FORMATS = ("json", "csv")
+INVENTORY_SCHEMA_VERSION = 1
+
+# Columns of the CSV form, one row per secret or variable
+INVENTORY_COLUMNS = (
+    "org", "repo", "kind", "level", "environment", "name", "visibility",
+    "selected_repositories", "updated_at",
+)
+
+Record = Union[SecretRecord, VariableRecord]
+
+
+def _entry(
+    kind: str, record: Record, repo: str = "", scope: Optional[OrgSecretScope] = None
+) -> Dict[str, Any]:
+    """Return the inventory entry of one secret or variable."""
+    selected = scope.repositories if scope is not None and scope.visibility == "selected" else []
+    return {
+        "kind": kind,
+        "level": record.level,
+        "repo": repo,
+        "environment": record.environment,
+        "name": record.name,
+        "visibility": record.visibility,
+        "selected_repositories": sorted(selected),
+        "updated_at": record.updated_at.isoformat() if record.updated_at else "",
+    }
+
+
+def build_inventory(
+    org: str,
+    repositories: Dict[str, Tuple[List[SecretRecord], List[VariableRecord]]],
+    org_secrets: Sequence[SecretRecord] = (),
+    org_variables: Sequence[VariableRecord] = (),
+    scopes: Optional[Dict[Tuple[str, str], OrgSecretScope]] = None,
+    taken_at: Optional[datetime] = None
+) -> Dict[str, Any]:
+    """Build the inventory document of an organization.
+
+    Args:
+        org: Organization name
+        repositories: Repository and environment secrets and variables, by repository
+        org_secrets: Organization secrets
+        org_variables: Organization variables
+        scopes: Visibility and selected repositories of organization secrets and
+            variables, by ('secret' or 'variable', name)
+        taken_at: Inventory time (defaults to now)
+    """
+    scopes = scopes or {}
+    entries = []
+    for kind, records in (("secret", org_secrets), ("variable", org_variables)):
+        entries += [
+            _entry(kind, record, scope=scopes.get((kind, record.name)))
+            for record in sorted(records, key=lambda item: item.key)
+        ]
+    for repo in sorted(repositories):
+        secrets, variables = repositories[repo]
+        for kind, records in (("secret", secrets), ("variable", variables)):
+            entries += [
+                _entry(kind, record, repo)
+                for record in sorted(records, key=lambda item: item.key)
+            ]
+    return {
+        "schema_version": INVENTORY_SCHEMA_VERSION,
+        "organization": org,
+        "taken_at": (taken_at or datetime.now(timezone.utc)).isoformat(),
+        "repositories": sorted(repositories),
+        "entries": entries,
+    }
+
+
+def inventory_csv(inventory: Dict[str, Any]) -> str:
+    """Render an inventory as CSV, one row per secret or variable."""
+    output = io.StringIO()
+    writer = csv.DictWriter(output, fieldnames=list(INVENTORY_COLUMNS), lineterminator="\n")
+    writer.writeheader()
+    for entry in inventory["entries"]:
+        writer.writerow(dict(
+            entry, org=inventory["organization"],
+            selected_repositories=";".join(entry["selected_repositories"])
+        ))
+    return output.getvalue()
+
+
+def format_inventory_summary(inventory: Dict[str, Any]) -> str:
+    """Summarize an inventory in one line."""
+    entries = inventory["entries"]
+    secrets = sum(entry["kind"] == "secret" for entry in entries)
+    return (
+        f"Inventory of {inventory['organization']}: {len(inventory['repositories'])} "
+        f"repository(ies), {secrets} secret(s), {len(entries) - secrets} variable(s)"
+    )
+
+
+def _from_csv(text: str) -> Dict[str, Any]:
+    """Rebuild an inventory document from its CSV form."""
+    rows = list(csv.DictReader(io.StringIO(text)))
+    missing = [column for column in INVENTORY_COLUMNS if rows and column not in rows[0]]
+    if not rows or missing:
+        raise ValueError(f"not an inventory CSV (expected columns {', '.join(INVENTORY_COLUMNS)})")
+    orgs = {row["org"] for row in rows}
+    if len(orgs) != 1:
+        raise ValueError("an inventory CSV covers exactly one organization")
+    entries = [
+        dict(
+            {column: row[column] for column in INVENTORY_COLUMNS if column != "org"},
+            selected_repositories=[
+                repo for repo in row["selected_repositories"].split(";") if repo
+            ],
+        )
+        for row in rows
+    ]
+    return {
+        "schema_version": INVENTORY_SCHEMA_VERSION,
+        "organization": orgs.pop(),
+        "taken_at": "",
+        "repositories": sorted({entry["repo"] for entry in entries if entry["repo"]}),
+        "entries": entries,
+    }
+
+
+def load_inventory(path: str) -> Dict[str, Any]:
+    """Read an inventory written by the inventory command, as JSON or CSV.
+
+    Raises:
+        OSError: If the file cannot be read
+        ValueError: If the file is not an inventory
+    """
+    with open(path, encoding="utf-8") as handle:
+        text = handle.read()
+    if not text.lstrip().startswith("{"):
+        return _from_csv(text)
+    try:
+        data = json.loads(text)
+    except json.JSONDecodeError as e:
+        raise ValueError(f"not valid JSON: {e}")
+    if not isinstance(data, dict) or data.get("schema_version") != INVENTORY_SCHEMA_VERSION:
+        raise ValueError(f"not a version {INVENTORY_SCHEMA_VERSION} inventory")
+    if not data.get("organization") or not isinstance(data.get("entries"), list):
+        raise ValueError("inventory has no organization or entries")
+    return data
+
+
+def inventory_repositories(inventory: Dict[str, Any]) -> List[str]:
+    """Return the repositories holding repository or environment secrets or variables."""
+    return sorted({entry["repo"] for entry in inventory["entries"] if entry.get("repo")})
@@ -0,0 +1,270 @@
+"""Multi-job migration pipelines defined in a YAML config file."""
+from typing import Any, Callable, Dict, List, Optional, Sequence
+import yaml
+from src.clients.github import REPO_VISIBILITIES
+from src.core.config import (
+    CREDENTIAL_OPTIONS, MigrationConfig, check_promotion, check_selection, check_target_env
+)
+from src.core.conflicts import CONFLICT_POLICIES
+from src.core.naming import NamingConvention, check_commit_options
+from src.core.placeholders import PLACEHOLDER_MODES
+from src.core.preflight import QUOTA_CHECK_MODES
+from src.core.branch_rules import BRANCH_CHECK_MODES
+from src.core.shared_repos import shared_first_rank
+from src.core.workflow_generator import DELIVERY_MODES, RUNNER_OSES, WORKFLOW_ENGINES
+from src.utils.logger import Logger
+
+# Options restricted to a fixed set of values, as on the command line
+_CHOICE_OPTIONS = {
+    "placeholder_mode": PLACEHOLDER_MODES,
+    "quota_check": QUOTA_CHECK_MODES,
+    "conflict_policy": CONFLICT_POLICIES,
+    "delivery": DELIVERY_MODES,
+    "runner_os": RUNNER_OSES,
+    "workflow_engine": WORKFLOW_ENGINES,
+    "branch_check": BRANCH_CHECK_MODES,
+    "target_repo_visibility": REPO_VISIBILITIES,
+}
+_LIST_OPTIONS = (
+    "rename_rules", "runner_labels", "extra_target_repos", "naming_reserved_prefixes",
+    "state_age_recipients", "levels", "promote_to_org", "destination_plugins",
+)
+_MAPPING_OPTIONS = ("environment_map",)
+
+
+def _check_option_values(name: str, options: Dict[str, Any]) -> None:
+    """Reject option values the command line would refuse.
+
+    Raises:
+        ValueError: If an option has an invalid value or type
+    """
+    for key, choices in _CHOICE_OPTIONS.items():
+        if key in options and options[key] not in choices:
+            raise ValueError(
+                f"Job '{name}' has invalid {key} '{options[key]}': "
+                f"expected one of {', '.join(choices)}"
+            )
+    for key in _LIST_OPTIONS:
+        if key in options and not isinstance(options[key], list):
+            raise ValueError(f"Job '{name}' option '{key}' must be a list")
+    for key in _MAPPING_OPTIONS:
+        if key in options and not isinstance(options[key], dict):
+            raise ValueError(f"Job '{name}' option '{key}' must be a mapping")
+    try:
+        check_commit_options(
+            str(options.get("branch_name", "")),
+            str(options.get("committer_name", "")),
+            str(options.get("committer_email", "")),
+        )
+    except ValueError as e:
+        raise ValueError(f"Job '{name}': {e}")
+    try:
+        check_selection(
+            [str(level) for level in options.get("levels", [])],
+            bool(options.get("org_to_org", False)),
+            bool(options.get("migrate_secrets", True)),
+            bool(options.get("migrate_variables", True)),
+        )
+        check_promotion(
+            [str(name) for name in options.get("promote_to_org", [])],
+            bool(options.get("org_to_org", False)),
+            [str(level) for level in options.get("levels", [])],
+        )
+        check_target_env(
+            str(options.get("target_env", "")),
+            bool(options.get("org_to_org", False)),
+            [str(level) for level in options.get("levels", [])],
+            dict(options.get("environment_map", {})),
+        )
+    except ValueError as e:
+        raise ValueError(f"Job '{name}': {e}")
+    max_length = options.get("naming_max_length", 0)
+    if isinstance(max_length, bool) or not isinstance(max_length, int):
+        raise ValueError(f"Job '{name}' option 'naming_max_length' must be an integer")
+    try:
+        NamingConvention(
+            str(options.get("naming_pattern", "")),
+            max_length,
+            [str(prefix) for prefix in options.get("naming_reserved_prefixes", [])],
+        )
+    except ValueError as e:
+        raise ValueError(f"Job '{name}': {e}")
+
+
+def _normalize(options: Dict[str, Any]) -> Dict[str, Any]:
+    """Accept both 'source-org' and 'source_org' style keys."""
+    return {str(key).replace("-", "_"): value for key, value in options.items()}
+
+
+class PipelineJob:
+    """A single migration job within a pipeline."""
+
+    def __init__(self, name: str, options: Dict[str, Any], continue_on_error: bool = False):
+        self.name = name
+        self.options = options
+        self.continue_on_error = continue_on_error
+        # Hosts reusable workflows/composite actions (or migrates org secrets) that
+        # later jobs depend on; set by order_shared_first
+        self.shared = False
+
+    def build_config(self, source_pat: str, target_pat: str) -> MigrationConfig:
+        """Build the MigrationConfig for this job."""
+        return MigrationConfig(source_pat=source_pat, target_pat=target_pat, **self.options)
+
+
+class PipelineResult:
+    """Outcome of a single pipeline job."""
+
+    def __init__(self, name: str, status: str, error: str = ""):
+        self.name = name
+        self.status = status  # 'succeeded', 'failed' or 'not_run'
+        self.error = error
+
+
+def parse_pipeline(data: Any) -> List[PipelineJob]:
+    """Parse pipeline jobs from a loaded YAML document.
+
+    The document has an optional 'defaults' mapping merged into every job and
+    an ordered 'jobs' list. Each job accepts any migrate option plus 'name'
+    and 'continue_on_error'.
+
+    Raises:
+        ValueError: If the document is malformed
+    """
+    if not isinstance(data, dict):
+        raise ValueError("Pipeline config must be a mapping with a 'jobs' list")
+
+    defaults = _normalize(data.get("defaults") or {})
+    jobs_data = data.get("jobs")
+    if not isinstance(jobs_data, list) or not jobs_data:
+        raise ValueError("Pipeline config must define a non-empty 'jobs' list")
+
+    allowed = set(MigrationConfig.option_names())
+    jobs = []
+    for index, raw_job in enumerate(jobs_data, start=1):
+        if not isinstance(raw_job, dict):
+            raise ValueError(f"Job #{index} must be a mapping")
+        job_data = dict(defaults)
+        job_data.update(_normalize(raw_job))
+
+        name = str(job_data.pop("name", f"job-{index}"))
+        continue_on_error = bool(job_data.pop("continue_on_error", False))
+
+        credentials = [key for key in job_data if key in CREDENTIAL_OPTIONS]
+        if credentials:
+            raise ValueError(
+                f"Job '{name}' sets {', '.join(credentials)}; tokens must be passed "
+                "via flags or environment variables, never in the config file"
+            )
+        unknown = sorted(key for key in job_data if key not in allowed)
+        if unknown:
+            raise ValueError(f"Job '{name}' has unknown option(s): {', '.join(unknown)}")
+        for required in ("source_org", "target_org", "source_repo"):
+            if not job_data.get(required):
+                raise ValueError(f"Job '{name}' is missing required option '{required}'")
+        if not job_data.get("org_to_org") and not job_data.get("target_repo"):
+            raise ValueError(f"Job '{name}' needs 'target_repo' (or 'org_to_org: true')")
+        _check_option_values(name, job_data)
+
+        jobs.append(PipelineJob(name, job_data, continue_on_error))
+    return jobs
+
+
+def load_pipeline(path: str, extra_jobs: Sequence[Dict[str, Any]] = ()) -> List[PipelineJob]:
+    """Load and validate pipeline jobs from a YAML file.
+
+    extra_jobs (e.g. the repositories of a GEI log) run after the file's jobs
+    and take its defaults; with extra jobs, the file is optional.
+    """
+    data: Any = {}
+    if path:
+        with open(path, "r", encoding="utf-8") as handle:
+            data = yaml.safe_load(handle)
+    if extra_jobs:
+        data = data or {}
+        if not isinstance(data, dict):
+            raise ValueError("Pipeline config must be a mapping")
+        data = dict(data, jobs=list(data.get("jobs") or []) + list(extra_jobs))
+    return parse_pipeline(data)
+
+
+def order_shared_first(
+    jobs: List[PipelineJob],
+    is_shared: Callable[[PipelineJob], bool]
+) -> List[PipelineJob]:
+    """Reorder jobs so the ones other repositories depend on run first.
+
+    Organization-secret jobs come first, then repository jobs for which
+    is_shared returns True, then all other (consumer) jobs. The relative order
+    within each group is preserved, and the first two groups are marked shared.
+
+    Args:
+        jobs: Jobs in config order
+        is_shared: Returns True if a repository job hosts reusable workflows or
+            composite actions
+
+    Returns:
+        The reordered jobs
+    """
+    ranked = []
+    for position, job in enumerate(jobs):
+        org_to_org = bool(job.options.get("org_to_org"))
+        rank = shared_first_rank(org_to_org, not org_to_org and is_shared(job))
+        job.shared = rank != shared_first_rank(False, False)
+        ranked.append((rank, position, job))
+    return [job for _, _, job in sorted(ranked, key=lambda item: item[:2])]
+
+
+def run_pipeline(
+    jobs: List[PipelineJob],
+    run_job: Callable[[PipelineJob], None],
+    logger: Logger,
+    on_result: Optional[Callable[[PipelineResult], None]] = None
+) -> List[PipelineResult]:
+    """Run pipeline jobs in order.
+
+    A failing job stops the pipeline unless it sets continue_on_error, in
+    which case the failure is recorded and the next job starts. A failing
+    shared job (see order_shared_first) never lets consumer jobs run, since
+    their workflows would fail without the shared repository's secrets.
+
+    Args:
+        jobs: Jobs to run, in order
+        run_job: Callable executing one job; raises on failure
+        logger: Logger for progress output
+        on_result: Optional callback invoked with each job's result
+
+    Returns:
+        One result per job, including jobs that never ran
+    """
+    results: List[PipelineResult] = []
+    stopped = False
+    shared_failed = ""
+    for index, job in enumerate(jobs, start=1):
+        if stopped:
+            result = PipelineResult(job.name, "not_run")
+        elif shared_failed and not job.shared:
+            result = PipelineResult(
+                job.name, "not_run", f"shared job '{shared_failed}' failed"
+            )
+        else:
+            logger.info(f"[{index}/{len(jobs)}] Running job '{job.name}'...")
+            try:
+                run_job(job)
+                result = PipelineResult(job.name, "succeeded")
+                logger.success(f"Job '{job.name}' succeeded")
+            except Exception as e:
+                result = PipelineResult(job.name, "failed", str(e))
+                if job.shared:
+                    shared_failed = shared_failed or job.name
+                if job.continue_on_error:
+                    logger.warn(f"Job '{job.name}' failed (continuing): {e}")
+                else:
+                    logger.error(f"Job '{job.name}' failed, stopping pipeline: {e}")
+                    stopped = True
+                if getattr(e, "remediation", ""):
+                    logger.hint(e.remediation)
+        results.append(result)
+        if on_result:
+            on_result(result)
+    return results
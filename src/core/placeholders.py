@@ -0,0 +1,121 @@
+"""Placeholder secret handling for migrations."""
+import json
+from datetime import datetime, timedelta
+from typing import Any, Dict, Iterable, List, Tuple
+from src.core.inventory import SecretRecord
+from src.utils.clock import as_utc
+
+PLACEHOLDER_MODES = ("none", "value", "skip-existing")
+DEFAULT_PLACEHOLDER_VALUE = "REPLACE_ME_LATER"
+
+
+def select_placeholder_secrets(
+    mode: str,
+    secret_names: Iterable[str],
+    existing_names: Iterable[str]
+) -> List[str]:
+    """Select which secrets should receive a placeholder on the target.
+
+    Args:
+        mode: Placeholder mode ('none', 'value' or 'skip-existing')
+        secret_names: Secret names that are going to be migrated
+        existing_names: Secret names already present on the target, matched case-insensitively
+
+    Returns:
+        List of secret names to create placeholders for, in input order
+    """
+    if mode not in PLACEHOLDER_MODES:
+        raise ValueError(f"Unknown placeholder mode: {mode}")
+
+    if mode == "none":
+        return []
+
+    names = list(secret_names)
+    if mode == "skip-existing":
+        existing = {name.upper() for name in existing_names}
+        return [name for name in names if name.upper() not in existing]
+
+    return names
+
+
+def placeholders_from_report(report: Dict[str, Any]) -> List[SecretRecord]:
+    """Read the placeholders a migration created from its JSON report (--report).
+
+    Each record's updated_at is when the placeholder was written (the event's
+    timestamp, on the migrating machine's clock). Environment placeholders are
+    keyed by their target environment.
+
+    Raises:
+        ValueError: If the report has no event list
+    """
+    events = report.get("events") if isinstance(report, dict) else None
+    if not isinstance(events, list):
+        raise ValueError("not a migration report: no 'events' list")
+    placeholders: Dict[Tuple[str, str, str], SecretRecord] = {}
+    for event in events:
+        if not isinstance(event, dict) or event.get("kind") != "placeholder_created":
+            continue
+        data = event.get("data") or {}
+        level = data.get("level")
+        name = data.get("secret")
+        if level not in ("repo", "env", "org") or not name:
+            continue
+        environment = ""
+        if level == "env":
+            environment = data.get("target_environment") or data.get("environment") or ""
+        try:
+            created = as_utc(datetime.fromisoformat(event["timestamp"]))
+        except (KeyError, TypeError, ValueError):
+            created = None
+        record = SecretRecord(name, level, environment=environment, updated_at=created)
+        # A placeholder written twice counts from its last write
+        placeholders[record.key] = record
+    return list(placeholders.values())
+
+
+def load_report_placeholders(path: str) -> List[SecretRecord]:
+    """Load the placeholders recorded in a JSON report file (see placeholders_from_report)."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return placeholders_from_report(json.load(handle))
+
+
+def unreplaced_placeholders(
+    placeholders: List[SecretRecord],
+    target_records: List[SecretRecord],
+    target_skew: timedelta = timedelta(0),
+    tolerance: timedelta = timedelta(0)
+) -> List[SecretRecord]:
+    """Select the target secrets still holding the placeholder the migration wrote.
+
+    A secret the workflow set was updated after its placeholder was created;
+    one last updated no later than the placeholder's creation (within
+    tolerance) was never replaced. Placeholders without a creation time, or
+    no longer on the target, are left out.
+
+    Args:
+        placeholders: Placeholders with their creation times as updated_at
+        target_records: Secrets currently on the target, with their update times
+        target_skew: How far the target host's clock is ahead of the local clock
+        tolerance: Margin within which timestamps are considered equal
+    """
+    current = {record.key: record for record in target_records}
+    selected = []
+    for placeholder in placeholders:
+        record = current.get(placeholder.key)
+        if record is None or record.updated_at is None or placeholder.updated_at is None:
+            continue
+        updated = as_utc(record.updated_at) - target_skew
+        if updated <= as_utc(placeholder.updated_at) + tolerance:
+            selected.append(record)
+    return selected
+
+
+def format_placeholder_drift(stale: List[SecretRecord]) -> List[str]:
+    """Render the placeholders the workflow never replaced (see unreplaced_placeholders)."""
+    lines = [
+        f"! {record.label} still holds its placeholder value (the migration workflow never set it)"
+        for record in stale
+    ]
+    if stale:
+        lines.append(f"{len(stale)} placeholder(s) never replaced")
+    return lines
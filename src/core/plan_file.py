@@ -0,0 +1,219 @@
+"""Saved migration plans: the reviewable file `plan` writes and `apply` executes.
+
+A plan records the options of each migration run (credentials left out) and
+every secret change the run would make on its targets. `apply` works the
+changes out again from the live source and target and refuses to run if they
+no longer match, so what was signed off is exactly what gets executed.
+"""
+import hashlib
+import json
+from datetime import datetime, timezone
+from typing import Any, Dict, List, Sequence, Tuple
+from src.core.config import MigrationConfig
+
+PLAN_FORMAT_VERSION = 1
+
+# What a run does to one target secret
+PLAN_ACTIONS = ("create", "replace", "delete", "skip")
+_ACTION_SYMBOLS = {"create": "+", "replace": "~", "delete": "-", "skip": "="}
+
+# Secret levels, as in the ledger and workflow log markers
+PLAN_LEVELS = ("repository", "environment", "organization")
+
+
+class PlannedChange:
+    """One target secret a run creates, replaces, deletes (--prune) or leaves alone."""
+
+    def __init__(
+        self,
+        action: str,
+        level: str,
+        location: str,
+        name: str,
+        source_name: str = "",
+        scope: str = "",
+        reason: str = ""
+    ):
+        if action not in PLAN_ACTIONS:
+            raise ValueError(f"unknown plan action '{action}': expected {', '.join(PLAN_ACTIONS)}")
+        if level not in PLAN_LEVELS:
+            raise ValueError(f"unknown secret level '{level}': expected {', '.join(PLAN_LEVELS)}")
+        self.action = action
+        self.level = level
+        self.location = location  # 'org/repo', 'org/repo:environment' or 'org'
+        self.name = name  # name on the target
+        self.source_name = source_name  # empty for deletions
+        self.scope = scope  # organization secrets: visibility (and selected repositories)
+        self.reason = reason  # why a secret is skipped, or how its value is rewritten
+
+    @property
+    def key(self) -> Tuple[str, str, str]:
+        """The target secret this change applies to."""
+        return self.level, self.location, self.name
+
+    def describe(self) -> str:
+        """Return the change as one line of the rendered plan."""
+        line = f"{_ACTION_SYMBOLS[self.action]} {self.level} {self.location} {self.name}"
+        if self.source_name and self.source_name != self.name:
+            line += f" (from {self.source_name})"
+        if self.scope:
+            line += f" [visibility: {self.scope}]"
+        if self.reason:
+            line += f": {self.reason}"
+        return line
+
+    def to_dict(self) -> Dict[str, str]:
+        """Return the change as saved in the plan file."""
+        return {
+            "action": self.action, "level": self.level, "location": self.location,
+            "name": self.name, "source_name": self.source_name, "scope": self.scope,
+            "reason": self.reason,
+        }
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "PlannedChange":
+        """Read a change saved by to_dict."""
+        return cls(
+            str(data["action"]), str(data["level"]), str(data["location"]), str(data["name"]),
+            str(data.get("source_name", "")), str(data.get("scope", "")),
+            str(data.get("reason", ""))
+        )
+
+
+class PlannedRun:
+    """One migration run of a plan (consolidation plans one per source repository)."""
+
+    def __init__(self, options: Dict[str, Any], changes: Sequence[PlannedChange]):
+        self.options = dict(options)
+        self.changes = list(changes)
+
+    @property
+    def title(self) -> str:
+        """Human-readable source and targets of the run."""
+        options = self.options
+        if options.get("org_to_org"):
+            return f"{options['source_org']} → {options['target_org']} (organization secrets)"
+        targets = [options.get("target_repo", "")] + list(options.get("extra_target_repos", []))
+        return (
+            f"{options['source_org']}/{options.get('source_repo', '')} → "
+            + ", ".join(f"{options['target_org']}/{repo}" for repo in targets)
+        )
+
+    def build_config(
+        self, source_pat: str, target_pat: str, state_passphrase: str = ""
+    ) -> MigrationConfig:
+        """Build the MigrationConfig this run was planned with."""
+        return MigrationConfig(
+            source_pat=source_pat, target_pat=target_pat, state_passphrase=state_passphrase,
+            **self.options
+        )
+
+
+class SavedPlan:
+    """Every run of a plan, in execution order."""
+
+    def __init__(self, runs: Sequence[PlannedRun], created_at: str = ""):
+        self.runs = list(runs)
+        self.created_at = created_at or datetime.now(timezone.utc).isoformat(timespec="seconds")
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the JSON document of the plan."""
+        return {
+            "version": PLAN_FORMAT_VERSION,
+            "created_at": self.created_at,
+            "runs": [
+                {"options": run.options, "changes": [change.to_dict() for change in run.changes]}
+                for run in self.runs
+            ],
+        }
+
+    @property
+    def digest(self) -> str:
+        """SHA-256 of the plan's content, which sign-off can refer to."""
+        content = json.dumps(self.to_dict(), sort_keys=True, separators=(",", ":"))
+        return hashlib.sha256(content.encode("utf-8")).hexdigest()
+
+
+def save_plan(path: str, plan: SavedPlan) -> None:
+    """Write a plan as JSON (secret names only, never values)."""
+    with open(path, "w", encoding="utf-8") as handle:
+        json.dump(plan.to_dict(), handle, indent=2, ensure_ascii=False)
+        handle.write("\n")
+
+
+def load_plan(path: str) -> SavedPlan:
+    """Read a plan written by save_plan.
+
+    Raises:
+        OSError: If the file cannot be read
+        ValueError: If the file is not a plan of this version, or sets
+            credentials or unknown options
+    """
+    with open(path, encoding="utf-8") as handle:
+        try:
+            data = json.load(handle)
+        except json.JSONDecodeError as e:
+            raise ValueError(f"not valid JSON: {e}")
+    if not isinstance(data, dict) or data.get("version") != PLAN_FORMAT_VERSION:
+        raise ValueError(f"not a version {PLAN_FORMAT_VERSION} migration plan")
+    allowed = set(MigrationConfig.option_names())
+    runs = []
+    for index, run in enumerate(data.get("runs") or [], start=1):
+        options = run.get("options") if isinstance(run, dict) else None
+        if not isinstance(options, dict):
+            raise ValueError(f"run #{index} has no options")
+        unknown = sorted(key for key in options if key not in allowed)
+        if unknown:
+            raise ValueError(
+                f"run #{index} sets unknown or credential option(s): {', '.join(unknown)}"
+            )
+        try:
+            changes = [PlannedChange.from_dict(change) for change in run.get("changes") or []]
+        except (KeyError, TypeError, AttributeError) as e:
+            raise ValueError(f"run #{index} has a malformed change: {e}")
+        runs.append(PlannedRun(options, changes))
+    if not runs:
+        raise ValueError("plan has no runs")
+    return SavedPlan(runs, str(data.get("created_at", "")))
+
+
+def plan_drift(planned: Sequence[PlannedChange], current: Sequence[PlannedChange]) -> List[str]:
+    """Compare a saved run's changes with the ones worked out now.
+
+    Returns:
+        One line per target secret whose change differs; empty when the plan
+        still holds
+    """
+    before = {change.key: change for change in planned}
+    after = {change.key: change for change in current}
+    drift = []
+    for key, change in before.items():
+        now = after.get(key)
+        if now is None:
+            drift.append(f"no longer planned: {change.describe()}")
+        elif now.to_dict() != change.to_dict():
+            drift.append(f"planned: {change.describe()}; now: {now.describe()}")
+    drift += [
+        f"not in the plan: {change.describe()}"
+        for key, change in after.items() if key not in before
+    ]
+    return drift
+
+
+def format_plan(plan: SavedPlan) -> List[str]:
+    """Render a plan as reviewable lines, one run after the other."""
+    lines = []
+    for run in plan.runs:
+        lines.append(f"{run.title}:")
+        lines += [f"  {change.describe()}" for change in run.changes]
+        if not run.changes:
+            lines.append("  (no secret changes)")
+    counts = {
+        action: sum(change.action == action for run in plan.runs for change in run.changes)
+        for action in PLAN_ACTIONS
+    }
+    lines.append(
+        f"{counts['create']} to create, {counts['replace']} to replace, "
+        f"{counts['delete']} to delete, {counts['skip']} skipped"
+    )
+    return lines
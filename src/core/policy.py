@@ -0,0 +1,95 @@
+"""Denylist/allowlist policy deciding which secrets may be migrated."""
+import fnmatch
+import re
+from typing import Any, Iterable, List, Optional
+import yaml
+
+# Patterns are secret-name globs; restricting the alphabet keeps them safe to
+# inject into the generated workflow's shell filter
+_PATTERN_RE = re.compile(r"^[A-Za-z0-9_*?]+$")
+
+
+def _validate_patterns(label: str, patterns: Any) -> List[str]:
+    """Check a list of glob patterns from a policy document."""
+    if patterns is None:
+        return []
+    if not isinstance(patterns, list):
+        raise ValueError(f"Policy '{label}' must be a list of secret names or patterns")
+    result = []
+    for pattern in patterns:
+        pattern = str(pattern)
+        if not _PATTERN_RE.match(pattern):
+            raise ValueError(
+                f"Invalid policy pattern '{pattern}' in '{label}': "
+                "use letters, digits, underscores and the wildcards * and ?"
+            )
+        result.append(pattern)
+    return result
+
+
+class SecretPolicy:
+    """Secret names or glob patterns that must never (deny) or may only (allow) be migrated.
+
+    Matching is case-insensitive, like GitHub secret names. Deny wins over allow;
+    an empty allowlist allows everything not denied.
+    """
+
+    def __init__(self, deny: Iterable[str] = (), allow: Iterable[str] = ()):
+        self.deny = _validate_patterns("deny", list(deny))
+        self.allow = _validate_patterns("allow", list(allow))
+
+    @property
+    def is_empty(self) -> bool:
+        """True if the policy does not restrict anything."""
+        return not self.deny and not self.allow
+
+    @staticmethod
+    def _matches(name: str, patterns: List[str]) -> Optional[str]:
+        """Return the first pattern matching the name, if any."""
+        for pattern in patterns:
+            if fnmatch.fnmatchcase(name.upper(), pattern.upper()):
+                return pattern
+        return None
+
+    def rejection_reason(self, name: str) -> Optional[str]:
+        """Return why the policy blocks a secret, or None if it may be migrated."""
+        denied_by = self._matches(name, self.deny)
+        if denied_by:
+            return f"denied by policy pattern '{denied_by}'"
+        if self.allow and not self._matches(name, self.allow):
+            return "not in policy allowlist"
+        return None
+
+    def allows(self, name: str) -> bool:
+        """Return True if the secret may be migrated."""
+        return self.rejection_reason(name) is None
+
+    def filter(self, names: Iterable[str]) -> List[str]:
+        """Keep only the secret names the policy allows."""
+        return [name for name in names if self.allows(name)]
+
+
+def parse_policy(data: Any) -> SecretPolicy:
+    """Build a policy from a loaded YAML document with optional 'deny' and 'allow' lists.
+
+    Raises:
+        ValueError: If the document is malformed
+    """
+    if data is None:
+        return SecretPolicy()
+    if not isinstance(data, dict):
+        raise ValueError("Policy file must be a mapping with 'deny' and/or 'allow' lists")
+    unknown = sorted(str(key) for key in data if key not in ("deny", "allow"))
+    if unknown:
+        raise ValueError(f"Policy file has unknown key(s): {', '.join(unknown)}")
+    return SecretPolicy(
+        _validate_patterns("deny", data.get("deny")),
+        _validate_patterns("allow", data.get("allow")),
+    )
+
+
+def load_policy(path: str) -> SecretPolicy:
+    """Load a secret policy from a YAML file."""
+    with open(path, "r", encoding="utf-8") as handle:
+        data = yaml.safe_load(handle)
+    return parse_policy(data)
@@ -0,0 +1,60 @@
+"""Preflight checks of GitHub secret quotas against the planned migration."""
+from typing import Iterable, List
+
+# GitHub Actions limits
+REPO_SECRET_LIMIT = 100
+ENVIRONMENT_SECRET_LIMIT = 100
+ORG_SECRET_LIMIT = 1000
+SECRET_VALUE_LIMIT_BYTES = 48 * 1024
+
+QUOTA_CHECK_MODES = ("fail", "warn", "off")
+
+
+class QuotaPlan:
+    """Projected secret count for one target scope (repository, environment or organization).
+
+    Secrets already on the target keep their slot; secrets new to the target
+    consume free slots in migration order, and the rest overflow.
+    """
+
+    def __init__(self, scope: str, limit: int, existing: Iterable[str], incoming: Iterable[str]):
+        self.scope = scope
+        self.limit = limit
+        existing_keys = {name.upper() for name in existing}
+        self.existing_count = len(existing_keys)
+        self.updated: List[str] = []
+        new: List[str] = []
+        for name in incoming:
+            if name.upper() in existing_keys:
+                self.updated.append(name)
+            elif name not in new:
+                new.append(name)
+        free = max(self.limit - self.existing_count, 0)
+        self.created = new[:free]
+        self.overflow = new[free:]
+
+    @property
+    def fits(self) -> bool:
+        """True if every incoming secret fits within the limit."""
+        return not self.overflow
+
+    @property
+    def projected_count(self) -> int:
+        """Number of secrets on the target scope after the migration (ignoring overflow)."""
+        return self.existing_count + len(self.created)
+
+    def describe(self) -> List[str]:
+        """Return human-readable lines describing the plan."""
+        lines = [
+            f"{self.scope}: {self.existing_count} existing, {len(self.updated)} updated, "
+            f"{len(self.created) + len(self.overflow)} new (limit {self.limit})"
+        ]
+        if self.overflow:
+            lines.append(
+                f"  {len(self.overflow)} secret(s) will not fit: {', '.join(self.overflow)}"
+            )
+            lines.append(
+                f"  Free at least {len(self.overflow)} slot(s) on the target "
+                "(e.g. delete unused secrets) or narrow the migration with --policy"
+            )
+        return lines
@@ -0,0 +1,130 @@
+"""Per-secret migration decisions from an OPA/Rego policy."""
+import json
+import shutil
+import subprocess  # nosec B404 - runs the opa binary with a fixed argv
+from typing import Any, Callable, Dict, Optional
+from src.core.naming import secret_name_error
+
+# Rule every policy defines: package secrets_migrator, rule decision
+REGO_QUERY = "data.secrets_migrator.decision"
+
+ALLOW = "allow"
+DENY = "deny"
+RENAME = "rename"
+REGO_ACTIONS = (ALLOW, DENY, RENAME)
+
+
+class RegoDecision:
+    """What a policy decided for one secret."""
+
+    def __init__(self, action: str, name: str = "", reason: str = ""):
+        self.action = action
+        self.name = name  # target name of a rename
+        self.reason = reason
+
+
+def secret_input(
+    name: str,
+    level: str,
+    source_org: str,
+    target_org: str,
+    source_repo: str = "",
+    target_repo: str = "",
+    environment: str = ""
+) -> Dict[str, Any]:
+    """Build the policy input for one secret.
+
+    Args:
+        name: Source secret name
+        level: 'repository', 'environment' or 'organization'
+        source_org: Source organization
+        target_org: Target organization
+        source_repo: Source repository ('' for organization secrets)
+        target_repo: Target repository ('' for organization secrets)
+        environment: Source environment of an environment secret
+    """
+    return {
+        "secret": {"name": name, "level": level, "environment": environment or None},
+        "source": {"org": source_org, "repo": source_repo or None},
+        "target": {"org": target_org, "repo": target_repo or None},
+    }
+
+
+def parse_decision(value: Any) -> RegoDecision:
+    """Interpret the value of the decision rule.
+
+    The rule yields 'allow' or 'deny', or an object with 'action' (allow,
+    deny or rename), 'name' (the target name of a rename) and an optional
+    'reason'. An undefined decision (None) denies the secret, so a policy
+    that forgets a case fails closed.
+
+    Raises:
+        ValueError: If the value is malformed
+    """
+    if value is None:
+        return RegoDecision(DENY, reason="policy returned no decision")
+    if isinstance(value, str):
+        value = {"action": value}
+    if not isinstance(value, dict):
+        raise ValueError(f"decision must be a string or an object, got {json.dumps(value)}")
+    action = value.get("action")
+    if action not in REGO_ACTIONS:
+        expected = ", ".join(REGO_ACTIONS)
+        raise ValueError(f"unknown decision action {json.dumps(action)}: expected {expected}")
+    reason = str(value.get("reason") or "")
+    if action != RENAME:
+        return RegoDecision(action, reason=reason)
+    name = value.get("name")
+    error = secret_name_error(name) if isinstance(name, str) else "name is missing"
+    if error:
+        raise ValueError(f"invalid rename target {json.dumps(name)}: {error}")
+    return RegoDecision(RENAME, name, reason)
+
+
+class RegoPolicy:
+    """A Rego policy file evaluated with the opa CLI, once per secret."""
+
+    def __init__(
+        self,
+        path: str,
+        opa: Optional[str] = None,
+        run: Callable[..., Any] = subprocess.run
+    ):
+        """Prepare the policy.
+
+        Args:
+            path: Rego file (or bundle directory) defining data.secrets_migrator.decision
+            opa: opa executable (looked up on PATH by default)
+            run: subprocess.run replacement (injectable for tests)
+
+        Raises:
+            ValueError: If opa is not installed
+        """
+        self.path = path
+        self.opa = opa or shutil.which("opa") or ""
+        if not self.opa:
+            raise ValueError("the opa CLI is required for Rego policies but was not found on PATH")
+        self.run = run
+
+    def evaluate(self, policy_input: Dict[str, Any]) -> RegoDecision:
+        """Evaluate the decision rule for one secret (see secret_input).
+
+        Raises:
+            ValueError: If opa fails or the decision is malformed
+        """
+        argv = [
+            self.opa, "eval", "--format", "json", "--stdin-input", "--data", self.path, REGO_QUERY
+        ]
+        result = self.run(  # nosec B603 - fixed argv
+            argv, input=json.dumps(policy_input), capture_output=True, text=True, timeout=60,
+        )
+        if result.returncode != 0:
+            detail = (result.stderr or result.stdout).strip().splitlines()
+            raise ValueError(f"opa eval failed: {detail[0] if detail else result.returncode}")
+        try:
+            output = json.loads(result.stdout or "{}")
+            expressions = output["result"][0]["expressions"] if output.get("result") else []
+            value = expressions[0]["value"] if expressions else None
+        except (ValueError, KeyError, IndexError, TypeError):
+            raise ValueError("opa eval returned unexpected output")
+        return parse_decision(value)
@@ -0,0 +1,77 @@
+"""Filters scoping org-wide repository discovery, e.g. to one wave of a migration."""
+import fnmatch
+from typing import Iterable, List, Sequence
+
+
+class RepoInfo:
+    """The attributes of an organization repository that discovery filters on."""
+
+    def __init__(
+        self,
+        name: str,
+        archived: bool = False,
+        fork: bool = False,
+        visibility: str = "private",
+        language: str = "",
+        topics: Sequence[str] = ()
+    ):
+        self.name = name
+        self.archived = archived
+        self.fork = fork
+        self.visibility = visibility
+        self.language = language or ""
+        self.topics = list(topics)
+
+
+class RepoFilter:
+    """Which discovered repositories to keep.
+
+    Archived repositories are always left out (they are read-only). Every
+    other criterion is optional; within one criterion any value matches, and
+    a repository must match all the criteria given.
+    """
+
+    def __init__(
+        self,
+        exclude_forks: bool = False,
+        topics: Sequence[str] = (),
+        visibilities: Sequence[str] = (),
+        languages: Sequence[str] = (),
+        name_patterns: Sequence[str] = ()
+    ):
+        self.exclude_forks = exclude_forks
+        self.topics = {topic.lower() for topic in topics}
+        self.visibilities = {visibility.lower() for visibility in visibilities}
+        self.languages = {language.lower() for language in languages}
+        self.name_patterns = list(name_patterns)
+
+    @property
+    def is_empty(self) -> bool:
+        """True if no criterion beyond the archived rule is set."""
+        return not (
+            self.exclude_forks or self.topics or self.visibilities or self.languages
+            or self.name_patterns
+        )
+
+    def rejection(self, repo: RepoInfo) -> str:
+        """Return why repo is left out, or '' if it is kept."""
+        if repo.archived:
+            return "archived"
+        if self.exclude_forks and repo.fork:
+            return "fork"
+        if self.visibilities and repo.visibility.lower() not in self.visibilities:
+            return f"visibility {repo.visibility}"
+        if self.languages and repo.language.lower() not in self.languages:
+            return f"language {repo.language or 'none'}"
+        if self.topics and not self.topics & {topic.lower() for topic in repo.topics}:
+            return "no matching topic"
+        name = repo.name.lower()
+        if self.name_patterns and not any(
+            fnmatch.fnmatchcase(name, pattern.lower()) for pattern in self.name_patterns
+        ):
+            return "name does not match"
+        return ""
+
+    def select(self, repos: Iterable[RepoInfo]) -> List[str]:
+        """Return the names of the repositories kept, in discovery order."""
+        return [repo.name for repo in repos if not self.rejection(repo)]
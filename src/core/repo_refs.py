@@ -0,0 +1,88 @@
+"""Repository references given as positional arguments: OWNER/REPO, HOST/OWNER/REPO or URLs."""
+import re
+from urllib.parse import urlparse
+from src.core.credentials import GITHUB_COM
+
+_NAME = re.compile(r"^[A-Za-z0-9._-]+$")
+_SCP_LIKE = re.compile(r"^[\w.-]+@([\w.-]+):(.+)$")  # git@host:owner/repo.git
+_EXPECTED = "expected OWNER/REPO, HOST/OWNER/REPO or a repository URL"
+
+
+class RepoRef:
+    """A repository (or, with an empty repo, an organization) on a GitHub host."""
+
+    def __init__(self, host: str, owner: str, repo: str = ""):
+        self.host = host
+        self.owner = owner
+        self.repo = repo
+
+    def __eq__(self, other: object) -> bool:
+        return isinstance(other, RepoRef) and (
+            (self.host, self.owner, self.repo) == (other.host, other.owner, other.repo)
+        )
+
+    def __repr__(self) -> str:
+        return f"RepoRef({self.host!r}, {self.owner!r}, {self.repo!r})"
+
+
+def normalize_host(host: str) -> str:
+    """Return host as gh names it: lowercase, without 'www.' or the 'api.' of API URLs."""
+    host = host.lower().strip().rstrip("/")
+    for prefix in ("www.", "api."):
+        if host.startswith(prefix):
+            host = host[len(prefix):]
+    return host
+
+
+def parse_repo_ref(value: str, default_host: str = GITHUB_COM) -> RepoRef:
+    """Parse a repository reference.
+
+    Accepts 'OWNER/REPO', 'HOST/OWNER/REPO' (as gh's --repo does),
+    'https://HOST/OWNER/REPO' URLs (trailing paths such as /tree/main and a
+    '.git' suffix are ignored) and 'git@HOST:OWNER/REPO.git' remotes. A bare
+    'OWNER' (or a URL naming only an owner) leaves repo empty.
+
+    Args:
+        value: Reference to parse
+        default_host: Host of references that do not name one
+
+    Raises:
+        ValueError: If value is not a repository reference
+    """
+    text = value.strip()
+    host = default_host
+    scp = _SCP_LIKE.match(text)
+    if "://" in text:
+        url = urlparse(text)
+        if url.scheme not in ("http", "https", "ssh", "git") or not url.hostname:
+            raise ValueError(f"'{value}' is not a repository reference: {_EXPECTED}")
+        host = url.hostname
+        parts = [part for part in url.path.split("/") if part][:2]
+    elif scp:
+        host = scp.group(1)
+        parts = [part for part in scp.group(2).split("/") if part]
+    else:
+        parts = text.strip("/").split("/")
+        if len(parts) == 3:
+            host = parts.pop(0)
+    if not 1 <= len(parts) <= 2:
+        raise ValueError(f"'{value}' is not a repository reference: {_EXPECTED}")
+    owner = parts[0]
+    repo = parts[1] if len(parts) == 2 else ""
+    if repo.endswith(".git"):
+        repo = repo[:-len(".git")]
+    if not _NAME.match(owner) or (repo and (not _NAME.match(repo) or repo in (".", ".."))):
+        raise ValueError(f"'{value}' is not a repository reference: {_EXPECTED}")
+    return RepoRef(normalize_host(host), owner, repo)
+
+
+def api_base_url(host: str) -> str:
+    """Return the REST API root of a GitHub host.
+
+    github.com and GHE.com tenants serve it from an 'api.' subdomain; GitHub
+    Enterprise Server appliances under /api/v3.
+    """
+    host = normalize_host(host)
+    if host == GITHUB_COM or host.endswith(".ghe.com"):
+        return f"https://api.{host}"
+    return f"https://{host}/api/v3"
@@ -0,0 +1,66 @@
+"""Mapping of environment reviewers (users and teams) from a source to a target organization."""
+from typing import Any, Dict, List, Optional
+import yaml
+
+
+class ReviewerMap:
+    """Source-to-target login and team slug mapping.
+
+    Users and teams missing from the map keep their name (the same-login
+    heuristic), which suits migrations between organizations of one
+    enterprise where logins are shared.
+    """
+
+    def __init__(
+        self,
+        users: Optional[Dict[str, str]] = None,
+        teams: Optional[Dict[str, str]] = None
+    ):
+        # Logins and slugs are case-insensitive on GitHub
+        self.users = {k.lower(): v for k, v in (users or {}).items()}
+        self.teams = {k.lower(): v for k, v in (teams or {}).items()}
+
+    def map_reviewer(self, reviewer: Dict[str, str]) -> Dict[str, str]:
+        """Return the target equivalent of a {'user': login} or {'team': slug} reviewer."""
+        if "team" in reviewer:
+            return {"team": self.teams.get(reviewer["team"].lower(), reviewer["team"])}
+        return {"user": self.users.get(reviewer["user"].lower(), reviewer["user"])}
+
+    def map_reviewers(self, reviewers: List[Dict[str, str]]) -> List[Dict[str, str]]:
+        """Map reviewers, dropping duplicates created by several sources mapping to one target."""
+        mapped: List[Dict[str, str]] = []
+        for reviewer in reviewers:
+            target = self.map_reviewer(reviewer)
+            if target not in mapped:
+                mapped.append(target)
+        return mapped
+
+
+def parse_reviewer_map(data: Any) -> ReviewerMap:
+    """Parse a reviewer map document ({'users': {...}, 'teams': {...}}).
+
+    Raises:
+        ValueError: If the document is malformed
+    """
+    if data is None:
+        return ReviewerMap()
+    if not isinstance(data, dict):
+        raise ValueError("Reviewer map must be a mapping with 'users' and/or 'teams'")
+    unknown = sorted(str(key) for key in data if key not in ("users", "teams"))
+    if unknown:
+        raise ValueError(f"Unknown reviewer map key(s): {', '.join(unknown)}")
+    sections = {}
+    for section in ("users", "teams"):
+        entries = data.get(section) or {}
+        if not isinstance(entries, dict) or not all(
+            isinstance(k, str) and isinstance(v, str) and k and v for k, v in entries.items()
+        ):
+            raise ValueError(f"'{section}' must map source names to target names")
+        sections[section] = entries
+    return ReviewerMap(sections["users"], sections["teams"])
+
+
+def load_reviewer_map(path: str) -> ReviewerMap:
+    """Load and validate a reviewer map YAML file."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return parse_reviewer_map(yaml.safe_load(handle))
@@ -0,0 +1,60 @@
+"""Safety net for runs against throwaway organizations (--sandbox).
+
+End-to-end tests and experiments run the real flow, so a mistyped name would
+write to production. With --sandbox, a run refuses to start unless every
+organization or repository it touches is designated in
+GH_SECRETS_MIGRATOR_SANDBOX: comma-separated 'org' entries (the whole
+organization is a sandbox) or 'org/repo' entries (only that repository is).
+The variable is set only where the sandboxes are, never on the command line
+being checked.
+"""
+from typing import List, Sequence
+
+SANDBOX_ENV = "GH_SECRETS_MIGRATOR_SANDBOX"
+
+
+def parse_sandbox(value: str) -> List[str]:
+    """Parse the designated sandboxes (lowercased, as GitHub names are case-insensitive).
+
+    Raises:
+        ValueError: If an entry is not 'org' or 'org/repo'
+    """
+    entries = []
+    for entry in (part.strip() for part in value.split(",")):
+        if not entry:
+            continue
+        parts = entry.split("/")
+        if len(parts) > 2 or not all(parts):
+            raise ValueError(f"{SANDBOX_ENV} entry '{entry}' must be 'org' or 'org/repo'")
+        entries.append(entry.lower())
+    return entries
+
+
+def sandbox_locations(
+    source_org: str, source_repo: str, target_org: str, target_repos: Sequence[str],
+    org_to_org: bool
+) -> List[str]:
+    """Return what a run writes to: both organizations, or the source and target repositories."""
+    if org_to_org:
+        return [source_org, target_org]
+    return [f"{source_org}/{source_repo}"] + [f"{target_org}/{repo}" for repo in target_repos]
+
+
+def check_sandbox(locations: Sequence[str], sandboxes: Sequence[str]) -> None:
+    """Reject a sandboxed run touching anything not designated as a sandbox.
+
+    Raises:
+        ValueError: If no sandbox is designated or a location is outside them
+    """
+    if not sandboxes:
+        raise ValueError(
+            f"--sandbox needs {SANDBOX_ENV} to name the throwaway organizations or repositories"
+        )
+    outside = [
+        location for location in locations
+        if location.lower() not in sandboxes and location.split("/")[0].lower() not in sandboxes
+    ]
+    if outside:
+        raise ValueError(
+            f"--sandbox refuses to touch {', '.join(outside)}: not designated in {SANDBOX_ENV}"
+        )
@@ -0,0 +1,29 @@
+"""Organization secret visibility scopes."""
+from typing import Dict, Iterable, List
+
+ORG_SECRET_VISIBILITIES = ("all", "private", "selected")
+
+
+class OrgSecretScope:
+    """Who can use an organization secret: a visibility and, if 'selected', which repositories."""
+
+    def __init__(self, visibility: str, repositories: Iterable[str] = ()):
+        if visibility not in ORG_SECRET_VISIBILITIES:
+            raise ValueError(f"Unknown organization secret visibility: {visibility}")
+        self.visibility = visibility
+        self.repositories = sorted(set(repositories)) if visibility == "selected" else []
+
+
+def scope_discrepancies(intended: Iterable[str], actual: Iterable[str]) -> Dict[str, List[str]]:
+    """Compare the intended selected-repository list with what the API reports.
+
+    Returns:
+        Dict with sorted 'missing' (intended but not applied) and 'unexpected'
+        (applied but not intended) repository names; both empty when in sync
+    """
+    intended_set = set(intended)
+    actual_set = set(actual)
+    return {
+        "missing": sorted(intended_set - actual_set),
+        "unexpected": sorted(actual_set - intended_set),
+    }
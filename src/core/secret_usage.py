@@ -0,0 +1,176 @@
+"""Detection of the secrets a repository's workflows actually reference."""
+import re
+from typing import Any, Dict, Iterable, List, Tuple
+import yaml
+from src.core.inventory import SecretRecord
+from src.core.shared_repos import WORKFLOWS_DIR
+
+# secrets.NAME, secrets['NAME'] and secrets["NAME"]; expressions are case-insensitive
+_REFERENCE = re.compile(
+    r"\bsecrets\s*(?:\.\s*([A-Za-z_][A-Za-z0-9_]*)|\[\s*['\"]([A-Za-z_][A-Za-z0-9_]*)['\"]\s*\])",
+    re.IGNORECASE,
+)
+# Forms handing every secret to code the scan cannot see
+_TO_JSON = re.compile(r"\btoJSON\s*\(\s*secrets\s*\)", re.IGNORECASE)
+
+USAGE_FORMATS = ("text", "json", "csv")
+
+# Workflows the migrator itself generates, which read every secret by design
+GENERATED_WORKFLOWS = (
+    f"{WORKFLOWS_DIR}/migrate-secrets.yml",
+    f"{WORKFLOWS_DIR}/migrate-org-secrets.yml",
+)
+
+
+class SecretUsage:
+    """Secret references found in a repository's workflow files."""
+
+    def __init__(self):
+        # Upper-cased secret name -> workflow paths referencing it
+        self.references: Dict[str, List[str]] = {}
+        # Workflows passing all secrets on (toJSON(secrets), or secrets: inherit to a
+        # workflow in another repository), so any secret may be in use
+        self.wholesale: List[str] = []
+
+    def add(self, name: str, path: str) -> None:
+        """Record that a workflow references a secret."""
+        paths = self.references.setdefault(name.upper(), [])
+        if path not in paths:
+            paths.append(path)
+
+    def is_used(self, name: str) -> bool:
+        """Return True if a workflow references the secret (names are case-insensitive)."""
+        return name.upper() in self.references
+
+    def split(self, names: Iterable[str]) -> Tuple[List[str], List[str]]:
+        """Split secret names into (used, orphaned), keeping their order."""
+        used, orphaned = [], []
+        for name in names:
+            (used if self.is_used(name) else orphaned).append(name)
+        return used, orphaned
+
+
+def _passes_all_secrets(text: str) -> bool:
+    """Return True if a workflow exposes every secret to something outside the repository."""
+    if _TO_JSON.search(text):
+        return True
+    try:
+        data = yaml.safe_load(text)
+    except yaml.YAMLError:
+        return False
+    jobs = data.get("jobs") if isinstance(data, dict) else None
+    if not isinstance(jobs, dict):
+        return False
+    # Local reusable workflows are scanned themselves; those in other repositories are not
+    return any(
+        isinstance(job, dict) and job.get("secrets") == "inherit"
+        and not str(job.get("uses", "")).startswith("./")
+        for job in jobs.values()
+    )
+
+
+def scan_workflows(files: Dict[str, str]) -> SecretUsage:
+    """Collect the secret references of the workflow files among files.
+
+    Args:
+        files: File contents keyed by repository path (non-workflow files are ignored)
+    """
+    usage = SecretUsage()
+    for path in sorted(files):
+        if not path.startswith(f"{WORKFLOWS_DIR}/") or path in GENERATED_WORKFLOWS:
+            continue
+        text = files[path]
+        for dotted, indexed in _REFERENCE.findall(text):
+            usage.add(dotted or indexed, path)
+        if _passes_all_secrets(text):
+            usage.wholesale.append(path)
+    return usage
+
+
+def _consumers(usage: SecretUsage, name: str) -> List[str]:
+    return list(usage.references.get(name.upper(), []))
+
+
+def build_usage_report(
+    org: str,
+    repositories: Dict[str, Tuple[List[SecretRecord], SecretUsage]],
+    org_secrets: Iterable[str] = ()
+) -> Dict[str, Any]:
+    """Cross-reference secrets with the workflows consuming them.
+
+    A reference resolves to the repository's own secret of that name, then
+    to an organization secret; references resolving to neither are listed
+    as unresolved (GITHUB_TOKEN is provided by Actions and skipped).
+
+    Args:
+        org: Organization the repositories belong to
+        repositories: Repository and environment secrets plus the workflow scan, by repository
+        org_secrets: Organization secret names (empty when not requested)
+
+    Returns:
+        JSON-serializable report
+    """
+    org_names = list(org_secrets)
+    org_keys = {name.upper() for name in org_names}
+    org_consumers: Dict[str, List[Dict[str, Any]]] = {name.upper(): [] for name in org_names}
+    repo_entries = []
+    for repo in sorted(repositories):
+        records, usage = repositories[repo]
+        secrets = [
+            {
+                "secret": record.name,
+                "level": record.level,
+                "environment": record.environment or None,
+                "workflows": _consumers(usage, record.name),
+            }
+            for record in sorted(records, key=lambda record: record.key)
+        ]
+        defined = {record.name.upper() for record in records if record.level == "repo"}
+        unresolved = []
+        for name in sorted(usage.references):
+            if name in defined or name == "GITHUB_TOKEN":
+                continue
+            if name in org_keys:
+                org_consumers[name].append({"repo": repo, "workflows": _consumers(usage, name)})
+            elif not any(record.name.upper() == name for record in records):
+                unresolved.append({"secret": name, "workflows": _consumers(usage, name)})
+        repo_entries.append({
+            "repo": repo,
+            "secrets": secrets,
+            "unresolved": unresolved,
+            "passes_all_secrets": list(usage.wholesale),
+        })
+    return {
+        "organization": org,
+        "repositories": repo_entries,
+        "organization_secrets": [
+            {"secret": name, "consumers": org_consumers[name.upper()]} for name in sorted(org_names)
+        ],
+    }
+
+
+def format_usage_report(report: Dict[str, Any]) -> List[str]:
+    """Render a usage report as text lines."""
+    lines = []
+    for entry in report["repositories"]:
+        lines.append(f"{report['organization']}/{entry['repo']}")
+        if not entry["secrets"] and not entry["unresolved"]:
+            lines.append("  (no secrets)")
+        for secret in entry["secrets"]:
+            label = secret["secret"]
+            if secret["environment"]:
+                label = f"env:{secret['environment']}/{label}"
+            lines.append(f"  {label}: {', '.join(secret['workflows']) or 'unused'}")
+        for secret in entry["unresolved"]:
+            lines.append(f"  ? {secret['secret']} (not defined): {', '.join(secret['workflows'])}")
+        for path in entry["passes_all_secrets"]:
+            lines.append(f"  ⚠ {path} passes every secret on; any secret may be in use")
+    if report["organization_secrets"]:
+        lines.append(f"{report['organization']} (organization secrets)")
+    for secret in report["organization_secrets"]:
+        consumers = [
+            f"{consumer['repo']} ({', '.join(consumer['workflows'])})"
+            for consumer in secret["consumers"]
+        ]
+        lines.append(f"  {secret['secret']}: {'; '.join(consumers) or 'unused'}")
+    return lines
@@ -0,0 +1,65 @@
+"""Detection and ordering of repositories hosting shared workflows and actions."""
+from typing import Any, Dict, List
+import yaml
+
+WORKFLOWS_DIR = ".github/workflows"
+ACTION_FILES = ("action.yml", "action.yaml")
+
+# Ordering ranks: organization secrets first, then shared repositories, then consumers
+_RANK_ORG = 0
+_RANK_SHARED = 1
+_RANK_CONSUMER = 2
+
+
+def _load(text: str) -> Any:
+    try:
+        return yaml.safe_load(text)
+    except yaml.YAMLError:
+        return None
+
+
+def is_reusable_workflow(text: str) -> bool:
+    """Return True if a workflow file can be called from other workflows."""
+    data = _load(text)
+    if not isinstance(data, dict):
+        return False
+    # YAML 1.1 parses a bare 'on' key as boolean True
+    triggers = data.get("on", data.get(True))
+    if isinstance(triggers, str):
+        return triggers == "workflow_call"
+    if isinstance(triggers, (list, dict)):
+        return "workflow_call" in triggers
+    return False
+
+
+def is_composite_action(text: str) -> bool:
+    """Return True if an action metadata file defines a composite action."""
+    data = _load(text)
+    if not isinstance(data, dict) or not isinstance(data.get("runs"), dict):
+        return False
+    return data["runs"].get("using") == "composite"
+
+
+def shared_automation(files: Dict[str, str]) -> List[str]:
+    """Return the paths among files that other repositories can consume.
+
+    Args:
+        files: Workflow and action metadata files, keyed by repository path
+
+    Returns:
+        Sorted paths of reusable workflows and composite actions
+    """
+    shared = []
+    for path, text in files.items():
+        if path.startswith(f"{WORKFLOWS_DIR}/") and is_reusable_workflow(text):
+            shared.append(path)
+        elif path.rsplit("/", 1)[-1] in ACTION_FILES and is_composite_action(text):
+            shared.append(path)
+    return sorted(shared)
+
+
+def shared_first_rank(org_to_org: bool, shared: bool) -> int:
+    """Return the position class of a job when shared repositories go first."""
+    if org_to_org:
+        return _RANK_ORG
+    return _RANK_SHARED if shared else _RANK_CONSUMER
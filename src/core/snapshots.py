@@ -0,0 +1,84 @@
+"""Point-in-time inventories of a migration target, taken before the first write."""
+import json
+import os
+import re
+from datetime import datetime, timezone
+from typing import Any, Dict, List, Optional
+from src.core.inventory import SecretRecord
+from src.core.scopes import OrgSecretScope
+from src.core.state_crypto import StateCipher, write_state
+
+SNAPSHOT_SCHEMA_VERSION = 1
+DEFAULT_STATE_DIR = ".gh-secrets-migrator"
+STATE_DIR_ENV = "GH_SECRETS_MIGRATOR_STATE_DIR"
+
+_UNSAFE_PATH_CHARS = re.compile(r"[^A-Za-z0-9._-]")
+
+
+def build_snapshot(
+    target: str,
+    secrets: List[SecretRecord],
+    environments: List[str],
+    org_scopes: Optional[Dict[str, OrgSecretScope]] = None,
+    taken_at: Optional[datetime] = None
+) -> Dict[str, Any]:
+    """Build the snapshot document of a target repository or organization.
+
+    Records names, levels, environments, organization visibilities/selected
+    repositories and last-update times; secret values are never readable.
+
+    Args:
+        target: 'org/repo' or 'org'
+        secrets: Secret records of the target
+        environments: Environment names of the target repository
+        org_scopes: Scopes of organization secrets by name
+        taken_at: Snapshot time (defaults to now)
+    """
+    taken_at = taken_at or datetime.now(timezone.utc)
+    scopes = org_scopes or {}
+    entries = []
+    for record in sorted(secrets, key=lambda item: item.key):
+        entry: Dict[str, Any] = {"name": record.name, "level": record.level}
+        if record.environment:
+            entry["environment"] = record.environment
+        if record.visibility:
+            entry["visibility"] = record.visibility
+        if record.name in scopes and scopes[record.name].visibility == "selected":
+            entry["selected_repositories"] = scopes[record.name].repositories
+        if record.updated_at:
+            entry["updated_at"] = record.updated_at.isoformat()
+        entries.append(entry)
+    return {
+        "schema_version": SNAPSHOT_SCHEMA_VERSION,
+        "target": target,
+        "taken_at": taken_at.isoformat(),
+        "environments": sorted(environments),
+        "secrets": entries,
+    }
+
+
+def snapshot_path(state_dir: str, target: str, taken_at: datetime) -> str:
+    """Return where the snapshot of target taken at taken_at is stored.
+
+    Layout: <state_dir>/snapshots/<org>__<repo>/<UTC timestamp>.json
+    """
+    folder = _UNSAFE_PATH_CHARS.sub("_", target.replace("/", "__"))
+    stamp = taken_at.astimezone(timezone.utc).strftime("%Y%m%dT%H%M%S%fZ")
+    return os.path.join(state_dir, "snapshots", folder, f"{stamp}.json")
+
+
+def write_snapshot(
+    state_dir: str, snapshot: Dict[str, Any], cipher: Optional[StateCipher] = None
+) -> str:
+    """Write a snapshot under state_dir (encrypted with cipher, if given) and return its path.
+
+    Raises:
+        OSError: If the file cannot be written
+        ValueError: If encryption fails
+    """
+    path = snapshot_path(
+        state_dir, snapshot["target"], datetime.fromisoformat(snapshot["taken_at"])
+    )
+    os.makedirs(os.path.dirname(path), exist_ok=True)
+    write_state(path, json.dumps(snapshot, indent=2) + "\n", cipher)
+    return path
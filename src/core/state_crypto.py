@@ -0,0 +1,195 @@
+"""Encryption at rest of state files (snapshots, ledger, migration state).
+
+They hold no secret values, but enumerate secret names and scope mappings,
+which count as sensitive metadata. Encrypted files start with a marker line
+naming the scheme, so plaintext files written before encryption was turned
+on still load.
+"""
+import os
+import shutil
+import subprocess  # nosec B404 - runs the age binary with a fixed argv
+from typing import Any, Callable, List, Optional, Sequence
+
+STATE_PASSPHRASE_ENV = "GH_SECRETS_MIGRATOR_STATE_PASSPHRASE"
+
+_MARKER = b"gh-secrets-migrator encrypted state v1 "
+PASSPHRASE_SCHEME = "passphrase"
+AGE_SCHEME = "age"
+
+
+class StateCipher:
+    """Encrypts and decrypts state file contents."""
+
+    scheme = ""
+
+    def encrypt(self, data: bytes) -> bytes:
+        """Return the ciphertext of data."""
+        raise NotImplementedError
+
+    def decrypt(self, data: bytes) -> bytes:
+        """Return the plaintext of data.
+
+        Raises:
+            ValueError: If data cannot be decrypted
+        """
+        raise NotImplementedError
+
+
+class PassphraseCipher(StateCipher):
+    """XSalsa20-Poly1305 (NaCl secretbox) with an Argon2id key derived from a passphrase."""
+
+    scheme = PASSPHRASE_SCHEME
+
+    def __init__(self, passphrase: str):
+        if not passphrase:
+            raise ValueError("the state passphrase must not be empty")
+        try:
+            import nacl.pwhash  # noqa: F401 - PyNaCl ships with PyGithub
+        except ImportError:
+            raise ValueError("passphrase-encrypted state needs PyNaCl (pip install pynacl)")
+        self.passphrase = passphrase.encode("utf-8")
+
+    def _box(self, salt: bytes) -> Any:
+        from nacl import pwhash, secret
+        key = pwhash.argon2id.kdf(
+            secret.SecretBox.KEY_SIZE, self.passphrase, salt,
+            opslimit=pwhash.argon2id.OPSLIMIT_MODERATE, memlimit=pwhash.argon2id.MEMLIMIT_MODERATE
+        )
+        return secret.SecretBox(key)
+
+    def encrypt(self, data: bytes) -> bytes:
+        from nacl import pwhash
+        salt = os.urandom(pwhash.argon2id.SALTBYTES)
+        return salt + bytes(self._box(salt).encrypt(data))
+
+    def decrypt(self, data: bytes) -> bytes:
+        from nacl import exceptions, pwhash
+        salt, box = data[:pwhash.argon2id.SALTBYTES], data[pwhash.argon2id.SALTBYTES:]
+        try:
+            return self._box(salt).decrypt(box)
+        except exceptions.CryptoError:
+            raise ValueError("wrong passphrase, or the file was modified")
+
+
+class AgeCipher(StateCipher):
+    """age encryption to one or more recipients, using the age CLI."""
+
+    scheme = AGE_SCHEME
+
+    def __init__(
+        self,
+        recipients: Sequence[str] = (),
+        identity: str = "",
+        age: Optional[str] = None,
+        run: Callable[..., Any] = subprocess.run
+    ):
+        """Prepare the cipher.
+
+        Args:
+            recipients: age public keys files are encrypted to (needed to write)
+            identity: age identity file (needed to read)
+            age: age executable (looked up on PATH by default)
+            run: subprocess.run replacement (injectable for tests)
+
+        Raises:
+            ValueError: If age is not installed
+        """
+        self.recipients = list(recipients)
+        self.identity = identity
+        self.age = age or shutil.which("age") or ""
+        if not self.age:
+            raise ValueError(
+                "the age CLI is required for age-encrypted state but was not found on PATH"
+            )
+        self.run = run
+
+    def _age(self, argv: List[str], data: bytes) -> bytes:
+        result = self.run(  # nosec B603 - fixed argv
+            [self.age] + argv, input=data, capture_output=True, timeout=60,
+        )
+        if result.returncode != 0:
+            detail = (result.stderr or b"").decode("utf-8", "replace").strip().splitlines()
+            raise ValueError(f"age failed: {detail[0] if detail else result.returncode}")
+        return result.stdout
+
+    def encrypt(self, data: bytes) -> bytes:
+        if not self.recipients:
+            raise ValueError("writing age-encrypted state needs at least one recipient")
+        argv = ["--encrypt"]
+        for recipient in self.recipients:
+            argv += ["--recipient", recipient]
+        return self._age(argv, data)
+
+    def decrypt(self, data: bytes) -> bytes:
+        if not self.identity:
+            raise ValueError("reading age-encrypted state needs an identity file")
+        return self._age(["--decrypt", "--identity", self.identity], data)
+
+
+def make_state_cipher(
+    passphrase: str = "", age_recipients: Sequence[str] = (), age_identity: str = ""
+) -> Optional[StateCipher]:
+    """Return the cipher the options select, or None to keep state in plaintext.
+
+    Raises:
+        ValueError: If both a passphrase and age keys are given, or age is missing
+    """
+    if passphrase and (age_recipients or age_identity):
+        raise ValueError("use either a state passphrase or age keys, not both")
+    if passphrase:
+        return PassphraseCipher(passphrase)
+    if age_recipients or age_identity:
+        return AgeCipher(age_recipients, age_identity)
+    return None
+
+
+def encode_state(text: str, cipher: Optional[StateCipher]) -> bytes:
+    """Return the file contents storing text, encrypted when a cipher is given."""
+    data = text.encode("utf-8")
+    if cipher is None:
+        return data
+    return _MARKER + cipher.scheme.encode("ascii") + b"\n" + cipher.encrypt(data)
+
+
+def decode_state(data: bytes, cipher: Optional[StateCipher]) -> str:
+    """Return the text stored in file contents, decrypting them if needed.
+
+    Raises:
+        ValueError: If the file is encrypted and cannot be decrypted with cipher
+    """
+    if not data.startswith(_MARKER):
+        return data.decode("utf-8")
+    header, _, payload = data[len(_MARKER):].partition(b"\n")
+    scheme = header.decode("ascii", "replace")
+    if cipher is None:
+        hint = (
+            f"set {STATE_PASSPHRASE_ENV}" if scheme == PASSPHRASE_SCHEME
+            else "pass --state-age-identity"
+        )
+        raise ValueError(f"file is encrypted ({scheme}); {hint} to read it")
+    if scheme != cipher.scheme:
+        raise ValueError(f"file is encrypted with {scheme}, not {cipher.scheme}")
+    return cipher.decrypt(payload).decode("utf-8")
+
+
+def read_state(path: str, cipher: Optional[StateCipher]) -> str:
+    """Read a state file, decrypting it if needed.
+
+    Raises:
+        OSError: If the file cannot be read
+        ValueError: If it cannot be decrypted
+    """
+    with open(path, "rb") as handle:
+        return decode_state(handle.read(), cipher)
+
+
+def write_state(path: str, text: str, cipher: Optional[StateCipher]) -> None:
+    """Write a state file, encrypted when a cipher is given.
+
+    Raises:
+        OSError: If the file cannot be written
+        ValueError: If encryption fails
+    """
+    data = encode_state(text, cipher)
+    with open(path, "wb") as handle:
+        handle.write(data)
@@ -0,0 +1,159 @@
+"""Where a migration stands: source branch and workflow run, and the target's contents."""
+from typing import Any, Dict, List, Optional
+from src.core.migration_state import MigrationRecord
+
+STATUS_FORMATS = ("text", "json")
+
+# Secrets the migrator creates in the source repository for the workflow's lifetime
+TEMPORARY_SECRETS = ("SECRETS_MIGRATOR_TARGET_PAT", "SECRETS_MIGRATOR_SOURCE_PAT")
+
+
+class WorkflowRunInfo:
+    """The latest run of a migration workflow."""
+
+    def __init__(
+        self,
+        run_id: int,
+        status: str,
+        conclusion: Optional[str] = None,
+        url: str = "",
+        created_at: str = ""
+    ):
+        self.run_id = run_id
+        self.status = status  # 'queued', 'in_progress', 'completed', ...
+        self.conclusion = conclusion  # set once completed ('success', 'failure', ...)
+        self.url = url
+        self.created_at = created_at
+
+
+class MigrationStatus:
+    """Observed state of one migration, read from both sides."""
+
+    def __init__(
+        self,
+        source: str,
+        branch: str,
+        workflow_path: str,
+        target: str,
+        branch_exists: bool = False,
+        workflow_exists: bool = False,
+        temporary_secrets: Optional[List[str]] = None,
+        run: Optional[WorkflowRunInfo] = None,
+        target_secrets: Optional[List[str]] = None,
+        recorded: Optional[MigrationRecord] = None
+    ):
+        self.source = source  # 'org/repo'
+        self.branch = branch
+        self.workflow_path = workflow_path
+        self.target = target  # 'org/repo', or 'org' for organization secrets
+        self.branch_exists = branch_exists
+        self.workflow_exists = workflow_exists
+        self.temporary_secrets = list(temporary_secrets or [])
+        self.run = run
+        self.target_secrets = list(target_secrets or [])  # labels, e.g. 'repo:NAME'
+        self.recorded = recorded  # latest migration in the state file, if any
+
+    @property
+    def state(self) -> str:
+        """One of 'not-started', 'pushed', the run's status, or 'completed'."""
+        if self.run is not None:
+            return self.run.status
+        return "pushed" if self.branch_exists else "not-started"
+
+    @property
+    def active(self) -> bool:
+        """True while the workflow is pushed but its run has not completed."""
+        return self.state not in ("not-started", "completed")
+
+    def warnings(self) -> List[str]:
+        """Leftovers that need attention once no run is active."""
+        if self.active:
+            return []
+        found = []
+        if self.temporary_secrets:
+            found.append(
+                f"temporary secret(s) still in {self.source}: {', '.join(self.temporary_secrets)} "
+                "(delete them; the workflow run did not clean up)"
+            )
+        if self.branch_exists and self.run is not None:
+            found.append(f"migration branch '{self.branch}' still exists in {self.source}")
+        if self.recorded is not None:
+            missing = [
+                label for label, outcome in self.recorded.outcome_labels()
+                if outcome in ("failed", "unconfirmed")
+            ]
+            if missing:
+                found.append(
+                    f"{len(missing)} secret(s) not set by the recorded run: {', '.join(missing)}"
+                )
+        return found
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return a JSON-serializable summary."""
+        run = None
+        if self.run is not None:
+            run = {
+                "id": self.run.run_id,
+                "status": self.run.status,
+                "conclusion": self.run.conclusion,
+                "url": self.run.url,
+                "created_at": self.run.created_at,
+            }
+        recorded = None
+        if self.recorded is not None:
+            recorded = {
+                "started_at": self.recorded.started_at,
+                "status": self.recorded.status,
+                "secrets": [
+                    {"secret": label, "outcome": outcome}
+                    for label, outcome in self.recorded.outcome_labels()
+                ],
+            }
+        return {
+            "source": self.source,
+            "target": self.target,
+            "state": self.state,
+            "branch": {"name": self.branch, "exists": self.branch_exists},
+            "workflow": {"path": self.workflow_path, "exists": self.workflow_exists},
+            "run": run,
+            "temporary_secrets": self.temporary_secrets,
+            "target_secrets": self.target_secrets,
+            "recorded": recorded,
+            "warnings": self.warnings(),
+        }
+
+
+def format_status(status: MigrationStatus) -> List[str]:
+    """Render a migration status as text lines."""
+    if status.run is None:
+        run = "none"
+    else:
+        run = status.run.status.replace("_", " ")
+        if status.run.conclusion:
+            run += f" ({status.run.conclusion})"
+        if status.run.created_at:
+            run += f", started {status.run.created_at}"
+        if status.run.url:
+            run += f" - {status.run.url}"
+    if status.branch_exists:
+        workflow = "present" if status.workflow_exists else "missing"
+        branch = f"{status.branch} (exists, workflow {workflow})"
+    else:
+        branch = f"{status.branch} (absent)"
+    lines = [
+        f"Source:        {status.source}",
+        f"Branch:        {branch}",
+        f"Workflow run:  {run}",
+        f"State:         {status.state.replace('_', ' ')}",
+        f"Target:        {status.target} ({len(status.target_secrets)} secret(s))",
+    ]
+    lines.extend(f"  - {label}" for label in status.target_secrets)
+    if status.recorded is not None:
+        outcomes = status.recorded.outcome_labels()
+        lines.append(
+            f"Recorded:      {status.recorded.status}, started {status.recorded.started_at} "
+            f"({len(outcomes)} secret(s) planned)"
+        )
+        lines.extend(f"  - {label}: {outcome}" for label, outcome in outcomes)
+    lines.extend(f"⚠ {warning}" for warning in status.warnings())
+    return lines
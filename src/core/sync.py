@@ -0,0 +1,37 @@
+"""Incremental sync: which secrets changed on the source since the last recorded run."""
+from datetime import datetime, timedelta, timezone
+from typing import Optional
+from src.core.migration_state import MigrationStateFile
+
+# Margin absorbing the one-second resolution of update times and HTTP Date headers
+SYNC_TOLERANCE = timedelta(seconds=2)
+
+
+def _parse_time(value: str) -> Optional[datetime]:
+    """Parse an ISO 8601 time as an aware datetime (naive times are UTC), or None."""
+    try:
+        parsed = datetime.fromisoformat(value)
+    except (TypeError, ValueError):
+        return None
+    return parsed if parsed.tzinfo else parsed.replace(tzinfo=timezone.utc)
+
+
+def last_completed_start(state: MigrationStateFile) -> Optional[datetime]:
+    """Return when the most recent completed migration started, or None if none completed.
+
+    Failed and cancelled runs are passed over: what they set is unknown.
+    """
+    for record in reversed(state.migrations):
+        if record.status == "completed":
+            return _parse_time(record.started_at)
+    return None
+
+
+def updated_since(updated_at: str, since: datetime) -> bool:
+    """Return True unless updated_at (ISO 8601) is known and earlier than since.
+
+    A secret whose update time is unknown counts as updated, so it is never
+    left out by mistake.
+    """
+    stamp = _parse_time(updated_at) if updated_at else None
+    return stamp is None or stamp >= since
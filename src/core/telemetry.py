@@ -0,0 +1,75 @@
+"""Opt-in anonymous usage statistics built from a run's event log."""
+import platform
+from collections import Counter
+from typing import Any, Dict
+from src.core.events import EventLog
+from src.utils.http import post_json
+
+TELEMETRY_SCHEMA_VERSION = 1
+TELEMETRY_URL_ENV = "GH_SECRETS_MIGRATOR_TELEMETRY_URL"
+
+# Event kinds whose occurrences are counted; anything else is never reported
+COUNTED_EVENT_KINDS = (
+    "conflict",
+    "environment_created",
+    "placeholder_created",
+    "pruned",
+    "skipped",
+    "warning",
+    "error",
+)
+
+
+def build_telemetry_payload(events: EventLog, command: str, duration: float) -> Dict[str, Any]:
+    """Aggregate a run's events into the anonymous telemetry payload.
+
+    Only counts, durations and exception class names are included; secret,
+    repository, environment and organization names never leave the event log.
+    The schema is documented in the README and versioned by schema_version.
+
+    Args:
+        events: Event log of the finished run
+        command: CLI command that ran ('migrate' or 'pipeline')
+        duration: Wall-clock duration of the run in seconds
+    """
+    runs: Counter = Counter()
+    secrets: Counter = Counter()
+    kinds: Counter = Counter()
+    error_classes: Counter = Counter()
+    environments = 0
+    for event in events.events:
+        if event.kind == "run_started":
+            runs["total"] += 1
+            if event.data.get("org_to_org"):
+                runs["org_to_org"] += 1
+        elif event.kind == "run_failed":
+            runs["failed"] += 1
+            error_classes[str(event.data.get("error_class", "unknown"))] += 1
+        elif event.kind == "decision" and event.data.get("level") in ("repo", "env", "org"):
+            secrets[event.data["level"]] += len(event.data.get("secrets", []))
+            environments += len(event.data.get("environments", []))
+        elif event.kind in COUNTED_EVENT_KINDS:
+            kinds[event.kind] += 1
+
+    return {
+        "schema_version": TELEMETRY_SCHEMA_VERSION,
+        "command": command,
+        "python_version": ".".join(platform.python_version_tuple()[:2]),
+        "platform": platform.system().lower(),
+        "duration_seconds": round(duration, 3),
+        "runs": {key: runs[key] for key in ("total", "failed", "org_to_org")},
+        "secrets": {key: secrets[key] for key in ("repo", "env", "org")},
+        "environments": environments,
+        "events": {kind: kinds[kind] for kind in COUNTED_EVENT_KINDS},
+        "error_classes": dict(sorted(error_classes.items())),
+    }
+
+
+def send_telemetry(url: str, payload: Dict[str, Any], timeout: float = 5.0) -> None:
+    """POST the telemetry payload as JSON.
+
+    Raises:
+        ValueError: If the URL is not https
+        OSError: If the endpoint cannot be reached or rejects the payload
+    """
+    post_json(url, payload, timeout=timeout)
@@ -0,0 +1,84 @@
+"""Minimum-permission templates for fine-grained personal access tokens."""
+from typing import Dict, List, Tuple
+from urllib.parse import urlencode
+
+TOKEN_ROLES = ("source", "target")
+FINE_GRAINED_PAT_URL = "https://github.com/settings/personal-access-tokens/new"
+DEFAULT_EXPIRES_IN_DAYS = 7
+MAX_EXPIRES_IN_DAYS = 366
+
+# Repository permissions for the repository hosting the migration workflow:
+# temporary secrets, the migration branch and workflow file, and run lookups
+_WORKFLOW_HOST_PERMISSIONS = {
+    "actions": "read",
+    "contents": "write",
+    "secrets": "write",
+    "workflows": "write",
+}
+
+_PERMISSIONS = {
+    ("source", False): dict(_WORKFLOW_HOST_PERMISSIONS, environments="read"),
+    ("source", True): dict(_WORKFLOW_HOST_PERMISSIONS, organization_secrets="read"),
+    # Creating environments requires repository administration
+    ("target", False): {"administration": "write", "environments": "write", "secrets": "write"},
+    ("target", True): {"organization_secrets": "write"},
+}
+
+_REASONS = {
+    "actions": "look up the migration workflow run",
+    "administration": "recreate environments",
+    "contents": "create the migration branch and workflow file",
+    "environments": "list or write environment secrets",
+    "organization_secrets": "read or write organization secrets",
+    "secrets": "write repository secrets",
+    "workflows": "push the migration workflow",
+}
+
+
+def required_permissions(role: str, org_to_org: bool = False) -> Dict[str, str]:
+    """Return the minimum fine-grained permissions (name -> access) for a token role.
+
+    Raises:
+        ValueError: If the role is unknown
+    """
+    if role not in TOKEN_ROLES:
+        raise ValueError(f"Unknown token role '{role}': expected one of {', '.join(TOKEN_ROLES)}")
+    return dict(sorted(_PERMISSIONS[(role, org_to_org)].items()))
+
+
+def describe_permissions(role: str, org_to_org: bool = False) -> List[Tuple[str, str, str]]:
+    """Return (permission, access, reason) rows for display."""
+    return [
+        (name, access, _REASONS[name])
+        for name, access in required_permissions(role, org_to_org).items()
+    ]
+
+
+def token_template_url(
+    role: str,
+    owner: str,
+    org_to_org: bool = False,
+    expires_in: int = DEFAULT_EXPIRES_IN_DAYS
+) -> str:
+    """Build the fine-grained PAT creation URL pre-filled for a migration role.
+
+    Args:
+        role: 'source' (extract side) or 'target' (write side)
+        owner: Organization or user that owns the resources
+        org_to_org: Whether the token is for an organization-to-organization migration
+        expires_in: Token lifetime in days
+
+    Raises:
+        ValueError: If the role or expiration is invalid
+    """
+    if not 1 <= expires_in <= MAX_EXPIRES_IN_DAYS:
+        raise ValueError(f"Expiration must be between 1 and {MAX_EXPIRES_IN_DAYS} days")
+    mode = "org-to-org" if org_to_org else "repo-to-repo"
+    params = {
+        "name": f"secrets-migrator-{role}-{owner}",
+        "description": f"gh-secrets-migrator {role} token ({mode}); revoke after the migration",
+        "target_name": owner,
+        "expires_in": str(expires_in),
+    }
+    params.update(required_permissions(role, org_to_org))
+    return f"{FINE_GRAINED_PAT_URL}?{urlencode(params)}"
@@ -0,0 +1,66 @@
+"""Follow-up checklist issue opened on the target after a migration."""
+from typing import Dict, Iterable, List, Tuple
+from src.core.events import MigrationEvent
+
+# Event kinds that need a human decision after the run
+_FOLLOW_UP_KINDS = ("conflict", "skipped", "warning")
+
+_LEVEL_LABELS = {"repo": "Repository", "env": "Environment", "org": "Organization"}
+
+
+def build_tracking_issue(
+    events: Iterable[MigrationEvent], source: str, target: str
+) -> Tuple[str, str]:
+    """Render the tracking issue title and Markdown body from a run's events.
+
+    The body lists every migrated secret, placeholders still holding a dummy
+    value, and warnings, skips and conflicts needing follow-up, each as a
+    task-list item. Secret values never appear in events, so none can leak.
+
+    Args:
+        events: Events of the run (a pipeline's log holds several runs)
+        source: Source owner (e.g. 'org/repo') shown in the title
+        target: Target owner shown in the body
+
+    Returns:
+        Tuple of (title, body)
+    """
+    renames: Dict[str, str] = {}
+    migrated: List[Tuple[str, str]] = []
+    placeholders: List[str] = []
+    follow_ups: List[str] = []
+    links: List[str] = []
+    for event in events:
+        data = event.data
+        if event.kind == "decision" and "target_name" in data:
+            renames[data["secret"]] = data["target_name"]
+        elif event.kind == "decision" and data.get("level") in _LEVEL_LABELS:
+            label = _LEVEL_LABELS[data["level"]]
+            migrated.extend((label, name) for name in data.get("secrets", []))
+        elif event.kind == "placeholder_created":
+            where = f"{data['environment']}/" if data.get("environment") else ""
+            placeholders.append(f"- [ ] Replace the placeholder value of `{where}{data['secret']}`")
+        elif event.kind in _FOLLOW_UP_KINDS:
+            follow_ups.append(f"- [ ] {event.message}")
+        elif event.kind == "link" and data.get("url"):
+            what = event.message[:1].lower() + event.message[1:]
+            links.append(f"- [ ] Review {what}: {data['url']}")
+
+    lines = [
+        f"Secrets were migrated from `{source}` to `{target}`. Values are not shown;",
+        "check each item off once it has been verified.",
+        "",
+        "## Migrated secrets",
+        "",
+    ]
+    for label, name in migrated:
+        secret = name.rsplit("/", 1)[-1]
+        renamed = f" (as `{renames[secret]}`)" if secret in renames else ""
+        lines.append(f"- [ ] {label} secret `{name}`{renamed}")
+    if not migrated:
+        lines.append("_No secrets were selected for migration._")
+    lines.extend(["", "## Placeholders to replace", ""])
+    lines.extend(placeholders or ["_None._"])
+    lines.extend(["", "## Manual follow-ups", ""])
+    lines.extend(links + follow_ups or ["_None._"])
+    return f"Secrets migration from {source}: follow-up checklist", "\n".join(lines) + "\n"
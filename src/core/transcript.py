@@ -0,0 +1,73 @@
+"""Human-readable Markdown transcript of a migration run."""
+from typing import List
+from src.core.events import EventLog, MigrationEvent
+
+# Transcript sections, in display order, and the event kinds they collect
+TRANSCRIPT_SECTIONS = (
+    ("Decisions", ("decision",)),
+    ("Conflicts resolved", ("conflict",)),
+    ("Skipped", ("skipped",)),
+    ("Warnings and errors", ("warning", "error")),
+    ("Links", ("link",)),
+)
+
+
+def _bullets(events: List[MigrationEvent]) -> List[str]:
+    lines = []
+    for event in events:
+        url = event.data.get("url")
+        if url and url not in event.message:
+            lines.append(f"- {event.message} ({url})")
+        else:
+            lines.append(f"- {event.message}")
+    return lines
+
+
+def render_transcript(events: EventLog, title: str = "Secrets migration transcript") -> str:
+    """Render the event stream as Markdown suitable for a change ticket or PR description.
+
+    The transcript is built from already-redacted events, so it never contains
+    secret values or registered tokens.
+
+    Args:
+        events: Event log recorded during the run
+        title: Heading for the transcript
+
+    Returns:
+        Markdown document
+    """
+    lines = [f"# {title}", ""]
+
+    started = events.of_kind("run_started")
+    finished = events.of_kind("run_completed", "run_failed")
+    if started:
+        lines.append(f"- **Started:** {started[0].timestamp}")
+    if finished:
+        outcome = "completed" if finished[-1].kind == "run_completed" else "failed"
+        lines.append(f"- **Finished:** {finished[-1].timestamp} ({outcome})")
+    for event in started:
+        lines.append(f"- {event.message}")
+    lines.append("")
+
+    for heading, kinds in TRANSCRIPT_SECTIONS:
+        section_events = events.of_kind(*kinds)
+        if not section_events:
+            continue
+        lines.append(f"## {heading}")
+        lines.append("")
+        lines.extend(_bullets(section_events))
+        lines.append("")
+
+    lines.append("## Timeline")
+    lines.append("")
+    for event in events.events:
+        lines.append(f"1. `{event.timestamp}` **{event.kind}** - {event.message}")
+    lines.append("")
+
+    return "\n".join(lines)
+
+
+def write_transcript(events: EventLog, path: str) -> None:
+    """Render the transcript and write it to path."""
+    with open(path, "w", encoding="utf-8") as handle:
+        handle.write(render_transcript(events))
@@ -0,0 +1,131 @@
+"""Declarative rewrites of secret values, applied by the migration workflow.
+
+Values never reach the CLI, so rules are resolved here to the secrets they
+apply to and the workflow rewrites each value before writing it to the
+target, for example to point URL-valued secrets at the new host:
+
+    rules:
+      - secrets: ["*_URL", "*_ENDPOINT"]
+        replace: ghes.corp.example
+        with: github.com
+      - secrets: ["REGISTRY_*"]
+        prefix: registry.corp.example/
+        with: ghcr.io/acme/
+
+'replace' rewrites every occurrence, 'prefix' only a value's beginning.
+Matching of values is literal and case-sensitive; secret name patterns are
+globs matched case-insensitively, like the secret policy's.
+"""
+import fnmatch
+import re
+from typing import Any, Dict, Iterable, List
+import yaml
+
+_PATTERN_RE = re.compile(r"^[A-Za-z0-9_*?]+$")
+
+
+class ValueRule:
+    """Rewrites one literal text in the values of secrets whose names match a pattern."""
+
+    def __init__(self, secrets: Iterable[str], text: str, replacement: str, prefix: bool = False):
+        self.secrets = list(secrets)
+        self.text = text
+        self.replacement = replacement
+        # Only rewrite text at the start of the value
+        self.prefix = prefix
+
+    def matches(self, name: str) -> bool:
+        """Return True if the rule applies to the secret."""
+        return any(fnmatch.fnmatchcase(name.upper(), pattern.upper()) for pattern in self.secrets)
+
+    def apply(self, value: str) -> str:
+        """Return value rewritten by the rule, as the workflow rewrites it."""
+        if not self.prefix:
+            return value.replace(self.text, self.replacement)
+        if value.startswith(self.text):
+            return self.replacement + value[len(self.text):]
+        return value
+
+    def describe(self) -> str:
+        """Describe the rule in one line."""
+        kind = "prefix" if self.prefix else "replace"
+        return f"{kind} '{self.text}' with '{self.replacement}'"
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the rule as the workflow reads it."""
+        return {"from": self.text, "to": self.replacement, "prefix": self.prefix}
+
+
+def parse_value_rules(data: Any) -> List[ValueRule]:
+    """Build value rules from a loaded YAML document with a 'rules' list.
+
+    Raises:
+        ValueError: If the document is malformed
+    """
+    if data is None:
+        return []
+    if not isinstance(data, dict):
+        raise ValueError("Value rules file must be a mapping with a 'rules' list")
+    unknown = sorted(str(key) for key in data if key != "rules")
+    if unknown:
+        raise ValueError(f"Value rules file has unknown key(s): {', '.join(unknown)}")
+    entries = data.get("rules") or []
+    if not isinstance(entries, list):
+        raise ValueError("'rules' must be a list")
+    rules = []
+    for number, entry in enumerate(entries, 1):
+        if not isinstance(entry, dict):
+            raise ValueError(f"Value rule {number} must be a mapping")
+        unknown = sorted(
+            str(key) for key in entry if key not in ("secrets", "replace", "prefix", "with")
+        )
+        if unknown:
+            raise ValueError(f"Value rule {number} has unknown key(s): {', '.join(unknown)}")
+        patterns = entry.get("secrets")
+        if isinstance(patterns, str):
+            patterns = [patterns]
+        if not patterns or not isinstance(patterns, list):
+            raise ValueError(f"Value rule {number} needs 'secrets': secret names or patterns")
+        for pattern in patterns:
+            if not _PATTERN_RE.match(str(pattern)):
+                raise ValueError(
+                    f"Invalid pattern '{pattern}' in value rule {number}: "
+                    "use letters, digits, underscores and the wildcards * and ?"
+                )
+        if ("replace" in entry) == ("prefix" in entry):
+            raise ValueError(f"Value rule {number} needs exactly one of 'replace' and 'prefix'")
+        text = entry.get("replace", entry.get("prefix"))
+        replacement = entry.get("with")
+        if not isinstance(text, str) or not text:
+            raise ValueError(f"Value rule {number}: the text to rewrite must be a non-empty string")
+        if not isinstance(replacement, str):
+            raise ValueError(f"Value rule {number} needs 'with': the replacement text")
+        patterns = [str(pattern) for pattern in patterns]
+        rules.append(ValueRule(patterns, text, replacement, "prefix" in entry))
+    return rules
+
+
+def load_value_rules(path: str) -> List[ValueRule]:
+    """Load value rules from a YAML file."""
+    with open(path, "r", encoding="utf-8") as handle:
+        return parse_value_rules(yaml.safe_load(handle))
+
+
+def resolve_value_rules(
+    rules: Iterable[ValueRule], secret_names: Iterable[str]
+) -> Dict[str, List[ValueRule]]:
+    """Return the rules applying to each secret, in file order; secrets without any are left out."""
+    rules = list(rules)
+    resolved = {}
+    for name in secret_names:
+        matching = [rule for rule in rules if rule.matches(name)]
+        if matching:
+            resolved[name] = matching
+    return resolved
+
+
+def rewrite_value(value: str, rules: Iterable[ValueRule]) -> str:
+    """Apply rules to a value in order, each to the previous one's result."""
+    for rule in rules:
+        value = rule.apply(value)
+    return value
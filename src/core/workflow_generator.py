@@ -1,8 +1,415 @@
 """Workflow generation for secrets migration."""
+import json
+import re
+import textwrap
 from typing import Dict, List, Optional
+from src.core.credentials import GITHUB_COM
+from src.core.destinations import PLUGIN_PROTOCOL, DestinationPlugin, destination_items
+from src.core.filters import SYSTEM_SECRETS, TARGET_APP_KEY_SECRET
+from src.core.policy import SecretPolicy
+from src.core.repo_refs import api_base_url
+from src.core.preflight import SECRET_VALUE_LIMIT_BYTES
+from src.core.scopes import OrgSecretScope
+from src.core.workflow_script import ACTION_REPOSITORY, GITHUB_SCRIPT_ACTION, MIGRATION_SCRIPT
+from src.core.workflow_log import (
+    MARKER, PROGRESS_BATCH_SIZE, phase_powershell, phase_shell, powershell_quoted,
+    secret_marker_powershell, secret_marker_shell
+)
 # flake8: noqa: E501
 
-def generate_environment_secret_steps(env_secrets: Dict[str, List[str]], source_org: str, source_repo: str, target_org: str, target_repo: str) -> str:
+# Oldest gh CLI release known to support every flag used by the generated steps
+GH_CLI_MIN_VERSION = "2.20.0"
+# Known-good gh CLI release installed when the runner's gh is missing or too old
+GH_CLI_PINNED_VERSION = "2.40.1"
+# SHA-256 of each gh CLI release archive the setup step may install, by asset
+# name as listed in the release's gh_<version>_checksums.txt. The step checks
+# the download against it before extracting and refuses archives not listed.
+# Releases not pinned here get their digests from the checksums file when the
+# workflow is generated (see parse_gh_cli_checksums).
+GH_CLI_SHA256: Dict[str, str] = {}
+# Checksums file published with every gh CLI release
+GH_CLI_CHECKSUMS_URL = "https://github.com/cli/cli/releases/download/v{version}/gh_{version}_checksums.txt"
+
+# Runner operating systems the workflow can target; windows steps are PowerShell scripts
+RUNNER_OSES = ("ubuntu", "windows", "macos")
+
+# shell: one bash (PowerShell on Windows) step per secret or target
+# github-script: a single actions/github-script step runs the migration (see workflow_script)
+# action: a single step using the published secrets-migrator-action (same script)
+WORKFLOW_ENGINES = ("shell", "github-script", "action")
+
+# owner/repo[/path]@<commit SHA>: the action engine only uses pinned references
+_PINNED_ACTION_REF = re.compile(r"^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+(/[A-Za-z0-9_./-]+)?@[0-9a-f]{40}$")
+
+# Expressions of the tokens steps write the target with: the stored target PAT, or
+# the installation token minted by the target App step
+_TARGET_PAT = "${{ secrets.SECRETS_MIGRATOR_TARGET_PAT }}"
+_APP_TOKEN = "${{ steps.target-token.outputs.token }}"
+
+# Limit of a migration workflow job's run time, well below GitHub's 6-hour default
+DEFAULT_WORKFLOW_TIMEOUT_MINUTES = 60
+
+# push: the workflow runs as soon as the migration branch is pushed
+# pull-request: the workflow is proposed in a pull request and runs once it is merged
+DELIVERY_MODES = ("push", "pull-request")
+
+# Characters a YAML scalar cannot hold literally: control characters and line breaks
+_YAML_UNPRINTABLE = re.compile(r"[\x00-\x1f\x7f-\x9f\u2028\u2029]")
+# Property names usable as `secrets.<name>` in an expression
+_EXPRESSION_IDENTIFIER = re.compile(r"^[A-Za-z_][A-Za-z0-9_-]*$")
+
+# Largest env: value a runner can hand a step: Linux caps one environment string at
+# 128 KiB and Windows one variable at 32767 characters (macOS kept to the Linux figure)
+SECRETS_PAYLOAD_LIMITS = {"ubuntu": 128 * 1024, "windows": 32767, "macos": 128 * 1024}
+# Secret values cannot be read before the run, so payloads are estimated at this size
+# per secret: generous for tokens and passwords, while certificates and keys may be larger
+ESTIMATED_SECRET_VALUE_BYTES = 2 * 1024
+
+
+def workflow_trigger(branch_name: str, delivery: str = "push", base_branch: str = "", workflow_path: str = "") -> str:
+    """Generate the workflow's `on:` block for a delivery mode.
+    
+    In pull-request mode the workflow only runs on a push to the base branch
+    that touches the workflow file itself, i.e. when the reviewed pull request
+    is merged, never from the migration branch.
+    
+    Raises:
+        ValueError: If the delivery mode is unknown or pull-request mode lacks a base branch
+    """
+    if delivery == "push":
+        return f"""on:
+  push:
+    branches: [ {json.dumps(branch_name)} ]"""
+    if delivery != "pull-request":
+        raise ValueError(f"Unknown delivery mode '{delivery}': expected one of {', '.join(DELIVERY_MODES)}")
+    if not base_branch or not workflow_path:
+        raise ValueError("pull-request delivery needs the base branch and workflow path")
+    return f"""on:
+  push:
+    branches: [ {json.dumps(base_branch)} ]
+    paths: [ {json.dumps(workflow_path)} ]"""
+
+
+def gh_cli_assets(version: str) -> List[str]:
+    """Archives of a gh CLI release the setup step may install, one per runner OS and architecture."""
+    return [
+        f"gh_{version}_{system}_{arch}.{extension}"
+        for system, extension in (("linux", "tar.gz"), ("macOS", "zip"), ("windows", "zip"))
+        for arch in ("amd64", "arm64")
+    ]
+
+
+def parse_gh_cli_checksums(text: str, version: str) -> Dict[str, str]:
+    """Read the digests of the installable archives from a release's gh_<version>_checksums.txt.
+
+    Lines are `<sha256>  <asset>`; other assets and malformed lines are ignored.
+    """
+    assets = set(gh_cli_assets(version))
+    digests = {}
+    for line in text.splitlines():
+        fields = line.split()
+        if len(fields) == 2 and fields[1] in assets and re.fullmatch(r"[0-9a-fA-F]{64}", fields[0]):
+            digests[fields[1]] = fields[0].lower()
+    return digests
+
+
+def _gh_cli_digests(version: str, digests: Optional[Dict[str, str]] = None) -> str:
+    """Space-separated ASSET=SHA256 pairs of a gh CLI release's archives.
+
+    Digests pinned in GH_CLI_SHA256 take precedence over the given ones.
+    """
+    prefix = f"gh_{version}_"
+    merged = {**(digests or {}), **GH_CLI_SHA256}
+    return " ".join(
+        f"{asset}={digest}" for asset, digest in sorted(merged.items()) if asset.startswith(prefix)
+    )
+
+
+def generate_gh_cli_setup_step(min_version: str = GH_CLI_MIN_VERSION, pinned_version: str = GH_CLI_PINNED_VERSION, digests: Optional[Dict[str, str]] = None) -> str:
+    """Generate the workflow step that ensures a compatible gh CLI is available.
+    
+    Older self-hosted runner images ship gh releases whose flags differ from the
+    ones used by the migration steps. The step detects the installed version and,
+    if it is missing or older than min_version, downloads pinned_version (the
+    Linux or macOS build), checks it against its SHA-256 in GH_CLI_SHA256 (or
+    digests) and prepends it to PATH for the remaining steps.
+    
+    Args:
+        min_version: Minimum gh CLI version accepted as-is
+        pinned_version: gh CLI version to install when the runner's version is too old
+        digests: Optional SHA-256 by asset name for releases not pinned in GH_CLI_SHA256
+        
+    Returns:
+        String containing the generated workflow step
+    """
+    return f"""      - name: Ensure compatible gh CLI
+        env:
+          GH_MIN_VERSION: {_yaml_quoted(min_version)}
+          GH_PINNED_VERSION: {_yaml_quoted(pinned_version)}
+          GH_PINNED_SHA256: {_yaml_quoted(_gh_cli_digests(pinned_version, digests))}
+        run: |
+          #!/bin/bash
+          set -e
+          {phase_shell("setup", "Ensure compatible gh CLI")}
+
+          # Never let gh block on interactive confirmation prompts, whatever its version
+          echo "GH_PROMPT_DISABLED=1" >> "$GITHUB_ENV"
+
+          CURRENT_VERSION=""
+          if command -v gh >/dev/null 2>&1; then
+            CURRENT_VERSION=$(gh --version | head -n1 | sed -E 's/^gh version ([0-9][0-9.]*).*/\\1/')
+          fi
+          echo "Detected gh CLI version: ${{CURRENT_VERSION:-none}}"
+
+          OLDEST=$(printf '%s\\n%s\\n' "$GH_MIN_VERSION" "$CURRENT_VERSION" | sort -V | head -n1)
+          if [ -n "$CURRENT_VERSION" ] && [ "$OLDEST" = "$GH_MIN_VERSION" ]; then
+            echo "✓ gh CLI $CURRENT_VERSION satisfies minimum version $GH_MIN_VERSION"
+          else
+            echo "Installing gh CLI $GH_PINNED_VERSION (minimum required: $GH_MIN_VERSION)..."
+            case "$(uname -m)" in
+              x86_64) GH_ARCH=amd64 ;;
+              aarch64|arm64) GH_ARCH=arm64 ;;
+              *) echo "❌ ERROR: Unsupported runner architecture $(uname -m)"; exit 1 ;;
+            esac
+            if [ "$(uname -s)" = "Darwin" ]; then
+              GH_DIST="gh_${{GH_PINNED_VERSION}}_macOS_${{GH_ARCH}}"
+              GH_ASSET="${{GH_DIST}}.zip"
+            else
+              GH_DIST="gh_${{GH_PINNED_VERSION}}_linux_${{GH_ARCH}}"
+              GH_ASSET="${{GH_DIST}}.tar.gz"
+            fi
+            GH_SHA256=""
+            for PAIR in $GH_PINNED_SHA256; do
+              if [ "${{PAIR%%=*}}" = "$GH_ASSET" ]; then GH_SHA256="${{PAIR#*=}}"; fi
+            done
+            if [ -z "$GH_SHA256" ]; then
+              echo "❌ ERROR: No pinned SHA-256 for $GH_ASSET; preinstall gh $GH_MIN_VERSION or later on the runner"
+              exit 1
+            fi
+            curl -fsSL "https://github.com/cli/cli/releases/download/v${{GH_PINNED_VERSION}}/$GH_ASSET" -o "$RUNNER_TEMP/$GH_ASSET"
+            if command -v sha256sum >/dev/null 2>&1; then
+              echo "$GH_SHA256  $RUNNER_TEMP/$GH_ASSET" | sha256sum -c -
+            else
+              echo "$GH_SHA256  $RUNNER_TEMP/$GH_ASSET" | shasum -a 256 -c -
+            fi
+            if [ "$(uname -s)" = "Darwin" ]; then
+              unzip -q -o "$RUNNER_TEMP/$GH_ASSET" -d "$RUNNER_TEMP"
+            else
+              tar -xzf "$RUNNER_TEMP/$GH_ASSET" -C "$RUNNER_TEMP"
+            fi
+            echo "$RUNNER_TEMP/$GH_DIST/bin" >> "$GITHUB_PATH"
+            echo "✓ Installed gh CLI $GH_PINNED_VERSION"
+          fi
+        shell: bash
+"""
+
+def generate_target_app_token_step(app_id: str, target_org: str, target_repos: Optional[List[str]] = None, target_host: str = GITHUB_COM) -> str:
+    """Generate the step minting a short-lived target token from a GitHub App.
+    
+    The App's private key is read from the SECRETS_MIGRATOR_TARGET_APP_KEY
+    secret the user keeps on the source; no long-lived target token is stored
+    there. The step signs a JWT with openssl, looks up the App's installation
+    on the target and exposes a one-hour installation token as its `token`
+    output, limited to target_repos when given. It always runs in bash (Git
+    Bash on Windows runners).
+    
+    Args:
+        app_id: GitHub App ID
+        target_org: Target organization (or user) the App is installed on
+        target_repos: Repositories the token is limited to (organization secrets need none)
+        target_host: GitHub host of the target
+    """
+    installation_path = f"repos/{target_org}/{target_repos[0]}/installation" if target_repos else f"orgs/{target_org}/installation"
+    token_request = json.dumps({"repositories": target_repos} if target_repos else {})
+    return f"""      - name: Mint target token
+        id: target-token
+        env:
+          APP_ID: {_yaml_quoted(app_id)}
+          APP_PRIVATE_KEY: ${{{{ secrets.{TARGET_APP_KEY_SECRET} }}}}
+          API_URL: {_yaml_quoted(api_base_url(target_host))}
+          INSTALLATION_PATH: {_yaml_quoted(installation_path)}
+          TOKEN_REQUEST: {_yaml_quoted(token_request)}
+        run: |
+          #!/bin/bash
+          set -e
+          {phase_shell("setup", "Mint target token")}
+
+          if [ -z "$APP_PRIVATE_KEY" ]; then
+            echo "❌ ERROR: {TARGET_APP_KEY_SECRET} is empty; store the GitHub App's private key as a repository or organization secret available to this repository"
+            exit 1
+          fi
+
+          b64url() {{ openssl base64 -A | tr '+/' '-_' | tr -d '='; }}
+          # Issued a minute early against clock drift; GitHub accepts JWTs valid for up to 10 minutes
+          NOW=$(date +%s)
+          HEADER=$(printf '{{"alg":"RS256","typ":"JWT"}}' | b64url)
+          PAYLOAD=$(printf '{{"iat":%d,"exp":%d,"iss":"%s"}}' $((NOW - 60)) $((NOW + 540)) "$APP_ID" | b64url)
+          KEY_FILE="$RUNNER_TEMP/target-app-key.pem"
+          (umask 077 && printf '%s\\n' "$APP_PRIVATE_KEY" > "$KEY_FILE")
+          SIGNATURE=$(printf '%s.%s' "$HEADER" "$PAYLOAD" | openssl dgst -sha256 -sign "$KEY_FILE" | b64url)
+          rm -f "$KEY_FILE"
+          JWT="$HEADER.$PAYLOAD.$SIGNATURE"
+
+          app_api() {{ curl -fsS -H "Authorization: Bearer $JWT" -H "Accept: application/vnd.github+json" "$@"; }}
+          INSTALLATION_ID=$(app_api "$API_URL/$INSTALLATION_PATH" | jq -r '.id // empty')
+          if [ -z "$INSTALLATION_ID" ]; then
+            echo "❌ ERROR: GitHub App $APP_ID is not installed on the target ($INSTALLATION_PATH)"
+            exit 1
+          fi
+          TOKEN=$(app_api -X POST -d "$TOKEN_REQUEST" "$API_URL/app/installations/$INSTALLATION_ID/access_tokens" | jq -r '.token // empty')
+          if [ -z "$TOKEN" ]; then
+            echo "❌ ERROR: Could not mint an installation token for GitHub App $APP_ID"
+            exit 1
+          fi
+          echo "::add-mask::$TOKEN"
+          echo "token=$TOKEN" >> "$GITHUB_OUTPUT"
+          echo "✓ Minted a one-hour target token for GitHub App $APP_ID (installation $INSTALLATION_ID)"
+        shell: bash
+"""
+
+
+def _yaml_quoted(value: str) -> str:
+    """Quote a value as a YAML scalar that reads back exactly as value.
+
+    Single quotes keep quotes, backticks and '$' literal; values with line
+    breaks or other control characters, which single-quoted scalars would
+    fold, are double-quoted with escapes instead.
+    """
+    if _YAML_UNPRINTABLE.search(value):
+        escaped = json.dumps(value, ensure_ascii=False)
+        return _YAML_UNPRINTABLE.sub(lambda match: f"\\u{ord(match.group(0)):04x}", escaped)
+    return "'" + value.replace("'", "''") + "'"
+
+
+def _secret_expression(name: str, to_json: bool = False) -> str:
+    """Return the expression reading a secret into an env: value (as a JSON string with to_json).
+
+    Raises:
+        ValueError: If name is not an expression identifier, so could end the expression early
+    """
+    if not _EXPRESSION_IDENTIFIER.match(name):
+        raise ValueError(f"invalid secret name {name!r}: expected letters, digits, '_' and '-'")
+    return f"${{{{ toJSON(secrets.{name}) }}}}" if to_json else f"${{{{ secrets.{name} }}}}"
+
+
+def _secrets_json(secret_names: Optional[List[str]]) -> str:
+    """Return the env: value holding secrets as a JSON object: every secret exposed to the
+    workflow (toJSON(secrets)), or only secret_names when given."""
+    if secret_names is None:
+        return "${{ toJSON(secrets) }}"
+    members = ", ".join(f"{json.dumps(name)}: {_secret_expression(name, to_json=True)}" for name in secret_names)
+    return _yaml_quoted("{" + members + "}")
+
+
+# jq expression applying one value rule ($rule) to the value (.): literal and case-sensitive
+_REWRITE_RULE_JQ = (
+    "if $rule.prefix then (if startswith($rule.from) then $rule.to + .[($rule.from | length):] else . end) "
+    "else (split($rule.from) | join($rule.to)) end"
+)
+# jq program applying a secret's value rewrites to $VALUE, in order
+_REWRITE_JQ = f"reduce ($rules[$name] // [])[] as $rule (env.VALUE; {_REWRITE_RULE_JQ})"
+
+
+def _value_rewrites_env(value_rewrites: Optional[Dict[str, List[Dict]]], secret_name: str = "") -> str:
+    """Return the VALUE_REWRITES env: line of a step (one secret's rewrites with secret_name),
+    or "" when the step rewrites no value."""
+    rewrites = value_rewrites or {}
+    if secret_name:
+        rewrites = {secret_name: rewrites[secret_name]} if secret_name in rewrites else {}
+    if not rewrites:
+        return ""
+    return f"\n          VALUE_REWRITES: {_yaml_quoted(json.dumps(rewrites, sort_keys=True))}"
+
+
+def _value_rewrite_shell(variable: str, indent: str) -> str:
+    """Return bash lines rewriting $variable with the VALUE_REWRITES of $SECRET_NAME.
+
+    The value reaches jq through its environment, not its command line; the
+    trailing '.' keeps line endings command substitution would strip.
+    """
+    lines = [
+        "# Value rules: rewrite the value before it is written to the target",
+        """if jq -e --arg name "$SECRET_NAME" 'has($name)' <<< "$VALUE_REWRITES" >/dev/null; then""",
+        f"""  {variable}=$(VALUE="${variable}" jq -jn --arg name "$SECRET_NAME" --argjson rules "$VALUE_REWRITES" \\""",
+        f"""    '{_REWRITE_JQ}' && printf .)""",
+        f'  {variable}="${{{variable}%.}}"',
+        '  echo "Rewrote the value of $SECRET_NAME (value rules)"',
+        "fi",
+    ]
+    return ("\n" + indent).join(lines)
+
+
+def estimated_secrets_payload(secret_names: List[str]) -> int:
+    """Estimate the bytes of toJSON(secrets) for a workflow exposing secret_names.
+
+    The migrator's own temporary secrets and github_token are counted as well;
+    toJSON pretty-prints one '  "NAME": "VALUE",' line per secret.
+    """
+    return 2 + sum(len(name) + ESTIMATED_SECRET_VALUE_BYTES + 8 for name in list(secret_names) + list(SYSTEM_SECRETS))
+
+
+def chunk_secret_names(secret_names: List[str], runner_os: str = "ubuntu") -> List[List[str]]:
+    """Split secret names into chunks whose estimated JSON fits in one env: value.
+
+    Returns [] when toJSON(secrets) as a whole is expected to fit, so the
+    workflow keeps reading every secret exposed to it.
+    """
+    limit = SECRETS_PAYLOAD_LIMITS[runner_os]
+    if estimated_secrets_payload(secret_names) <= limit:
+        return []
+    chunks: List[List[str]] = []
+    size = limit
+    for name in secret_names:
+        entry = len(name) + ESTIMATED_SECRET_VALUE_BYTES + 8
+        if size + entry > limit:
+            chunks.append([])
+            size = 2
+        chunks[-1].append(name)
+        size += entry
+    return chunks
+
+
+def _part(text: str, index: int, count: int) -> str:
+    """Suffix a step name or phase title with its chunk ('Name (part 2/3)')."""
+    return f"{text} (part {index}/{count})" if count > 1 else text
+
+
+def _chunked_repository_steps(
+    repository_step,
+    chunks: List[List[str]],
+    target_org: str,
+    target_repo: str,
+    name_map: Optional[Dict[str, str]],
+    policy: SecretPolicy,
+    skip_secrets: Optional[List[str]],
+    step_name: str = "Populate Repository Secrets",
+    step_id: str = "migrate",
+    phase_title: str = "Repository secrets",
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None,
+    target_token: str = _TARGET_PAT
+) -> str:
+    """Generate a target's repository secret step, or one step per chunk of secret names."""
+    if not chunks:
+        return repository_step(
+            target_org, target_repo, name_map, policy, skip_secrets,
+            step_name=step_name, step_id=step_id, phase_title=phase_title,
+            value_rewrites=value_rewrites, target_token=target_token
+        )
+    return "".join(
+        repository_step(
+            target_org, target_repo, name_map, policy, skip_secrets,
+            step_name=_part(step_name, index, len(chunks)),
+            step_id=step_id if index == 1 else f"{step_id}-part-{index}",
+            phase_title=_part(phase_title, index, len(chunks)),
+            secret_names=chunk,
+            value_rewrites=value_rewrites,
+            target_token=target_token
+        )
+        for index, chunk in enumerate(chunks, 1)
+    )
+
+
+def generate_environment_secret_steps(env_secrets: Dict[str, List[str]], source_org: str, source_repo: str, target_org: str, target_repo: str, name_map: Optional[Dict[str, str]] = None, environment_map: Optional[Dict[str, str]] = None, step_suffix: str = "", value_rewrites: Optional[Dict[str, List[Dict]]] = None, target_token: str = _TARGET_PAT) -> str:
     """Generate workflow steps for each environment secret.
     
     Args:
@@ -12,38 +419,68 @@ def generate_environment_secret_steps(env_secrets: Dict[str, List[str]], source_
         source_repo: Source repository
         target_org: Target organization
         target_repo: Target repository
+        name_map: Optional dict mapping source secret names to target names
+        environment_map: Optional dict routing source environments to differently named
+                         target environments
+        step_suffix: Optional text appended to step names (the target of a fan-out run)
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        (ValueRule.to_dict()) rewriting their values, in order
+        target_token: Expression of the token the step writes the target with
         
     Returns:
         String containing all the generated workflow steps
     """
     steps = []
     
+    name_map = name_map or {}
+    environment_map = environment_map or {}
+    
+    total = sum(len(secret_names) for secret_names in env_secrets.values())
+    index = 0
+    
     for env_name, secret_names in env_secrets.items():
         for secret_name in secret_names:
-            step = f"""      - name: Migrate {env_name} - {secret_name}
+            index += 1
+            target_name = name_map.get(secret_name, secret_name)
+            rewrite = ""
+            if secret_name in (value_rewrites or {}):
+                rewrite = "\n          " + _value_rewrite_shell("SECRET_VALUE", "          ") + "\n"
+            step = f"""      - name: {_yaml_quoted(f"Migrate {env_name} - {secret_name}{step_suffix}")}
         env:
-          TARGET_ORG: '{target_org}'
-          TARGET_REPO: '{target_repo}'
-          ENVIRONMENT: '{env_name}'
-          SECRET_NAME: '{secret_name}'
-          SECRET_VALUE: ${{{{ secrets.{secret_name} }}}}
-          GH_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_TARGET_PAT }}}}
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          TARGET_REPO: {_yaml_quoted(target_repo)}
+          ENVIRONMENT: {_yaml_quoted(environment_map.get(env_name, env_name))}
+          SECRET_NAME: {_yaml_quoted(secret_name)}
+          TARGET_SECRET_NAME: {_yaml_quoted(target_name)}
+          SECRET_VALUE: {_secret_expression(secret_name)}{_value_rewrites_env(value_rewrites, secret_name)}
+          GH_TOKEN: {target_token}
         run: |
           #!/bin/bash
           set -e
+          {phase_shell("environment-secrets", f"Environment secret {env_name} - {secret_name}{step_suffix}")}
 
           echo "=========================================="
           echo "Migrating environment secret: $ENVIRONMENT - $SECRET_NAME"
           echo "=========================================="
-          
+          {rewrite}
+          VALUE_BYTES=$(printf '%s' "$SECRET_VALUE" | wc -c)
+          if [ "$VALUE_BYTES" -gt {SECRET_VALUE_LIMIT_BYTES} ]; then
+            echo "❌ ERROR: '$SECRET_NAME' is $VALUE_BYTES bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+            {secret_marker_shell("failed", "environment", "$TARGET_ORG/$TARGET_REPO:$ENVIRONMENT")}
+            exit 1
+          fi
+
           # Create secret in target environment with the value from workflow secrets
-          if gh secret set "$SECRET_NAME" \\
+          if gh secret set "$TARGET_SECRET_NAME" \\
             --body "$SECRET_VALUE" \\
             --repo "$TARGET_ORG/$TARGET_REPO" \\
             --env "$ENVIRONMENT"; then
-            echo "✓ Successfully migrated '$SECRET_NAME' to $ENVIRONMENT"
+            echo "✓ Successfully migrated '$SECRET_NAME' to $ENVIRONMENT as '$TARGET_SECRET_NAME'"
+            {secret_marker_shell("ok", "environment", "$TARGET_ORG/$TARGET_REPO:$ENVIRONMENT")}
+            echo "{MARKER} progress environment-secrets {index}/{total}"
           else
-            echo "❌ ERROR: Failed to create secret '$SECRET_NAME' in target environment '$ENVIRONMENT'"
+            echo "❌ ERROR: Failed to create secret '$TARGET_SECRET_NAME' in target environment '$ENVIRONMENT'"
+            {secret_marker_shell("failed", "environment", "$TARGET_ORG/$TARGET_REPO:$ENVIRONMENT")}
             exit 1
           fi
         shell: bash
@@ -53,43 +490,106 @@ def generate_environment_secret_steps(env_secrets: Dict[str, List[str]], source_
     return "\n".join(steps)
 
 
-def generate_org_secret_steps(org_secrets: List[str], target_org: str) -> str:
+def generate_org_secret_steps(org_secrets: List[str], target_org: str, name_map: Optional[Dict[str, str]] = None, scopes: Optional[Dict[str, OrgSecretScope]] = None, value_rewrites: Optional[Dict[str, List[Dict]]] = None, target_token: str = _TARGET_PAT) -> str:
     """Generate workflow steps for each organization secret.
     
+    When a scope is known for a secret, its visibility (and selected repository
+    list) is applied on the target. For 'selected' visibility the step reads the
+    repository list back and re-applies it up to 3 times until it matches, since
+    scope updates can partially apply under rate limiting.
+    
     Args:
         org_secrets: List of organization secret names
                      Example: ['DB_PASSWORD', 'API_KEY', 'DEPLOY_TOKEN']
         target_org: Target organization
+        name_map: Optional dict mapping source secret names to target names
+        scopes: Optional dict mapping source secret names to their intended scope
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        rewriting their values, in order
+        target_token: Expression of the token the step writes the target with
         
     Returns:
         String containing all the generated workflow steps
     """
     steps = []
     
-    for secret_name in org_secrets:
-        step = f"""      - name: Migrate Org Secret - {secret_name}
+    name_map = name_map or {}
+    scopes = scopes or {}
+    
+    for index, secret_name in enumerate(org_secrets, start=1):
+        target_name = name_map.get(secret_name, secret_name)
+        scope = scopes.get(secret_name)
+        visibility = scope.visibility if scope else ""
+        selected_repos = ",".join(scope.repositories) if scope else ""
+        rewrite = ""
+        if secret_name in (value_rewrites or {}):
+            rewrite = "\n          " + _value_rewrite_shell("SECRET_VALUE", "          ") + "\n"
+        step = f"""      - name: {_yaml_quoted(f"Migrate Org Secret - {secret_name}")}
         env:
-          TARGET_ORG: '{target_org}'
-          SECRET_NAME: '{secret_name}'
-          SECRET_VALUE: ${{{{ secrets.{secret_name} }}}}
-          GH_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_TARGET_PAT }}}}
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          SECRET_NAME: {_yaml_quoted(secret_name)}
+          TARGET_SECRET_NAME: {_yaml_quoted(target_name)}
+          VISIBILITY: {_yaml_quoted(visibility)}
+          SELECTED_REPOS: {_yaml_quoted(selected_repos)}
+          SECRET_VALUE: {_secret_expression(secret_name)}{_value_rewrites_env(value_rewrites, secret_name)}
+          GH_TOKEN: {target_token}
         run: |
           #!/bin/bash
           set -e
+          {phase_shell("organization-secrets", f"Organization secret {secret_name}")}
 
           echo "=========================================="
           echo "Migrating organization secret: $SECRET_NAME"
           echo "=========================================="
-          
+          {rewrite}
+          VALUE_BYTES=$(printf '%s' "$SECRET_VALUE" | wc -c)
+          if [ "$VALUE_BYTES" -gt {SECRET_VALUE_LIMIT_BYTES} ]; then
+            echo "❌ ERROR: '$SECRET_NAME' is $VALUE_BYTES bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+            {secret_marker_shell("failed", "organization", "$TARGET_ORG")}
+            exit 1
+          fi
+
+          SET_ARGS=(--body "$SECRET_VALUE" --org "$TARGET_ORG")
+          if [ -n "$VISIBILITY" ]; then
+            SET_ARGS+=(--visibility "$VISIBILITY")
+          fi
+          if [ "$VISIBILITY" = "selected" ] && [ -n "$SELECTED_REPOS" ]; then
+            SET_ARGS+=(--repos "$SELECTED_REPOS")
+          fi
+
           # Create secret in target organization with the value from workflow secrets
-          if gh secret set "$SECRET_NAME" \\
-            --body "$SECRET_VALUE" \\
-            --org "$TARGET_ORG"; then
-            echo "✓ Successfully migrated '$SECRET_NAME' to organization '$TARGET_ORG'"
+          if gh secret set "$TARGET_SECRET_NAME" "${{SET_ARGS[@]}}"; then
+            echo "✓ Successfully migrated '$SECRET_NAME' to organization '$TARGET_ORG' as '$TARGET_SECRET_NAME'"
+            {secret_marker_shell("ok", "organization", "$TARGET_ORG")}
+            echo "{MARKER} progress organization-secrets {index}/{len(org_secrets)}"
           else
-            echo "❌ ERROR: Failed to create secret '$SECRET_NAME' in target organization '$TARGET_ORG'"
+            echo "❌ ERROR: Failed to create secret '$TARGET_SECRET_NAME' in target organization '$TARGET_ORG'"
+            {secret_marker_shell("failed", "organization", "$TARGET_ORG")}
             exit 1
           fi
+
+          # Read back the selected repositories and re-apply until they match the intended scope
+          if [ "$VISIBILITY" = "selected" ]; then
+            EXPECTED=$(echo "$SELECTED_REPOS" | tr ',' '\\n' | sed '/^$/d' | sort)
+            for ATTEMPT in 1 2 3; do
+              ACTUAL=$(gh api --paginate "orgs/$TARGET_ORG/actions/secrets/$TARGET_SECRET_NAME/repositories" \\
+                --jq '.repositories[].name' | sort)
+              if [ "$ACTUAL" = "$EXPECTED" ]; then
+                echo "✓ Verified repository scope of '$TARGET_SECRET_NAME'"
+                break
+              fi
+              MISSING=$(comm -23 <(echo "$EXPECTED") <(echo "$ACTUAL") | sed '/^$/d' | paste -sd, -)
+              UNEXPECTED=$(comm -13 <(echo "$EXPECTED") <(echo "$ACTUAL") | sed '/^$/d' | paste -sd, -)
+              echo "⚠️  Scope mismatch for '$TARGET_SECRET_NAME' (attempt $ATTEMPT/3): missing [$MISSING], unexpected [$UNEXPECTED]"
+              if [ "$ATTEMPT" -eq 3 ]; then
+                echo "❌ ERROR: Repository scope of '$TARGET_SECRET_NAME' does not match the source after 3 attempts"
+                {secret_marker_shell("failed", "organization", "$TARGET_ORG")}
+                exit 1
+              fi
+              sleep $((ATTEMPT * 5))
+              gh secret set "$TARGET_SECRET_NAME" "${{SET_ARGS[@]}}"
+            done
+          fi
         shell: bash
 """
         steps.append(step)
@@ -97,61 +597,128 @@ def generate_org_secret_steps(org_secrets: List[str], target_org: str) -> str:
     return "\n".join(steps)
 
 
-def generate_workflow(
-    source_org: str, 
-    source_repo: str, 
-    target_org: str, 
-    target_repo: str, 
-    branch_name: str, 
-    env_secrets: Optional[Dict[str, List[str]]] = None,
-    org_secrets: Optional[List[str]] = None
+class FanOutTarget:
+    """A target repository of a fan-out workflow and what is written to it."""
+
+    def __init__(
+        self,
+        repo: str,
+        env_secrets: Optional[Dict[str, List[str]]] = None,
+        skip_secrets: Optional[List[str]] = None
+    ):
+        self.repo = repo
+        self.env_secrets = dict(env_secrets or {})
+        self.skip_secrets = list(skip_secrets or [])
+
+
+def generate_repository_secret_step(
+    target_org: str,
+    target_repo: str,
+    name_map: Optional[Dict[str, str]] = None,
+    policy: Optional[SecretPolicy] = None,
+    skip_secrets: Optional[List[str]] = None,
+    step_name: str = "Populate Repository Secrets",
+    step_id: str = "migrate",
+    phase_title: str = "Repository secrets",
+    secret_names: Optional[List[str]] = None,
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None,
+    target_token: str = _TARGET_PAT
 ) -> str:
-    """Generate the GitHub Actions workflow for secret migration.
+    """Generate the step copying every repository secret exposed to the workflow to one target.
     
     Args:
-        source_org: Source organization
-        source_repo: Source repository
         target_org: Target organization
         target_repo: Target repository
-        branch_name: Migration branch name
-        env_secrets: Optional dict of environment secrets to generate dynamic steps
-                     Example: {'production': ['DB_PASSWORD', 'API_KEY']}
-        org_secrets: Optional list of organization secret names for org-to-org migration
-                     Example: ['DB_PASSWORD', 'API_KEY', 'DEPLOY_TOKEN']
+        name_map: Optional dict mapping source secret names to target names
+        policy: Optional deny/allow policy re-checked for every secret
+        skip_secrets: Optional list of source secret names not to write
+        step_name: Step name (fan-out runs name one step per target)
+        step_id: Step id, unique within the job
+        phase_title: Title of the step's phase in the run log
+        secret_names: Optional source secret names to read instead of every secret
+                      exposed to the workflow (one chunk of a payload too large for one env: value)
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        rewriting their values, in order
+        target_token: Expression of the token the step writes the target with
     """
-    # Generate migration steps based on type
-    migration_steps = ""
-    
-    # Repo-to-repo: include repository secrets step
-    if not org_secrets:
-        migration_steps = f"""      - name: Populate Repository Secrets
-        id: migrate
+    policy = policy or SecretPolicy()
+    rewrite = ""
+    if value_rewrites:
+        rewrite = "\n              " + _value_rewrite_shell("FINAL_VALUE", "              ") + "\n"
+    return f"""      - name: {_yaml_quoted(step_name)}
+        id: {step_id}
         env:
-          REPO_SECRETS: ${{{{ toJSON(secrets) }}}}
-          TARGET_ORG: '{target_org}'
-          TARGET_REPO: '{target_repo}'
-          GH_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_TARGET_PAT }}}}
+          REPO_SECRETS: {_secrets_json(secret_names)}
+          NAME_MAP: {_yaml_quoted(json.dumps(name_map or {}, sort_keys=True))}
+          SKIP_SECRETS: {_yaml_quoted(json.dumps(sorted(skip_secrets or [])))}
+          DENY_PATTERNS: {_yaml_quoted(" ".join(policy.deny))}
+          ALLOW_PATTERNS: {_yaml_quoted(" ".join(policy.allow))}{_value_rewrites_env(value_rewrites)}
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          TARGET_REPO: {_yaml_quoted(target_repo)}
+          GH_TOKEN: {target_token}
         run: |
           #!/bin/bash
           set -e
 
           MIGRATION_FAILED=0
+          {phase_shell("repository-secrets", phase_title)}
+
+          # Secret policy: deny patterns always win; a non-empty allowlist limits what is migrated
+          read -ra DENY <<< "$DENY_PATTERNS"
+          read -ra ALLOW <<< "$ALLOW_PATTERNS"
+          shopt -s nocasematch
+          policy_allows() {{
+            local pattern
+            for pattern in "${{DENY[@]}}"; do
+              if [[ "$1" == $pattern ]]; then return 1; fi
+            done
+            if [ ${{#ALLOW[@]}} -eq 0 ]; then return 0; fi
+            for pattern in "${{ALLOW[@]}}"; do
+              if [[ "$1" == $pattern ]]; then return 0; fi
+            done
+            return 1
+          }}
 
           echo "Populating secrets in target repository..."
+          TOTAL=$(echo "$REPO_SECRETS" | jq '[keys[] | select({" and ".join(f'. != "{name}"' for name in SYSTEM_SECRETS)})] | length')
+          DONE=0
           echo "$REPO_SECRETS" | jq -r 'to_entries[] | "\\(.key)|\\(.value)"' | while IFS='|' read -r SECRET_NAME SECRET_VALUE; do
-            if [[ "$SECRET_NAME" != "github_token" && "$SECRET_NAME" != "SECRETS_MIGRATOR_PAT" && "$SECRET_NAME" != "SECRETS_MIGRATOR_TARGET_PAT" && "$SECRET_NAME" != "SECRETS_MIGRATOR_SOURCE_PAT" ]]; then
+            if [[ {" && ".join(f'"$SECRET_NAME" != "{name}"' for name in SYSTEM_SECRETS)} ]]; then
+              DONE=$((DONE + 1))
+              if [ $((DONE % {PROGRESS_BATCH_SIZE})) -eq 0 ] || [ "$DONE" -eq "$TOTAL" ]; then
+                echo "{MARKER} progress repository-secrets $DONE/$TOTAL"
+              fi
+              if ! policy_allows "$SECRET_NAME"; then
+                echo "Skipping $SECRET_NAME (blocked by secret policy)"
+                continue
+              fi
+              if echo "$SKIP_SECRETS" | jq -e --arg name "$SECRET_NAME" 'index($name) != null' >/dev/null; then
+                echo "Skipping $SECRET_NAME (already exists on target)"
+                continue
+              fi
+              TARGET_NAME=$(echo "$NAME_MAP" | jq -r --arg name "$SECRET_NAME" '.[$name] // $name')
               echo "Processing: $SECRET_NAME"
               
               # Echo secret, reverse twice, and capture output
               FINAL_VALUE=$(echo "$SECRET_VALUE" | rev | rev)
-              
+              {rewrite}
+              VALUE_BYTES=$(printf '%s' "$FINAL_VALUE" | wc -c)
+              if [ "$VALUE_BYTES" -gt {SECRET_VALUE_LIMIT_BYTES} ]; then
+                echo "❌ ERROR: '$SECRET_NAME' is $VALUE_BYTES bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+                {secret_marker_shell("failed", "repository", "$TARGET_ORG/$TARGET_REPO", "$TARGET_NAME")}
+                MIGRATION_FAILED=1
+                continue
+              fi
+
               # Create secret in target repo using target PAT
-              if gh secret set "$SECRET_NAME" \\
+              if gh secret set "$TARGET_NAME" \\
                 --body "$FINAL_VALUE" \\
                 --repo "$TARGET_ORG/$TARGET_REPO"; then
-                echo "✓ Created '$SECRET_NAME' in target repo"
+                echo "✓ Created '$TARGET_NAME' in target repo"
+                {secret_marker_shell("ok", "repository", "$TARGET_ORG/$TARGET_REPO", "$TARGET_NAME")}
               else
-                echo "❌ ERROR: Failed to create secret $SECRET_NAME"
+                echo "❌ ERROR: Failed to create secret $TARGET_NAME"
+                {secret_marker_shell("failed", "repository", "$TARGET_ORG/$TARGET_REPO", "$TARGET_NAME")}
                 MIGRATION_FAILED=1
               fi
             fi
@@ -167,52 +734,724 @@ def generate_workflow(
           echo "✓ All secrets migrated successfully!"
         shell: bash
 """
+
+
+def migration_plan(
+    target_org: str,
+    targets: List[FanOutTarget],
+    name_map: Optional[Dict[str, str]] = None,
+    policy: Optional[SecretPolicy] = None,
+    environment_map: Optional[Dict[str, str]] = None,
+    repo_secrets: bool = True,
+    org_secrets: Optional[List[str]] = None,
+    org_secret_scopes: Optional[Dict[str, OrgSecretScope]] = None,
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None
+) -> Dict:
+    """Build the MIGRATION_PLAN document the github-script engine's script follows.
+
+    Args:
+        target_org: Target organization
+        targets: Target repositories with their environment secrets and skipped
+                 secrets (the first is the primary target; empty for organization secrets)
+        name_map: Optional dict mapping source secret names to target names
+        policy: Optional deny/allow policy re-checked for every repository secret
+        environment_map: Optional dict routing source environments to target environments
+        repo_secrets: Whether repository secrets are copied to each target
+        org_secrets: Organization secret names (organization migrations)
+        org_secret_scopes: Optional dict mapping organization secret names to their scope
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        rewriting their values, in order
+    """
+    policy = policy or SecretPolicy()
+    environment_map = environment_map or {}
+    scopes = org_secret_scopes or {}
+    fan_out = len(targets) > 1
+    return {
+        "marker": MARKER,
+        "batch_size": PROGRESS_BATCH_SIZE,
+        "value_limit_bytes": SECRET_VALUE_LIMIT_BYTES,
+        "internal": list(SYSTEM_SECRETS),
+        "target_org": target_org,
+        "name_map": dict(name_map or {}),
+        "value_rewrites": dict(value_rewrites or {}),
+        "deny": list(policy.deny),
+        "allow": list(policy.allow),
+        "repository_secrets": repo_secrets,
+        "targets": [
+            {
+                "repo": target.repo,
+                "title": f"Repository secrets ({target.repo})" if fan_out else "Repository secrets",
+                "suffix": f" ({target.repo})" if fan_out else "",
+                "skip": sorted(target.skip_secrets),
+                "environment_secrets": [
+                    {"environment": env_name, "target_environment": environment_map.get(env_name, env_name), "name": name}
+                    for env_name, names in target.env_secrets.items() for name in names
+                ],
+            }
+            for target in targets
+        ],
+        "org_secrets": [
+            {
+                "name": name,
+                "visibility": scopes[name].visibility if name in scopes else "",
+                "repositories": list(scopes[name].repositories) if name in scopes else [],
+            }
+            for name in org_secrets or []
+        ],
+    }
+
+
+def check_action_ref(engine: str, action_ref: str) -> None:
+    """Validate the action reference of a workflow engine.
+
+    Raises:
+        ValueError: If the action engine lacks a reference pinned to a commit SHA, or
+                    another engine is given one
+    """
+    if engine != "action":
+        if action_ref:
+            raise ValueError("--action-ref only applies to --workflow-engine action")
+        return
+    if not _PINNED_ACTION_REF.match(action_ref):
+        raise ValueError(
+            f"--workflow-engine action needs --action-ref {ACTION_REPOSITORY}@<commit SHA> "
+            f"(got '{action_ref}')"
+        )
+
+
+def chunk_plan(plan: Dict, secret_names: List[str], index: int, count: int) -> Dict:
+    """Restrict a migration plan to the secrets of one chunk of its payload.
+
+    Repository secrets follow from the chunk's SOURCE_SECRETS; environment and
+    organization secrets outside the chunk are dropped, and phase titles name the part.
+    """
+    names = set(secret_names)
+    return dict(
+        plan,
+        targets=[
+            dict(
+                target,
+                title=_part(target["title"], index, count),
+                environment_secrets=[item for item in target["environment_secrets"] if item["name"] in names],
+            )
+            for target in plan["targets"]
+        ],
+        org_secrets=[item for item in plan["org_secrets"] if item["name"] in names],
+    )
+
+
+def generate_action_step(
+    plan: Dict,
+    action_ref: str,
+    target_host: str = GITHUB_COM,
+    secret_names: Optional[List[str]] = None,
+    step_name: str = "Migrate Secrets",
+    step_id: str = "migrate",
+    target_token: str = _TARGET_PAT
+) -> str:
+    """Generate the step handing a plan to the published secrets-migrator-action.
+
+    With secret_names, only those secrets are passed (one chunk of a large payload);
+    target_token is the expression of the token the action writes the target with.
+    """
+    gh_host = f"\n          gh-host: {_yaml_quoted(target_host)}" if target_host != GITHUB_COM else ""
+    return f"""      - name: {_yaml_quoted(step_name)}
+        id: {step_id}
+        uses: {action_ref}
+        with:
+          plan: {_yaml_quoted(json.dumps(plan, sort_keys=True))}
+          secrets: {_secrets_json(secret_names)}
+          token: {target_token}{gh_host}
+"""
+
+
+def generate_github_script_step(
+    plan: Dict,
+    secret_names: Optional[List[str]] = None,
+    step_name: str = "Migrate Secrets",
+    step_id: str = "migrate",
+    target_token: str = _TARGET_PAT
+) -> str:
+    """Generate the actions/github-script step migrating every secret of a plan.
+
+    The script is fixed; the plan and the secrets reach it as env: values. With
+    secret_names, only those secrets are passed (one chunk of a large payload).
+    target_token is the expression of the token the script writes the target with.
+    """
+    return f"""      - name: {_yaml_quoted(step_name)}
+        id: {step_id}
+        uses: {GITHUB_SCRIPT_ACTION} # v7.0.1
+        env:
+          SOURCE_SECRETS: {_secrets_json(secret_names)}
+          MIGRATION_PLAN: {_yaml_quoted(json.dumps(plan, sort_keys=True))}
+          GH_TOKEN: {target_token}
+        with:
+          script: |
+{textwrap.indent(MIGRATION_SCRIPT, " " * 12)}"""
+
+
+# PowerShell steps for Windows runners. They mirror the bash steps above and print
+# the same log markers; messages stay ASCII since Windows PowerShell 5.1 reads
+# scripts in the system code page.
+
+# Defined in every PowerShell step writing secrets
+_SET_SECRET_POWERSHELL = """function Set-TargetSecret([string]$Name, [string]$Value, [string[]]$Scope) {
+              # Piped rather than passed with --body, since Windows PowerShell drops quotes
+              # inside native arguments; gh trims the line ending the pipe adds
+              $ErrorActionPreference = 'Continue'
+              $OutputEncoding = New-Object System.Text.UTF8Encoding $false
+              $Value | gh secret set $Name @Scope | Out-Host
+              return $LASTEXITCODE -eq 0
+            }"""
+
+
+# Defined in PowerShell steps rewriting values; String.Replace and StartsWith with
+# Ordinal compare literally and case-sensitively, like the bash steps' jq program
+_EDIT_VALUE_POWERSHELL = """function Edit-SecretValue([string]$Name, [string]$Value) {
+              $Rewrites = $env:VALUE_REWRITES | ConvertFrom-Json
+              if (-not $Rewrites.PSObject.Properties[$Name]) { return $Value }
+              foreach ($Rule in @($Rewrites.PSObject.Properties[$Name].Value | ForEach-Object { $_ })) {
+                $From = [string]$Rule.from
+                if (-not $Rule.prefix) {
+                  $Value = $Value.Replace($From, [string]$Rule.to)
+                } elseif ($Value.StartsWith($From, [StringComparison]::Ordinal)) {
+                  $Value = [string]$Rule.to + $Value.Substring($From.Length)
+                }
+              }
+              Write-Host "Rewrote the value of $Name (value rules)"
+              return $Value
+            }"""
+
+
+def _edit_value_powershell(value_rewrites: Optional[Dict[str, List[Dict]]], secret_name: str = "") -> str:
+    """Return the Edit-SecretValue definition for a step body, or "" when it rewrites no value."""
+    if not value_rewrites or (secret_name and secret_name not in value_rewrites):
+        return ""
+    return "\n            " + _EDIT_VALUE_POWERSHELL + "\n"
+
+
+def generate_gh_cli_setup_step_powershell(min_version: str = GH_CLI_MIN_VERSION, pinned_version: str = GH_CLI_PINNED_VERSION, digests: Optional[Dict[str, str]] = None) -> str:
+    """Generate the Windows (PowerShell) version of the step ensuring a compatible gh CLI."""
+    body = '''
+            # Never let gh block on interactive confirmation prompts, whatever its version
+            Add-Content -Path $env:GITHUB_ENV -Value 'GH_PROMPT_DISABLED=1'
+
+            $CurrentVersion = ''
+            if (Get-Command gh -ErrorAction SilentlyContinue) {
+              if ((gh --version | Select-Object -First 1) -match '^gh version ([0-9][0-9.]*)') {
+                $CurrentVersion = $Matches[1].TrimEnd('.')
+              }
+            }
+            Write-Output "Detected gh CLI version: $(if ($CurrentVersion) { $CurrentVersion } else { 'none' })"
+
+            if ($CurrentVersion -and ([version]$CurrentVersion -ge [version]$env:GH_MIN_VERSION)) {
+              Write-Output "OK: gh CLI $CurrentVersion satisfies minimum version $($env:GH_MIN_VERSION)"
+            } else {
+              Write-Output "Installing gh CLI $($env:GH_PINNED_VERSION) (minimum required: $($env:GH_MIN_VERSION))..."
+              $Arch = if ($env:PROCESSOR_ARCHITECTURE -eq 'ARM64') { 'arm64' } else { 'amd64' }
+              $Dist = "gh_$($env:GH_PINNED_VERSION)_windows_$Arch"
+              $Asset = "$Dist.zip"
+              $Digest = ''
+              foreach ($Pair in ($env:GH_PINNED_SHA256 -split ' ')) {
+                if ($Pair -like "$Asset=*") { $Digest = $Pair.Substring($Asset.Length + 1) }
+              }
+              if (-not $Digest) {
+                throw "No pinned SHA-256 for $Asset; preinstall gh $($env:GH_MIN_VERSION) or later on the runner"
+              }
+              $Archive = Join-Path $env:RUNNER_TEMP $Asset
+              [Net.ServicePointManager]::SecurityProtocol = [Net.SecurityProtocolType]::Tls12
+              $ProgressPreference = 'SilentlyContinue'
+              Invoke-WebRequest -UseBasicParsing -OutFile $Archive `
+                -Uri "https://github.com/cli/cli/releases/download/v$($env:GH_PINNED_VERSION)/$Asset"
+              $Actual = (Get-FileHash -Algorithm SHA256 -Path $Archive).Hash
+              if ($Actual -ne $Digest) {
+                throw "SHA-256 mismatch for ${Asset}: expected $Digest, got $Actual"
+              }
+              Expand-Archive -Path $Archive -DestinationPath (Join-Path $env:RUNNER_TEMP $Dist) -Force
+              Add-Content -Path $env:GITHUB_PATH -Value (Join-Path $env:RUNNER_TEMP "$Dist\\bin")
+              Write-Output "OK: Installed gh CLI $($env:GH_PINNED_VERSION)"
+            }'''
+    return f"""      - name: Ensure compatible gh CLI
+        env:
+          GH_MIN_VERSION: {_yaml_quoted(min_version)}
+          GH_PINNED_VERSION: {_yaml_quoted(pinned_version)}
+          GH_PINNED_SHA256: {_yaml_quoted(_gh_cli_digests(pinned_version, digests))}
+        run: |
+          {phase_powershell("setup", "Ensure compatible gh CLI", body)}
+        shell: powershell
+"""
+
+
+def generate_environment_secret_steps_powershell(env_secrets: Dict[str, List[str]], source_org: str, source_repo: str, target_org: str, target_repo: str, name_map: Optional[Dict[str, str]] = None, environment_map: Optional[Dict[str, str]] = None, step_suffix: str = "", value_rewrites: Optional[Dict[str, List[Dict]]] = None, target_token: str = _TARGET_PAT) -> str:
+    """Generate the Windows (PowerShell) version of the environment secret steps."""
+    steps = []
+    name_map = name_map or {}
+    environment_map = environment_map or {}
+    total = sum(len(secret_names) for secret_names in env_secrets.values())
+    index = 0
+    location = "$($env:TARGET_ORG)/$($env:TARGET_REPO):$($env:ENVIRONMENT)"
+
+    for env_name, secret_names in env_secrets.items():
+        for secret_name in secret_names:
+            index += 1
+            target_name = name_map.get(secret_name, secret_name)
+            rewrite = ""
+            if secret_name in (value_rewrites or {}):
+                rewrite = "            $env:SECRET_VALUE = Edit-SecretValue $env:SECRET_NAME $env:SECRET_VALUE\n"
+            body = f'''
+            {_SET_SECRET_POWERSHELL}
+{_edit_value_powershell(value_rewrites, secret_name)}
+            Write-Output '=========================================='
+            Write-Output "Migrating environment secret: $($env:ENVIRONMENT) - $($env:SECRET_NAME)"
+            Write-Output '=========================================='
+{rewrite}
+            $ValueBytes = [Text.Encoding]::UTF8.GetByteCount([string]$env:SECRET_VALUE)
+            if ($ValueBytes -gt {SECRET_VALUE_LIMIT_BYTES}) {{
+              {secret_marker_powershell("failed", "environment", location)}
+              throw "'$($env:SECRET_NAME)' is $ValueBytes bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+            }}
+
+            # Create secret in target environment with the value from workflow secrets
+            $Scope = @('--repo', "$($env:TARGET_ORG)/$($env:TARGET_REPO)", '--env', $env:ENVIRONMENT)
+            if (Set-TargetSecret $env:TARGET_SECRET_NAME $env:SECRET_VALUE $Scope) {{
+              Write-Output "OK: Migrated '$($env:SECRET_NAME)' to $($env:ENVIRONMENT) as '$($env:TARGET_SECRET_NAME)'"
+              {secret_marker_powershell("ok", "environment", location)}
+              Write-Output "{MARKER} progress environment-secrets {index}/{total}"
+            }} else {{
+              {secret_marker_powershell("failed", "environment", location)}
+              throw "Failed to create secret '$($env:TARGET_SECRET_NAME)' in target environment '$($env:ENVIRONMENT)'"
+            }}'''
+            step = f"""      - name: {_yaml_quoted(f"Migrate {env_name} - {secret_name}{step_suffix}")}
+        env:
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          TARGET_REPO: {_yaml_quoted(target_repo)}
+          ENVIRONMENT: {_yaml_quoted(environment_map.get(env_name, env_name))}
+          SECRET_NAME: {_yaml_quoted(secret_name)}
+          TARGET_SECRET_NAME: {_yaml_quoted(target_name)}
+          SECRET_VALUE: {_secret_expression(secret_name)}{_value_rewrites_env(value_rewrites, secret_name)}
+          GH_TOKEN: {target_token}
+        run: |
+          {phase_powershell("environment-secrets", f"Environment secret {env_name} - {secret_name}{step_suffix}", body)}
+        shell: powershell
+"""
+            steps.append(step)
+
+    return "\n".join(steps)
+
+
+def generate_org_secret_steps_powershell(org_secrets: List[str], target_org: str, name_map: Optional[Dict[str, str]] = None, scopes: Optional[Dict[str, OrgSecretScope]] = None, value_rewrites: Optional[Dict[str, List[Dict]]] = None, target_token: str = _TARGET_PAT) -> str:
+    """Generate the Windows (PowerShell) version of the organization secret steps."""
+    steps = []
+    name_map = name_map or {}
+    scopes = scopes or {}
+    location = "$($env:TARGET_ORG)"
+
+    for index, secret_name in enumerate(org_secrets, start=1):
+        target_name = name_map.get(secret_name, secret_name)
+        scope = scopes.get(secret_name)
+        visibility = scope.visibility if scope else ""
+        selected_repos = ",".join(scope.repositories) if scope else ""
+        rewrite = ""
+        if secret_name in (value_rewrites or {}):
+            rewrite = "            $env:SECRET_VALUE = Edit-SecretValue $env:SECRET_NAME $env:SECRET_VALUE\n"
+        body = f'''
+            {_SET_SECRET_POWERSHELL}
+{_edit_value_powershell(value_rewrites, secret_name)}
+            Write-Output '=========================================='
+            Write-Output "Migrating organization secret: $($env:SECRET_NAME)"
+            Write-Output '=========================================='
+{rewrite}
+            $ValueBytes = [Text.Encoding]::UTF8.GetByteCount([string]$env:SECRET_VALUE)
+            if ($ValueBytes -gt {SECRET_VALUE_LIMIT_BYTES}) {{
+              {secret_marker_powershell("failed", "organization", location)}
+              throw "'$($env:SECRET_NAME)' is $ValueBytes bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+            }}
+
+            $Scope = @('--org', $env:TARGET_ORG)
+            if ($env:VISIBILITY) {{ $Scope += @('--visibility', $env:VISIBILITY) }}
+            if ($env:VISIBILITY -eq 'selected' -and $env:SELECTED_REPOS) {{ $Scope += @('--repos', $env:SELECTED_REPOS) }}
+
+            # Create secret in target organization with the value from workflow secrets
+            if (Set-TargetSecret $env:TARGET_SECRET_NAME $env:SECRET_VALUE $Scope) {{
+              Write-Output "OK: Migrated '$($env:SECRET_NAME)' to organization '$($env:TARGET_ORG)' as '$($env:TARGET_SECRET_NAME)'"
+              {secret_marker_powershell("ok", "organization", location)}
+              Write-Output "{MARKER} progress organization-secrets {index}/{len(org_secrets)}"
+            }} else {{
+              {secret_marker_powershell("failed", "organization", location)}
+              throw "Failed to create secret '$($env:TARGET_SECRET_NAME)' in target organization '$($env:TARGET_ORG)'"
+            }}
+
+            # Read back the selected repositories and re-apply until they match the intended scope
+            if ($env:VISIBILITY -eq 'selected') {{
+              $Expected = @($env:SELECTED_REPOS -split ',' | Where-Object {{ $_ }} | Sort-Object)
+              foreach ($Attempt in 1..3) {{
+                $Actual = @(& {{
+                  $ErrorActionPreference = 'Continue'
+                  gh api --paginate "orgs/$($env:TARGET_ORG)/actions/secrets/$($env:TARGET_SECRET_NAME)/repositories" --jq '.repositories[].name'
+                }} | Where-Object {{ $_ }} | Sort-Object)
+                $Missing = @($Expected | Where-Object {{ $Actual -notcontains $_ }}) -join ','
+                $Unexpected = @($Actual | Where-Object {{ $Expected -notcontains $_ }}) -join ','
+                if (-not $Missing -and -not $Unexpected) {{
+                  Write-Output "OK: Verified repository scope of '$($env:TARGET_SECRET_NAME)'"
+                  break
+                }}
+                Write-Output "WARNING: Scope mismatch for '$($env:TARGET_SECRET_NAME)' (attempt $Attempt/3): missing [$Missing], unexpected [$Unexpected]"
+                if ($Attempt -eq 3) {{
+                  {secret_marker_powershell("failed", "organization", location)}
+                  throw "Repository scope of '$($env:TARGET_SECRET_NAME)' does not match the source after 3 attempts"
+                }}
+                Start-Sleep -Seconds ($Attempt * 5)
+                [void](Set-TargetSecret $env:TARGET_SECRET_NAME $env:SECRET_VALUE $Scope)
+              }}
+            }}'''
+        step = f"""      - name: {_yaml_quoted(f"Migrate Org Secret - {secret_name}")}
+        env:
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          SECRET_NAME: {_yaml_quoted(secret_name)}
+          TARGET_SECRET_NAME: {_yaml_quoted(target_name)}
+          VISIBILITY: {_yaml_quoted(visibility)}
+          SELECTED_REPOS: {_yaml_quoted(selected_repos)}
+          SECRET_VALUE: {_secret_expression(secret_name)}{_value_rewrites_env(value_rewrites, secret_name)}
+          GH_TOKEN: {target_token}
+        run: |
+          {phase_powershell("organization-secrets", f"Organization secret {secret_name}", body)}
+        shell: powershell
+"""
+        steps.append(step)
+
+    return "\n".join(steps)
+
+
+def generate_repository_secret_step_powershell(
+    target_org: str,
+    target_repo: str,
+    name_map: Optional[Dict[str, str]] = None,
+    policy: Optional[SecretPolicy] = None,
+    skip_secrets: Optional[List[str]] = None,
+    step_name: str = "Populate Repository Secrets",
+    step_id: str = "migrate",
+    phase_title: str = "Repository secrets",
+    secret_names: Optional[List[str]] = None,
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None,
+    target_token: str = _TARGET_PAT
+) -> str:
+    """Generate the Windows (PowerShell) version of the repository secret step.
     
-    # Org-to-org Migration flow
-    if org_secrets:
-        migration_steps += generate_org_secret_steps(org_secrets, target_org)
-        env_steps = ""
-    else:
-        # Environment secrets only for repo-to-repo migrations
-        env_steps = ""
-        if env_secrets:
-            env_steps = generate_environment_secret_steps(env_secrets, source_org, source_repo, target_org, target_repo)
-    
-    workflow = f"""name: move-secrets
-on:
-  push:
-    branches: [ "{branch_name}" ]
-permissions:
-  contents: write
-  repository-projects: write
-jobs:
-  migrate-repo-secrets:
-    runs-on: ubuntu-latest
-    steps:
-{migration_steps}
-{env_steps if env_steps else '      # No environment secrets to migrate'}
+    Policy patterns are matched with -like, which is case-insensitive like the
+    bash step's nocasematch globs.
+    """
+    policy = policy or SecretPolicy()
+    location = "$($env:TARGET_ORG)/$($env:TARGET_REPO)"
+    internal = ", ".join(powershell_quoted(name) for name in SYSTEM_SECRETS)
+    rewrite = ""
+    if value_rewrites:
+        rewrite = "              $Secret.Value = Edit-SecretValue $Secret.Name ([string]$Secret.Value)\n"
+    body = f'''
+            {_SET_SECRET_POWERSHELL}
+{_edit_value_powershell(value_rewrites)}
+            $MigrationFailed = $false
+
+            # Secret policy: deny patterns always win; a non-empty allowlist limits what is migrated
+            $Deny = @($env:DENY_PATTERNS -split ' ' | Where-Object {{ $_ }})
+            $Allow = @($env:ALLOW_PATTERNS -split ' ' | Where-Object {{ $_ }})
+            function Test-PolicyAllows([string]$Name) {{
+              foreach ($Pattern in $Deny) {{ if ($Name -like $Pattern) {{ return $false }} }}
+              if ($Allow.Count -eq 0) {{ return $true }}
+              foreach ($Pattern in $Allow) {{ if ($Name -like $Pattern) {{ return $true }} }}
+              return $false
+            }}
+
+            Write-Output 'Populating secrets in target repository...'
+            $Internal = @({internal})
+            $Secrets = @(($env:REPO_SECRETS | ConvertFrom-Json).PSObject.Properties | Where-Object {{ $Internal -notcontains $_.Name }})
+            # ForEach-Object unrolls the array Windows PowerShell's ConvertFrom-Json emits as one object
+            $Skip = @($env:SKIP_SECRETS | ConvertFrom-Json | ForEach-Object {{ $_ }})
+            $NameMap = $env:NAME_MAP | ConvertFrom-Json
+            $Total = $Secrets.Count
+            $Done = 0
+            foreach ($Secret in $Secrets) {{
+              $Done++
+              if ($Done % {PROGRESS_BATCH_SIZE} -eq 0 -or $Done -eq $Total) {{
+                Write-Output "{MARKER} progress repository-secrets $Done/$Total"
+              }}
+              if (-not (Test-PolicyAllows $Secret.Name)) {{
+                Write-Output "Skipping $($Secret.Name) (blocked by secret policy)"
+                continue
+              }}
+              if ($Skip -contains $Secret.Name) {{
+                Write-Output "Skipping $($Secret.Name) (already exists on target)"
+                continue
+              }}
+              $TargetName = $Secret.Name
+              if ($NameMap.PSObject.Properties[$Secret.Name]) {{ $TargetName = $NameMap.PSObject.Properties[$Secret.Name].Value }}
+              Write-Output "Processing: $($Secret.Name)"
+{rewrite}
+              $ValueBytes = [Text.Encoding]::UTF8.GetByteCount([string]$Secret.Value)
+              if ($ValueBytes -gt {SECRET_VALUE_LIMIT_BYTES}) {{
+                Write-Output "ERROR: '$($Secret.Name)' is $ValueBytes bytes; GitHub limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes"
+                {secret_marker_powershell("failed", "repository", location, "$TargetName")}
+                $MigrationFailed = $true
+                continue
+              }}
+
+              # Create secret in target repo using target PAT
+              if (Set-TargetSecret $TargetName ([string]$Secret.Value) @('--repo', "$($env:TARGET_ORG)/$($env:TARGET_REPO)")) {{
+                Write-Output "OK: Created '$TargetName' in target repo"
+                {secret_marker_powershell("ok", "repository", location, "$TargetName")}
+              }} else {{
+                Write-Output "ERROR: Failed to create secret $TargetName"
+                {secret_marker_powershell("failed", "repository", location, "$TargetName")}
+                $MigrationFailed = $true
+              }}
+            }}
+
+            if ($MigrationFailed) {{
+              Write-Output ''
+              Write-Output 'WARNING: The SECRETS_MIGRATOR_TARGET_PAT MUST be manually deleted from source repo!'
+              throw 'MIGRATION FAILED - Some secrets could not be created'
+            }}
+
+            Write-Output "OK: All secrets migrated successfully!"'''
+    return f"""      - name: {_yaml_quoted(step_name)}
+        id: {step_id}
+        env:
+          REPO_SECRETS: {_secrets_json(secret_names)}
+          NAME_MAP: {_yaml_quoted(json.dumps(name_map or {}, sort_keys=True))}
+          SKIP_SECRETS: {_yaml_quoted(json.dumps(sorted(skip_secrets or [])))}
+          DENY_PATTERNS: {_yaml_quoted(" ".join(policy.deny))}
+          ALLOW_PATTERNS: {_yaml_quoted(" ".join(policy.allow))}{_value_rewrites_env(value_rewrites)}
+          TARGET_ORG: {_yaml_quoted(target_org)}
+          TARGET_REPO: {_yaml_quoted(target_repo)}
+          GH_TOKEN: {target_token}
+        run: |
+          {phase_powershell("repository-secrets", phase_title, body)}
+        shell: powershell
+"""
+
+
+# jq program building a plugin's put request; values come from $PLUGIN_SECRETS, never argv
+_PLUGIN_REQUEST_JQ = (
+    "(env.PLUGIN_SECRETS | fromjson) as $values | "
+    f"{{protocol: {PLUGIN_PROTOCOL}, operation: \"put\", destination: $config, secrets: [$items[] | . as $item | "
+    "$item + {value: (($values[$item.source_name] // error(\"\\($item.source_name) is not available to the "
+    f"workflow\")) | reduce ($rules[$item.source_name] // [])[] as $rule (.; {_REWRITE_RULE_JQ}))}}]}}"
+)
+# jq program listing the outcome of every item of a put response (tab-separated)
+_PLUGIN_RESULTS_JQ = (
+    ". as $response | $items[] | . as $item | "
+    "([$response.results[] | objects | select(.id == $item.id)] | first) as $result | "
+    "(if $item.environment != \"\" then \"\\($item.environment)/\\($item.name)\" else $item.name end) as $target | "
+    "if $result == null then [\"failed\", $target, \"no result\"] "
+    "elif $result.ok == true then [\"ok\", $target, \"\"] "
+    "else [\"failed\", $target, ($result.error // \"failed\" | tostring)] end | @tsv"
+)
+
+
+def generate_destination_steps(
+    plugin: DestinationPlugin,
+    items: List[Dict],
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None
+) -> str:
+    """Generate the step handing the migrated secrets to a destination plugin (bash).
+
+    The step pipes one put request to the plugin, which must already be on
+    the runner, and reports every item: it fails unless the plugin confirms
+    each one.
+
+    Args:
+        plugin: Plugin definition
+        items: What the plugin receives (see destinations.destination_items)
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        rewriting their values, in order
+    """
+    phase = f"destination-{plugin.name}"
+    sources = sorted({item["source_name"] for item in items})
+    rewrites = {name: rules for name, rules in (value_rewrites or {}).items() if name in sources}
+    credentials = "".join(
+        f"\n          {variable}: {_secret_expression(secret)}"
+        for variable, secret in sorted(plugin.credentials.items())
+    )
+    return f"""      - name: {_yaml_quoted(f"Deliver to destination plugin {plugin.name}")}
+        env:
+          PLUGIN_COMMAND: {_yaml_quoted(json.dumps(plugin.command))}
+          PLUGIN_CONFIG: {_yaml_quoted(json.dumps(plugin.config, sort_keys=True))}
+          PLUGIN_ITEMS: {_yaml_quoted(json.dumps(items, sort_keys=True))}
+          PLUGIN_SECRETS: {_secrets_json(sources)}
+          VALUE_REWRITES: {_yaml_quoted(json.dumps(rewrites, sort_keys=True))}{credentials}
+        run: |
+          #!/bin/bash
+          set -eo pipefail
+          {phase_shell(phase, f"Destination plugin {plugin.name}")}
+
+          mapfile -t COMMAND < <(jq -r '.[]' <<< "$PLUGIN_COMMAND")
+          TOTAL=$(jq 'length' <<< "$PLUGIN_ITEMS")
+          echo "Delivering $TOTAL secret(s) to destination plugin {plugin.name}..."
+          # The request holds the values: built in memory and piped to the plugin by a builtin, never in argv
+          REQUEST=$(jq -nc --argjson items "$PLUGIN_ITEMS" --argjson config "$PLUGIN_CONFIG" \\
+            --argjson rules "$VALUE_REWRITES" '{_PLUGIN_REQUEST_JQ}')
+          RESPONSE=$(printf '%s' "$REQUEST" | "${{COMMAND[@]}}")
+          unset REQUEST
+          if ! jq -e '.protocol == {PLUGIN_PROTOCOL} and (.results | type == "array")' <<< "$RESPONSE" >/dev/null 2>&1; then
+            echo "❌ ERROR: destination plugin {plugin.name} did not answer with a protocol {PLUGIN_PROTOCOL} put response"
+            exit 1
+          fi
+
+          FAILED=0
+          mapfile -t OUTCOMES < <(jq -r --argjson items "$PLUGIN_ITEMS" '{_PLUGIN_RESULTS_JQ}' <<< "$RESPONSE")
+          if [ "${{#OUTCOMES[@]}}" -ne "$TOTAL" ]; then
+            echo "❌ ERROR: could not read the results of destination plugin {plugin.name}"
+            exit 1
+          fi
+          for LINE in "${{OUTCOMES[@]}}"; do
+            IFS=$'\\t' read -r OUTCOME LABEL ERROR <<< "$LINE"
+            if [ "$OUTCOME" = "ok" ]; then
+              echo "✓ Delivered '$LABEL' to {plugin.name}"
+            else
+              echo "❌ ERROR: {plugin.name} did not store '$LABEL': $ERROR"
+              FAILED=1
+            fi
+          done
+          echo "{MARKER} progress {phase} $TOTAL/$TOTAL"
+          if [ $FAILED -eq 1 ]; then
+            echo "❌ Destination plugin {plugin.name} FAILED for some secrets"
+            exit 1
+          fi
+        shell: bash
+"""
+
+
+def _cleanup_env(branch_name: str, delivery: str, base_branch: str, workflow_path: str, approval_environment: str) -> str:
+    """env: lines handing the cleanup step's names to its script, which never embeds them."""
+    lines = [f"MIGRATION_BRANCH: {_yaml_quoted(branch_name)}"]
+    if approval_environment:
+        lines.append(f"APPROVAL_ENVIRONMENT: {_yaml_quoted(approval_environment)}")
+    if delivery == "pull-request":
+        lines += [f"BASE_BRANCH: {_yaml_quoted(base_branch)}", f"WORKFLOW_PATH: {_yaml_quoted(workflow_path)}"]
+    return "".join(f"\n          {line}" for line in lines)
+
+
+def generate_cleanup_step_powershell(branch_name: str, delivery: str = "push", base_branch: str = "", workflow_path: str = "", target_pat: bool = True, approval_environment: str = "") -> str:
+    """Generate the Windows (PowerShell) version of the cleanup step."""
+    temporary = ["SECRETS_MIGRATOR_TARGET_PAT", "SECRETS_MIGRATOR_SOURCE_PAT"] if target_pat else ["SECRETS_MIGRATOR_SOURCE_PAT"]
+    scope = "@('--repo', $env:GITHUB_REPOSITORY)"
+    if approval_environment:
+        scope = "@('--repo', $env:GITHUB_REPOSITORY, '--env', $env:APPROVAL_ENVIRONMENT)"
+    notice = ""
+    if delivery == "pull-request":
+        notice = '''
+            Write-Output ''
+            Write-Output "::notice::$($env:WORKFLOW_PATH) was merged into $($env:BASE_BRANCH); remove it in a follow-up pull request"'''
+    body = f'''
+            $ErrorActionPreference = 'Continue'
+            $CleanupFailed = $false
+
+            Write-Output 'Cleaning up temporary secrets from source repo...'
+            $Temporary = @({", ".join(powershell_quoted(name) for name in temporary)})
+            $Scope = {scope}
+            foreach ($Name in $Temporary) {{
+              gh secret delete $Name @Scope | Out-Host
+              if ($LASTEXITCODE -eq 0) {{
+                Write-Output "OK: Successfully deleted $Name"
+              }} else {{
+                Write-Output "ERROR: Failed to delete $Name - THIS IS CRITICAL!"
+                $CleanupFailed = $true
+              }}
+            }}
+
+            if ($CleanupFailed) {{
+              Write-Output ''
+              Write-Output "MANUAL ACTION REQUIRED: Please delete remaining temporary secrets from $($env:GITHUB_REPOSITORY)"
+              foreach ($Name in $Temporary) {{ Write-Output "  - $Name" }}
+            }}
 
-      - name: Cleanup (Always)
+            Write-Output ''
+            Write-Output 'Deleting migration branch...'
+            gh api --method DELETE "repos/$($env:GITHUB_REPOSITORY)/git/refs/heads/$($env:MIGRATION_BRANCH)" 2>$null | Out-Null
+            if ($LASTEXITCODE -eq 0) {{
+              Write-Output 'OK: Successfully deleted migration branch'
+            }} else {{
+              Write-Output 'Migration branch already deleted or does not exist (this is okay)'
+            }}
+
+            if ($CleanupFailed) {{
+              throw "CLEANUP INCOMPLETE - delete the temporary secrets from $($env:GITHUB_REPOSITORY) manually"
+            }}
+{notice}
+            Write-Output ''
+            Write-Output "OK: Cleanup complete!"'''
+    return f"""      - name: Cleanup (Always)
         if: always()
         env:
           GH_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}}}
-          GITHUB_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}}}
+          GITHUB_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}}}{_cleanup_env(branch_name, delivery, base_branch, workflow_path, approval_environment)}
         run: |
-          #!/bin/bash
-          set -e
+          {phase_powershell("cleanup", "Cleanup", body)}
+        shell: powershell
+"""
 
-          CLEANUP_FAILED=0
 
-          echo "Cleaning up temporary secrets from source repo..."
-          
-          if gh secret delete SECRETS_MIGRATOR_TARGET_PAT --repo ${{{{ github.repository }}}}; then
+def pin_gh_hosts(workflow: str, source_host: str = GITHUB_COM, target_host: str = GITHUB_COM, target_token: str = _TARGET_PAT) -> str:
+    """Point each step's gh commands at the host its token belongs to.
+    
+    gh talks to github.com unless GH_HOST names another host, so steps using
+    the target token (target_token, the expression the steps were generated
+    with) get the target host and the cleanup step (source token) the source
+    host; github.com hosts are left implicit.
+    """
+    source_token = "${{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}"
+    for token, host in ((target_token, target_host), (source_token, source_host)):
+        if host == GITHUB_COM:
+            continue
+        workflow = re.sub(
+            rf"^( *)GH_TOKEN: {re.escape(token)}$",
+            lambda match: f"{match.group(0)}\n{match.group(1)}GH_HOST: {_yaml_quoted(host)}",
+            workflow, flags=re.MULTILINE
+        )
+    return workflow
+
+
+def _merged_workflow_notice() -> str:
+    """Cleanup lines reminding that a merged workflow stays on the base branch."""
+    return (
+        '          echo ""\n'
+        '          echo "::notice::$WORKFLOW_PATH was merged into $BASE_BRANCH; remove it in a follow-up pull request"\n'
+    )
+
+
+def generate_cleanup_step(branch_name: str, delivery: str = "push", base_branch: str = "", workflow_path: str = "", target_pat: bool = True, approval_environment: str = "") -> str:
+    """Generate the step removing the temporary secrets and the migration branch, even after failures.
+    
+    target_pat is False when the workflow mints its target token from a
+    GitHub App, so no SECRETS_MIGRATOR_TARGET_PAT was stored. With an
+    approval_environment, the temporary secrets are that environment's.
+    """
+    scope = '--repo "$GITHUB_REPOSITORY"'
+    if approval_environment:
+        scope += ' --env "$APPROVAL_ENVIRONMENT"'
+    delete_target_pat = f"""
+          if gh secret delete SECRETS_MIGRATOR_TARGET_PAT {scope}; then
             echo "✓ Successfully deleted SECRETS_MIGRATOR_TARGET_PAT"
           else
             echo "ERROR: Failed to delete SECRETS_MIGRATOR_TARGET_PAT - THIS IS CRITICAL!"
             CLEANUP_FAILED=1
           fi
+""" if target_pat else ""
+    list_target_pat = '\n            echo "  - SECRETS_MIGRATOR_TARGET_PAT"' if target_pat else ""
+    return f"""      - name: Cleanup (Always)
+        if: always()
+        env:
+          GH_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}}}
+          GITHUB_TOKEN: ${{{{ secrets.SECRETS_MIGRATOR_SOURCE_PAT }}}}{_cleanup_env(branch_name, delivery, base_branch, workflow_path, approval_environment)}
+        run: |
+          #!/bin/bash
+          set -e
+          {phase_shell("cleanup", "Cleanup")}
 
-          if gh secret delete SECRETS_MIGRATOR_SOURCE_PAT --repo ${{{{ github.repository }}}}; then
+          CLEANUP_FAILED=0
+
+          echo "Cleaning up temporary secrets from source repo..."
+          {delete_target_pat}
+          if gh secret delete SECRETS_MIGRATOR_SOURCE_PAT {scope}; then
             echo "✓ Successfully deleted SECRETS_MIGRATOR_SOURCE_PAT"
           else
             echo "ERROR: Failed to delete SECRETS_MIGRATOR_SOURCE_PAT - THIS IS CRITICAL!"
@@ -221,14 +1460,13 @@ jobs:
 
           if [ $CLEANUP_FAILED -eq 1 ]; then
             echo ""
-            echo "MANUAL ACTION REQUIRED: Please delete remaining temporary secrets from ${{{{ github.repository }}}}"
-            echo "  - SECRETS_MIGRATOR_TARGET_PAT"
+            echo "MANUAL ACTION REQUIRED: Please delete remaining temporary secrets from $GITHUB_REPOSITORY"{list_target_pat}
             echo "  - SECRETS_MIGRATOR_SOURCE_PAT"
           fi
 
           echo ""
           echo "Deleting migration branch..."
-          if gh api --method DELETE repos/${{{{ github.repository }}}}/git/refs/heads/{branch_name} 2>/dev/null; then
+          if gh api --method DELETE "repos/$GITHUB_REPOSITORY/git/refs/heads/$MIGRATION_BRANCH" 2>/dev/null; then
             echo "✓ Successfully deleted migration branch"
           else
             echo "ℹ️  Migration branch already deleted or does not exist (this is okay)"
@@ -239,13 +1477,256 @@ jobs:
             echo "ERROR: CLEANUP INCOMPLETE"
             if [ ! -z "$CLEANUP_FAILED" ]; then
               echo "MANUAL ACTION REQUIRED:"
-              echo "  - Delete temporary secrets from ${{{{ github.repository }}}}"
+              echo "  - Delete temporary secrets from $GITHUB_REPOSITORY"
             fi
             exit 1
           fi
 
-          echo ""
+{_merged_workflow_notice() if delivery == "pull-request" else ""}          echo ""
           echo "✓ Cleanup complete!"
         shell: bash
 """
-    return workflow.strip()
+
+
+def generate_workflow(
+    source_org: str, 
+    source_repo: str, 
+    target_org: str, 
+    target_repo: str, 
+    branch_name: str, 
+    env_secrets: Optional[Dict[str, List[str]]] = None,
+    org_secrets: Optional[List[str]] = None,
+    gh_cli_version: str = GH_CLI_PINNED_VERSION,
+    gh_cli_digests: Optional[Dict[str, str]] = None,
+    name_map: Optional[Dict[str, str]] = None,
+    org_secret_scopes: Optional[Dict[str, OrgSecretScope]] = None,
+    policy: Optional[SecretPolicy] = None,
+    skip_secrets: Optional[List[str]] = None,
+    environment_map: Optional[Dict[str, str]] = None,
+    runner_labels: Optional[List[str]] = None,
+    delivery: str = "push",
+    base_branch: str = "",
+    workflow_path: str = "",
+    extra_targets: Optional[List[FanOutTarget]] = None,
+    repo_secrets: bool = True,
+    source_host: str = GITHUB_COM,
+    target_host: str = GITHUB_COM,
+    runner_os: str = "ubuntu",
+    target_app_id: str = "",
+    approval_environment: str = "",
+    expected_actor: str = "",
+    timeout_minutes: int = DEFAULT_WORKFLOW_TIMEOUT_MINUTES,
+    engine: str = "shell",
+    action_ref: str = "",
+    repo_secret_names: Optional[List[str]] = None,
+    promoted_secrets: Optional[List[str]] = None,
+    promoted_scopes: Optional[Dict[str, OrgSecretScope]] = None,
+    value_rewrites: Optional[Dict[str, List[Dict]]] = None,
+    destinations: Optional[List[DestinationPlugin]] = None
+) -> str:
+    """Generate the GitHub Actions workflow for secret migration.
+    
+    Args:
+        source_org: Source organization
+        source_repo: Source repository
+        target_org: Target organization
+        target_repo: Target repository
+        branch_name: Migration branch name
+        env_secrets: Optional dict of environment secrets to generate dynamic steps
+                     Example: {'production': ['DB_PASSWORD', 'API_KEY']}
+        org_secrets: Optional list of organization secret names for org-to-org migration
+                     Example: ['DB_PASSWORD', 'API_KEY', 'DEPLOY_TOKEN']
+        gh_cli_version: gh CLI version installed when the runner's gh is missing or too old
+        gh_cli_digests: Optional SHA-256 by asset name of gh_cli_version's archives, for
+                        releases not pinned in GH_CLI_SHA256 (see parse_gh_cli_checksums)
+        name_map: Optional dict mapping source secret names to target names (renames);
+                  secrets not in the map keep their name
+        org_secret_scopes: Optional dict mapping organization secret names to the
+                           visibility/selected repositories to apply on the target
+        policy: Optional deny/allow policy; the repository step re-checks every secret
+                exposed to the workflow (including inherited org secrets) against it
+        skip_secrets: Optional list of source repository secret names the repository
+                      step must not write (e.g. already present on the target)
+        environment_map: Optional dict routing source environments to target environments
+        runner_labels: Optional runner labels for `runs-on` (defaults to ubuntu-latest)
+        delivery: 'push' (run on push to branch_name) or 'pull-request' (run once merged)
+        base_branch: Branch the pull request targets (pull-request delivery)
+        workflow_path: Repository path of the workflow file (pull-request delivery)
+        extra_targets: Optional further target repositories in target_org (fan-out);
+                       each gets its own repository and environment secret steps
+        repo_secrets: Whether repository secrets are copied (False migrates only
+                      environment secrets)
+        source_host: GitHub host of the source repository the workflow runs in
+        target_host: GitHub host the secrets are written to
+        runner_os: Operating system of the runner ('ubuntu', 'windows' or 'macos');
+                   Windows runners get PowerShell steps, the others bash
+        target_app_id: GitHub App whose installation token the workflow mints for the
+                       target (from the SECRETS_MIGRATOR_TARGET_APP_KEY secret) instead
+                       of reading SECRETS_MIGRATOR_TARGET_PAT
+        approval_environment: Source environment the job runs in; its required
+                              reviewers must approve the run before any secret
+                              (including the temporary ones stored there) is read
+        expected_actor: Login allowed to trigger the job (the source token's user, who
+                        pushes the migration branch); runs started by anyone else are
+                        skipped before any secret is read
+        timeout_minutes: Job timeout in minutes
+        engine: 'shell' (a bash or PowerShell step per secret or target),
+                'github-script' (one actions/github-script step for all of them) or
+                'action' (one step using the published secrets-migrator-action)
+        action_ref: Pinned reference of the action ('owner/repo@<commit SHA>'; action engine)
+        repo_secret_names: Optional source repository secret names being migrated. When
+                           toJSON(secrets) would likely overflow one env: value (see
+                           chunk_secret_names), the secrets are read by name in chunks,
+                           one step per chunk; secrets not named, such as inherited
+                           organization secrets, are then no longer copied
+        promoted_secrets: Optional source repository secret names written as organization
+                          secrets of target_org instead of repository secrets (repo-to-repo
+                          migrations; list them in skip_secrets too)
+        promoted_scopes: Optional dict mapping promoted secret names to the visibility/
+                         selected repositories to apply on the target
+        value_rewrites: Optional dict mapping source secret names to the value rules
+                        (ValueRule.to_dict()) the workflow applies, in order, to their
+                        values before writing them
+        destinations: Optional destination plugins the migrated secrets are also
+                      handed to, once written to the target (bash runners only)
+
+    Raises:
+        ValueError: If runner_os is not one of RUNNER_OSES, engine not one of
+                    WORKFLOW_ENGINES, action_ref does not suit the engine, or
+                    destination plugins are used on a Windows runner
+    """
+    if runner_os not in RUNNER_OSES:
+        raise ValueError(f"unsupported runner OS '{runner_os}' (expected one of: {', '.join(RUNNER_OSES)})")
+    if engine not in WORKFLOW_ENGINES:
+        raise ValueError(f"unsupported workflow engine '{engine}' (expected one of: {', '.join(WORKFLOW_ENGINES)})")
+    check_action_ref(engine, action_ref)
+    if destinations and runner_os == "windows":
+        raise ValueError("destination plugins need a bash runner (ubuntu or macos)")
+    policy = policy or SecretPolicy()
+    if runner_os == "windows":
+        setup_step = generate_gh_cli_setup_step_powershell
+        repository_step = generate_repository_secret_step_powershell
+        org_steps = generate_org_secret_steps_powershell
+        environment_steps = generate_environment_secret_steps_powershell
+        cleanup = generate_cleanup_step_powershell
+    else:
+        setup_step = generate_gh_cli_setup_step
+        repository_step = generate_repository_secret_step
+        org_steps = generate_org_secret_steps
+        environment_steps = generate_environment_secret_steps
+        cleanup = generate_cleanup_step
+    trigger = workflow_trigger(branch_name, delivery, base_branch, workflow_path)
+    target_token = _APP_TOKEN if target_app_id else _TARGET_PAT
+
+    # Generate migration steps based on type
+    migration_steps = ""
+    
+    if engine in ("github-script", "action"):
+        # A single step migrates every target's repository and environment secrets
+        targets = [] if org_secrets else [FanOutTarget(target_repo, env_secrets, skip_secrets)] + list(extra_targets or [])
+        plan = migration_plan(
+            target_org, targets, name_map, policy, environment_map, repo_secrets,
+            org_secrets or promoted_secrets, org_secret_scopes or promoted_scopes, value_rewrites
+        )
+        # ...or, when its secrets are too large for one env: value, one step per chunk
+        chunks = []
+        if org_secrets:
+            chunks = chunk_secret_names(list(org_secrets), runner_os)
+        elif repo_secret_names is not None:
+            chunks = chunk_secret_names(list(dict.fromkeys(
+                list(repo_secret_names) + list(promoted_secrets or [])
+                + [name for target in targets for names in target.env_secrets.values() for name in names]
+            )), runner_os)
+        parts = [(plan, None)] if not chunks else [
+            (chunk_plan(plan, chunk, index, len(chunks)), chunk) for index, chunk in enumerate(chunks, 1)
+        ]
+        migration_steps = ""
+        for index, (part_plan, chunk) in enumerate(parts, 1):
+            step_name = _part("Migrate Secrets", index, len(parts))
+            step_id = "migrate" if index == 1 else f"migrate-part-{index}"
+            if engine == "action":
+                migration_steps += generate_action_step(part_plan, action_ref, target_host, chunk, step_name, step_id, target_token)
+            else:
+                migration_steps += generate_github_script_step(part_plan, chunk, step_name, step_id, target_token)
+        env_steps = "      # Environment secrets are migrated by the Migrate Secrets step"
+    else:
+        chunks = chunk_secret_names(list(repo_secret_names), runner_os) if repo_secret_names is not None else []
+        # Repo-to-repo: include repository secrets step
+        if not org_secrets and repo_secrets:
+            migration_steps = _chunked_repository_steps(
+                repository_step, chunks, target_org, target_repo, name_map, policy, skip_secrets,
+                value_rewrites=value_rewrites, target_token=target_token
+            )
+    
+        # Org-to-org Migration flow
+        if org_secrets:
+            migration_steps += org_steps(org_secrets, target_org, name_map, org_secret_scopes, value_rewrites, target_token)
+            env_steps = ""
+        elif extra_targets:
+            # Fan-out: the same secrets to every target, one set of steps per target
+            targets = [FanOutTarget(target_repo, env_secrets, skip_secrets)] + list(extra_targets)
+            migration_steps = ""
+            env_step_blocks = []
+            for index, target in enumerate(targets, 1):
+                if repo_secrets:
+                    migration_steps += _chunked_repository_steps(
+                        repository_step, chunks, target_org, target.repo, name_map, policy, target.skip_secrets,
+                        step_name=f"Populate Repository Secrets ({target_org}/{target.repo})",
+                        step_id="migrate" if index == 1 else f"migrate-{index}",
+                        phase_title=f"Repository secrets ({target.repo})",
+                        value_rewrites=value_rewrites, target_token=target_token
+                    )
+                if target.env_secrets:
+                    env_step_blocks.append(environment_steps(target.env_secrets, source_org, source_repo, target_org, target.repo, name_map, environment_map, f" ({target.repo})", value_rewrites, target_token))
+            env_steps = "\n".join(env_step_blocks)
+        else:
+            # Environment secrets only for repo-to-repo migrations
+            env_steps = ""
+            if env_secrets:
+                env_steps = environment_steps(env_secrets, source_org, source_repo, target_org, target_repo, name_map, environment_map, value_rewrites=value_rewrites, target_token=target_token)
+        if promoted_secrets and not org_secrets:
+            # Promoted repository secrets: organization secrets scoped to the target repositories
+            migration_steps += org_steps(promoted_secrets, target_org, name_map, promoted_scopes, value_rewrites, target_token)
+    
+    destination_steps = ""
+    if destinations:
+        # What was written to the target: secrets the workflow reads by name, never inherited ones
+        skipped = set(skip_secrets or [])
+        delivered_repo = [] if org_secrets or not repo_secrets else [
+            name for name in repo_secret_names or [] if name not in skipped
+        ]
+        items = destination_items(
+            delivered_repo, None if org_secrets else env_secrets,
+            list(org_secrets or []) + ([] if org_secrets else list(promoted_secrets or [])),
+            name_map, environment_map
+        )
+        destination_steps = "\n" + "\n".join(
+            generate_destination_steps(plugin, items, value_rewrites) for plugin in destinations
+        )
+    cleanup_step = cleanup(branch_name, delivery, base_branch, workflow_path, target_pat=not target_app_id, approval_environment=approval_environment)
+    approval = f"\n    environment: {_yaml_quoted(approval_environment)}" if approval_environment else ""
+    # Logins are letters, digits and dashes (plus '[bot]' for apps): safe in an expression literal
+    actor_guard = f"\n    if: github.actor == '{expected_actor}'" if expected_actor else ""
+    token_step = ""
+    if target_app_id:
+        target_repos = [] if org_secrets else [target_repo] + [target.repo for target in extra_targets or []]
+        token_step = generate_target_app_token_step(target_app_id, target_org, target_repos, target_host) + "\n"
+    workflow = f"""name: move-secrets
+{trigger}
+# Steps authenticate with the migration tokens, never with GITHUB_TOKEN
+permissions: {{}}
+# One migration at a time per repository; a second push waits instead of racing the cleanup
+concurrency:
+  group: gh-secrets-migrator
+  cancel-in-progress: false
+jobs:
+  migrate-repo-secrets:{actor_guard}
+    runs-on: {json.dumps(runner_labels) if runner_labels else f"{runner_os}-latest"}{approval}
+    timeout-minutes: {timeout_minutes}
+    steps:
+{setup_step(pinned_version=gh_cli_version, digests=gh_cli_digests)}
+{token_step}{migration_steps}
+{env_steps if env_steps else '      # No environment secrets to migrate'}{destination_steps}
+
+{cleanup_step}"""
+    return pin_gh_hosts(workflow.strip(), source_host, target_host, target_token)
@@ -0,0 +1,181 @@
+"""Static checks of a generated workflow before it is pushed to the source repository."""
+import re
+import shutil
+import os
+import subprocess  # nosec B404 - runs bash/actionlint on generated text, no shell
+import tempfile
+from typing import Any, List, Optional
+import yaml
+
+# Contexts available to expressions in a workflow job
+EXPRESSION_CONTEXTS = (
+    "github", "env", "vars", "job", "jobs", "steps", "runner", "secrets",
+    "strategy", "matrix", "needs", "inputs",
+)
+# Functions usable at the start of an expression
+EXPRESSION_FUNCTIONS = (
+    "always", "success", "failure", "cancelled", "contains", "startsWith", "endsWith",
+    "format", "join", "toJSON", "fromJSON", "hashFiles",
+)
+
+_EXPRESSION = re.compile(r"\$\{\{(.*?)\}\}", re.DOTALL)
+_IDENTIFIER = re.compile(r"[A-Za-z_][A-Za-z0-9_-]*")
+_STRING_LITERAL = re.compile(r"'(?:[^']|'')*'")
+# Actions and reusable workflows must be pinned to a full commit SHA (or a digest for images)
+_PINNED_USES = re.compile(r"^(\./.*|docker://[^@]+@sha256:[0-9a-f]{64}|[^@\s]+@[0-9a-f]{40})$")
+# Package installs from public registries, which locked-down runners cannot reach
+_RUNTIME_INSTALL = re.compile(
+    r"\b(npm (install|i|ci)|npx|yarn add|pnpm add|pip3? install|gem install|go install)\b"
+)
+
+
+def _expression_problems(where: str, text: str) -> List[str]:
+    problems = []
+    if text.count("${{") != len(_EXPRESSION.findall(text)):
+        problems.append(f"{where}: unterminated '${{{{' expression")
+    for body in _EXPRESSION.findall(text):
+        expression = _STRING_LITERAL.sub("''", body).strip()
+        if not expression:
+            problems.append(f"{where}: empty expression")
+            continue
+        if expression.count("(") != expression.count(")") or "'" in expression.replace("''", ""):
+            problems.append(f"{where}: malformed expression '{body.strip()}'")
+            continue
+        for name in _IDENTIFIER.findall(re.sub(r"\.[A-Za-z0-9_-]+", "", expression)):
+            if name in ("true", "false", "null") or name in EXPRESSION_FUNCTIONS:
+                continue
+            if name not in EXPRESSION_CONTEXTS:
+                problems.append(f"{where}: unknown context '{name}' in '{body.strip()}'")
+    return problems
+
+
+def _strings(node: Any, where: str):
+    """Yield (location, string) for every string in a parsed YAML node."""
+    if isinstance(node, str):
+        yield where, node
+    elif isinstance(node, dict):
+        for key, value in node.items():
+            yield from _strings(value, f"{where}.{key}")
+    elif isinstance(node, list):
+        for index, value in enumerate(node):
+            yield from _strings(value, f"{where}[{index}]")
+
+
+def bash_syntax_error(script: str) -> Optional[str]:
+    """Return bash's syntax error for a run script, or None if it parses (or bash is missing).
+
+    Expressions are substituted by the runner before bash sees the script, so
+    they are replaced with a plain word first.
+    """
+    bash = shutil.which("bash")
+    if not bash:
+        return None
+    result = subprocess.run(  # nosec B603 - fixed argv, script passed on stdin
+        [bash, "-n"], input=_EXPRESSION.sub("EXPR", script),
+        capture_output=True, text=True, timeout=30,
+    )
+    if result.returncode == 0:
+        return None
+    return re.sub(r"^\S*bash: ", "", result.stderr.strip(), flags=re.MULTILINE) or "syntax error"
+
+
+def lint_workflow(text: str, check_shell: bool = True) -> List[str]:
+    """Check a workflow for YAML, structure, expression and shell syntax errors.
+
+    Catches template and quoting mistakes (e.g. from unusual secret names)
+    before the workflow is pushed, instead of as a failed run on the source.
+    Expressions inside run (and github-script) scripts are reported as
+    well: the runner pastes their values into the script, so they must be
+    passed through env:.
+    Actions not pinned to a commit SHA and package installs at run time are
+    reported too, so the workflow keeps running on runners without registry
+    access.
+
+    Args:
+        text: Workflow YAML
+        check_shell: Also run `bash -n` over every bash run script (other shells,
+            such as the PowerShell of Windows runners, are not checked)
+
+    Returns:
+        Problems found; empty when the workflow looks valid
+    """
+    try:
+        document = yaml.safe_load(text)
+    except yaml.YAMLError as e:
+        return [f"invalid YAML: {e}"]
+    if not isinstance(document, dict):
+        return ["workflow must be a mapping"]
+    problems = []
+    # YAML 1.1 reads a bare 'on' key as True
+    if "on" not in document and True not in document:
+        problems.append("missing 'on' trigger")
+    jobs = document.get("jobs")
+    if not isinstance(jobs, dict) or not jobs:
+        return problems + ["missing 'jobs'"]
+
+    for job_id, job in jobs.items():
+        if not isinstance(job, dict):
+            problems.append(f"jobs.{job_id}: must be a mapping")
+            continue
+        if "runs-on" not in job and "uses" not in job:
+            problems.append(f"jobs.{job_id}: missing 'runs-on'")
+        if isinstance(job.get("uses"), str) and not _PINNED_USES.match(job["uses"]):
+            problems.append(f"jobs.{job_id}: '{job['uses']}' is not pinned to a commit SHA")
+        steps = job.get("steps", [])
+        if not isinstance(steps, list):
+            problems.append(f"jobs.{job_id}.steps: must be a list")
+            continue
+        for index, step in enumerate(steps):
+            where = f"jobs.{job_id}.steps[{index}]"
+            if not isinstance(step, dict):
+                problems.append(f"{where}: must be a mapping")
+                continue
+            if ("run" in step) == ("uses" in step):
+                problems.append(f"{where}: needs exactly one of 'run' or 'uses'")
+            if isinstance(step.get("uses"), str) and not _PINNED_USES.match(step["uses"]):
+                problems.append(f"{where}: '{step['uses']}' is not pinned to a commit SHA")
+            run = step.get("run")
+            inputs = step.get("with") if isinstance(step.get("with"), dict) else {}
+            for kind, script in (("run", run), ("github-script", inputs.get("script"))):
+                if isinstance(script, str) and "${{" in script:
+                    # Substituted into the script text, so a quote or `$(...)` in a name would run
+                    problems.append(
+                        f"{where}: expression in a {kind} script; pass it through env: instead"
+                    )
+            install = _RUNTIME_INSTALL.search(run) if isinstance(run, str) else None
+            if install:
+                problems.append(f"{where}: installs packages at run time ('{install.group(0)}')")
+            if check_shell and isinstance(step.get("run"), str) and (
+                step.get("shell", "bash") == "bash"
+            ):
+                error = bash_syntax_error(step["run"])
+                if error:
+                    name = step.get("name", "unnamed")
+                    problems.append(f"{where} ({name}): shell syntax error: {error}")
+
+    for key, node in document.items():
+        for where, value in _strings(node, "on" if key is True else str(key)):
+            problems.extend(_expression_problems(where, value))
+    return problems
+
+
+def run_actionlint(text: str) -> Optional[List[str]]:
+    """Run actionlint over the workflow if it is installed.
+
+    Returns:
+        actionlint's findings (empty if clean), or None when actionlint is not on PATH
+    """
+    actionlint = shutil.which("actionlint")
+    if not actionlint:
+        return None
+    handle, path = tempfile.mkstemp(suffix=".yml")
+    try:
+        with os.fdopen(handle, "w", encoding="utf-8") as workflow_file:
+            workflow_file.write(text)
+        result = subprocess.run(  # nosec B603 - fixed argv
+            [actionlint, "-no-color", "-oneline", path],
+            capture_output=True, text=True, timeout=60,
+        )
+    finally:
+        os.unlink(path)
+    return [line.replace(path, "workflow") for line in result.stdout.splitlines() if line.strip()]
@@ -0,0 +1,196 @@
+"""Phase and secret markers printed by the generated workflow, and their parsers.
+
+The in-runner scripts print one marker line at each phase boundary, after
+each batch of secrets and for each secret set (or not) on the target.
+Marker lines carry names and counts only, never values.
+"""
+import re
+from typing import Dict, Iterable, List, Optional, Tuple
+
+MARKER = "[secrets-migrator]"
+PROGRESS_BATCH_SIZE = 25
+
+_MARKER_RE = re.compile(
+    r"\[secrets-migrator\] (?P<kind>phase-start|phase-end|progress) (?P<phase>\S+)"
+    r"(?: (?P<value>\S+))?"
+)
+# Line breaks and other control characters, kept out of the one-line titles of phases
+_CONTROL_CHARS = re.compile(r"[\x00-\x1f\x7f-\x9f\u2028\u2029]+")
+# PowerShell ends single-quoted strings at typographic single quotes too
+_POWERSHELL_QUOTES = re.compile(r"(['\u2018\u2019\u201a\u201b])")
+# Location is the rest of the line: environment names may contain spaces
+_SECRET_MARKER_RE = re.compile(
+    r"\[secrets-migrator\] secret (?P<outcome>ok|failed) "
+    r"(?P<level>repository|environment|organization) (?P<name>\S+) (?P<location>.+?)\s*$"
+)
+
+
+def phase_shell(phase: str, title: str) -> str:
+    """Return shell lines opening a log group and phase for the rest of a step.
+
+    An EXIT trap closes the group and prints the phase-end marker with the
+    step's outcome, so the boundary is logged even when the step fails.
+    The result is indented for a `run: |` block.
+    """
+    # The title lands in a double-quoted bash string (environment names may hold quotes)
+    title = _CONTROL_CHARS.sub(" ", title)
+    title = "".join(f"\\{char}" if char in '\\"$`' else char for char in title)
+    lines = [
+        f'echo "::group::{title}"',
+        f'echo "{MARKER} phase-start {phase}"',
+        "trap 'STATUS=$?; echo \"::endgroup::\"; "
+        f"if [ $STATUS -eq 0 ]; then echo \"{MARKER} phase-end {phase} ok\"; "
+        f"else echo \"{MARKER} phase-end {phase} failed\"; fi' EXIT",
+    ]
+    return "\n          ".join(lines)
+
+
+def secret_marker_shell(
+    outcome: str, level: str, location: str, name: str = "$TARGET_SECRET_NAME"
+) -> str:
+    """Return the shell line reporting whether a secret was set on the target.
+
+    name and location are expanded when the line runs; location is
+    'org/repo' for repository secrets, 'org/repo:environment' for
+    environment secrets and 'org' for organization secrets.
+    """
+    return f'echo "{MARKER} secret {outcome} {level} {name} {location}"'
+
+
+def powershell_quoted(value: str) -> str:
+    """Quote value as a PowerShell single-quoted (verbatim) string."""
+    return "'" + _POWERSHELL_QUOTES.sub(r"\1\1", value) + "'"
+
+
+def phase_powershell(phase: str, title: str, body: str) -> str:
+    """Return a PowerShell script running body as one log group and phase.
+
+    body signals failure by throwing; the phase-end marker then reports the
+    outcome and the script exits 1. body lines carry their full indentation
+    within the `run: |` block the result is indented for.
+    """
+    opening = [
+        f"Write-Output {powershell_quoted('::group::' + _CONTROL_CHARS.sub(' ', title))}",
+        f'Write-Output "{MARKER} phase-start {phase}"',
+        "$PhaseOk = $false",
+        "try {",
+    ]
+    closing = [
+        "  $PhaseOk = $true",
+        "} catch {",
+        '  Write-Output "ERROR: $($_.Exception.Message)"',
+        "} finally {",
+        "  Write-Output '::endgroup::'",
+        f'  if ($PhaseOk) {{ Write-Output "{MARKER} phase-end {phase} ok" }} '
+        f'else {{ Write-Output "{MARKER} phase-end {phase} failed" }}',
+        "}",
+        "if (-not $PhaseOk) { exit 1 }",
+    ]
+    indent = "\n          "
+    return indent.join(opening) + "\n" + body.strip("\n") + indent + indent.join(closing)
+
+
+def secret_marker_powershell(
+    outcome: str, level: str, location: str, name: str = "$env:TARGET_SECRET_NAME"
+) -> str:
+    """Return the PowerShell line reporting whether a secret was set on the target.
+
+    name and location are PowerShell expressions (e.g. '$TargetName' or
+    "$($env:TARGET_ORG)/$($env:TARGET_REPO)") expanded when the line runs.
+    """
+    return f'Write-Output "{MARKER} secret {outcome} {level} $({name}) {location}"'
+
+
+class PhaseStatus:
+    """State of one workflow phase reconstructed from the run log."""
+
+    def __init__(self, phase: str):
+        self.phase = phase
+        self.started = False
+        self.outcome: Optional[str] = None  # 'ok' or 'failed' once the phase ended
+        self.done = 0
+        self.total = 0
+
+    @property
+    def state(self) -> str:
+        """One of 'pending', 'running', 'ok' or 'failed'."""
+        if self.outcome:
+            return self.outcome
+        return "running" if self.started else "pending"
+
+
+def parse_phase_markers(log_text: str) -> List[PhaseStatus]:
+    """Parse phase markers from a workflow run log, in order of first appearance.
+
+    Lines may carry the timestamp prefix GitHub adds to downloaded logs.
+    """
+    phases: Dict[str, PhaseStatus] = {}
+    for line in log_text.splitlines():
+        match = _MARKER_RE.search(line)
+        if not match:
+            continue
+        status = phases.setdefault(match["phase"], PhaseStatus(match["phase"]))
+        kind, value = match["kind"], match["value"] or ""
+        # A phase may span several steps (one per secret); a failure sticks
+        if kind == "phase-start":
+            status.started = True
+            if status.outcome != "failed":
+                status.outcome = None
+        elif kind == "phase-end":
+            status.started = True
+            if status.outcome != "failed":
+                status.outcome = "ok" if value == "ok" else "failed"
+        else:
+            done, _, total = value.partition("/")
+            if done.isdigit() and total.isdigit():
+                status.done, status.total = int(done), int(total)
+    return list(phases.values())
+
+
+class SecretOutcome:
+    """Whether the workflow set one secret on one target."""
+
+    def __init__(self, level: str, location: str, name: str, ok: bool):
+        self.level = level  # 'repository', 'environment' or 'organization'
+        self.location = location
+        self.name = name  # target name
+        self.ok = ok
+
+    @property
+    def key(self) -> Tuple[str, str, str]:
+        """Identity of the secret across outcomes."""
+        return (self.level, self.location, self.name)
+
+    @property
+    def label(self) -> str:
+        """Human-readable location of the secret."""
+        return f"{self.location}/{self.name}"
+
+
+def parse_secret_markers(log_text: str) -> List[SecretOutcome]:
+    """Parse per-secret markers from a workflow run log, in order of first appearance.
+
+    A secret reported more than once (e.g. set, then failing its scope
+    check) keeps its last outcome.
+    """
+    outcomes: Dict[Tuple[str, str, str], SecretOutcome] = {}
+    for line in log_text.splitlines():
+        match = _SECRET_MARKER_RE.search(line)
+        if not match:
+            continue
+        outcome = SecretOutcome(
+            match["level"], match["location"], match["name"], match["outcome"] == "ok"
+        )
+        if outcome.key in outcomes:
+            outcomes[outcome.key].ok = outcome.ok
+        else:
+            outcomes[outcome.key] = outcome
+    return list(outcomes.values())
+
+
+def unconfirmed_secrets(
+    outcomes: Iterable[SecretOutcome], expected: Iterable[Tuple[str, str, str]]
+) -> List[Tuple[str, str, str]]:
+    """Return the expected (level, location, name) keys no marker reported."""
+    reported = {outcome.key for outcome in outcomes}
+    return [key for key in expected if key not in reported]
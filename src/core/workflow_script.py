@@ -0,0 +1,23 @@
+"""Runner-side migration logic shared by the github-script and action workflow engines.
+
+The logic itself is action/migrate.js, the script of the composite action
+published as renan-alm/secrets-migrator-action. The action engine's workflow
+`uses:` that action; the github-script engine inlines the same script into a
+single actions/github-script step. Either way it reads SOURCE_SECRETS (the
+workflow's secrets as JSON) and MIGRATION_PLAN (the JSON built by
+workflow_generator.migration_plan) from environment variables.
+"""
+import os
+
+# actions/github-script v7.0.1, pinned to its commit (action/action.yml uses the same)
+GITHUB_SCRIPT_ACTION = "actions/github-script@60a0d83039c74a4aee543508d2ffcb1c3799cdea"
+
+# Repository the composite action in action/ is published to, one tag per release
+ACTION_REPOSITORY = "renan-alm/secrets-migrator-action"
+
+ACTION_DIR = os.path.join(os.path.dirname(os.path.abspath(__file__)), "..", "..", "action")
+MIGRATE_JS_PATH = os.path.join(ACTION_DIR, "migrate.js")
+
+with open(MIGRATE_JS_PATH, encoding="utf-8") as _handle:
+    # Body of an async function called with github-script's `core` and `exec`
+    MIGRATION_SCRIPT = _handle.read() + "\nawait migrate({ core, exec });\n"
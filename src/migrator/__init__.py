@@ -0,0 +1,37 @@
+"""Programmatic interface of gh-secrets-migrator, for orchestrators embedding it.
+
+Everything the migrate command does is available without shelling out:
+
+    from src.migrator import MigrationOptions, migrate
+
+    options = MigrationOptions("acme", "app", "acme-cloud", "app", wait=True,
+                               conflict_policy="skip")
+    result = migrate(options, source_token, target_token,
+                     progress=lambda event: print(event.kind, event.message))
+    if not result.succeeded:
+        print(result.error, [s.name for s in result.secrets if s.status == "failed"])
+
+Names exported here follow API_VERSION: they change incompatibly only with
+a new major version. Options are the migrate command's, under their pipeline
+names, and are validated like pipeline jobs.
+"""
+from src.core.events import MigrationEvent
+from src.core.plan_file import PlannedChange
+from src.migrator.api import ProgressCallback, migrate, plan
+from src.migrator.options import MigrationOptions
+from src.migrator.results import RESULT_STATUSES, MigrationResult, SecretResult
+
+API_VERSION = 1
+
+__all__ = [
+    "API_VERSION",
+    "MigrationEvent",
+    "MigrationOptions",
+    "MigrationResult",
+    "PlannedChange",
+    "ProgressCallback",
+    "RESULT_STATUSES",
+    "SecretResult",
+    "migrate",
+    "plan",
+]
@@ -0,0 +1,82 @@
+"""Running migrations from Python."""
+import time
+from typing import Callable, List, Optional, Tuple
+from src.core.config import MigrationConfig
+from src.core.events import EventLog, MigrationEvent
+from src.core.migrator import Migrator
+from src.core.plan_file import PlannedChange
+from src.migrator.options import MigrationOptions
+from src.migrator.results import MigrationResult
+from src.utils.logger import Logger
+
+ProgressCallback = Callable[[MigrationEvent], None]
+
+
+def _prepare(
+    options: MigrationOptions,
+    source_token: str,
+    target_token: str,
+    state_passphrase: str,
+    progress: Optional[ProgressCallback]
+) -> Tuple[MigrationConfig, EventLog]:
+    """Validate the options and open the run's event log."""
+    config = options.build_config(source_token, target_token, state_passphrase)
+    events = EventLog([source_token, target_token, state_passphrase])
+    if progress is not None:
+        events.subscribe(progress)
+    return config, events
+
+
+def migrate(
+    options: MigrationOptions,
+    source_token: str,
+    target_token: str,
+    state_passphrase: str = "",
+    progress: Optional[ProgressCallback] = None,
+    logger: Optional[Logger] = None
+) -> MigrationResult:
+    """Run one migration, as the migrate command would.
+
+    Args:
+        options: What to migrate and how
+        source_token: Token of the source side
+        target_token: Token of the target side
+        state_passphrase: Passphrase of encrypted state files, if any
+        progress: Called with every event as it is recorded (already redacted)
+        logger: Where log lines go (default: errors and summaries only, on stdout)
+
+    Returns:
+        The outcome; migration failures are reported in it, not raised
+
+    Raises:
+        ValueError: If the options are invalid (nothing was done)
+        KeyboardInterrupt: If the run was interrupted (after its cleanup)
+    """
+    config, events = _prepare(options, source_token, target_token, state_passphrase, progress)
+    started_at = time.time()
+    try:
+        Migrator(config, logger or Logger(quiet=True), events).run()
+    except RuntimeError as e:
+        duration = round(time.time() - started_at, 3)
+        return MigrationResult("failed", events.events, duration, events.redact(str(e)))
+    return MigrationResult("succeeded", events.events, round(time.time() - started_at, 3))
+
+
+def plan(
+    options: MigrationOptions,
+    source_token: str,
+    target_token: str,
+    state_passphrase: str = "",
+    progress: Optional[ProgressCallback] = None,
+    logger: Optional[Logger] = None
+) -> List[PlannedChange]:
+    """Work out the secret changes a migration would make, writing nothing.
+
+    Arguments are those of migrate().
+
+    Raises:
+        ValueError: If the options are invalid
+        RuntimeError: If the run would fail its checks (permissions, policies, conflicts...)
+    """
+    config, events = _prepare(options, source_token, target_token, state_passphrase, progress)
+    return Migrator(config, logger or Logger(quiet=True), events).plan()
@@ -0,0 +1,58 @@
+"""Options of a programmatic migration."""
+from typing import Any, Dict
+from src.core.config import MigrationConfig
+from src.core.pipeline import parse_pipeline
+
+
+class MigrationOptions:
+    """What to migrate and how.
+
+    The common options are named; any other option of the migrate command
+    is accepted as a keyword, under its pipeline name (e.g. conflict_policy,
+    environment_map, policy_file). Tokens are never options: they are passed
+    to migrate() and plan() separately.
+    """
+
+    def __init__(
+        self,
+        source_org: str,
+        source_repo: str,
+        target_org: str,
+        target_repo: str = "",
+        org_to_org: bool = False,
+        wait: bool = False,
+        **settings: Any
+    ):
+        self.source_org = source_org
+        self.source_repo = source_repo
+        self.target_org = target_org
+        self.target_repo = target_repo
+        self.org_to_org = org_to_org
+        # Follow the workflow run to its end, so secret outcomes are confirmed
+        self.wait = wait
+        self.settings = dict(settings)
+
+    def as_dict(self) -> Dict[str, Any]:
+        """Return every option, as a pipeline job would list them."""
+        options = dict(self.settings)
+        options.update(
+            source_org=self.source_org, source_repo=self.source_repo,
+            target_org=self.target_org, target_repo=self.target_repo,
+            org_to_org=self.org_to_org, wait=self.wait,
+        )
+        return options
+
+    def build_config(
+        self, source_token: str, target_token: str, state_passphrase: str = ""
+    ) -> MigrationConfig:
+        """Validate the options like a pipeline job's and build the run's configuration.
+
+        Raises:
+            ValueError: If an option is unknown, missing or invalid
+        """
+        name = f"{self.source_org}/{self.source_repo}"
+        job = parse_pipeline({"jobs": [dict(self.as_dict(), name=name)]})[0]
+        return MigrationConfig(
+            source_pat=source_token, target_pat=target_token, state_passphrase=state_passphrase,
+            **job.options
+        )
@@ -0,0 +1,82 @@
+"""Typed outcome of a programmatic migration, read from its events."""
+from typing import Any, Dict, List
+from src.core.events import MigrationEvent
+
+RESULT_STATUSES = ("succeeded", "failed")
+
+
+class SecretResult:
+    """What happened to one secret.
+
+    status is 'confirmed' (the workflow log shows it set on the target, only
+    known when the run waited for the workflow), 'failed' or 'skipped'.
+    """
+
+    def __init__(
+        self, name: str, status: str, level: str = "", location: str = "", message: str = ""
+    ):
+        self.name = name
+        self.status = status
+        self.level = level
+        self.location = location
+        self.message = message
+
+    def to_dict(self) -> Dict[str, str]:
+        """Return the result as a JSON-friendly dictionary."""
+        return {
+            "name": self.name, "status": self.status, "level": self.level,
+            "location": self.location, "message": self.message,
+        }
+
+
+class MigrationResult:
+    """Outcome of one migration run."""
+
+    def __init__(
+        self, status: str, events: List[MigrationEvent], duration_seconds: float, error: str = ""
+    ):
+        self.status = status  # one of RESULT_STATUSES
+        self.events = list(events)
+        self.duration_seconds = duration_seconds
+        self.error = error
+
+    @property
+    def succeeded(self) -> bool:
+        """Whether the run finished without error."""
+        return self.status == "succeeded"
+
+    @property
+    def secrets(self) -> List[SecretResult]:
+        """Per-secret outcomes, in the order they were recorded."""
+        results = []
+        for event in self.events:
+            name = event.data.get("secret")
+            if not isinstance(name, str):
+                continue
+            if event.kind == "secret_confirmed":
+                results.append(SecretResult(
+                    name, "confirmed", str(event.data.get("level", "")),
+                    str(event.data.get("location", "")), event.message
+                ))
+            elif event.kind in ("error", "skipped"):
+                status = "failed" if event.kind == "error" else "skipped"
+                results.append(SecretResult(name, status, message=event.message))
+        return results
+
+    @property
+    def links(self) -> Dict[str, str]:
+        """URLs of what the run created or started (workflow run, pull request...), by title."""
+        return {
+            event.message: str(event.data["url"])
+            for event in self.events if event.kind == "link" and event.data.get("url")
+        }
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Return the result as a JSON-friendly dictionary."""
+        return {
+            "status": self.status,
+            "error": self.error,
+            "duration_seconds": self.duration_seconds,
+            "secrets": [secret.to_dict() for secret in self.secrets],
+            "links": self.links,
+        }
@@ -0,0 +1,25 @@
+"""Sealed-box encryption of GitHub secret values, for any tool writing secrets through the REST API.
+
+GitHub encrypts Actions, Dependabot and Codespaces secrets with libsodium
+sealed boxes: the value is sealed with the public key of the scope
+(repository, environment or organization) and sent with that key's id.
+This package has no dependency on the rest of gh-secrets-migrator; it only
+needs PyNaCl (installed with PyGithub):
+
+    from src.sealedbox import PublicKeyCache, parse_public_key, sealed_secret_payload
+
+    keys = PublicKeyCache()
+    key = keys.get("/repos/acme/app/actions/secrets", lambda: parse_public_key(fetch_key()))
+    body = sealed_secret_payload(key, "s3cr3t")  # {"encrypted_value": ..., "key_id": ...}
+"""
+from src.sealedbox.box import seal, sealed_secret_payload
+from src.sealedbox.keys import KEY_SIZE, PublicKeyCache, SecretsPublicKey, parse_public_key
+
+__all__ = [
+    "KEY_SIZE",
+    "PublicKeyCache",
+    "SecretsPublicKey",
+    "parse_public_key",
+    "seal",
+    "sealed_secret_payload",
+]
@@ -0,0 +1,30 @@
+"""Sealing secret values with libsodium's crypto_box_seal."""
+import base64
+from typing import Dict
+from src.sealedbox.keys import SecretsPublicKey
+
+
+def seal(public_key: SecretsPublicKey, value: str) -> str:
+    """Return value sealed with public_key, base64-encoded as the secrets API expects.
+
+    Only the holder of the matching private key (GitHub) can open it; the
+    sender cannot, as the box uses a fresh ephemeral key pair.
+
+    Raises:
+        ValueError: If the key is invalid or PyNaCl is not installed
+    """
+    raw = public_key.raw
+    try:
+        from nacl import public  # PyNaCl ships with PyGithub
+    except ImportError:
+        raise ValueError("sealing secrets needs PyNaCl (pip install pynacl)")
+    sealed = public.SealedBox(public.PublicKey(raw)).encrypt(value.encode("utf-8"))
+    return base64.b64encode(bytes(sealed)).decode("ascii")
+
+
+def sealed_secret_payload(public_key: SecretsPublicKey, value: str) -> Dict[str, str]:
+    """Return the body of a secrets API PUT: value sealed once with the scope's public key.
+
+    The value must be the plaintext; GitHub decrypts exactly one sealed box.
+    """
+    return {"encrypted_value": seal(public_key, value), "key_id": public_key.key_id}
@@ -0,0 +1,62 @@
+"""Public keys of GitHub secret scopes."""
+import base64
+import binascii
+from typing import Any, Callable, Dict, NamedTuple
+
+# Bytes of a Curve25519 public key (libsodium's crypto_box_PUBLICKEYBYTES)
+KEY_SIZE = 32
+
+
+def _decode(key_id: str, key: str) -> bytes:
+    try:
+        raw = base64.b64decode(key, validate=True)
+    except (binascii.Error, ValueError):
+        raise ValueError(f"public key {key_id} is not valid base64")
+    if len(raw) != KEY_SIZE:
+        raise ValueError(f"public key {key_id} has {len(raw)} bytes, expected {KEY_SIZE}")
+    return raw
+
+
+class SecretsPublicKey(NamedTuple):
+    """The public key of a secret scope, as its public-key endpoint returns it."""
+
+    key_id: str
+    key: str  # base64
+
+    @property
+    def raw(self) -> bytes:
+        """The key's bytes.
+
+        Raises:
+            ValueError: If the key is not a base64-encoded Curve25519 public key
+        """
+        return _decode(self.key_id, self.key)
+
+
+def parse_public_key(data: Any) -> SecretsPublicKey:
+    """Read the response of a secrets public-key endpoint ({"key_id": ..., "key": ...}).
+
+    Raises:
+        ValueError: If the response does not hold a valid key
+    """
+    if not isinstance(data, dict) or not data.get("key_id") or not isinstance(data.get("key"), str):
+        raise ValueError("the public key response needs 'key_id' and 'key'")
+    _decode(str(data["key_id"]), data["key"])
+    return SecretsPublicKey(str(data["key_id"]), data["key"])
+
+
+class PublicKeyCache:
+    """Public keys by secrets API path, fetched once each.
+
+    A scope's key changes rarely and every write names the key it used, so
+    one fetch per path serves a whole run.
+    """
+
+    def __init__(self) -> None:
+        self._keys: Dict[str, SecretsPublicKey] = {}
+
+    def get(self, secrets_path: str, fetch: Callable[[], SecretsPublicKey]) -> SecretsPublicKey:
+        """Return the key of the secrets under secrets_path, calling fetch the first time."""
+        if secrets_path not in self._keys:
+            self._keys[secrets_path] = fetch()
+        return self._keys[secrets_path]
@@ -0,0 +1,40 @@
+"""Server clock skew estimation from HTTP Date headers."""
+from datetime import datetime, timedelta, timezone
+from email.utils import parsedate_to_datetime
+from typing import Optional
+
+
+def parse_http_date(value: str) -> Optional[datetime]:
+    """Parse an HTTP Date header into an aware UTC datetime, or None if malformed."""
+    try:
+        parsed = parsedate_to_datetime(value)
+    except (TypeError, ValueError, IndexError):
+        return None
+    if parsed is None:
+        return None
+    if parsed.tzinfo is None:
+        parsed = parsed.replace(tzinfo=timezone.utc)
+    return parsed.astimezone(timezone.utc)
+
+
+def estimate_skew(server_time: datetime, sent_at: datetime, received_at: datetime) -> timedelta:
+    """Estimate how far a server's clock is ahead of the local clock.
+
+    The server stamped its response somewhere between sending and receiving;
+    the midpoint halves the error introduced by network latency.
+    """
+    midpoint = sent_at + (received_at - sent_at) / 2
+    return server_time - midpoint
+
+
+def as_utc(moment: datetime) -> datetime:
+    """Return moment as an aware datetime, reading a naive one as UTC.
+
+    PyGithub returns naive UTC datetimes in older releases.
+    """
+    return moment if moment.tzinfo else moment.replace(tzinfo=timezone.utc)
+
+
+def format_skew(skew: timedelta) -> str:
+    """Format a skew as a signed number of seconds, e.g. '+3.2s'."""
+    return f"{skew.total_seconds():+.1f}s"
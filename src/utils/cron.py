@@ -0,0 +1,119 @@
+"""Cron schedules (five-field expressions) for commands that repeat on a timetable."""
+from datetime import datetime, timedelta
+from typing import Dict, Set, Tuple
+
+_MACROS = {
+    "@hourly": "0 * * * *",
+    "@daily": "0 0 * * *",
+    "@midnight": "0 0 * * *",
+    "@weekly": "0 0 * * 0",
+    "@monthly": "0 0 1 * *",
+}
+
+_MONTH_NAMES = {
+    name: number for number, name in enumerate(
+        ("jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"), 1
+    )
+}
+_DAY_NAMES = {
+    name: number for number, name in enumerate(("sun", "mon", "tue", "wed", "thu", "fri", "sat"))
+}
+
+# (name, lowest, highest, value names) of each field, in expression order
+_FIELDS: Tuple[Tuple[str, int, int, Dict[str, int]], ...] = (
+    ("minute", 0, 59, {}),
+    ("hour", 0, 23, {}),
+    ("day of month", 1, 31, {}),
+    ("month", 1, 12, _MONTH_NAMES),
+    ("day of week", 0, 7, _DAY_NAMES),
+)
+
+
+def _parse_value(value: str, field: str, names: Dict[str, int]) -> int:
+    """Parse a number or a month/day name."""
+    if value.lower() in names:
+        return names[value.lower()]
+    if not value.isdigit():
+        raise ValueError(f"invalid {field} '{value}'")
+    return int(value)
+
+
+def _parse_field(text: str, field: str, low: int, high: int, names: Dict[str, int]) -> Set[int]:
+    """Expand one field ('*', '5', '1-5', '*/15', '0-30/10', 'mon-fri', lists of these)."""
+    values: Set[int] = set()
+    for part in text.split(","):
+        span, _, step_text = part.partition("/")
+        step = _parse_value(step_text, f"{field} step", {}) if step_text else 1
+        if step < 1:
+            raise ValueError(f"invalid {field} step '{step_text}'")
+        if span == "*":
+            start, end = low, high
+        elif "-" in span:
+            start_text, _, end_text = span.partition("-")
+            start = _parse_value(start_text, field, names)
+            end = _parse_value(end_text, field, names)
+        else:
+            start = _parse_value(span, field, names)
+            end = high if step_text else start
+        if not low <= start <= end <= high:
+            raise ValueError(f"{field} '{part}' out of range {low}-{high}")
+        values.update(range(start, end + 1, step))
+    return values
+
+
+class CronSchedule:
+    """A standard five-field cron expression: minute hour day-of-month month day-of-week.
+
+    As in cron, when both day fields are restricted a day matching either
+    one is scheduled. Times are evaluated in the clock of the datetime given.
+    """
+
+    def __init__(self, expression: str):
+        self.expression = expression.strip()
+        fields = _MACROS.get(self.expression.lower(), self.expression).split()
+        if len(fields) != len(_FIELDS):
+            raise ValueError(
+                f"cron expression '{expression}' needs 5 fields "
+                "(minute hour day-of-month month day-of-week)"
+            )
+        parsed = [
+            _parse_field(text, name, low, high, names)
+            for text, (name, low, high, names) in zip(fields, _FIELDS)
+        ]
+        self.minutes, self.hours, self.days, self.months, weekdays = parsed
+        self.weekdays = {day % 7 for day in weekdays}  # 7 is Sunday too
+        # As in cron, a day field starting with '*' (e.g. '*/2') does not restrict the other
+        self._any_day = fields[2].startswith("*")
+        self._any_weekday = fields[4].startswith("*")
+        # Fail now on expressions such as '0 0 30 2 *' rather than when waiting for them
+        self.next_after(datetime(2000, 1, 1))
+
+    def _day_matches(self, moment: datetime) -> bool:
+        """Whether the day of moment is scheduled, per the day-of-month and day-of-week fields."""
+        day = moment.day in self.days
+        weekday = (moment.weekday() + 1) % 7 in self.weekdays
+        if self._any_day or self._any_weekday:
+            return day and weekday
+        return day or weekday
+
+    def next_after(self, moment: datetime) -> datetime:
+        """Return the first scheduled minute strictly after moment.
+
+        Raises:
+            ValueError: If the expression never matches (e.g. February 30)
+        """
+        candidate = moment.replace(second=0, microsecond=0) + timedelta(minutes=1)
+        limit = candidate + timedelta(days=5 * 366)  # covers a leap day
+        while candidate < limit:
+            if candidate.month not in self.months:
+                month_start = candidate.replace(day=1, hour=0, minute=0)
+                candidate = (month_start + timedelta(days=32)).replace(day=1)
+            elif not self._day_matches(candidate):
+                candidate = candidate.replace(hour=0, minute=0) + timedelta(days=1)
+            elif candidate.hour not in self.hours:
+                candidate = candidate.replace(minute=0) + timedelta(hours=1)
+            elif candidate.minute not in self.minutes:
+                candidate += timedelta(minutes=1)
+            else:
+                return candidate
+        raise ValueError(f"cron expression '{self.expression}' never matches")
@@ -0,0 +1,84 @@
+"""ETag cache for conditional GitHub API reads."""
+import hashlib
+import json
+import os
+from typing import Any, Dict, Optional, Tuple
+
+CACHE_FORMAT_VERSION = 1
+
+
+def credential_scope(token: str) -> str:
+    """Return a non-reversible cache namespace for a token.
+
+    Responses depend on who asks, so entries are never shared between tokens.
+    """
+    return hashlib.sha256(token.encode("utf-8")).hexdigest()[:16]
+
+
+class ETagCache:
+    """Stores response bodies by request key alongside their ETag.
+
+    A read sends the stored ETag in If-None-Match; GitHub answers 304 Not
+    Modified without counting the request against the rate limit, and the
+    stored body is reused. Any write on GitHub's side changes the ETag, so
+    entries never go stale. With a path, the cache is loaded from and saved
+    to a JSON file so later waves of a migration start warm.
+    """
+
+    def __init__(self, path: str = ""):
+        self.path = path
+        self.entries: Dict[str, Tuple[str, Any]] = {}
+        self.hits = 0
+        self.misses = 0
+        if path and os.path.exists(path):
+            self._load()
+
+    def _load(self) -> None:
+        try:
+            with open(self.path, "r", encoding="utf-8") as handle:
+                data = json.load(handle)
+        except (OSError, ValueError):
+            return  # an unreadable cache only costs full reads
+        if isinstance(data, dict) and data.get("version") == CACHE_FORMAT_VERSION:
+            self.entries = {
+                key: (entry[0], entry[1])
+                for key, entry in (data.get("entries") or {}).items()
+                if isinstance(entry, list) and len(entry) == 2
+            }
+
+    def get(self, key: str) -> Optional[Tuple[str, Any]]:
+        """Return the (etag, body) stored for key, if any."""
+        return self.entries.get(key)
+
+    def put(self, key: str, etag: str, body: Any) -> None:
+        """Store a response body under key; responses without an ETag are not stored."""
+        if etag:
+            self.entries[key] = (etag, body)
+
+    def record(self, revalidated: bool) -> None:
+        """Count a read answered from the cache (304) or from a full response."""
+        if revalidated:
+            self.hits += 1
+        else:
+            self.misses += 1
+
+    def save(self) -> None:
+        """Write the cache file (owner-readable only), if the cache has a path.
+
+        Raises:
+            OSError: If the file cannot be written
+        """
+        if not self.path:
+            return
+        document = {
+            "version": CACHE_FORMAT_VERSION,
+            "entries": {key: list(entry) for key, entry in self.entries.items()},
+        }
+        descriptor = os.open(self.path, os.O_WRONLY | os.O_CREAT | os.O_TRUNC, 0o600)
+        with os.fdopen(descriptor, "w", encoding="utf-8") as handle:
+            json.dump(document, handle)
+
+    def describe(self) -> str:
+        """Summarize cache effectiveness for the log."""
+        total = self.hits + self.misses
+        return f"{self.hits} of {total} metadata read(s) answered 304 Not Modified"
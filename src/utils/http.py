@@ -0,0 +1,57 @@
+"""Minimal HTTP helpers for outbound notifications and downloads."""
+import json
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, Optional, Sequence
+
+
+def encode_json(payload: Any) -> bytes:
+    """Serialize payload exactly as post_json sends it."""
+    return json.dumps(payload).encode("utf-8")
+
+
+def post_json(
+    url: str,
+    payload: Any,
+    headers: Optional[Dict[str, str]] = None,
+    timeout: float = 10.0,
+    schemes: Sequence[str] = ("https",)
+) -> None:
+    """POST a JSON payload.
+
+    Args:
+        url: Endpoint URL
+        payload: JSON-serializable document
+        headers: Extra request headers
+        timeout: Socket timeout in seconds
+        schemes: URL schemes the endpoint may use
+
+    Raises:
+        ValueError: If the URL scheme is not allowed
+        OSError: If the endpoint cannot be reached or rejects the request
+    """
+    if urllib.parse.urlparse(url).scheme not in schemes:
+        raise ValueError(f"URL must use {' or '.join(schemes)}: {url}")
+    request = urllib.request.Request(
+        url,
+        data=encode_json(payload),
+        method="POST",
+        headers={"Content-Type": "application/json", **(headers or {})},
+    )
+    # Scheme is restricted by the check above
+    with urllib.request.urlopen(request, timeout=timeout) as response:  # nosec B310
+        response.read()
+
+
+def get_text(url: str, timeout: float = 30.0) -> str:
+    """GET a text document, such as a signed log download URL.
+
+    Raises:
+        ValueError: If the URL does not use https
+        OSError: If the document cannot be downloaded
+    """
+    if urllib.parse.urlparse(url).scheme != "https":
+        raise ValueError(f"URL must use https: {url}")
+    # Scheme is restricted by the check above
+    with urllib.request.urlopen(url, timeout=timeout) as response:  # nosec B310
+        return response.read().decode("utf-8", errors="replace")
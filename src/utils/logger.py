@@ -1,30 +1,113 @@
 """Logger module for consistent output formatting."""
+import os
 import sys
+from typing import Optional
+
+# Verbosity levels
+QUIET = -1    # errors and the final summary only
+NORMAL = 0
+VERBOSE = 1   # -v: debug messages
+TRACE = 2     # -vv: also per-call API tracing (rate limits)
+
+# ANSI styles per message type
+_STYLES = {
+    "info": "\033[36m",     # cyan
+    "debug": "\033[2m",     # dim
+    "trace": "\033[2m",
+    "success": "\033[32m",  # green
+    "summary": "\033[1m",   # bold
+    "error": "\033[31m",    # red
+    "warn": "\033[33m",     # yellow
+    "hint": "\033[33m",
+}
+_RESET = "\033[0m"
 
 
 class Logger:
     """Simple logger for CLI output."""
 
-    def __init__(self, verbose: bool = False):
-        self.verbose = verbose
+    def __init__(self, verbose: int = False, quiet: bool = False, color: Optional[bool] = None):
+        """Create a logger.
+        
+        Args:
+            verbose: Verbosity level (True/1 for debug, 2 for trace)
+            quiet: Only print errors and summaries
+            color: Force colors on or off; by default colors are used only when
+                   the output stream is a terminal and NO_COLOR is not set
+        """
+        self.level = QUIET if quiet else int(verbose)
+        self.color = color
+        self.verbose = self.level >= VERBOSE
+        self.quiet = self.level <= QUIET
+        # Active progress bars, innermost last; only the innermost is redrawn
+        self._progress = []
+        self._stdout_to_stderr = False
+
+    def use_stderr(self) -> None:
+        """Write every message to stderr, leaving stdout to machine-readable output."""
+        self._stdout_to_stderr = True
+
+    def attach_progress(self, progress) -> None:
+        """Keep log lines from overwriting an active progress bar."""
+        self._progress.append(progress)
+
+    def detach_progress(self, progress) -> None:
+        """Stop redrawing a finished progress bar."""
+        if progress in self._progress:
+            self._progress.remove(progress)
+
+    def _use_color(self, stream) -> bool:
+        if self.color is not None:
+            return self.color
+        if "NO_COLOR" in os.environ:
+            return False
+        return bool(getattr(stream, "isatty", lambda: False)())
+
+    def _write(self, line: str, stream=None, style: str = "") -> None:
+        if stream is None:
+            stream = sys.stderr if self._stdout_to_stderr else sys.stdout
+        if style and self._use_color(stream):
+            line = f"{_STYLES[style]}{line}{_RESET}"
+        progress = self._progress[-1] if self._progress else None
+        if progress is not None:
+            progress.clear()
+        print(line, file=stream)
+        if progress is not None:
+            progress.render()
 
     def info(self, message: str) -> None:
         """Log info message."""
-        print(f"ℹ️  {message}")
+        if self.level >= NORMAL:
+            self._write(f"ℹ️  {message}", style="info")
 
     def debug(self, message: str) -> None:
         """Log debug message (only if verbose)."""
-        if self.verbose:
-            print(f"🔍 {message}", file=sys.stderr)
+        if self.level >= VERBOSE:
+            self._write(f"🔍 {message}", sys.stderr, "debug")
+
+    def trace(self, message: str) -> None:
+        """Log trace message (only at -vv)."""
+        if self.level >= TRACE:
+            self._write(f"🔬 {message}", sys.stderr, "trace")
 
     def success(self, message: str) -> None:
         """Log success message."""
-        print(f"✅ {message}")
+        if self.level >= NORMAL:
+            self._write(f"✅ {message}", style="success")
+
+    def summary(self, message: str) -> None:
+        """Log a final summary line; printed even in quiet mode."""
+        self._write(f"📋 {message}", style="summary")
 
     def error(self, message: str) -> None:
         """Log error message."""
-        print(f"❌ {message}", file=sys.stderr)
+        self._write(f"❌ {message}", sys.stderr, "error")
+
+    def hint(self, message: str) -> None:
+        """Log how to fix the error just logged; printed even in quiet mode."""
+        self._write(f"💡 {message}", sys.stderr, "hint")
 
     def warn(self, message: str) -> None:
         """Log warning message."""
-        print(f"⚠️  {message}", file=sys.stderr)
+        if self.level >= NORMAL:
+            self._write(f"⚠️  {message}", sys.stderr, "warn")
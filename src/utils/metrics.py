@@ -0,0 +1,78 @@
+"""Prometheus Pushgateway metrics for migration runs."""
+import urllib.parse
+import urllib.request
+from typing import Dict, Optional, Tuple
+
+DEFAULT_PUSHGATEWAY_JOB = "gh_secrets_migrator"
+
+# Metric name -> help text
+METRIC_HELP = {
+    "secrets_migrator_repos_migrated": "Repositories or pipeline jobs migrated successfully",
+    "secrets_migrator_failures": "Repositories or pipeline jobs that failed",
+    "secrets_migrator_duration_seconds": "Wall-clock duration of the run in seconds",
+    "secrets_migrator_last_completion_timestamp_seconds": "Unix time the run finished",
+}
+
+
+def build_run_metrics(
+    migrated: int, failures: int, duration: float, finished_at: float
+) -> Dict[str, float]:
+    """Build the completion metrics pushed at the end of a run."""
+    return {
+        "secrets_migrator_repos_migrated": migrated,
+        "secrets_migrator_failures": failures,
+        "secrets_migrator_duration_seconds": round(duration, 3),
+        "secrets_migrator_last_completion_timestamp_seconds": int(finished_at),
+    }
+
+
+def format_metrics(metrics: Dict[str, float]) -> str:
+    """Render metrics in the Prometheus text exposition format (all gauges)."""
+    lines = []
+    for name, value in metrics.items():
+        help_text = METRIC_HELP.get(name)
+        if help_text:
+            lines.append(f"# HELP {name} {help_text}")
+        lines.append(f"# TYPE {name} gauge")
+        lines.append(f"{name} {value}")
+    return "\n".join(lines) + "\n"
+
+
+def pushgateway_request(url: str, job: str, grouping: Dict[str, str]) -> Tuple[str, str]:
+    """Build the Pushgateway endpoint URL for a job and grouping labels.
+
+    Returns:
+        Tuple of (endpoint URL, HTTP method)
+    """
+    parsed = urllib.parse.urlparse(url)
+    if parsed.scheme not in ("http", "https"):
+        raise ValueError(f"Pushgateway URL must use http or https: {url}")
+    path = f"/metrics/job/{urllib.parse.quote(job, safe='')}"
+    for label, value in grouping.items():
+        path += f"/{urllib.parse.quote(label, safe='')}/{urllib.parse.quote(value, safe='')}"
+    return url.rstrip("/") + path, "PUT"
+
+
+def push_metrics(
+    url: str,
+    metrics: Dict[str, float],
+    job: str = DEFAULT_PUSHGATEWAY_JOB,
+    grouping: Optional[Dict[str, str]] = None,
+    timeout: float = 10.0
+) -> None:
+    """Push metrics to a Prometheus Pushgateway, replacing the job's previous group.
+
+    Raises:
+        ValueError: If the URL is not http(s)
+        OSError: If the Pushgateway cannot be reached or rejects the push
+    """
+    endpoint, method = pushgateway_request(url, job, grouping or {})
+    request = urllib.request.Request(
+        endpoint,
+        data=format_metrics(metrics).encode("utf-8"),
+        method=method,
+        headers={"Content-Type": "text/plain; version=0.0.4"},
+    )
+    # Scheme is restricted to http(s) by pushgateway_request
+    with urllib.request.urlopen(request, timeout=timeout) as response:  # nosec B310
+        response.read()
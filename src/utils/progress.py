@@ -0,0 +1,101 @@
+"""Terminal progress bars for bulk operations."""
+import sys
+import time
+from typing import Callable, Optional, TextIO
+
+BAR_WIDTH = 24
+
+
+def format_eta(seconds: float) -> str:
+    """Format a duration in seconds as M:SS (or H:MM:SS)."""
+    seconds = max(int(round(seconds)), 0)
+    hours, remainder = divmod(seconds, 3600)
+    minutes, secs = divmod(remainder, 60)
+    if hours:
+        return f"{hours}:{minutes:02d}:{secs:02d}"
+    return f"{minutes}:{secs:02d}"
+
+
+class Progress:
+    """A single-line progress bar with ETA, drawn only when attached to a TTY.
+
+    When the stream is not a TTY (CI logs, redirected output) the bar is
+    disabled and the regular log lines are the only output.
+
+    Use as a context manager; passing the logger keeps log lines from
+    overwriting the bar while it is active.
+    """
+
+    def __init__(
+        self,
+        total: int,
+        label: str,
+        logger=None,
+        stream: Optional[TextIO] = None,
+        enabled: Optional[bool] = None,
+        clock: Callable[[], float] = time.monotonic
+    ):
+        self.total = total
+        self.label = label
+        self.logger = logger
+        self.stream = stream if stream is not None else sys.stderr
+        if enabled is None:
+            quiet = bool(getattr(logger, "quiet", False))
+            enabled = not quiet and bool(getattr(self.stream, "isatty", lambda: False)())
+        self.enabled = enabled and total > 0
+        self.clock = clock
+        self.current = 0
+        self.item = ""
+        self.started_at = clock()
+
+    def __enter__(self) -> "Progress":
+        if self.enabled and self.logger is not None:
+            self.logger.attach_progress(self)
+        self.render()
+        return self
+
+    def __exit__(self, *exc_info) -> None:
+        self.close()
+
+    def render_line(self) -> str:
+        """Return the current bar as text."""
+        fraction = self.current / self.total if self.total else 1.0
+        filled = int(BAR_WIDTH * fraction)
+        bar = "#" * filled + "-" * (BAR_WIDTH - filled)
+        line = f"{self.label} [{bar}] {self.current}/{self.total} {int(fraction * 100):3d}%"
+        if 0 < self.current < self.total:
+            elapsed = self.clock() - self.started_at
+            line += f" ETA {format_eta(elapsed / self.current * (self.total - self.current))}"
+        if self.item:
+            line += f" {self.item}"
+        return line
+
+    def render(self) -> None:
+        """Draw the bar over the current terminal line."""
+        if self.enabled:
+            self.stream.write("\r\033[K" + self.render_line())
+            self.stream.flush()
+
+    def clear(self) -> None:
+        """Erase the bar so a log line can be printed."""
+        if self.enabled:
+            self.stream.write("\r\033[K")
+            self.stream.flush()
+
+    def advance(self, item: str = "") -> None:
+        """Mark one unit of work done; item names what is being processed."""
+        self.current = min(self.current + 1, self.total)
+        self.item = item
+        self.render()
+
+    def close(self) -> None:
+        """Finish the bar and release the logger."""
+        if not self.enabled:
+            return
+        self.item = ""
+        self.render()
+        self.stream.write("\n")
+        self.stream.flush()
+        if self.logger is not None:
+            self.logger.detach_progress(self)
+        self.enabled = False
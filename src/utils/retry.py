@@ -0,0 +1,56 @@
+"""Retry helpers for GitHub API calls."""
+import time
+from typing import Callable, Optional, TypeVar
+from src.utils.logger import Logger
+
+T = TypeVar("T")
+
+
+def is_not_found_error(error: Exception) -> bool:
+    """Return True if the error is an HTTP 404 from the GitHub API."""
+    status = getattr(error, "status", None)
+    if status == 404:
+        return True
+    message = str(error)
+    return "404" in message or "Not Found" in message
+
+
+def retry_on_not_found(
+    operation: Callable[[], T],
+    attempts: int = 5,
+    base_delay: float = 1.0,
+    max_delay: float = 8.0,
+    logger: Optional[Logger] = None,
+    description: str = "operation",
+    sleep: Callable[[float], None] = time.sleep
+) -> T:
+    """Run operation, retrying with exponential backoff while it fails with 404.
+
+    Meant for writes against resources the tool itself just created: GitHub
+    can briefly return 404 for a new repository or environment. Any other
+    error, or a 404 on the final attempt, is raised unchanged.
+
+    Args:
+        operation: Callable performing the API call
+        attempts: Maximum number of attempts (including the first)
+        base_delay: Delay in seconds before the first retry
+        max_delay: Upper bound for a single delay
+        logger: Optional logger for retry messages
+        description: Operation name used in log messages
+        sleep: Sleep function (injectable for tests)
+    """
+    delay = base_delay
+    for attempt in range(1, attempts + 1):
+        try:
+            return operation()
+        except Exception as e:
+            if attempt == attempts or not is_not_found_error(e):
+                raise
+            if logger:
+                logger.debug(
+                    f"{description} returned 404 on a just-created resource, "
+                    f"retrying in {delay:.0f}s (attempt {attempt}/{attempts})"
+                )
+            sleep(delay)
+            delay = min(delay * 2, max_delay)
+    raise RuntimeError(f"{description} did not run")  # pragma: no cover - attempts < 1
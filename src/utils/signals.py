@@ -0,0 +1,59 @@
+"""Cancellation signal handling and run deadlines for the CLI."""
+import _thread
+import signal
+import threading
+from typing import Optional
+
+# Conventional exit status of a process stopped by SIGINT (128 + 2)
+EXIT_CANCELLED = 130
+
+
+class Cancelled(KeyboardInterrupt):
+    """Raised on SIGTERM so a cancelled CI job cleans up like Ctrl-C does."""
+
+
+def _raise_cancelled(signum, frame) -> None:
+    raise Cancelled(f"received signal {signum}")
+
+
+def install_signal_handlers() -> None:
+    """Turn SIGTERM into Cancelled; SIGINT already raises KeyboardInterrupt.
+
+    Both interrupt the blocking API call in progress, so commands can stop
+    and clean up. Handlers can only be installed from the main thread.
+    """
+    if threading.current_thread() is threading.main_thread():
+        signal.signal(signal.SIGTERM, _raise_cancelled)
+
+
+# Exit status of a run stopped by --timeout, as reported by coreutils timeout(1)
+EXIT_TIMED_OUT = 124
+
+
+class Deadline:
+    """Overall run timeout that interrupts the main thread like Ctrl-C when it expires.
+
+    The interrupt is delivered between Python operations, so a blocking API
+    call finishes first; per-request timeouts (--api-timeout) bound that wait.
+    Use as a context manager; a limit of 0 disables the deadline.
+    """
+
+    def __init__(self, seconds: float):
+        self.seconds = seconds
+        self.expired = False
+        self._timer: Optional[threading.Timer] = None
+
+    def _expire(self) -> None:
+        self.expired = True
+        _thread.interrupt_main()
+
+    def __enter__(self) -> "Deadline":
+        if self.seconds > 0:
+            self._timer = threading.Timer(self.seconds, self._expire)
+            self._timer.daemon = True
+            self._timer.start()
+        return self
+
+    def __exit__(self, *exc_info) -> None:
+        if self._timer is not None:
+            self._timer.cancel()
@@ -0,0 +1,47 @@
+"""Pacing of workflow dispatches across the jobs of a bulk run."""
+import time
+from typing import Callable, Optional
+from src.utils.logger import Logger
+
+
+class DispatchThrottle:
+    """Spaces workflow dispatches (migration branch pushes) evenly.
+
+    At a rate of N per minute, each dispatch waits until 60/N seconds have
+    passed since the previous one, so hundreds of repositories do not flood
+    the source organization's runner queue or trip secondary rate limits.
+    A rate of 0 disables pacing.
+    """
+
+    def __init__(
+        self,
+        per_minute: float = 0,
+        logger: Optional[Logger] = None,
+        clock: Callable[[], float] = time.monotonic,
+        sleep: Callable[[float], None] = time.sleep
+    ):
+        if per_minute < 0:
+            raise ValueError(f"dispatch rate must be 0 (unlimited) or positive, got {per_minute:g}")
+        self.interval = 60.0 / per_minute if per_minute else 0.0
+        self.log = logger
+        self._clock = clock
+        self._sleep = sleep
+        self._last: Optional[float] = None
+
+    def wait(self, description: str = "the next workflow dispatch") -> float:
+        """Block until the next dispatch may happen, then count it.
+
+        Returns:
+            Seconds waited
+        """
+        waited = 0.0
+        if self.interval and self._last is not None:
+            waited = max(0.0, self._last + self.interval - self._clock())
+            if waited:
+                if self.log:
+                    self.log.info(
+                        f"Pacing dispatches: waiting {waited:.1f}s before {description}"
+                    )
+                self._sleep(waited)
+        self._last = self._clock()
+        return waited
@@ -0,0 +1,211 @@
+"""Recorded GitHub API interactions ("cassettes") for whole-run tests.
+
+PyGithub sends every REST call through a connection class; the harness swaps
+in one answering from a cassette, so a migration runs end to end without
+credentials or network. Record a cassette against throwaway organizations:
+
+    GH_SECRETS_MIGRATOR_RECORD=1 \\
+    SOURCE_GITHUB_TOKEN=... TARGET_GITHUB_TOKEN=... \\
+    CASSETTE_SOURCE_ORG=... CASSETTE_SOURCE_REPO=... \\
+    CASSETTE_TARGET_ORG=... CASSETTE_TARGET_REPO=... \\
+    pytest tests/test_recorded_runs.py
+
+Recordings keep each call's method, path, status, a few response headers and
+both bodies. Tokens are never written and sealed secret values are masked;
+response bodies are kept as GitHub sent them, so review a cassette (logins,
+e-mail addresses) before committing it.
+"""
+import json
+import os
+from contextlib import contextmanager
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple
+from urllib.parse import urlsplit
+
+RECORD_ENV = "GH_SECRETS_MIGRATOR_RECORD"
+CASSETTE_DIR = Path(__file__).parent / "fixtures" / "cassettes"
+MASK = "<masked>"
+
+# Response headers PyGithub and the migrator read (pagination, rate limits, scopes, clock)
+_KEPT_HEADERS = (
+    "content-type", "date", "etag", "last-modified", "link", "location",
+    "x-accepted-oauth-scopes", "x-oauth-scopes", "x-ratelimit-limit",
+    "x-ratelimit-remaining", "x-ratelimit-reset", "x-ratelimit-resource", "x-ratelimit-used",
+)
+# Request body fields holding sealed secret values
+_MASKED_FIELDS = ("encrypted_value",)
+
+
+class CassetteError(AssertionError):
+    """Raised when a run makes a call its cassette has no answer for."""
+
+
+def recording() -> bool:
+    """Return True if tests should record cassettes instead of replaying them."""
+    return os.environ.get(RECORD_ENV, "") not in ("", "0", "false")
+
+
+def _split(url: str) -> Tuple[str, str]:
+    """Split a request URL into its path and query string."""
+    parts = urlsplit(url)
+    return parts.path, parts.query
+
+
+def mask_body(body: Any) -> Optional[str]:
+    """Return a request body as text with sealed secret values masked."""
+    if body is None:
+        return None
+    if hasattr(body, "read"):
+        return MASK  # uploaded files are not kept
+    text = body.decode("utf-8", "replace") if isinstance(body, bytes) else str(body)
+    try:
+        data = json.loads(text)
+    except ValueError:
+        return text
+    if isinstance(data, dict):
+        for field in _MASKED_FIELDS:
+            if field in data:
+                data[field] = MASK
+        return json.dumps(data, sort_keys=True)
+    return text
+
+
+class Cassette:
+    """An ordered list of recorded API calls and their responses.
+
+    Replay answers each call with the first unused interaction of the same
+    method and path, preferring one whose query string matches too (queries
+    may carry timestamps that differ between recording and replay).
+    """
+
+    def __init__(self, path: Path, meta: Optional[Dict[str, str]] = None,
+                 interactions: Optional[List[Dict[str, Any]]] = None):
+        self.path = Path(path)
+        self.meta = dict(meta or {})
+        self.interactions = list(interactions or [])
+        self._used: List[bool] = [False] * len(self.interactions)
+
+    @classmethod
+    def load(cls, path: Path) -> "Cassette":
+        """Load a cassette file."""
+        with open(path, "r", encoding="utf-8") as handle:
+            data = json.load(handle)
+        return cls(path, data.get("meta"), data.get("interactions"))
+
+    def save(self) -> None:
+        """Write the cassette file."""
+        self.path.parent.mkdir(parents=True, exist_ok=True)
+        with open(self.path, "w", encoding="utf-8") as handle:
+            json.dump({"meta": self.meta, "interactions": self.interactions}, handle, indent=2)
+            handle.write("\n")
+
+    @property
+    def unused(self) -> List[Dict[str, Any]]:
+        """Interactions the replay never asked for."""
+        return [item for item, used in zip(self.interactions, self._used) if not used]
+
+    def play(self, method: str, url: str) -> Dict[str, Any]:
+        """Return the recorded response to a call.
+
+        Raises:
+            CassetteError: If no unused interaction matches
+        """
+        path, query = _split(url)
+        candidates = [
+            index for index, item in enumerate(self.interactions)
+            if not self._used[index] and item["method"] == method and item["path"] == path
+        ]
+        exact = [index for index in candidates if self.interactions[index]["query"] == query]
+        if not candidates:
+            raise CassetteError(f"{self.path.name} has no recorded response for {method} {url}")
+        index = (exact or candidates)[0]
+        self._used[index] = True
+        return self.interactions[index]
+
+    def record(self, method: str, url: str, body: Any, status: int,
+               headers: List[Tuple[str, str]], response: str) -> None:
+        """Append one call and its response."""
+        path, query = _split(url)
+        self.interactions.append({
+            "method": method,
+            "path": path,
+            "query": query,
+            "body": mask_body(body),
+            "status": status,
+            "headers": {
+                name.lower(): value for name, value in headers if name.lower() in _KEPT_HEADERS
+            },
+            "response": response,
+        })
+        self._used.append(True)
+
+
+class _Response:
+    """A response in the shape PyGithub reads from its connection classes."""
+
+    def __init__(self, status: int, headers: Dict[str, str], body: str):
+        self.status = status
+        self._headers = headers
+        self.text = body
+
+    def getheaders(self) -> List[Tuple[str, str]]:
+        return list(self._headers.items())
+
+    def read(self) -> str:
+        return self.text
+
+
+def connection_class(cassette: Cassette, live: Optional[type] = None) -> type:
+    """Return a PyGithub connection class replaying from (or recording live calls to) a cassette."""
+
+    class CassetteConnection:
+        def __init__(self, host: str, port: Optional[int] = None, *args: Any, **kwargs: Any):
+            self._live = live(host, port, *args, **kwargs) if live else None
+            self._call: Tuple[str, str, Any] = ("", "", None)
+
+        def request(self, verb: str, url: str, input: Any = None, headers: Any = None) -> None:
+            self._call = (verb, url, input)
+            if self._live:
+                self._live.request(verb, url, input, headers)
+
+        def getresponse(self) -> _Response:
+            verb, url, body = self._call
+            if not self._live:
+                item = cassette.play(verb, url)
+                return _Response(item["status"], item["headers"], item["response"])
+            response = self._live.getresponse()
+            text = response.read()
+            if isinstance(text, bytes):
+                text = text.decode("utf-8", "replace")
+            cassette.record(verb, url, body, response.status, response.getheaders(), text)
+            return _Response(response.status, dict(response.getheaders()), text)
+
+        def close(self) -> None:
+            if self._live:
+                self._live.close()
+
+    return CassetteConnection
+
+
+@contextmanager
+def use_cassette(cassette: Cassette, record: bool = False) -> Iterator[Cassette]:
+    """Route all PyGithub traffic through a cassette for the duration of the block.
+
+    Replaying, every call must find a recorded answer; recording, calls go
+    to GitHub and the cassette is saved when the block exits.
+    """
+    from github import Requester as requester_module
+
+    requester = requester_module.Requester
+    if record:
+        http = connection_class(cassette, requester_module.HTTPRequestsConnectionClass)
+        https = connection_class(cassette, requester_module.HTTPSRequestsConnectionClass)
+    else:
+        http = https = connection_class(cassette)
+    requester.injectConnectionClasses(http, https)
+    try:
+        yield cassette
+    finally:
+        requester.resetConnectionClasses()
+        if record:
+            cassette.save()
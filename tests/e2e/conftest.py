@@ -0,0 +1,91 @@
+"""Fixtures for end-to-end tests: real migrations against sandbox organizations.
+
+Tests marked e2e are skipped unless GH_SECRETS_MIGRATOR_E2E=1 (make e2e). They
+then need:
+
+    SOURCE_GITHUB_TOKEN, TARGET_GITHUB_TOKEN   tokens of the sandbox organizations
+    E2E_SOURCE_ORG, E2E_SOURCE_REPO            the throwaway source repository
+    E2E_TARGET_ORG, E2E_TARGET_REPO            the throwaway target repository
+    GH_SECRETS_MIGRATOR_SANDBOX                designating them (every run uses --sandbox)
+
+Tests create secrets named E2E_<random>_* on the source and delete them from
+both sides afterwards; nothing else in the repositories is touched.
+"""
+import os
+import uuid
+import pytest
+from src.clients.errors import GitHubAPIError
+from src.clients.github import GitHubClient
+from src.migrator import MigrationOptions
+from src.utils.logger import Logger
+
+E2E_ENV = "GH_SECRETS_MIGRATOR_E2E"
+_REQUIRED = (
+    "SOURCE_GITHUB_TOKEN", "TARGET_GITHUB_TOKEN", "E2E_SOURCE_ORG", "E2E_SOURCE_REPO",
+    "E2E_TARGET_ORG", "E2E_TARGET_REPO", "GH_SECRETS_MIGRATOR_SANDBOX",
+)
+
+
+def pytest_configure(config):
+    config.addinivalue_line("markers", "e2e: end-to-end test against sandbox organizations")
+
+
+def pytest_collection_modifyitems(config, items):
+    if os.environ.get(E2E_ENV) == "1":
+        return
+    skip = pytest.mark.skip(reason=f"end-to-end tests run only with {E2E_ENV}=1")
+    for item in items:
+        if "e2e" in item.keywords:
+            item.add_marker(skip)
+
+
+class Sandbox:
+    """The sandbox repositories of an end-to-end session and clients to inspect them."""
+
+    def __init__(self, environ):
+        self.source_org = environ["E2E_SOURCE_ORG"]
+        self.source_repo = environ["E2E_SOURCE_REPO"]
+        self.target_org = environ["E2E_TARGET_ORG"]
+        self.target_repo = environ["E2E_TARGET_REPO"]
+        self.source_token = environ["SOURCE_GITHUB_TOKEN"]
+        self.target_token = environ["TARGET_GITHUB_TOKEN"]
+        logger = Logger(quiet=True)
+        self.source = GitHubClient(self.source_token, logger, side="source")
+        self.target = GitHubClient(self.target_token, logger, side="target")
+
+    def options(self, **settings) -> MigrationOptions:
+        """Return options migrating the source repository to the target one, sandboxed."""
+        return MigrationOptions(
+            self.source_org, self.source_repo, self.target_org, self.target_repo,
+            sandbox=True, **settings
+        )
+
+
+@pytest.fixture(scope="session")
+def sandbox():
+    """The configured sandbox; an enabled but unconfigured suite fails rather than skips."""
+    missing = [name for name in _REQUIRED if not os.environ.get(name)]
+    if missing:
+        pytest.fail(f"end-to-end tests need {', '.join(missing)}")
+    return Sandbox(os.environ)
+
+
+@pytest.fixture
+def seeded_secrets(sandbox):
+    """Create uniquely named secrets on the source, removing them from both sides afterwards."""
+    prefix = f"E2E_{uuid.uuid4().hex[:8].upper()}"
+    names = [f"{prefix}_API_KEY", f"{prefix}_DB_PASSWORD"]
+    for name in names:
+        sandbox.source.create_repo_secret(
+            sandbox.source_org, sandbox.source_repo, name, f"value of {name}"
+        )
+    yield names
+    for client, org, repo in (
+        (sandbox.source, sandbox.source_org, sandbox.source_repo),
+        (sandbox.target, sandbox.target_org, sandbox.target_repo),
+    ):
+        for name in names:
+            try:
+                client.delete_secret(org, repo, name)
+            except GitHubAPIError:
+                pass  # never created there
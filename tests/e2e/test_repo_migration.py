@@ -0,0 +1,51 @@
+"""End-to-end repository migrations, with the target state checked afterwards."""
+import pytest
+from src.core.sandbox import SANDBOX_ENV
+from src.core.status import TEMPORARY_SECRETS
+from src.migrator import migrate
+
+pytestmark = pytest.mark.e2e
+
+
+class TestRepoMigration:
+    """Test cases for complete runs between the sandbox repositories."""
+
+    def test_migrate(self, sandbox, seeded_secrets, tmp_path, monkeypatch):
+        """Test a waited run: secrets reach the target, the source is left clean."""
+        monkeypatch.chdir(tmp_path)  # run state stays out of the checkout
+        result = migrate(sandbox.options(wait=True), sandbox.source_token, sandbox.target_token)
+
+        assert result.succeeded, result.error
+        confirmed = {secret.name for secret in result.secrets if secret.status == "confirmed"}
+        assert set(seeded_secrets) <= confirmed
+        target_secrets = sandbox.target.list_repo_secrets(sandbox.target_org, sandbox.target_repo)
+        assert set(seeded_secrets) <= set(target_secrets)
+        source_secrets = sandbox.source.list_repo_secrets(sandbox.source_org, sandbox.source_repo)
+        assert not set(TEMPORARY_SECRETS) & set(source_secrets)
+        assert not sandbox.source.branch_exists(
+            sandbox.source_org, sandbox.source_repo, "migrate-secrets"
+        )
+
+    def test_placeholders(self, sandbox, seeded_secrets, tmp_path, monkeypatch):
+        """Test that placeholder secrets are created on the target before the workflow runs."""
+        monkeypatch.chdir(tmp_path)
+        result = migrate(
+            sandbox.options(wait=True, placeholder_mode="value"),
+            sandbox.source_token, sandbox.target_token,
+        )
+
+        assert result.succeeded, result.error
+        placeholders = {
+            event.data["secret"] for event in result.events if event.kind == "placeholder_created"
+        }
+        assert set(seeded_secrets) <= placeholders
+
+    def test_refuses_outside_sandbox(self, sandbox, tmp_path, monkeypatch):
+        """Test that --sandbox stops a run aimed at repositories not designated as sandboxes."""
+        monkeypatch.chdir(tmp_path)
+        monkeypatch.setenv(SANDBOX_ENV, "some-other-sandbox-org")
+        result = migrate(sandbox.options(), sandbox.source_token, sandbox.target_token)
+
+        assert not result.succeeded
+        assert "--sandbox refuses to touch" in result.error
+        assert "workflow_pushed" not in [event.kind for event in result.events]
@@ -0,0 +1,110 @@
+"""Tests for signed migration attestations."""
+import json
+from datetime import datetime, timezone
+import pytest
+from src.core.attestation import (
+    CosignSigner, MinisignSigner, build_manifest, make_signer, manifest_text
+)
+from src.core.migration_state import MigrationRecord
+from src.core.workflow_log import SecretOutcome
+
+API_KEY = ("repository", "dst/app", "API_KEY")
+DB_PASSWORD = ("environment", "dst/app:production", "DB_PASSWORD")
+
+
+class Completed:
+    """Stand-in for subprocess.CompletedProcess."""
+
+    def __init__(self, returncode=0, stderr=b""):
+        self.stdout = b""
+        self.returncode = returncode
+        self.stderr = stderr
+
+
+def fake_run(calls, result=None):
+    """Return a run stand-in recording its argv."""
+    def run(argv, **kwargs):
+        calls.append(argv)
+        return result or Completed()
+    return run
+
+
+def make_record():
+    record = MigrationRecord(
+        {"source_org": "src", "source_repo": "app", "target_org": "dst", "target_repos": ["app"]},
+        "2026-05-01T10:00:00+00:00"
+    )
+    record.actors = {"source": "alice", "target": "migration-bot"}
+    record.add_resource(
+        "workflow_run", repo="app", run_id=7, url="https://github.com/src/app/run/7"
+    )
+    record.plan_secrets(7, [DB_PASSWORD, API_KEY])
+    record.record_outcomes(7, [SecretOutcome(*API_KEY, True)])
+    record.finish()
+    return record
+
+
+class TestBuildManifest:
+    """Test cases for migration manifests."""
+
+    def test_manifest(self):
+        """Test that the manifest names secrets, repositories, times and actors."""
+        manifest = build_manifest(make_record(), datetime(2026, 5, 2, tzinfo=timezone.utc))
+        migration = manifest["migration"]
+        assert (migration["source"], migration["targets"]) == ("src/app", ["dst/app"])
+        assert migration["actors"] == {"source": "alice", "target": "migration-bot"}
+        assert migration["workflow_runs"] == ["https://github.com/src/app/run/7"]
+        assert migration["status"] == "completed"
+        assert [(secret["name"], secret["outcome"]) for secret in manifest["secrets"]] == [
+            ("DB_PASSWORD", "unconfirmed"), ("API_KEY", "set"),
+        ]
+        assert manifest["generated_at"] == "2026-05-02T00:00:00+00:00"
+
+    def test_text_is_deterministic(self):
+        """Test that the same manifest always serializes to the same bytes."""
+        manifest = build_manifest(make_record(), datetime(2026, 5, 2, tzinfo=timezone.utc))
+        text = manifest_text(manifest)
+        assert text == manifest_text(json.loads(text))
+        assert text.endswith("}\n")
+
+
+class TestSigners:
+    """Test cases for minisign and cosign invocations."""
+
+    def test_minisign(self):
+        """Test the minisign sign and verify commands."""
+        calls = []
+        signer = MinisignSigner("minisign", run=fake_run(calls))
+        assert signer.sign("m.json", "key.sec", "migration") == "m.json.minisig"
+        signer.verify("m.json", "key.pub")
+        assert calls == [
+            ["minisign", "-S", "-s", "key.sec", "-m", "m.json", "-x", "m.json.minisig",
+             "-t", "migration"],
+            ["minisign", "-V", "-p", "key.pub", "-m", "m.json", "-x", "m.json.minisig"],
+        ]
+        with pytest.raises(ValueError, match="public key"):
+            signer.verify("m.json")
+
+    def test_cosign_keyless(self):
+        """Test that keyless cosign bundles are verified by identity and issuer."""
+        calls = []
+        signer = CosignSigner("cosign", run=fake_run(calls))
+        assert signer.sign("m.json", "") == "m.json.sigstore.json"
+        signer.verify("m.json", identity="a@example.com", issuer="https://issuer")
+        assert calls == [
+            ["cosign", "sign-blob", "--yes", "--bundle", "m.json.sigstore.json", "m.json"],
+            ["cosign", "verify-blob", "--bundle", "m.json.sigstore.json",
+             "--certificate-identity", "a@example.com",
+             "--certificate-oidc-issuer", "https://issuer", "m.json"],
+        ]
+        with pytest.raises(ValueError, match="identity and OIDC issuer"):
+            signer.verify("m.json")
+
+    def test_failures(self):
+        """Test that a failing tool and an unknown signer raise ValueError."""
+        failing = Completed(1, b"Signature verification failed\n")
+        signer = MinisignSigner("minisign", run=fake_run([], failing))
+        with pytest.raises(ValueError, match="minisign failed: Signature verification failed"):
+            signer.verify("m.json", "key.pub")
+        with pytest.raises(ValueError, match="unknown signer"):
+            make_signer("gpg")
@@ -0,0 +1,118 @@
+"""Tests for the tamper-evident audit log."""
+import json
+import os
+from src.core.audit import GENESIS_HASH, AuditLog, operation_of, request_path, verify_chain
+
+IDENTITY = {"side": "source", "login": "octocat", "token": "0123456789abcdef"}
+
+
+def write_log(path, calls=3):
+    audit = AuditLog(str(path), operator="alice@laptop")
+    for index in range(calls):
+        audit.record(IDENTITY, "GET", f"/repos/org/repo-{index}/actions/secrets")
+    return audit
+
+
+def read_lines(path):
+    return path.read_text().splitlines(keepends=True)
+
+
+class TestRequestHelpers:
+    """Test cases for request classification."""
+
+    def test_request_path(self):
+        """Test that absolute pagination URLs and queries are reduced to the path."""
+        url = "https://api.github.com/repos/org/repo/actions/secrets?page=2"
+        assert request_path(url) == "/repos/org/repo/actions/secrets"
+        assert request_path("/orgs/org/actions/secrets?per_page=100") == "/orgs/org/actions/secrets"
+
+    def test_operation_of(self):
+        """Test read/write classification."""
+        assert operation_of("get") == "read"
+        assert operation_of("PUT") == "write"
+        assert operation_of("DELETE") == "write"
+
+
+class TestAuditLog:
+    """Test cases for AuditLog."""
+
+    def test_records_are_chained(self, tmp_path):
+        """Test that each record points at the previous one's hash."""
+        path = tmp_path / "audit.jsonl"
+        audit = write_log(path)
+        records = [json.loads(line) for line in read_lines(path)]
+        assert [record["seq"] for record in records] == [1, 2, 3]
+        assert records[0]["prev_hash"] == GENESIS_HASH
+        assert records[1]["prev_hash"] == records[0]["hash"]
+        assert records[0]["operator"] == "alice@laptop"
+        assert records[0]["identity"] == IDENTITY
+        assert records[0]["operation"] == "read"
+        assert audit.head == records[-1]["hash"]
+        assert audit.appended == 3
+
+    def test_failed_call(self, tmp_path):
+        """Test that failed calls are recorded with their status."""
+        audit = AuditLog(str(tmp_path / "audit.jsonl"), operator="alice@laptop")
+        record = audit.record(IDENTITY, "put", "/repos/org/repo/actions/secrets/A", 403, True)
+        assert record["method"] == "PUT"
+        assert record["operation"] == "write"
+        assert (record["outcome"], record["status"]) == ("failed", 403)
+
+    def test_later_runs_continue_the_chain(self, tmp_path):
+        """Test that reopening a log appends after its last record."""
+        path = tmp_path / "audit.jsonl"
+        first = write_log(path, calls=2)
+        second = AuditLog(str(path), operator="bob@ci")
+        record = second.record(IDENTITY, "DELETE", "/repos/org/repo/actions/secrets/A")
+        assert record["seq"] == 3
+        assert record["prev_hash"] == first.head
+        assert verify_chain(read_lines(path))[2] == []
+
+    def test_file_is_owner_only(self, tmp_path):
+        """Test that a new log is not readable by other users."""
+        path = tmp_path / "audit.jsonl"
+        write_log(path, calls=1)
+        assert os.stat(path).st_mode & 0o077 == 0
+
+
+class TestVerifyChain:
+    """Test cases for verify_chain."""
+
+    def test_intact_log(self, tmp_path):
+        """Test that an untouched log verifies."""
+        path = tmp_path / "audit.jsonl"
+        audit = write_log(path)
+        assert verify_chain(read_lines(path)) == (3, audit.head, [])
+
+    def test_edited_record(self, tmp_path):
+        """Test that changing a record is detected."""
+        path = tmp_path / "audit.jsonl"
+        write_log(path)
+        lines = read_lines(path)
+        lines[1] = lines[1].replace('"GET"', '"DELETE"')
+        problems = verify_chain(lines)[2]
+        assert problems == ["line 2: hash mismatch (record edited)"]
+
+    def test_removed_record(self, tmp_path):
+        """Test that dropping a record in the middle is detected."""
+        path = tmp_path / "audit.jsonl"
+        write_log(path)
+        lines = read_lines(path)
+        del lines[1]
+        problems = verify_chain(lines)[2]
+        assert "line 2: sequence 3, expected 2" in problems
+        assert "line 2: chain broken (records removed or reordered)" in problems
+
+    def test_truncation_needs_an_anchor(self, tmp_path):
+        """Test that dropped trailing records are only caught with an anchor."""
+        path = tmp_path / "audit.jsonl"
+        audit = write_log(path)
+        truncated = read_lines(path)[:2]
+        assert verify_chain(truncated)[2] == []
+        problems = verify_chain(truncated, audit.head[:16])[2]
+        assert problems == [f"no record has hash {audit.head[:16]} (records dropped from the end)"]
+        assert verify_chain(read_lines(path), audit.head[:16])[2] == []
+
+    def test_malformed_line(self):
+        """Test that a line that is not JSON is reported."""
+        assert verify_chain(["not json\n"]) == (0, GENESIS_HASH, ["line 1: not a JSON record"])
@@ -0,0 +1,55 @@
+"""Tests for the audit log changes that drive syncs."""
+from datetime import datetime, timedelta, timezone
+from src.core.audit_changes import audit_log_phrase, audited_actions, audited_changes
+
+NOW = datetime(2026, 3, 1, 12, 0, tzinfo=timezone.utc)
+STAMP = int(NOW.timestamp() * 1000)
+
+
+class TestAuditLogPhrase:
+    """Test cases for the audit log search phrase."""
+
+    def test_phrase_in_utc(self):
+        """Test that the time is searched in UTC, narrowed to a repository when given."""
+        since = NOW.astimezone(timezone(timedelta(hours=2)))
+        assert audit_log_phrase(since) == "created:>=2026-03-01T12:00:00Z"
+        assert audit_log_phrase(since, "src/app") == "created:>=2026-03-01T12:00:00Z repo:src/app"
+
+    def test_actions_per_level_and_kind(self):
+        """Test that only the actions of the levels and kinds migrated are watched."""
+        assert audited_actions(["org"], variables=False) == [
+            "org.create_actions_secret", "org.update_actions_secret", "org.remove_actions_secret",
+        ]
+        assert "environment.update_actions_variable" in audited_actions(["env"], secrets=False)
+
+
+class TestAuditedChanges:
+    """Test cases for picking secret changes out of audit log entries."""
+
+    ENTRIES = [
+        {"action": "repo.update_actions_secret", "repo": "src/app", "actor": "mona",
+         "@timestamp": STAMP},
+        {"action": "repo.update_actions_secret", "repo": "src/other"},
+        {"action": "environment.create_actions_secret", "repo": "SRC/App"},
+        {"action": "org.remove_actions_secret"},
+        {"action": "repo.create", "repo": "src/app"},
+    ]
+
+    def test_changes_of_the_source_repository(self):
+        """Test that other repositories and unrelated actions are left out."""
+        changes = audited_changes(self.ENTRIES, ["repo", "env"], "src/app")
+        assert [change.action for change in changes] == [
+            "repo.update_actions_secret", "environment.create_actions_secret",
+        ]
+        assert changes[0].describe() == (
+            "repo.update_actions_secret in src/app by mona at 2026-03-01T12:00:00+00:00"
+        )
+
+    def test_organization_changes_count_anywhere(self):
+        """Test that organization changes count whichever repository is synced."""
+        changes = audited_changes(self.ENTRIES, ["org"], "src/app")
+        assert [change.action for change in changes] == ["org.remove_actions_secret"]
+
+    def test_no_changes_of_migrated_kinds(self):
+        """Test that secret changes are ignored when only variables migrate."""
+        assert audited_changes(self.ENTRIES, ["repo", "env", "org"], secrets=False) == []
@@ -0,0 +1,80 @@
+"""Tests for the migration branch rules preflight."""
+from src.core.branch_rules import (
+    branch_pattern_matches,
+    candidate_branches,
+    protection_blockers,
+    remediation,
+    ruleset_blockers,
+)
+
+WORKFLOW = ".github/workflows/migrate-secrets.yml"
+
+
+class TestRulesetBlockers:
+    """Test cases for ruleset rules."""
+
+    def test_blocking_rules(self):
+        """Test that creation and pull request rules block the branch."""
+        rules = [
+            {"type": "creation", "ruleset_id": 7},
+            {"type": "pull_request", "ruleset_id": 7},
+            {"type": "non_fast_forward", "ruleset_id": 7},
+            {"type": "required_linear_history", "ruleset_id": 8},
+        ]
+        blockers = ruleset_blockers(rules, WORKFLOW)
+        assert [str(blocker) for blocker in blockers] == [
+            "ruleset 7 restricts branch creation",
+            "ruleset 7 requires changes through a pull request",
+        ]
+
+    def test_file_path_restriction(self):
+        """Test that only restrictions covering the workflow file block."""
+        rule = {
+            "type": "file_path_restriction", "ruleset_id": 3,
+            "parameters": {"restricted_file_paths": [".github/workflows/*"]},
+        }
+        assert len(ruleset_blockers([rule], WORKFLOW)) == 1
+        rule["parameters"]["restricted_file_paths"] = ["docs/*"]
+        assert ruleset_blockers([rule], WORKFLOW) == []
+
+
+class TestProtectionBlockers:
+    """Test cases for classic branch protection rules."""
+
+    def test_matching_pattern(self):
+        """Test that a wildcard protection matching the branch blocks it."""
+        protections = [
+            {"pattern": "*", "blocksCreations": True},
+            {"pattern": "release/*", "requiresApprovingReviews": True},
+        ]
+        blockers = protection_blockers(protections, "migrate-secrets")
+        assert [blocker.source for blocker in blockers] == ["branch protection '*'"]
+
+    def test_unprotected_branch(self):
+        """Test that protections on other branches do not block."""
+        protections = [{"pattern": "main", "requiresStatusChecks": True}]
+        assert protection_blockers(protections, "migrate-secrets") == []
+
+    def test_pattern_matching(self):
+        """Test that '*' stays within one path segment and '**' does not."""
+        assert branch_pattern_matches("*", "migrate-secrets")
+        assert not branch_pattern_matches("*", "automation/migrate-secrets")
+        assert branch_pattern_matches("**", "automation/migrate-secrets")
+        assert branch_pattern_matches("automation/*", "automation/migrate-secrets")
+
+
+class TestCandidates:
+    """Test cases for fallback branch names and remediation."""
+
+    def test_candidates_start_with_branch(self):
+        """Test that auto mode tries the configured name first."""
+        candidates = candidate_branches("migrate-secrets")
+        assert candidates[0] == "migrate-secrets"
+        assert "automation/migrate-secrets" in candidates
+
+    def test_remediation_lists_blockers(self):
+        """Test that remediation names every blocker and the fixes."""
+        blockers = ruleset_blockers([{"type": "update", "ruleset_id": 1}], WORKFLOW)
+        lines = remediation("migrate-secrets", blockers)
+        assert "ruleset 1 restricts pushes" in lines[1]
+        assert "--branch-name" in lines[-1]
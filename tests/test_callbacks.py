@@ -0,0 +1,72 @@
+"""Tests for signed lifecycle callbacks."""
+import pytest
+from src.core.callbacks import (
+    EVENT_HEADER,
+    SIGNATURE_HEADER,
+    CallbackSender,
+    sign_payload,
+    verify_signature,
+)
+from src.core.events import EventLog
+from src.utils.http import encode_json
+
+
+class TestSignatures:
+    """Test cases for HMAC signing."""
+
+    def test_sign_and_verify(self):
+        """Test that a signature verifies only for the same secret and body."""
+        signature = sign_payload("s3cret", b'{"event": "started"}')
+        assert signature.startswith("sha256=")
+        assert verify_signature("s3cret", b'{"event": "started"}', signature)
+        assert not verify_signature("other", b'{"event": "started"}', signature)
+        assert not verify_signature("s3cret", b'{"event": "failed"}', signature)
+
+
+class TestCallbackSender:
+    """Test cases for CallbackSender."""
+
+    def _sender(self, temp_logger, sent, url="https://hooks.example.com/cb", fail=False):
+        def post(url, payload, headers, schemes):
+            if fail:
+                raise OSError("connection refused")
+            sent.append((url, payload, headers))
+
+        events = EventLog(["ghp_token"])
+        return CallbackSender(
+            url, "s3cret", "migrate", temp_logger, redact=events.redact, post=post
+        )
+
+    def test_signed_payload(self, temp_logger):
+        """Test that the body is signed and carries the run id."""
+        sent = []
+        sender = self._sender(temp_logger, sent)
+        sender.send("started", source_org="acme")
+        sender.send("finished", migrated=1)
+        (_, first, headers), (_, second, _) = sent
+        assert first["event"] == "started"
+        assert first["source_org"] == "acme"
+        assert first["run_id"] == second["run_id"] == sender.run_id
+        assert headers[EVENT_HEADER] == "started"
+        assert verify_signature("s3cret", encode_json(first), headers[SIGNATURE_HEADER])
+
+    def test_payload_redacted(self, temp_logger):
+        """Test that tokens in error messages never leave the process."""
+        sent = []
+        self._sender(temp_logger, sent).send("failed", error="bad token ghp_token")
+        assert "ghp_token" not in sent[0][1]["error"]
+
+    def test_disabled_without_url(self, temp_logger):
+        """Test that nothing is sent when no URL is configured."""
+        sent = []
+        assert self._sender(temp_logger, sent, url="").send("started") is None
+        assert sent == []
+
+    def test_delivery_failure_does_not_raise(self, temp_logger):
+        """Test that an unreachable endpoint only produces a warning."""
+        assert self._sender(temp_logger, [], fail=True).send("started") is None
+
+    def test_unknown_event_rejected(self, temp_logger):
+        """Test that only documented events can be sent."""
+        with pytest.raises(ValueError):
+            self._sender(temp_logger, []).send("progress")
@@ -0,0 +1,66 @@
+"""Tests for finding the placeholders an aborted migration leaves behind."""
+from datetime import datetime, timezone
+from src.core.cancel import placeholder_only, recorded_placeholders
+from src.core.inventory import SecretRecord
+from src.core.migration_state import MigrationRecord
+
+RUN_STARTED = datetime(2024, 5, 1, 10, 0, tzinfo=timezone.utc)
+BEFORE = datetime(2024, 5, 1, 9, 58)  # naive UTC, as older PyGithub releases return
+AFTER = datetime(2024, 5, 1, 10, 2, tzinfo=timezone.utc)
+
+
+class TestRecordedPlaceholders:
+    """Test cases for recorded_placeholders."""
+
+    def test_filters_by_target(self):
+        """Test that placeholders of other fan-out targets are left out."""
+        record = MigrationRecord({})
+        record.add_resource("placeholder", level="repo", repo="app", environment="", name="A")
+        record.add_resource("placeholder", level="env", repo="app", environment="prod", name="B")
+        record.add_resource("placeholder", level="repo", repo="other", environment="", name="C")
+        record.add_resource("branch", repo="app", name="migrate-secrets")
+        labels = [placeholder.label for placeholder in recorded_placeholders(record, "app")]
+        assert labels == ["repo:A", "env:prod/B"]
+
+    def test_creation_time(self):
+        """Test that the recorded creation time becomes the record's update time."""
+        record = MigrationRecord({})
+        record.add_resource(
+            "placeholder", level="org", repo="", environment="", name="A",
+            created_at=RUN_STARTED.isoformat()
+        )
+        record.add_resource("placeholder", level="org", repo="", environment="", name="B")
+        placeholders = recorded_placeholders(record, "")
+        assert [placeholder.updated_at for placeholder in placeholders] == [RUN_STARTED, None]
+
+
+class TestPlaceholderOnly:
+    """Test cases for placeholder_only."""
+
+    def test_overwritten_placeholders_are_kept(self):
+        """Test that only secrets untouched since before the run are selected."""
+        placeholders = [
+            SecretRecord("API_KEY", "repo"),
+            SecretRecord("DB_PASSWORD", "env", environment="production"),
+            SecretRecord("GONE", "repo"),
+            SecretRecord("UNKNOWN", "repo"),
+        ]
+        target = [
+            SecretRecord("API_KEY", "repo", updated_at=BEFORE),
+            SecretRecord("DB_PASSWORD", "env", environment="production", updated_at=AFTER),
+            SecretRecord("UNKNOWN", "repo"),
+            SecretRecord("UNRELATED", "repo", updated_at=BEFORE),
+        ]
+        selected = placeholder_only(placeholders, target, RUN_STARTED)
+        assert [record.label for record in selected] == ["repo:API_KEY"]
+
+    def test_no_run(self):
+        """Test that every remaining placeholder is selected when the workflow never ran."""
+        placeholders = [SecretRecord("API_KEY", "repo"), SecretRecord("NPM_TOKEN", "org")]
+        target = [
+            SecretRecord("API_KEY", "repo", updated_at=AFTER),
+            SecretRecord("NPM_TOKEN", "org", updated_at=BEFORE),
+        ]
+        assert [record.label for record in placeholder_only(placeholders, target)] == [
+            "repo:API_KEY", "org:NPM_TOKEN"
+        ]
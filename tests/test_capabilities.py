@@ -0,0 +1,126 @@
+"""Tests for the capability matrix."""
+from src.core.capabilities import (
+    ALLOWED,
+    BLOCKED,
+    SKIPPED,
+    missing_scopes,
+    org_capabilities,
+    repo_capabilities,
+)
+from src.core.config import MigrationConfig
+
+
+def _config(**options):
+    defaults = {"source_org": "s", "target_org": "t", "source_pat": "a", "target_pat": "b",
+                "source_repo": "app", "target_repo": "app"}
+    defaults.update(options)
+    return MigrationConfig(**defaults)
+
+
+def _statuses(matrix):
+    return {row.namespace: row.status for row in matrix.rows}
+
+
+class TestMissingScopes:
+    """Test cases for classic token scope checks."""
+
+    def test_implied_scopes(self):
+        """Test that broader scopes satisfy narrower ones."""
+        assert missing_scopes(["admin:org", "repo"], ["read:org", "public_repo"]) == []
+
+    def test_missing(self):
+        """Test that absent scopes are reported in order."""
+        assert missing_scopes(["repo"], ["repo", "workflow", "admin:org"]) == ["workflow", "admin:org"]
+
+    def test_fine_grained_tokens_are_not_checked(self):
+        """Test that tokens without a scopes header are left to the API."""
+        assert missing_scopes(None, ["repo"]) == []
+
+
+class TestRepoCapabilities:
+    """Test cases for repository-to-repository runs."""
+
+    def test_defaults_allowed(self):
+        """Test a run with every permission in place."""
+        statuses = _statuses(repo_capabilities(_config(), ["repo", "workflow"], ["repo"], True, True))
+        assert statuses["repository secrets"] == ALLOWED
+        assert statuses["environments"] == ALLOWED
+        assert statuses["repository settings"] == SKIPPED
+        assert statuses["organization secrets"] == SKIPPED
+        assert statuses["dependabot secrets"] == SKIPPED
+
+    def test_non_admin_blocks_environments_and_settings(self):
+        """Test that admin-only parts are blocked without admin access."""
+        matrix = repo_capabilities(_config(migrate_settings=True), None, None, True, False)
+        assert [row.namespace for row in matrix.blocked] == [
+            "environments", "environment secrets", "environment variables", "repository settings"
+        ]
+
+    def test_skip_envs_needs_no_admin(self):
+        """Test that --skip-envs leaves a non-admin run unblocked."""
+        matrix = repo_capabilities(_config(skip_envs=True), None, None, True, False)
+        assert matrix.blocked == []
+        assert _statuses(matrix)["environments"] == SKIPPED
+
+    def test_missing_target(self):
+        """Test that a missing target is blocked unless it will be created."""
+        assert _statuses(repo_capabilities(_config(), None, None, False, None))[
+            "target repository"] == BLOCKED
+        created = repo_capabilities(_config(create_target_repo=True), None, None, False, None)
+        assert created.blocked == []
+        assert "private" in created.rows[0].reason
+
+    def test_levels_and_categories(self):
+        """Test that unselected levels and categories are skipped, not blocked."""
+        matrix = repo_capabilities(
+            _config(levels=["env"], migrate_variables=False), None, None, True, False
+        )
+        statuses = _statuses(matrix)
+        assert statuses["repository secrets"] == SKIPPED
+        assert statuses["repository variables"] == SKIPPED
+        assert statuses["environment variables"] == SKIPPED
+        assert [row.namespace for row in matrix.blocked] == ["environments", "environment secrets"]
+
+    def test_org_level(self):
+        """Test that --levels org adds the organization rows to a repository run."""
+        statuses = _statuses(repo_capabilities(
+            _config(levels=["repo", "org"], migrate_secrets=False),
+            ["repo", "workflow", "admin:org"], ["repo", "admin:org"], True, True
+        ))
+        assert statuses["organization secrets"] == SKIPPED
+        assert statuses["organization variables"] == ALLOWED
+        assert statuses["environments"] == SKIPPED
+
+    def test_missing_workflow_scope(self):
+        """Test that the source token must be able to push the workflow."""
+        matrix = repo_capabilities(_config(), ["repo"], ["repo"], True, True)
+        assert matrix.blocked[0].namespace == "repository secrets"
+        assert "workflow" in matrix.blocked[0].reason
+
+
+class TestOrgCapabilities:
+    """Test cases for organization-to-organization runs."""
+
+    def test_target_needs_admin_org(self):
+        """Test that organization secrets need admin:org on the target token."""
+        matrix = org_capabilities(_config(org_to_org=True), ["repo", "workflow", "admin:org"], ["repo"])
+        assert [row.namespace for row in matrix.blocked] == [
+            "organization secrets", "organization variables"
+        ]
+
+    def test_repo_only_flags_not_applicable(self):
+        """Test that repository-only flags are listed as skipped, not blocked."""
+        matrix = org_capabilities(
+            _config(org_to_org=True, migrate_settings=True, create_target_repo=True), None, None
+        )
+        statuses = _statuses(matrix)
+        assert statuses["repository settings"] == SKIPPED
+        assert statuses["target repository"] == SKIPPED
+        assert matrix.blocked == []
+
+    def test_table_alignment(self):
+        """Test that the rendered table has a header and aligned columns."""
+        lines = org_capabilities(_config(org_to_org=True), None, None).format_table()
+        assert lines[0].startswith("NAMESPACE")
+        offset = lines[0].index("STATUS")
+        assert all(line[offset:].split()[0] in ("STATUS", ALLOWED, SKIPPED) for line in lines)
@@ -0,0 +1,121 @@
+"""Tests for the recorded-fixture harness."""
+import json
+import pytest
+from tests.cassette import MASK, Cassette, CassetteError, connection_class, mask_body
+
+
+def _item(method, path, query="", status=200, response="{}"):
+    return {"method": method, "path": path, "query": query, "body": None, "status": status,
+            "headers": {"content-type": "application/json"}, "response": response}
+
+
+class TestCassette:
+    """Test cases for matching calls to recorded interactions."""
+
+    def test_play_in_order(self, tmp_path):
+        """Test that repeated calls are answered in recorded order, once each."""
+        cassette = Cassette(tmp_path / "run.json", interactions=[
+            _item("GET", "/repos/acme/app", response='{"n": 1}'),
+            _item("GET", "/repos/acme/app", response='{"n": 2}'),
+        ])
+        assert cassette.play("GET", "/repos/acme/app")["response"] == '{"n": 1}'
+        assert cassette.play("GET", "/repos/acme/app")["response"] == '{"n": 2}'
+        with pytest.raises(CassetteError, match="run.json has no recorded response for GET"):
+            cassette.play("GET", "/repos/acme/app")
+
+    def test_query_preferred_not_required(self, tmp_path):
+        """Test that a matching query string wins, and a differing one still matches the path."""
+        cassette = Cassette(tmp_path / "run.json", interactions=[
+            _item("GET", "/repos/acme/app/actions/runs", "created=%3E2026-01-01"),
+            _item("GET", "/repos/acme/app/actions/runs", "branch=migrate-secrets"),
+        ])
+        assert cassette.play("GET", "/repos/acme/app/actions/runs?branch=migrate-secrets")[
+            "query"] == "branch=migrate-secrets"
+        assert cassette.play("GET", "/repos/acme/app/actions/runs?created=%3E2026-10-16")
+        assert cassette.unused == []
+
+    def test_method_must_match(self, tmp_path):
+        """Test that a call is never answered with another method's response."""
+        cassette = Cassette(tmp_path / "run.json", interactions=[_item("GET", "/user")])
+        with pytest.raises(CassetteError):
+            cassette.play("DELETE", "/user")
+        assert len(cassette.unused) == 1
+
+    def test_record_save_load(self, tmp_path):
+        """Test that recordings keep only known headers and survive a round trip."""
+        cassette = Cassette(tmp_path / "cassettes" / "run.json", {"source_org": "acme"})
+        cassette.record(
+            "PUT", "/repos/acme/app/actions/secrets/DB?x=1",
+            json.dumps({"encrypted_value": "c2VhbGVk", "key_id": "1"}), 201,
+            [("Content-Type", "application/json"), ("Set-Cookie", "session=abc"),
+             ("X-RateLimit-Remaining", "4999")], "",
+        )
+        cassette.save()
+        loaded = Cassette.load(tmp_path / "cassettes" / "run.json")
+        item = loaded.interactions[0]
+        assert loaded.meta == {"source_org": "acme"}
+        assert (item["path"], item["query"]) == ("/repos/acme/app/actions/secrets/DB", "x=1")
+        assert item["headers"] == {"content-type": "application/json",
+                                   "x-ratelimit-remaining": "4999"}
+        assert json.loads(item["body"]) == {"encrypted_value": MASK, "key_id": "1"}
+
+
+class TestMaskBody:
+    """Test cases for keeping sealed values out of cassettes."""
+
+    @pytest.mark.parametrize("body, expected", [
+        (None, None),
+        ('{"name": "x"}', '{"name": "x"}'),
+        (b'{"encrypted_value": "abc"}', json.dumps({"encrypted_value": MASK})),
+        ("[1, 2]", "[1, 2]"),
+        ("not json", "not json"),
+    ])
+    def test_mask(self, body, expected):
+        """Test that only sealed secret values are replaced."""
+        assert mask_body(body) == expected
+
+
+class TestConnectionClass:
+    """Test cases for the connection classes handed to PyGithub."""
+
+    def test_replay(self, tmp_path):
+        """Test that a replaying connection answers like an HTTP connection would."""
+        cassette = Cassette(tmp_path / "run.json", interactions=[
+            _item("GET", "/repos/acme/app", status=404, response='{"message": "Not Found"}')
+        ])
+        connection = connection_class(cassette)("api.github.com", 443, timeout=15, retry=None)
+        connection.request("GET", "/repos/acme/app", None, {"Authorization": "token x"})
+        response = connection.getresponse()
+        assert response.status == 404
+        assert response.getheaders() == [("content-type", "application/json")]
+        assert json.loads(response.read()) == {"message": "Not Found"}
+        connection.close()
+
+    def test_record(self, tmp_path):
+        """Test that a recording connection passes calls through and keeps them."""
+        sent = []
+
+        class Live:
+            def __init__(self, host, port, **kwargs):
+                self.host = host
+
+            def request(self, verb, url, input, headers):
+                sent.append((verb, url, headers))
+
+            def getresponse(self):
+                response = type("Response", (), {})()
+                response.status = 200
+                response.getheaders = lambda: [("ETag", '"abc"')]
+                response.read = lambda: b'{"login": "octocat"}'
+                return response
+
+            def close(self):
+                pass
+
+        cassette = Cassette(tmp_path / "run.json")
+        connection = connection_class(cassette, Live)("api.github.com", 443, timeout=15)
+        connection.request("GET", "/user", None, {"Authorization": "token secret-token"})
+        assert connection.getresponse().read() == '{"login": "octocat"}'
+        assert sent == [("GET", "/user", {"Authorization": "token secret-token"})]
+        assert cassette.interactions[0]["headers"] == {"etag": '"abc"'}
+        assert "secret-token" not in json.dumps(cassette.interactions)
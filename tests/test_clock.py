@@ -0,0 +1,66 @@
+"""Tests for server clock skew estimation."""
+from datetime import datetime, timedelta, timezone
+from src.core.inventory import SecretRecord, diff_inventories
+from src.utils.clock import as_utc, estimate_skew, format_skew, parse_http_date
+
+LOCAL = datetime(2025, 6, 1, 12, 0, 0, tzinfo=timezone.utc)
+
+
+class TestParseHttpDate:
+    """Test cases for parse_http_date."""
+
+    def test_valid_header(self):
+        """Test parsing an RFC 7231 date."""
+        assert parse_http_date("Sun, 01 Jun 2025 12:00:05 GMT") == LOCAL + timedelta(seconds=5)
+
+    def test_malformed_header(self):
+        """Test that garbage yields None."""
+        assert parse_http_date("") is None
+        assert parse_http_date("yesterday") is None
+
+
+class TestEstimateSkew:
+    """Test cases for estimate_skew."""
+
+    def test_uses_request_midpoint(self):
+        """Test that latency is split around the midpoint."""
+        server_time = LOCAL + timedelta(seconds=31)
+        skew = estimate_skew(server_time, LOCAL, LOCAL + timedelta(seconds=2))
+        assert skew == timedelta(seconds=30)
+        assert format_skew(skew) == "+30.0s"
+
+
+class TestAsUtc:
+    """Test cases for as_utc."""
+
+    def test_naive_is_utc(self):
+        """Test that naive datetimes are read as UTC and aware ones kept."""
+        assert as_utc(LOCAL.replace(tzinfo=None)) == LOCAL
+        offset = LOCAL.astimezone(timezone(timedelta(hours=2)))
+        assert as_utc(offset) is offset
+
+
+class TestSkewAdjustedDiff:
+    """Test that inventory comparison accounts for clock skew."""
+
+    def test_fast_source_clock_does_not_report_stale(self):
+        """Test that a source clock running ahead doesn't flag fresh targets as stale."""
+        # Target was written 10s (real time) after the source, but the source clock is 60s fast
+        source = [SecretRecord("A", "repo", updated_at=LOCAL + timedelta(seconds=60))]
+        target = [SecretRecord("A", "repo", updated_at=LOCAL + timedelta(seconds=10))]
+        assert len(diff_inventories(source, target).stale_on_target) == 1
+        diff = diff_inventories(source, target, source_skew=timedelta(seconds=60))
+        assert diff.stale_on_target == []
+
+    def test_slow_target_clock_still_detects_stale(self):
+        """Test that a real update after the target copy is still reported."""
+        source = [SecretRecord("A", "repo", updated_at=LOCAL + timedelta(minutes=5))]
+        target = [SecretRecord("A", "repo", updated_at=LOCAL - timedelta(minutes=1))]
+        diff = diff_inventories(source, target, target_skew=timedelta(minutes=-2))
+        assert len(diff.stale_on_target) == 1
+
+    def test_tolerance(self):
+        """Test that near-identical timestamps are treated as equal."""
+        source = [SecretRecord("A", "repo", updated_at=LOCAL + timedelta(seconds=1))]
+        target = [SecretRecord("A", "repo", updated_at=LOCAL)]
+        assert diff_inventories(source, target, tolerance=timedelta(seconds=2)).stale_on_target == []
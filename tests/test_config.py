@@ -1,5 +1,8 @@
 """Tests for configuration module."""
-from src.core.config import MigrationConfig
+import pytest
+from src.core.config import (
+    MigrationConfig, check_promotion, check_target_env, check_unarchive
+)
 
 
 class TestMigrationConfig:
@@ -21,6 +24,7 @@ class TestMigrationConfig:
         assert config.target_repo == "target-repo"
         assert config.org_to_org is False
         assert config.skip_envs is False
+        assert config.placeholder_mode == "none"
 
     def test_config_org_to_org(self):
         """Test org-to-org configuration."""
@@ -84,3 +88,78 @@ class TestMigrationConfig:
         assert config.verbose is True
         assert config.skip_envs is True
         assert config.org_to_org is False
+
+    def test_config_placeholder_options(self):
+        """Test placeholder configuration."""
+        config = MigrationConfig(
+            source_org="source-org",
+            source_repo="source-repo",
+            target_org="target-org",
+            target_repo="target-repo",
+            source_pat="test-pat",
+            target_pat="test-pat",
+            placeholder_mode="skip-existing",
+            placeholder_value="TBD",
+        )
+        assert config.placeholder_mode == "skip-existing"
+        assert config.placeholder_value == "TBD"
+
+    def test_config_selected_levels(self):
+        """Test that levels default to the mode's own and keep migration order."""
+        options = {"source_org": "s", "target_org": "t", "source_pat": "a", "target_pat": "b"}
+        assert MigrationConfig(**options).selected_levels == ["repo", "env"]
+        assert MigrationConfig(org_to_org=True, **options).selected_levels == ["org"]
+        assert MigrationConfig(levels=["org", "repo"], **options).selected_levels == ["repo", "org"]
+
+    def test_options_leave_out_credentials(self):
+        """Test that options() rebuilds the configuration without its credentials."""
+        config = MigrationConfig(
+            source_org="s", target_org="t", source_pat="a", target_pat="b",
+            state_passphrase="secret", extra_target_repos=["x"], environment_map={"prod": "live"},
+        )
+        options = config.options()
+        assert not {"source_pat", "target_pat", "state_passphrase"} & set(options)
+        assert options["extra_target_repos"] == ["x"]
+        assert MigrationConfig(source_pat="c", target_pat="d", **options).options() == options
+
+
+class TestCheckUnarchive:
+    """Test cases for check_unarchive."""
+
+    def test_unarchive_needs_wait_and_push_delivery(self):
+        """Test that repositories are only unarchived when the run waits for the workflow."""
+        check_unarchive(False, False, "pull-request")
+        check_unarchive(True, True, "push")
+        with pytest.raises(ValueError, match="needs --wait"):
+            check_unarchive(True, False, "push")
+        with pytest.raises(ValueError, match="pull-request"):
+            check_unarchive(True, True, "pull-request")
+
+
+class TestCheckPromotion:
+    """Test cases for check_promotion."""
+
+    def test_promotion_needs_repository_secrets(self):
+        """Test that secrets are only promoted by runs migrating repository secrets."""
+        check_promotion([], True, ["org"])
+        check_promotion(["NPM"], False, [])
+        check_promotion(["NPM"], False, ["repo", "env"])
+        with pytest.raises(ValueError, match="only applies to repository migrations"):
+            check_promotion(["NPM"], True, [])
+        with pytest.raises(ValueError, match="needs the 'repo' level"):
+            check_promotion(["NPM"], False, ["env"])
+
+
+class TestCheckTargetEnv:
+    """Test cases for check_target_env."""
+
+    def test_target_env(self):
+        """Test that repository secrets are only routed when the run migrates them."""
+        check_target_env("", True, ["org"], {})
+        check_target_env("production", False, [], {"prod": "production"})
+        with pytest.raises(ValueError, match="only applies to repository migrations"):
+            check_target_env("production", True, [], {})
+        with pytest.raises(ValueError, match="needs the 'repo' level"):
+            check_target_env("production", False, ["env"], {})
+        with pytest.raises(ValueError, match="--map-environment production=live"):
+            check_target_env("production", False, [], {"production": "live"})
@@ -0,0 +1,14 @@
+"""Tests for target conflict detection."""
+from src.core.conflicts import find_conflicts
+
+
+class TestFindConflicts:
+    """Test cases for find_conflicts."""
+
+    def test_case_insensitive(self):
+        """Test that GitHub's case-insensitive names are honoured."""
+        assert find_conflicts(["DB", "API"], ["db", "OTHER"]) == ["DB"]
+
+    def test_no_conflicts(self):
+        """Test an empty target."""
+        assert find_conflicts(["DB"], []) == []
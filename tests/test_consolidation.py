@@ -0,0 +1,98 @@
+"""Tests for consolidating several sources into one target."""
+from src.core.consolidation import (
+    consolidation_error, find_collisions, parse_source, plan_source, source_prefix,
+)
+from src.core.naming import SecretNameTransformer
+from src.core.policy import SecretPolicy
+
+
+class TestSourcePrefix:
+    """Test cases for automatic and explicit prefixes."""
+
+    def test_automatic_prefix(self):
+        """Test that repository names become valid upper-case prefixes."""
+        assert source_prefix("payments-api") == "PAYMENTS_API_"
+        assert source_prefix("web.frontend") == "WEB_FRONTEND_"
+        assert source_prefix("2fa") == "_2FA_"
+
+    def test_parse_source(self):
+        """Test the REPO=PREFIX_ form, including an empty prefix."""
+        assert parse_source("payments") == ("payments", "PAYMENTS_")
+        assert parse_source("payments=PAY_") == ("payments", "PAY_")
+        assert parse_source("legacy=") == ("legacy", "")
+
+
+class TestPlanSource:
+    """Test cases for listing what one source writes."""
+
+    def test_prefix_policy_and_routing(self):
+        """Test that names are prefixed, policy-filtered and routed to target environments."""
+        planned = plan_source(
+            "api", SecretNameTransformer("API_"), ["DB", "SECRETS_MIGRATOR_PAT", "DEBUG_KEY"],
+            {"prod": ["TOKEN"]}, {"prod": "production"}, SecretPolicy(deny=["DEBUG_*"])
+        )
+        assert [(item.scope, item.target_name) for item in planned] == [
+            ("repository", "API_DB"), ("environment production", "API_TOKEN")
+        ]
+
+
+    def test_target_env(self):
+        """Test that repository secrets are planned in the target environment."""
+        planned = plan_source(
+            "api", SecretNameTransformer(), ["DB"], {"prod": ["TOKEN"]}, target_env="prod"
+        )
+        assert [(item.scope, item.target_name) for item in planned] == [
+            ("environment prod", "DB"), ("environment prod", "TOKEN")
+        ]
+
+
+class TestFindCollisions:
+    """Test cases for collision detection before any write."""
+
+    def test_distinct_prefixes(self):
+        """Test that prefixed names from different sources do not collide."""
+        planned = plan_source("a", SecretNameTransformer("A_"), ["DB"], {})
+        planned += plan_source("b", SecretNameTransformer("B_"), ["DB"], {})
+        assert find_collisions(planned) == []
+
+    def test_collision_is_case_insensitive(self):
+        """Test that two sources writing the same target name are reported."""
+        planned = plan_source("a", SecretNameTransformer(), ["DB"], {})
+        planned += plan_source("b", SecretNameTransformer(), ["db"], {})
+        problems = find_collisions(planned)
+        assert problems == ["repository 'DB' would be written by a:DB, b:db"]
+
+    def test_same_name_in_different_scopes(self):
+        """Test that repository and environment secrets may share a name."""
+        planned = plan_source("a", SecretNameTransformer(), ["DB"], {})
+        planned += plan_source("b", SecretNameTransformer(), [], {"prod": ["DB"]})
+        assert find_collisions(planned) == []
+
+    def test_promoted_secrets_are_shared(self):
+        """Test that sources promoting the same organization secret do not collide."""
+        planned = plan_source("a", SecretNameTransformer(), ["NPM", "DB"], {}, promoted=["npm"])
+        planned += plan_source("b", SecretNameTransformer(), ["NPM"], {}, promoted=["NPM"])
+        assert [item.scope for item in planned] == ["organization", "repository", "organization"]
+        assert find_collisions(planned) == []
+
+    def test_existing_and_invalid_names(self):
+        """Test reporting of existing target secrets and names GitHub would refuse."""
+        planned = plan_source("a", SecretNameTransformer("GITHUB_"), ["DB"], {})
+        planned += plan_source("b", SecretNameTransformer("B_"), ["DB"], {})
+        problems = find_collisions(planned, {"repository": ["b_db"]})
+        assert any("GITHUB_DB" in problem for problem in problems)
+        assert problems[-1] == "b:DB -> repository 'B_DB' already exists on target"
+
+
+class TestConsolidationError:
+    """Test cases for the options a consolidation accepts."""
+
+    def test_two_sources_one_target(self):
+        """Test that two sources into one target run without --prune and are rejected with it."""
+        assert consolidation_error(1, org_to_org=False, prune=False) is None
+        assert "--prune" in consolidation_error(1, org_to_org=False, prune=True)
+
+    def test_needs_one_target_and_repo_to_repo(self):
+        """Test that several targets or --org-to-org are rejected."""
+        assert "one target repository" in consolidation_error(2, org_to_org=False, prune=False)
+        assert "one target repository" in consolidation_error(1, org_to_org=True, prune=False)
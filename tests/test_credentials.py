@@ -0,0 +1,107 @@
+"""Tests for source and target token resolution."""
+import pytest
+from src.core.credentials import (
+    default_host, gh_stored_token, is_enterprise_host, resolve_token
+)
+
+
+class TestResolveToken:
+    """Test cases for per-side token precedence."""
+
+    def test_flag_wins(self):
+        """Test that an explicit flag beats every environment variable."""
+        environ = {"SOURCE_GITHUB_TOKEN": "env", "GITHUB_TOKEN": "shared"}
+        assert resolve_token("source", "--source-pat", "flag", environ) == ("flag", "--source-pat")
+
+    def test_side_specific_variables(self):
+        """Test that each side reads its own variable before the shared one."""
+        environ = {"SOURCE_GITHUB_TOKEN": "src", "TARGET_GITHUB_TOKEN": "tgt", "GITHUB_TOKEN": "x"}
+        assert resolve_token("source", "--source-pat", "", environ) == ("src", "SOURCE_GITHUB_TOKEN")
+        assert resolve_token("target", "--target-pat", "", environ) == ("tgt", "TARGET_GITHUB_TOKEN")
+
+    def test_shared_fallback(self):
+        """Test that GITHUB_TOKEN still serves both sides."""
+        assert resolve_token("target", "--pat", "", {"GITHUB_TOKEN": "x"}) == ("x", "GITHUB_TOKEN")
+        assert resolve_token("target", "--pat", "", {}) == ("", "")
+
+    def test_enterprise_token_only_for_ghes(self):
+        """Test that GH_ENTERPRISE_TOKEN is used for GHES hosts only."""
+        environ = {"GH_ENTERPRISE_TOKEN": "ghes", "GITHUB_TOKEN": "dotcom"}
+        assert resolve_token("source", "--source-pat", "", environ, "github.acme.com")[0] == "ghes"
+        assert resolve_token("source", "--source-pat", "", environ, "github.com")[0] == "dotcom"
+
+    def test_gh_token_for_non_enterprise_hosts(self):
+        """Test that GH_TOKEN comes before GITHUB_TOKEN, like in gh, except on GHES hosts."""
+        environ = {"GH_TOKEN": "gh", "GITHUB_TOKEN": "shared"}
+        assert resolve_token("source", "--source-pat", "", environ) == ("gh", "GH_TOKEN")
+        assert resolve_token("source", "--source-pat", "", environ, "acme.ghe.com")[0] == "gh"
+        assert resolve_token("source", "--source-pat", "", environ, "ghes.acme.com")[0] == "shared"
+
+    def test_stored_token_last(self):
+        """Test that gh's stored token of the side's host is only asked for as a last resort."""
+        asked = []
+
+        def stored(host):
+            asked.append(host)
+            return "gho_stored"
+
+        environ = {"GITHUB_TOKEN": "x"}
+        assert resolve_token("source", "--pat", "", environ, stored_token=stored)[0] == "x"
+        assert asked == []
+        assert resolve_token("target", "--pat", "", {}, "ghes.acme.com", stored) == (
+            "gho_stored", "gh auth login (ghes.acme.com)"
+        )
+        assert asked == ["ghes.acme.com"]
+
+    @pytest.mark.parametrize("host, expected", [
+        ("github.com", False),
+        ("api.github.com", False),
+        ("acme.ghe.com", False),
+        ("github.acme.internal", True),
+    ])
+    def test_is_enterprise_host(self, host, expected):
+        """Test GHES host detection."""
+        assert is_enterprise_host(host) is expected
+
+
+class Completed:
+    """Stand-in for subprocess.CompletedProcess."""
+
+    def __init__(self, stdout="", returncode=0):
+        self.stdout = stdout
+        self.returncode = returncode
+
+
+class TestGhStoredToken:
+    """Test cases for reading the tokens of `gh auth login`."""
+
+    def test_hosts_file(self, tmp_path):
+        """Test that plain-text tokens are read from hosts.yml in GH_CONFIG_DIR."""
+        (tmp_path / "hosts.yml").write_text(
+            "github.com:\n    user: octocat\n    oauth_token: gho_dotcom\n"
+            "ghes.acme.com:\n    user: octocat\n    git_protocol: https\n"
+        )
+        environ = {"GH_CONFIG_DIR": str(tmp_path)}
+        assert gh_stored_token("github.com", environ, gh="") == "gho_dotcom"
+        assert gh_stored_token("ghes.acme.com", environ, gh="") == ""
+
+    def test_keyring_through_gh(self, tmp_path):
+        """Test that tokens kept in the keyring are asked from `gh auth token`."""
+        calls = []
+
+        def run(argv, **kwargs):
+            calls.append(argv)
+            return Completed("gho_keyring\n")
+
+        environ = {"GH_CONFIG_DIR": str(tmp_path)}
+        assert gh_stored_token("ghes.acme.com", environ, run, gh="gh") == "gho_keyring"
+        assert calls == [["gh", "auth", "token", "--hostname", "ghes.acme.com"]]
+        def failing(argv, **kwargs):
+            return Completed("", 1)
+
+        assert gh_stored_token("github.com", environ, failing, "gh") == ""
+
+    def test_default_host(self):
+        """Test that GH_HOST selects the host of sides that do not name one."""
+        assert default_host({}) == "github.com"
+        assert default_host({"GH_HOST": "ghes.acme.com"}) == "ghes.acme.com"
@@ -0,0 +1,43 @@
+"""Tests for cron schedules."""
+from datetime import datetime
+import pytest
+from src.utils.cron import CronSchedule
+
+MOMENT = datetime(2026, 5, 6, 10, 7, 30)  # a Wednesday
+
+
+class TestCronSchedule:
+    """Test cases for parsing cron expressions and finding the next run."""
+
+    def test_every_fifteen_minutes(self):
+        """Test steps over the whole range, starting strictly after the moment."""
+        schedule = CronSchedule("*/15 * * * *")
+        assert schedule.next_after(MOMENT) == datetime(2026, 5, 6, 10, 15)
+        assert schedule.next_after(datetime(2026, 5, 6, 10, 15)) == datetime(2026, 5, 6, 10, 30)
+
+    def test_weekdays_by_name(self):
+        """Test day names and ranges, rolling over to the next matching day."""
+        schedule = CronSchedule("30 2 * * mon-fri")
+        assert schedule.next_after(MOMENT) == datetime(2026, 5, 7, 2, 30)
+        assert schedule.next_after(datetime(2026, 5, 8, 3, 0)) == datetime(2026, 5, 11, 2, 30)
+
+    def test_day_fields_combine_with_or(self):
+        """Test that a restricted day of month and day of week each schedule a day."""
+        schedule = CronSchedule("0 0 1 * sun")
+        assert schedule.next_after(MOMENT) == datetime(2026, 5, 10, 0, 0)
+        assert schedule.next_after(datetime(2026, 5, 31, 1, 0)) == datetime(2026, 6, 1, 0, 0)
+
+    def test_lists_months_and_macros(self):
+        """Test lists, month names, Sunday as 7 and the @ macros."""
+        assert CronSchedule("0 9,17 * * *").next_after(MOMENT) == datetime(2026, 5, 6, 17, 0)
+        assert CronSchedule("0 0 1 jan,jul *").next_after(MOMENT) == datetime(2026, 7, 1, 0, 0)
+        assert CronSchedule("0 0 * * 7").next_after(MOMENT) == datetime(2026, 5, 10, 0, 0)
+        assert CronSchedule("@daily").next_after(MOMENT) == datetime(2026, 5, 7, 0, 0)
+
+    @pytest.mark.parametrize("expression", [
+        "* * * *", "60 * * * *", "*/0 * * * *", "0 0 * * someday", "5-1 * * * *", "0 0 30 2 *",
+    ])
+    def test_invalid_expressions(self, expression):
+        """Test that malformed, out-of-range and never-matching expressions are rejected."""
+        with pytest.raises(ValueError):
+            CronSchedule(expression)
@@ -0,0 +1,121 @@
+"""Tests for destination plugins."""
+import json
+import subprocess  # nosec B404 - fake results for the plugin runner
+import pytest
+from src.core.destinations import (
+    DestinationPlugin,
+    PluginRunner,
+    check_destination_names,
+    check_response,
+    destination_items,
+    load_destination_plugin,
+    parse_destination_plugin,
+)
+
+
+class TestParseDestinationPlugin:
+    """Test cases for plugin definitions."""
+
+    def test_parse_definition(self):
+        """Test parsing a complete definition; a string command is split like a shell."""
+        plugin = parse_destination_plugin({
+            "name": "doppler",
+            "command": "doppler-plugin --project 'a b'",
+            "config": {"project": "payments"},
+            "credentials": {"DOPPLER_TOKEN": "MIGRATION_TOKEN", "DP_TOKEN": "MIGRATION_TOKEN"},
+        })
+        assert plugin.command == ["doppler-plugin", "--project", "a b"]
+        assert plugin.credential_secrets == ["MIGRATION_TOKEN"]
+        assert plugin.request("put", []) == {
+            "protocol": 1, "operation": "put", "destination": {"project": "payments"}, "secrets": []
+        }
+
+    @pytest.mark.parametrize("data, message", [
+        (["a"], "must be a mapping"),
+        ({"name": "Doppler", "command": ["x"]}, "needs a 'name'"),
+        ({"name": "doppler"}, "needs a 'command'"),
+        ({"name": "doppler", "command": ["x", ""]}, "needs a 'command'"),
+        ({"name": "doppler", "command": ["x"], "config": "a"}, "'config' must be a mapping"),
+        ({"name": "doppler", "command": ["x"], "credentials": {"PATH": "A"}}, "reserved"),
+        ({"name": "doppler", "command": ["x"], "credentials": {"plugin_x": "A"}}, "reserved"),
+        ({"name": "doppler", "command": ["x"], "credentials": {"T": "A-B"}}, "invalid secret"),
+        ({"name": "doppler", "command": ["x"], "env": {}}, "unknown key"),
+    ])
+    def test_malformed_definitions(self, data, message):
+        """Test that malformed definitions are rejected."""
+        with pytest.raises(ValueError, match=message):
+            parse_destination_plugin(data)
+
+    def test_load_file(self, tmp_path):
+        """Test loading a definition from YAML."""
+        path = tmp_path / "plugin.yml"
+        path.write_text("name: vault\ncommand: [vault-plugin]\n")
+        assert load_destination_plugin(str(path)).name == "vault"
+
+    def test_names_unique(self):
+        """Test that two plugins of a run cannot share a name."""
+        plugin = DestinationPlugin("vault", ["x"])
+        with pytest.raises(ValueError, match="vault"):
+            check_destination_names([plugin, DestinationPlugin("vault", ["y"])])
+
+
+class TestDestinationItems:
+    """Test cases for what plugins receive."""
+
+    def test_items(self):
+        """Test that items carry target names and environments, numbered in order."""
+        items = destination_items(
+            ["DB"], {"prod": ["API"]}, ["ORG"], {"DB": "DATABASE"}, {"prod": "production"}
+        )
+        assert items == [
+            {"id": 1, "source_name": "DB", "name": "DATABASE", "level": "repository",
+             "environment": ""},
+            {"id": 2, "source_name": "API", "name": "API", "level": "environment",
+             "environment": "production"},
+            {"id": 3, "source_name": "ORG", "name": "ORG", "level": "organization",
+             "environment": ""},
+        ]
+
+
+class TestPluginRunner:
+    """Test cases for checking plugins locally."""
+
+    def _runner(self, monkeypatch, stdout, returncode=0, stderr=b""):
+        monkeypatch.setattr("shutil.which", lambda name: f"/usr/bin/{name}")
+        calls = []
+
+        def run(argv, **kwargs):
+            calls.append((argv, json.loads(kwargs["input"])))
+            return subprocess.CompletedProcess(argv, returncode, stdout, stderr)
+
+        return PluginRunner(DestinationPlugin("vault", ["vault-plugin", "-q"]), run), calls
+
+    def test_describe(self, monkeypatch):
+        """Test a plugin answering a describe request."""
+        runner, calls = self._runner(monkeypatch, b'{"protocol": 1, "operations": ["put"]}')
+        assert runner.describe()["operations"] == ["put"]
+        assert calls == [(["/usr/bin/vault-plugin", "-q"],
+                          {"protocol": 1, "operation": "describe", "destination": {}})]
+
+    @pytest.mark.parametrize("stdout, returncode, message", [
+        (b"", 2, "failed: bad token"),
+        (b"not json", 0, "did not answer with JSON"),
+        (b'{"protocol": 2, "results": []}', 0, "protocol 1"),
+        (b'{"protocol": 1}', 0, "no 'results' list"),
+    ])
+    def test_probe_failures(self, monkeypatch, stdout, returncode, message):
+        """Test that failing or off-protocol plugins are reported."""
+        runner, _ = self._runner(monkeypatch, stdout, returncode, b"warming up\nbad token\n")
+        with pytest.raises(ValueError, match=message):
+            runner.probe()
+
+    def test_missing_executable(self, monkeypatch):
+        """Test that a plugin that is not installed is reported."""
+        monkeypatch.setattr("shutil.which", lambda name: None)
+        with pytest.raises(ValueError, match="not found on PATH"):
+            PluginRunner(DestinationPlugin("vault", ["vault-plugin"]))
+
+    def test_describe_needs_put(self):
+        """Test that a plugin must support put."""
+        with pytest.raises(ValueError, match="'put'"):
+            check_response({"protocol": 1, "operations": ["get"]}, "describe")
@@ -104,12 +104,11 @@ class TestWorkflowGeneratorEdgeCases:
         )
         assert "migrate-secrets-v1.2.3" in workflow
 
-    def test_workflow_has_required_permissions(self):
-        """Test that generated workflow includes required permissions."""
+    def test_workflow_has_no_token_permissions(self):
+        """Test that generated workflow grants GITHUB_TOKEN no permissions."""
         workflow = generate_workflow("org", "repo", "target", "target", "branch")
-        assert "permissions:" in workflow
-        assert "contents: write" in workflow
-        assert "repository-projects: write" in workflow
+        assert "permissions: {}" in workflow
+        assert "contents: write" not in workflow
 
     def test_workflow_has_error_handling(self):
         """Test that workflow includes error handling."""
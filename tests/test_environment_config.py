@@ -0,0 +1,196 @@
+"""Tests for environment definitions as code."""
+import pytest
+import yaml
+from src.core.environment_config import (
+    EnvironmentSpec,
+    approval_gate_problems,
+    branch_policy_payload,
+    deployment_branches_from_api,
+    dump_environment_config,
+    format_reviewer,
+    load_environment_config,
+    parse_environment_config,
+    protection_from_api,
+    spec_from_api,
+    split_branch_pattern,
+)
+
+
+def _api_environment():
+    return {
+        "name": "production",
+        "protection_rules": [
+            {"type": "wait_timer", "wait_timer": 30},
+            {
+                "type": "required_reviewers",
+                "prevent_self_review": True,
+                "reviewers": [
+                    {"type": "User", "reviewer": {"login": "octocat", "id": 1}},
+                    {"type": "Team", "reviewer": {"slug": "release-managers", "id": 7}},
+                ],
+            },
+            {"type": "branch_policy"},
+        ],
+        "deployment_branch_policy": {"protected_branches": False, "custom_branch_policies": True},
+    }
+
+
+class TestSpecFromApi:
+    """Test cases for reading the REST representation of environments."""
+
+    def test_protection_rules(self):
+        """Test that timers, reviewers and branch/tag policies are captured."""
+        spec = spec_from_api(
+            _api_environment(),
+            [{"name": "main", "type": "branch"}, {"name": "v*", "type": "tag"}],
+            ["DEPLOY_KEY"],
+            {"REGION": "eu-west-1"},
+        )
+        assert spec.wait_timer == 30
+        assert spec.prevent_self_review is True
+        assert spec.reviewers == [{"user": "octocat"}, {"team": "release-managers"}]
+        assert spec.deployment_branches == ["main", "tag:v*"]
+        assert spec.secrets == ["DEPLOY_KEY"]
+        assert spec.variables == {"REGION": "eu-west-1"}
+
+    def test_unprotected_environment(self):
+        """Test that an environment without rules exports only its name."""
+        spec = spec_from_api({"name": "dev", "protection_rules": []}, [], [], {})
+        assert spec.to_dict() == {"name": "dev"}
+
+    def test_protected_branches(self):
+        """Test the protected-branches deployment policy."""
+        environment = {
+            "name": "staging",
+            "deployment_branch_policy": {"protected_branches": True, "custom_branch_policies": False},
+        }
+        assert spec_from_api(environment, [], [], {}).deployment_branches == "protected"
+
+
+class TestParseEnvironmentConfig:
+    """Test cases for environment config validation."""
+
+    def test_round_trip(self, tmp_path):
+        """Test that an exported document loads back to the same specs."""
+        spec = spec_from_api(_api_environment(), [{"name": "main"}], ["B", "A"], {"X": "1"})
+        path = tmp_path / "environments.yml"
+        path.write_text(dump_environment_config([spec], "org/repo"))
+        loaded = load_environment_config(str(path))
+        assert [item.to_dict() for item in loaded] == [spec.to_dict()]
+        assert loaded[0].secrets == ["A", "B"]
+
+    def test_dump_never_contains_values_header(self):
+        """Test that the exported document explains secret values are absent."""
+        document = dump_environment_config([EnvironmentSpec("dev", secrets=["TOKEN"])])
+        assert "Secret values are never exported" in document
+        assert yaml.safe_load(document) == {"environments": [{"name": "dev", "secrets": ["TOKEN"]}]}
+
+    @pytest.mark.parametrize("environment, message", [
+        ({"name": "prod", "wait_timer": -1}, "wait_timer"),
+        ({"name": "prod", "reviewers": [{"group": "x"}]}, "reviewer"),
+        ({"name": "prod", "deployment_branches": "some"}, "deployment_branches"),
+        ({"name": "prod", "deployment_branches": ["tag:"]}, "deployment_branches"),
+        ({"name": "prod", "secrets": ["GITHUB_TOKEN"]}, "invalid secret name"),
+        ({"name": "prod", "colour": "red"}, "unknown key"),
+        ({"wait_timer": 5}, "name"),
+    ])
+    def test_invalid_environments(self, environment, message):
+        """Test that malformed environments are rejected with a clear message."""
+        with pytest.raises(ValueError, match=message):
+            parse_environment_config({"environments": [environment]})
+
+    def test_duplicate_environments(self):
+        """Test that environment names must be unique (case-insensitively)."""
+        with pytest.raises(ValueError, match="Duplicate"):
+            parse_environment_config({"environments": [{"name": "Prod"}, {"name": "prod"}]})
+
+    def test_missing_environments_list(self):
+        """Test that the top-level environments list is required."""
+        with pytest.raises(ValueError, match="environments"):
+            parse_environment_config({"env": []})
+
+
+class TestProtectionPayload:
+    """Test cases for the environment update request body."""
+
+    def test_custom_branch_policies(self):
+        """Test the payload for an environment limited to branch patterns."""
+        spec = EnvironmentSpec("prod", wait_timer=5, deployment_branches=["main"])
+        payload = spec.protection_payload([{"type": "User", "id": 1}])
+        assert payload["wait_timer"] == 5
+        assert payload["reviewers"] == [{"type": "User", "id": 1}]
+        assert payload["deployment_branch_policy"] == {
+            "protected_branches": False, "custom_branch_policies": True
+        }
+
+    def test_all_branches(self):
+        """Test that 'all' clears the deployment branch policy and reviewers."""
+        payload = EnvironmentSpec("dev").protection_payload([])
+        assert payload["deployment_branch_policy"] is None
+        assert payload["reviewers"] is None
+
+    def test_split_branch_pattern(self):
+        """Test the tag: prefix for tag patterns."""
+        assert split_branch_pattern("tag:v*") == ("v*", "tag")
+        assert split_branch_pattern("release/*") == ("release/*", "branch")
+
+    def test_format_reviewer(self):
+        """Test the reviewer rendering used in logs and reports."""
+        assert format_reviewer({"team": "sre"}) == "team sre"
+
+
+class TestDeploymentBranches:
+    """Test cases for copying deployment branch policies between environments."""
+
+    def test_from_api(self):
+        """Test the three kinds of deployment branch policy."""
+        custom = {"protected_branches": False, "custom_branch_policies": True}
+        assert deployment_branches_from_api(None, []) == "all"
+        assert deployment_branches_from_api({"protected_branches": True}, []) == "protected"
+        assert deployment_branches_from_api(
+            custom, [{"name": "release/*", "type": "branch"}, {"name": "v*", "type": "tag"}]
+        ) == ["release/*", "tag:v*"]
+
+    def test_payload(self):
+        """Test that each policy maps back to the field the API expects."""
+        assert branch_policy_payload("all") is None
+        assert branch_policy_payload("protected") == {
+            "protected_branches": True, "custom_branch_policies": False
+        }
+        assert branch_policy_payload(["main"]) == {
+            "protected_branches": False, "custom_branch_policies": True
+        }
+
+    def test_other_rules_are_kept(self):
+        """Test that the wait timer and reviewers of the target environment are re-sent."""
+        assert protection_from_api(_api_environment()) == {
+            "wait_timer": 30,
+            "prevent_self_review": True,
+            "reviewers": [{"type": "User", "id": 1}, {"type": "Team", "id": 7}],
+        }
+        assert protection_from_api({"name": "dev"})["reviewers"] is None
+
+
+class TestApprovalGate:
+    """Test cases for approval_gate_problems."""
+
+    def test_gating_environment(self):
+        """Test that an environment with reviewers accepting the branch can gate the run."""
+        spec = EnvironmentSpec("approval", reviewers=[{"user": "octocat"}])
+        assert approval_gate_problems(spec, "migrate-secrets") == []
+        spec.deployment_branches = ["migrate-*", "tag:v*"]
+        assert approval_gate_problems(spec, "migrate-secrets") == []
+
+    def test_problems(self):
+        """Test missing reviewers and branches the environment does not accept."""
+        assert approval_gate_problems(EnvironmentSpec("approval"), "migrate-secrets") == [
+            "environment 'approval' has no required reviewers"
+        ]
+        spec = EnvironmentSpec("approval", reviewers=[{"team": "security"}],
+                               deployment_branches="protected")
+        assert "only accepts protected branches" in approval_gate_problems(spec, "m")[0]
+        assert approval_gate_problems(spec, "main", branch_protected=True) == []
+        spec.deployment_branches = ["main", "tag:migrate-secrets"]
+        assert "does not allow branch 'migrate-secrets'" in (
+            approval_gate_problems(spec, "migrate-secrets")[0]
+        )
@@ -0,0 +1,105 @@
+"""Tests for typed GitHub API errors."""
+from src.clients.errors import (
+    ActionsDisabled, AuthError, EmptyRepository, GitHubAPIError, NotFound, PermissionDenied,
+    RateLimited, RepositoryArchived, SecretTooLarge, api_error
+)
+
+
+class GithubException(Exception):
+    """Stand-in for PyGithub's GithubException."""
+
+    def __init__(self, status, message="", headers=None):
+        super().__init__(status, message)
+        self.status = status
+        self.data = {"message": message}
+        self.headers = headers
+
+
+class ReadTimeout(Exception):
+    """Stand-in for a requests timeout."""
+
+
+class TestApiError:
+    """Test cases for api_error."""
+
+    def test_not_found(self):
+        """Test that a 404 becomes NotFound with a readable message."""
+        action = "Failed to get commit SHA for o/r/main"
+        error = api_error(GithubException(404, "Not Found"), action)
+        assert isinstance(error, NotFound)
+        assert isinstance(error, RuntimeError)
+        assert str(error) == "Failed to get commit SHA for o/r/main: HTTP 404: Not Found"
+        assert error.status == 404
+        assert "private" in error.remediation
+
+    def test_auth_and_permission(self):
+        """Test 401 and 403 responses."""
+        assert isinstance(api_error(GithubException(401, "Bad credentials"), "x"), AuthError)
+        denied = api_error(GithubException(403, "Resource not accessible by integration"), "x")
+        assert isinstance(denied, PermissionDenied)
+        assert "workflow" in denied.remediation
+
+    def test_saml_enforcement(self):
+        """Test that SSO enforcement gets its own remediation."""
+        message = "Resource protected by organization SAML enforcement"
+        error = api_error(GithubException(403, message), "x")
+        assert isinstance(error, PermissionDenied)
+        assert "single sign-on" in error.remediation
+
+    def test_rate_limited(self):
+        """Test primary and secondary rate limits, with the reset time."""
+        headers = {"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "0"}
+        primary = api_error(GithubException(403, "API rate limit exceeded", headers), "x")
+        assert isinstance(primary, RateLimited)
+        assert primary.remediation.endswith("It resets at 00:00:00 UTC.")
+        secondary = api_error(GithubException(429, "Too many requests", {"Retry-After": "60"}), "x")
+        assert isinstance(secondary, RateLimited)
+        assert secondary.remediation.endswith("It resets in 60s.")
+
+    def test_actions_disabled(self):
+        """Test that a disabled-Actions response is recognised whatever its status."""
+        error = api_error(GithubException(409, "Actions has been disabled for this repository."), "x")
+        assert isinstance(error, ActionsDisabled)
+
+    def test_repository_archived(self):
+        """Test that a write to an archived repository becomes RepositoryArchived."""
+        error = api_error(GithubException(403, "Repository was archived so is read-only."), "x")
+        assert isinstance(error, RepositoryArchived)
+        assert isinstance(error, GitHubAPIError)
+        assert "--unarchive" in error.remediation
+
+    def test_empty_repository(self):
+        """Test that a repository without commits becomes EmptyRepository."""
+        error = api_error(GithubException(409, "Git Repository is empty."), "x")
+        assert isinstance(error, EmptyRepository)
+        assert error.status == 409
+        assert type(api_error(GithubException(409, "Conflict"), "x")) is GitHubAPIError
+
+    def test_secret_too_large(self):
+        """Test that an oversized secret value becomes SecretTooLarge."""
+        error = api_error(GithubException(422, "Secret value is too large"), "x")
+        assert isinstance(error, SecretTooLarge)
+        assert "48 KB" in error.remediation
+
+    def test_other_status(self):
+        """Test that other responses keep the base class without a hint."""
+        error = api_error(GithubException(500, "Server Error"), "Failed to open issue")
+        assert type(error) is GitHubAPIError
+        assert str(error) == "Failed to open issue: HTTP 500: Server Error"
+        assert error.remediation == ""
+
+    def test_non_api_errors(self):
+        """Test errors without a response, including timeouts."""
+        error = api_error(ValueError("bad input"), "Failed to create file")
+        assert str(error) == "Failed to create file: bad input"
+        assert error.status is None
+        assert "--api-timeout" in api_error(ReadTimeout("timed out"), "x").remediation
+
+    def test_typed_error_keeps_class(self):
+        """Test that wrapping a typed error again keeps its class and remediation."""
+        inner = api_error(GithubException(404, "Not Found"), "Failed to list secrets in o/r")
+        inner.remediation = "custom"
+        outer = api_error(inner, "Failed to migrate organization secrets")
+        assert isinstance(outer, NotFound)
+        assert outer.remediation == "custom"
+        assert str(outer).startswith("Failed to migrate organization secrets: Failed to list")
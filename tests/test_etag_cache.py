@@ -0,0 +1,50 @@
+"""Tests for the ETag cache used by conditional metadata reads."""
+import json
+import os
+from src.utils.etag_cache import ETagCache, credential_scope
+
+
+class TestETagCache:
+    """Test cases for ETagCache."""
+
+    def test_put_and_get(self):
+        """Test that bodies are stored with their ETag."""
+        cache = ETagCache()
+        cache.put("k", '"abc"', {"secrets": []})
+        assert cache.get("k") == ('"abc"', {"secrets": []})
+        assert cache.get("other") is None
+
+    def test_responses_without_etag_not_stored(self):
+        """Test that a response without an ETag cannot be revalidated and is skipped."""
+        cache = ETagCache()
+        cache.put("k", "", {"secrets": []})
+        assert cache.get("k") is None
+
+    def test_persistence(self, tmp_path):
+        """Test that a saved cache is loaded by the next run with owner-only permissions."""
+        path = str(tmp_path / "cache.json")
+        cache = ETagCache(path)
+        cache.put("k", '"abc"', {"total_count": 1})
+        cache.save()
+        assert os.stat(path).st_mode & 0o777 == 0o600
+        assert ETagCache(path).get("k") == ('"abc"', {"total_count": 1})
+
+    def test_unreadable_or_foreign_file_ignored(self, tmp_path):
+        """Test that a corrupt or other-version file starts an empty cache."""
+        path = tmp_path / "cache.json"
+        path.write_text("not json")
+        assert ETagCache(str(path)).entries == {}
+        path.write_text(json.dumps({"version": 99, "entries": {"k": ["e", 1]}}))
+        assert ETagCache(str(path)).entries == {}
+
+    def test_describe_counts(self):
+        """Test the hit-rate summary."""
+        cache = ETagCache()
+        cache.record(revalidated=True)
+        cache.record(revalidated=False)
+        assert cache.describe() == "1 of 2 metadata read(s) answered 304 Not Modified"
+
+    def test_credential_scope(self):
+        """Test that scopes differ per token and do not contain it."""
+        assert credential_scope("ghp_a") != credential_scope("ghp_b")
+        assert "ghp_a" not in credential_scope("ghp_a")
@@ -0,0 +1,114 @@
+"""Tests for the migration event stream and transcript rendering."""
+import io
+import json
+from src.core.events import REDACTED, EventLog, NdjsonStream
+from src.core.transcript import render_transcript, write_transcript
+
+
+class TestEventLog:
+    """Test cases for EventLog."""
+
+    def test_emit_records_events_in_order(self):
+        """Test that events are recorded in emission order."""
+        events = EventLog()
+        events.emit("run_started", "Started")
+        events.emit("decision", "Migrating 2 secrets", secrets=["A", "B"])
+        assert [e.kind for e in events.events] == ["run_started", "decision"]
+        assert events.events[1].data == {"secrets": ["A", "B"]}
+
+    def test_redaction_of_messages_and_data(self):
+        """Test that registered tokens never reach stored events."""
+        events = EventLog(["ghp_supersecret"])
+        events.emit("error", "Auth failed for ghp_supersecret", detail={"token": "ghp_supersecret"})
+        event = events.events[0]
+        assert "ghp_supersecret" not in event.message
+        assert REDACTED in event.message
+        assert event.data["detail"]["token"] == REDACTED
+
+    def test_empty_redaction_values_ignored(self):
+        """Test that empty tokens do not blank out messages."""
+        events = EventLog(["", ""])
+        events.emit("decision", "Nothing to hide")
+        assert events.events[0].message == "Nothing to hide"
+
+    def test_summary_counts_by_kind(self):
+        """Test event summary counts."""
+        events = EventLog()
+        events.emit("skipped", "one")
+        events.emit("skipped", "two")
+        events.emit("decision", "three")
+        assert events.summary() == {"skipped": 2, "decision": 1}
+
+    def test_write_json_report(self, tmp_path):
+        """Test that the JSON report is written from the event stream."""
+        events = EventLog(["token-value"])
+        events.emit("link", "Run", url="https://example.com/run/1")
+        path = tmp_path / "report.json"
+        events.write_json_report(str(path))
+        report = json.loads(path.read_text())
+        assert report["summary"] == {"link": 1}
+        assert report["events"][0]["data"]["url"] == "https://example.com/run/1"
+
+
+
+class TestNdjsonStream:
+    """Test cases for the live NDJSON event stream."""
+
+    def test_streams_each_event_as_one_line(self):
+        """Test that subscribed events are written as they are emitted, redacted."""
+        events = EventLog(["ghp_supersecret"])
+        events.emit("run_started", "Before subscribing")
+        handle = io.StringIO()
+        events.subscribe(NdjsonStream(handle))
+        events.emit("secret_discovered", "Found repository secret 'API_KEY'", secret="API_KEY")
+        assert handle.getvalue().count("\n") == 1
+        events.emit("error", "Auth failed for ghp_supersecret")
+        lines = [json.loads(line) for line in handle.getvalue().splitlines()]
+        assert [line["kind"] for line in lines] == ["secret_discovered", "error"]
+        assert lines[0]["data"] == {"secret": "API_KEY"}
+        assert "ghp_supersecret" not in lines[1]["message"]
+
+
+class TestTranscript:
+    """Test cases for transcript rendering."""
+
+    def _sample_events(self):
+        events = EventLog(["ghp_token"])
+        events.emit("run_started", "Repository-to-repository migration: a/b → c/d")
+        events.emit("decision", "Migrating 2 repository secret(s)")
+        events.emit("conflict", "Environment 'prod' already existed on target; reused it")
+        events.emit("skipped", "Repository secret 'SECRETS_MIGRATOR_PAT' skipped")
+        events.emit("link", "Secrets migration workflow run", url="https://github.com/a/b/actions/runs/1")
+        events.emit("warning", "Token ghp_token rejected")
+        events.emit("run_completed", "Migration run completed")
+        return events
+
+    def test_transcript_sections(self):
+        """Test that the transcript groups events into sections."""
+        transcript = render_transcript(self._sample_events())
+        assert transcript.startswith("# Secrets migration transcript")
+        assert "## Decisions" in transcript
+        assert "## Conflicts resolved" in transcript
+        assert "## Skipped" in transcript
+        assert "## Links" in transcript
+        assert "https://github.com/a/b/actions/runs/1" in transcript
+        assert "(completed)" in transcript
+
+    def test_transcript_is_redacted(self):
+        """Test that registered tokens never appear in the transcript."""
+        transcript = render_transcript(self._sample_events())
+        assert "ghp_token" not in transcript
+
+    def test_transcript_omits_empty_sections(self):
+        """Test that sections without events are not rendered."""
+        events = EventLog()
+        events.emit("run_started", "Started")
+        transcript = render_transcript(events)
+        assert "## Conflicts resolved" not in transcript
+        assert "## Timeline" in transcript
+
+    def test_write_transcript(self, tmp_path):
+        """Test writing the transcript to a file."""
+        path = tmp_path / "out.md"
+        write_transcript(self._sample_events(), str(path))
+        assert "## Decisions" in path.read_text()
@@ -0,0 +1,29 @@
+"""Tests for CLI exit codes."""
+import pytest
+from src.clients.errors import AuthError, NotFound, PermissionDenied
+from src.core.exit_codes import (
+    EXIT_AUTH, EXIT_FAILED, EXIT_PARTIAL, EXIT_VERIFICATION, PartialMigration,
+    VerificationMismatch, exit_code,
+)
+
+
+class TestExitCode:
+    """Test cases for mapping failures to exit statuses."""
+
+    @pytest.mark.parametrize("error, expected", [
+        (AuthError("bad credentials", 401), EXIT_AUTH),
+        (PermissionDenied("resource not accessible", 403), EXIT_AUTH),
+        (PartialMigration("1 secret(s) failed"), EXIT_PARTIAL),
+        (VerificationMismatch("0 secret(s) failed and 2 were not confirmed"), EXIT_VERIFICATION),
+        (NotFound("repository not found", 404), EXIT_FAILED),
+        (RuntimeError("conflict policy 'fail'"), EXIT_FAILED),
+    ])
+    def test_first_run(self, error, expected):
+        """Test the status of a failure before anything was migrated."""
+        assert exit_code(error) == expected
+
+    def test_after_completed_runs(self):
+        """Test that a generic failure after successful runs is partial; typed ones keep theirs."""
+        assert exit_code(RuntimeError("boom"), completed=2) == EXIT_PARTIAL
+        assert exit_code(AuthError("bad credentials", 401), completed=2) == EXIT_AUTH
+        assert exit_code(VerificationMismatch("drift"), completed=1) == EXIT_VERIFICATION
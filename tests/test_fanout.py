@@ -0,0 +1,29 @@
+"""Tests for fan-out target lists."""
+import pytest
+from src.core.fanout import load_targets_file, parse_targets
+
+
+class TestParseTargets:
+    """Test cases for targets files."""
+
+    def test_names_comments_and_duplicates(self):
+        """Test that comments and blanks are ignored and duplicates dropped in order."""
+        lines = ["# split of the monorepo", "api", "", "acme/web  # frontend", "API", "worker"]
+        assert parse_targets(lines, "acme") == ["api", "web", "worker"]
+
+    def test_other_organization_rejected(self):
+        """Test that every target must be in the target organization."""
+        with pytest.raises(ValueError, match="line 1: 'other/api' is not in target organization"):
+            parse_targets(["other/api"], "acme")
+
+    @pytest.mark.parametrize("entry", ["my repo", "..", "acme/"])
+    def test_invalid_names(self, entry):
+        """Test that malformed repository names are rejected."""
+        with pytest.raises(ValueError, match="invalid repository name"):
+            parse_targets([entry], "acme")
+
+    def test_load_file(self, tmp_path):
+        """Test loading targets from a file."""
+        path = tmp_path / "targets.txt"
+        path.write_text("api\nACME/web\n")
+        assert load_targets_file(str(path), "acme") == ["api", "web"]
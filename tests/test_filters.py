@@ -0,0 +1,51 @@
+"""Tests for secret name filtering."""
+from src.core.filters import (
+    SYSTEM_SECRETS,
+    is_managed_secret,
+    managed_secrets,
+    secrets_to_prune,
+)
+
+
+class TestManagedSecrets:
+    """Test cases for managed secret filtering."""
+
+    def test_system_secrets_not_managed(self):
+        """Test that every system secret is excluded."""
+        for name in SYSTEM_SECRETS:
+            assert is_managed_secret(name) is False
+
+    def test_regular_secret_managed(self):
+        """Test that regular secrets are managed."""
+        assert is_managed_secret("DB_PASSWORD") is True
+
+    def test_managed_secrets_preserves_order(self):
+        """Test filtering keeps input order."""
+        names = ["B", "SECRETS_MIGRATOR_TARGET_PAT", "A", "github_token"]
+        assert managed_secrets(names) == ["B", "A"]
+
+
+class TestSecretsToPrune:
+    """Test cases for prune selection."""
+
+    def test_prune_target_only_secrets(self):
+        """Test that secrets missing on the source are selected."""
+        assert secrets_to_prune(["A", "B"], ["A", "B", "C"]) == ["C"]
+
+    def test_prune_compares_names_case_insensitively(self):
+        """Test that a target secret differing only in case from a source name is kept."""
+        assert secrets_to_prune(["legacy_api_key"], ["LEGACY_API_KEY", "LEGACY_GONE"]) == [
+            "LEGACY_GONE"
+        ]
+
+    def test_prune_nothing_when_in_sync(self):
+        """Test that nothing is pruned when both sides match."""
+        assert secrets_to_prune(["A", "B"], ["B", "A"]) == []
+
+    def test_prune_never_selects_system_secrets(self):
+        """Test that migrator-owned secrets on the target are left alone."""
+        assert secrets_to_prune([], ["SECRETS_MIGRATOR_TARGET_PAT", "OLD"]) == ["OLD"]
+
+    def test_prune_everything_when_source_empty(self):
+        """Test that an empty source prunes all managed target secrets."""
+        assert secrets_to_prune([], ["A", "B"]) == ["A", "B"]
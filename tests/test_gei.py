@@ -0,0 +1,101 @@
+"""Tests for GitHub Enterprise Importer interoperability."""
+import csv
+import io
+import pytest
+from src.core.gei import load_gei_log, parse_gei_log, results_csv, usage_csv
+
+
+def announce(source_repo, target_repo="", stamp="[2026-05-01 10:00:00] [INFO]"):
+    """Return the lines gh gei prints when a repository migration starts."""
+    lines = [
+        f"{stamp} Migrating Repo...",
+        f"{stamp} GITHUB SOURCE ORG: src",
+        f"{stamp} SOURCE REPO: {source_repo}",
+        f"{stamp} GITHUB TARGET ORG: dst",
+    ]
+    if target_repo:
+        lines.append(f"{stamp} TARGET REPO: {target_repo}")
+    return lines
+
+
+class TestParseGeiLog:
+    """Test cases for reading GEI migration logs."""
+
+    def test_sequential_migrations(self):
+        """Test that outcomes without a queued ID go to the last announced migration."""
+        lines = announce("app", "app-new") + [
+            "[2026-05-01 10:01:00] [INFO] Migration completed (ID: RM_1)! State: SUCCEEDED",
+        ] + announce("api") + [
+            "[2026-05-01 10:02:00] [ERROR] Migration Failed. Migration ID: RM_2",
+        ]
+        migrations = parse_gei_log("\n".join(lines))
+        assert [(m.label, m.migration_id, m.succeeded) for m in migrations] == [
+            ("src/app → dst/app-new", "RM_1", True),
+            ("src/api → dst/api", "RM_2", False),
+        ]
+
+    def test_queued_migrations_matched_by_id(self):
+        """Test that a parallel script's outcomes are matched by migration ID."""
+        queued = "[INFO] A repository migration (ID: {}) was successfully queued."
+        lines = announce("app", "app") + [queued.format("RM_1")] + announce("api", "api") + [
+            queued.format("RM_2"),
+            "[2026-05-01 10:05:00] [INFO] Migration RM_2 failed for api",
+            "[2026-05-01 10:06:00] [INFO] Migration completed (ID: RM_1)! State: SUCCEEDED",
+        ]
+        states = {m.source_repo: m.state for m in parse_gei_log("\n".join(lines))}
+        assert states == {"app": "SUCCEEDED", "api": "FAILED"}
+
+    def test_retried_repository_keeps_last_outcome(self, tmp_path):
+        """Test that a repository migrated again is listed once, with its last outcome."""
+        lines = announce("app") + ["Migration Failed. Migration ID: RM_1"]
+        lines += announce("app") + ["Migration completed (ID: RM_2)! State: SUCCEEDED"]
+        path = tmp_path / "migration.octoshift.log"
+        path.write_text("\n".join(lines))
+        (migration,) = load_gei_log(str(path))
+        assert migration.succeeded
+        assert migration.job() == {
+            "name": "src/app", "source_org": "src", "source_repo": "app",
+            "target_org": "dst", "target_repo": "app",
+        }
+
+    def test_not_a_gei_log(self, tmp_path):
+        """Test that a file without migrations is rejected."""
+        path = tmp_path / "other.log"
+        path.write_text("hello\n")
+        with pytest.raises(ValueError, match="no repository migrations"):
+            load_gei_log(str(path))
+
+
+class TestGeiCsv:
+    """Test cases for the org/repo CSVs."""
+
+    def test_usage_csv(self):
+        """Test that a usage report becomes one row per repository."""
+        report = {
+            "organization": "src",
+            "repositories": [{
+                "repo": "app",
+                "secrets": [
+                    {"secret": "A", "level": "repo", "environment": None, "workflows": ["ci.yml"]},
+                    {"secret": "B", "level": "env", "environment": "prod", "workflows": []},
+                ],
+                "unresolved": [{"secret": "C", "workflows": ["ci.yml"]}],
+                "passes_all_secrets": [],
+            }],
+            "organization_secrets": [],
+        }
+        (row,) = csv.DictReader(io.StringIO(usage_csv(report)))
+        assert row == {
+            "org": "src", "repo": "app", "repo_secrets": "1", "environment_secrets": "1",
+            "environments": "1", "unused_secrets": "1", "unresolved_secrets": "1",
+        }
+
+    def test_results_csv(self):
+        """Test the header and quoting of results."""
+        text = results_csv([{
+            "source_org": "src", "source_repo": "app", "target_org": "dst", "target_repo": "app",
+            "job": "src/app", "status": "failed", "error": "HTTP 403: denied, retry",
+        }])
+        header, row = text.splitlines()
+        assert header == "source_org,source_repo,target_org,target_repo,job,status,error"
+        assert row.endswith('failed,"HTTP 403: denied, retry"')
@@ -0,0 +1,147 @@
+"""Tests for the GitHub API client."""
+import base64
+import pytest
+from github import GithubException
+from src.clients.errors import GitHubAPIError
+from src.clients.github import GitHubClient
+from src.utils.logger import Logger
+
+KEY = base64.b64encode(bytes(range(32))).decode("ascii")
+
+
+class FakeRequester:
+    """Requester answering from responses ((verb, path) -> data or exception), recording calls."""
+
+    def __init__(self, responses):
+        self.responses = responses
+        self.requests = []
+
+    def requestJsonAndCheck(self, verb, path, input=None, **kwargs):
+        self.requests.append((verb, path, input))
+        response = self.responses.get((verb, path), {})
+        if isinstance(response, Exception):
+            raise response
+        return {}, response
+
+
+def with_requester(responses):
+    """Build a client whose raw REST calls go to a FakeRequester."""
+    client = GitHubClient("token", Logger(verbose=False))
+    requester = FakeRequester(responses)
+    client.client = type("Github", (), {"requester": requester})()
+    client._log_rate_limit = lambda operation: None
+    return client, requester
+
+
+def make_client(responses):
+    """Build a client whose REST reads are answered from responses (path -> data or exception)."""
+    client = GitHubClient("token", Logger(verbose=False))
+
+    def get_json(path, params=None):
+        response = responses[path]
+        if isinstance(response, Exception):
+            raise response
+        return response
+
+    client._get_json = get_json
+    return client
+
+
+class TestOrgSecrets:
+    """Test cases for organization secret writes."""
+
+    ORG_KEY = {("GET", "/orgs/acme/actions/secrets/public-key"): {"key_id": "org-key", "key": KEY}}
+
+    def test_visibility_all_by_default(self):
+        """Test that a secret is sealed with the organization key and visible to all repos."""
+        pytest.importorskip("nacl.public")
+        client, requester = with_requester(self.ORG_KEY)
+        client.create_org_secret("acme", "NPM_TOKEN", "s3cr3t")
+        verb, path, payload = requester.requests[-1]
+        assert (verb, path) == ("PUT", "/orgs/acme/actions/secrets/NPM_TOKEN")
+        assert payload["key_id"] == "org-key"
+        assert payload["visibility"] == "all"
+        assert "selected_repository_ids" not in payload
+        assert payload["encrypted_value"] and payload["encrypted_value"] != "s3cr3t"
+
+    def test_selected_repositories(self):
+        """Test that a 'selected' secret carries the repository IDs, and only such a secret does."""
+        pytest.importorskip("nacl.public")
+        client, requester = with_requester(self.ORG_KEY)
+        client.create_org_secret("acme", "NPM_TOKEN", "s3cr3t", "selected", [42, 7])
+        client.create_org_secret("acme", "DEPLOY_KEY", "s3cr3t", "selected")
+        client.create_org_secret("acme", "SENTRY_DSN", "s3cr3t", "private", [42])
+        payloads = [payload for verb, _, payload in requester.requests if verb == "PUT"]
+        assert payloads[0]["selected_repository_ids"] == [42, 7]
+        assert payloads[1]["selected_repository_ids"] == []
+        assert payloads[2]["visibility"] == "private"
+        assert "selected_repository_ids" not in payloads[2]
+
+    def test_key_is_fetched_once_and_logged(self):
+        """Test that the organization key is read once per client, logging the rate limit."""
+        client, requester = with_requester(self.ORG_KEY)
+        logged = []
+        client._log_rate_limit = logged.append
+        assert client.get_org_public_key("acme") == ("org-key", KEY)
+        assert client.get_org_public_key("acme") == ("org-key", KEY)
+        assert len(requester.requests) == 1
+        assert logged == ["get_public_key(/orgs/acme/actions/secrets)"]
+
+    def test_unreadable_key(self):
+        """Test that nothing is written when the organization key cannot be read."""
+        forbidden = GithubException(403, {"message": "Resource not accessible by integration"})
+        client, requester = with_requester({
+            ("GET", "/orgs/acme/actions/secrets/public-key"): forbidden
+        })
+        with pytest.raises(GitHubAPIError, match="public key of organization acme"):
+            client.create_org_secret("acme", "NPM_TOKEN", "s3cr3t")
+        assert [verb for verb, _, _ in requester.requests] == ["GET"]
+
+
+class TestEnvironmentSecrets:
+    """Test cases for environment secret writes."""
+
+    def test_sealed_with_environment_key(self):
+        """Test that an environment secret is sealed with its environment's key, not the repo's."""
+        pytest.importorskip("nacl.public")
+        client, requester = with_requester({
+            ("GET", "/repos/acme/app/actions/secrets/public-key"): {
+                "key_id": "repo-key", "key": KEY
+            },
+            ("GET", "/repos/acme/app/environments/prod/secrets/public-key"): {
+                "key_id": "env-key", "key": KEY
+            },
+        })
+        client.create_environment_secret("acme", "app", "prod", "DB_PASSWORD", "s3cr3t")
+        assert [(verb, path) for verb, path, _ in requester.requests] == [
+            ("GET", "/repos/acme/app/environments/prod/secrets/public-key"),
+            ("PUT", "/repos/acme/app/environments/prod/secrets/DB_PASSWORD"),
+        ]
+        assert requester.requests[-1][2]["key_id"] == "env-key"
+
+
+
+class TestEnvironmentListings:
+    """Test cases for listing environment secrets."""
+
+    RESPONSES = {
+        "/repos/acme/app/environments": {"environments": [{"name": "prod"}, {"name": "staging"}]},
+        "/repos/acme/app/environments/prod/secrets": {"secrets": [{"name": "DB"}]},
+        "/repos/acme/app/environments/staging/secrets": RuntimeError("502 Bad Gateway"),
+    }
+
+    def test_lenient_by_default(self):
+        """Test that an environment whose secrets cannot be listed reads as empty."""
+        client = make_client(self.RESPONSES)
+        assert client.list_environment_secrets("acme", "app", "staging") == []
+        assert client.list_all_environments_with_secrets("acme", "app") == {
+            "prod": ["DB"], "staging": []
+        }
+
+    def test_strict_raises(self):
+        """Test that strict listings fail instead of reporting an empty environment."""
+        client = make_client(self.RESPONSES)
+        with pytest.raises(GitHubAPIError, match="environment 'staging'"):
+            client.list_environment_secrets("acme", "app", "staging", strict=True)
+        with pytest.raises(GitHubAPIError, match="environment 'staging'"):
+            client.list_all_environments_with_secrets("acme", "app", strict=True)
@@ -0,0 +1,54 @@
+"""Tests for pre- and post-migration hooks."""
+import sys
+import pytest
+from src.core.hooks import HookRunner, hook_environment
+
+
+class TestHookEnvironment:
+    """Test cases for the variables hooks receive."""
+
+    def test_pre_hook(self):
+        """Test the variables of a repository migration's pre-hook."""
+        assert hook_environment("pre", "src", "app", "dst", ["app", "app-2"], False) == {
+            "GH_SECRETS_MIGRATOR_HOOK": "pre",
+            "GH_SECRETS_MIGRATOR_SOURCE_ORG": "src",
+            "GH_SECRETS_MIGRATOR_SOURCE_REPO": "app",
+            "GH_SECRETS_MIGRATOR_TARGET_ORG": "dst",
+            "GH_SECRETS_MIGRATOR_TARGET_REPOS": "app,app-2",
+            "GH_SECRETS_MIGRATOR_ORG_TO_ORG": "false",
+        }
+
+    def test_post_hook(self):
+        """Test that post-hooks also learn the result; org-to-org runs have no repositories."""
+        variables = hook_environment("post", "src", "runner", "dst", [], True, "failed", "boom")
+        assert variables["GH_SECRETS_MIGRATOR_SOURCE_REPO"] == ""
+        assert variables["GH_SECRETS_MIGRATOR_ORG_TO_ORG"] == "true"
+        assert variables["GH_SECRETS_MIGRATOR_RESULT"] == "failed"
+        assert variables["GH_SECRETS_MIGRATOR_ERROR"] == "boom"
+
+    def test_unknown_result(self):
+        """Test that post-hooks need a known result."""
+        with pytest.raises(ValueError, match="Unknown migration result"):
+            hook_environment("post", "src", "app", "dst", ["app"], False)
+
+
+@pytest.mark.skipif(sys.platform == "win32", reason="POSIX shell commands")
+class TestHookRunner:
+    """Test cases for running hook commands."""
+
+    def test_output_and_variables(self):
+        """Test that a hook runs in a shell, sees its variables and returns its output."""
+        lines = HookRunner()('echo "$GH_SECRETS_MIGRATOR_HOOK"; echo err >&2', {
+            "GH_SECRETS_MIGRATOR_HOOK": "pre"
+        })
+        assert lines == ["pre", "err"]
+
+    def test_failure(self):
+        """Test that a failing hook reports its status and last output line."""
+        with pytest.raises(ValueError, match="exited with status 3: ticket not found"):
+            HookRunner()("echo ticket not found; exit 3", {})
+
+    def test_timeout(self):
+        """Test that a hook taking too long is stopped."""
+        with pytest.raises(ValueError, match="did not finish within 0.2s"):
+            HookRunner(timeout=0.2)("sleep 5", {})
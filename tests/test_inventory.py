@@ -0,0 +1,121 @@
+"""Tests for inventory comparison."""
+from datetime import datetime
+from src.core.inventory import (
+    SecretRecord,
+    VariableRecord,
+    diff_inventories,
+    diff_variables,
+    format_diff,
+    format_variable_diff,
+)
+
+OLD = datetime(2025, 1, 1)
+NEW = datetime(2025, 6, 1)
+
+
+class TestSecretRecord:
+    """Test cases for SecretRecord."""
+
+    def test_key_includes_environment(self):
+        """Test that environment secrets are keyed by environment."""
+        prod = SecretRecord("DB", "env", environment="prod")
+        staging = SecretRecord("DB", "env", environment="staging")
+        assert prod.key != staging.key
+
+    def test_label(self):
+        """Test human-readable labels."""
+        assert SecretRecord("DB", "env", environment="prod").label == "env:prod/DB"
+        assert SecretRecord("DB", "repo").label == "repo:DB"
+
+
+class TestDiffInventories:
+    """Test cases for diff_inventories."""
+
+    def test_missing_and_only_on_target(self):
+        """Test detection of missing and extra secrets."""
+        source = [SecretRecord("A", "repo"), SecretRecord("B", "repo")]
+        target = [SecretRecord("B", "repo"), SecretRecord("C", "repo")]
+        diff = diff_inventories(source, target)
+        assert [r.name for r in diff.missing_on_target] == ["A"]
+        assert [r.name for r in diff.only_on_target] == ["C"]
+        assert [r.name for r in diff.in_sync] == ["B"]
+        assert diff.has_changes
+
+    def test_stale_target(self):
+        """Test that a source updated after the target is reported stale."""
+        source = [SecretRecord("A", "repo", updated_at=NEW)]
+        target = [SecretRecord("A", "repo", updated_at=OLD)]
+        diff = diff_inventories(source, target)
+        assert len(diff.stale_on_target) == 1
+
+    def test_newer_target_is_in_sync(self):
+        """Test that a target updated after the source is in sync."""
+        source = [SecretRecord("A", "repo", updated_at=OLD)]
+        target = [SecretRecord("A", "repo", updated_at=NEW)]
+        diff = diff_inventories(source, target)
+        assert not diff.has_changes
+
+    def test_org_visibility_mismatch(self):
+        """Test that org secret visibility differences are reported."""
+        source = [SecretRecord("A", "org", visibility="selected")]
+        target = [SecretRecord("A", "org", visibility="all")]
+        diff = diff_inventories(source, target)
+        assert len(diff.scope_mismatch) == 1
+
+    def test_levels_are_distinct(self):
+        """Test that a repo secret does not satisfy an env secret of the same name."""
+        source = [SecretRecord("A", "env", environment="prod")]
+        target = [SecretRecord("A", "repo")]
+        diff = diff_inventories(source, target)
+        assert len(diff.missing_on_target) == 1
+        assert len(diff.only_on_target) == 1
+
+
+class TestFormatDiff:
+    """Test cases for format_diff."""
+
+    def test_format_lines(self):
+        """Test rendering of every delta category."""
+        source = [
+            SecretRecord("A", "repo"),
+            SecretRecord("B", "repo", updated_at=NEW),
+        ]
+        target = [
+            SecretRecord("B", "repo", updated_at=OLD),
+            SecretRecord("C", "repo"),
+        ]
+        lines = format_diff(diff_inventories(source, target))
+        assert lines[0].startswith("+ repo:A")
+        assert lines[1].startswith("~ repo:B")
+        assert lines[2].startswith("- repo:C")
+        assert "1 missing, 1 stale" in lines[-1]
+
+
+class TestDiffVariables:
+    """Test cases for value-level variable comparison."""
+
+    def test_value_mismatch(self):
+        """Test that differing values are reported, equal ones are in sync."""
+        source = [VariableRecord("REGION", "repo", "eu"), VariableRecord("TIER", "repo", "gold")]
+        target = [VariableRecord("REGION", "repo", "us"), VariableRecord("TIER", "repo", "gold")]
+        result = diff_variables(source, target)
+        assert [(a.value, b.value) for a, b in result.value_mismatch] == [("eu", "us")]
+        assert [record.name for record in result.in_sync] == ["TIER"]
+        assert result.has_changes
+
+    def test_environment_scoped(self):
+        """Test that environment variables are matched per environment."""
+        source = [VariableRecord("URL", "env", "a", environment="prod")]
+        target = [VariableRecord("URL", "env", "a", environment="staging")]
+        result = diff_variables(source, target)
+        assert len(result.missing_on_target) == 1
+        assert len(result.only_on_target) == 1
+
+    def test_format_truncates_long_values(self):
+        """Test the rendered lines and truncation of long values."""
+        source = [VariableRecord("CONFIG", "org", "x" * 100)]
+        target = [VariableRecord("CONFIG", "org", "y")]
+        lines = format_variable_diff(diff_variables(source, target))
+        assert lines[0].startswith("~ var:org:CONFIG value differs")
+        assert "..." in lines[0]
+        assert lines[-1].startswith("Variables: 0 missing, 1 value mismatch(es)")
@@ -0,0 +1,83 @@
+"""Tests for the ledger of migrated secrets."""
+import os
+from datetime import datetime, timezone
+import pytest
+from src.core.ledger import MigrationLedger, ledger_path, load_ledger, save_ledger
+from src.core.workflow_log import SecretOutcome
+
+API_KEY = ("repository", "dst/app", "API_KEY")
+DB_PASSWORD = ("environment", "dst/app:production", "DB_PASSWORD")
+STAMP = "2024-05-01T09:00:00+00:00"
+AT = datetime(2024, 5, 2, 12, 0, tzinfo=timezone.utc)
+
+
+def settled_ledger():
+    ledger = MigrationLedger("src/app")
+    ledger.track_run(7, {API_KEY: STAMP, DB_PASSWORD: STAMP})
+    ledger.settle(7, [
+        SecretOutcome("repository", "dst/app", "API_KEY", True),
+        SecretOutcome("environment", "dst/app:production", "DB_PASSWORD", False),
+    ], AT)
+    return ledger
+
+
+class TestMigrationLedger:
+    """Test cases for MigrationLedger."""
+
+    def test_settle_records_only_set_secrets(self):
+        """Test that a finished run records what it set and stops being tracked."""
+        ledger = settled_ledger()
+        assert ledger.pending == {}
+        assert ledger.entries == {
+            API_KEY: {"source_updated_at": STAMP, "run_id": 7, "migrated_at": AT.isoformat()}
+        }
+
+    def test_unexpected_outcomes_are_ignored(self):
+        """Test that outcomes of secrets the run was not tracked for are not recorded."""
+        ledger = MigrationLedger("src/app")
+        ledger.track_run(7, {API_KEY: STAMP})
+        assert ledger.settle(7, [SecretOutcome("repository", "dst/other", "API_KEY", True)]) == 0
+        assert ledger.entries == {}
+
+    def test_failure_forgets_earlier_success(self):
+        """Test that a secret a later run failed to set is migrated again."""
+        ledger = settled_ledger()
+        ledger.track_run(8, {API_KEY: STAMP})
+        ledger.settle(8, [SecretOutcome("repository", "dst/app", "API_KEY", False)])
+        assert ledger.entries == {}
+
+    def test_is_migrated_requires_unchanged_source(self):
+        """Test that a rotated source secret, or an unknown update time, is not skipped."""
+        ledger = settled_ledger()
+        assert ledger.is_migrated(API_KEY, STAMP) is True
+        assert ledger.is_migrated(API_KEY, "2024-05-03T08:00:00+00:00") is False
+        assert ledger.is_migrated(API_KEY, "") is False
+        assert ledger.is_migrated(DB_PASSWORD, STAMP) is False
+
+
+class TestLedgerFile:
+    """Test cases for loading and saving ledgers."""
+
+    def test_round_trip(self, tmp_path):
+        """Test that entries and pending runs survive a save and load."""
+        ledger = settled_ledger()
+        ledger.track_run(9, {DB_PASSWORD: STAMP})
+        path = save_ledger(str(tmp_path), ledger)
+        assert path == os.path.join(str(tmp_path), "ledger", "src__app.json")
+        loaded = load_ledger(str(tmp_path), "src/app")
+        assert loaded.to_dict() == ledger.to_dict()
+        assert loaded.pending == {9: {DB_PASSWORD: STAMP}}
+
+    def test_missing_file_is_empty(self, tmp_path):
+        """Test that the first run starts from an empty ledger."""
+        ledger = load_ledger(str(tmp_path), "src/app")
+        assert ledger.entries == {} and ledger.pending == {}
+
+    def test_malformed_file(self, tmp_path):
+        """Test that a file that is not a ledger is rejected."""
+        path = ledger_path(str(tmp_path), "src/app")
+        os.makedirs(os.path.dirname(path))
+        with open(path, "w", encoding="utf-8") as handle:
+            handle.write('{"schema_version": 1, "secrets": [{"name": "API_KEY"}]}')
+        with pytest.raises(ValueError):
+            load_ledger(str(tmp_path), "src/app")
@@ -42,3 +42,73 @@ class TestLogger:
         logger.debug("Debug message")
         # Debug message was logged in verbose mode
         assert logger.verbose is True
+
+
+class TestLoggerVerbosity:
+    """Test cases for quiet mode and verbosity levels."""
+
+    def test_quiet_prints_only_errors_and_summary(self, capsys):
+        """Test that quiet mode suppresses everything but errors and the summary."""
+        logger = Logger(quiet=True)
+        logger.info("info")
+        logger.success("success")
+        logger.warn("warn")
+        logger.debug("debug")
+        logger.error("boom")
+        logger.hint("fix it")
+        logger.summary("done")
+        captured = capsys.readouterr()
+        assert captured.out.strip() == "📋 done"
+        assert captured.err.splitlines() == ["❌ boom", "💡 fix it"]
+
+    def test_single_v_enables_debug_but_not_trace(self, capsys):
+        """Test -v."""
+        logger = Logger(verbose=1)
+        assert logger.verbose is True
+        logger.debug("debug")
+        logger.trace("trace")
+        err = capsys.readouterr().err
+        assert "debug" in err
+        assert "trace" not in err
+
+    def test_double_v_enables_trace(self, capsys):
+        """Test -vv."""
+        logger = Logger(verbose=2)
+        logger.trace("rate limit")
+        assert "rate limit" in capsys.readouterr().err
+
+
+    def test_use_stderr_keeps_stdout_clean(self, capsys):
+        """Test that use_stderr moves stdout messages to stderr."""
+        logger = Logger()
+        logger.use_stderr()
+        logger.info("info")
+        logger.summary("done")
+        captured = capsys.readouterr()
+        assert captured.out == ""
+        assert captured.err.splitlines() == ["ℹ️  info", "📋 done"]
+
+
+class TestLoggerColor:
+    """Test cases for colored output."""
+
+    def test_no_color_when_not_a_tty(self, capsys):
+        """Test that captured (non-TTY) output has no escape codes."""
+        Logger().success("done")
+        assert "\033[" not in capsys.readouterr().out
+
+    def test_forced_color(self, capsys):
+        """Test that color=True styles messages."""
+        Logger(color=True).error("boom")
+        assert capsys.readouterr().err.startswith("\033[31m❌ boom")
+
+    def test_no_color_env_wins_over_tty(self, monkeypatch):
+        """Test that NO_COLOR disables colors on a terminal."""
+        class FakeTTY:
+            def isatty(self):
+                return True
+        logger = Logger()
+        monkeypatch.delenv("NO_COLOR", raising=False)
+        assert logger._use_color(FakeTTY()) is True
+        monkeypatch.setenv("NO_COLOR", "1")
+        assert logger._use_color(FakeTTY()) is False
@@ -0,0 +1,43 @@
+"""Tests for Pushgateway metrics."""
+import pytest
+from src.utils.metrics import build_run_metrics, format_metrics, pushgateway_request
+
+
+class TestRunMetrics:
+    """Test cases for metric building and formatting."""
+
+    def test_build_run_metrics(self):
+        """Test completion metrics values."""
+        metrics = build_run_metrics(3, 1, 12.34567, 1700000000.9)
+        assert metrics["secrets_migrator_repos_migrated"] == 3
+        assert metrics["secrets_migrator_failures"] == 1
+        assert metrics["secrets_migrator_duration_seconds"] == 12.346
+        assert metrics["secrets_migrator_last_completion_timestamp_seconds"] == 1700000000
+
+    def test_format_metrics_exposition(self):
+        """Test Prometheus text exposition output."""
+        text = format_metrics({"secrets_migrator_failures": 2})
+        assert "# HELP secrets_migrator_failures" in text
+        assert "# TYPE secrets_migrator_failures gauge" in text
+        assert "secrets_migrator_failures 2\n" in text
+        assert text.endswith("\n")
+
+
+class TestPushgatewayRequest:
+    """Test cases for Pushgateway endpoint construction."""
+
+    def test_endpoint_for_job(self):
+        """Test endpoint path and method."""
+        endpoint, method = pushgateway_request("http://pgw:9091/", "wave 1", {})
+        assert endpoint == "http://pgw:9091/metrics/job/wave%201"
+        assert method == "PUT"
+
+    def test_grouping_labels(self):
+        """Test that grouping labels are appended to the path."""
+        endpoint, _ = pushgateway_request("https://pgw", "job", {"wave": "3"})
+        assert endpoint == "https://pgw/metrics/job/job/wave/3"
+
+    def test_non_http_scheme_rejected(self):
+        """Test that non-http(s) URLs are rejected."""
+        with pytest.raises(ValueError):
+            pushgateway_request("file:///etc/passwd", "job", {})
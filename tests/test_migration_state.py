@@ -0,0 +1,101 @@
+"""Tests for the migration state file."""
+import json
+import os
+import pytest
+from src.core.migration_state import (
+    MigrationRecord, MigrationStateFile, default_state_path, load_state_file
+)
+from src.core.workflow_log import SecretOutcome
+
+API_KEY = ("repository", "dst/app", "API_KEY")
+DB_PASSWORD = ("environment", "dst/app:production", "DB_PASSWORD")
+TOKEN = ("repository", "dst/app", "TOKEN")
+
+
+def make_record():
+    record = MigrationRecord({"source_org": "src", "source_repo": "app"}, "2024-05-01T10:00:00")
+    record.add_resource("temporary_secret", repo="app", name="SECRETS_MIGRATOR_TARGET_PAT")
+    record.add_resource("placeholder", level="repo", repo="app", environment="", name="API_KEY")
+    record.add_resource("branch", repo="app", name="migrate-secrets")
+    record.plan_secrets(7, [API_KEY, DB_PASSWORD, TOKEN])
+    record.actors = {"source": "alice", "target": "migration-bot"}
+    return record
+
+
+class TestMigrationRecord:
+    """Test cases for MigrationRecord."""
+
+    def test_resources(self):
+        """Test that resources are kept in order, once each."""
+        record = make_record()
+        record.add_resource("branch", repo="app", name="migrate-secrets")
+        assert [resource["kind"] for resource in record.resources] == [
+            "temporary_secret", "placeholder", "branch"
+        ]
+        assert record.resources_of("branch") == [
+            {"kind": "branch", "repo": "app", "name": "migrate-secrets"}
+        ]
+
+    def test_record_outcomes(self):
+        """Test set, failed and unconfirmed outcomes of a finished run."""
+        record = make_record()
+        assert record.record_outcomes(8, []) is False
+        assert record.record_outcomes(7, [
+            SecretOutcome(*API_KEY, True), SecretOutcome(*DB_PASSWORD, False)
+        ]) is True
+        assert record.outcome_labels() == [
+            ("dst/app:production/DB_PASSWORD", "failed"),
+            ("dst/app/API_KEY", "set"),
+            ("dst/app/TOKEN", "unconfirmed"),
+        ]
+
+    def test_finish(self):
+        """Test the final status of a run."""
+        record = make_record()
+        assert record.status == "running"
+        record.finish("boom")
+        assert (record.status, record.error) == ("failed", "boom")
+        assert record.finished_at
+
+
+class TestStateFile:
+    """Test cases for loading and saving state files."""
+
+    def test_default_path(self):
+        """Test the per-source layout, kept apart for organization secret migrations."""
+        assert default_state_path("state", "src", "app", False) == os.path.join(
+            "state", "migrations", "src__app.json"
+        )
+        assert default_state_path("state", "src", "app", True).endswith("src__app.org-secrets.json")
+
+    def test_round_trip(self, tmp_path):
+        """Test that migrations survive a save and load, newest last."""
+        path = str(tmp_path / "migrations" / "src__app.json")
+        earlier = make_record()
+        earlier.finish()
+        state = MigrationStateFile(path, [earlier, make_record()])
+        state.record_outcomes(7, [SecretOutcome(*API_KEY, True)])
+        state.save()
+        loaded = load_state_file(path)
+        assert [migration.to_dict() for migration in loaded.migrations] == [
+            migration.to_dict() for migration in state.migrations
+        ]
+        assert loaded.latest.status == "running"
+        assert loaded.latest.actors == {"source": "alice", "target": "migration-bot"}
+        assert loaded.latest.secrets[API_KEY] == {"outcome": "set", "run_id": 7}
+        # The newest migration that planned the run takes its outcomes
+        assert loaded.migrations[0].secrets[API_KEY]["outcome"] == "pending"
+
+    def test_missing_file(self, tmp_path):
+        """Test that an absent state file is empty."""
+        assert load_state_file(str(tmp_path / "none.json")).latest is None
+
+    def test_malformed_file(self, tmp_path):
+        """Test that other JSON documents are rejected."""
+        path = tmp_path / "state.json"
+        path.write_text(json.dumps({"schema_version": 1, "migrations": [{"status": "x"}]}))
+        with pytest.raises(ValueError):
+            load_state_file(str(path))
+        path.write_text(json.dumps({"events": []}))
+        with pytest.raises(ValueError):
+            load_state_file(str(path))
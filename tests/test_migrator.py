@@ -0,0 +1,306 @@
+"""Tests for the migrator's housekeeping on the source and target."""
+import pytest
+from src.core.config import MigrationConfig
+from src.core.migrator import Migrator
+from src.core.policy import SecretPolicy
+from src.core.workflow_generator import gh_cli_assets
+from src.utils.logger import Logger
+
+
+class FakeAPI:
+    """Client holding secrets in memory and recording deletions."""
+
+    def __init__(self, repo_secrets=(), env_secrets=None, org_secrets=(), failing_envs=()):
+        self.repo_secrets = list(repo_secrets)
+        self.env_secrets = {env: list(names) for env, names in (env_secrets or {}).items()}
+        self.org_secrets = list(org_secrets)
+        self.failing_envs = set(failing_envs)
+        # Names whose deletion fails
+        self.undeletable = set()
+        self.deleted = []
+
+    def get_login(self):
+        return "octocat"
+
+    def is_archived(self, org, repo):
+        return False
+
+    def list_repo_secrets(self, org, repo):
+        return list(self.repo_secrets)
+
+    def list_environment_secrets(self, org, repo, environment_name, strict=False):
+        if environment_name in self.failing_envs:
+            if strict:
+                raise RuntimeError(f"Failed to list secrets of environment '{environment_name}'")
+            return []
+        return list(self.env_secrets.get(environment_name, []))
+
+    def list_org_secrets(self, org):
+        return list(self.org_secrets)
+
+    def delete_secret(self, org, repo, name):
+        if name in self.undeletable:
+            raise RuntimeError(f"Failed to delete secret {name}")
+        self.deleted.append(("repo", name))
+
+    def delete_branch(self, org, repo, name):
+        self.deleted.append(("branch", name))
+
+    def delete_environment_secret(self, org, repo, environment_name, name):
+        self.deleted.append((environment_name, name))
+
+    def delete_org_secret(self, org, name):
+        self.deleted.append(("org", name))
+
+
+def make_migrator(**options):
+    """Build a migrator whose source and target clients are FakeAPIs."""
+    options.setdefault("prune", True)
+    config = MigrationConfig(
+        source_org="src-org", source_repo="app", target_org="dst-org", target_repo="app",
+        source_pat="source-token", target_pat="target-token", **options
+    )
+    migrator = Migrator(config, Logger(verbose=False))
+    migrator.source_api = FakeAPI()
+    migrator.target_api = FakeAPI()
+    return migrator
+
+
+def leave_pending(migrator, then):
+    """Replace the migration with one that creates the usual source resources, then calls then()."""
+    def run_migration():
+        migrator._created("branch", "app", "migrate-secrets")
+        migrator._created("secret", "app", "SECRETS_MIGRATOR_TARGET_PAT")
+        migrator._created("secret", "app", "SECRETS_MIGRATOR_SOURCE_PAT")
+        then()
+    migrator._run_migration = run_migration
+
+
+def interrupt():
+    raise KeyboardInterrupt
+
+
+class TestCancelCleanup:
+    """Test cases for removing what a cancelled or failed run left on the source."""
+
+    def test_cancel_removes_every_pending_resource(self, tmp_path):
+        """Test that Ctrl-C removes the temporary secrets and branch, newest first."""
+        migrator = make_migrator(state_dir=str(tmp_path))
+        leave_pending(migrator, interrupt)
+        with pytest.raises(KeyboardInterrupt):
+            migrator.run()
+        assert migrator.source_api.deleted == [
+            ("repo", "SECRETS_MIGRATOR_SOURCE_PAT"),
+            ("repo", "SECRETS_MIGRATOR_TARGET_PAT"),
+            ("branch", "migrate-secrets"),
+        ]
+        deleted = [event.data for event in migrator.events.events if event.kind == "deleted"]
+        assert [(data["resource_kind"], data["name"]) for data in deleted] == [
+            ("secret", "SECRETS_MIGRATOR_SOURCE_PAT"),
+            ("secret", "SECRETS_MIGRATOR_TARGET_PAT"),
+            ("branch", "migrate-secrets"),
+        ]
+        assert migrator._pending_cleanup == []
+
+    def test_cancel_continues_past_a_failed_removal(self, tmp_path):
+        """Test that a resource that cannot be removed is reported and the others still go."""
+        migrator = make_migrator(state_dir=str(tmp_path))
+        migrator.source_api.undeletable = {"SECRETS_MIGRATOR_SOURCE_PAT"}
+        leave_pending(migrator, interrupt)
+        with pytest.raises(KeyboardInterrupt):
+            migrator.run()
+        assert migrator.source_api.deleted == [
+            ("repo", "SECRETS_MIGRATOR_TARGET_PAT"), ("branch", "migrate-secrets")
+        ]
+        warnings = [event for event in migrator.events.events if event.kind == "warning"]
+        assert [(event.data["resource_kind"], event.data["name"]) for event in warnings] == [
+            ("secret", "SECRETS_MIGRATOR_SOURCE_PAT")
+        ]
+
+    def test_failure_removes_pending_resources(self, tmp_path):
+        """Test that a run failing with any error cleans up the source, then re-raises."""
+        migrator = make_migrator(state_dir=str(tmp_path))
+
+        def fail():
+            raise RuntimeError("Failed to push workflow: 502 Bad Gateway")
+
+        leave_pending(migrator, fail)
+        with pytest.raises(RuntimeError, match="502 Bad Gateway"):
+            migrator.run()
+        assert [name for _, name in migrator.source_api.deleted] == [
+            "SECRETS_MIGRATOR_SOURCE_PAT", "SECRETS_MIGRATOR_TARGET_PAT", "migrate-secrets"
+        ]
+        assert migrator._pending_cleanup == []
+
+
+class TestWorkflowLintFailure:
+    """Test cases for a generated workflow that fails lint before it is pushed."""
+
+    def test_run_fails_and_removes_pending_resources(self, tmp_path):
+        """Test that the run fails with the lint error after cleaning up the source."""
+        migrator = make_migrator(state_dir=str(tmp_path))
+        workflow_path = ".github/workflows/migrate-secrets.yml"
+        leave_pending(migrator, lambda: migrator._lint_workflow(workflow_path, "jobs: ["))
+        with pytest.raises(RuntimeError, match="failed lint with 1 problem"):
+            migrator.run()
+        assert [name for _, name in migrator.source_api.deleted] == [
+            "SECRETS_MIGRATOR_SOURCE_PAT", "SECRETS_MIGRATOR_TARGET_PAT", "migrate-secrets"
+        ]
+        kinds = [event.kind for event in migrator.events.events]
+        assert kinds.index("error") < kinds.index("deleted") < kinds.index("run_failed")
+
+
+class TestPrune:
+    """Test cases for --prune."""
+
+    def test_only_names_the_run_could_write_are_pruned(self):
+        """Test that secrets outside the prefix or the policy are left alone."""
+        migrator = make_migrator(target_prefix="A_")
+        migrator.policy = SecretPolicy(deny=["LEGACY_*"])
+        migrator.target_api.repo_secrets = [
+            "A_KEEP", "A_GONE", "B_OTHER_SOURCE", "UNPREFIXED", "A_LEGACY_TOKEN",
+            "SECRETS_MIGRATOR_PAT",
+        ]
+        migrator._prune_target_secrets(["KEEP"], {})
+        assert migrator.target_api.deleted == [("repo", "A_GONE")]
+
+    def test_lowercase_prefix(self):
+        """Test that a lowercase prefix still matches the upper-cased names on the target."""
+        migrator = make_migrator(target_prefix="legacy_")
+        migrator.target_api.repo_secrets = ["LEGACY_API_KEY", "LEGACY_GONE", "OTHER"]
+        migrator._prune_target_secrets(["API_KEY"], {})
+        assert migrator.target_api.deleted == [("repo", "LEGACY_GONE")]
+
+    def test_policy_checked_against_source_name(self):
+        """Test that the policy sees the name a secret was migrated from, not its new name."""
+        migrator = make_migrator()
+        migrator.policy = SecretPolicy(deny=["INTERNAL_*"])
+        migrator.namer.overrides["INTERNAL_TOKEN"] = "SHARED_TOKEN"
+        migrator.target_api.repo_secrets = ["SHARED_TOKEN", "GONE"]
+        migrator._prune_target_secrets([], {})
+        assert migrator.target_api.deleted == [("repo", "GONE")]
+
+    def test_rename_rules_prune_only_without_policy(self):
+        """Test that rule-renamed secrets are pruned only when there is no policy to check."""
+        migrator = make_migrator(rename_rules=["s/^PROD_/PRD_/"])
+        migrator.target_api.repo_secrets = ["PRD_GONE"]
+        migrator._prune_target_secrets([], {})
+        assert migrator.target_api.deleted == [("repo", "PRD_GONE")]
+        migrator = make_migrator(rename_rules=["s/^PROD_/PRD_/"])
+        migrator.policy = SecretPolicy(deny=["INTERNAL_*"])
+        migrator.target_api.repo_secrets = ["PRD_GONE"]
+        migrator._prune_target_secrets([], {})
+        assert migrator.target_api.deleted == []
+
+    def test_suffix_limits_org_pruning(self):
+        """Test that organization pruning only touches names with the run's suffix."""
+        migrator = make_migrator(target_suffix="_OLD")
+        migrator.target_api.org_secrets = ["TOKEN_OLD", "GONE_OLD", "TEAM_SECRET", "_OLD"]
+        migrator._prune_target_org_secrets(["TOKEN"])
+        assert migrator.target_api.deleted == [("org", "GONE_OLD")]
+
+    def test_policy_allowlist_limits_environment_pruning(self):
+        """Test that environment secrets outside the allowlist are not pruned."""
+        migrator = make_migrator()
+        migrator.policy = SecretPolicy(allow=["APP_*"])
+        migrator.target_api.env_secrets = {"prod": ["APP_KEY", "APP_GONE", "INFRA_KEY"]}
+        migrator._prune_target_secrets([], {"prod": ["APP_KEY"]}, repo_level=False)
+        assert migrator.target_api.deleted == [("prod", "APP_GONE")]
+
+    def test_environment_listing_failure_aborts(self):
+        """Test that a target environment that cannot be listed fails the prune, not skipped."""
+        migrator = make_migrator()
+        migrator.target_api.repo_secrets = ["GONE"]
+        migrator.target_api.failing_envs = {"prod"}
+        with pytest.raises(RuntimeError, match="environment 'prod'"):
+            migrator._prune_target_secrets(["KEEP"], {"prod": ["DB"], "staging": []})
+        # Repository secrets are pruned first; nothing in an unlisted environment is touched
+        assert migrator.target_api.deleted == [("repo", "GONE")]
+
+
+class TestTemporarySecretCheck:
+    """Test cases for the check that the workflow deleted the temporary source secrets."""
+
+    def setup_migrator(self):
+        migrator = make_migrator()
+        migrator._temporary_secrets = [
+            ("app", "SECRETS_MIGRATOR_SOURCE_PAT"), ("app", "SECRETS_MIGRATOR_TARGET_PAT")
+        ]
+        migrator.source_api.repo_secrets = ["SECRETS_MIGRATOR_TARGET_PAT", "DB_PASSWORD"]
+        return migrator
+
+    def test_leftover_is_removed(self):
+        """Test that a temporary secret the workflow left behind is deleted and reported."""
+        migrator = self.setup_migrator()
+        migrator._verify_temporary_secrets_removed()
+        assert migrator.source_api.deleted == [("repo", "SECRETS_MIGRATOR_TARGET_PAT")]
+        deleted = [event.data for event in migrator.events.events if event.kind == "deleted"]
+        assert deleted == [{"resource_kind": "secret", "name": "SECRETS_MIGRATOR_TARGET_PAT"}]
+
+    def test_undeletable_leftover_is_reported(self):
+        """Test that a leftover that cannot be deleted is reported as left in the source."""
+        migrator = self.setup_migrator()
+        migrator.source_api.undeletable = {"SECRETS_MIGRATOR_TARGET_PAT"}
+        migrator._verify_temporary_secrets_removed()
+        assert migrator.source_api.deleted == []
+        warnings = [event for event in migrator.events.events if event.kind == "warning"]
+        assert [event.message for event in warnings] == [
+            "Temporary secret 'SECRETS_MIGRATOR_TARGET_PAT' left in src-org/app"
+        ]
+        assert warnings[0].data == {
+            "resource_kind": "secret", "name": "SECRETS_MIGRATOR_TARGET_PAT"
+        }
+
+
+class TestNothingToMigrate:
+    """Test cases for telling a run that wrote nothing (exit status 6) from one that did."""
+
+    def test_no_secret_and_no_other_write(self):
+        """Test that a run with no secret to migrate and nothing else written wrote nothing."""
+        migrator = make_migrator()
+        migrator._nothing_to_migrate("every secret already exists on target")
+        assert migrator.wrote_nothing
+
+    def test_pruning_counts_as_a_write(self):
+        """Test that a pruned target secret means the run wrote something."""
+        migrator = make_migrator()
+        migrator.target_api.repo_secrets = ["GONE"]
+        migrator._prune_target_secrets([], {})
+        migrator._nothing_to_migrate("source repository holds only system secrets")
+        assert migrator.target_writes == 1
+        assert not migrator.wrote_nothing
+
+
+class TestGhCliChecksums:
+    """Test cases for reading the gh CLI digests when generating the workflow."""
+
+    CHECKSUMS = "".join(
+        f"{index:064x}  {asset}\n" for index, asset in enumerate(gh_cli_assets("2.40.1"), 1)
+    )
+
+    def test_digests_read_once(self, monkeypatch):
+        """Test that the release's checksums file is downloaded once per run."""
+        urls = []
+
+        def get_text(url, timeout=30.0):
+            urls.append(url)
+            return self.CHECKSUMS
+
+        monkeypatch.setattr("src.core.migrator.get_text", get_text)
+        migrator = make_migrator(gh_cli_version="2.40.1")
+        assert migrator._gh_cli_digests()["gh_2.40.1_linux_amd64.tar.gz"] == f"{1:064x}"
+        assert len(migrator._gh_cli_digests()) == 6
+        assert urls == [
+            "https://github.com/cli/cli/releases/download/v2.40.1/gh_2.40.1_checksums.txt"
+        ]
+
+    def test_unavailable_checksums_warn(self, monkeypatch):
+        """Test that a failed download is a warning and leaves the workflow without digests."""
+        def get_text(url, timeout=30.0):
+            raise OSError("Name or service not known")
+
+        monkeypatch.setattr("src.core.migrator.get_text", get_text)
+        migrator = make_migrator(gh_cli_version="2.40.1")
+        assert migrator._gh_cli_digests() == {}
+        warnings = [event.message for event in migrator.events.events if event.kind == "warning"]
+        assert warnings == ["gh CLI 2.40.1 checksums not downloaded: Name or service not known"]
@@ -0,0 +1,60 @@
+"""Tests for the programmatic interface."""
+import pytest
+from src.core.events import EventLog
+from src.migrator import MigrationOptions, MigrationResult
+
+
+class TestMigrationOptions:
+    """Test cases for programmatic options."""
+
+    def test_build_config(self):
+        """Test that named options and settings reach the run's configuration."""
+        options = MigrationOptions(
+            "acme", "app", "acme-cloud", "app", wait=True, conflict_policy="skip",
+            environment_map={"prod": "production"},
+        )
+        config = options.build_config("source-token", "target-token")
+        assert (config.source_org, config.target_repo, config.wait) == ("acme", "app", True)
+        assert config.conflict_policy == "skip"
+        assert config.environment_map == {"prod": "production"}
+        assert config.source_pat == "source-token"
+
+    @pytest.mark.parametrize("settings, message", [
+        ({"conflict_policy": "merge"}, "invalid conflict_policy"),
+        ({"colour": True}, "unknown option"),
+        ({"target_pat": "x"}, "tokens must be passed"),
+        ({"runner_labels": "self-hosted"}, "must be a list"),
+    ])
+    def test_invalid_options(self, settings, message):
+        """Test that options are validated like pipeline jobs."""
+        with pytest.raises(ValueError, match=message):
+            MigrationOptions("acme", "app", "acme-cloud", "app", **settings).build_config("a", "b")
+
+    def test_target_repo_required(self):
+        """Test that repository migrations need a target repository."""
+        with pytest.raises(ValueError, match="needs 'target_repo'"):
+            MigrationOptions("acme", "app", "acme-cloud").build_config("a", "b")
+
+
+class TestMigrationResult:
+    """Test cases for typed results."""
+
+    def test_secrets_and_links(self):
+        """Test that per-secret outcomes and links are read from the run's events."""
+        events = EventLog()
+        events.emit("skipped", "Repository secret 'OLD' skipped: denied", secret="OLD")
+        events.emit(
+            "secret_confirmed", "Workflow set repository secret 'API_KEY'", secret="API_KEY",
+            level="repository", location="acme-cloud/app"
+        )
+        events.emit("error", "Workflow failed to set secret 'DB'", secret="DB", run_url="u")
+        events.emit("link", "Secrets migration workflow run", url="https://example.test/run/1")
+        events.emit("error", "Something else went wrong")
+        result = MigrationResult("failed", events.events, 1.5, "1 secret(s) failed")
+        assert not result.succeeded
+        assert [(secret.name, secret.status) for secret in result.secrets] == [
+            ("OLD", "skipped"), ("API_KEY", "confirmed"), ("DB", "failed")
+        ]
+        assert result.secrets[1].location == "acme-cloud/app"
+        assert result.links == {"Secrets migration workflow run": "https://example.test/run/1"}
+        assert result.to_dict()["secrets"][2]["status"] == "failed"
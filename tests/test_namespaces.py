@@ -0,0 +1,66 @@
+"""Tests for secret namespaces and bulk selection."""
+import pytest
+from src.core.namespaces import (
+    NamespaceUnavailableError,
+    secrets_api_path,
+    select_matching,
+    unavailable_reason,
+)
+
+
+class TestSecretsApiPath:
+    """Test cases for secrets_api_path."""
+
+    @pytest.mark.parametrize("namespace", ["actions", "dependabot", "codespaces"])
+    def test_repository_path(self, namespace):
+        """Test repository-level paths for each namespace."""
+        assert secrets_api_path(namespace, "acme", "app") == f"/repos/acme/app/{namespace}/secrets"
+
+    def test_organization_path(self):
+        """Test that an empty repo targets organization secrets."""
+        assert secrets_api_path("dependabot", "acme") == "/orgs/acme/dependabot/secrets"
+
+    def test_unknown_namespace(self):
+        """Test that unknown namespaces are rejected."""
+        with pytest.raises(ValueError, match="Unknown secret namespace"):
+            secrets_api_path("packages", "acme")
+
+
+class TestSelectMatching:
+    """Test cases for select_matching."""
+
+    def test_glob_filters(self):
+        """Test selection by several case-insensitive patterns."""
+        names = ["OLD_DB", "old_api", "NEW_DB", "LEGACY"]
+        assert select_matching(names, ["OLD_*", "LEGACY"]) == ["OLD_DB", "old_api", "LEGACY"]
+
+    def test_no_match(self):
+        """Test that nothing is selected without a match."""
+        assert select_matching(["NEW_DB"], ["OLD_*"]) == []
+
+    def test_migrator_secrets_never_selected(self):
+        """Test that the migrator's own secrets survive a catch-all filter."""
+        names = ["SECRETS_MIGRATOR_TARGET_PAT", "APP_TOKEN"]
+        assert select_matching(names, ["*"]) == ["APP_TOKEN"]
+
+
+class TestUnavailableReason:
+    """Test cases for detecting disabled Dependabot/Codespaces APIs."""
+
+    def test_disabled_feature_detected(self):
+        """Test 403/404 answers naming the disabled feature."""
+        assert unavailable_reason("codespaces", 403, "Codespaces is not enabled for this org")
+        assert unavailable_reason("dependabot", 404, "Dependabot alerts are disabled")
+
+    def test_genuine_failures_not_swallowed(self):
+        """Test that other errors are still reported as failures."""
+        assert unavailable_reason("dependabot", 404, "Not Found") == ""
+        assert unavailable_reason("dependabot", 500, "Dependabot error") == ""
+        assert unavailable_reason("actions", 403, "Actions is disabled") == ""
+
+    def test_error_message(self):
+        """Test that the error keeps the namespace and reason."""
+        reason = unavailable_reason("codespaces", 403, "Codespaces disabled")
+        error = NamespaceUnavailableError("codespaces", "acme", reason)
+        assert error.namespace == "codespaces"
+        assert "acme" in str(error) and "HTTP 403" in str(error)
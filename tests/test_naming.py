@@ -0,0 +1,160 @@
+"""Tests for target secret name transformations."""
+import pytest
+from src.core.naming import (
+    NamingConvention, RenameRule, SecretNameTransformer, branch_name_error, check_commit_options
+)
+
+
+class TestSecretNameTransformer:
+    """Test cases for SecretNameTransformer."""
+
+    def test_identity_by_default(self):
+        """Test that names are unchanged without prefix or suffix."""
+        namer = SecretNameTransformer()
+        assert namer.is_identity is True
+        assert namer.transform("DB_PASSWORD") == "DB_PASSWORD"
+
+    def test_prefix_and_suffix(self):
+        """Test namespacing with prefix and suffix."""
+        namer = SecretNameTransformer(prefix="LEGACY_", suffix="_V1")
+        assert namer.is_identity is False
+        assert namer.transform("DB_PASSWORD") == "LEGACY_DB_PASSWORD_V1"
+
+    def test_strip_affixes(self):
+        """Test recovering the name inside the prefix and suffix of a target secret."""
+        namer = SecretNameTransformer(prefix="legacy_", suffix="_V1")
+        assert namer.strip_affixes("LEGACY_DB_PASSWORD_V1") == "DB_PASSWORD"
+        assert namer.strip_affixes("DB_PASSWORD_V1") is None
+        assert namer.strip_affixes("LEGACY_DB_PASSWORD") is None
+        assert namer.strip_affixes("LEGACY__V1") is None
+        assert SecretNameTransformer().strip_affixes("ANY") == "ANY"
+
+    def test_target_names_upper_cased(self):
+        """Test that lowercase affixes and replacements give the name GitHub stores."""
+        namer = SecretNameTransformer(prefix="legacy_", suffix="_v1", rules=["s/_KEY$/_token/"])
+        assert namer.transform("API_KEY") == "LEGACY_API_TOKEN_V1"
+        namer.overrides["DB"] = "old_db"
+        assert namer.transform("DB") == "OLD_DB"
+        assert namer.strip_affixes(namer.transform("API_KEY")) == "API_TOKEN"
+
+    def test_source_name(self):
+        """Test recovering the source name of a target secret, when the rename can be undone."""
+        namer = SecretNameTransformer(prefix="legacy_")
+        namer.overrides["DB"] = "old_db"
+        assert namer.source_name("LEGACY_API_KEY") == "API_KEY"
+        assert namer.source_name("OLD_DB") == "DB"
+        assert namer.source_name("API_KEY") is None
+        ruled = SecretNameTransformer(rules=["s/^PROD_/PRD_/"])
+        assert ruled.source_name("PRD_API_KEY") is None
+
+    def test_build_map(self):
+        """Test building a source-to-target name map."""
+        namer = SecretNameTransformer(prefix="APP_")
+        assert namer.build_map(["A", "B"]) == {"A": "APP_A", "B": "APP_B"}
+
+    def test_invalid_prefix_rejected(self):
+        """Test that characters GitHub rejects are refused up front."""
+        with pytest.raises(ValueError, match="prefix"):
+            SecretNameTransformer(prefix="LEGACY-")
+
+    def test_rename_rules_applied_before_affixes(self):
+        """Test that regex rules run in order, then prefix/suffix are added."""
+        namer = SecretNameTransformer(prefix="X_", rules=["s/^PROD_/PRD_/", "s/_KEY$/_TOKEN/"])
+        assert namer.is_identity is False
+        assert namer.transform("PROD_API_KEY") == "X_PRD_API_TOKEN"
+        assert namer.transform("DEV_API") == "X_DEV_API"
+
+    def test_rename_rule_flags_and_groups(self):
+        """Test the g and i flags and group references."""
+        assert RenameRule("s/a/b/gi").apply("AAa") == "bbb"
+        assert RenameRule("s/a/b/").apply("aaa") == "baa"
+        namer = SecretNameTransformer(rules=[r"s|^(\w+)_OLD$|NEW_\1|"])
+        assert namer.transform("DB_OLD") == "NEW_DB"
+
+    @pytest.mark.parametrize("rule", ["PROD_/PRD_", "s/PROD_", "s/(/x/", "s/a/b/z", r"s/a/\2/"])
+    def test_invalid_rules_rejected(self, rule):
+        """Test that malformed rules fail up front."""
+        with pytest.raises(ValueError, match="rename rule"):
+            SecretNameTransformer(rules=[rule])
+
+    def test_overrides_replace_rules_and_affixes(self):
+        """Test that an override is used verbatim for its source name."""
+        namer = SecretNameTransformer(prefix="APP_", rules=["s/^PROD_//"])
+        namer.overrides["PROD_KEY"] = "LEGACY_KEY"
+        assert namer.transform("PROD_KEY") == "LEGACY_KEY"
+        assert namer.transform("PROD_TOKEN") == "APP_TOKEN"
+        identity = SecretNameTransformer()
+        identity.overrides["A"] = "B"
+        assert identity.is_identity is False
+
+    def test_validate_rejects_invalid_target_names(self):
+        """Test GitHub secret-name rules on transformed names."""
+        with pytest.raises(ValueError, match="GITHUB_ prefix"):
+            SecretNameTransformer(rules=["s/^/GITHUB_/"]).validate(["TOKEN"])
+        with pytest.raises(ValueError, match="must not start with a digit"):
+            SecretNameTransformer(rules=["s/^PROD_//"]).validate(["PROD_1KEY"])
+
+    def test_validate_rejects_collisions(self):
+        """Test that two secrets renamed to the same target are refused."""
+        namer = SecretNameTransformer(rules=["s/^(PROD|STAGE)_//"])
+        with pytest.raises(ValueError, match="both map to 'KEY'"):
+            namer.validate(["PROD_KEY", "STAGE_KEY"])
+        assert namer.validate(["PROD_A", "STAGE_B"]) == {"PROD_A": "A", "STAGE_B": "B"}
+
+
+class TestBranchNames:
+    """Test cases for migration branch and commit identity validation."""
+
+    def test_valid_branch_names(self):
+        """Test names allowed by git ref rules."""
+        for name in ("migrate-secrets", "chore/secrets-migration", "ops/2026.10"):
+            assert branch_name_error(name) is None
+
+    def test_invalid_branch_names(self):
+        """Test names git would reject."""
+        for name in ("", "has space", "a..b", "-lead", "trail/", "x.lock", "a~1", ".hidden"):
+            assert branch_name_error(name) is not None, name
+
+    def test_commit_identity_pairs(self):
+        """Test that committer name and email are required together."""
+        check_commit_options("", "Release Bot", "bot@example.com")
+        with pytest.raises(ValueError, match="together"):
+            check_commit_options("", "Release Bot", "")
+        with pytest.raises(ValueError, match="email"):
+            check_commit_options("", "Release Bot", "bot")
+        with pytest.raises(ValueError, match="branch name"):
+            check_commit_options("bad name", "", "")
+
+
+class TestNamingConvention:
+    """Test cases for NamingConvention."""
+
+    def test_empty_by_default(self):
+        """Test that no rule means no violation."""
+        convention = NamingConvention()
+        assert convention.is_empty is True
+        assert convention.check(["lower_case", "X" * 500]) == {}
+
+    def test_pattern_must_match_fully(self):
+        """Test that the pattern applies to the whole name."""
+        convention = NamingConvention(pattern="[A-Z][A-Z0-9_]*")
+        assert convention.violations("API_KEY") == []
+        assert convention.violations("API_key") == ["does not match pattern '[A-Z][A-Z0-9_]*'"]
+
+    def test_max_length_and_reserved_prefixes(self):
+        """Test the length limit and case-insensitive reserved prefixes."""
+        convention = NamingConvention(max_length=10, reserved_prefixes=["TMP_", "", "DEBUG_"])
+        assert convention.reserved_prefixes == ["TMP_", "DEBUG_"]
+        assert convention.check(["API_KEY", "tmp_TOKEN", "DEBUG_PASSWORD"]) == {
+            "tmp_TOKEN": ["uses reserved prefix 'TMP_'"],
+            "DEBUG_PASSWORD": ["is 14 characters long (max 10)", "uses reserved prefix 'DEBUG_'"],
+        }
+
+    @pytest.mark.parametrize("kwargs, message", [
+        ({"pattern": "[A-Z"}, "Invalid naming pattern"),
+        ({"max_length": -1}, "must not be negative"),
+    ])
+    def test_invalid_rules(self, kwargs, message):
+        """Test that malformed rules are rejected up front."""
+        with pytest.raises(ValueError, match=message):
+            NamingConvention(**kwargs)
@@ -0,0 +1,39 @@
+"""Tests for completion notifications."""
+import pytest
+from src.core.events import EventLog
+from src.core.notifications import build_summary_text, send_notification
+
+
+class TestBuildSummaryText:
+    """Test cases for build_summary_text."""
+
+    def test_success_with_links_and_report(self):
+        """Test counts, duration, workflow links and report location."""
+        events = EventLog()
+        events.emit("link", "Secrets migration workflow run", url="https://github.com/a/b/actions")
+        text = build_summary_text(
+            "a/b → c/b", 1, 0, 65, events, report="https://reports.example.com/run.json"
+        )
+        lines = text.splitlines()
+        assert lines[0] == "Secrets migration succeeded: a/b → c/b"
+        assert lines[1] == "1 migrated, 0 failed in 1:05"
+        assert "Secrets migration workflow run: https://github.com/a/b/actions" in lines
+        assert lines[-1] == "Report: https://reports.example.com/run.json"
+
+    def test_failures_and_details(self):
+        """Test the failure headline and per-job detail lines."""
+        text = build_summary_text(
+            "pipeline p.yml", 1, 1, 3, EventLog(), details=["- a: succeeded", "- b: failed"]
+        )
+        assert text.startswith("Secrets migration finished with failures")
+        assert "- b: failed" in text
+        assert "Report:" not in text
+
+
+class TestSendNotification:
+    """Test cases for send_notification."""
+
+    def test_https_required(self):
+        """Test that webhooks must use https."""
+        with pytest.raises(ValueError):
+            send_notification("http://hooks.example.com/x", "hello")
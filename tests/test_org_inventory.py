@@ -0,0 +1,81 @@
+"""Tests for organization-wide inventories."""
+import json
+from datetime import datetime, timezone
+import pytest
+from src.core.inventory import SecretRecord, VariableRecord
+from src.core.org_inventory import (
+    build_inventory, format_inventory_summary, inventory_csv, inventory_repositories,
+    load_inventory
+)
+from src.core.scopes import OrgSecretScope
+
+UPDATED = datetime(2026, 1, 2, 3, 4, 5, tzinfo=timezone.utc)
+
+
+def sample_inventory():
+    """Return the inventory of an organization with one repository and one empty one."""
+    return build_inventory(
+        "src",
+        {
+            "app": (
+                [SecretRecord("DB", "repo", updated_at=UPDATED),
+                 SecretRecord("API", "env", environment="prod")],
+                [VariableRecord("REGION", "repo", "eu")],
+            ),
+            "docs": ([], []),
+        },
+        org_secrets=[SecretRecord("NPM", "org", visibility="selected")],
+        org_variables=[VariableRecord("TIER", "org", "gold", visibility="all")],
+        scopes={("secret", "NPM"): OrgSecretScope("selected", ["web", "app"])},
+        taken_at=UPDATED,
+    )
+
+
+class TestBuildInventory:
+    """Test cases for building inventories."""
+
+    def test_entries(self):
+        """Test that every level is recorded with scopes and timestamps, never values."""
+        inventory = sample_inventory()
+        assert inventory["repositories"] == ["app", "docs"]
+        npm, tier, api, db, region = inventory["entries"]
+        assert npm["selected_repositories"] == ["app", "web"]
+        assert (tier["kind"], tier["visibility"]) == ("variable", "all")
+        assert (api["repo"], api["environment"]) == ("app", "prod")
+        assert db["updated_at"] == "2026-01-02T03:04:05+00:00"
+        assert region["name"] == "REGION"
+        assert "eu" not in json.dumps(inventory) and "gold" not in json.dumps(inventory)
+        assert format_inventory_summary(inventory) == (
+            "Inventory of src: 2 repository(ies), 3 secret(s), 2 variable(s)"
+        )
+
+    def test_repositories_to_plan(self):
+        """Test that only repositories with secrets or variables are planned."""
+        assert inventory_repositories(sample_inventory()) == ["app"]
+
+
+class TestLoadInventory:
+    """Test cases for reading inventories back."""
+
+    def test_json_and_csv_round_trip(self, tmp_path):
+        """Test that both formats load to the same entries."""
+        inventory = sample_inventory()
+        json_path = tmp_path / "inventory.json"
+        json_path.write_text(json.dumps(inventory))
+        csv_path = tmp_path / "inventory.csv"
+        csv_path.write_text(inventory_csv(inventory))
+        from_csv = load_inventory(str(csv_path))
+        assert load_inventory(str(json_path)) == inventory
+        assert from_csv["organization"] == "src"
+        assert from_csv["entries"] == inventory["entries"]
+        assert inventory_repositories(from_csv) == ["app"]
+
+    def test_invalid_files(self, tmp_path):
+        """Test that other JSON documents and CSVs are rejected."""
+        path = tmp_path / "other.json"
+        path.write_text('{"schema_version": 99}')
+        with pytest.raises(ValueError, match="not a version 1 inventory"):
+            load_inventory(str(path))
+        path.write_text("org,repo\nsrc,app\n")
+        with pytest.raises(ValueError, match="not an inventory CSV"):
+            load_inventory(str(path))
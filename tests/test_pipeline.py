@@ -0,0 +1,247 @@
+"""Tests for multi-job migration pipelines."""
+import pytest
+from src.core.pipeline import (
+    PipelineJob,
+    load_pipeline,
+    order_shared_first,
+    parse_pipeline,
+    run_pipeline,
+)
+
+
+def _pipeline_data():
+    return {
+        "defaults": {"source-org": "src-org", "target-org": "dst-org"},
+        "jobs": [
+            {"name": "org secrets", "org_to_org": True, "source_repo": ".github"},
+            {
+                "name": "batch A",
+                "source_repo": "app-a",
+                "target_repo": "app-a",
+                "continue_on_error": True,
+            },
+            {"source_repo": "app-b", "target_repo": "app-b", "skip_envs": True},
+        ],
+    }
+
+
+class TestParsePipeline:
+    """Test cases for pipeline parsing."""
+
+    def test_defaults_merged_into_jobs(self):
+        """Test that defaults apply to every job and keys are normalized."""
+        jobs = parse_pipeline(_pipeline_data())
+        assert len(jobs) == 3
+        assert all(job.options["source_org"] == "src-org" for job in jobs)
+        assert jobs[0].options["org_to_org"] is True
+
+    def test_job_names_and_continue_on_error(self):
+        """Test job names (with fallback) and continue_on_error parsing."""
+        jobs = parse_pipeline(_pipeline_data())
+        assert [job.name for job in jobs] == ["org secrets", "batch A", "job-3"]
+        assert [job.continue_on_error for job in jobs] == [False, True, False]
+        assert "continue_on_error" not in jobs[1].options
+
+    def test_build_config(self):
+        """Test that a job builds a MigrationConfig with supplied tokens."""
+        job = parse_pipeline(_pipeline_data())[2]
+        config = job.build_config("src-token", "dst-token")
+        assert config.source_repo == "app-b"
+        assert config.skip_envs is True
+        assert config.source_pat == "src-token"
+
+    def test_missing_jobs_rejected(self):
+        """Test that a config without jobs is rejected."""
+        with pytest.raises(ValueError):
+            parse_pipeline({"defaults": {}})
+
+    def test_unknown_option_rejected(self):
+        """Test that typos in option names are reported."""
+        data = _pipeline_data()
+        data["jobs"][0]["skip_env"] = True
+        with pytest.raises(ValueError, match="skip_env"):
+            parse_pipeline(data)
+
+    def test_tokens_in_config_rejected(self):
+        """Test that tokens cannot be stored in the config file."""
+        data = _pipeline_data()
+        data["jobs"][0]["source_pat"] = "ghp_x"
+        with pytest.raises(ValueError, match="source_pat"):
+            parse_pipeline(data)
+
+    def test_repo_job_requires_target_repo(self):
+        """Test that repo-to-repo jobs need a target repository."""
+        data = {"jobs": [{"source_org": "a", "target_org": "b", "source_repo": "r"}]}
+        with pytest.raises(ValueError, match="target_repo"):
+            parse_pipeline(data)
+
+    def test_load_pipeline_from_yaml(self, tmp_path):
+        """Test loading a pipeline from a YAML file."""
+        path = tmp_path / "pipeline.yml"
+        path.write_text(
+            "jobs:\n"
+            "  - name: only\n"
+            "    source_org: a\n"
+            "    target_org: b\n"
+            "    source_repo: r\n"
+            "    target_repo: r\n"
+        )
+        jobs = load_pipeline(str(path))
+        assert jobs[0].name == "only"
+
+    def test_extra_jobs_take_defaults(self, tmp_path):
+        """Test that extra jobs run after the file's jobs, with its defaults, or without a file."""
+        path = tmp_path / "pipeline.yml"
+        path.write_text("defaults:\n  skip_envs: true\n")
+        extra = [{"name": "a/r", "source_org": "a", "target_org": "b", "source_repo": "r",
+                  "target_repo": "r"}]
+        (job,) = load_pipeline(str(path), extra)
+        assert job.name == "a/r" and job.options["skip_envs"] is True
+        assert [job.name for job in load_pipeline("", extra)] == ["a/r"]
+
+
+class TestRunPipeline:
+    """Test cases for pipeline execution."""
+
+    def _jobs(self, continue_on_error):
+        return [
+            PipelineJob("first", {}),
+            PipelineJob("second", {}, continue_on_error=continue_on_error),
+            PipelineJob("third", {}),
+        ]
+
+    def test_all_jobs_succeed(self, temp_logger):
+        """Test that every job runs in order."""
+        ran = []
+        results = run_pipeline(self._jobs(False), lambda job: ran.append(job.name), temp_logger)
+        assert ran == ["first", "second", "third"]
+        assert [r.status for r in results] == ["succeeded"] * 3
+
+    def test_failure_stops_pipeline(self, temp_logger):
+        """Test that a failing job stops the remaining jobs."""
+        def run_job(job):
+            if job.name == "second":
+                raise RuntimeError("boom")
+
+        results = run_pipeline(self._jobs(False), run_job, temp_logger)
+        assert [r.status for r in results] == ["succeeded", "failed", "not_run"]
+        assert results[1].error == "boom"
+
+    def test_continue_on_error(self, temp_logger):
+        """Test that continue_on_error lets the pipeline proceed."""
+        def run_job(job):
+            if job.name == "second":
+                raise RuntimeError("boom")
+
+        seen = []
+        results = run_pipeline(
+            self._jobs(True), run_job, temp_logger, on_result=lambda r: seen.append(r.name)
+        )
+        assert [r.status for r in results] == ["succeeded", "failed", "succeeded"]
+        assert seen == ["first", "second", "third"]
+
+
+class TestPipelineOverrides:
+    """Test per-job overrides of routing, conflict policy and runner labels."""
+
+    def test_job_overrides_defaults(self):
+        """Test that a job's options replace the defaults for that job only."""
+        jobs = parse_pipeline({
+            "defaults": {
+                "source_org": "s", "target_org": "t",
+                "conflict_policy": "skip", "runner_labels": ["ubuntu-latest"],
+            },
+            "jobs": [
+                {
+                    "source_repo": "payments", "target_repo": "payments",
+                    "conflict_policy": "fail", "runner_labels": ["self-hosted", "linux"],
+                    "environment_map": {"prod": "production"},
+                },
+                {"source_repo": "web", "target_repo": "web"},
+            ],
+        })
+        payments = jobs[0].build_config("a", "b")
+        web = jobs[1].build_config("a", "b")
+        assert payments.conflict_policy == "fail"
+        assert payments.runner_labels == ["self-hosted", "linux"]
+        assert payments.environment_map == {"prod": "production"}
+        assert web.conflict_policy == "skip"
+        assert web.environment_map == {}
+
+    def test_invalid_choice_rejected(self):
+        """Test that option values are validated like on the command line."""
+        with pytest.raises(ValueError, match="invalid conflict_policy"):
+            parse_pipeline({"jobs": [{
+                "source_org": "s", "target_org": "t", "source_repo": "r",
+                "target_repo": "r", "conflict_policy": "merge",
+            }]})
+
+    def test_runner_labels_must_be_a_list(self):
+        """Test type checking of list options."""
+        with pytest.raises(ValueError, match="must be a list"):
+            parse_pipeline({"jobs": [{
+                "source_org": "s", "target_org": "t", "source_repo": "r",
+                "target_repo": "r", "runner_labels": "self-hosted",
+            }]})
+
+
+    @pytest.mark.parametrize("options, message", [
+        ({"naming_pattern": "[A-Z"}, "Invalid naming pattern"),
+        ({"naming_max_length": "64"}, "must be an integer"),
+        ({"naming_reserved_prefixes": "TMP_"}, "must be a list"),
+    ])
+    def test_naming_options_validated(self, options, message):
+        """Test that naming conventions are validated when the pipeline is loaded."""
+        with pytest.raises(ValueError, match=message):
+            parse_pipeline({"jobs": [{
+                "source_org": "s", "target_org": "t", "source_repo": "r",
+                "target_repo": "r", **options,
+            }]})
+
+
+class TestSharedFirst:
+    """Test ordering and gating of shared-repository jobs."""
+
+    def _jobs(self):
+        return [
+            PipelineJob("app", {"source_repo": "app"}),
+            PipelineJob("workflows", {"source_repo": "workflows"}),
+            PipelineJob("org", {"source_repo": ".github", "org_to_org": True}),
+            PipelineJob("web", {"source_repo": "web"}, continue_on_error=True),
+        ]
+
+    def _ordered(self):
+        return order_shared_first(
+            self._jobs(), lambda job: job.options["source_repo"] == "workflows"
+        )
+
+    def test_org_then_shared_then_consumers(self):
+        """Test that relative order is kept within each group."""
+        jobs = self._ordered()
+        assert [job.name for job in jobs] == ["org", "workflows", "app", "web"]
+        assert [job.shared for job in jobs] == [True, True, False, False]
+
+    def test_shared_failure_blocks_consumers(self, temp_logger):
+        """Test that consumers don't run after a shared job fails, even with continue_on_error."""
+        jobs = self._ordered()
+        jobs[1].continue_on_error = True
+
+        def run_job(job):
+            if job.name == "workflows":
+                raise RuntimeError("boom")
+
+        results = run_pipeline(jobs, run_job, temp_logger)
+        assert [r.status for r in results] == ["succeeded", "failed", "not_run", "not_run"]
+        assert "workflows" in results[2].error
+
+    def test_consumer_failure_does_not_block_others(self, temp_logger):
+        """Test that continue_on_error still applies between consumer jobs."""
+        jobs = self._ordered()
+
+        def run_job(job):
+            if job.name == "app":
+                raise RuntimeError("boom")
+
+        jobs[2].continue_on_error = True
+        results = run_pipeline(jobs, run_job, temp_logger)
+        assert [r.status for r in results] == ["succeeded", "succeeded", "failed", "succeeded"]
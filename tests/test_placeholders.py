@@ -0,0 +1,130 @@
+"""Tests for placeholder selection module."""
+from datetime import datetime, timedelta, timezone
+import pytest
+from src.core.inventory import SecretRecord
+from src.core.placeholders import (
+    DEFAULT_PLACEHOLDER_VALUE,
+    format_placeholder_drift,
+    placeholders_from_report,
+    select_placeholder_secrets,
+    unreplaced_placeholders,
+)
+
+CREATED = datetime(2025, 6, 1, 12, 0, tzinfo=timezone.utc)
+
+
+class TestSelectPlaceholderSecrets:
+    """Test cases for select_placeholder_secrets."""
+
+    def test_mode_none_creates_nothing(self):
+        """Test that 'none' mode never creates placeholders."""
+        result = select_placeholder_secrets("none", ["A", "B"], [])
+        assert result == []
+
+    def test_mode_value_creates_all(self):
+        """Test that 'value' mode creates placeholders for every secret."""
+        result = select_placeholder_secrets("value", ["A", "B"], ["A"])
+        assert result == ["A", "B"]
+
+    def test_mode_skip_existing(self):
+        """Test that 'skip-existing' mode leaves existing target secrets alone."""
+        result = select_placeholder_secrets("skip-existing", ["A", "B", "C"], ["B"])
+        assert result == ["A", "C"]
+
+    def test_skip_existing_ignores_case(self):
+        """Test that a target secret differing only in case counts as existing."""
+        result = select_placeholder_secrets("skip-existing", ["api_key", "DB"], ["API_KEY", "db"])
+        assert result == []
+
+    def test_unknown_mode_raises(self):
+        """Test that an unknown mode is rejected."""
+        with pytest.raises(ValueError):
+            select_placeholder_secrets("always", ["A"], [])
+
+    def test_default_placeholder_value(self):
+        """Test the default placeholder value."""
+        assert DEFAULT_PLACEHOLDER_VALUE == "REPLACE_ME_LATER"
+
+
+def placeholder_event(level, name, timestamp=CREATED, **data):
+    """Build a placeholder_created entry of a JSON report."""
+    return {
+        "timestamp": timestamp.isoformat(),
+        "kind": "placeholder_created",
+        "message": f"Created placeholder for {name}",
+        "data": {"secret": name, "level": level, **data},
+    }
+
+
+class TestPlaceholdersFromReport:
+    """Test cases for placeholders_from_report."""
+
+    def test_reads_placeholder_events(self):
+        """Test that each placeholder is read with its creation time."""
+        report = {"events": [
+            {"timestamp": CREATED.isoformat(), "kind": "decision", "message": "", "data": {}},
+            placeholder_event("repo", "API_KEY"),
+            placeholder_event("env", "DB", environment="prod", target_environment="production"),
+            placeholder_event("env", "TOKEN", environment="staging"),
+            placeholder_event("org", "NPM_TOKEN"),
+        ]}
+        placeholders = placeholders_from_report(report)
+        assert [record.label for record in placeholders] == [
+            "repo:API_KEY", "env:production/DB", "env:staging/TOKEN", "org:NPM_TOKEN"
+        ]
+        assert all(record.updated_at == CREATED for record in placeholders)
+
+    def test_last_write_counts(self):
+        """Test that a placeholder written twice counts from its last write."""
+        later = CREATED + timedelta(minutes=5)
+        report = {"events": [
+            placeholder_event("repo", "API_KEY"), placeholder_event("repo", "API_KEY", later)
+        ]}
+        assert [record.updated_at for record in placeholders_from_report(report)] == [later]
+
+    def test_not_a_report(self):
+        """Test that a file without an event list is rejected."""
+        with pytest.raises(ValueError, match="no 'events' list"):
+            placeholders_from_report({"summary": {}})
+        with pytest.raises(ValueError):
+            placeholders_from_report([])
+
+
+class TestUnreplacedPlaceholders:
+    """Test cases for unreplaced_placeholders."""
+
+    def test_selects_secrets_untouched_since_creation(self):
+        """Test that only secrets not updated after their placeholder are selected."""
+        placeholders = [
+            SecretRecord("API_KEY", "repo", updated_at=CREATED),
+            SecretRecord("DB", "env", environment="prod", updated_at=CREATED),
+            SecretRecord("GONE", "repo", updated_at=CREATED),
+        ]
+        target = [
+            SecretRecord("API_KEY", "repo", updated_at=CREATED.replace(tzinfo=None)),
+            SecretRecord("DB", "env", environment="prod", updated_at=CREATED + timedelta(hours=1)),
+        ]
+        stale = unreplaced_placeholders(placeholders, target)
+        assert [record.label for record in stale] == ["repo:API_KEY"]
+
+    def test_tolerance_and_skew(self):
+        """Test that the target clock's skew is corrected and the tolerance applied."""
+        placeholders = [SecretRecord("API_KEY", "repo", updated_at=CREATED)]
+        target = [SecretRecord("API_KEY", "repo", updated_at=CREATED + timedelta(seconds=60))]
+        assert unreplaced_placeholders(placeholders, target) == []
+        assert unreplaced_placeholders(placeholders, target, tolerance=timedelta(seconds=60))
+        assert unreplaced_placeholders(placeholders, target, target_skew=timedelta(minutes=1))
+
+    def test_format_placeholder_drift(self):
+        """Test that each placeholder never replaced is listed, followed by the count."""
+        stale = [
+            SecretRecord("API_KEY", "repo"), SecretRecord("DB", "env", environment="production")
+        ]
+        assert format_placeholder_drift(stale) == [
+            "! repo:API_KEY still holds its placeholder value "
+            "(the migration workflow never set it)",
+            "! env:production/DB still holds its placeholder value "
+            "(the migration workflow never set it)",
+            "2 placeholder(s) never replaced",
+        ]
+        assert format_placeholder_drift([]) == []
@@ -0,0 +1,125 @@
+"""Tests for saved migration plans."""
+import json
+import pytest
+from src.core.config import MigrationConfig
+from src.core.plan_file import (
+    PlannedChange,
+    PlannedRun,
+    SavedPlan,
+    format_plan,
+    load_plan,
+    plan_drift,
+    save_plan,
+)
+
+
+def _options(**overrides):
+    config = MigrationConfig(
+        source_org="src", source_repo="app", target_org="dst", target_repo="app",
+        source_pat="a", target_pat="b", **overrides
+    )
+    return config.options()
+
+
+def _changes():
+    return [
+        PlannedChange("create", "repository", "dst/app", "NEW_API", "API"),
+        PlannedChange("replace", "environment", "dst/app:production", "DB", "DB"),
+        PlannedChange(
+            "skip", "repository", "dst/app", "TOKEN", "TOKEN", reason="already exists on target"
+        ),
+        PlannedChange("delete", "repository", "dst/app", "OLD", reason="not present on source"),
+    ]
+
+
+class TestPlannedChange:
+    """Test cases for single planned changes."""
+
+    def test_describe(self):
+        """Test that renames, scopes and reasons appear in the rendered line."""
+        lines = [change.describe() for change in _changes()]
+        assert lines == [
+            "+ repository dst/app NEW_API (from API)",
+            "~ environment dst/app:production DB",
+            "= repository dst/app TOKEN: already exists on target",
+            "- repository dst/app OLD: not present on source",
+        ]
+        org = PlannedChange("create", "organization", "dst", "KEY", "KEY", "selected, api, web")
+        assert org.describe() == "+ organization dst KEY [visibility: selected, api, web]"
+
+    def test_unknown_action_rejected(self):
+        """Test that only known actions and levels are accepted."""
+        with pytest.raises(ValueError, match="unknown plan action"):
+            PlannedChange("update", "repository", "dst/app", "X")
+        with pytest.raises(ValueError, match="unknown secret level"):
+            PlannedChange("create", "variable", "dst/app", "X")
+
+
+class TestPlanFile:
+    """Test cases for writing and reading plan files."""
+
+    def test_round_trip_keeps_digest(self, tmp_path):
+        """Test that a saved plan loads back unchanged, with the same digest."""
+        plan = SavedPlan([PlannedRun(_options(prune=True), _changes())])
+        path = str(tmp_path / "plan.json")
+        save_plan(path, plan)
+        loaded = load_plan(path)
+        assert loaded.to_dict() == plan.to_dict()
+        assert loaded.digest == plan.digest
+        assert loaded.runs[0].build_config("a", "b").prune is True
+
+    def test_digest_changes_with_content(self):
+        """Test that editing any change alters the digest."""
+        plan = SavedPlan([PlannedRun(_options(), _changes())], "2026-01-01T00:00:00+00:00")
+        edited = SavedPlan([PlannedRun(_options(), _changes()[:-1])], plan.created_at)
+        assert plan.digest != edited.digest
+
+    def test_credentials_rejected(self, tmp_path):
+        """Test that a plan setting a token or an unknown option is refused."""
+        data = SavedPlan([PlannedRun(_options(), [])]).to_dict()
+        data["runs"][0]["options"]["target_pat"] = "ghp_x"
+        path = tmp_path / "plan.json"
+        path.write_text(json.dumps(data))
+        with pytest.raises(ValueError, match="target_pat"):
+            load_plan(str(path))
+
+    def test_other_files_rejected(self, tmp_path):
+        """Test that files that are not plans of this version are refused."""
+        path = tmp_path / "plan.json"
+        path.write_text(json.dumps({"version": 99, "runs": []}))
+        with pytest.raises(ValueError, match="not a version 1 migration plan"):
+            load_plan(str(path))
+        path.write_text("{")
+        with pytest.raises(ValueError, match="not valid JSON"):
+            load_plan(str(path))
+
+
+class TestPlanDrift:
+    """Test cases for comparing a plan with the current state."""
+
+    def test_unchanged_plan_holds(self):
+        """Test that identical changes report no drift."""
+        assert plan_drift(_changes(), _changes()) == []
+
+    def test_differences_listed(self):
+        """Test that added, removed and altered changes are all reported."""
+        current = _changes()[1:] + [PlannedChange("create", "repository", "dst/app", "NEW", "NEW")]
+        current[0] = PlannedChange("create", "environment", "dst/app:production", "DB", "DB")
+        assert plan_drift(_changes(), current) == [
+            "no longer planned: + repository dst/app NEW_API (from API)",
+            "planned: ~ environment dst/app:production DB; "
+            "now: + environment dst/app:production DB",
+            "not in the plan: + repository dst/app NEW",
+        ]
+
+    def test_format_plan_counts(self):
+        """Test that the rendered plan lists each run and totals the actions."""
+        plan = SavedPlan([
+            PlannedRun(_options(extra_target_repos=["web"]), _changes()),
+            PlannedRun(_options(org_to_org=True), []),
+        ])
+        lines = format_plan(plan)
+        assert lines[0] == "src/app → dst/app, dst/web:"
+        assert "  (no secret changes)" in lines
+        assert "src → dst (organization secrets):" in lines
+        assert lines[-1] == "1 to create, 1 to replace, 1 to delete, 1 skipped"
@@ -0,0 +1,84 @@
+"""Tests for the secret deny/allow policy."""
+import pytest
+from src.core.policy import SecretPolicy, load_policy, parse_policy
+from src.core.workflow_generator import generate_workflow
+
+
+class TestSecretPolicy:
+    """Test cases for SecretPolicy."""
+
+    def test_empty_policy_allows_everything(self):
+        """Test that no policy restricts nothing."""
+        policy = SecretPolicy()
+        assert policy.is_empty is True
+        assert policy.allows("ANY_SECRET") is True
+
+    def test_deny_patterns(self):
+        """Test glob denial, case-insensitively."""
+        policy = SecretPolicy(deny=["*_PRIVATE_KEY", "LEGACY_TOKEN"])
+        assert policy.allows("DEPLOY_PRIVATE_KEY") is False
+        assert policy.allows("deploy_private_key") is False
+        assert policy.allows("LEGACY_TOKEN") is False
+        assert policy.allows("API_TOKEN") is True
+        assert policy.rejection_reason("SSH_PRIVATE_KEY") == "denied by policy pattern '*_PRIVATE_KEY'"
+
+    def test_allowlist_limits_migration_and_deny_wins(self):
+        """Test that a non-empty allowlist restricts, and deny beats allow."""
+        policy = SecretPolicy(deny=["APP_PRIVATE_KEY"], allow=["APP_*"])
+        assert policy.filter(["APP_TOKEN", "APP_PRIVATE_KEY", "OTHER"]) == ["APP_TOKEN"]
+        assert policy.rejection_reason("OTHER") == "not in policy allowlist"
+
+    def test_unsafe_pattern_rejected(self):
+        """Test that patterns outside the secret-name alphabet are refused."""
+        with pytest.raises(ValueError, match="Invalid policy pattern"):
+            SecretPolicy(deny=["A'; rm -rf /"])
+
+
+class TestParsePolicy:
+    """Test cases for policy documents."""
+
+    def test_parse_document(self):
+        """Test parsing deny and allow lists."""
+        policy = parse_policy({"deny": ["*_KEY"], "allow": ["APP_*"]})
+        assert policy.deny == ["*_KEY"]
+        assert policy.allow == ["APP_*"]
+
+    def test_empty_document(self):
+        """Test that an empty file yields an empty policy."""
+        assert parse_policy(None).is_empty is True
+
+    def test_unknown_key_rejected(self):
+        """Test that typos in the policy file are reported."""
+        with pytest.raises(ValueError, match="unknown key"):
+            parse_policy({"denylist": ["*_KEY"]})
+
+    def test_non_list_rejected(self):
+        """Test that a scalar instead of a list is reported."""
+        with pytest.raises(ValueError, match="must be a list"):
+            parse_policy({"deny": "*_KEY"})
+
+    def test_load_policy(self, tmp_path):
+        """Test loading a policy from YAML."""
+        path = tmp_path / "policy.yml"
+        path.write_text("deny:\n  - '*_PRIVATE_KEY'\n")
+        assert load_policy(str(path)).deny == ["*_PRIVATE_KEY"]
+
+
+class TestWorkflowPolicyFilter:
+    """Test that the policy is injected into the generated workflow."""
+
+    def test_patterns_injected_into_repo_step(self):
+        """Test that the repository step re-checks secrets against the policy."""
+        workflow = generate_workflow(
+            "src-org", "src-repo", "tgt-org", "tgt-repo", "migrate-secrets",
+            policy=SecretPolicy(deny=["*_PRIVATE_KEY"], allow=["APP_*", "DB_*"])
+        )
+        assert "DENY_PATTERNS: '*_PRIVATE_KEY'" in workflow
+        assert "ALLOW_PATTERNS: 'APP_* DB_*'" in workflow
+        assert 'if ! policy_allows "$SECRET_NAME"; then' in workflow
+
+    def test_no_policy_injects_empty_patterns(self):
+        """Test that the filter is a no-op without a policy."""
+        workflow = generate_workflow("a", "b", "c", "d", "migrate-secrets")
+        assert "DENY_PATTERNS: ''" in workflow
+        assert "ALLOW_PATTERNS: ''" in workflow
@@ -0,0 +1,50 @@
+"""Tests for preflight quota checks."""
+from src.core.preflight import REPO_SECRET_LIMIT, SECRET_VALUE_LIMIT_BYTES, QuotaPlan
+from src.core.workflow_generator import generate_environment_secret_steps, generate_workflow
+
+
+class TestQuotaPlan:
+    """Test cases for QuotaPlan."""
+
+    def test_fits_within_limit(self):
+        """Test a migration well under the limit."""
+        plan = QuotaPlan("Repository o/r", REPO_SECRET_LIMIT, ["A"], ["A", "B"])
+        assert plan.fits is True
+        assert plan.updated == ["A"]
+        assert plan.created == ["B"]
+        assert plan.projected_count == 2
+
+    def test_existing_secrets_keep_their_slot(self):
+        """Test that overwriting existing secrets never overflows, case-insensitively."""
+        existing = [f"S{i}" for i in range(3)]
+        plan = QuotaPlan("Repository o/r", 3, existing, ["s0", "S1", "S2"])
+        assert plan.fits is True
+        assert plan.created == []
+
+    def test_overflow_in_migration_order(self):
+        """Test that secrets beyond the free slots are reported as overflow."""
+        plan = QuotaPlan("Environment 'prod'", 3, ["X", "Y"], ["A", "B", "C"])
+        assert plan.fits is False
+        assert plan.created == ["A"]
+        assert plan.overflow == ["B", "C"]
+        lines = plan.describe()
+        assert "2 secret(s) will not fit: B, C" in lines[1]
+
+    def test_target_already_over_limit(self):
+        """Test that a full target leaves no free slot."""
+        plan = QuotaPlan("Organization o", 1, ["X", "Y"], ["A"])
+        assert plan.overflow == ["A"]
+
+
+class TestWorkflowValueSizeCheck:
+    """Test that generated steps refuse oversized values."""
+
+    def test_repo_step_checks_value_size(self):
+        """Test the size guard in the repository step."""
+        workflow = generate_workflow("a", "b", "c", "d", "migrate-secrets")
+        assert f'-gt {SECRET_VALUE_LIMIT_BYTES} ]' in workflow
+
+    def test_environment_step_checks_value_size(self):
+        """Test the size guard in environment steps."""
+        steps = generate_environment_secret_steps({"prod": ["A"]}, "a", "b", "c", "d")
+        assert f"limits secret values to {SECRET_VALUE_LIMIT_BYTES} bytes" in steps
@@ -0,0 +1,61 @@
+"""Tests for progress bars."""
+import io
+from src.utils.logger import Logger
+from src.utils.progress import Progress, format_eta
+
+
+class FakeClock:
+    """Manually advanced clock."""
+
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self):
+        return self.now
+
+
+class TestFormatEta:
+    """Test cases for format_eta."""
+
+    def test_minutes_and_seconds(self):
+        """Test short durations."""
+        assert format_eta(75) == "1:15"
+
+    def test_hours(self):
+        """Test long durations."""
+        assert format_eta(3725) == "1:02:05"
+
+
+class TestProgress:
+    """Test cases for Progress."""
+
+    def test_disabled_without_tty(self):
+        """Test that nothing is drawn when the stream is not a TTY."""
+        stream = io.StringIO()
+        with Progress(3, "Secrets", stream=stream) as progress:
+            progress.advance("A")
+        assert stream.getvalue() == ""
+
+    def test_renders_count_and_eta(self):
+        """Test the bar text with an ETA extrapolated from elapsed time."""
+        clock = FakeClock()
+        progress = Progress(4, "Secrets", stream=io.StringIO(), enabled=True, clock=clock)
+        clock.now = 10.0
+        progress.advance("DB_PASSWORD")
+        line = progress.render_line()
+        assert line.startswith("Secrets [######")
+        assert "1/4  25%" in line
+        assert "ETA 0:30" in line
+        assert line.endswith("DB_PASSWORD")
+
+    def test_logger_lines_do_not_overwrite_bar(self, capsys):
+        """Test that the logger clears and redraws an attached bar."""
+        stream = io.StringIO()
+        logger = Logger()
+        with Progress(2, "Jobs", logger, stream=stream, enabled=True) as progress:
+            logger.info("working")
+            progress.advance("job-1")
+        assert "working" in capsys.readouterr().out
+        assert stream.getvalue().count("\r\033[K") >= 3
+        assert stream.getvalue().endswith("\n")
+        assert logger._progress == []
@@ -0,0 +1,91 @@
+"""Whole-run regression tests replaying recorded GitHub API cassettes (see tests/cassette.py)."""
+import os
+import pytest
+from src.clients.github import GitHubClient
+from src.migrator import MigrationOptions, migrate
+from src.utils.logger import Logger
+from tests.cassette import CASSETTE_DIR, Cassette, recording, use_cassette
+
+REPO_TO_REPO = CASSETTE_DIR / "repo_to_repo.json"
+# Written by hand from GitHub's documented responses (see its meta), not recorded
+TARGET_SECRETS = CASSETTE_DIR / "target_secrets.json"
+# Where a recording runs, read from the environment (replays take them from the cassette)
+_RECORD_META = {
+    "source_org": "CASSETTE_SOURCE_ORG",
+    "source_repo": "CASSETTE_SOURCE_REPO",
+    "target_org": "CASSETTE_TARGET_ORG",
+    "target_repo": "CASSETTE_TARGET_REPO",
+}
+
+
+def _open_cassette(path):
+    """Return the cassette to replay, or a new one to record.
+
+    Without PyGithub nothing can be replayed, and recording needs credentials;
+    both skip. A cassette missing on replay fails the test.
+    """
+    pytest.importorskip("github.Requester")
+    if recording():
+        missing = [name for name in list(_RECORD_META.values())
+                   + ["SOURCE_GITHUB_TOKEN", "TARGET_GITHUB_TOKEN"] if not os.environ.get(name)]
+        if missing:
+            pytest.skip(f"recording needs {', '.join(missing)}")
+        return Cassette(path, {key: os.environ[name] for key, name in _RECORD_META.items()})
+    if not path.exists():
+        pytest.fail(f"{path.name} is missing; record it (see tests/cassette.py)")
+    return Cassette.load(path)
+
+
+def _tokens():
+    if recording():
+        return os.environ["SOURCE_GITHUB_TOKEN"], os.environ["TARGET_GITHUB_TOKEN"]
+    return "replayed-source-token", "replayed-target-token"
+
+
+class TestRecordedTargetSecrets:
+    """Test cases for the client's target secret reads and writes against a cassette."""
+
+    def test_list_and_write(self, monkeypatch):
+        """Test listing repository and environment secrets, then sealing and writing one."""
+        pytest.importorskip("github.Requester")
+        cassette = Cassette.load(TARGET_SECRETS)
+        monkeypatch.setattr("time.sleep", lambda seconds: None)
+        org, repo = cassette.meta["target_org"], cassette.meta["target_repo"]
+        with use_cassette(cassette):
+            client = GitHubClient("replayed-target-token", Logger(verbose=False))
+            assert client.list_repo_secrets(org, repo) == ["DB_PASSWORD", "LEGACY_TOKEN"]
+            assert client.list_all_environments_with_secrets(org, repo, strict=True) == {
+                "production": ["DEPLOY_KEY"]
+            }
+            client.create_repo_secret(org, repo, "API_KEY", "s3cr3t")
+        assert cassette.unused == []
+
+
+class TestRecordedRepoToRepo:
+    """Test cases for a repository-to-repository run against recorded responses."""
+
+    @pytest.mark.xfail(
+        not REPO_TO_REPO.exists() and not recording(), strict=True,
+        reason="repo_to_repo.json has not been recorded against sandbox organizations yet"
+    )
+    def test_run(self, tmp_path, monkeypatch):
+        """Test the full run: branch creation, placeholder secrets and the workflow push."""
+        cassette = _open_cassette(REPO_TO_REPO)
+        monkeypatch.chdir(tmp_path)  # run state stays out of the checkout
+        monkeypatch.setattr("time.sleep", lambda seconds: None)
+        options = MigrationOptions(
+            cassette.meta["source_org"], cassette.meta["source_repo"],
+            cassette.meta["target_org"], cassette.meta["target_repo"],
+            placeholder_mode="value",
+        )
+        with use_cassette(cassette, record=recording()):
+            result = migrate(options, *_tokens())
+
+        assert result.succeeded, result.error
+        kinds = [event.kind for event in result.events]
+        assert "placeholder_created" in kinds
+        assert "workflow_pushed" in kinds
+        assert kinds[-1] == "run_completed"
+        pushed = next(event for event in result.events if event.kind == "workflow_pushed")
+        assert pushed.data["branch"].startswith("migrate-secrets")
+        assert cassette.unused == []  # the run made every call it made when recorded
@@ -0,0 +1,96 @@
+"""Tests for Rego policy decisions."""
+import json
+import pytest
+from src.core.rego import ALLOW, DENY, RENAME, REGO_QUERY, RegoPolicy, parse_decision, secret_input
+
+
+class Completed:
+    """Stand-in for subprocess.CompletedProcess."""
+
+    def __init__(self, stdout="", returncode=0, stderr=""):
+        self.stdout = stdout
+        self.returncode = returncode
+        self.stderr = stderr
+
+
+def opa_output(value):
+    return json.dumps({"result": [{"expressions": [{"value": value, "text": REGO_QUERY}]}]})
+
+
+class TestParseDecision:
+    """Test cases for parse_decision."""
+
+    def test_string_decisions(self):
+        """Test the short 'allow'/'deny' forms."""
+        assert parse_decision("allow").action == ALLOW
+        assert parse_decision("deny").action == DENY
+
+    def test_rename(self):
+        """Test a rename object with its reason."""
+        decision = parse_decision({"action": "rename", "name": "LEGACY_KEY", "reason": "standard"})
+        assert decision.action == RENAME
+        assert (decision.name, decision.reason) == ("LEGACY_KEY", "standard")
+
+    def test_undefined_fails_closed(self):
+        """Test that a policy without a decision denies the secret."""
+        decision = parse_decision(None)
+        assert decision.action == DENY
+        assert decision.reason == "policy returned no decision"
+
+    @pytest.mark.parametrize("value, message", [
+        ("skip", "unknown decision action"),
+        (["allow"], "must be a string or an object"),
+        ({"action": "rename"}, "name is missing"),
+        ({"action": "rename", "name": "GITHUB_X"}, "GITHUB_ prefix"),
+    ])
+    def test_malformed_decisions(self, value, message):
+        """Test that malformed decisions are rejected."""
+        with pytest.raises(ValueError, match=message):
+            parse_decision(value)
+
+
+class TestRegoPolicy:
+    """Test cases for RegoPolicy."""
+
+    def test_evaluate_passes_input_to_opa(self):
+        """Test the opa invocation and that its result is parsed."""
+        calls = []
+
+        def run(argv, **kwargs):
+            calls.append((argv, json.loads(kwargs["input"])))
+            return Completed(opa_output({"action": "deny", "reason": "prod keys stay"}))
+
+        policy = RegoPolicy("policy.rego", opa="/usr/bin/opa", run=run)
+        decision = policy.evaluate(
+            secret_input("PROD_KEY", "environment", "src", "dst", "app", "app2", "production")
+        )
+        assert (decision.action, decision.reason) == (DENY, "prod keys stay")
+        argv, sent = calls[0]
+        assert argv[:2] == ["/usr/bin/opa", "eval"]
+        assert argv[-3:] == ["--data", "policy.rego", REGO_QUERY]
+        assert sent == {
+            "secret": {"name": "PROD_KEY", "level": "environment", "environment": "production"},
+            "source": {"org": "src", "repo": "app"},
+            "target": {"org": "dst", "repo": "app2"},
+        }
+
+    def test_undefined_result(self):
+        """Test that opa's empty output for an undefined rule denies."""
+        policy = RegoPolicy("policy.rego", opa="opa", run=lambda argv, **kwargs: Completed("{}"))
+        assert policy.evaluate(secret_input("A", "organization", "src", "dst")).action == DENY
+
+    def test_opa_errors(self):
+        """Test that opa failures and garbage output are reported."""
+        error = Completed(returncode=1, stderr="1 error occurred: rego_parse_error")
+        failing = RegoPolicy("policy.rego", opa="opa", run=lambda argv, **kwargs: error)
+        with pytest.raises(ValueError, match="opa eval failed: 1 error occurred"):
+            failing.evaluate({})
+        garbage = RegoPolicy("policy.rego", opa="opa", run=lambda argv, **kwargs: Completed("oops"))
+        with pytest.raises(ValueError, match="unexpected output"):
+            garbage.evaluate({})
+
+    def test_missing_opa(self, monkeypatch):
+        """Test that a missing opa binary is reported up front."""
+        monkeypatch.setenv("PATH", "")
+        with pytest.raises(ValueError, match="opa CLI is required"):
+            RegoPolicy("policy.rego")
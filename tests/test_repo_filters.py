@@ -0,0 +1,41 @@
+"""Tests for org-wide repository discovery filters."""
+from src.core.repo_filters import RepoFilter, RepoInfo
+
+REPOS = [
+    RepoInfo("payments-api", visibility="internal", language="Go", topics=["payments", "wave-1"]),
+    RepoInfo("payments-web", visibility="public", language="TypeScript", topics=["payments"]),
+    RepoInfo("payments-old", archived=True, language="Go", topics=["wave-1"]),
+    RepoInfo("payments-fork", fork=True, language="Go", topics=["wave-1"]),
+    RepoInfo("infra", language="", topics=["Wave-1"]),
+]
+
+
+class TestRepoFilter:
+    """Test cases for RepoFilter."""
+
+    def test_default_skips_archived_only(self):
+        """Test that without criteria every non-archived repository is kept."""
+        repo_filter = RepoFilter()
+        assert repo_filter.is_empty
+        assert repo_filter.select(REPOS) == [
+            "payments-api", "payments-web", "payments-fork", "infra"
+        ]
+        assert repo_filter.rejection(REPOS[2]) == "archived"
+
+    def test_forks_and_topics(self):
+        """Test that forks can be left out and topics match case-insensitively."""
+        repo_filter = RepoFilter(exclude_forks=True, topics=["wave-1"])
+        assert not repo_filter.is_empty
+        assert repo_filter.select(REPOS) == ["payments-api", "infra"]
+        assert repo_filter.rejection(REPOS[3]) == "fork"
+        assert repo_filter.rejection(REPOS[1]) == "no matching topic"
+
+    def test_visibility_language_and_name(self):
+        """Test that every criterion given must match, with any value within one."""
+        repo_filter = RepoFilter(
+            visibilities=["internal", "private"], languages=["go"], name_patterns=["PAYMENTS-*"]
+        )
+        assert repo_filter.select(REPOS) == ["payments-api", "payments-fork"]
+        assert repo_filter.rejection(REPOS[1]) == "visibility public"
+        assert RepoFilter(languages=["go"]).rejection(REPOS[4]) == "language none"
+        assert RepoFilter(name_patterns=["pay*-web"]).select(REPOS) == ["payments-web"]
@@ -0,0 +1,46 @@
+"""Tests for repository references given as positional arguments."""
+import pytest
+from src.core.repo_refs import RepoRef, api_base_url, parse_repo_ref
+
+
+class TestParseRepoRef:
+    """Test cases for parse_repo_ref."""
+
+    @pytest.mark.parametrize("value,expected", [
+        ("acme/app", RepoRef("github.com", "acme", "app")),
+        ("ghes.example.com/acme/app", RepoRef("ghes.example.com", "acme", "app")),
+        ("https://github.com/acme/app", RepoRef("github.com", "acme", "app")),
+        ("https://ghes.example.com/acme/app.git", RepoRef("ghes.example.com", "acme", "app")),
+        ("https://GitHub.com/acme/app/tree/main/src", RepoRef("github.com", "acme", "app")),
+        ("https://www.github.com/acme/app/", RepoRef("github.com", "acme", "app")),
+        ("git@ghes.example.com:acme/app.git", RepoRef("ghes.example.com", "acme", "app")),
+        ("ssh://git@github.com/acme/app.git", RepoRef("github.com", "acme", "app")),
+        ("acme", RepoRef("github.com", "acme", "")),
+        ("https://github.com/acme", RepoRef("github.com", "acme", "")),
+    ])
+    def test_forms(self, value, expected):
+        """Test the accepted reference forms."""
+        assert parse_repo_ref(value) == expected
+
+    def test_default_host(self):
+        """Test that references without a host take the default one."""
+        assert parse_repo_ref("acme/app", default_host="").host == ""
+        assert parse_repo_ref("acme/app", "ghes.example.com").host == "ghes.example.com"
+
+    @pytest.mark.parametrize("value", [
+        "", "a/b/c/d", "acme/my app", "ftp://github.com/acme/app", "https://github.com/", "acme/..",
+    ])
+    def test_invalid(self, value):
+        """Test that malformed references are rejected."""
+        with pytest.raises(ValueError, match="expected OWNER/REPO"):
+            parse_repo_ref(value)
+
+
+class TestApiBaseUrl:
+    """Test cases for api_base_url."""
+
+    def test_hosts(self):
+        """Test the REST API root of github.com, GHE.com tenants and GHES appliances."""
+        assert api_base_url("github.com") == "https://api.github.com"
+        assert api_base_url("acme.ghe.com") == "https://api.acme.ghe.com"
+        assert api_base_url("ghes.example.com") == "https://ghes.example.com/api/v3"
@@ -0,0 +1,68 @@
+"""Tests for retry helpers."""
+import pytest
+from src.utils.retry import is_not_found_error, retry_on_not_found
+
+
+class NotFound(Exception):
+    """Stand-in for a GitHub 404 exception."""
+
+    status = 404
+
+
+class TestIsNotFoundError:
+    """Test cases for is_not_found_error."""
+
+    def test_status_attribute(self):
+        """Test detection via the status attribute."""
+        assert is_not_found_error(NotFound()) is True
+
+    def test_message(self):
+        """Test detection via the error message."""
+        assert is_not_found_error(Exception('404 {"message": "Not Found"}')) is True
+
+    def test_other_errors(self):
+        """Test that other errors are not treated as 404."""
+        assert is_not_found_error(Exception("403 Forbidden")) is False
+
+
+class TestRetryOnNotFound:
+    """Test cases for retry_on_not_found."""
+
+    def test_returns_after_transient_404s(self):
+        """Test that the call succeeds once the resource becomes visible."""
+        calls = []
+        delays = []
+
+        def operation():
+            calls.append(1)
+            if len(calls) < 3:
+                raise NotFound()
+            return "ok"
+
+        assert retry_on_not_found(operation, sleep=delays.append) == "ok"
+        assert len(calls) == 3
+        assert delays == [1.0, 2.0]
+
+    def test_backoff_is_bounded(self):
+        """Test that delays never exceed max_delay and attempts are bounded."""
+        delays = []
+
+        def operation():
+            raise NotFound()
+
+        with pytest.raises(NotFound):
+            retry_on_not_found(
+                operation, attempts=5, base_delay=2.0, max_delay=5.0, sleep=delays.append
+            )
+        assert delays == [2.0, 4.0, 5.0, 5.0]
+
+    def test_other_errors_not_retried(self):
+        """Test that non-404 errors are raised immediately."""
+        delays = []
+
+        def operation():
+            raise ValueError("boom")
+
+        with pytest.raises(ValueError):
+            retry_on_not_found(operation, sleep=delays.append)
+        assert delays == []
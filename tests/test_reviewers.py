@@ -0,0 +1,47 @@
+"""Tests for mapping environment reviewers across organizations."""
+import pytest
+from src.core.reviewers import ReviewerMap, load_reviewer_map, parse_reviewer_map
+
+
+class TestReviewerMap:
+    """Test cases for reviewer mapping."""
+
+    def test_mapped_and_same_login(self):
+        """Test that mapped names are translated and others keep their name."""
+        reviewer_map = ReviewerMap(users={"OctoCat": "octocat-emu"}, teams={"ops": "platform-ops"})
+        assert reviewer_map.map_reviewer({"user": "octocat"}) == {"user": "octocat-emu"}
+        assert reviewer_map.map_reviewer({"team": "ops"}) == {"team": "platform-ops"}
+        assert reviewer_map.map_reviewer({"user": "hubot"}) == {"user": "hubot"}
+
+    def test_duplicates_collapsed(self):
+        """Test that several sources mapping to one target yield one reviewer."""
+        reviewer_map = ReviewerMap(users={"a": "shared", "b": "shared"})
+        mapped = reviewer_map.map_reviewers([{"user": "a"}, {"user": "b"}, {"team": "a"}])
+        assert mapped == [{"user": "shared"}, {"team": "a"}]
+
+
+class TestParseReviewerMap:
+    """Test cases for reviewer map files."""
+
+    def test_load(self, tmp_path):
+        """Test loading a reviewer map from YAML."""
+        path = tmp_path / "reviewers.yml"
+        path.write_text("users:\n  alice: alice_corp\nteams:\n  sre: platform-sre\n")
+        reviewer_map = load_reviewer_map(str(path))
+        assert reviewer_map.users == {"alice": "alice_corp"}
+        assert reviewer_map.teams == {"sre": "platform-sre"}
+
+    def test_empty_document(self):
+        """Test that an empty file means the same-login heuristic only."""
+        assert parse_reviewer_map(None).map_reviewer({"user": "x"}) == {"user": "x"}
+
+    @pytest.mark.parametrize("data", [
+        ["alice"],
+        {"groups": {}},
+        {"users": {"alice": ""}},
+        {"teams": ["sre"]},
+    ])
+    def test_invalid(self, data):
+        """Test that malformed reviewer maps are rejected."""
+        with pytest.raises(ValueError):
+            parse_reviewer_map(data)
@@ -0,0 +1,43 @@
+"""Tests for sandboxed runs."""
+import pytest
+from src.core.sandbox import check_sandbox, parse_sandbox, sandbox_locations
+
+
+class TestParseSandbox:
+    """Test cases for designated sandboxes."""
+
+    def test_parse(self):
+        """Test that organizations and repositories are read, lowercased."""
+        assert parse_sandbox(" Sandbox-Org, acme/E2E-App ,") == ["sandbox-org", "acme/e2e-app"]
+        assert parse_sandbox("") == []
+
+    @pytest.mark.parametrize("value", ["acme/app/x", "/app", "acme/"])
+    def test_malformed(self, value):
+        """Test that entries other than 'org' or 'org/repo' are rejected."""
+        with pytest.raises(ValueError, match="must be 'org' or 'org/repo'"):
+            parse_sandbox(value)
+
+
+class TestCheckSandbox:
+    """Test cases for keeping runs inside their sandboxes."""
+
+    def test_locations(self):
+        """Test what repository and organization runs write to."""
+        assert sandbox_locations("src", "app", "dst", ["app", "app-2"], False) == [
+            "src/app", "dst/app", "dst/app-2"
+        ]
+        assert sandbox_locations("src", "app", "dst", ["app"], True) == ["src", "dst"]
+
+    def test_inside(self):
+        """Test that designated organizations cover their repositories, case-insensitively."""
+        check_sandbox(["Sandbox/app", "acme/e2e-app"], ["sandbox", "acme/e2e-app"])
+
+    def test_outside(self):
+        """Test that a run touching an undesignated repository or organization is refused."""
+        with pytest.raises(ValueError, match="refuses to touch acme/app, acme:"):
+            check_sandbox(["sandbox/app", "acme/app", "acme"], ["sandbox", "acme/e2e-app"])
+
+    def test_nothing_designated(self):
+        """Test that --sandbox without designated sandboxes refuses every run."""
+        with pytest.raises(ValueError, match="needs GH_SECRETS_MIGRATOR_SANDBOX"):
+            check_sandbox(["sandbox/app"], [])
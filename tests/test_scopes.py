@@ -0,0 +1,54 @@
+"""Tests for organization secret scopes."""
+import pytest
+from src.core.scopes import OrgSecretScope, scope_discrepancies
+from src.core.workflow_generator import generate_org_secret_steps
+
+
+class TestOrgSecretScope:
+    """Test cases for OrgSecretScope."""
+
+    def test_selected_repositories_sorted_and_unique(self):
+        """Test that selected repositories are normalized."""
+        scope = OrgSecretScope("selected", ["b", "a", "b"])
+        assert scope.repositories == ["a", "b"]
+
+    def test_repositories_ignored_for_other_visibilities(self):
+        """Test that only 'selected' keeps a repository list."""
+        assert OrgSecretScope("all", ["a"]).repositories == []
+
+    def test_unknown_visibility_rejected(self):
+        """Test that invalid visibilities are rejected."""
+        with pytest.raises(ValueError):
+            OrgSecretScope("public")
+
+
+class TestScopeDiscrepancies:
+    """Test cases for scope_discrepancies."""
+
+    def test_in_sync(self):
+        """Test matching scopes."""
+        assert scope_discrepancies(["a", "b"], ["b", "a"]) == {"missing": [], "unexpected": []}
+
+    def test_partial_apply(self):
+        """Test a partially applied scope."""
+        result = scope_discrepancies(["a", "b", "c"], ["a", "d"])
+        assert result == {"missing": ["b", "c"], "unexpected": ["d"]}
+
+
+class TestOrgSecretStepsWithScopes:
+    """Test scope handling in generated org secret steps."""
+
+    def test_selected_scope_is_applied_and_verified(self):
+        """Test that selected visibility is set and read back."""
+        steps = generate_org_secret_steps(
+            ["TOKEN"], "target-org", scopes={"TOKEN": OrgSecretScope("selected", ["app", "api"])}
+        )
+        assert "VISIBILITY: 'selected'" in steps
+        assert "SELECTED_REPOS: 'api,app'" in steps
+        assert "actions/secrets/$TARGET_SECRET_NAME/repositories" in steps
+        assert "for ATTEMPT in 1 2 3" in steps
+
+    def test_no_scope_keeps_default_visibility(self):
+        """Test that secrets without a known scope set no visibility."""
+        steps = generate_org_secret_steps(["TOKEN"], "target-org")
+        assert "VISIBILITY: ''" in steps
@@ -0,0 +1,59 @@
+"""Tests for the sealed-box encryption package."""
+import base64
+import pytest
+from src.sealedbox import PublicKeyCache, SecretsPublicKey, parse_public_key, sealed_secret_payload
+
+KEY = base64.b64encode(bytes(range(32))).decode("ascii")
+
+
+class TestSealedSecretPayload:
+    """Test cases for sealed_secret_payload."""
+
+    def test_value_is_sealed_once(self):
+        """Test that the target's private key recovers the plaintext in one decryption."""
+        public = pytest.importorskip("nacl.public")
+        private_key = public.PrivateKey.generate()
+        key = base64.b64encode(bytes(private_key.public_key)).decode("ascii")
+        payload = sealed_secret_payload(SecretsPublicKey("key-1", key), "s3cr3t value")
+        assert payload["key_id"] == "key-1"
+        sealed = base64.b64decode(payload["encrypted_value"])
+        assert public.SealedBox(private_key).decrypt(sealed) == b"s3cr3t value"
+
+    def test_invalid_key_refused(self):
+        """Test that nothing is sealed with a malformed key."""
+        with pytest.raises(ValueError, match="has 3 bytes, expected 32"):
+            sealed_secret_payload(SecretsPublicKey("key-1", "YWJj"), "s3cr3t value")
+
+
+class TestPublicKeys:
+    """Test cases for public key handling."""
+
+    def test_parse_response(self):
+        """Test reading a public-key endpoint response."""
+        key = parse_public_key({"key_id": "568250167242549743", "key": KEY})
+        assert key == ("568250167242549743", KEY)
+        assert key.raw == bytes(range(32))
+
+    @pytest.mark.parametrize("data, message", [
+        ({"key": KEY}, "needs 'key_id' and 'key'"),
+        ({"key_id": "1", "key": None}, "needs 'key_id' and 'key'"),
+        ({"key_id": "1", "key": "not base64!"}, "not valid base64"),
+        ({"key_id": "1", "key": KEY[:-4]}, "expected 32"),
+    ])
+    def test_malformed_responses(self, data, message):
+        """Test that malformed responses are rejected."""
+        with pytest.raises(ValueError, match=message):
+            parse_public_key(data)
+
+    def test_cache_fetches_once(self):
+        """Test that each path's key is fetched once."""
+        cache = PublicKeyCache()
+        fetched = []
+
+        def fetch():
+            fetched.append(1)
+            return SecretsPublicKey("1", KEY)
+
+        path = "/orgs/acme/actions/secrets"
+        assert cache.get(path, fetch) == cache.get(path, fetch)
+        assert len(fetched) == 1
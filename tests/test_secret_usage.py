@@ -0,0 +1,122 @@
+"""Tests for workflow secret reference scanning."""
+from src.core.inventory import SecretRecord
+from src.core.secret_usage import build_usage_report, format_usage_report, scan_workflows
+
+DEPLOY = """
+name: deploy
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    environment: production
+    steps:
+      - uses: actions/checkout@v4
+      - run: ./deploy.sh
+        env:
+          DB_PASSWORD: ${{ secrets.DB_PASSWORD }}
+          API_KEY: ${{ secrets['api_key'] }}
+          TOKEN: ${{ secrets[ "DEPLOY_TOKEN" ] }}
+"""
+
+
+class TestScanWorkflows:
+    """Test cases for scan_workflows."""
+
+    def test_reference_forms(self):
+        """Test dotted and indexed references, matched case-insensitively."""
+        usage = scan_workflows({".github/workflows/deploy.yml": DEPLOY})
+        assert sorted(usage.references) == ["API_KEY", "DB_PASSWORD", "DEPLOY_TOKEN"]
+        assert usage.references["DB_PASSWORD"] == [".github/workflows/deploy.yml"]
+        assert usage.is_used("Api_Key")
+        assert usage.wholesale == []
+
+    def test_split_keeps_order(self):
+        """Test splitting secret names into used and orphaned ones."""
+        usage = scan_workflows({".github/workflows/deploy.yml": DEPLOY})
+        names = ["OLD_TOKEN", "DB_PASSWORD", "UNUSED", "API_KEY"]
+        assert usage.split(names) == (["DB_PASSWORD", "API_KEY"], ["OLD_TOKEN", "UNUSED"])
+
+    def test_only_workflow_files_are_scanned(self):
+        """Test that actions and the migrator's own workflows are ignored."""
+        usage = scan_workflows({
+            "action.yml": "runs:\n  using: composite\n# ${{ secrets.IN_ACTION }}\n",
+            ".github/workflows/migrate-secrets.yml": "run: echo '${{ toJSON(secrets) }}'\n",
+        })
+        assert usage.references == {}
+        assert usage.wholesale == []
+
+    def test_wholesale_usage(self):
+        """Test workflows that hand every secret to code the scan cannot see."""
+        remote = (
+            "jobs:\n  call:\n    uses: org/shared/.github/workflows/build.yml@main\n"
+            "    secrets: inherit\n"
+        )
+        local = "jobs:\n  call:\n    uses: ./.github/workflows/build.yml\n    secrets: inherit\n"
+        dump = "jobs:\n  dump:\n    steps:\n      - run: echo '${{ toJson(secrets) }}'\n"
+        usage = scan_workflows({
+            ".github/workflows/remote.yml": remote,
+            ".github/workflows/local.yml": local,
+            ".github/workflows/dump.yaml": dump,
+        })
+        assert usage.wholesale == [".github/workflows/dump.yaml", ".github/workflows/remote.yml"]
+
+
+class TestUsageReport:
+    """Test cases for build_usage_report and format_usage_report."""
+
+    def _report(self):
+        build = "steps:\n  - run: npm publish\n    env:\n      T: ${{ secrets.NPM_TOKEN }}\n"
+        app = {
+            ".github/workflows/deploy.yml": DEPLOY,
+            ".github/workflows/build.yml": build + "      G: ${{ secrets.GITHUB_TOKEN }}\n",
+        }
+        records = [
+            SecretRecord("OLD_TOKEN", "repo"),
+            SecretRecord("DB_PASSWORD", "repo"),
+            SecretRecord("API_KEY", "env", environment="production"),
+        ]
+        return build_usage_report(
+            "acme",
+            {"app": (records, scan_workflows(app)), "docs": ([], scan_workflows({}))},
+            ["NPM_TOKEN", "UNUSED_ORG"],
+        )
+
+    def test_secrets_and_consumers(self):
+        """Test repository, environment and organization secret consumers."""
+        report = self._report()
+        app = report["repositories"][0]
+        assert app["repo"] == "app"
+        assert [(s["secret"], s["workflows"]) for s in app["secrets"]] == [
+            ("API_KEY", [".github/workflows/deploy.yml"]),
+            ("DB_PASSWORD", [".github/workflows/deploy.yml"]),
+            ("OLD_TOKEN", []),
+        ]
+        assert app["secrets"][0]["environment"] == "production"
+        assert report["organization_secrets"] == [
+            {"secret": "NPM_TOKEN", "consumers": [
+                {"repo": "app", "workflows": [".github/workflows/build.yml"]}
+            ]},
+            {"secret": "UNUSED_ORG", "consumers": []},
+        ]
+
+    def test_unresolved_references(self):
+        """Test references to secrets defined nowhere (GITHUB_TOKEN excluded)."""
+        app = self._report()["repositories"][0]
+        assert app["unresolved"] == [
+            {"secret": "DEPLOY_TOKEN", "workflows": [".github/workflows/deploy.yml"]}
+        ]
+
+    def test_format(self):
+        """Test the text rendering."""
+        assert format_usage_report(self._report()) == [
+            "acme/app",
+            "  env:production/API_KEY: .github/workflows/deploy.yml",
+            "  DB_PASSWORD: .github/workflows/deploy.yml",
+            "  OLD_TOKEN: unused",
+            "  ? DEPLOY_TOKEN (not defined): .github/workflows/deploy.yml",
+            "acme/docs",
+            "  (no secrets)",
+            "acme (organization secrets)",
+            "  NPM_TOKEN: app (.github/workflows/build.yml)",
+            "  UNUSED_ORG: unused",
+        ]
@@ -0,0 +1,52 @@
+"""Tests for shared workflow and action detection."""
+from src.core.shared_repos import is_composite_action, is_reusable_workflow, shared_automation
+
+
+class TestIsReusableWorkflow:
+    """Test cases for reusable workflow detection."""
+
+    def test_mapping_trigger(self):
+        """Test a workflow_call trigger with inputs and secrets."""
+        text = "on:\n  workflow_call:\n    secrets:\n      TOKEN:\n        required: true\n"
+        assert is_reusable_workflow(text)
+
+    def test_string_and_list_triggers(self):
+        """Test the short trigger forms."""
+        assert is_reusable_workflow("on: workflow_call\n")
+        assert is_reusable_workflow("on: [push, workflow_call]\n")
+
+    def test_regular_workflow(self):
+        """Test that push-only and invalid workflows are not shared."""
+        assert not is_reusable_workflow("on: [push]\njobs: {}\n")
+        assert not is_reusable_workflow("on: [unclosed\n")
+
+
+class TestIsCompositeAction:
+    """Test cases for composite action detection."""
+
+    def test_composite(self):
+        """Test a composite action."""
+        assert is_composite_action("name: x\nruns:\n  using: composite\n  steps: []\n")
+
+    def test_other_actions(self):
+        """Test that JavaScript and Docker actions are ignored."""
+        assert not is_composite_action("runs:\n  using: node20\n  main: index.js\n")
+        assert not is_composite_action("runs: docker\n")
+
+
+class TestSharedAutomation:
+    """Test cases for shared_automation."""
+
+    def test_paths(self):
+        """Test that only shared files are reported, sorted by path."""
+        files = {
+            ".github/workflows/ci.yml": "on: push\n",
+            ".github/workflows/deploy.yml": "on: workflow_call\n",
+            "setup/action.yml": "runs:\n  using: composite\n",
+            "action.yaml": "runs:\n  using: node20\n",
+        }
+        assert shared_automation(files) == [".github/workflows/deploy.yml", "setup/action.yml"]
+
+    def test_workflow_outside_workflows_dir(self):
+        """Test that only .github/workflows files count as workflows."""
+        assert shared_automation({"docs/example.yml": "on: workflow_call\n"}) == []
@@ -0,0 +1,47 @@
+"""Tests for cancellation signal handling."""
+import os
+import signal
+import time
+import pytest
+from src.utils.signals import Cancelled, Deadline, install_signal_handlers
+
+
+class TestSignals:
+    """Test cases for SIGTERM handling."""
+
+    def test_sigterm_raises_cancelled(self):
+        """Test that SIGTERM is turned into a KeyboardInterrupt subclass."""
+        previous = signal.getsignal(signal.SIGTERM)
+        try:
+            install_signal_handlers()
+            with pytest.raises(KeyboardInterrupt) as excinfo:
+                os.kill(os.getpid(), signal.SIGTERM)
+            assert isinstance(excinfo.value, Cancelled)
+        finally:
+            signal.signal(signal.SIGTERM, previous)
+
+
+class TestDeadline:
+    """Test cases for the overall run timeout."""
+
+    def test_expiry_interrupts_main_thread(self):
+        """Test that an expired deadline raises KeyboardInterrupt and is flagged."""
+        deadline = Deadline(0.05)
+        with pytest.raises(KeyboardInterrupt):
+            with deadline:
+                end = time.time() + 5
+                while time.time() < end:
+                    time.sleep(0.01)
+        assert deadline.expired
+
+    def test_finished_in_time(self):
+        """Test that a block finishing before the deadline is not interrupted."""
+        with Deadline(5) as deadline:
+            pass
+        assert not deadline.expired
+
+    def test_zero_disables(self):
+        """Test that a limit of 0 never starts a timer."""
+        with Deadline(0) as deadline:
+            time.sleep(0.01)
+        assert not deadline.expired
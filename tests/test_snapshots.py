@@ -0,0 +1,54 @@
+"""Tests for pre-write target inventory snapshots."""
+import json
+from datetime import datetime, timezone
+from src.core.inventory import SecretRecord
+from src.core.scopes import OrgSecretScope
+from src.core.snapshots import build_snapshot, snapshot_path, write_snapshot
+
+TAKEN_AT = datetime(2026, 3, 1, 12, 30, 5, tzinfo=timezone.utc)
+
+
+class TestBuildSnapshot:
+    """Test cases for snapshot documents."""
+
+    def test_repository_snapshot(self):
+        """Test that repository and environment secrets are recorded by name and level."""
+        records = [
+            SecretRecord("TOKEN", "repo", updated_at=TAKEN_AT),
+            SecretRecord("DB", "env", environment="prod"),
+        ]
+        snapshot = build_snapshot("org/app", records, ["prod", "dev"], taken_at=TAKEN_AT)
+        assert snapshot["target"] == "org/app"
+        assert snapshot["environments"] == ["dev", "prod"]
+        assert snapshot["secrets"] == [
+            {"name": "DB", "level": "env", "environment": "prod"},
+            {"name": "TOKEN", "level": "repo", "updated_at": TAKEN_AT.isoformat()},
+        ]
+
+    def test_org_scopes(self):
+        """Test that selected repositories are captured for 'selected' org secrets."""
+        records = [
+            SecretRecord("A", "org", visibility="selected"),
+            SecretRecord("B", "org", visibility="all"),
+        ]
+        scopes = {"A": OrgSecretScope("selected", ["web", "api"])}
+        snapshot = build_snapshot("org", records, [], scopes, taken_at=TAKEN_AT)
+        assert snapshot["secrets"][0]["selected_repositories"] == ["api", "web"]
+        assert "selected_repositories" not in snapshot["secrets"][1]
+
+
+class TestWriteSnapshot:
+    """Test cases for snapshot files."""
+
+    def test_path_layout(self):
+        """Test the per-target, timestamped layout."""
+        path = snapshot_path("state", "my-org/my repo", TAKEN_AT)
+        assert path.replace("\\", "/") == "state/snapshots/my-org__my_repo/20260301T123005000000Z.json"
+
+    def test_write(self, tmp_path):
+        """Test that a snapshot is written as JSON under the state directory."""
+        snapshot = build_snapshot("org/app", [SecretRecord("X", "repo")], [], taken_at=TAKEN_AT)
+        path = write_snapshot(str(tmp_path), snapshot)
+        assert path.startswith(str(tmp_path))
+        with open(path, encoding="utf-8") as handle:
+            assert json.load(handle) == snapshot
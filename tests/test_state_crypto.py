@@ -0,0 +1,98 @@
+"""Tests for encryption of state files at rest."""
+import pytest
+from src.core.ledger import MigrationLedger, load_ledger, save_ledger
+from src.core.state_crypto import (
+    AgeCipher, decode_state, encode_state, make_state_cipher, read_state, write_state
+)
+
+
+class Completed:
+    """Stand-in for subprocess.CompletedProcess."""
+
+    def __init__(self, stdout=b"", returncode=0, stderr=b""):
+        self.stdout = stdout
+        self.returncode = returncode
+        self.stderr = stderr
+
+
+def fake_age(calls):
+    """Return a run stand-in that 'encrypts' by reversing its input."""
+    def run(argv, input, **kwargs):
+        calls.append(argv)
+        return Completed(input[::-1])
+    return run
+
+
+class TestStateEncoding:
+    """Test cases for encode_state and decode_state."""
+
+    def test_plaintext_passthrough(self, tmp_path):
+        """Test that state is written as is without a cipher, and plaintext always loads."""
+        path = str(tmp_path / "state.json")
+        write_state(path, '{"a": 1}\n', None)
+        assert open(path, encoding="utf-8").read() == '{"a": 1}\n'
+        cipher = AgeCipher(["age1abc"], "key.txt", age="age", run=fake_age([]))
+        assert read_state(path, cipher) == '{"a": 1}\n'
+
+    def test_age_round_trip(self):
+        """Test that age is run with the recipients and identity."""
+        calls = []
+        cipher = AgeCipher(["age1abc", "age1def"], "key.txt", age="/usr/bin/age",
+                           run=fake_age(calls))
+        data = encode_state("secret names", cipher)
+        assert b"secret names" not in data
+        assert decode_state(data, cipher) == "secret names"
+        assert calls == [
+            ["/usr/bin/age", "--encrypt", "--recipient", "age1abc", "--recipient", "age1def"],
+            ["/usr/bin/age", "--decrypt", "--identity", "key.txt"],
+        ]
+
+    def test_encrypted_file_needs_cipher(self):
+        """Test that encrypted state is not read without the matching cipher."""
+        data = encode_state("x", AgeCipher(["age1abc"], age="age", run=fake_age([])))
+        with pytest.raises(ValueError, match="--state-age-identity"):
+            decode_state(data, None)
+
+    def test_age_errors(self):
+        """Test that age failures and missing keys are reported."""
+        error = Completed(returncode=1, stderr=b"age: error: no identity matched")
+        failing = AgeCipher(["age1abc"], "key.txt", age="age", run=lambda argv, **kwargs: error)
+        with pytest.raises(ValueError, match="age failed: age: error: no identity matched"):
+            failing.decrypt(b"x")
+        with pytest.raises(ValueError, match="recipient"):
+            AgeCipher([], "key.txt", age="age").encrypt(b"x")
+        with pytest.raises(ValueError, match="identity"):
+            AgeCipher(["age1abc"], age="age").decrypt(b"x")
+
+    def test_ledger_round_trip(self, tmp_path):
+        """Test that an encrypted ledger loads back with the same cipher."""
+        cipher = AgeCipher(["age1abc"], "key.txt", age="age", run=fake_age([]))
+        ledger = MigrationLedger("src/app")
+        ledger.track_run(7, {("repository", "dst/app", "API_KEY"): ""})
+        path = save_ledger(str(tmp_path), ledger, cipher)
+        assert b"API_KEY" not in open(path, "rb").read()
+        assert load_ledger(str(tmp_path), "src/app", cipher).to_dict() == ledger.to_dict()
+
+
+class TestMakeStateCipher:
+    """Test cases for make_state_cipher."""
+
+    def test_no_options_keep_plaintext(self):
+        """Test that no cipher is built by default."""
+        assert make_state_cipher() is None
+
+    def test_schemes_are_exclusive(self):
+        """Test that a passphrase and age keys cannot be combined."""
+        with pytest.raises(ValueError, match="not both"):
+            make_state_cipher("hunter2", ["age1abc"])
+
+    def test_passphrase_round_trip(self):
+        """Test that a passphrase decrypts what it encrypted, and only it."""
+        pytest.importorskip("nacl")
+        data = encode_state("secret names", make_state_cipher("hunter2"))
+        assert b"secret names" not in data
+        assert decode_state(data, make_state_cipher("hunter2")) == "secret names"
+        with pytest.raises(ValueError, match="wrong passphrase"):
+            decode_state(data, make_state_cipher("hunter3"))
+        with pytest.raises(ValueError, match="GH_SECRETS_MIGRATOR_STATE_PASSPHRASE"):
+            decode_state(data, None)
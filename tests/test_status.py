@@ -0,0 +1,105 @@
+"""Tests for migration status reporting."""
+from src.core.migration_state import MigrationRecord
+from src.core.status import MigrationStatus, WorkflowRunInfo, format_status
+from src.core.workflow_log import SecretOutcome
+
+RUN_URL = "https://github.com/src/app/actions/runs/7"
+
+
+def make_status(**kwargs):
+    return MigrationStatus(
+        "src/app", "migrate-secrets", ".github/workflows/migrate-secrets.yml", "dst/app", **kwargs
+    )
+
+
+class TestMigrationStatus:
+    """Test cases for MigrationStatus."""
+
+    def test_states(self):
+        """Test the state derived from the branch and the latest run."""
+        assert make_status().state == "not-started"
+        assert make_status(branch_exists=True, workflow_exists=True).state == "pushed"
+        running = make_status(branch_exists=True, run=WorkflowRunInfo(7, "in_progress"))
+        assert running.state == "in_progress"
+        assert running.active is True
+        done = make_status(run=WorkflowRunInfo(7, "completed", "success"))
+        assert done.state == "completed"
+        assert done.active is False
+
+    def test_leftovers_only_warned_when_idle(self):
+        """Test that temporary secrets and the branch are flagged once no run is active."""
+        secrets = ["SECRETS_MIGRATOR_TARGET_PAT"]
+        running = make_status(
+            branch_exists=True, temporary_secrets=secrets, run=WorkflowRunInfo(7, "queued")
+        )
+        assert running.warnings() == []
+        failed = make_status(
+            branch_exists=True, temporary_secrets=secrets,
+            run=WorkflowRunInfo(7, "completed", "cancelled")
+        )
+        warnings = failed.warnings()
+        assert len(warnings) == 2
+        assert "SECRETS_MIGRATOR_TARGET_PAT" in warnings[0]
+        assert "migration branch 'migrate-secrets'" in warnings[1]
+
+    def test_to_dict(self):
+        """Test the JSON summary."""
+        run = WorkflowRunInfo(7, "completed", "success", RUN_URL, "2024-05-01T10:00:00+00:00")
+        data = make_status(run=run, target_secrets=["repo:API_KEY"]).to_dict()
+        assert data["state"] == "completed"
+        assert data["run"] == {
+            "id": 7, "status": "completed", "conclusion": "success", "url": RUN_URL,
+            "created_at": "2024-05-01T10:00:00+00:00",
+        }
+        assert data["branch"] == {"name": "migrate-secrets", "exists": False}
+        assert data["target_secrets"] == ["repo:API_KEY"]
+        assert data["warnings"] == []
+
+
+class TestFormatStatus:
+    """Test cases for format_status."""
+
+    def test_completed_run(self):
+        """Test the text rendering of a finished migration."""
+        run = WorkflowRunInfo(7, "completed", "failure", RUN_URL, "2024-05-01T10:00:00+00:00")
+        lines = format_status(make_status(
+            run=run, temporary_secrets=["SECRETS_MIGRATOR_SOURCE_PAT"],
+            target_secrets=["env:prod/DB_PASSWORD", "repo:API_KEY"]
+        ))
+        assert lines[:7] == [
+            "Source:        src/app",
+            "Branch:        migrate-secrets (absent)",
+            f"Workflow run:  completed (failure), started 2024-05-01T10:00:00+00:00 - {RUN_URL}",
+            "State:         completed",
+            "Target:        dst/app (2 secret(s))",
+            "  - env:prod/DB_PASSWORD",
+            "  - repo:API_KEY",
+        ]
+        assert lines[7].startswith("⚠ temporary secret(s) still in src/app")
+
+    def test_pushed_without_run(self):
+        """Test a pushed workflow that has not started."""
+        lines = format_status(make_status(branch_exists=True, workflow_exists=True))
+        assert lines[1] == "Branch:        migrate-secrets (exists, workflow present)"
+        assert lines[2] == "Workflow run:  none"
+        assert lines[3] == "State:         pushed"
+
+    def test_recorded_outcomes(self):
+        """Test the outcomes of the migration recorded in the state file."""
+        recorded = MigrationRecord({}, "2024-05-01T09:59:00+00:00")
+        recorded.plan_secrets(
+            7, [("repository", "dst/app", "API_KEY"), ("repository", "dst/app", "X")]
+        )
+        recorded.record_outcomes(7, [SecretOutcome("repository", "dst/app", "API_KEY", True)])
+        recorded.finish()
+        status = make_status(run=WorkflowRunInfo(7, "completed", "success"), recorded=recorded)
+        lines = format_status(status)
+        assert lines[5:8] == [
+            "Recorded:      completed, started 2024-05-01T09:59:00+00:00 (2 secret(s) planned)",
+            "  - dst/app/API_KEY: set",
+            "  - dst/app/X: unconfirmed",
+        ]
+        assert lines[8] == "⚠ 1 secret(s) not set by the recorded run: dst/app/X"
+        assert status.to_dict()["recorded"]["secrets"][1] == {
+            "secret": "dst/app/X", "outcome": "unconfirmed"
+        }
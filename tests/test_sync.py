@@ -0,0 +1,46 @@
+"""Tests for incremental sync."""
+from datetime import datetime, timezone
+from src.core.migration_state import MigrationRecord, MigrationStateFile
+from src.core.sync import last_completed_start, updated_since
+
+SINCE = datetime(2026, 5, 1, 12, 0, tzinfo=timezone.utc)
+
+
+class TestLastCompletedStart:
+    """Test cases for finding the last recorded run a sync starts from."""
+
+    def test_latest_completed_run(self):
+        """Test that running and failed runs after the last completed one are passed over."""
+        state = MigrationStateFile("state.json", [
+            MigrationRecord({}, "2026-04-01T08:00:00+00:00", "completed"),
+            MigrationRecord({}, "2026-05-01T12:00:00+00:00", "completed"),
+            MigrationRecord({}, "2026-05-02T12:00:00+00:00", "failed"),
+            MigrationRecord({}, "2026-05-03T12:00:00+00:00", "running"),
+        ])
+        assert last_completed_start(state) == SINCE
+
+    def test_no_completed_run(self):
+        """Test that a source without a completed run has no sync point."""
+        assert last_completed_start(MigrationStateFile("state.json")) is None
+        failed = MigrationStateFile("state.json", [MigrationRecord({}, status="failed")])
+        assert last_completed_start(failed) is None
+
+
+class TestUpdatedSince:
+    """Test cases for telling changed secrets from unchanged ones."""
+
+    def test_compares_update_times(self):
+        """Test that only secrets updated before the sync point count as unchanged."""
+        assert updated_since("2026-05-01T12:30:00+00:00", SINCE)
+        assert updated_since("2026-05-01T12:00:00+00:00", SINCE)
+        assert not updated_since("2026-04-30T09:00:00+00:00", SINCE)
+
+    def test_naive_times_are_utc(self):
+        """Test that update times without an offset are read as UTC."""
+        assert not updated_since("2026-05-01T11:59:00", SINCE)
+        assert updated_since("2026-05-01T12:01:00", SINCE)
+
+    def test_unknown_times_count_as_updated(self):
+        """Test that secrets without a usable update time are always migrated."""
+        assert updated_since("", SINCE)
+        assert updated_since("yesterday", SINCE)
@@ -0,0 +1,46 @@
+"""Tests for workflow dispatch pacing."""
+import pytest
+from src.utils.throttle import DispatchThrottle
+
+
+class FakeClock:
+    """Monotonic clock advanced by the fake sleep."""
+
+    def __init__(self):
+        self.now = 100.0
+        self.sleeps = []
+
+    def __call__(self):
+        return self.now
+
+    def sleep(self, seconds):
+        self.sleeps.append(seconds)
+        self.now += seconds
+
+
+class TestDispatchThrottle:
+    """Test cases for DispatchThrottle."""
+
+    def test_dispatches_spaced_by_rate(self):
+        """Test that 10 per minute spaces dispatches 6 seconds apart, counting elapsed time."""
+        clock = FakeClock()
+        throttle = DispatchThrottle(10, clock=clock, sleep=clock.sleep)
+        assert throttle.wait() == 0
+        assert throttle.wait() == pytest.approx(6.0)
+        clock.now += 4
+        assert throttle.wait() == pytest.approx(2.0)
+        clock.now += 30
+        assert throttle.wait() == 0
+        assert clock.sleeps == [pytest.approx(6.0), pytest.approx(2.0)]
+
+    def test_zero_rate_never_waits(self):
+        """Test that the default rate disables pacing."""
+        clock = FakeClock()
+        throttle = DispatchThrottle(clock=clock, sleep=clock.sleep)
+        assert [throttle.wait() for _ in range(3)] == [0, 0, 0]
+        assert clock.sleeps == []
+
+    def test_negative_rate_rejected(self):
+        """Test that a negative rate is refused."""
+        with pytest.raises(ValueError, match="dispatch rate"):
+            DispatchThrottle(-1)
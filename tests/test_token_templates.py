@@ -0,0 +1,59 @@
+"""Tests for fine-grained PAT templates."""
+from urllib.parse import parse_qs, urlparse
+import pytest
+from src.core.token_templates import (
+    FINE_GRAINED_PAT_URL,
+    describe_permissions,
+    required_permissions,
+    token_template_url,
+)
+
+
+class TestRequiredPermissions:
+    """Test cases for required_permissions."""
+
+    def test_source_repo_mode(self):
+        """Test the extract-side permissions for repo-to-repo migrations."""
+        assert required_permissions("source") == {
+            "actions": "read",
+            "contents": "write",
+            "environments": "read",
+            "secrets": "write",
+            "workflows": "write",
+        }
+
+    def test_target_org_mode_is_minimal(self):
+        """Test that the org-to-org target token only writes org secrets."""
+        assert required_permissions("target", org_to_org=True) == {"organization_secrets": "write"}
+
+    def test_unknown_role(self):
+        """Test that an unknown role is rejected."""
+        with pytest.raises(ValueError, match="Unknown token role"):
+            required_permissions("admin")
+
+    def test_every_permission_is_explained(self):
+        """Test that each permission has a reason for display."""
+        for role in ("source", "target"):
+            for org_to_org in (False, True):
+                assert all(reason for _, _, reason in describe_permissions(role, org_to_org))
+
+
+class TestTokenTemplateUrl:
+    """Test cases for token_template_url."""
+
+    def test_url_is_prefilled(self):
+        """Test that name, owner, expiration and permissions are in the URL."""
+        url = token_template_url("target", "acme", expires_in=3)
+        assert url.startswith(FINE_GRAINED_PAT_URL + "?")
+        params = parse_qs(urlparse(url).query)
+        assert params["target_name"] == ["acme"]
+        assert params["expires_in"] == ["3"]
+        assert params["name"] == ["secrets-migrator-target-acme"]
+        assert params["administration"] == ["write"]
+        assert params["secrets"] == ["write"]
+
+    @pytest.mark.parametrize("days", [0, 367])
+    def test_expiration_bounds(self, days):
+        """Test that out-of-range expirations are rejected."""
+        with pytest.raises(ValueError, match="Expiration"):
+            token_template_url("source", "acme", expires_in=days)
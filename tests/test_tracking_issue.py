@@ -0,0 +1,41 @@
+"""Tests for the follow-up tracking issue."""
+from src.core.events import EventLog
+from src.core.tracking_issue import build_tracking_issue
+
+
+def _events():
+    events = EventLog()
+    events.emit("decision", "Secret 'DB' renamed", secret="DB", target_name="NEW_DB")
+    events.emit("decision", "Migrating 2 secret(s)", secrets=["DB", "API"], level="repo")
+    events.emit(
+        "decision", "Migrating 1 environment secret(s) across 1 environment(s)",
+        secrets=["prod/TOKEN"], level="env", environments=["prod"]
+    )
+    events.emit(
+        "placeholder_created", "Created placeholder",
+        secret="TOKEN", environment="prod", level="env"
+    )
+    events.emit("conflict", "Secret 'API' already exists on target; left untouched", secret="API")
+    events.emit("link", "Workflow run", url="https://github.com/a/app/actions/runs/1")
+    return events
+
+
+class TestBuildTrackingIssue:
+    """Test cases for build_tracking_issue."""
+
+    def test_sections(self):
+        """Test migrated secrets, placeholders and follow-ups as task items."""
+        title, body = build_tracking_issue(_events().events, "a/app", "b/app")
+        assert title == "Secrets migration from a/app: follow-up checklist"
+        assert "- [ ] Repository secret `DB` (as `NEW_DB`)" in body
+        assert "- [ ] Repository secret `API`" in body
+        assert "- [ ] Environment secret `prod/TOKEN`" in body
+        assert "- [ ] Replace the placeholder value of `prod/TOKEN`" in body
+        assert "- [ ] Secret 'API' already exists on target; left untouched" in body
+        assert "https://github.com/a/app/actions/runs/1" in body
+
+    def test_empty_run(self):
+        """Test the placeholders for empty sections."""
+        _, body = build_tracking_issue([], "a/app", "b/app")
+        assert "_No secrets were selected for migration._" in body
+        assert body.count("_None._") == 2
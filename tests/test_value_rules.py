@@ -0,0 +1,94 @@
+"""Tests for secret value rewrite rules."""
+import pytest
+from src.core.value_rules import (
+    ValueRule,
+    load_value_rules,
+    parse_value_rules,
+    resolve_value_rules,
+    rewrite_value,
+)
+
+
+class TestValueRule:
+    """Test cases for a single rule."""
+
+    def test_replace_every_occurrence(self):
+        """Test that 'replace' rewrites all occurrences, case-sensitively."""
+        rule = ValueRule(["*_URL"], "ghes.corp.example", "github.com")
+        value = "https://ghes.corp.example/a,https://ghes.corp.example/b,GHES.CORP.EXAMPLE"
+        assert rule.apply(value) == "https://github.com/a,https://github.com/b,GHES.CORP.EXAMPLE"
+
+    def test_prefix_only_at_start(self):
+        """Test that 'prefix' rewrites only the beginning of the value."""
+        rule = ValueRule(["REGISTRY_*"], "registry.corp.example/", "ghcr.io/acme/", prefix=True)
+        assert rule.apply("registry.corp.example/app:1") == "ghcr.io/acme/app:1"
+        assert rule.apply("mirror/registry.corp.example/app") == "mirror/registry.corp.example/app"
+
+    def test_name_patterns_case_insensitive(self):
+        """Test that secret names match globs case-insensitively."""
+        rule = ValueRule(["*_URL", "ENDPOINT"], "a", "b")
+        assert rule.matches("api_url") is True
+        assert rule.matches("ENDPOINT") is True
+        assert rule.matches("URL_LIST") is False
+
+    def test_rules_apply_in_order(self):
+        """Test that each rule sees the previous rule's result."""
+        rules = [
+            ValueRule(["*"], "http://", "https://", prefix=True),
+            ValueRule(["*"], "https://old", "https://new"),
+        ]
+        assert rewrite_value("http://old.example", rules) == "https://new.example"
+
+
+class TestParseValueRules:
+    """Test cases for value rules documents."""
+
+    def test_parse_document(self):
+        """Test parsing both kinds of rules."""
+        rules = parse_value_rules({"rules": [
+            {"secrets": ["*_URL"], "replace": "ghes.corp.example", "with": "github.com"},
+            {"secrets": "REGISTRY", "prefix": "old/", "with": ""},
+        ]})
+        assert [rule.to_dict() for rule in rules] == [
+            {"from": "ghes.corp.example", "to": "github.com", "prefix": False},
+            {"from": "old/", "to": "", "prefix": True},
+        ]
+        assert rules[1].secrets == ["REGISTRY"]
+        assert rules[0].describe() == "replace 'ghes.corp.example' with 'github.com'"
+
+    def test_empty_document(self):
+        """Test that an empty file has no rules."""
+        assert parse_value_rules(None) == []
+
+    @pytest.mark.parametrize("data, message", [
+        (["a"], "must be a mapping"),
+        ({"rule": []}, "unknown key"),
+        ({"rules": [{"secrets": ["A"], "replace": "x"}]}, "needs 'with'"),
+        ({"rules": [{"secrets": ["A"], "with": "x"}]}, "exactly one of"),
+        ({"rules": [{"secrets": ["A"], "replace": "x", "prefix": "y", "with": "z"}]}, "exactly"),
+        ({"rules": [{"secrets": ["A"], "replace": "", "with": "z"}]}, "non-empty"),
+        ({"rules": [{"replace": "x", "with": "y"}]}, "needs 'secrets'"),
+        ({"rules": [{"secrets": ["A B"], "replace": "x", "with": "y"}]}, "Invalid pattern"),
+        ({"rules": [{"secrets": ["A"], "replace": "x", "with": "y", "regex": 1}]}, "unknown key"),
+    ])
+    def test_malformed_documents(self, data, message):
+        """Test that malformed documents are rejected."""
+        with pytest.raises(ValueError, match=message):
+            parse_value_rules(data)
+
+    def test_load_file(self, tmp_path):
+        """Test loading rules from YAML."""
+        path = tmp_path / "rules.yml"
+        path.write_text("rules:\n  - secrets: ['*_URL']\n    replace: a.test\n    with: b.test\n")
+        assert load_value_rules(str(path))[0].replacement == "b.test"
+
+
+class TestResolveValueRules:
+    """Test cases for resolving rules to secrets."""
+
+    def test_resolve(self):
+        """Test that only secrets with matching rules are listed, rules in file order."""
+        first = ValueRule(["*_URL"], "a", "b")
+        second = ValueRule(["API_*"], "c", "d")
+        resolved = resolve_value_rules([first, second], ["API_URL", "DB_URL", "TOKEN"])
+        assert resolved == {"API_URL": [first, second], "DB_URL": [first]}
@@ -1,8 +1,31 @@
 """Tests for workflow generation module."""
+import hashlib
+import json
+import os
+import shutil
+import subprocess  # nosec B404 - runs generated scripts against a fake gh
+from unittest import mock
+import pytest
+import yaml
+from src.core.destinations import DestinationPlugin
+from src.core.scopes import OrgSecretScope
+from src.core.workflow_lint import lint_workflow
+from src.core.workflow_script import MIGRATION_SCRIPT
 from src.core.workflow_generator import (
+    GH_CLI_MIN_VERSION,
+    GH_CLI_PINNED_VERSION,
+    GH_CLI_SHA256,
+    FanOutTarget,
+    chunk_secret_names,
+    check_action_ref,
     generate_environment_secret_steps,
+    generate_gh_cli_setup_step,
+    generate_gh_cli_setup_step_powershell,
     generate_org_secret_steps,
     generate_workflow,
+    gh_cli_assets,
+    parse_gh_cli_checksums,
+    workflow_trigger,
 )
 
 
@@ -86,6 +109,15 @@ class TestWorkflowGenerator:
         assert "production" in workflow
         assert "DB_PASSWORD" in workflow
 
+    def test_generate_workflow_environment_secrets_only(self):
+        """Test that repository secrets can be left out of the workflow."""
+        workflow = generate_workflow(
+            "source-org", "source-repo", "target-org", "target-repo", "migrate-secrets",
+            env_secrets={"production": ["DB_PASSWORD"]}, repo_secrets=False,
+        )
+        assert "Populate Repository Secrets" not in workflow
+        assert "DB_PASSWORD" in workflow
+
     def test_generate_workflow_with_org_secrets(self):
         """Test generating workflow with organization secrets."""
         org_secrets = ["ORG_SECRET"]
@@ -113,3 +145,659 @@ class TestWorkflowGenerator:
         assert "SECRETS_MIGRATOR_TARGET_PAT" in workflow
         assert "SECRETS_MIGRATOR_SOURCE_PAT" in workflow
         assert "always()" in workflow
+
+    def test_generate_gh_cli_setup_step_defaults(self):
+        """Test that the gh CLI setup step checks and pins versions."""
+        step = generate_gh_cli_setup_step()
+        assert "Ensure compatible gh CLI" in step
+        assert f"GH_MIN_VERSION: '{GH_CLI_MIN_VERSION}'" in step
+        assert f"GH_PINNED_VERSION: '{GH_CLI_PINNED_VERSION}'" in step
+        assert "gh --version" in step
+        assert "GITHUB_PATH" in step
+        assert "GH_PROMPT_DISABLED=1" in step
+
+    def test_gh_cli_download_is_checked_before_extracting(self):
+        """Test that the installed gh archive must match its pinned SHA-256."""
+        digests = {
+            "gh_2.45.0_linux_amd64.tar.gz": "a" * 64,
+            "gh_2.45.0_windows_amd64.zip": "b" * 64,
+            "gh_2.44.0_linux_amd64.tar.gz": "c" * 64,
+        }
+        with mock.patch.dict(GH_CLI_SHA256, digests):
+            step = generate_gh_cli_setup_step(pinned_version="2.45.0")
+            windows_step = generate_gh_cli_setup_step_powershell(pinned_version="2.45.0")
+        pinned = (
+            f"GH_PINNED_SHA256: 'gh_2.45.0_linux_amd64.tar.gz={'a' * 64} "
+            f"gh_2.45.0_windows_amd64.zip={'b' * 64}'"
+        )
+        assert pinned in step
+        assert pinned in windows_step
+        assert step.index("sha256sum -c -") < step.index("tar -xzf")
+        assert step.index("shasum -a 256 -c -") < step.index("unzip -q")
+        assert windows_step.index("Get-FileHash") < windows_step.index("Expand-Archive")
+
+    def test_every_installable_gh_archive_gets_a_digest(self):
+        """Test that the checksums file gives a digest for every OS and architecture installed."""
+        # Fixture in the format of gh_<version>_checksums.txt, with made-up digests
+        assets = [
+            f"gh_{GH_CLI_PINNED_VERSION}_{name}" for name in (
+                "linux_386.tar.gz", "linux_amd64.deb", "linux_amd64.tar.gz", "linux_arm64.tar.gz",
+                "macOS_amd64.zip", "macOS_arm64.zip", "windows_amd64.msi", "windows_amd64.zip",
+                "windows_arm64.zip",
+            )
+        ]
+        checksums = "".join(
+            f"{hashlib.sha256(asset.encode()).hexdigest()}  {asset}\n" for asset in assets
+        )
+        digests = parse_gh_cli_checksums(checksums, GH_CLI_PINNED_VERSION)
+        assert sorted(digests) == sorted(gh_cli_assets(GH_CLI_PINNED_VERSION))
+        for step in (
+            generate_gh_cli_setup_step(digests=digests),
+            generate_gh_cli_setup_step_powershell(digests=digests),
+            generate_workflow("a", "b", "c", "d", "m", gh_cli_digests=digests),
+        ):
+            for asset in gh_cli_assets(GH_CLI_PINNED_VERSION):
+                assert f"{asset}={digests[asset]}" in step
+
+    def test_gh_cli_without_pinned_digest_is_not_installed(self):
+        """Test that a release without pinned digests fails instead of installing unchecked."""
+        step = generate_gh_cli_setup_step(pinned_version="9.9.9")
+        assert "GH_PINNED_SHA256: ''" in step
+        assert "No pinned SHA-256 for $GH_ASSET" in step
+        assert "No pinned SHA-256 for $Asset" in generate_gh_cli_setup_step_powershell(pinned_version="9.9.9")
+
+    def test_generate_workflow_runs_gh_cli_setup_first(self):
+        """Test that the gh CLI check runs before any migration step."""
+        workflow = generate_workflow(
+            "source-org",
+            "source-repo",
+            "target-org",
+            "target-repo",
+            "migrate-secrets",
+            gh_cli_version="2.45.0",
+        )
+        assert "GH_PINNED_VERSION: '2.45.0'" in workflow
+        assert workflow.index("Ensure compatible gh CLI") < workflow.index(
+            "Populate Repository Secrets"
+        )
+
+    def test_generate_workflow_applies_name_map(self):
+        """Test that renamed secrets are written under their target names."""
+        workflow = generate_workflow(
+            "source-org",
+            "source-repo",
+            "target-org",
+            "target-repo",
+            "migrate-secrets",
+            env_secrets={"production": ["DB_PASSWORD"]},
+            name_map={"DB_PASSWORD": "LEGACY_DB_PASSWORD", "API_KEY": "LEGACY_API_KEY"},
+        )
+        assert "TARGET_SECRET_NAME: 'LEGACY_DB_PASSWORD'" in workflow
+        assert '"API_KEY": "LEGACY_API_KEY"' in workflow
+        # Values are still read from the source secret name
+        assert "${{ secrets.DB_PASSWORD }}" in workflow
+
+    def test_generate_org_secret_steps_with_name_map(self):
+        """Test org secret steps honour the name map."""
+        steps = generate_org_secret_steps(["TOKEN"], "target-org", {"TOKEN": "OLD_TOKEN"})
+        assert "TARGET_SECRET_NAME: 'OLD_TOKEN'" in steps
+        assert 'gh secret set "$TARGET_SECRET_NAME"' in steps
+
+
+class TestWorkflowRoutingAndRunners:
+    """Test environment routing, skipped secrets and runner labels."""
+
+    def test_environment_routing(self):
+        """Test that environment secrets are written to the routed environment."""
+        steps = generate_environment_secret_steps(
+            {"prod": ["DB"]}, "so", "sr", "to", "tr", environment_map={"prod": "production"}
+        )
+        assert "ENVIRONMENT: 'production'" in steps
+        assert "Migrate prod - DB" in steps
+
+    def test_runner_labels(self):
+        """Test custom runs-on labels."""
+        workflow = generate_workflow("a", "b", "c", "d", "m", runner_labels=["self-hosted", "linux"])
+        assert 'runs-on: ["self-hosted", "linux"]' in workflow
+        assert "runs-on: ubuntu-latest" in generate_workflow("a", "b", "c", "d", "m")
+
+    def test_skip_secrets(self):
+        """Test that conflicting secrets are passed to the repository step."""
+        workflow = generate_workflow("a", "b", "c", "d", "m", skip_secrets=["B", "A"])
+        assert """SKIP_SECRETS: '["A", "B"]'""" in workflow
+        assert "already exists on target" in workflow
+
+    def test_fan_out_targets(self):
+        """Test that each fan-out target gets its own steps, skips and environment secrets."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, skip_secrets=["A"],
+            extra_targets=[FanOutTarget("e", skip_secrets=["B"])]
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        by_name = {step["name"]: step for step in steps}
+        assert by_name["Populate Repository Secrets (c/d)"]["id"] == "migrate"
+        assert by_name["Populate Repository Secrets (c/e)"]["id"] == "migrate-2"
+        assert by_name["Populate Repository Secrets (c/e)"]["env"]["SKIP_SECRETS"] == '["B"]'
+        assert "Migrate prod - DB (d)" in by_name
+        assert not any(name.endswith("(e)") and "prod" in name for name in by_name)
+
+    def test_promoted_secrets(self):
+        """Test that promoted secrets get organization secret steps scoped to the targets."""
+        scopes = {"NPM": OrgSecretScope("selected", ["d", "e"])}
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", skip_secrets=["NPM"], promoted_secrets=["NPM"],
+            promoted_scopes=scopes, extra_targets=[FanOutTarget("e", skip_secrets=["NPM"])]
+        )
+        assert lint_workflow(text) == []
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        by_name = {step["name"]: step for step in steps}
+        assert by_name["Populate Repository Secrets (c/e)"]["env"]["SKIP_SECRETS"] == '["NPM"]'
+        promote = by_name["Migrate Org Secret - NPM"]["env"]
+        assert (promote["TARGET_ORG"], promote["VISIBILITY"]) == ("c", "selected")
+        assert promote["SELECTED_REPOS"] == "d,e"
+        assert promote["SECRET_VALUE"] == "${{ secrets.NPM }}"
+
+    def test_promoted_secrets_in_script_plan(self):
+        """Test that the github-script plan carries promoted secrets next to the targets."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", skip_secrets=["NPM"], engine="github-script",
+            promoted_secrets=["NPM"], promoted_scopes={"NPM": OrgSecretScope("selected", ["d"])}
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        plan = json.loads(steps[1]["env"]["MIGRATION_PLAN"])
+        assert plan["targets"][0]["skip"] == ["NPM"]
+        assert plan["org_secrets"] == [
+            {"name": "NPM", "visibility": "selected", "repositories": ["d"]}
+        ]
+
+    def test_gh_hosts(self):
+        """Test that steps point gh at the host of the token they use."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]},
+            source_host="ghes.example.com", target_host="github.com"
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        by_name = {step["name"]: step for step in steps}
+        assert by_name["Cleanup (Always)"]["env"]["GH_HOST"] == "ghes.example.com"
+        assert "GH_HOST" not in by_name["Migrate prod - DB"]["env"]
+        assert "GH_HOST" not in generate_workflow("a", "b", "c", "d", "m")
+        org = generate_workflow(
+            "a", "b", "c", "d", "m", org_secrets=["X"], target_host="acme.ghe.com"
+        )
+        assert "GH_HOST: 'acme.ghe.com'" in org
+
+    def test_values_passed_to_gh_in_plaintext(self):
+        """Test that gh secret set gets the plain secret value and does the encryption itself."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        env_step = {step["name"]: step for step in steps}["Migrate prod - DB"]
+        assert env_step["env"]["SECRET_VALUE"] == "${{ secrets.DB }}"
+        assert '--body "$SECRET_VALUE"' in env_step["run"]
+        for workflow in (text, generate_workflow("a", "b", "c", "d", "m", runner_os="windows")):
+            assert "tweetnacl" not in workflow
+            assert "encrypt" not in workflow.lower()
+
+    def test_target_app_token(self):
+        """Test that a GitHub App token minted in the workflow replaces the stored target PAT."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, target_app_id="42",
+            extra_targets=[FanOutTarget("e")]
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        by_name = {step["name"]: step for step in steps}
+        mint = by_name["Mint target token"]
+        assert steps.index(mint) == 1
+        assert mint["env"]["APP_ID"] == "42"
+        assert mint["env"]["INSTALLATION_PATH"] == "repos/c/d/installation"
+        assert mint["env"]["TOKEN_REQUEST"] == '{"repositories": ["d", "e"]}'
+        assert by_name["Migrate prod - DB (d)"]["env"]["GH_TOKEN"] == (
+            "${{ steps.target-token.outputs.token }}"
+        )
+        assert "SECRETS_MIGRATOR_TARGET_PAT" not in by_name["Cleanup (Always)"]["run"]
+        assert "secrets.SECRETS_MIGRATOR_TARGET_PAT" not in text
+        org = generate_workflow("a", "b", "c", "", "m", org_secrets=["X"], target_app_id="42")
+        assert "INSTALLATION_PATH: 'orgs/c/installation'" in org
+        assert "TOKEN_REQUEST: '{}'" in org
+
+    def test_target_app_token_on_another_host(self):
+        """Test that steps writing with the App token are pointed at the target host."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, target_app_id="42",
+            target_host="acme.ghe.com", runner_os="windows"
+        )
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        env_step = {step["name"]: step for step in steps}["Migrate prod - DB"]
+        assert env_step["env"]["GH_TOKEN"] == "${{ steps.target-token.outputs.token }}"
+        assert env_step["env"]["GH_HOST"] == "acme.ghe.com"
+        assert "secrets.SECRETS_MIGRATOR_TARGET_PAT" not in text
+
+    def test_approval_environment(self):
+        """Test that the job runs in the approval environment and cleans up its secrets."""
+        text = generate_workflow("a", "b", "c", "d", "m", approval_environment="secrets gate")
+        job = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]
+        assert job["environment"] == "secrets gate"
+        cleanup = job["steps"][-1]
+        assert cleanup["env"]["APPROVAL_ENVIRONMENT"] == "secrets gate"
+        assert (
+            'gh secret delete SECRETS_MIGRATOR_SOURCE_PAT --repo "$GITHUB_REPOSITORY" '
+            '--env "$APPROVAL_ENVIRONMENT"'
+        ) in cleanup["run"]
+        assert "environment:" not in generate_workflow("a", "b", "c", "d", "m")
+
+    def test_run_restrictions(self):
+        """Test the actor guard, concurrency group, timeout and empty token permissions."""
+        workflow = yaml.safe_load(generate_workflow(
+            "a", "b", "c", "d", "m", expected_actor="octocat", timeout_minutes=30
+        ))
+        job = workflow["jobs"]["migrate-repo-secrets"]
+        assert job["if"] == "github.actor == 'octocat'"
+        assert job["timeout-minutes"] == 30
+        assert workflow["permissions"] == {}
+        assert workflow["concurrency"] == {
+            "group": "gh-secrets-migrator", "cancel-in-progress": False
+        }
+        default = yaml.safe_load(generate_workflow("a", "b", "c", "d", "m"))
+        assert "if" not in default["jobs"]["migrate-repo-secrets"]
+        assert default["jobs"]["migrate-repo-secrets"]["timeout-minutes"] == 60
+
+    def test_windows_runner(self):
+        """Test that Windows runners get PowerShell steps printing the same markers."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, runner_os="windows"
+        )
+        job = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]
+        assert job["runs-on"] == "windows-latest"
+        assert {step["shell"] for step in job["steps"]} == {"powershell"}
+        assert "gh_$($env:GH_PINNED_VERSION)_windows_$Arch" in job["steps"][0]["run"]
+        assert '"[secrets-migrator] phase-start repository-secrets"' in text
+        assert "[secrets-migrator] secret ok environment $($env:TARGET_SECRET_NAME)" in text
+        assert text.isascii()
+
+    def test_macos_runner(self):
+        """Test that macOS runners keep the bash steps."""
+        job = yaml.safe_load(generate_workflow("a", "b", "c", "d", "m", runner_os="macos"))
+        job = job["jobs"]["migrate-repo-secrets"]
+        assert job["runs-on"] == "macos-latest"
+        assert job["steps"][0]["shell"] == "bash"
+        assert "_macOS_" in job["steps"][0]["run"]
+
+    def test_github_script_engine(self):
+        """Test that one pinned github-script step replaces the per-secret steps."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, engine="github-script",
+            extra_targets=[FanOutTarget("e")], runner_os="windows", target_app_id="42"
+        )
+        assert lint_workflow(text) == []
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        assert [step["name"] for step in steps] == [
+            "Ensure compatible gh CLI", "Mint target token", "Migrate Secrets", "Cleanup (Always)"
+        ]
+        migrate = steps[2]
+        assert migrate["uses"].startswith("actions/github-script@")
+        assert migrate["with"]["script"] == MIGRATION_SCRIPT
+        assert migrate["env"]["SOURCE_SECRETS"] == "${{ toJSON(secrets) }}"
+        assert migrate["env"]["GH_TOKEN"] == "${{ steps.target-token.outputs.token }}"
+        plan = json.loads(migrate["env"]["MIGRATION_PLAN"])
+        assert [target["repo"] for target in plan["targets"]] == ["d", "e"]
+        assert plan["targets"][0]["environment_secrets"] == [
+            {"environment": "prod", "target_environment": "prod", "name": "DB"}
+        ]
+        with pytest.raises(ValueError, match="unsupported workflow engine 'perl'"):
+            generate_workflow("a", "b", "c", "d", "m", engine="perl")
+
+    def test_action_engine(self):
+        """Test that the action engine hands the plan to the pinned secrets-migrator-action."""
+        ref = "renan-alm/secrets-migrator-action@" + "0" * 40
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["DB"]}, engine="action", action_ref=ref,
+            target_app_id="42", target_host="acme.ghe.com"
+        )
+        assert lint_workflow(text) == []
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        migrate = steps[2]
+        assert migrate["uses"] == ref
+        assert migrate["with"]["secrets"] == "${{ toJSON(secrets) }}"
+        assert migrate["with"]["token"] == "${{ steps.target-token.outputs.token }}"
+        assert migrate["with"]["gh-host"] == "acme.ghe.com"
+        plan = json.loads(migrate["with"]["plan"])
+        assert plan["targets"][0]["environment_secrets"][0]["target_environment"] == "prod"
+        assert "run" not in migrate and "script" not in migrate["with"]
+
+    def test_action_ref_checked(self):
+        """Test that the action engine needs a commit-pinned reference, and only it takes one."""
+        check_action_ref("action", "owner/action/sub@" + "a" * 40)
+        check_action_ref("shell", "")
+        with pytest.raises(ValueError, match="needs --action-ref"):
+            check_action_ref("action", "renan-alm/secrets-migrator-action@v1")
+        with pytest.raises(ValueError, match="needs --action-ref"):
+            generate_workflow("a", "b", "c", "d", "m", engine="action")
+        with pytest.raises(ValueError, match="only applies to --workflow-engine action"):
+            check_action_ref("github-script", "owner/action@" + "a" * 40)
+
+    def test_unknown_runner_os(self):
+        """Test that an unsupported runner OS is rejected."""
+        with pytest.raises(ValueError, match="unsupported runner OS 'solaris'"):
+            generate_workflow("a", "b", "c", "d", "m", runner_os="solaris")
+
+
+class TestWorkflowDelivery:
+    """Test push and pull-request delivery triggers."""
+
+    def test_push_trigger_is_default(self):
+        """Test that the migration branch push triggers the workflow by default."""
+        workflow = yaml.safe_load(generate_workflow("s", "r", "t", "r", "migrate-secrets"))
+        assert workflow[True] == {"push": {"branches": ["migrate-secrets"]}}
+
+    def test_pull_request_trigger_runs_on_merge(self):
+        """Test that pull-request delivery only runs once merged into the base branch."""
+        text = generate_workflow(
+            "s", "r", "t", "r", "migrate-secrets", delivery="pull-request",
+            base_branch="main", workflow_path=".github/workflows/migrate-secrets.yml"
+        )
+        workflow = yaml.safe_load(text)
+        assert workflow[True] == {
+            "push": {"branches": ["main"], "paths": [".github/workflows/migrate-secrets.yml"]}
+        }
+        assert "remove it in a follow-up pull request" in text
+
+    def test_invalid_delivery(self):
+        """Test that unknown modes and missing base branches are rejected."""
+        with pytest.raises(ValueError):
+            workflow_trigger("b", "merge-queue")
+        with pytest.raises(ValueError):
+            workflow_trigger("b", "pull-request")
+
+
+# Names and values that would run commands or end strings if pasted into a script
+HOSTILE_ENVIRONMENT = "qa 'blue' \"$(touch env-pwned)\" `touch env-tick` \u2019; exit 0\nnext"
+HOSTILE_VALUE = "it's \"$(touch value-pwned)\" `touch value-tick` $HOME\nline 2\\"
+HOSTILE_BRANCH = "migrate/$(touch branch-pwned)'`x`\"$HOME"
+
+
+def _run_bash_step(step, tmp_path, secrets, **environ):
+    """Run a generated bash step as the runner would, with a fake gh recording its argv."""
+    bin_dir = tmp_path / "bin"
+    bin_dir.mkdir(exist_ok=True)
+    fake_gh = bin_dir / "gh"
+    fake_gh.write_text(
+        '#!/bin/sh\nprintf \'%s\\0\' "$@" >> "$GH_CALLS"\nprintf \'\\n\' >> "$GH_CALLS"\n'
+    )
+    fake_gh.chmod(0o755)
+    work = tmp_path / "work"
+    work.mkdir(exist_ok=True)
+    calls_file = tmp_path / "calls"
+    env = {"PATH": f"{bin_dir}{os.pathsep}{os.environ['PATH']}", "GH_CALLS": str(calls_file)}
+    for name, value in step["env"].items():
+        # The runner substitutes expressions in env: values before the script starts
+        for secret, secret_value in secrets.items():
+            value = value.replace(f"${{{{ secrets.{secret} }}}}", secret_value)
+            value = value.replace(f"${{{{ toJSON(secrets.{secret}) }}}}", json.dumps(secret_value))
+        env[name] = value
+    env.update(environ)
+    result = subprocess.run(  # nosec B603 - fixed argv
+        ["bash", "--noprofile", "--norc", "-eo", "pipefail", "-c", step["run"]],
+        cwd=work, env=env, capture_output=True, text=True, timeout=30,
+    )
+    calls = calls_file.read_text() if calls_file.exists() else ""
+    argvs = [call.split("\0") for call in calls.split("\0\n") if call]
+    return result, argvs, sorted(os.listdir(work))
+
+
+class TestHostileNames:
+    """Names and values with quotes, backticks and newlines only reach scripts through env:."""
+
+    def _workflows(self):
+        common = dict(
+            env_secrets={HOSTILE_ENVIRONMENT: ["DB"]},
+            environment_map={HOSTILE_ENVIRONMENT: HOSTILE_ENVIRONMENT + " target"},
+            delivery="pull-request", base_branch="main'`x`",
+            workflow_path=".github/workflows/$(x).yml",
+            approval_environment=HOSTILE_ENVIRONMENT,
+        )
+        return {
+            runner_os: generate_workflow(
+                "a", "b", "c", "d", HOSTILE_BRANCH, runner_os=runner_os, **common
+            )
+            for runner_os in ("ubuntu", "windows")
+        }
+
+    def test_no_expressions_in_scripts(self):
+        """Test that no run script holds an expression and env values read back exactly."""
+        for runner_os, text in self._workflows().items():
+            assert lint_workflow(text) == [], runner_os
+            job = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]
+            assert job["environment"] == HOSTILE_ENVIRONMENT
+            steps = {step["name"]: step for step in job["steps"]}
+            for step in steps.values():
+                assert "${{" not in step["run"]
+            migrate = steps[f"Migrate {HOSTILE_ENVIRONMENT} - DB"]
+            assert migrate["env"]["ENVIRONMENT"] == HOSTILE_ENVIRONMENT + " target"
+            cleanup = steps["Cleanup (Always)"]
+            assert cleanup["env"]["MIGRATION_BRANCH"] == HOSTILE_BRANCH
+            assert cleanup["env"]["BASE_BRANCH"] == "main'`x`"
+            assert HOSTILE_BRANCH not in cleanup["run"]
+
+    def test_invalid_secret_name_rejected(self):
+        """Test that a name that could end the secrets expression early is refused."""
+        with pytest.raises(ValueError, match="invalid secret name"):
+            generate_environment_secret_steps(
+                {"prod": ["A }} ${{ github.token"]}, "a", "b", "c", "d"
+            )
+
+    @pytest.mark.skipif(not shutil.which("bash"), reason="bash not installed")
+    def test_bash_steps_run_nothing_from_names(self, tmp_path):
+        """Test that hostile names and values reach gh verbatim and execute nothing."""
+        job = yaml.safe_load(self._workflows()["ubuntu"])["jobs"]["migrate-repo-secrets"]
+        steps = {step["name"]: step for step in job["steps"]}
+        result, calls, created = _run_bash_step(
+            steps[f"Migrate {HOSTILE_ENVIRONMENT} - DB"], tmp_path, {"DB": HOSTILE_VALUE}
+        )
+        assert result.returncode == 0, result.stderr
+        assert calls == [[
+            "secret", "set", "DB", "--body", HOSTILE_VALUE, "--repo", "c/d",
+            "--env", HOSTILE_ENVIRONMENT + " target",
+        ]]
+        result, calls, created = _run_bash_step(
+            steps["Cleanup (Always)"], tmp_path, {}, GITHUB_REPOSITORY="a/b"
+        )
+        assert result.returncode == 0, result.stderr
+        assert ["api", "--method", "DELETE", f"repos/a/b/git/refs/heads/{HOSTILE_BRANCH}"] in calls
+        assert created == []
+
+
+class TestSecretsPayloadChunks:
+    """Test reading secrets in chunks when toJSON(secrets) would not fit in one env: value."""
+
+    NAMES = [f"SECRET_{index}" for index in range(40)]
+
+    def test_small_payload_not_chunked(self):
+        """Test that a payload expected to fit keeps reading every secret exposed."""
+        assert chunk_secret_names(self.NAMES) == []
+        text = generate_workflow("a", "b", "c", "d", "m", repo_secret_names=self.NAMES)
+        assert text == generate_workflow("a", "b", "c", "d", "m")
+
+    def test_chunks_fit_the_windows_limit(self):
+        """Test that Windows' smaller variable limit splits the names into ordered chunks."""
+        chunks = chunk_secret_names(self.NAMES, "windows")
+        assert len(chunks) > 1
+        assert [name for chunk in chunks for name in chunk] == self.NAMES
+
+    def test_shell_steps_per_chunk(self):
+        """Test that each chunk gets its own repository step reading only its secrets."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", runner_os="windows", repo_secret_names=self.NAMES
+        )
+        assert lint_workflow(text) == []
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        parts = [step for step in steps if step["name"].startswith("Populate Repository Secrets")]
+        assert [step["id"] for step in parts] == ["migrate", "migrate-part-2", "migrate-part-3"]
+        assert parts[1]["name"] == "Populate Repository Secrets (part 2/3)"
+        assert "toJSON(secrets)" not in text
+        assert "${{ toJSON(secrets.SECRET_39) }}" in parts[2]["env"]["REPO_SECRETS"]
+
+    def test_script_steps_per_chunk(self):
+        """Test that each chunk's plan only holds the environment secrets of its part."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["SECRET_0", "SECRET_39"]}, engine="github-script",
+            runner_os="windows", repo_secret_names=self.NAMES
+        )
+        assert lint_workflow(text) == []
+        steps = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+        parts = [step for step in steps if step["name"].startswith("Migrate Secrets")]
+        plans = [json.loads(step["env"]["MIGRATION_PLAN"]) for step in parts]
+        environment_secrets = [plan["targets"][0]["environment_secrets"] for plan in plans]
+        assert [[item["name"] for item in items] for items in environment_secrets] == [
+            ["SECRET_0"], [], ["SECRET_39"]
+        ]
+        assert plans[2]["targets"][0]["title"] == "Repository secrets (part 3/3)"
+
+    @pytest.mark.skipif(not shutil.which("jq"), reason="jq not installed")
+    def test_chunk_step_runs(self, tmp_path):
+        """Test that a chunk's JSON feeds the unchanged bash script."""
+        secrets = {f"S{index}": "x" for index in range(70)}
+        secrets.update({"A": "it's \"a\" $HOME", "B": "b"})
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", runner_os="macos", skip_secrets=["B"],
+            repo_secret_names=list(secrets)
+        )
+        step = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"][-2]
+        assert step["name"] == "Populate Repository Secrets (part 2/2)"
+        result, calls, _ = _run_bash_step(step, tmp_path, secrets)
+        assert result.returncode == 0, result.stderr
+        assert ["secret", "set", "A", "--body", "it's \"a\" $HOME", "--repo", "c/d"] in calls
+        assert "Skipping B (already exists on target)" in result.stdout
+        # Every secret of the chunk but the skipped one
+        assert len(calls) == step["env"]["REPO_SECRETS"].count("toJSON(secrets.") - 1
+
+
+class TestValueRewrites:
+    """Value rules resolved by the CLI are applied by every engine before writing."""
+
+    REWRITES = {
+        "API_URL": [
+            {"from": "ghes.corp.example", "to": "github.com", "prefix": False},
+            {"from": "https://", "to": "git+https://", "prefix": True},
+        ]
+    }
+
+    def test_only_rewritten_secrets_carry_rules(self):
+        """Test that steps of secrets without rules are unchanged."""
+        plain = generate_workflow("a", "b", "c", "d", "m", {"prod": ["API_URL", "DB"]})
+        assert "VALUE_REWRITES" not in plain
+        for runner_os in ("ubuntu", "windows"):
+            text = generate_workflow(
+                "a", "b", "c", "d", "m", {"prod": ["API_URL", "DB"]}, runner_os=runner_os,
+                value_rewrites=self.REWRITES
+            )
+            assert lint_workflow(text) == []
+            job = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]
+            steps = {step["name"]: step for step in job["steps"]}
+            for name in ("Populate Repository Secrets", "Migrate prod - API_URL"):
+                assert json.loads(steps[name]["env"]["VALUE_REWRITES"]) == self.REWRITES
+            assert "VALUE_REWRITES" not in steps["Migrate prod - DB"]["env"]
+            assert "Edit-SecretValue" not in steps["Migrate prod - DB"]["run"]
+
+    def test_plan_carries_rewrites(self):
+        """Test that the github-script engine's plan holds the resolved rules."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", engine="github-script", value_rewrites=self.REWRITES
+        )
+        step = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"][1]
+        assert json.loads(step["env"]["MIGRATION_PLAN"])["value_rewrites"] == self.REWRITES
+
+    @pytest.mark.skipif(not shutil.which("jq"), reason="jq not installed")
+    def test_environment_step_rewrites_value(self, tmp_path):
+        """Test that the bash step writes the rewritten value, keeping its line ending."""
+        text = generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["API_URL"]}, value_rewrites=self.REWRITES
+        )
+        step = yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"][-2]
+        assert step["name"] == "Migrate prod - API_URL"
+        value = "https://ghes.corp.example/api GHES.corp.example $HOME\n"
+        result, calls, _ = _run_bash_step(step, tmp_path, {"API_URL": value})
+        assert result.returncode == 0, result.stderr
+        assert "Rewrote the value of API_URL (value rules)" in result.stdout
+        rewritten = "git+https://github.com/api GHES.corp.example $HOME\n"
+        assert calls == [
+            ["secret", "set", "API_URL", "--body", rewritten, "--repo", "c/d", "--env", "prod"]
+        ]
+
+
+class TestDestinationPlugins:
+    """Destination plugins receive the migrated secrets after the target steps."""
+
+    PLUGIN = DestinationPlugin(
+        "vault", ["fake-plugin", "--store"], {"project": "payments"},
+        {"VAULT_TOKEN": "VAULT_MIGRATION_TOKEN"}
+    )
+
+    def _workflow(self, **kwargs):
+        return generate_workflow(
+            "a", "b", "c", "d", "m", {"prod": ["API_URL"]}, repo_secret_names=["DB", "OLD"],
+            skip_secrets=["OLD"], name_map={"DB": "DATABASE"}, destinations=[self.PLUGIN], **kwargs
+        )
+
+    def _steps(self, text):
+        return yaml.safe_load(text)["jobs"]["migrate-repo-secrets"]["steps"]
+
+    def test_step_before_cleanup(self):
+        """Test that the delivery step follows the migration and precedes cleanup."""
+        text = self._workflow()
+        assert lint_workflow(text) == []
+        steps = self._steps(text)
+        names = [step["name"] for step in steps]
+        assert names[-3:] == [
+            "Migrate prod - API_URL", "Deliver to destination plugin vault", "Cleanup (Always)"
+        ]
+        env = steps[-2]["env"]
+        assert json.loads(env["PLUGIN_COMMAND"]) == ["fake-plugin", "--store"]
+        assert env["VAULT_TOKEN"] == "${{ secrets.VAULT_MIGRATION_TOKEN }}"
+        assert [(item["source_name"], item["name"], item["environment"])
+                for item in json.loads(env["PLUGIN_ITEMS"])] == [
+            ("DB", "DATABASE", ""), ("API_URL", "API_URL", "prod")
+        ]
+        assert "OLD" not in env["PLUGIN_SECRETS"]
+
+    def test_windows_runner_rejected(self):
+        """Test that plugins need a bash runner."""
+        with pytest.raises(ValueError, match="bash runner"):
+            self._workflow(runner_os="windows")
+
+    def _deliver(self, tmp_path, results):
+        bin_dir = tmp_path / "bin"
+        bin_dir.mkdir()
+        plugin = bin_dir / "fake-plugin"
+        plugin.write_text('#!/bin/sh\ncat > "$REQUEST_FILE"\necho "$RESPONSE"\n')
+        plugin.chmod(0o755)
+        step = self._steps(self._workflow(value_rewrites={
+            "API_URL": [{"from": "ghes.corp.example", "to": "github.com", "prefix": False}]
+        }))[-2]
+        request_file = tmp_path / "request.json"
+        response = json.dumps({"protocol": 1, "results": results})
+        result, _, _ = _run_bash_step(
+            step, tmp_path, {"DB": "s3cret'\n", "API_URL": "https://ghes.corp.example"},
+            REQUEST_FILE=str(request_file), RESPONSE=response,
+        )
+        return result, json.loads(request_file.read_text())
+
+    @pytest.mark.skipif(not shutil.which("jq"), reason="jq not installed")
+    def test_delivery_request(self, tmp_path):
+        """Test that the plugin reads one put request with the rewritten values."""
+        result, request = self._deliver(tmp_path, [{"id": 1, "ok": True}, {"id": 2, "ok": True}])
+        assert result.returncode == 0, result.stderr
+        assert request["operation"] == "put"
+        assert request["destination"] == {"project": "payments"}
+        assert [(item["name"], item["value"]) for item in request["secrets"]] == [
+            ("DATABASE", "s3cret'\n"), ("API_URL", "https://github.com")
+        ]
+        assert "[secrets-migrator] phase-end destination-vault ok" in result.stdout
+
+    @pytest.mark.skipif(not shutil.which("jq"), reason="jq not installed")
+    def test_unconfirmed_items_fail(self, tmp_path):
+        """Test that the step fails unless the plugin confirms every secret."""
+        result, _ = self._deliver(tmp_path, [{"id": 2, "ok": False, "error": "denied"}])
+        assert result.returncode == 1
+        assert "did not store 'DATABASE': no result" in result.stdout
+        assert "did not store 'prod/API_URL': denied" in result.stdout
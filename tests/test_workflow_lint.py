@@ -0,0 +1,137 @@
+"""Tests for the pre-push lint of generated workflows."""
+import shutil
+import pytest
+from src.core.scopes import OrgSecretScope
+from src.core.workflow_generator import generate_workflow
+from src.core.workflow_lint import lint_workflow
+
+_STEP_WORKFLOW = """name: test
+on: push
+jobs:
+  migrate:
+    runs-on: ubuntu-latest
+    steps:
+{steps}
+"""
+
+
+def _workflow(steps: str) -> str:
+    return _STEP_WORKFLOW.format(steps=steps)
+
+
+class TestGeneratedWorkflowsLintClean:
+    """The generator's own output must always pass the lint."""
+
+    def test_repository_workflow(self):
+        """Test a repository workflow with unusual environment names and renames."""
+        workflow = generate_workflow(
+            "src-org", "src-repo", "dst-org", "dst-repo", "migrate-secrets",
+            {"prod": ["DB_PASSWORD"], "it's staging": ["API_KEY"], 'qa "blue"': ["TOKEN"]},
+            name_map={"DB_PASSWORD": "PROD_DB_PASSWORD"},
+            environment_map={"prod": "production"},
+        )
+        assert lint_workflow(workflow) == []
+
+    def test_org_workflow(self):
+        """Test an organization workflow with selected-repository scopes."""
+        workflow = generate_workflow(
+            "src-org", "src-repo", "dst-org", "dst-repo", "migrate-org-secrets",
+            org_secrets=["A", "_B1"],
+            org_secret_scopes={"A": OrgSecretScope("selected", ["web", "api"])},
+        )
+        assert lint_workflow(workflow) == []
+
+    def test_pull_request_delivery(self):
+        """Test the workflow triggered by merging a pull request."""
+        workflow = generate_workflow(
+            "src-org", "src-repo", "dst-org", "dst-repo", "automation/migrate-secrets",
+            delivery="pull-request", base_branch="main",
+            workflow_path=".github/workflows/migrate-secrets.yml",
+        )
+        assert lint_workflow(workflow) == []
+
+    def test_windows_workflow(self):
+        """Test that the PowerShell steps of Windows runners are not fed to bash."""
+        workflow = generate_workflow(
+            "src-org", "src-repo", "dst-org", "dst-repo", "migrate-secrets",
+            {"prod": ["DB_PASSWORD"]}, runner_os="windows",
+        )
+        assert lint_workflow(workflow) == []
+
+
+class TestLintProblems:
+    """Test cases for detected problems."""
+
+    def test_invalid_yaml(self):
+        """Test that unparsable YAML is reported."""
+        assert lint_workflow("jobs: [unclosed")[0].startswith("invalid YAML")
+
+    def test_structure(self):
+        """Test missing trigger, runs-on and run/uses."""
+        problems = lint_workflow("jobs:\n  a:\n    steps:\n      - name: nothing\n")
+        assert "missing 'on' trigger" in problems
+        assert "jobs.a: missing 'runs-on'" in problems
+        assert "jobs.a.steps[0]: needs exactly one of 'run' or 'uses'" in problems
+
+    def test_expressions(self):
+        """Test unknown contexts, unterminated and malformed expressions."""
+        problems = lint_workflow(_workflow(
+            "      - run: echo ${{ secret.TOKEN }}\n"
+            "      - run: echo ${{ secrets.TOKEN\n"
+            "      - run: echo ${{ format('{0}', github.ref }}\n"
+        ))
+        assert any("unknown context 'secret'" in problem for problem in problems)
+        assert any("unterminated" in problem for problem in problems)
+        assert any("malformed expression" in problem for problem in problems)
+
+    def test_expression_in_run_script(self):
+        """Test that expressions must reach run scripts through env:."""
+        problems = lint_workflow(_workflow(
+            "      - run: echo \"${{ github.head_ref }}\"\n"
+            "      - env:\n"
+            "          REF: ${{ github.head_ref }}\n"
+            "        run: echo \"$REF\"\n"
+            f"      - uses: actions/github-script@{'0' * 40}\n"
+            "        with:\n"
+            "          script: console.log('${{ github.head_ref }}')\n"
+        ))
+        assert problems == [
+            "jobs.migrate.steps[0]: expression in a run script; pass it through env: instead",
+            "jobs.migrate.steps[2]: expression in a github-script script; "
+            "pass it through env: instead",
+        ]
+
+    def test_pinning(self):
+        """Test that actions must be pinned to a commit SHA and registries left alone."""
+        sha = "8e5e7e5ab8b370d6c329ec480221332ada57f0ab"
+        problems = lint_workflow(_workflow(
+            "      - uses: actions/checkout@v4\n"
+            f"      - uses: actions/checkout@{sha}\n"
+            "      - uses: ./.github/actions/local\n"
+            "      - run: npm install tweetnacl\n"
+        ))
+        assert problems == [
+            "jobs.migrate.steps[0]: 'actions/checkout@v4' is not pinned to a commit SHA",
+            "jobs.migrate.steps[3]: installs packages at run time ('npm install')",
+        ]
+
+    def test_valid_expressions(self):
+        """Test that literals, functions and index access are accepted."""
+        workflow = _workflow(
+            "      - if: ${{ always() && github.ref != 'refs/heads/it''s' }}\n"
+            "        env:\n"
+            "          VALUE: ${{ secrets['MY_SECRET'] }}\n"
+            "        run: echo \"$VALUE\"\n"
+        )
+        assert lint_workflow(workflow, check_shell=False) == []
+
+    @pytest.mark.skipif(not shutil.which("bash"), reason="bash not installed")
+    def test_shell_syntax(self):
+        """Test that a quoting error in a run script is caught by bash -n."""
+        problems = lint_workflow(_workflow(
+            "      - name: broken\n"
+            "        run: |\n"
+            "          echo \"$TOKEN\n"
+        ))
+        assert len(problems) == 1
+        assert "(broken): shell syntax error" in problems[0]
@@ -0,0 +1,127 @@
+"""Tests for workflow phase and secret markers."""
+from src.core.workflow_generator import generate_org_secret_steps, generate_workflow
+from src.core.workflow_log import (
+    parse_phase_markers, parse_secret_markers, phase_powershell, phase_shell,
+    powershell_quoted, unconfirmed_secrets
+)
+
+
+class TestPhaseShell:
+    """Test cases for phase_shell."""
+
+    def test_opens_group_and_traps_phase_end(self):
+        """Test that a phase opens a log group and always logs its end."""
+        shell = phase_shell("cleanup", "Cleanup")
+        assert 'echo "::group::Cleanup"' in shell
+        assert 'echo "[secrets-migrator] phase-start cleanup"' in shell
+        assert "::endgroup::" in shell
+        assert "phase-end cleanup failed" in shell
+        assert shell.rstrip().endswith("EXIT")
+
+    def test_title_escaped_for_bash(self):
+        """Test that quotes and expansions in titles stay literal."""
+        shell = phase_shell("environment-secrets", 'Environment qa "$blue" - TOKEN')
+        assert 'echo "::group::Environment qa \\"\\$blue\\" - TOKEN"' in shell
+
+    def test_title_kept_on_one_line(self):
+        """Test that line breaks in titles cannot end the script line early."""
+        shell = phase_shell("environment-secrets", "qa\nrm -rf x")
+        assert 'echo "::group::qa rm -rf x"' in shell
+        script = phase_powershell("environment-secrets", "qa\r\nexit 0", "")
+        assert "Write-Output '::group::qa exit 0'" in script
+
+    def test_powershell_quotes(self):
+        """Test that typographic single quotes, which also end PowerShell strings, are doubled."""
+        assert powershell_quoted("it's") == "'it''s'"
+        assert powershell_quoted("it\u2019s") == "'it\u2019\u2019s'"
+
+
+class TestParsePhaseMarkers:
+    """Test cases for parse_phase_markers."""
+
+    def test_parses_boundaries_and_progress(self):
+        """Test phases, outcomes and batch progress from a timestamped log."""
+        log = "\n".join([
+            "2024-05-01T10:00:00.0000000Z [secrets-migrator] phase-start setup",
+            "2024-05-01T10:00:01.0000000Z [secrets-migrator] phase-end setup ok",
+            "2024-05-01T10:00:02.0000000Z [secrets-migrator] phase-start repository-secrets",
+            "2024-05-01T10:00:03.0000000Z Processing: DB_PASSWORD",
+            "2024-05-01T10:00:04.0000000Z [secrets-migrator] progress repository-secrets 25/300",
+        ])
+        phases = parse_phase_markers(log)
+        assert [phase.phase for phase in phases] == ["setup", "repository-secrets"]
+        assert phases[0].state == "ok"
+        assert phases[1].state == "running"
+        assert (phases[1].done, phases[1].total) == (25, 300)
+
+    def test_failure_sticks_across_steps(self):
+        """Test that a phase spanning several steps stays failed."""
+        log = "\n".join([
+            "[secrets-migrator] phase-start organization-secrets",
+            "[secrets-migrator] phase-end organization-secrets failed",
+            "[secrets-migrator] phase-start organization-secrets",
+            "[secrets-migrator] phase-end organization-secrets ok",
+        ])
+        assert parse_phase_markers(log)[0].state == "failed"
+
+
+
+class TestParseSecretMarkers:
+    """Test cases for parse_secret_markers and unconfirmed_secrets."""
+
+    LOG = "\n".join([
+        "2024-05-01T10:00:01.0000000Z [secrets-migrator] secret ok repository API_KEY dst/app",
+        "2024-05-01T10:00:02.0000000Z [secrets-migrator] secret failed repository BIG dst/app",
+        "[secrets-migrator] secret ok environment DB_PASSWORD dst/app:QA env\r",
+        "[secrets-migrator] secret ok organization NPM_TOKEN dst",
+        "[secrets-migrator] secret failed organization NPM_TOKEN dst",
+    ])
+
+    def test_outcomes(self):
+        """Test levels, locations with spaces, and that the last outcome wins."""
+        outcomes = parse_secret_markers(self.LOG)
+        assert [(o.label, o.ok) for o in outcomes] == [
+            ("dst/app/API_KEY", True),
+            ("dst/app/BIG", False),
+            ("dst/app:QA env/DB_PASSWORD", True),
+            ("dst/NPM_TOKEN", False),
+        ]
+        assert outcomes[2].key == ("environment", "dst/app:QA env", "DB_PASSWORD")
+
+    def test_unconfirmed(self):
+        """Test that expected secrets without any marker are returned."""
+        expected = [
+            ("repository", "dst/app", "API_KEY"),
+            ("repository", "dst/app", "BIG"),
+            ("repository", "dst/app", "NEVER_REACHED"),
+        ]
+        missing = unconfirmed_secrets(parse_secret_markers(self.LOG), expected)
+        assert missing == [("repository", "dst/app", "NEVER_REACHED")]
+
+class TestGeneratedMarkers:
+    """Test that generated steps carry phase markers."""
+
+    def test_repo_workflow_phases(self):
+        """Test groups and batch progress in the repository workflow."""
+        workflow = generate_workflow("a", "b", "c", "d", "migrate-secrets", {"prod": ["A", "B"]})
+        for phase in ("setup", "repository-secrets", "environment-secrets", "cleanup"):
+            assert f"phase-start {phase}" in workflow
+        assert "progress repository-secrets $DONE/$TOTAL" in workflow
+        assert "progress environment-secrets 2/2" in workflow
+        assert "::group::" in workflow
+
+    def test_org_steps_progress(self):
+        """Test per-secret progress in organization steps."""
+        steps = generate_org_secret_steps(["A", "B", "C"], "target-org")
+        assert "progress organization-secrets 3/3" in steps
+
+    def test_secret_markers(self):
+        """Test that every step reports each secret's outcome."""
+        workflow = generate_workflow("a", "b", "c", "d", "migrate-secrets", {"prod": ["A"]})
+        assert "secret ok repository $TARGET_NAME $TARGET_ORG/$TARGET_REPO" in workflow
+        assert "secret failed repository $TARGET_NAME $TARGET_ORG/$TARGET_REPO" in workflow
+        location = "$TARGET_ORG/$TARGET_REPO:$ENVIRONMENT"
+        assert f"secret ok environment $TARGET_SECRET_NAME {location}" in workflow
+        steps = generate_org_secret_steps(["A"], "target-org")
+        assert "secret ok organization $TARGET_SECRET_NAME $TARGET_ORG" in steps
+        assert steps.count("secret failed organization") == 3
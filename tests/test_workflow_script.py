@@ -0,0 +1,190 @@
+"""Tests for the runner-side migration script and its composite action, run under node."""
+import json
+import os
+import shutil
+import subprocess  # nosec B404 - runs node on the migration script
+import pytest
+import yaml
+from src.core.policy import SecretPolicy
+from src.core.preflight import SECRET_VALUE_LIMIT_BYTES
+from src.core.scopes import OrgSecretScope
+from src.core.workflow_generator import FanOutTarget, migration_plan
+from src.core.workflow_log import parse_phase_markers, parse_secret_markers
+from src.core.workflow_script import (
+    ACTION_DIR, GITHUB_SCRIPT_ACTION, MIGRATE_JS_PATH, MIGRATION_SCRIPT
+)
+
+pytestmark = pytest.mark.skipif(not shutil.which("node"), reason="node not installed")
+
+# Calls the script the way github-script does, with stand-ins for core, exec and timers
+_HARNESS = r"""
+const fs = require('fs');
+const [scriptPath, fakePath, callsPath] = process.argv.slice(2);
+const fake = JSON.parse(fs.readFileSync(fakePath, 'utf8'));
+const calls = [];
+const core = {
+  startGroup: (title) => console.log('::group::' + title),
+  endGroup: () => console.log('::endgroup::'),
+  setFailed: (message) => { console.log('::error::' + message); process.exitCode = 1; },
+};
+const exec = {
+  getExecOutput: async (command, args, options) => {
+    calls.push({ args, input: options.input ? options.input.toString('utf8') : null });
+    if (args[0] === 'api') {
+      return { exitCode: 0, stdout: fake.listed.shift() || '' };
+    }
+    return { exitCode: fake.failing.includes(args[2]) ? 1 : 0, stdout: '' };
+  },
+};
+const AsyncFunction = Object.getPrototypeOf(async function () {}).constructor;
+new AsyncFunction('core', 'exec', 'setTimeout', fs.readFileSync(scriptPath, 'utf8'))(
+  core, exec, (resolve) => resolve()
+).then(() => fs.writeFileSync(callsPath, JSON.stringify(calls)));
+"""
+
+HOSTILE_VALUE = "it's \"$(touch pwned)\" `id` ${HOME}\nline 2\\"
+
+
+def run_script(tmp_path, plan, secrets, failing=(), listed=()):
+    """Run the migration script; return (exit code, log, gh calls as (argv, stdin))."""
+    (tmp_path / "script.js").write_text(MIGRATION_SCRIPT)
+    (tmp_path / "harness.js").write_text(_HARNESS)
+    fake = {"failing": list(failing), "listed": list(listed)}
+    (tmp_path / "fake.json").write_text(json.dumps(fake))
+    env = dict(os.environ, MIGRATION_PLAN=json.dumps(plan), SOURCE_SECRETS=json.dumps(secrets))
+    result = subprocess.run(  # nosec B603 - fixed argv
+        ["node", str(tmp_path / "harness.js"), str(tmp_path / "script.js"),
+         str(tmp_path / "fake.json"), str(tmp_path / "calls.json")],
+        env=env, cwd=tmp_path, capture_output=True, text=True, timeout=30,
+    )
+    assert result.stderr == ""
+    calls = json.loads((tmp_path / "calls.json").read_text())
+    return result.returncode, result.stdout, [(call["args"], call["input"]) for call in calls]
+
+
+class TestRepositoryTargets:
+    """Test cases for repository and environment secrets."""
+
+    def _plan(self, **kwargs):
+        return migration_plan(
+            "dst", [FanOutTarget("app", {"prod": ["DB"]}, ["SKIPPED"])],
+            name_map={"API": "NEW_API"}, policy=SecretPolicy(deny=["DENY_*"]),
+            environment_map={"prod": "production"}, **kwargs
+        )
+
+    def test_secrets_piped_to_gh(self, tmp_path):
+        """Test that values reach gh verbatim on stdin, filtered, renamed and scoped."""
+        secrets = {
+            "API": HOSTILE_VALUE, "DB": "db", "SKIPPED": "x", "deny_me": "x",
+            "github_token": "t", "SECRETS_MIGRATOR_TARGET_PAT": "p",
+        }
+        code, log, calls = run_script(tmp_path, self._plan(), secrets)
+        assert code == 0
+        assert calls == [
+            (["secret", "set", "NEW_API", "--repo", "dst/app"], HOSTILE_VALUE),
+            (["secret", "set", "DB", "--repo", "dst/app"], "db"),
+            (["secret", "set", "DB", "--repo", "dst/app", "--env", "production"], "db"),
+        ]
+        assert "Skipping deny_me (blocked by secret policy)" in log
+        assert "Skipping SKIPPED (already exists on target)" in log
+        assert [(phase.phase, phase.state) for phase in parse_phase_markers(log)] == [
+            ("repository-secrets", "ok"), ("environment-secrets", "ok"),
+        ]
+        assert {outcome.key for outcome in parse_secret_markers(log)} == {
+            ("repository", "dst/app", "NEW_API"), ("repository", "dst/app", "DB"),
+            ("environment", "dst/app:production", "DB"),
+        }
+        assert not os.path.exists(tmp_path / "pwned")
+
+    def test_failures_fail_the_step(self, tmp_path):
+        """Test that rejected, oversized and missing secrets are reported and fail the step."""
+        secrets = {"API": "x", "BIG": "x" * (SECRET_VALUE_LIMIT_BYTES + 1)}
+        code, log, calls = run_script(
+            tmp_path, self._plan(), secrets, failing=["NEW_API"]
+        )
+        assert code == 1
+        assert "::error::MIGRATION FAILED - Some secrets could not be created" in log
+        assert [args[2] for args, _ in calls] == ["NEW_API"]
+        outcomes = {outcome.key: outcome.ok for outcome in parse_secret_markers(log)}
+        assert outcomes == {
+            ("repository", "dst/app", "NEW_API"): False,
+            ("repository", "dst/app", "BIG"): False,
+            ("environment", "dst/app:production", "DB"): False,
+        }
+        assert "ERROR: DB is not available to the workflow" in log
+
+    def test_environment_secrets_only(self, tmp_path):
+        """Test that repository secrets are left alone when only environments migrate."""
+        code, _, calls = run_script(tmp_path, self._plan(repo_secrets=False), {"DB": "db"})
+        assert code == 0
+        assert calls == [
+            (["secret", "set", "DB", "--repo", "dst/app", "--env", "production"], "db")
+        ]
+
+    def test_values_rewritten(self, tmp_path):
+        """Test that value rules rewrite the values of the secrets they apply to."""
+        rewrites = {"DB": [
+            {"from": "ghes.corp.example", "to": "github.com", "prefix": False},
+            {"from": "postgres://", "to": "postgresql://", "prefix": True},
+        ]}
+        plan = self._plan(repo_secrets=False, value_rewrites=rewrites)
+        value = "postgres://ghes.corp.example/db?mirror=GHES.corp.example"
+        code, log, calls = run_script(tmp_path, plan, {"DB": value})
+        assert code == 0
+        assert "Rewrote the value of DB (value rules)" in log
+        assert calls[0][1] == "postgresql://github.com/db?mirror=GHES.corp.example"
+
+
+class TestOrganizationSecrets:
+    """Test cases for organization secrets."""
+
+    def test_selected_scope_reapplied_until_it_matches(self, tmp_path):
+        """Test that the selected repositories are read back and re-applied on a mismatch."""
+        plan = migration_plan(
+            "dst", [], org_secrets=["TOKEN"],
+            org_secret_scopes={"TOKEN": OrgSecretScope("selected", ["web", "api"])},
+        )
+        code, log, calls = run_script(
+            tmp_path, plan, {"TOKEN": "t"}, listed=["web\n", "api\nweb\n"]
+        )
+        assert code == 0
+        set_call = (
+            ["secret", "set", "TOKEN", "--org", "dst", "--visibility", "selected",
+             "--repos", "api,web"], "t"
+        )
+        listing = ["api", "--paginate", "orgs/dst/actions/secrets/TOKEN/repositories",
+                   "--jq", ".repositories[].name"]
+        assert calls == [set_call, (listing, None), set_call, (listing, None)]
+        assert "missing [api], unexpected []" in log
+        assert [outcome.ok for outcome in parse_secret_markers(log)] == [True]
+
+
+class TestCompositeAction:
+    """Test cases for the composite action published from action/."""
+
+    def test_action_runs_migrate_js(self):
+        """Test that the action passes its inputs to migrate.js through a pinned github-script."""
+        with open(os.path.join(ACTION_DIR, "action.yml"), encoding="utf-8") as handle:
+            action = yaml.safe_load(handle)
+        assert set(action["inputs"]) == {"plan", "secrets", "token", "gh-host"}
+        assert action["runs"]["using"] == "composite"
+        (step,) = action["runs"]["steps"]
+        assert step["uses"] == GITHUB_SCRIPT_ACTION
+        assert step["env"]["MIGRATION_PLAN"] == "${{ inputs.plan }}"
+        assert step["env"]["SOURCE_SECRETS"] == "${{ inputs.secrets }}"
+        assert step["env"]["GH_TOKEN"] == "${{ inputs.token }}"
+        assert "require(process.env.MIGRATE_JS)" in step["with"]["script"]
+
+    def test_migrate_js_exports_the_inlined_script(self, tmp_path):
+        """Test that migrate.js exports the logic the github-script engine inlines."""
+        (tmp_path / "load.js").write_text(
+            "const migrate = require(process.argv[2]);\n"
+            "console.log(typeof migrate, migrate.length);\n"
+        )
+        result = subprocess.run(  # nosec B603 - fixed argv
+            ["node", str(tmp_path / "load.js"), MIGRATE_JS_PATH],
+            capture_output=True, text=True, timeout=30,
+        )
+        assert result.stdout.strip() == "function 1"
+        with open(MIGRATE_JS_PATH, encoding="utf-8") as handle:
+            assert MIGRATION_SCRIPT.startswith(handle.read())